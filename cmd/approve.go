@@ -30,15 +30,15 @@ var approveCmd = &cobra.Command{
 			logr.Error(err)
 		}
 		logr.Info("> FIX database sections and platforms")
-		if err := database.Fix(db, w); err != nil {
+		if err := database.Fix(db, w, false); err != nil {
 			logr.Error(err)
 		}
 		logr.Info("> FIX database groups")
-		if err := groups.Fix(db, w); err != nil {
+		if err := groups.Fix(db, w, false); err != nil {
 			logr.Error(err)
 		}
 		logr.Info("> FIX database people")
-		if err := people.Fix(db, w); err != nil {
+		if err := people.Fix(db, w, false); err != nil {
 			logr.Error(err)
 		}
 	},
@@ -6,7 +6,7 @@ import (
 
 	"github.com/Defacto2/df2/cmd/internal/arg"
 	"github.com/Defacto2/df2/pkg/assets"
-	"github.com/Defacto2/df2/pkg/database/msql"
+	"github.com/Defacto2/df2/pkg/database"
 	"github.com/Defacto2/df2/pkg/directories"
 	"github.com/spf13/cobra"
 )
@@ -26,7 +26,7 @@ database. These can include UUID named thumbnails, previews, textfile previews.`
 		if _, err := directories.Init(confg, clean.MakeDirs); err != nil {
 			logr.Fatal(err)
 		}
-		db, err := msql.Connect(confg)
+		db, err := database.Connect(confg)
 		if err != nil {
 			logr.Fatal(err)
 		}
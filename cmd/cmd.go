@@ -32,14 +32,11 @@ var (
 // ProgData is used for holding the version flag template data.
 type ProgData struct {
 	Database   string
-	Ansilove   string
-	Webp       string
 	Magick     string
 	Netpbm     string
 	PngQuant   string
-	Arj        string
-	File       string
-	Lha        string
+	Avif       string
+	JXL        string
 	UnRar      string
 	UnZip      string
 	ZipInfo    string
@@ -142,13 +139,11 @@ func ExeTmpl() string {
  │                             │                             │
  │  requirements               │   recommended               │
  │                             │                             │
- │      database  {{.Database}}  │           arj  {{.Arj}}  │
- │                             │    file magic  {{.File}}  │
- │      ansilove  {{.Ansilove}}  │         lhasa  {{.Lha}}  │
- │      webp lib  {{.Webp}}  │         unrar  {{.UnRar}}  │
+ │      database  {{.Database}}  │         unrar  {{.UnRar}}  │
  │   imagemagick  {{.Magick}}  │         unzip  {{.UnZip}}  │
  │        netpbm  {{.Netpbm}}  │       zipinfo  {{.ZipInfo}}  │
- │      pngquant  {{.PngQuant}}  │                             │
+ │      pngquant  {{.PngQuant}}  │       avifenc  {{.Avif}}  │
+ │                             │          cjxl  {{.JXL}}  │
  │                             │                             │
  ┴─────────────────────────────┴─────────────────── {{.Cmd}} ─────┴
          version  {{.Version}}
@@ -217,14 +212,11 @@ func ProgInfo(logr *zap.SugaredLogger, cfg conf.Config, version string) (string,
 	}
 	data := ProgData{
 		Database:   colorize(l["db"]),
-		Ansilove:   colorize(l["ansilove"]),
-		Webp:       colorize(l["cwebp"]),
 		Magick:     colorize(l["convert"]),
 		Netpbm:     colorize(l["pnmtopng"]),
 		PngQuant:   colorize(l["pngquant"]),
-		Arj:        colorize(l["arj"]),
-		File:       colorize(l["file"]),
-		Lha:        colorize(l["lha"]),
+		Avif:       colorize(l["avifenc"]),
+		JXL:        colorize(l["cjxl"]),
 		UnRar:      colorize(l["unrar"]),
 		UnZip:      colorize(l["unzip"]),
 		ZipInfo:    colorize(l["zipinfo"]),
@@ -264,14 +256,11 @@ func check(logr *zap.SugaredLogger, cfg conf.Config) (lookups, error) {
 	)
 	l := lookups{
 		"db":       ok,
-		"ansilove": miss,
-		"cwebp":    miss,
 		"convert":  miss,
 		"pnmtopng": miss,
 		"pngquant": miss,
-		"arj":      miss,
-		"file":     miss,
-		"lha":      miss,
+		"avifenc":  miss,
+		"cjxl":     miss,
 		"unrar":    miss,
 		"unzip":    miss,
 		"zipinfo":  miss,
@@ -0,0 +1,78 @@
+//nolint:gochecknoglobals,gochecknoinits
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/Defacto2/df2/cmd/internal/arg"
+	"github.com/Defacto2/df2/cmd/internal/run"
+	"github.com/Defacto2/df2/pkg/database"
+	"github.com/spf13/cobra"
+)
+
+var dbs arg.DB
+
+// dbCmd represents the db command.
+var dbCmd = &cobra.Command{
+	Use:     "db",
+	Short:   "Manage the versioned schema migrations of the database.",
+	GroupID: "group1",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := cmd.Usage(); err != nil {
+			logr.Fatal(err)
+		}
+		if len(args) > 0 {
+			logr.Errorf("%q subcommand for db is an %s\n", args[0], ErrCommand)
+		}
+	},
+}
+
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate up|down|status",
+	Short: "Apply, revert or list the schema migrations.",
+	Long: `Apply, revert or list the versioned schema migrations of the database.
+
+The migrations are numbered SQL files built into df2 for both the MySQL and
+Postgres engines. Applied migrations are recorded in the schema_migrations
+table, which is created when it does not exist.
+
+  up      apply all the pending migrations, or the number set by --steps
+  down    revert the last applied migration, or the number set by --steps
+  status  list the migrations and when they were applied`,
+	Example: `  df2 db migrate status
+  df2 db migrate up
+  df2 db migrate down --steps=2`,
+	ValidArgs: []string{"up", "down", "status"},
+	Args:      cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := database.Connect(confg)
+		if err != nil {
+			logr.Fatal(err)
+		}
+		defer db.Close()
+		err = run.Schema(db, os.Stdout, dbs, args...)
+		switch {
+		case errors.Is(err, run.ErrToFew):
+			if err := cmd.Usage(); err != nil {
+				logr.Fatal(err)
+			}
+		case errors.Is(err, run.ErrArg):
+			if err := cmd.Usage(); err != nil {
+				logr.Fatal(err)
+			}
+			if err := arg.Invalid(os.Stdout, "db migrate", args...); err != nil {
+				logr.Fatal(err)
+			}
+		case err != nil:
+			logr.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dbCmd)
+	dbCmd.AddCommand(dbMigrateCmd)
+	dbMigrateCmd.Flags().IntVarP(&dbs.Steps, "steps", "s", 0,
+		"number of migrations to apply or revert")
+}
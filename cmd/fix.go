@@ -3,22 +3,32 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
 	"os"
+	"strings"
 
 	"github.com/Defacto2/df2/cmd/internal/arg"
 	"github.com/Defacto2/df2/cmd/internal/run"
+	"github.com/Defacto2/df2/pkg/archive"
 	"github.com/Defacto2/df2/pkg/database"
 	"github.com/Defacto2/df2/pkg/demozoo"
 	"github.com/Defacto2/df2/pkg/groups"
 	"github.com/Defacto2/df2/pkg/images"
 	"github.com/Defacto2/df2/pkg/people"
+	"github.com/Defacto2/df2/pkg/sauce"
 	"github.com/Defacto2/df2/pkg/text"
 	"github.com/Defacto2/df2/pkg/zipcmmt"
 	"github.com/Defacto2/df2/pkg/zipcontent"
 	"github.com/spf13/cobra"
 )
 
-var zipc arg.ZipCmmt
+var (
+	arch arg.Archives
+	imgs arg.Images
+	rens arg.Rename
+	txtf arg.Text
+	zipc arg.ZipCmmt
+)
 
 // fixCmd represents the fix command.
 var fixCmd = &cobra.Command{
@@ -48,7 +58,10 @@ var fixArchivesCmd = &cobra.Command{
 	Short: "Repair archives listing empty content.",
 	Long: `Records with downloads that are packaged into archives need to have
 their file content added to the database. This command finds and repair
-records that do not have this expected context.`,
+records that do not have this expected context.
+
+The --depth flag also lists the content of archives that are packed within
+the archive, such as OUTER.ZIP/INNER.ARJ/README.NFO.`,
 	Aliases: []string{"a"},
 	GroupID: "groupU",
 	Run: func(cmd *cobra.Command, args []string) {
@@ -57,7 +70,7 @@ records that do not have this expected context.`,
 			logr.Fatal(err)
 		}
 		defer db.Close()
-		if err := zipcontent.Fix(db, os.Stdout, logr, confg, true); err != nil {
+		if err := zipcontent.Fix(db, os.Stdout, logr, confg, int(arch.Depth), true); err != nil {
 			logr.Errorf("archives fix: %s", err)
 		}
 	},
@@ -68,8 +81,9 @@ var fixDatabaseCmd = &cobra.Command{
 	Short: "Repair malformed database entries.",
 	Long: `Repair malformed records and entries in the database.
 This includes the formatting and trimming of groups, people, platforms and sections.`,
-	Aliases: []string{"d", "db"},
-	GroupID: "groupU",
+	Aliases:     []string{"d", "db"},
+	GroupID:     "groupU",
+	Annotations: dryRunnable(),
 	Run: func(cmd *cobra.Command, args []string) {
 		db, err := database.Connect(confg)
 		if err != nil {
@@ -77,30 +91,31 @@ This includes the formatting and trimming of groups, people, platforms and secti
 		}
 		defer db.Close()
 		w := os.Stdout
-		if err := database.Fix(db, w); err != nil {
+		if err := database.Fix(db, w, persist.DryRun); err != nil {
 			logr.Error(err)
 		}
-		if err := groups.Fix(db, w); err != nil {
+		if err := groups.Fix(db, w, persist.DryRun); err != nil {
 			logr.Error(err)
 		}
-		if err := people.Fix(db, w); err != nil {
+		if err := people.Fix(db, w, persist.DryRun); err != nil {
 			logr.Error(err)
 		}
 	},
 }
 
 var fixDemozooCmd = &cobra.Command{
-	Use:     "demozoo",
-	Short:   "Repair imported Demozoo data conflicts.",
-	Aliases: []string{"dz"},
-	GroupID: "groupU",
+	Use:         "demozoo",
+	Short:       "Repair imported Demozoo data conflicts.",
+	Aliases:     []string{"dz"},
+	GroupID:     "groupU",
+	Annotations: dryRunnable(),
 	Run: func(cmd *cobra.Command, args []string) {
 		db, err := database.Connect(confg)
 		if err != nil {
 			logr.Fatal(err)
 		}
 		defer db.Close()
-		if err := demozoo.Fix(db, os.Stdout); err != nil {
+		if err := demozoo.Fix(db, os.Stdout, persist.DryRun); err != nil {
 			logr.Errorf("demozoo fix: %s", err)
 		}
 	},
@@ -110,27 +125,54 @@ var fixImagesCmd = &cobra.Command{
 	Use:   "images",
 	Short: "Generate missing images.",
 	Long: `Create missing previews, thumbnails and optimised formats for records
-that are raster images.`,
+that are raster images.
+
+The optional AVIF and JPEG XL formats are also created when they are listed in
+the DF2_IMGFORMATS environment variable. The --formats flag backfills these
+formats for the existing previews and thumbnails of every record, and then
+compares their sizes to the PNG and WebP images.`,
 	Aliases: []string{"i"},
 	GroupID: "groupG",
+	Example: `  df2 fix images --formats=avif,jxl`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if err := images.ValidFormats(imgs.Formats...); err != nil {
+			logr.Fatal(err)
+		}
 		db, err := database.Connect(confg)
 		if err != nil {
 			logr.Fatal(err)
 		}
 		defer db.Close()
-		if err := images.Fix(db, os.Stdout, confg); err != nil {
+		cfg := confg
+		if len(imgs.Formats) > 0 {
+			cfg.ImageFormats = strings.Join(imgs.Formats, ",")
+		}
+		if err := images.Fix(db, os.Stdout, cfg); err != nil {
+			logr.Error(err)
+		}
+		if len(imgs.Formats) == 0 {
+			return
+		}
+		if err := images.Backfill(db, os.Stdout, cfg, imgs.Formats...); err != nil {
 			logr.Error(err)
 		}
 	},
 }
 
 var fixRenGroup = &cobra.Command{
-	Use:     "rename group replacement",
-	Short:   "Rename all instances of a group.",
-	Aliases: []string{"ren", "r"},
-	GroupID: "groupR",
-	Example: `  df2 fix rename "The Group" "New Group Name"`,
+	Use:   "rename group replacement",
+	Short: "Rename all instances of a group.",
+	Long: `Rename all instances of a group.
+
+Each rename is saved as a changeset that records the previous values of the
+renamed records. The --undo flag restores those records using the changeset id,
+but any values that were edited after the rename are reported as conflicts
+and left unchanged.`,
+	Aliases:     []string{"ren", "r"},
+	GroupID:     "groupR",
+	Annotations: dryRunnable(),
+	Example: `  df2 fix rename "The Group" "New Group Name"
+  df2 fix rename --undo 42`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// in the future this command could be adapted to use a --person flag
 		db, err := database.Connect(confg)
@@ -138,7 +180,13 @@ var fixRenGroup = &cobra.Command{
 			logr.Fatal(err)
 		}
 		defer db.Close()
-		err = run.Rename(db, os.Stdout, args...)
+		if rens.Undo > 0 {
+			if err := run.Undo(db, os.Stdout, persist.DryRun, rens.Undo); err != nil {
+				logr.Error(err)
+			}
+			return
+		}
+		err = run.Rename(db, os.Stdout, persist.DryRun, args...)
 		if errors.Is(err, run.ErrToFew) {
 			if err := cmd.Usage(); err != nil {
 				logr.Fatal(err)
@@ -151,11 +199,36 @@ var fixRenGroup = &cobra.Command{
 	},
 }
 
+var fixSauceCmd = &cobra.Command{
+	Use:   "sauce",
+	Short: "Fill empty records using SAUCE metadata.",
+	Long: `Read the SAUCE metadata of text, ANSI and music downloads and use it
+to fill any empty record titles, artist credits, groups and publish dates.
+
+SAUCE is a 128 byte record that is often appended to the end of ANSI art,
+ASCII art and tracker music files. Existing record values are never replaced.`,
+	GroupID:     "groupR",
+	Annotations: dryRunnable(),
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := database.Connect(confg)
+		if err != nil {
+			logr.Fatal(err)
+		}
+		defer db.Close()
+		if err := sauce.Fix(db, os.Stdout, confg, persist.DryRun); err != nil {
+			logr.Error(err)
+		}
+	},
+}
+
 var fixTextCmd = &cobra.Command{
 	Use:   "text",
 	Short: "Generate missing text previews.",
 	Long: `Create missing previews, thumbnails and optimised formats for records
-that are plain text files.`,
+that are plain text files.
+
+The --animate flag also creates animated PNG previews for records tagged as
+ANSI art, that play back the text at the --baud rate of a modem connection.`,
 	Aliases: []string{"t", "txt"},
 	GroupID: "groupG",
 	Run: func(cmd *cobra.Command, args []string) {
@@ -164,7 +237,28 @@ that are plain text files.`,
 			logr.Fatal(err)
 		}
 		defer db.Close()
-		if err := text.Fix(db, os.Stdout, logr, confg); err != nil {
+		if err := text.Fix(db, os.Stdout, logr, confg, txtf.Animate, txtf.Baud); err != nil {
+			logr.Error(err)
+		}
+	},
+}
+
+var fixCmmtCmd = &cobra.Command{
+	Use:   "comments",
+	Short: "Extract missing comments from zip, rar, arj, lha and zoo archives.",
+	Long: `Extract and save missing comments from zip, rar, arj, lha and zoo archives.
+
+BBS adverts and courier tags are often embedded into archive comments.
+The comments are saved to the same text files as the zip comments.`,
+	Aliases: []string{"c", "cmmt"},
+	GroupID: "groupG",
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := database.Connect(confg)
+		if err != nil {
+			logr.Fatal(err)
+		}
+		defer db.Close()
+		if err := zipcmmt.Comments(db, os.Stdout, confg, zipc.Unicode, zipc.OW, zipc.Stdout); err != nil {
 			logr.Error(err)
 		}
 	},
@@ -175,15 +269,28 @@ var fixZipCmmtCmd = &cobra.Command{
 	Short: "Extract missing comments from zip archives.",
 	Long: `Extract and save missing comments from zip archives.
 
-"A comment is optional text information that is embedded in a Zip file."`,
-	Aliases: []string{"z"},
-	GroupID: "groupG",
+"A comment is optional text information that is embedded in a Zip file."
+
+The --reencode flag instead repairs the saved archive listings that contain
+DOS era CP-437 or Shift-JIS filenames, which are decoded to UTF-8.`,
+	Aliases:     []string{"z"},
+	GroupID:     "groupG",
+	Annotations: dryRunnable(),
 	Run: func(cmd *cobra.Command, args []string) {
+		if persist.DryRun && !zipc.Reencode {
+			logr.Fatalf("%s: %s, except with the --reencode flag", ErrDryRun, cmd.CommandPath())
+		}
 		db, err := database.Connect(confg)
 		if err != nil {
 			logr.Fatal(err)
 		}
 		defer db.Close()
+		if zipc.Reencode {
+			if err := zipcontent.Reencode(db, os.Stdout, persist.DryRun); err != nil {
+				logr.Error(err)
+			}
+			return
+		}
 		if err := zipcmmt.Fix(db, os.Stdout, confg, zipc.Unicode, zipc.OW, zipc.Stdout); err != nil {
 			logr.Error(err)
 		}
@@ -196,16 +303,36 @@ func init() {
 	fixCmd.AddGroup(&cobra.Group{ID: "groupG", Title: "Create:"})
 	fixCmd.AddGroup(&cobra.Group{ID: "groupR", Title: "Update:"})
 	fixCmd.AddCommand(fixArchivesCmd)
+	fixCmd.AddCommand(fixCmmtCmd)
 	fixCmd.AddCommand(fixDatabaseCmd)
 	fixCmd.AddCommand(fixDemozooCmd)
 	fixCmd.AddCommand(fixImagesCmd)
 	fixCmd.AddCommand(fixRenGroup)
+	fixCmd.AddCommand(fixSauceCmd)
 	fixCmd.AddCommand(fixTextCmd)
 	fixCmd.AddCommand(fixZipCmmtCmd)
+	fixArchivesCmd.Flags().UintVarP(&arch.Depth, "depth", "n", 0,
+		fmt.Sprintf("list the content of nested archives up to this depth (suggested %d)", archive.NestDepth))
+	fixImagesCmd.Flags().StringSliceVarP(&imgs.Formats, "formats", "f", nil,
+		"backfill the optional image formats of the existing previews"+arg.CleanOpts(images.Formats()...))
+	fixRenGroup.Flags().Int64VarP(&rens.Undo, "undo", "u", 0,
+		"restore the records of a rename using its changeset id")
+	fixTextCmd.Flags().BoolVarP(&txtf.Animate, "animate", "a", false,
+		"also generate animated previews of ansi art")
+	fixTextCmd.Flags().IntVarP(&txtf.Baud, "baud", "b", text.Baud,
+		"modem speed in bits per second used to playback the animations")
+	fixCmmtCmd.Flags().BoolVarP(&zipc.Stdout, "print", "p", false,
+		"also print saved comments to the stdout")
+	fixCmmtCmd.Flags().BoolVarP(&zipc.Unicode, "unicode", "u", false,
+		"also convert saved comments into Unicode and print to the stdout")
+	fixCmmtCmd.Flags().BoolVarP(&zipc.OW, "overwrite", "o", false,
+		"overwrite all existing saved comments")
 	fixZipCmmtCmd.PersistentFlags().BoolVarP(&zipc.Stdout, "print", "p", false,
 		"also print saved comments to the stdout")
 	fixZipCmmtCmd.PersistentFlags().BoolVarP(&zipc.Unicode, "unicode", "u", false,
 		"also convert saved comments into Unicode and print to the stdout")
 	fixZipCmmtCmd.PersistentFlags().BoolVarP(&zipc.OW, "overwrite", "o", false,
 		"overwrite all existing saved comments")
+	fixZipCmmtCmd.PersistentFlags().BoolVarP(&zipc.Reencode, "reencode", "r", false,
+		"repair the legacy filenames of saved archive listings")
 }
@@ -0,0 +1,47 @@
+//nolint:gochecknoglobals,gochecknoinits
+package cmd
+
+import (
+	"os"
+
+	"github.com/Defacto2/df2/cmd/internal/arg"
+	"github.com/Defacto2/df2/pkg/database"
+	"github.com/spf13/cobra"
+)
+
+var hist arg.History
+
+var historyCmd = &cobra.Command{
+	Use:   "history --id=(id|uuid)",
+	Short: "List the changes made to a file record.",
+	Long: `List the changes made to a file record that are saved in the audit log.
+
+Every column changed by a df2 command is listed with its change id, the time,
+the command, and the old and new values. The change id can be used with the
+revert command to restore the old value.`,
+	GroupID: "group1",
+	Example: `  df2 history --id=1
+  df2 history --id=00000000-0000-0000-0000-000000000000`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if hist.ID == "" {
+			if err := cmd.Usage(); err != nil {
+				logr.Fatal(err)
+			}
+			return
+		}
+		db, err := database.Connect(confg)
+		if err != nil {
+			logr.Fatal(err)
+		}
+		defer db.Close()
+		if err := database.History(db, os.Stdout, hist.ID); err != nil {
+			logr.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.Flags().StringVarP(&hist.ID, "id", "i", "",
+		"id or uuid of the file record")
+}
@@ -0,0 +1,43 @@
+//nolint:gochecknoglobals,gochecknoinits
+package cmd
+
+import (
+	"os"
+
+	"github.com/Defacto2/df2/cmd/internal/arg"
+	"github.com/Defacto2/df2/pkg/database"
+	"github.com/Defacto2/df2/pkg/index"
+	"github.com/spf13/cobra"
+)
+
+var idx arg.Index
+
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Update the full-text search index of the readmes and NFOs.",
+	Long: `Update the full-text search index of the readmes, NFOs and file_id.diz texts.
+
+The index is saved to the DF2_INDEX directory and is updated incrementally,
+only the records with new or changed texts are read. The texts are decoded
+from their legacy character sets, so the index can be searched using the
+search command to find releases by their couriers, BBS adverts or crack notes.`,
+	GroupID: "group2",
+	Example: `  df2 index
+  df2 index --rebuild`,
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := database.Connect(confg)
+		if err != nil {
+			logr.Fatal(err)
+		}
+		defer db.Close()
+		if err := index.Update(db, os.Stdout, confg, idx.Rebuild); err != nil {
+			logr.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(indexCmd)
+	indexCmd.Flags().BoolVarP(&idx.Rebuild, "rebuild", "r", false,
+		"discard the existing index and index every record")
+}
@@ -30,6 +30,7 @@ func Targets() []string {
 // Persistent global flags.
 type Persistent struct {
 	Panic   bool // Enable panic errors to help debug.
+	DryRun  bool // DryRun prints the database changes and then rolls them back.
 	ASCII   bool // Ascii is placeholder for Cobra to store the PersistentFlag value*
 	Quiet   bool // Quiet is placeholder for Cobra to store the PersistentFlag value*
 	Version bool // Version is placeholder for Cobra to store the PersistentFlag value*
@@ -49,6 +50,11 @@ type Approve struct {
 	Verbose bool // Verbose display the records that are being approved.
 }
 
+// Archives repair flags.
+type Archives struct {
+	Depth uint // Depth of the nested archives to list, zero only lists the top level.
+}
+
 // Clean orphan file flags.
 type Clean struct {
 	Delete   bool   // Delete erase the orphan files.
@@ -57,6 +63,11 @@ type Clean struct {
 	Target   string // Target is the type of file to clean.
 }
 
+// DB schema migration flags.
+type DB struct {
+	Steps int // Steps is the number of migrations to apply or revert.
+}
+
 // Demozoo synchronization flags.
 type Demozoo struct {
 	All       bool     // All scans all demozoo records.
@@ -85,12 +96,37 @@ type Group struct {
 	Format   string // Format the output.
 }
 
+// History flags.
+type History struct {
+	ID string // ID auto-generated id or a uuid of the file record.
+}
+
 // Import flags.
 type Import struct {
 	Insert bool // Insert the found text files metadata into the database.
 	Limit  uint // Limit the number of found text files to import.
 }
 
+// Images flags.
+type Images struct {
+	Formats []string // Formats are the optional image formats to backfill.
+}
+
+// Index flags.
+type Index struct {
+	Rebuild bool // Rebuild discards the existing search index.
+}
+
+// Migrate flags.
+type Migrate struct {
+	Batch  int      // Batch is the number of rows copied in each transaction.
+	Tables []string // Tables to copy.
+	Host   string   // Host name of the Postgres server.
+	Port   uint     // Port number of the Postgres server.
+	Name   string   // Name of the Postgres database.
+	User   string   // User for the Postgres database login.
+}
+
 // People flags.
 type People struct {
 	Cronjob  bool   // Cronjob run the people command as a cronjob.
@@ -114,16 +150,43 @@ type Recent struct {
 	Limit    uint // Limit the number of recent records to display.
 }
 
+// Rename flags.
+type Rename struct {
+	Undo int64 // Undo is the id of a rename changeset to restore.
+}
+
+// Revert flags.
+type Revert struct {
+	Change int64 // Change is the id of the change in the audit log.
+}
+
+// Lookup flags.
+type Lookup struct {
+	Manifest bool // Manifest prints the JSON manifest of the archive content.
+}
+
+// Search flags.
+type Search struct {
+	Limit uint // Limit the number of records to display.
+}
+
 // TestSite flags.
 type TestSite struct {
 	LocalHost bool // LocalHost runs the tests to target a developer, Docker setup.
 }
 
+// Text preview flags.
+type Text struct {
+	Animate bool // Animate generates animated PNG previews of ANSI art.
+	Baud    int  // Baud is the modem speed in bits per second used to playback the animations.
+}
+
 // ZipCmmt flags.
 type ZipCmmt struct {
-	Stdout  bool // Stdout writes any found zip comment to the stdout.
-	Unicode bool // Unicode attempts to convert any CP-437 encoded comments to Unicode.
-	OW      bool // OW overwrites any existing save zip comments, otherwise they're skipped.
+	Stdout   bool // Stdout writes any found zip comment to the stdout.
+	Unicode  bool // Unicode attempts to convert any CP-437 encoded comments to Unicode.
+	OW       bool // OW overwrites any existing save zip comments, otherwise they're skipped.
+	Reencode bool // Reencode repairs the legacy filenames of saved archive listings, instead of extracting comments.
 }
 
 // Invalid returns instructions for invalid command arguments and exits with an error code.
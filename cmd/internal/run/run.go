@@ -5,6 +5,7 @@ package run
 import (
 	"bufio"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -18,10 +19,13 @@ import (
 	"github.com/Defacto2/df2/pkg/archive"
 	"github.com/Defacto2/df2/pkg/conf"
 	"github.com/Defacto2/df2/pkg/database"
+	"github.com/Defacto2/df2/pkg/database/msql"
+	"github.com/Defacto2/df2/pkg/database/psql"
 	"github.com/Defacto2/df2/pkg/demozoo"
 	"github.com/Defacto2/df2/pkg/directories"
 	"github.com/Defacto2/df2/pkg/groups"
 	"github.com/Defacto2/df2/pkg/images"
+	"github.com/Defacto2/df2/pkg/migrate"
 	"github.com/Defacto2/df2/pkg/people"
 	"github.com/Defacto2/df2/pkg/prompt"
 	"github.com/Defacto2/df2/pkg/proof"
@@ -31,6 +35,7 @@ import (
 	"github.com/Defacto2/df2/pkg/zipcontent"
 	"github.com/google/uuid"
 	"github.com/gookit/color"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 	"go.uber.org/zap"
 )
 
@@ -44,6 +49,7 @@ const (
 
 var (
 	ErrArg     = errors.New("argument is unsupported")
+	ErrMigrate = errors.New("the copied table does not match the source table")
 	ErrToFew   = errors.New("too few arguments given")
 	ErrNothing = errors.New(str.NothingToDo)
 	ErrZap     = errors.New("zap logger cannot be nil")
@@ -98,7 +104,10 @@ func Env(w io.Writer, l *zap.SugaredLogger, cfg conf.Config) error {
 		w = io.Discard
 	}
 	fmt.Fprintln(w, "Quietly creating directories.")
-	dirs := []string{cfg.Downloads, cfg.Images, cfg.Thumbs, cfg.IncomingFiles, cfg.IncomingImgs, cfg.SQLDumps}
+	dirs := []string{
+		cfg.Downloads, cfg.Images, cfg.Thumbs, cfg.IncomingFiles, cfg.IncomingImgs,
+		cfg.SQLDumps, cfg.SearchIndex,
+	}
 	for _, d := range dirs {
 		if err := os.MkdirAll(d, 0o755); err != nil {
 			l.Errorln(err)
@@ -383,7 +392,7 @@ func newProof(db *sql.DB, w io.Writer, cfg conf.Config) error {
 }
 
 func genZIPList(db *sql.DB, w io.Writer, l *zap.SugaredLogger, cfg conf.Config) error {
-	return zipcontent.Fix(db, w, l, cfg, true)
+	return zipcontent.Fix(db, w, l, cfg, 0, true)
 }
 
 func genImage(db *sql.DB, w io.Writer, cfg conf.Config) error {
@@ -391,19 +400,19 @@ func genImage(db *sql.DB, w io.Writer, cfg conf.Config) error {
 }
 
 func genText(db *sql.DB, w io.Writer, l *zap.SugaredLogger, cfg conf.Config) error {
-	return text.Fix(db, w, l, cfg)
+	return text.Fix(db, w, l, cfg, false, 0)
 }
 
 func fixDZ(db *sql.DB, w io.Writer) error {
-	return demozoo.Fix(db, w)
+	return demozoo.Fix(db, w, false)
 }
 
 func fixDB(db *sql.DB, w io.Writer) error {
-	return database.Fix(db, w)
+	return database.Fix(db, w, false)
 }
 
 func fixGroup(db *sql.DB, w io.Writer) error {
-	return groups.Fix(db, w)
+	return groups.Fix(db, w, false)
 }
 
 // New is the work function for the new command.
@@ -490,8 +499,76 @@ func People(db *sql.DB, w io.Writer, directory string, f arg.People) error {
 	return ErrNothing
 }
 
+// Migrate is the work function for the migrate command.
+// The configured MySQL database is copied to the Postgres database set by the flags.
+func Migrate(w io.Writer, cfg conf.Config, m arg.Migrate) error {
+	if w == nil {
+		w = io.Discard
+	}
+	src, err := msql.Connect(cfg)
+	if err != nil {
+		return fmt.Errorf("mysql: %w", err)
+	}
+	defer src.Close()
+	pg := cfg
+	pg.DBEngine = conf.Postgres
+	if m.Host != "" {
+		pg.DBHost = m.Host
+	}
+	if m.Name != "" {
+		pg.DBName = m.Name
+	}
+	if m.User != "" {
+		pg.DBUser = m.User
+	}
+	if cfg.MigratePass != "" {
+		pg.DBPass = cfg.MigratePass
+	}
+	pg.DBPort = m.Port
+	dst, err := psql.Connect(pg)
+	if err != nil {
+		return fmt.Errorf("postgres: %w", err)
+	}
+	defer dst.Close()
+	mig := migrate.Migrate{
+		Batch:  m.Batch,
+		Tables: m.Tables,
+	}
+	reports, err := mig.Run(src, dst, w)
+	migrate.Print(w, reports...)
+	if err != nil {
+		return err
+	}
+	for _, r := range reports {
+		if !r.OK() {
+			return fmt.Errorf("%w: %s", ErrMigrate, r.Table)
+		}
+	}
+	return nil
+}
+
+// Schema is the work function for the db migrate command.
+func Schema(db *sql.DB, w io.Writer, d arg.DB, args ...string) error {
+	if db == nil {
+		return database.ErrDB
+	}
+	if len(args) == 0 {
+		return ErrToFew
+	}
+	switch args[0] {
+	case "up":
+		return database.MigrateUp(db, w, d.Steps)
+	case "down":
+		return database.MigrateDown(db, w, d.Steps)
+	case "status":
+		return database.MigrateStatus(db, w)
+	}
+	return fmt.Errorf("%w: %s", ErrArg, args[0])
+}
+
 // Rename is the work function for the rename command.
-func Rename(db *sql.DB, w io.Writer, args ...string) error {
+// A dry run prints the renamed records without saving them.
+func Rename(db *sql.DB, w io.Writer, dry bool, args ...string) error {
 	if db == nil {
 		return database.ErrDB
 	}
@@ -530,23 +607,56 @@ func Rename(db *sql.DB, w io.Writer, args ...string) error {
 	default:
 		fmt.Fprintf(w, "Will merge the %d records of %q into the group %q to total %d records\n",
 			src, oldArg, newName, src+dest)
-		color.Danger.Println("This cannot be undone")
 	}
-	b, err := prompt.YN(w, "Rename the group", false)
+	if !dry {
+		b, err := prompt.YN(w, "Rename the group", false)
+		if err != nil {
+			return err
+		}
+		if !b {
+			return nil
+		}
+	}
+	tx, err := database.Begin(db, w, dry)
 	if err != nil {
 		return err
 	}
-	if !b {
-		return nil
-	}
-	i, err := groups.Update(db, newName, oldArg)
+	tx.Label = fmt.Sprintf("rename group %q to %q", oldArg, newName)
+	i, err := groups.Update(tx, newName, oldArg)
 	if err != nil {
-		return err
+		return tx.Cancel(err)
 	}
 	fmt.Fprintf(w, "%d records updated to use %q\n", i, newName)
+	if err := tx.End(); err != nil {
+		return err
+	}
+	if tx.Changeset > 0 {
+		fmt.Fprintf(w, "The rename can be undone with: df2 fix rename --undo %d\n", tx.Changeset)
+	}
 	return nil
 }
 
+// Undo is the work function for the rename --undo command.
+// A dry run prints the restored records without saving them.
+func Undo(db *sql.DB, w io.Writer, dry bool, changeset int64) error {
+	if db == nil {
+		return database.ErrDB
+	}
+	if w == nil {
+		w = io.Discard
+	}
+	if !dry {
+		b, err := prompt.YN(w, fmt.Sprintf("Undo the changeset %d", changeset), false)
+		if err != nil {
+			return err
+		}
+		if !b {
+			return nil
+		}
+	}
+	return database.Undo(db, w, dry, changeset)
+}
+
 // TestSite is the work function for the test command.
 func TestSite(db *sql.DB, w io.Writer, base string) error { //nolint:funlen
 	if db == nil {
@@ -641,3 +751,43 @@ func TestSite(db *sql.DB, w io.Writer, base string) error { //nolint:funlen
 
 	return nil
 }
+
+// Manifest prints the JSON manifest of the archive content for the record id or uuid.
+// When the manifest sidecar file is missing, the manifest is read from the archive download.
+func Manifest(db *sql.DB, w io.Writer, cfg conf.Config, id string) error {
+	if db == nil {
+		return database.ErrDB
+	}
+	if w == nil {
+		w = io.Discard
+	}
+	key, err := database.GetID(db, id)
+	if err != nil {
+		return err
+	}
+	files, err := database.Select(db, qm.Select("id", "uuid", "filename"), qm.Where("id = ?", key))
+	if err != nil {
+		return fmt.Errorf("manifest select %s: %w", id, err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("manifest %s: %w", id, ErrNothing)
+	}
+	f := files[0]
+	dir, err := directories.Files(cfg, f.UUID.String)
+	if err != nil {
+		return fmt.Errorf("manifest %s: %w", id, err)
+	}
+	m, err := archive.LoadManifest(dir.UUID + archive.ManifestExt)
+	if errors.Is(err, fs.ErrNotExist) {
+		m, err = archive.ReadManifest(dir.UUID, f.Filename.String)
+	}
+	if err != nil {
+		return fmt.Errorf("manifest %s: %w", id, err)
+	}
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("manifest %s: %w", id, err)
+	}
+	fmt.Fprintln(w, string(b))
+	return nil
+}
@@ -91,9 +91,9 @@ func TestPeople(t *testing.T) {
 func TestRename(t *testing.T) {
 	t.Parallel()
 	s := []string{}
-	err := run.Rename(nil, nil, s...)
+	err := run.Rename(nil, nil, false, s...)
 	assert.NotNil(t, err)
-	err = run.Rename(db, io.Discard, s...)
+	err = run.Rename(db, io.Discard, false, s...)
 	assert.NotNil(t, err)
 }
 
@@ -5,13 +5,20 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/Defacto2/df2/cmd/internal/arg"
+	"github.com/Defacto2/df2/cmd/internal/run"
 	"github.com/Defacto2/df2/pkg/database"
 	"github.com/spf13/cobra"
 )
 
+var look arg.Lookup
+
 var lookupCmd = &cobra.Command{
-	Use:     "lookup (ids|uuids)",
-	Short:   "Lookup the file URL of a record's ID or UUID.",
+	Use:   "lookup (ids|uuids)",
+	Short: "Lookup the file URL of a record's ID or UUID.",
+	Long: `Lookup the file URL of a record's ID or UUID.
+The manifest flag prints the JSON listing of the files within an archive download,
+including their names, sizes, packed sizes, modification times, checksums and methods.`,
 	Aliases: []string{"l"},
 	GroupID: "group3",
 	Example: `  id is a unique numeric identifier
@@ -42,10 +49,17 @@ var lookupCmd = &cobra.Command{
 			}
 			fmt.Fprintf(os.Stdout, "https://defacto2.net/f/%v\n",
 				database.ObfuscateParam(fmt.Sprint(id)))
+			if look.Manifest {
+				if err := run.Manifest(db, os.Stdout, confg, a); err != nil {
+					logr.Info(err)
+				}
+			}
 		}
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(lookupCmd)
+	lookupCmd.Flags().BoolVarP(&look.Manifest, "manifest", "m", false,
+		"print the JSON manifest of the archive content")
 }
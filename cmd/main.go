@@ -24,6 +24,7 @@ import (
 
 var (
 	ErrConfig  = errors.New("config cannot be empty")
+	ErrDryRun  = errors.New("the dry-run flag is not supported by this command")
 	ErrCommand = errors.New("invalid command, please use one of the available commands")
 	ErrID      = errors.New("invalid id or uuid specified")
 	ErrLogger  = errors.New("logger cannot be nil")
@@ -36,6 +37,14 @@ var (
 	persist arg.Persistent     // Persistent, command-line bool flags.
 )
 
+// dryRun is the annotation key for commands that support the dry-run flag.
+const dryRun = "dry-run"
+
+// dryRunnable is the annotation for commands that support the dry-run flag.
+func dryRunnable() map[string]string {
+	return map[string]string{dryRun: "true"}
+}
+
 // rootCmd represents the base command when called without any subcommands.
 var rootCmd = &cobra.Command{
 	Use:   "df2",
@@ -44,6 +53,13 @@ var rootCmd = &cobra.Command{
 		color.Info.Sprint(About),
 		Copyright(),
 		color.Primary.Sprint(URL)),
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if persist.DryRun && cmd.Annotations[dryRun] == "" {
+			return fmt.Errorf("%w: %s", ErrDryRun, cmd.CommandPath())
+		}
+		database.SetCommand(cmd.CommandPath())
+		return nil
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		db, err := database.Connect(confg)
 		if err != nil {
@@ -95,6 +111,8 @@ func init() {
 		"suppress all feedback except for errors")
 	rootCmd.PersistentFlags().BoolVarP(&persist.Version, "version", "v", false,
 		"version and information for this program")
+	rootCmd.PersistentFlags().BoolVar(&persist.DryRun, dryRun, false,
+		"print the database changes of a fix without saving them")
 	rootCmd.PersistentFlags().BoolVar(&persist.Panic, "panic", false,
 		"panic in the disco")
 	if err := rootCmd.PersistentFlags().MarkHidden("panic"); err != nil {
@@ -0,0 +1,56 @@
+//nolint:gochecknoglobals,gochecknoinits
+package cmd
+
+import (
+	"os"
+
+	"github.com/Defacto2/df2/cmd/internal/arg"
+	"github.com/Defacto2/df2/cmd/internal/run"
+	"github.com/Defacto2/df2/pkg/database/psql"
+	"github.com/Defacto2/df2/pkg/migrate"
+	"github.com/spf13/cobra"
+)
+
+var mig arg.Migrate
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Copy the MySQL database tables into a Postgres database.",
+	Long: `Copy the files, groupnames and netresources tables from the configured
+MySQL database into a Postgres database.
+
+Tinyint flags are converted to boolean 0 or 1 values and zero dates are
+converted to NULL. The rows are copied in batches, so an interrupted migration
+resumes from the last copied row when run again. Once done, the row counts and
+checksums of each table are compared.
+
+The Postgres database uses the DF2_DB connection settings unless overridden
+by the flags. The password of the Postgres user is read from the
+DF2_MIGRATEPASS environment variable, otherwise DF2_DBPASS is used.
+The Postgres tables must exist before the migration.`,
+	Example: `  df2 migrate --host=localhost --name=defacto2-ps
+  df2 migrate --tables=groupnames,netresources --batch=500`,
+	GroupID: "group1",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := run.Migrate(os.Stdout, confg, mig); err != nil {
+			logr.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+	migrateCmd.Flags().StringSliceVarP(&mig.Tables, "tables", "t", migrate.Tables(),
+		"tables to copy"+arg.CleanOpts(migrate.Tables()...))
+	migrateCmd.Flags().IntVarP(&mig.Batch, "batch", "b", migrate.Batch,
+		"number of rows to copy in each transaction")
+	migrateCmd.Flags().StringVar(&mig.Host, "host", "",
+		"host name of the Postgres server")
+	migrateCmd.Flags().UintVar(&mig.Port, "port", psql.Port,
+		"port number of the Postgres server")
+	migrateCmd.Flags().StringVar(&mig.Name, "name", "",
+		"name of the Postgres database")
+	migrateCmd.Flags().StringVar(&mig.User, "user", "",
+		"user for the Postgres database login")
+	migrateCmd.Flags().SortFlags = false
+}
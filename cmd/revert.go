@@ -0,0 +1,47 @@
+//nolint:gochecknoglobals,gochecknoinits
+package cmd
+
+import (
+	"os"
+
+	"github.com/Defacto2/df2/cmd/internal/arg"
+	"github.com/Defacto2/df2/pkg/database"
+	"github.com/spf13/cobra"
+)
+
+var revt arg.Revert
+
+var revertCmd = &cobra.Command{
+	Use:   "revert --change=id",
+	Short: "Undo a change of a record listed in the audit log.",
+	Long: `Undo a change of a record listed in the audit log by restoring the old value.
+
+The revert is refused when the value was edited after the change,
+and the revert is itself saved to the audit log. Use the history command
+to find the change id.`,
+	GroupID: "group1",
+	Example: `  df2 history --id=1
+  df2 revert --change=123`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if revt.Change < 1 {
+			if err := cmd.Usage(); err != nil {
+				logr.Fatal(err)
+			}
+			return
+		}
+		db, err := database.Connect(confg)
+		if err != nil {
+			logr.Fatal(err)
+		}
+		defer db.Close()
+		if err := database.Revert(db, os.Stdout, revt.Change); err != nil {
+			logr.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(revertCmd)
+	revertCmd.Flags().Int64VarP(&revt.Change, "change", "c", 0,
+		"id of the change in the audit log")
+}
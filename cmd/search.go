@@ -0,0 +1,61 @@
+//nolint:gochecknoglobals,gochecknoinits
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Defacto2/df2/cmd/internal/arg"
+	"github.com/Defacto2/df2/pkg/database"
+	"github.com/Defacto2/df2/pkg/index"
+	"github.com/gookit/color"
+	"github.com/spf13/cobra"
+)
+
+var find arg.Search
+
+var searchCmd = &cobra.Command{
+	Use:   "search words",
+	Short: "Search the text of the readmes and NFOs.",
+	Long: `Search the full-text index of the readmes, NFOs and file_id.diz texts
+for the records that contain every word. The records are listed by relevance
+with their id, URL and the line of text that best matches the words.
+
+The index must first be created using the index command.`,
+	GroupID: "group2",
+	Example: `  df2 search razor 1911
+  df2 search --limit=50 "dream team courier"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			if err := cmd.Usage(); err != nil {
+				logr.Fatal(err)
+			}
+			return
+		}
+		results, err := index.Search(confg, strings.Join(args, " "), int(find.Limit))
+		if errors.Is(err, index.ErrEmpty) {
+			logr.Info(err)
+			return
+		}
+		if err != nil {
+			logr.Fatal(err)
+		}
+		w := os.Stdout
+		for _, r := range results {
+			fmt.Fprintf(w, "%s %s https://defacto2.net/f/%v\n", color.Primary.Sprint(r.ID), r.Name,
+				database.ObfuscateParam(fmt.Sprint(r.ID)))
+			if r.Snippet != "" {
+				fmt.Fprintf(w, "  %s %s\n", color.Secondary.Sprintf("%d:", r.Line), r.Snippet)
+			}
+		}
+		fmt.Fprintf(w, "%d records found\n", len(results))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+	searchCmd.Flags().UintVarP(&find.Limit, "limit", "l", 20,
+		"maximum number of records to list, 0 lists every record")
+}
@@ -28,7 +28,7 @@ import (
 
 	"github.com/Defacto2/df2/cmd"
 	"github.com/Defacto2/df2/pkg/conf"
-	"github.com/Defacto2/df2/pkg/database/msql"
+	"github.com/Defacto2/df2/pkg/database"
 	"github.com/Defacto2/df2/pkg/logger"
 	"github.com/caarlos0/env/v7"
 	"github.com/gookit/color"
@@ -112,7 +112,7 @@ func setProcs(c conf.Config) {
 
 // checkDB checks the database connection.
 func checkDB(ls *zap.SugaredLogger, c conf.Config) {
-	db, err := msql.Connect(c)
+	db, err := database.Connect(c)
 	if err != nil {
 		ls.Errorf("Could not connect to the database: %s.", err)
 	}
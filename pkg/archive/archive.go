@@ -25,9 +25,17 @@ import (
 )
 
 const (
-	diz = ".diz"
-	nfo = ".nfo"
-	txt = ".txt"
+	arcx = ".arc"
+	arjx = ".arj"
+	arkx = ".ark"
+	diz  = ".diz"
+	lhax = ".lha"
+	lzhx = ".lzh"
+	pakx = ".pak"
+	zoox = ".zoo"
+	zipx = ".zip"
+	nfo  = ".nfo"
+	txt  = ".txt"
 )
 
 var (
@@ -129,8 +137,14 @@ func (z Demozoo) Decompress(db *sql.DB, w io.Writer) (demozoo.Data, error) {
 		}
 	}
 	if _, err = Restore(w, z.Source, name, tmp); err != nil {
+		if err := Flag(db, w, z.UUID, err); err != nil {
+			return demozoo.Data{}, fmt.Errorf("extract demozoo flag %q: %w", name, err)
+		}
 		return demozoo.Data{}, fmt.Errorf("extract demozoo restore %q: %w", name, err)
 	}
+	if err = unnest(tmp, Nested()); err != nil {
+		return demozoo.Data{}, fmt.Errorf("extract demozoo unnest %q: %w", name, err)
+	}
 
 	zips, err := zips(tmp)
 	if err != nil {
@@ -149,24 +163,38 @@ func (z Demozoo) Decompress(db *sql.DB, w io.Writer) (demozoo.Data, error) {
 	return dz, nil
 }
 
+// zips returns the files within the name directory and its subdirectories,
+// the name of each file is its path relative to the directory.
 func zips(name string) (content.Contents, error) {
-	files, err := os.ReadDir(name)
-	if err != nil {
-		return nil, fmt.Errorf("extract demozoo readdir %q: %w", name, err)
-	}
 	zips := make(content.Contents)
-	for i, file := range files {
-		f, err := file.Info()
+	i := 0
+	err := filepath.WalkDir(name, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		f, err := d.Info()
 		if err != nil {
 			fmt.Fprintf(os.Stdout, "extract demozoo file info error: %s\n", err)
+			return nil
 		}
 		var zip content.File
-		zip.Path = name // filename gets appended by z.scan()
+		zip.Path = filepath.Dir(path) // filename gets appended by z.scan()
 		zip.Scan(f)
+		if rel, err := filepath.Rel(name, path); err == nil {
+			zip.Name = filepath.ToSlash(rel)
+		}
 		if err = zip.MIME(); err != nil {
-			return nil, fmt.Errorf("extract demozoo filemime %q: %w", f, err)
+			return fmt.Errorf("extract demozoo filemime %q: %w", f, err)
 		}
 		zips[i] = zip
+		i++
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("extract demozoo walk %q: %w", name, err)
 	}
 	return zips, nil
 }
@@ -246,6 +274,9 @@ func (p Proof) Decompress(w io.Writer) error {
 	if err = Unarchiver(p.Source, tmp, p.Name); err != nil {
 		return fmt.Errorf("unarchiver: %w", err)
 	}
+	if _, err = Assemble(w, tmp); err != nil {
+		return fmt.Errorf("archive %w", err)
+	}
 	th, tx, err := task.Run(tmp)
 	if err != nil {
 		return err
@@ -303,6 +334,8 @@ func Read(w io.Writer, src, name string) ([]string, string, error) {
 // The archive format is selected implicitly. Restore relies on the filename
 // extension to determine which decompression format to use, which must be
 // supplied using filename.
+// Any multi-volume or split archives within the archive are reassembled and
+// their files are appended to the returned list.
 // src is the absolute path to the archive file named as a unique id.
 // filename is the original archive filename and file extension.
 func Restore(w io.Writer, src, name, dest string) ([]string, error) {
@@ -317,5 +350,9 @@ func Restore(w io.Writer, src, name, dest string) ([]string, error) {
 	if err != nil {
 		return nil, fmt.Errorf("restore readr: %w", err)
 	}
-	return files, nil
+	assembled, err := Assemble(w, dest)
+	if err != nil {
+		return nil, fmt.Errorf("restore %w", err)
+	}
+	return append(files, assembled...), nil
 }
@@ -3,57 +3,67 @@ package archive
 import (
 	"archive/tar"
 	"archive/zip"
-	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"path/filepath"
 	"strings"
 	"unicode/utf8"
 
 	"github.com/Defacto2/df2/pkg/archive/internal/arc"
+	"github.com/Defacto2/df2/pkg/archive/internal/arj"
+	"github.com/Defacto2/df2/pkg/archive/internal/lha"
+	"github.com/Defacto2/df2/pkg/archive/internal/sea"
 	"github.com/Defacto2/df2/pkg/archive/internal/sys"
+	"github.com/Defacto2/df2/pkg/archive/internal/zoo"
+	"github.com/Defacto2/df2/pkg/charset"
 	"github.com/mholt/archiver"
 	"github.com/nwaples/rardecode"
-	"golang.org/x/text/encoding/charmap"
-	"golang.org/x/text/transform"
 )
 
 // Extractor extracts the named file from the given archive file into the destination folder.
 // The archive format is selected implicitly.
 // Archiver relies on the filename extension to determine which
 // decompression format to use, which must be supplied using filename.
+// Archives that break the default Limits return a Violation error and nothing is extracted.
 func Extractor(name, src, target, dest string) error {
-	name = strings.ToLower(name)
-	f, err := archiver.ByExtension(name)
-	if err != nil {
-		return fmt.Errorf("extractor byextension %q: %w", name, err)
-	}
-	if err := arc.Configure(f); err != nil {
-		return fmt.Errorf("extractor configure: %w", err)
+	return Limited().Extract(name, src, target, dest)
+}
+
+// rawName returns the filename stored in the src archive that decodes to the target filename.
+// The target is returned when it is plain ASCII or when there is no match.
+func rawName(src, name, target string) string {
+	ascii := true
+	for i := 0; i < len(target); i++ {
+		if target[i] >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
 	}
-	e, ok := f.(archiver.Extractor)
-	if !ok {
-		return fmt.Errorf("extractor %s (%T): %w", name, f, ErrArchive)
+	if ascii {
+		return target
 	}
-
-	// recover from panic caused by mholt/archiver.
-	defer func() {
-		if r := recover(); r != nil {
-			err = fmt.Errorf("extractor panic %s: %v", name, r)
+	raw := target
+	_ = arc.Walkr(src, name, func(f archiver.File) error {
+		fn := f.Name()
+		switch h := f.Header.(type) {
+		case zip.FileHeader:
+			fn = h.Name
+		case *tar.Header:
+			fn = h.Name
+		case *rardecode.FileHeader:
+			fn = h.Name
 		}
-	}()
-
-	if err := e.Extract(src, target, dest); err != nil {
-		// second attempt at extraction using a system archiver program
-		if err := sys.Extract(name, src, target, dest); err != nil {
-			return fmt.Errorf("extractor, system attempt: %w", err)
+		if fn != target && charset.Name([]byte(fn)) == target {
+			raw = fn
+			return archiver.ErrStopWalk
 		}
-		return fmt.Errorf("extractor: %w", err)
-	}
-	return nil
+		return nil
+	})
+	return raw
 }
 
-// Readr returns both a list of files within an rar, tar or zip archive,
+// Readr returns both a list of files within an arc, arj, lha, rar, tar, zip or zoo archive,
 // and a suitable archive filename string.
 // If there are problems reading the archive due to an incorrect filename
 // extension, the returned filename string will be corrected.
@@ -65,6 +75,9 @@ func Readr(w io.Writer, src, filename string) ([]string, string, error) {
 		return files, filename, nil
 	}
 	files, ext, err := sys.Readr(w, src, filename)
+	for i, file := range files {
+		files[i] = charset.Name([]byte(file))
+	}
 	if errors.Is(err, sys.ErrWrongExt) {
 		newname := sys.Rename(ext, filename)
 		fmt.Fprintf(w, "rename to %s; ", newname)
@@ -81,6 +94,16 @@ func Readr(w io.Writer, src, filename string) ([]string, string, error) {
 }
 
 func readr(src, filename string) ([]string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case arjx:
+		return arj.List(src)
+	case lhax, lzhx:
+		return lha.List(src)
+	case arcx, arkx, pakx:
+		return sea.List(src)
+	case zoox:
+		return zoo.List(src)
+	}
 	files := []string{}
 	return files, arc.Walkr(src, filename, func(f archiver.File) error {
 		if f.IsDir() {
@@ -97,18 +120,8 @@ func readr(src, filename string) ([]string, error) {
 		default:
 			fn = f.Name()
 		}
-		b := []byte(fn)
-		if utf8.Valid(b) {
-			files = append(files, fn)
-			return nil
-		}
-		// handle cheeky DOS era filenames with CP437 extended characters.
-		r := transform.NewReader(bytes.NewReader(b), charmap.CodePage437.NewDecoder())
-		result, err := io.ReadAll(r)
-		if err != nil {
-			return err
-		}
-		files = append(files, string(result))
+		// handle cheeky DOS era filenames with CP437 or Shift-JIS characters.
+		files = append(files, charset.Name([]byte(fn)))
 		return nil
 	})
 }
@@ -117,20 +130,7 @@ func readr(src, filename string) ([]string, error) {
 // The archive format is selected implicitly.
 // Archiver relies on the filename extension to determine which
 // decompression format to use, which must be supplied using filename.
+// Archives that break the default Limits return a Violation error and nothing is extracted.
 func Unarchiver(src, dest, filename string) error {
-	f, err := archiver.ByExtension(filename)
-	if err != nil {
-		return fmt.Errorf("unarchiver byextension %q: %w", filename, err)
-	}
-	if err := arc.Configure(f); err != nil {
-		return fmt.Errorf("unarchiver configure: %w", err)
-	}
-	un, ok := f.(archiver.Unarchiver)
-	if !ok {
-		return fmt.Errorf("unarchiver %s (%T): %w", filename, f, ErrArchive)
-	}
-	if err := un.Unarchive(src, dest); err != nil {
-		return fmt.Errorf("unarchiver: %w", err)
-	}
-	return nil
+	return Limited().Unarchive(src, dest, filename)
 }
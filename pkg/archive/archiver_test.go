@@ -3,10 +3,12 @@ package archive_test
 import (
 	"log"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
 	"github.com/Defacto2/df2/pkg/archive"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestReadr(t *testing.T) {
@@ -23,6 +25,13 @@ func TestReadr(t *testing.T) {
 	}{
 		{"empty", args{"", ""}, nil, true},
 		{"zip", args{testDir("demozoo/test.zip"), "test.zip"}, []string{"test.png", "test.txt"}, false},
+		{"arj", args{testDir("demozoo/test.arj"), "test.arj"}, []string{"test.png", "test.txt"}, false},
+		{"arj methods", args{testDir("arj/hello.arj"), "HELLO.ARJ"}, []string{"HELLO.TXT"}, false},
+		{"lha", args{testDir("demozoo/test.lha"), "test.lha"}, []string{"ext dir/test file.text", "test.png", "test.txt"}, false},
+		{"lzh methods", args{testDir("lha/hello.lzh"), "HELLO.LZH"}, []string{"HELLO.TXT"}, false},
+		{"arc", args{testDir("arc/hello.arc"), "HELLO.ARC"}, []string{"HELLO.TXT"}, false},
+		{"pak", args{testDir("arc/methods.pak"), "methods.pak"}, []string{"SQUASHED.TXT", "CRUSHED.TXT", "DISTILL.TXT"}, false},
+		{"zoo", args{testDir("zoo/hello.zoo"), "hello.zoo"}, []string{"HELLO.TXT"}, false},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -48,6 +57,14 @@ func TestUnarchiver(t *testing.T) {
 		fn  = "test.zip"
 		z7  = testDir("demozoo/test.7z")
 		zfn = "test.7z"
+		arj = testDir("arj/methods.arj")
+		afn = "methods.arj"
+		lzh = testDir("lha/methods.lzh")
+		lfn = "methods.lzh"
+		arc = testDir("arc/methods.arc")
+		cfn = "methods.arc"
+		zoo = testDir("zoo/methods.zoo")
+		ofn = "methods.zoo"
 	)
 	if err != nil {
 		t.Error(err)
@@ -68,6 +85,10 @@ func TestUnarchiver(t *testing.T) {
 		{"missing dest", args{src, fn, ""}, true},
 		{"okay", args{src, fn, dir}, false},
 		{"7z", args{z7, zfn, dir}, true},
+		{"arj", args{arj, afn, dir}, false},
+		{"lzh", args{lzh, lfn, dir}, false},
+		{"arc", args{arc, cfn, dir}, false},
+		{"zoo", args{zoo, ofn, dir}, false},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -82,3 +103,22 @@ func TestUnarchiver(t *testing.T) {
 		log.Print(err)
 	}
 }
+
+func TestReadr_Legacy(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	src := mkzip(t, dir, map[string][]byte{
+		"CAF\x90.NFO":          []byte("cp437"),
+		"\x83\x66\x83\x82.TXT": []byte("shift-jis"),
+	})
+	files, _, err := archive.Readr(nil, src, "legacy.zip")
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"CAFÉ.NFO", "デモ.TXT"}, files)
+
+	dest := t.TempDir()
+	err = archive.Extractor("legacy.zip", src, "デモ.TXT", dest)
+	assert.Nil(t, err)
+	b, err := os.ReadFile(filepath.Join(dest, "デモ.TXT"))
+	assert.Nil(t, err)
+	assert.Equal(t, "shift-jis", string(b))
+}
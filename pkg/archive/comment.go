@@ -0,0 +1,109 @@
+package archive
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Defacto2/df2/pkg/archive/internal/arj"
+	"github.com/Defacto2/df2/pkg/archive/internal/lha"
+	"github.com/Defacto2/df2/pkg/archive/internal/rarcmmt"
+	"github.com/Defacto2/df2/pkg/archive/internal/zoo"
+)
+
+var ErrComment = errors.New("archive format does not support comments")
+
+// Comment returns the comment embedded in the src archive, such as a BBS advert or a courier tag.
+// name is the original archive filename and file extension,
+// which is used to determine the archive format.
+//
+// The comment is returned using its original legacy character set, which is usually CP437,
+// as the archive formats do not record the character set of the text.
+// The ZIP, RAR and ARJ formats support an archive comment,
+// while the comments of each file in LHA and ZOO archives are combined.
+func Comment(src, name string) (string, error) {
+	var (
+		s   string
+		err error
+	)
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".zip":
+		s, err = zipComment(src)
+	case ".rar":
+		s, err = rarcmmt.Comment(src)
+	case arjx:
+		s, err = arjComment(src)
+	case lhax, lzhx:
+		s, err = lhaComment(src)
+	case zoox:
+		s, err = zooComment(src)
+	default:
+		return "", fmt.Errorf("%w: %s", ErrComment, name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("comment %s: %w", name, err)
+	}
+	return s, nil
+}
+
+func zipComment(src string) (string, error) {
+	r, err := zip.OpenReader(src)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	return r.Comment, nil
+}
+
+func arjComment(src string) (string, error) {
+	r, err := arj.OpenReader(src)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	return string(r.RawComment), nil
+}
+
+func lhaComment(src string) (string, error) {
+	r, err := lha.OpenReader(src)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	cmmts := make([][]byte, 0, len(r.File))
+	for _, f := range r.File {
+		cmmts = append(cmmts, f.RawComment)
+	}
+	return combine(cmmts), nil
+}
+
+func zooComment(src string) (string, error) {
+	r, err := zoo.OpenReader(src)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	cmmts := make([][]byte, 0, len(r.File))
+	for _, f := range r.File {
+		cmmts = append(cmmts, f.RawComment)
+	}
+	return combine(cmmts), nil
+}
+
+// combine returns the unique, non-empty raw file comments as a single comment
+// that uses the legacy character set of the archive.
+func combine(cmmts [][]byte) string {
+	seen := map[string]bool{}
+	s := []string{}
+	for _, b := range cmmts {
+		c := string(b)
+		if strings.TrimSpace(c) == "" || seen[c] {
+			continue
+		}
+		seen[c] = true
+		s = append(s, c)
+	}
+	return strings.Join(s, "\n")
+}
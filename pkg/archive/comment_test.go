@@ -0,0 +1,50 @@
+package archive_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Defacto2/df2/pkg/archive"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComment(t *testing.T) {
+	t.Parallel()
+	_, err := archive.Comment(testDir("demozoo/test.tar"), "test.tar")
+	assert.ErrorIs(t, err, archive.ErrComment)
+	_, err = archive.Comment(testDir("demozoo/test.zip"), "test.rar")
+	assert.NotNil(t, err)
+	tests := []struct {
+		src  string
+		name string
+		want string
+	}{
+		{filepath.Join("demozoo", "test.zip"), "test.zip", ""},
+		{filepath.Join("rar", "cmt3.rar"), "CMT3.RAR", "Greetings from the\r\nRAR comment BBS \xcd\xcd\r\n"},
+		{filepath.Join("arj", "methods.arj"), "methods.arj", "ARJ fixture for methods 0 to 4."},
+		{filepath.Join("lha", "comment.lzh"), "comment.lzh", "Greetings from the LHA comment BBS \xcd\xcd"},
+		{filepath.Join("zoo", "hello.zoo"), "hello.zoo", "Hello comment."},
+	}
+	for _, tt := range tests {
+		s, err := archive.Comment(testDir(tt.src), tt.name)
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.want, s, tt.name)
+	}
+}
+
+func TestComment_Raw(t *testing.T) {
+	t.Parallel()
+	// the CP437 box characters ├⌐ are also the valid UTF-8 encoding of é,
+	// so the comment bytes must be returned unchanged and not decoded
+	b, err := os.ReadFile(testDir(filepath.Join("zoo", "hello.zoo")))
+	assert.Nil(t, err)
+	const raw = "\xc3\xa9box comment."
+	b = bytes.Replace(b, []byte("Hello comment."), []byte(raw), 1)
+	src := filepath.Join(t.TempDir(), "raw.zoo")
+	assert.Nil(t, os.WriteFile(src, b, 0o644))
+	s, err := archive.Comment(src, "raw.zoo")
+	assert.Nil(t, err)
+	assert.Equal(t, raw, s)
+}
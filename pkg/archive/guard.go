@@ -0,0 +1,652 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Defacto2/df2/pkg/archive/internal/arc"
+	"github.com/Defacto2/df2/pkg/archive/internal/arj"
+	"github.com/Defacto2/df2/pkg/archive/internal/dosarc"
+	"github.com/Defacto2/df2/pkg/archive/internal/lha"
+	"github.com/Defacto2/df2/pkg/archive/internal/sea"
+	"github.com/Defacto2/df2/pkg/archive/internal/sys"
+	"github.com/Defacto2/df2/pkg/archive/internal/zoo"
+	"github.com/Defacto2/df2/pkg/database"
+	"github.com/mholt/archiver"
+	"github.com/nwaples/rardecode"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+const (
+	LimitSize  = 2 << 30               // LimitSize is the default size limit in bytes of the files extracted from an archive.
+	LimitFiles = 10000                 // LimitFiles is the default limit of files and directories extracted from an archive.
+	LimitRatio = 200                   // LimitRatio is the default limit of the extracted size compared to the archive size.
+	LimitDepth = 32                    // LimitDepth is the default limit of directories within the path of an extracted file.
+	ratioSize  = 16 << 20              // ratioSize is the extracted size in bytes before the ratio limit applies.
+	alertLen   = 256                   // alertLen is the maximum length of the security violation column.
+	quotaPoll  = 50 * time.Millisecond // quotaPoll is how often the files extracted by a program are measured.
+)
+
+var (
+	ErrUnsafe   = errors.New("archive is unsafe to extract")
+	ErrAbsolute = errors.New("file path is absolute")
+	ErrTraverse = errors.New("file path traverses outside of the destination")
+	ErrLink     = errors.New("file is a link or a special file")
+	ErrSize     = errors.New("extracted files exceed the size limit")
+	ErrFiles    = errors.New("extracted files exceed the file count limit")
+	ErrRatio    = errors.New("extracted files exceed the compression ratio limit")
+	ErrDepth    = errors.New("extracted file exceeds the directory depth limit")
+	ErrBounds   = errors.New("archive cannot be extracted within the limits")
+)
+
+// Violation is the error returned when an archive breaks the limits of a safe extraction.
+// Any violation matches ErrUnsafe and the broken rule, such as ErrTraverse or ErrRatio.
+type Violation struct {
+	Archive string // Archive is the filename of the archive.
+	Entry   string // Entry is the file within the archive that caused the violation, if known.
+	Err     error  // Err is the broken rule.
+}
+
+func (v *Violation) Error() string {
+	if v.Entry == "" {
+		return fmt.Sprintf("%s %s: %s", ErrUnsafe, v.Archive, v.Err)
+	}
+	return fmt.Sprintf("%s %s: %s: %s", ErrUnsafe, v.Archive, v.Err, v.Entry)
+}
+
+func (v *Violation) Unwrap() error {
+	return v.Err
+}
+
+func (v *Violation) Is(target error) bool {
+	return target == ErrUnsafe //nolint:errorlint,goerr113
+}
+
+// Limits the extraction of untrusted archives.
+// A zero value uses the default limit.
+type Limits struct {
+	Size  int64 // Size is the total size limit in bytes of the extracted files.
+	Files int   // Files is the limit of extracted files and directories.
+	Ratio int64 // Ratio is the limit of the extracted size compared to the archive size.
+	Depth int   // Depth is the limit of directories within the path of an extracted file.
+}
+
+// Limited returns the default limits for extracting untrusted archives.
+func Limited() Limits {
+	return Limits{Size: LimitSize, Files: LimitFiles, Ratio: LimitRatio, Depth: LimitDepth}
+}
+
+func (l Limits) defaults() Limits {
+	d := Limited()
+	if l.Size <= 0 {
+		l.Size = d.Size
+	}
+	if l.Files <= 0 {
+		l.Files = d.Files
+	}
+	if l.Ratio <= 0 {
+		l.Ratio = d.Ratio
+	}
+	if l.Depth <= 0 {
+		l.Depth = d.Depth
+	}
+	return l
+}
+
+// Unarchive decompresses the src archive into the dest directory in the same manner as Unarchiver,
+// but returns a Violation error and extracts nothing when the archive breaks the limits.
+// name is the original archive filename and file extension.
+func (l Limits) Unarchive(src, dest, name string) error {
+	if err := l.Check(src, name); err != nil {
+		return err
+	}
+	return l.guard(filepath.Base(src), size(src), dest, func(dir string, lim *dosarc.Limit) error {
+		return unarchiveLimit(src, dir, name, lim)
+	})
+}
+
+// Extract extracts the target file from the src archive into the dest directory in the same manner
+// as Extractor, but returns a Violation error and extracts nothing when the archive breaks the limits.
+// name is the original archive filename and file extension.
+func (l Limits) Extract(name, src, target, dest string) error {
+	return l.extract(name, src, target, dest, 0)
+}
+
+// extract is Extract that also stops and returns ErrNestSize as soon as the extracted target
+// is larger than the nest size in bytes. A zero nest size only uses the limits.
+func (l Limits) extract(name, src, target, dest string, nest int64) error {
+	if err := l.Names(name, target); err != nil {
+		return err
+	}
+	if err := l.Check(src, name); err != nil {
+		return err
+	}
+	return l.guard(filepath.Base(src), size(src), dest, func(dir string, lim *dosarc.Limit) error {
+		if nest <= 0 || nest >= lim.Size {
+			return extractLimit(name, src, target, dir, lim)
+		}
+		lim.Size = nest
+		err := extractLimit(name, src, target, dir, lim)
+		if errors.Is(err, dosarc.ErrSize) {
+			return fmt.Errorf("%w: %s", ErrNestSize, target)
+		}
+		return err
+	})
+}
+
+// Names returns a Violation error if any of the file names within the named archive
+// are absolute paths, traverse outside of the destination or are too deep.
+func (l Limits) Names(name string, names ...string) error {
+	l = l.defaults()
+	for _, n := range names {
+		if n == "" {
+			continue
+		}
+		n = strings.ReplaceAll(n, "\\", "/")
+		if strings.HasPrefix(n, "/") || (len(n) > 1 && n[1] == ':') {
+			return &Violation{Archive: filepath.Base(name), Entry: n, Err: ErrAbsolute}
+		}
+		clean := path.Clean(n)
+		if clean == ".." || strings.HasPrefix(clean, "../") {
+			return &Violation{Archive: filepath.Base(name), Entry: n, Err: ErrTraverse}
+		}
+		if strings.Count(clean, "/")+1 > l.Depth {
+			return &Violation{Archive: filepath.Base(name), Entry: n, Err: ErrDepth}
+		}
+	}
+	return nil
+}
+
+// Check reads the headers of the src archive and returns a Violation error if the files within
+// the archive break the limits. Archives that cannot be read are not checked.
+// name is the original archive filename and file extension.
+func (l Limits) Check(src, name string) error {
+	l = l.defaults()
+	st, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("check: %w", err)
+	}
+	var size int64
+	files := 0
+	// the violation is kept as the mholt walkers do not wrap the returned errors
+	var violation error
+	_ = entries(src, name, func(e entry) error {
+		violation = l.Names(name, e.name)
+		if violation == nil && e.link {
+			violation = &Violation{Archive: filepath.Base(name), Entry: e.name, Err: ErrLink}
+		}
+		if violation == nil {
+			size += e.size
+			files++
+			violation = l.exceeds(name, size, st.Size(), files)
+		}
+		return violation
+	})
+	return violation
+}
+
+// exceeds returns a Violation error when the size or count of the files
+// extracted from an archive of the src size breaks the limits.
+func (l Limits) exceeds(name string, size, src int64, files int) error {
+	switch {
+	case size > l.Size:
+		return &Violation{Archive: filepath.Base(name), Err: ErrSize}
+	case files > l.Files:
+		return &Violation{Archive: filepath.Base(name), Err: ErrFiles}
+	case size > ratioSize && src > 0 && size/src > l.Ratio:
+		return &Violation{Archive: filepath.Base(name), Err: ErrRatio}
+	}
+	return nil
+}
+
+// guard runs the extract func to unpack the named archive of the src size in bytes into a staging
+// directory within dest. The extract func must stop when the extracted files break the lim limit.
+// The staged files are inspected and if they break the limits, they are removed and
+// a Violation error is returned, otherwise the files are moved into the dest directory.
+func (l Limits) guard(name string, src int64, dest string, extract func(dir string, lim *dosarc.Limit) error) error {
+	if err := os.MkdirAll(dest, dirMode); err != nil {
+		return fmt.Errorf("guard mkdir: %w", err)
+	}
+	dir, err := os.MkdirTemp(dest, ".guard-")
+	if err != nil {
+		return fmt.Errorf("guard tempdir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	errs := extract(dir, l.limit(src))
+	if err := l.violation(name, src, errs); err != nil {
+		return err
+	}
+	if err := l.Inspect(name, dir, src); err != nil {
+		return err
+	}
+	if err := move(dir, dest); err != nil {
+		return err
+	}
+	return errs
+}
+
+// size returns the size in bytes of the named file, or 0 when it cannot be read.
+func size(name string) int64 {
+	st, err := os.Stat(name)
+	if err != nil {
+		return 0
+	}
+	return st.Size()
+}
+
+// limit returns the size and file count limits of the files extracted from an archive of the src size in bytes.
+func (l Limits) limit(src int64) *dosarc.Limit {
+	l = l.defaults()
+	size := l.Size
+	if ratio := src * l.Ratio; src > 0 && ratio < size {
+		size = min(size, max(ratio, ratioSize))
+	}
+	return &dosarc.Limit{Size: size, Files: l.Files}
+}
+
+// violation returns a Violation error when the err of an extraction from an archive
+// of the src size in bytes was caused by breaking the limits.
+func (l Limits) violation(name string, src int64, err error) error {
+	l = l.defaults()
+	var v *Violation
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &v):
+		return v
+	case errors.Is(err, dosarc.ErrFiles):
+		return &Violation{Archive: name, Err: ErrFiles}
+	case errors.Is(err, dosarc.ErrSize):
+		if src > 0 && max(src*l.Ratio, ratioSize) < l.Size {
+			return &Violation{Archive: name, Err: ErrRatio}
+		}
+		return &Violation{Archive: name, Err: ErrSize}
+	}
+	return nil
+}
+
+// unarchiveLimit decompresses the src archive into the dir directory in the same manner as unarchiver,
+// but stops when the extracted files break the lim limit. Formats that cannot be read natively or walked
+// are refused, as they cannot be extracted within the limit.
+func unarchiveLimit(src, dir, name string, lim *dosarc.Limit) error {
+	if ok, err := dosExtract(src, name, dir, lim); ok {
+		if err != nil {
+			return fmt.Errorf("unarchiver: %w", err)
+		}
+		return nil
+	}
+	ok, err := walkExtract(src, name, dir, "", lim)
+	if !ok {
+		return fmt.Errorf("unarchiver %s: %w", filepath.Base(name), ErrBounds)
+	}
+	return err
+}
+
+// extractLimit extracts the target file from the src archive into the dir directory in the same manner
+// as extractor, but stops when the extracted files break the lim limit. ZIP archives that cannot be walked,
+// such as those using the legacy compression methods, are extracted by the unzip program within a quota of
+// the lim limit. Other formats that cannot be read natively or walked are refused.
+func extractLimit(name, src, target, dir string, lim *dosarc.Limit) error {
+	if ok, err := dosExtract(src, name, dir, lim, target); ok {
+		if err != nil {
+			return fmt.Errorf("extractor: %w", err)
+		}
+		return nil
+	}
+	ok, err := walkExtract(src, name, dir, target, lim)
+	if ok && (err == nil || limited(err)) {
+		return err
+	}
+	if strings.ToLower(filepath.Ext(name)) == zipx {
+		return quota(dir, lim, func(ctx context.Context) error {
+			return sys.ZipExtractContext(ctx, src, target, dir)
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("extractor: %w", err)
+	}
+	return fmt.Errorf("extractor %s: %w", filepath.Base(name), ErrBounds)
+}
+
+// quota runs the extract func of a system program and cancels the program as soon as
+// the files within the dir directory break the lim limit.
+func quota(dir string, lim *dosarc.Limit, extract func(ctx context.Context) error) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- extract(ctx)
+	}()
+	tick := time.NewTicker(quotaPoll)
+	defer tick.Stop()
+	for {
+		select {
+		case err := <-done:
+			if qerr := usage(dir, lim); qerr != nil {
+				return qerr
+			}
+			return err
+		case <-tick.C:
+			if err := usage(dir, lim); err != nil {
+				cancel()
+				<-done
+				return err
+			}
+		}
+	}
+}
+
+// usage returns dosarc.ErrSize or dosarc.ErrFiles when the files within the dir directory break the lim limit.
+func usage(dir string, lim *dosarc.Limit) error {
+	if lim == nil {
+		return nil
+	}
+	var size int64
+	files := 0
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == dir {
+			return nil //nolint:nilerr
+		}
+		files++
+		if lim.Files > 0 && files > lim.Files {
+			return dosarc.ErrFiles
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		if lim.Size > 0 && size > lim.Size {
+			return dosarc.ErrSize
+		}
+		return nil
+	})
+}
+
+// limited returns true when the err was caused by breaking the limits.
+func limited(err error) bool {
+	var v *Violation
+	return errors.As(err, &v) || errors.Is(err, dosarc.ErrSize) || errors.Is(err, dosarc.ErrFiles)
+}
+
+// dosExtract extracts the targets from the src ARC, ARJ, LHA or ZOO archive into the dir directory,
+// stopping when the extracted files break the lim limit. It returns false for the other archive formats.
+func dosExtract(src, name, dir string, lim *dosarc.Limit, targets ...string) (bool, error) {
+	var (
+		format  string
+		entries []dosarc.Entry
+		c       io.Closer
+	)
+	switch strings.ToLower(filepath.Ext(name)) {
+	case arjx:
+		z, err := arj.OpenReader(src)
+		if err != nil {
+			return true, err
+		}
+		format, entries, c = "arj", z.Entries(), z
+	case lhax, lzhx:
+		z, err := lha.OpenReader(src)
+		if err != nil {
+			return true, err
+		}
+		format, entries, c = "lha", z.Entries(), z
+	case arcx, arkx, pakx:
+		z, err := sea.OpenReader(src)
+		if err != nil {
+			return true, err
+		}
+		format, entries, c = "arc", z.Entries(), z
+	case zoox:
+		z, err := zoo.OpenReader(src)
+		if err != nil {
+			return true, err
+		}
+		format, entries, c = "zoo", z.Entries(), z
+	default:
+		return false, nil
+	}
+	defer c.Close()
+	return true, dosarc.ExtractLimit(format, dir, entries, lim, targets...)
+}
+
+// walkExtract extracts the target, or every file when the target is empty, from the src archive
+// into the dir directory, stopping when the extracted files break the lim limit.
+// It returns false when the archive format cannot be walked or the target was not found.
+func walkExtract(src, name, dir, target string, lim *dosarc.Limit) (bool, error) {
+	a, err := archiver.ByExtension(strings.ToLower(name))
+	if err != nil {
+		return false, nil //nolint:nilerr
+	}
+	if _, ok := a.(archiver.Walker); !ok {
+		return false, nil
+	}
+	raw := target
+	if target != "" {
+		raw = rawName(src, name, target)
+	}
+	found := target == ""
+	// the error is kept as the mholt walkers do not wrap the returned errors
+	var werr error
+	err = arc.Walkr(src, name, func(f archiver.File) error {
+		e := entry{name: f.Name()}
+		switch h := f.Header.(type) {
+		case zip.FileHeader:
+			e.name = h.Name
+		case *tar.Header:
+			e.name = h.Name
+		case *rardecode.FileHeader:
+			e.name = h.Name
+		}
+		if target != "" {
+			if e.name != target && e.name != raw {
+				return nil
+			}
+			e.name = target
+		}
+		found = true
+		if !f.IsDir() && !f.Mode().IsRegular() {
+			werr = &Violation{Archive: filepath.Base(name), Entry: e.name, Err: ErrLink}
+			return werr
+		}
+		werr = dosarc.Entry{
+			Name: e.name, Modified: f.ModTime(), Dir: f.IsDir(),
+			Open: func() (io.ReadCloser, error) { return io.NopCloser(f), nil },
+		}.Write(dir, lim)
+		return werr
+	})
+	if werr != nil {
+		return true, werr
+	}
+	if err != nil {
+		return true, fmt.Errorf("walk extract: %w", err)
+	}
+	return found, nil
+}
+
+// Inspect the files extracted into the dir directory from an archive of the src size in bytes.
+// A Violation error is returned when the files break the limits or when any file is a link,
+// device, or other special file. name is the archive filename used by the error.
+func (l Limits) Inspect(name, dir string, src int64) error {
+	l = l.defaults()
+	var size int64
+	files := 0
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == dir {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !d.IsDir() && !d.Type().IsRegular() {
+			return &Violation{Archive: name, Entry: rel, Err: ErrLink}
+		}
+		if strings.Count(rel, "/")+1 > l.Depth {
+			return &Violation{Archive: name, Entry: rel, Err: ErrDepth}
+		}
+		files++
+		if !d.IsDir() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return l.exceeds(name, size, src, files)
+	})
+}
+
+// move the files and directories within the src directory into the dest directory.
+func move(src, dest string) error {
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == src {
+			return nil
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+		if d.IsDir() {
+			if err := os.MkdirAll(target, dirMode); err != nil {
+				return fmt.Errorf("guard move: %w", err)
+			}
+			return nil
+		}
+		if err := os.Rename(p, target); err != nil {
+			return fmt.Errorf("guard move: %w", err)
+		}
+		return nil
+	})
+}
+
+// entry is a file header within an archive.
+type entry struct {
+	name string
+	size int64
+	link bool
+}
+
+// entries calls fn for each file header within the src archive.
+func entries(src, name string, fn func(entry) error) error {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case arjx:
+		z, err := arj.OpenReader(src)
+		if err != nil {
+			return err
+		}
+		defer z.Close()
+		for _, f := range z.File {
+			if err := fn(entry{name: f.Name, size: f.Size}); err != nil {
+				return err
+			}
+		}
+		return nil
+	case lhax, lzhx:
+		z, err := lha.OpenReader(src)
+		if err != nil {
+			return err
+		}
+		defer z.Close()
+		for _, f := range z.File {
+			if err := fn(entry{name: f.Name, size: f.Size}); err != nil {
+				return err
+			}
+		}
+		return nil
+	case arcx, arkx, pakx:
+		z, err := sea.OpenReader(src)
+		if err != nil {
+			return err
+		}
+		defer z.Close()
+		for _, f := range z.File {
+			if err := fn(entry{name: f.Name, size: f.Size}); err != nil {
+				return err
+			}
+		}
+		return nil
+	case zoox:
+		z, err := zoo.OpenReader(src)
+		if err != nil {
+			return err
+		}
+		defer z.Close()
+		for _, f := range z.File {
+			if err := fn(entry{name: f.Name, size: f.Size}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return arc.Walkr(src, name, func(f archiver.File) error {
+		e := entry{name: f.Name(), size: f.Size(), link: f.Mode()&(fs.ModeSymlink|fs.ModeDevice|fs.ModeNamedPipe) != 0}
+		switch h := f.Header.(type) {
+		case zip.FileHeader:
+			e.name = h.Name
+		case *tar.Header:
+			e.name = h.Name
+			e.link = e.link || h.Typeflag == tar.TypeLink || h.Typeflag == tar.TypeSymlink
+		case *rardecode.FileHeader:
+			e.name = h.Name
+		}
+		return fn(e)
+	})
+}
+
+// Flag marks the file record of the uuid as unsafe when the err is a Violation.
+// The violation is saved to the security violation column of the record which also blocks its download.
+// Records that already have a security violation are left unchanged.
+func Flag(db *sql.DB, w io.Writer, uuid string, err error) error {
+	if db == nil {
+		return database.ErrDB
+	}
+	if w == nil {
+		w = io.Discard
+	}
+	var v *Violation
+	if !errors.As(err, &v) {
+		return nil
+	}
+	count, err := database.UpdateFiles(db, map[string]any{"file_security_violation": truncate(v.Error(), alertLen)},
+		qm.Where("uuid = ?", uuid),
+		qm.Where("(file_security_violation IS NULL OR file_security_violation = '')"))
+	if err != nil {
+		return fmt.Errorf("flag: %w", err)
+	}
+	if count > 0 {
+		fmt.Fprintf(w, " flagged as unsafe: %s", v.Err)
+	}
+	return nil
+}
+
+// truncate returns s cut to a length of at most n bytes, without splitting a UTF-8 encoded rune.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,157 @@
+package archive_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Defacto2/df2/pkg/archive"
+	"github.com/Defacto2/df2/pkg/database"
+	"github.com/stretchr/testify/assert"
+)
+
+// mkzip creates a zip archive in the dir directory containing the named files.
+func mkzip(t *testing.T, dir string, files map[string][]byte) string {
+	t.Helper()
+	name := filepath.Join(dir, "test.zip")
+	f, err := os.Create(name)
+	assert.Nil(t, err)
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for n, b := range files {
+		w, err := zw.Create(n)
+		assert.Nil(t, err)
+		_, err = w.Write(b)
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, zw.Close())
+	return name
+}
+
+func TestLimits_Names(t *testing.T) {
+	t.Parallel()
+	l := archive.Limited()
+	assert.Nil(t, l.Names("x.zip", "", "readme.nfo", "dir/file.txt", "./a/../b.txt"))
+	tests := []struct {
+		name string
+		want error
+	}{
+		{"/etc/passwd", archive.ErrAbsolute},
+		{"C:\\AUTOEXEC.BAT", archive.ErrAbsolute},
+		{"../evil.txt", archive.ErrTraverse},
+		{"dir\\..\\..\\evil.txt", archive.ErrTraverse},
+		{strings.Repeat("d/", 40) + "deep.txt", archive.ErrDepth},
+	}
+	for _, tt := range tests {
+		err := l.Names("x.zip", tt.name)
+		assert.ErrorIs(t, err, tt.want, tt.name)
+		assert.ErrorIs(t, err, archive.ErrUnsafe, tt.name)
+		var v *archive.Violation
+		assert.True(t, errors.As(err, &v))
+		assert.Equal(t, "x.zip", v.Archive)
+	}
+}
+
+func TestUnarchiver_Traverse(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	src := mkzip(t, dir, map[string][]byte{"../evil.txt": []byte("evil")})
+	dest := filepath.Join(dir, "dest")
+	err := archive.Unarchiver(src, dest, "test.zip")
+	assert.ErrorIs(t, err, archive.ErrTraverse)
+	_, err = os.Stat(filepath.Join(dir, "evil.txt"))
+	assert.NotNil(t, err)
+}
+
+func TestUnarchiver_Link(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	assert.Nil(t, tw.WriteHeader(&tar.Header{Name: "etc", Typeflag: tar.TypeSymlink, Linkname: "/etc"}))
+	assert.Nil(t, tw.Close())
+	src := filepath.Join(dir, "test.tar")
+	assert.Nil(t, os.WriteFile(src, buf.Bytes(), 0o644))
+	dest := filepath.Join(dir, "dest")
+	err := archive.Unarchiver(src, dest, "test.tar")
+	assert.ErrorIs(t, err, archive.ErrLink)
+	_, err = os.Lstat(filepath.Join(dest, "etc"))
+	assert.NotNil(t, err)
+}
+
+func TestUnarchiver_Limits(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	src := mkzip(t, dir, map[string][]byte{
+		"a.txt": []byte("a"), "b.txt": []byte("b"), "c.txt": []byte("c"),
+	})
+	dest := filepath.Join(dir, "dest")
+	err := archive.Limits{Files: 2}.Unarchive(src, dest, "test.zip")
+	assert.ErrorIs(t, err, archive.ErrFiles)
+	err = archive.Limits{Size: 2}.Unarchive(src, dest, "test.zip")
+	assert.ErrorIs(t, err, archive.ErrSize)
+	err = archive.Limits{}.Unarchive(src, dest, "test.zip")
+	assert.Nil(t, err)
+	b, err := os.ReadFile(filepath.Join(dest, "c.txt"))
+	assert.Nil(t, err)
+	assert.Equal(t, "c", string(b))
+
+	bomb := t.TempDir()
+	src = mkzip(t, bomb, map[string][]byte{"zeros.bin": make([]byte, 20<<20)})
+	err = archive.Unarchiver(src, filepath.Join(bomb, "dest"), "test.zip")
+	assert.ErrorIs(t, err, archive.ErrRatio)
+}
+
+func TestLimits_Extract_Unzip(t *testing.T) {
+	t.Parallel()
+	if _, err := exec.LookPath("unzip"); err != nil {
+		t.Skip("unzip is not installed")
+	}
+	// the bzip2 method cannot be walked and the header of ZEROS.BIN understates its 20 MB size
+	src := testDir(filepath.Join("bomb", "BZIP2.ZIP"))
+	dest := t.TempDir()
+	err := archive.Extractor("BZIP2.ZIP", src, "README.TXT", dest)
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(dest, "README.TXT"))
+	assert.Nil(t, err)
+
+	dest = t.TempDir()
+	err = archive.Extractor("BZIP2.ZIP", src, "ZEROS.BIN", dest)
+	assert.ErrorIs(t, err, archive.ErrRatio)
+	entries, err := os.ReadDir(dest)
+	assert.Nil(t, err)
+	assert.Empty(t, entries)
+
+	err = archive.Unarchiver(src, t.TempDir(), "BZIP2.ZIP")
+	assert.NotNil(t, err)
+}
+
+func TestLimits_Inspect(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	err := os.Mkdir(filepath.Join(dir, "docs"), 0o755)
+	assert.Nil(t, err)
+	err = os.WriteFile(filepath.Join(dir, "docs", "file.txt"), []byte("x"), 0o644)
+	assert.Nil(t, err)
+	err = archive.Limited().Inspect("test.zip", dir, 1)
+	assert.Nil(t, err)
+	err = archive.Limits{Files: 1}.Inspect("test.zip", dir, 1)
+	assert.ErrorIs(t, err, archive.ErrFiles)
+
+	err = os.Symlink("/etc/passwd", filepath.Join(dir, "passwd"))
+	assert.Nil(t, err)
+	err = archive.Limited().Inspect("test.zip", dir, 1)
+	assert.ErrorIs(t, err, archive.ErrLink)
+}
+
+func TestFlag(t *testing.T) {
+	t.Parallel()
+	err := archive.Flag(nil, nil, "", archive.ErrTraverse)
+	assert.ErrorIs(t, err, database.ErrDB)
+}
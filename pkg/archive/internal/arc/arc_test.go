@@ -72,7 +72,7 @@ func TestMagicExt(t *testing.T) {
 	}{
 		{"empty", "", "", true},
 		{"invalid rar", testDir("demozoo/test.invalid.ext.rar"), ".zip", false},
-		{"7zip", testDir("demozoo/test.7z"), ".7z", false},
+		{"7zip", testDir("demozoo/test.7z"), "", true},
 		{"bz2", testDir("demozoo/test.tar.bz2"), ".tar.bz2", false},
 		{"gz", testDir("demozoo/test.tar.gz"), ".tar.gz", false},
 		{"tar", testDir("demozoo/test.tar"), ".tar", false},
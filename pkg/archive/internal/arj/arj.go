@@ -0,0 +1,311 @@
+// Package arj reads and extracts ARJ archives, the format created by Robert Jung
+// for the ARJ archiver on MS-DOS.
+// Files that are stored or compressed with the methods 1 to 4 are supported,
+// but not password protected (garbled) files.
+package arj
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Defacto2/df2/pkg/archive/internal/dosarc"
+	"github.com/Defacto2/df2/pkg/archive/internal/lzh"
+)
+
+var (
+	ErrChecksum = errors.New("arj checksum error")
+	ErrFormat   = errors.New("not a valid arj archive")
+	ErrGarbled  = errors.New("arj file is password protected")
+	ErrHeader   = errors.New("arj header is corrupt")
+	ErrMethod   = errors.New("arj compression method is not supported")
+)
+
+const (
+	id0       = 0x60 // id0 is the first byte of the header id.
+	id1       = 0xea // id1 is the second byte of the header id.
+	maxHeader = 2600 // maxHeader is the largest size of a basic header.
+	fixedSize = 30   // fixedSize is the length of the fixed fields of a basic header.
+)
+
+// Compression methods.
+const (
+	Stored = iota // Stored without any compression.
+	Method1
+	Method2
+	Method3
+	Method4
+)
+
+// File types.
+const (
+	Binary    = 0 // Binary file.
+	Text      = 1 // Text file converted to 7-bits.
+	Comment   = 2 // Comment header of the archive.
+	Directory = 3 // Directory.
+	Label     = 4 // Volume label.
+)
+
+// Header flags.
+const (
+	FlagGarbled = 0x01 // FlagGarbled is set on password protected files.
+	FlagVolume  = 0x04 // FlagVolume is set on files continued in the next volume.
+	FlagExtFile = 0x08 // FlagExtFile is set on files continued from the previous volume.
+	FlagPathSym = 0x10 // FlagPathSym is set when the path separators were translated.
+)
+
+// File is a file or directory stored in an ARJ archive.
+type File struct {
+	Name           string    // Name of the file using forward slash path separators.
+	Comment        string    // Comment of the file.
+	RawComment     []byte    // RawComment is the comment using its legacy character set.
+	Method         int       // Method of compression.
+	Type           int       // Type of the file.
+	Flags          int       // Flags of the header.
+	HostOS         int       // HostOS is the operating system used to create the archive.
+	Modified       time.Time // Modified is the last modification time of the file.
+	CompressedSize int64     // CompressedSize of the file data.
+	Size           int64     // Size of the uncompressed file.
+	CRC32          uint32    // CRC32 checksum of the uncompressed file.
+	Position       int64     // Position of the file data when it is continued from the previous volume.
+	r              io.ReaderAt
+	offset         int64 // offset of the file data.
+}
+
+// IsDir returns true when the file is a directory.
+func (f *File) IsDir() bool {
+	return f.Type == Directory
+}
+
+// Open returns a reader of the uncompressed file content.
+// The checksum of the content is validated when the end of the file is read.
+func (f *File) Open() (io.ReadCloser, error) {
+	if f.Flags&FlagGarbled != 0 {
+		return nil, fmt.Errorf("%w: %s", ErrGarbled, f.Name)
+	}
+	data := io.NewSectionReader(f.r, f.offset, f.CompressedSize)
+	var r io.Reader
+	switch f.Method {
+	case Stored:
+		r = data
+	case Method1, Method2, Method3:
+		r = lzh.NewReader(data, f.Size, lzh.ARJ)
+	case Method4:
+		r = newFastest(data, f.Size)
+	default:
+		return nil, fmt.Errorf("%w: %d %s", ErrMethod, f.Method, f.Name)
+	}
+	return &checksum{
+		r:    io.LimitReader(r, f.Size),
+		hash: crc32.NewIEEE(),
+		want: f.CRC32,
+		size: f.Size,
+	}, nil
+}
+
+// checksum validates the CRC32 and size of a file when the end is read.
+type checksum struct {
+	r    io.Reader
+	hash hash.Hash32
+	want uint32
+	size int64
+	read int64
+}
+
+func (c *checksum) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.hash.Write(p[:n])
+	c.read += int64(n)
+	if errors.Is(err, io.EOF) {
+		if c.read != c.size || c.hash.Sum32() != c.want {
+			return n, ErrChecksum
+		}
+	}
+	return n, err //nolint:wrapcheck
+}
+
+func (c *checksum) Close() error {
+	return nil
+}
+
+// Reader is an ARJ archive.
+type Reader struct {
+	Name       string  // Name of the archive when it was created.
+	Comment    string  // Comment of the archive.
+	RawComment []byte  // RawComment is the comment of the archive using its legacy character set.
+	File       []*File // File lists the files and directories in the archive.
+}
+
+// NewReader returns a Reader of the ARJ archive read from r, which is size bytes long.
+// Any self-extracting program that precedes the archive is skipped.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	off, err := find(r, size)
+	if err != nil {
+		return nil, err
+	}
+	main, off, err := header(r, off)
+	if err != nil {
+		return nil, err
+	}
+	if main == nil {
+		return nil, ErrFormat
+	}
+	z := &Reader{}
+	z.Name, z.RawComment = names(main)
+	z.Comment = dosarc.Decode(z.RawComment)
+	for {
+		basic, next, err := header(r, off)
+		if err != nil {
+			return nil, err
+		}
+		if basic == nil {
+			return z, nil
+		}
+		f := parse(basic)
+		f.r, f.offset = r, next
+		if f.offset+f.CompressedSize > size {
+			return nil, fmt.Errorf("%w: %s data is truncated", ErrHeader, f.Name)
+		}
+		z.File = append(z.File, f)
+		off = next + f.CompressedSize
+	}
+}
+
+// ReadCloser is an ARJ archive file that must be closed after use.
+type ReadCloser struct {
+	Reader
+	f *os.File
+}
+
+// OpenReader opens the named ARJ archive file.
+func OpenReader(name string) (*ReadCloser, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("arj open: %w", err)
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("arj stat: %w", err)
+	}
+	r, err := NewReader(f, st.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &ReadCloser{Reader: *r, f: f}, nil
+}
+
+// Close the archive file.
+func (rc *ReadCloser) Close() error {
+	return rc.f.Close()
+}
+
+// find returns the offset of the main header, which is the first valid header of the archive.
+func find(r io.ReaderAt, size int64) (int64, error) {
+	const chunk = 32 * 1024
+	buf := make([]byte, chunk+1)
+	for base := int64(0); base < size; base += chunk {
+		n, err := r.ReadAt(buf, base)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return 0, fmt.Errorf("arj find: %w", err)
+		}
+		for i := 0; i+1 < n; i++ {
+			if buf[i] != id0 || buf[i+1] != id1 {
+				continue
+			}
+			if basic, _, err := header(r, base+int64(i)); err == nil && basic != nil {
+				return base + int64(i), nil
+			}
+		}
+	}
+	return 0, ErrFormat
+}
+
+// header reads the header at the offset and returns the basic header
+// and the offset that follows the header.
+// A nil basic header is returned for the end of archive header.
+func header(r io.ReaderAt, off int64) ([]byte, int64, error) {
+	var id [4]byte
+	if _, err := r.ReadAt(id[:], off); err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", ErrHeader, err)
+	}
+	if id[0] != id0 || id[1] != id1 {
+		return nil, 0, ErrHeader
+	}
+	size := int64(binary.LittleEndian.Uint16(id[2:]))
+	off += int64(len(id))
+	if size == 0 {
+		return nil, off, nil
+	}
+	if size > maxHeader || size < fixedSize {
+		return nil, 0, ErrHeader
+	}
+	basic := make([]byte, size+4)
+	if _, err := r.ReadAt(basic, off); err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", ErrHeader, err)
+	}
+	crc := binary.LittleEndian.Uint32(basic[size:])
+	basic = basic[:size]
+	if crc32.ChecksumIEEE(basic) != crc {
+		return nil, 0, fmt.Errorf("%w: %w", ErrHeader, ErrChecksum)
+	}
+	if int(basic[0]) > len(basic) {
+		return nil, 0, ErrHeader
+	}
+	off += size + 4
+	// skip any extended headers
+	for {
+		var ext [2]byte
+		if _, err := r.ReadAt(ext[:], off); err != nil {
+			return nil, 0, fmt.Errorf("%w: %w", ErrHeader, err)
+		}
+		off += int64(len(ext))
+		n := int64(binary.LittleEndian.Uint16(ext[:]))
+		if n == 0 {
+			return basic, off, nil
+		}
+		off += n + 4
+	}
+}
+
+// parse the basic header of a file.
+func parse(basic []byte) *File {
+	le := binary.LittleEndian
+	f := &File{
+		HostOS:         int(basic[3]),
+		Flags:          int(basic[4]),
+		Method:         int(basic[5]),
+		Type:           int(basic[6]),
+		Modified:       dosarc.MSDOS(le.Uint16(basic[10:]), le.Uint16(basic[8:])),
+		CompressedSize: int64(le.Uint32(basic[12:])),
+		Size:           int64(le.Uint32(basic[16:])),
+		CRC32:          le.Uint32(basic[20:]),
+	}
+	if f.Flags&FlagExtFile != 0 && int(basic[0]) >= fixedSize+4 {
+		f.Position = int64(le.Uint32(basic[fixedSize:]))
+	}
+	f.Name, f.RawComment = names(basic)
+	f.Comment = dosarc.Decode(f.RawComment)
+	return f
+}
+
+// names returns the null terminated filename and the raw comment that follow the first header.
+func names(basic []byte) (string, []byte) {
+	s := basic[basic[0]:]
+	name, cmmt := s, []byte{}
+	if i := bytes.IndexByte(s, 0); i >= 0 {
+		name, cmmt = s[:i], s[i+1:]
+	}
+	if i := bytes.IndexByte(cmmt, 0); i >= 0 {
+		cmmt = cmmt[:i]
+	}
+	return strings.ReplaceAll(dosarc.Decode(name), "\\", "/"), bytes.Clone(cmmt)
+}
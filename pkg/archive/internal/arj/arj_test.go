@@ -0,0 +1,150 @@
+package arj_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Defacto2/df2/pkg/archive/internal/arj"
+	"github.com/stretchr/testify/assert"
+)
+
+func testDir(name string) string {
+	dir, _ := os.Getwd()
+	return filepath.Join(dir, "..", "..", "..", "..", "testdata", name)
+}
+
+const hello = "Hello world.\r\n"
+
+func TestOpenReader(t *testing.T) {
+	t.Parallel()
+	_, err := arj.OpenReader("")
+	assert.NotNil(t, err)
+	_, err = arj.OpenReader(testDir("demozoo/test.zip"))
+	assert.ErrorIs(t, err, arj.ErrFormat)
+
+	z, err := arj.OpenReader(testDir("arj/methods.arj"))
+	assert.Nil(t, err)
+	defer z.Close()
+	assert.Equal(t, "METHODS.ARJ", z.Name)
+	assert.Equal(t, "ARJ fixture for methods 0 to 4.", z.Comment)
+	assert.Equal(t, []byte("ARJ fixture for methods 0 to 4."), z.RawComment)
+	assert.Len(t, z.File, 9)
+	methods := []int{arj.Stored, arj.Method1, arj.Method2, arj.Method3, arj.Method4}
+	for i, m := range methods {
+		assert.Equal(t, m, z.File[i].Method)
+	}
+	assert.True(t, z.File[5].IsDir())
+	assert.Equal(t, "DOCS/README.NFO", z.File[6].Name)
+	assert.Equal(t, time.Date(1994, 6, 15, 12, 30, 20, 0, time.UTC), z.File[0].Modified)
+}
+
+func TestFile_Open(t *testing.T) {
+	t.Parallel()
+	z, err := arj.OpenReader(testDir("arj/methods.arj"))
+	assert.Nil(t, err)
+	defer z.Close()
+	for _, f := range z.File {
+		r, err := f.Open()
+		assert.Nil(t, err, f.Name)
+		b, err := io.ReadAll(r)
+		assert.Nil(t, err, f.Name)
+		assert.Equal(t, f.Size, int64(len(b)), f.Name)
+		switch f.Name {
+		case "STORED.TXT", "METHOD1.TXT", "METHOD4.TXT":
+			assert.True(t, strings.HasPrefix(string(b), hello), f.Name)
+		case "DOCS/README.NFO", "DOCS/LONG4.NFO":
+			assert.Contains(t, string(b), "00699 The quick brown fox jumps over the lazy dog, line 33.")
+		}
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	t.Parallel()
+	b, err := os.ReadFile(testDir("arj/hello.arj"))
+	assert.Nil(t, err)
+	_, err = arj.NewReader(bytes.NewReader(b[:20]), 20)
+	assert.ErrorIs(t, err, arj.ErrFormat)
+
+	// a self-extracting program stub is skipped
+	sfx := append([]byte("MZ program stub"), b...)
+	z, err := arj.NewReader(bytes.NewReader(sfx), int64(len(sfx)))
+	assert.Nil(t, err)
+	assert.Len(t, z.File, 1)
+
+	// corrupt the compressed data
+	bad := bytes.Clone(b)
+	bad[len(bad)-10] ^= 0xff
+	z, err = arj.NewReader(bytes.NewReader(bad), int64(len(bad)))
+	assert.Nil(t, err)
+	r, err := z.File[0].Open()
+	assert.Nil(t, err)
+	_, err = io.ReadAll(r)
+	assert.NotNil(t, err)
+}
+
+func TestList(t *testing.T) {
+	t.Parallel()
+	files, err := arj.List(testDir("demozoo/test.arj"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"test.png", "test.txt"}, files)
+	files, err = arj.List(testDir("arj/methods.arj"))
+	assert.Nil(t, err)
+	assert.Len(t, files, 8)
+}
+
+func TestExtract(t *testing.T) {
+	t.Parallel()
+	src := testDir("arj/methods.arj")
+	err := arj.Extract(src, "")
+	assert.ErrorIs(t, err, arj.ErrDest)
+
+	dir := t.TempDir()
+	err = arj.Extract(src, dir)
+	assert.Nil(t, err)
+	b, err := os.ReadFile(filepath.Join(dir, "DOCS", "LONG4.NFO"))
+	assert.Nil(t, err)
+	assert.Len(t, b, 42510)
+	st, err := os.Stat(filepath.Join(dir, "EMPTY.TXT"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), st.Size())
+
+	dir = t.TempDir()
+	err = arj.Extract(src, dir, "method2.ans")
+	assert.Nil(t, err)
+	b, err = os.ReadFile(filepath.Join(dir, "METHOD2.ANS"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(b), "DEFACTO2")
+	_, err = os.Stat(filepath.Join(dir, "STORED.TXT"))
+	assert.NotNil(t, err)
+
+	err = arj.Extract(src, dir, "nothing.txt")
+	assert.ErrorIs(t, err, arj.ErrTarget)
+}
+
+func TestExtractVolumes(t *testing.T) {
+	t.Parallel()
+	srcs := []string{
+		testDir("volumes/SPLIT.ARJ"),
+		testDir("volumes/SPLIT.A01"),
+		testDir("volumes/SPLIT.A02"),
+	}
+	files, err := arj.ListVolumes(srcs...)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"SPLIT.NFO", "LONG.TXT", "HELLO.TXT"}, files)
+
+	dir := t.TempDir()
+	err = arj.ExtractVolumes(dir, srcs)
+	assert.Nil(t, err)
+	b, err := os.ReadFile(filepath.Join(dir, "LONG.TXT"))
+	assert.Nil(t, err)
+	assert.Len(t, b, 42510)
+
+	// the continued file cannot be extracted without the previous volume
+	err = arj.ExtractVolumes(t.TempDir(), srcs[1:])
+	assert.NotNil(t, err)
+}
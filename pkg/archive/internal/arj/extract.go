@@ -0,0 +1,128 @@
+package arj
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Defacto2/df2/pkg/archive/internal/dosarc"
+)
+
+var (
+	ErrDest   = dosarc.ErrDest
+	ErrPath   = dosarc.ErrPath
+	ErrTarget = dosarc.ErrTarget
+)
+
+// List returns the names of the files in the src ARJ archive, excluding any directories.
+func List(src string) ([]string, error) {
+	z, err := OpenReader(src)
+	if err != nil {
+		return nil, err
+	}
+	defer z.Close()
+	return dosarc.List(z.Entries()), nil
+}
+
+// ListVolumes returns the names of the files in the src volumes of a multi-volume ARJ archive,
+// excluding any directories. A file that is continued across the volumes is only listed once.
+func ListVolumes(srcs ...string) ([]string, error) {
+	files := []string{}
+	for _, src := range srcs {
+		z, err := OpenReader(src)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range z.File {
+			if f.IsDir() || f.Type == Label || f.Flags&FlagExtFile != 0 {
+				continue
+			}
+			files = append(files, f.Name)
+		}
+		z.Close()
+	}
+	return files, nil
+}
+
+// Extract the targets from the src ARJ archive to the dest directory.
+// The targets are matched against the file paths and names, ignoring case.
+// When no targets are given, or the target is "*", all the files are extracted.
+// The dest directory is created when it does not exist.
+func Extract(src, dest string, targets ...string) error {
+	return ExtractVolumes(dest, []string{src}, targets...)
+}
+
+// ExtractVolumes extracts the targets from the src volumes of a multi-volume ARJ archive
+// to the dest directory, in the same manner as Extract.
+// The volumes must be in order, starting with the .arj volume followed by the .a01, .a02 volumes.
+func ExtractVolumes(dest string, srcs []string, targets ...string) error {
+	return ExtractVolumesLimit(dest, srcs, nil, targets...)
+}
+
+// ExtractVolumesLimit extracts the targets from the src volumes in the same manner as ExtractVolumes,
+// but stops and returns dosarc.ErrSize or dosarc.ErrFiles when the extracted files break the lim limit.
+func ExtractVolumesLimit(dest string, srcs []string, lim *dosarc.Limit, targets ...string) error {
+	if err := dosarc.Dest("arj", dest); err != nil {
+		return err
+	}
+	found := false
+	for _, src := range srcs {
+		err := extract(src, dest, lim, targets...)
+		if errors.Is(err, ErrTarget) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("arj %w: %s", ErrTarget, strings.Join(targets, " "))
+	}
+	return nil
+}
+
+// extract the targets from the src archive.
+func extract(src, dest string, lim *dosarc.Limit, targets ...string) error {
+	z, err := OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer z.Close()
+	return dosarc.ExtractLimit("arj", dest, z.Entries(), lim, targets...)
+}
+
+// Entries returns the files and directories of the archive for extraction, excluding the volume label.
+func (z *Reader) Entries() []dosarc.Entry {
+	entries := make([]dosarc.Entry, 0, len(z.File))
+	for _, f := range z.File {
+		if f.Type == Label {
+			continue
+		}
+		f := f
+		entries = append(entries, dosarc.Entry{
+			Name: f.Name, Modified: f.Modified, Dir: f.IsDir(), Open: f.Open,
+			Create: func(name string) (*os.File, error) { return create(name, f) },
+		})
+	}
+	return entries
+}
+
+// create the named file, or open the existing file at the position of the data
+// when the file is continued from a previous volume.
+func create(name string, f *File) (*os.File, error) {
+	if f.Flags&FlagExtFile == 0 {
+		return os.Create(name)
+	}
+	w, err := os.OpenFile(name, os.O_WRONLY, 0)
+	if err != nil {
+		return nil, fmt.Errorf("%s is missing the previous volume: %w", f.Name, err)
+	}
+	if _, err := w.Seek(f.Position, io.SeekStart); err != nil {
+		w.Close()
+		return nil, err
+	}
+	return w, nil
+}
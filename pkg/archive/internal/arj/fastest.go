@@ -0,0 +1,94 @@
+package arj
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/Defacto2/df2/pkg/archive/internal/lzh"
+)
+
+const (
+	dicSize   = 26624 // dicSize is the size of the sliding dictionary.
+	threshold = 3     // threshold is the minimum match length.
+	startLen  = 0     // startLen is the bit width of the shortest length code.
+	stopLen   = 7     // stopLen is the bit width of the longest length code.
+	startPtr  = 9     // startPtr is the bit width of the shortest position code.
+	stopPtr   = 13    // stopPtr is the bit width of the longest position code.
+)
+
+// fastest decompresses the ARJ method 4, which uses LZSS with
+// variable length integer codes in place of huffman codes.
+type fastest struct {
+	bits   *lzh.Bits
+	window []byte
+	pos    int   // pos is the next write position in the window.
+	remain int64 // remain is the number of bytes left to decompress.
+	copy   int   // copy is the number of match bytes left to copy.
+	from   int   // from is the window position of the next match byte.
+}
+
+func newFastest(r io.Reader, size int64) *fastest {
+	return &fastest{
+		bits:   lzh.NewBits(bufio.NewReader(r)),
+		window: make([]byte, dicSize),
+		remain: size,
+	}
+}
+
+func (z *fastest) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if z.copy > 0 {
+			b := z.window[z.from]
+			z.from = (z.from + 1) % dicSize
+			z.copy--
+			z.put(b)
+			p[n] = b
+			n++
+			continue
+		}
+		if z.remain <= 0 {
+			return n, io.EOF
+		}
+		c := z.code(startLen, stopLen)
+		if c == 0 {
+			b := byte(z.bits.Read(8))
+			z.remain--
+			z.put(b)
+			p[n] = b
+			n++
+			continue
+		}
+		length := int64(c - 1 + threshold)
+		if length > z.remain {
+			length = z.remain
+		}
+		z.remain -= length
+		z.copy = int(length)
+		dist := z.code(startPtr, stopPtr)
+		z.from = ((z.pos-dist-1)%dicSize + dicSize) % dicSize
+	}
+	return n, nil
+}
+
+func (z *fastest) put(b byte) {
+	z.window[z.pos] = b
+	z.pos++
+	if z.pos >= dicSize {
+		z.pos = 0
+	}
+}
+
+// code reads a variable length integer, where a unary prefix of up to
+// stop - start one bits sets the bit width of the value that follows.
+func (z *fastest) code(start, stop uint) int {
+	plus, pwr, width := 0, 1<<start, start
+	for ; width < stop; width++ {
+		if z.bits.Read(1) == 0 {
+			break
+		}
+		plus += pwr
+		pwr <<= 1
+	}
+	return plus + int(z.bits.Read(width))
+}
@@ -0,0 +1,38 @@
+// Package crc16 implements the CRC-16/ARC checksum, which was used by the
+// ARC, LHA and ZOO archivers to validate the content of the archived files.
+package crc16
+
+const poly = 0xa001 // poly is the reversed polynomial x^16 + x^15 + x^2 + 1.
+
+// Size of a CRC-16 checksum in bytes.
+const Size = 2
+
+// Digest is a running CRC-16/ARC checksum.
+type Digest uint16
+
+// Write adds p to the running checksum, it never returns an error.
+func (d *Digest) Write(p []byte) (int, error) {
+	for _, b := range p {
+		*d ^= Digest(b)
+		for i := 0; i < 8; i++ {
+			if *d&1 != 0 {
+				*d = *d>>1 ^ poly
+				continue
+			}
+			*d >>= 1
+		}
+	}
+	return len(p), nil
+}
+
+// Sum16 returns the checksum.
+func (d Digest) Sum16() uint16 {
+	return uint16(d)
+}
+
+// Checksum returns the CRC-16/ARC checksum of the data.
+func Checksum(data []byte) uint16 {
+	var d Digest
+	d.Write(data)
+	return d.Sum16()
+}
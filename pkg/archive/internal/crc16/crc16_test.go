@@ -0,0 +1,19 @@
+package crc16_test
+
+import (
+	"testing"
+
+	"github.com/Defacto2/df2/pkg/archive/internal/crc16"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChecksum(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, uint16(0), crc16.Checksum(nil))
+	assert.Equal(t, uint16(0xbb3d), crc16.Checksum([]byte("123456789")))
+
+	var d crc16.Digest
+	d.Write([]byte("1234"))
+	d.Write([]byte("56789"))
+	assert.Equal(t, uint16(0xbb3d), d.Sum16())
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -84,6 +85,7 @@ func (f Finds) Top() string {
 }
 
 // DOS attempts to discover a software package starting executable from a collection of files.
+// The names of the files can be paths within nested archives.
 func DOS(w io.Writer, name string, files content.Contents, varNames *[]string) string {
 	if w == nil {
 		w = io.Discard
@@ -94,7 +96,7 @@ func DOS(w io.Writer, name string, files content.Contents, varNames *[]string) s
 			continue
 		}
 		base := strings.TrimSuffix(name, filepath.Ext(name)) // base filename without extension
-		fn := strings.ToLower(file.Name)                     // normalise filenames
+		fn := strings.ToLower(path.Base(file.Name))          // normalise filenames
 		ext := strings.ToLower(file.Ext)                     // normalise file extensions
 		e := findVariant(fn, exe, varNames)
 		c := findVariant(fn, com, varNames)
@@ -146,6 +148,7 @@ func MoveText(w io.Writer, cfg conf.Config, src, uuid string) error {
 }
 
 // NFO attempts to discover a archive package NFO or information textfile from a collection of files.
+// The names of the files can be paths within nested archives.
 func NFO(name string, files content.Contents, varNames *[]string) string {
 	f := make(Finds) // filename and priority values
 	for _, file := range files {
@@ -193,7 +196,7 @@ func (f Finds) setUsable(obj nfoObj, file content.File) {
 func parseNfo(name string, file content.File, varNames *[]string) nfoObj {
 	obj := nfoObj{
 		base:     strings.TrimSuffix(name, file.Ext),
-		filename: strings.ToLower(file.Name),
+		filename: strings.ToLower(path.Base(file.Name)),
 		ext:      strings.ToLower(file.Ext),
 	}
 	obj.altNFO = findVariant(obj.filename, nfo, varNames)
@@ -116,6 +116,12 @@ func TestDOS(t *testing.T) {
 	assert.Equal(t, "random.exe", f)
 	f = demozoo.DOS(nil, "hi.zip", t4, &none)
 	assert.Equal(t, "random.exe", f)
+	t5 := content.Contents{
+		0: {Ext: exe, Name: "INNER.ZIP/hi.exe", Executable: true},
+		1: f3,
+	}
+	f = demozoo.DOS(nil, "hi.zip", t5, &none)
+	assert.Equal(t, "INNER.ZIP/hi.exe", f)
 }
 
 func Test_MoveText(t *testing.T) {
@@ -161,6 +167,10 @@ func Test_NFO(t *testing.T) {
 	ff3[0] = f1
 	ff3[1] = f2
 	ff3[2] = f3
+	nested := content.Contents{
+		0: f1,
+		1: content.File{Ext: ".nfo", Name: "INNER.ARJ/release.nfo", Textfile: true},
+	}
 	tests := []struct {
 		name string
 		args args
@@ -171,6 +181,7 @@ func Test_NFO(t *testing.T) {
 		{"1 file", args{"hi.zip", ff1}, "file_id.diz"},
 		{"2 file", args{"hi.zip", ff2}, "hi.nfo"},
 		{"3 file", args{"hi.zip", ff2}, "hi.nfo"},
+		{"nested", args{"hi.zip", nested}, "INNER.ARJ/release.nfo"},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -0,0 +1,204 @@
+// Package dosarc has the filename, timestamp, listing and extraction handling shared by
+// the readers of the DOS era ARC, ARJ, LHA and ZOO archives.
+package dosarc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Defacto2/df2/pkg/charset"
+)
+
+var (
+	ErrDest   = errors.New("dest directory is empty or points to a file")
+	ErrPath   = errors.New("file path is outside of the dest directory")
+	ErrTarget = errors.New("archive does not contain the target")
+	ErrSize   = errors.New("extracted files exceed the size limit")
+	ErrFiles  = errors.New("extracted files exceed the file count limit")
+)
+
+const dirMode = 0o755
+
+// Entry is a file or directory within an archive.
+type Entry struct {
+	Name     string                              // Name of the file using forward slash path separators.
+	Modified time.Time                           // Modified is the last modification time of the file.
+	Dir      bool                                // Dir is true when the entry is a directory.
+	Open     func() (io.ReadCloser, error)       // Open returns a reader of the uncompressed file content.
+	Create   func(name string) (*os.File, error) // Create the named file for writing, os.Create is used when nil.
+}
+
+// Limit is the total size and number of the files and directories that an extraction can write.
+// A nil Limit or a zero value is unlimited.
+type Limit struct {
+	Size  int64 // Size is the limit in bytes of the extracted files.
+	Files int   // Files is the limit of the extracted files and directories.
+	size  int64
+	files int
+}
+
+// add counts an extracted file or directory.
+func (l *Limit) add() error {
+	if l == nil {
+		return nil
+	}
+	l.files++
+	if l.Files > 0 && l.files > l.Files {
+		return ErrFiles
+	}
+	return nil
+}
+
+// copy r to w, but stop and return ErrSize as soon as the extracted files exceed the size limit.
+func (l *Limit) copy(w io.Writer, r io.Reader) error {
+	if l == nil || l.Size <= 0 {
+		_, err := io.Copy(w, r)
+		return err
+	}
+	n, err := io.Copy(w, io.LimitReader(r, l.Size-l.size+1))
+	l.size += n
+	if err != nil {
+		return err
+	}
+	if l.size > l.Size {
+		return ErrSize
+	}
+	return nil
+}
+
+// Decode returns the text as a UTF-8 string, text that is not UTF-8 is treated as either
+// Shift-JIS or IBM Code Page 437.
+func Decode(b []byte) string {
+	return charset.Name(b)
+}
+
+// MSDOS returns the MS-DOS date and time as a time value.
+func MSDOS(d, t uint16) time.Time {
+	const (
+		dosEpoch = 1980
+		secs     = 2
+	)
+	return time.Date(
+		int(d>>9)+dosEpoch, time.Month(d>>5&0xf), int(d&0x1f),
+		int(t>>11), int(t>>5&0x3f), int(t&0x1f)*secs, 0, time.UTC)
+}
+
+// List returns the names of the entries, excluding any directories.
+func List(entries []Entry) []string {
+	files := []string{}
+	for _, e := range entries {
+		if e.Dir {
+			continue
+		}
+		files = append(files, e.Name)
+	}
+	return files
+}
+
+// Extract the entries that match the targets to the dest directory.
+// The targets are matched against the file paths and names, ignoring case.
+// When no targets are given, or the target is "*", all the files are extracted.
+// The dest directory is created when it does not exist.
+// The format is the name of the archive format used by the returned errors.
+func Extract(format, dest string, entries []Entry, targets ...string) error {
+	return ExtractLimit(format, dest, entries, nil, targets...)
+}
+
+// ExtractLimit extracts the entries that match the targets to the dest directory in the same manner
+// as Extract, but stops and returns ErrSize or ErrFiles when the extracted files break the limit.
+func ExtractLimit(format, dest string, entries []Entry, lim *Limit, targets ...string) error {
+	if err := Dest(format, dest); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dest, dirMode); err != nil {
+		return fmt.Errorf("%s extract: %w", format, err)
+	}
+	found := false
+	for _, e := range entries {
+		if !Match(e.Name, targets...) {
+			continue
+		}
+		found = true
+		if err := e.Write(dest, lim); err != nil {
+			return fmt.Errorf("%s extract: %w", format, err)
+		}
+	}
+	if !found {
+		return fmt.Errorf("%s %w: %s", format, ErrTarget, strings.Join(targets, " "))
+	}
+	return nil
+}
+
+// Dest returns an error when the dest directory is empty or points to a file.
+// The format is the name of the archive format used by the returned error.
+func Dest(format, dest string) error {
+	if dest == "" {
+		return fmt.Errorf("%s extract %w: %q", format, ErrDest, dest)
+	}
+	if st, err := os.Stat(dest); err == nil && !st.IsDir() {
+		return fmt.Errorf("%s extract %w: %s", format, ErrDest, dest)
+	}
+	return nil
+}
+
+// Match returns true when the name matches any of the targets, ignoring case.
+// A target matches either the file path or the name of the file.
+func Match(name string, targets ...string) bool {
+	if len(targets) == 0 {
+		return true
+	}
+	for _, t := range targets {
+		t = strings.ReplaceAll(t, "\\", "/")
+		switch {
+		case t == "", t == "*":
+			return true
+		case strings.EqualFold(t, name), strings.EqualFold(t, path.Base(name)):
+			return true
+		}
+	}
+	return false
+}
+
+// Write the entry to the dest directory, within the lim limit.
+func (e Entry) Write(dest string, lim *Limit) error {
+	if err := lim.add(); err != nil {
+		return err
+	}
+	name := filepath.Join(dest, filepath.FromSlash(path.Clean("/"+e.Name)))
+	if rel, err := filepath.Rel(dest, name); err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("%w: %s", ErrPath, e.Name)
+	}
+	if e.Dir {
+		return os.MkdirAll(name, dirMode)
+	}
+	if err := os.MkdirAll(filepath.Dir(name), dirMode); err != nil {
+		return err
+	}
+	r, err := e.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	create := e.Create
+	if create == nil {
+		create = os.Create
+	}
+	w, err := create(name)
+	if err != nil {
+		return err
+	}
+	if err := lim.copy(w, r); err != nil {
+		w.Close()
+		return fmt.Errorf("%s: %w", e.Name, err)
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(name, e.Modified, e.Modified)
+}
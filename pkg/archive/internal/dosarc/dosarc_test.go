@@ -0,0 +1,97 @@
+package dosarc_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Defacto2/df2/pkg/archive/internal/dosarc"
+	"github.com/stretchr/testify/assert"
+)
+
+func entry(name, content string) dosarc.Entry {
+	return dosarc.Entry{
+		Name:     name,
+		Modified: time.Date(1994, 2, 3, 4, 5, 6, 0, time.UTC),
+		Open: func() (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(content)), nil
+		},
+	}
+}
+
+func TestMSDOS(t *testing.T) {
+	t.Parallel()
+	// 1994-02-03 04:05:06
+	d := uint16(14<<9 | 2<<5 | 3)
+	tm := uint16(4<<11 | 5<<5 | 3)
+	assert.Equal(t, time.Date(1994, 2, 3, 4, 5, 6, 0, time.UTC), dosarc.MSDOS(d, tm))
+}
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "README.TXT", dosarc.Decode([]byte("README.TXT")))
+	assert.Equal(t, "Ç", dosarc.Decode([]byte{0x80}))
+}
+
+func TestList(t *testing.T) {
+	t.Parallel()
+	dir := entry("DOCS", "")
+	dir.Dir = true
+	files := dosarc.List([]dosarc.Entry{dir, entry("DOCS/A.TXT", "a")})
+	assert.Equal(t, []string{"DOCS/A.TXT"}, files)
+}
+
+func TestMatch(t *testing.T) {
+	t.Parallel()
+	assert.True(t, dosarc.Match("DOCS/A.TXT"))
+	assert.True(t, dosarc.Match("DOCS/A.TXT", "*"))
+	assert.True(t, dosarc.Match("DOCS/A.TXT", "a.txt"))
+	assert.True(t, dosarc.Match("DOCS/A.TXT", "docs\\a.txt"))
+	assert.False(t, dosarc.Match("DOCS/A.TXT", "b.txt"))
+}
+
+func TestExtract(t *testing.T) {
+	t.Parallel()
+	entries := []dosarc.Entry{entry("DOCS/A.TXT", "abc"), entry("B.TXT", "de")}
+	err := dosarc.Extract("test", "", entries)
+	assert.ErrorIs(t, err, dosarc.ErrDest)
+
+	dir := t.TempDir()
+	err = dosarc.Extract("test", dir, entries)
+	assert.Nil(t, err)
+	b, err := os.ReadFile(filepath.Join(dir, "DOCS", "A.TXT"))
+	assert.Nil(t, err)
+	assert.Equal(t, "abc", string(b))
+	st, err := os.Stat(filepath.Join(dir, "B.TXT"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1994, st.ModTime().UTC().Year())
+
+	dir = t.TempDir()
+	err = dosarc.Extract("test", dir, entries, "b.txt")
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(dir, "DOCS"))
+	assert.NotNil(t, err)
+
+	err = dosarc.Extract("test", dir, entries, "nothing.txt")
+	assert.ErrorIs(t, err, dosarc.ErrTarget)
+	err = dosarc.Extract("test", dir, []dosarc.Entry{entry("..", "")})
+	assert.ErrorIs(t, err, dosarc.ErrPath)
+}
+
+func TestExtractLimit(t *testing.T) {
+	t.Parallel()
+	entries := []dosarc.Entry{entry("A.TXT", "abc"), entry("B.TXT", "defgh")}
+	err := dosarc.ExtractLimit("test", t.TempDir(), entries, &dosarc.Limit{Size: 8, Files: 2})
+	assert.Nil(t, err)
+	err = dosarc.ExtractLimit("test", t.TempDir(), entries, &dosarc.Limit{Files: 1})
+	assert.ErrorIs(t, err, dosarc.ErrFiles)
+	dir := t.TempDir()
+	err = dosarc.ExtractLimit("test", dir, entries, &dosarc.Limit{Size: 5})
+	assert.ErrorIs(t, err, dosarc.ErrSize)
+	// the file that breaks the limit is never written in full
+	b, _ := os.ReadFile(filepath.Join(dir, "B.TXT"))
+	assert.Less(t, len(b), 5)
+}
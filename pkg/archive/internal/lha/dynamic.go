@@ -0,0 +1,204 @@
+package lha
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/Defacto2/df2/pkg/archive/internal/lzh"
+)
+
+const (
+	dicSize   = 1 << 12                        // dicSize is the size of the sliding dictionary.
+	threshold = 3                              // threshold is the minimum match length.
+	maxMatch  = 60                             // maxMatch is the maximum match length.
+	nChar     = 256 - threshold + maxMatch + 1 // nChar is the number of literal and length codes.
+	tree      = nChar*2 - 1                    // tree is the number of nodes in the huffman tree.
+	root      = tree - 1                       // root is the node at the top of the huffman tree.
+	maxFreq   = 0x8000                         // maxFreq is the root frequency that rescales the tree.
+	posBits   = 6                              // posBits is the bit size of the uncoded lower position.
+)
+
+// dynamic decompresses the LHA -lh1- method, the LZHUF algorithm by Haruyasu Yoshizaki
+// that uses LZSS with an adaptive huffman code for the literals and match lengths.
+type dynamic struct {
+	bits   *lzh.Bits
+	pos    *lzh.Huffman // pos is the fixed code of the upper position bits.
+	freq   [tree + 1]int
+	son    [tree]int         // son is the first child of a node, or the leaf symbol plus tree.
+	prnt   [tree + nChar]int // prnt is the parent of a node, leaves are indexed from tree.
+	window []byte
+	wpos   int   // wpos is the next write position in the window.
+	remain int64 // remain is the number of bytes left to decompress.
+	copy   int   // copy is the number of match bytes left to copy.
+	from   int   // from is the window position of the next match byte.
+	err    error
+}
+
+func newDynamic(r io.Reader, size int64) *dynamic {
+	z := &dynamic{
+		bits:   lzh.NewBits(bufio.NewReader(r)),
+		window: make([]byte, dicSize),
+		remain: size,
+	}
+	// the dictionary starts filled with spaces
+	for i := range z.window {
+		z.window[i] = ' '
+	}
+	// the upper 6 bits of a position use the code lengths 3 to 8
+	lens := make([]uint8, 0, 1<<posBits)
+	for _, ln := range []struct {
+		len   uint8
+		count int
+	}{{3, 1}, {4, 3}, {5, 8}, {6, 12}, {7, 24}, {8, 16}} {
+		for i := 0; i < ln.count; i++ {
+			lens = append(lens, ln.len)
+		}
+	}
+	z.pos, z.err = lzh.NewHuffman(lens)
+	z.start()
+	return z
+}
+
+func (z *dynamic) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if z.copy > 0 {
+			b := z.window[z.from]
+			z.from = (z.from + 1) % dicSize
+			z.copy--
+			z.put(b)
+			p[n] = b
+			n++
+			continue
+		}
+		if z.err != nil {
+			return n, z.err
+		}
+		if z.remain <= 0 {
+			return n, io.EOF
+		}
+		c := z.decodeC()
+		if c < 256 {
+			z.remain--
+			z.put(byte(c))
+			p[n] = byte(c)
+			n++
+			continue
+		}
+		length := int64(c - 256 + threshold)
+		hi, err := z.pos.Decode(z.bits)
+		if err != nil {
+			z.err = err
+			continue
+		}
+		dist := hi<<posBits | int(z.bits.Read(posBits))
+		if length > z.remain {
+			length = z.remain
+		}
+		z.remain -= length
+		z.copy = int(length)
+		z.from = ((z.wpos-dist-1)%dicSize + dicSize) % dicSize
+	}
+	return n, nil
+}
+
+func (z *dynamic) put(b byte) {
+	z.window[z.wpos] = b
+	z.wpos++
+	if z.wpos >= dicSize {
+		z.wpos = 0
+	}
+}
+
+// start builds the initial tree where every symbol has a frequency of one.
+func (z *dynamic) start() {
+	for i := 0; i < nChar; i++ {
+		z.freq[i] = 1
+		z.son[i] = i + tree
+		z.prnt[i+tree] = i
+	}
+	for i, j := 0, nChar; j <= root; i, j = i+2, j+1 {
+		z.freq[j] = z.freq[i] + z.freq[i+1]
+		z.son[j] = i
+		z.prnt[i], z.prnt[i+1] = j, j
+	}
+	z.freq[tree] = 0xffff
+	z.prnt[root] = 0
+}
+
+// decodeC returns the next literal byte or match length code.
+func (z *dynamic) decodeC() int {
+	c := z.son[root]
+	for c < tree {
+		c = z.son[c+int(z.bits.Read(1))]
+	}
+	c -= tree
+	z.update(c)
+	return c
+}
+
+// update increments the frequency of the symbol c and
+// swaps the nodes that are out of order to keep the tree sorted.
+func (z *dynamic) update(c int) {
+	if z.freq[root] == maxFreq {
+		z.rebuild()
+	}
+	c = z.prnt[c+tree]
+	for {
+		z.freq[c]++
+		k := z.freq[c]
+		if l := c + 1; k > z.freq[l] {
+			for k > z.freq[l+1] {
+				l++
+			}
+			z.freq[c], z.freq[l] = z.freq[l], k
+			i := z.son[c]
+			z.prnt[i] = l
+			if i < tree {
+				z.prnt[i+1] = l
+			}
+			j := z.son[l]
+			z.son[l] = i
+			z.prnt[j] = c
+			if j < tree {
+				z.prnt[j+1] = c
+			}
+			z.son[c] = j
+			c = l
+		}
+		if c = z.prnt[c]; c == 0 {
+			return
+		}
+	}
+}
+
+// rebuild halves the frequencies of the leaves and rebuilds the tree.
+func (z *dynamic) rebuild() {
+	j := 0
+	for i := 0; i < tree; i++ {
+		if z.son[i] >= tree {
+			z.freq[j] = (z.freq[i] + 1) / 2
+			z.son[j] = z.son[i]
+			j++
+		}
+	}
+	for i, j := 0, nChar; j < tree; i, j = i+2, j+1 {
+		f := z.freq[i] + z.freq[i+1]
+		k := j - 1
+		for f < z.freq[k] {
+			k--
+		}
+		k++
+		copy(z.freq[k+1:j+1], z.freq[k:j])
+		z.freq[k] = f
+		copy(z.son[k+1:j+1], z.son[k:j])
+		z.son[k] = i
+	}
+	for i := 0; i < tree; i++ {
+		k := z.son[i]
+		z.prnt[k] = i
+		if k < tree {
+			z.prnt[k+1] = i
+		}
+	}
+}
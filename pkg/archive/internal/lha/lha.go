@@ -0,0 +1,401 @@
+// Package lha reads and extracts LHA archives, also known as LZH, the format
+// created by Haruyasu Yoshizaki for the LHarc and LHA archivers on MS-DOS.
+// Files that are stored or compressed with the -lh1- and -lh4- to -lh7- methods
+// are supported, using any of the header levels 0, 1 and 2.
+package lha
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Defacto2/df2/pkg/archive/internal/crc16"
+	"github.com/Defacto2/df2/pkg/archive/internal/dosarc"
+	"github.com/Defacto2/df2/pkg/archive/internal/lzh"
+)
+
+var (
+	ErrChecksum = errors.New("lha checksum error")
+	ErrFormat   = errors.New("not a valid lha archive")
+	ErrHeader   = errors.New("lha header is corrupt")
+	ErrMethod   = errors.New("lha compression method is not supported")
+)
+
+const (
+	baseSize  = 22   // baseSize is the length of the fields shared by all the header levels.
+	level2Min = 26   // level2Min is the length of the fixed fields of a level 2 header.
+	sep       = 0xff // sep is the path separator of the directory name extended header.
+)
+
+// Compression methods.
+const (
+	Stored    = "-lh0-" // Stored without any compression.
+	Directory = "-lhd-" // Directory without any content.
+	LH1       = "-lh1-" // LH1 is LZSS with a 4 KB dictionary and adaptive huffman codes.
+	LH4       = "-lh4-" // LH4 is LZSS with a 4 KB dictionary and static huffman codes.
+	LH5       = "-lh5-" // LH5 is LZSS with an 8 KB dictionary and static huffman codes.
+	LH6       = "-lh6-" // LH6 is LZSS with a 32 KB dictionary and static huffman codes.
+	LH7       = "-lh7-" // LH7 is LZSS with a 64 KB dictionary and static huffman codes.
+	LZ4       = "-lz4-" // LZ4 is stored without any compression by LArc.
+)
+
+// Extended header types.
+const (
+	extCommon  = 0x00 // extCommon holds the checksum of a level 2 header.
+	extName    = 0x01 // extName is the filename.
+	extDir     = 0x02 // extDir is the directory name.
+	extComment = 0x3f // extComment is the file comment.
+	extUnix    = 0x54 // extUnix is the Unix modification time.
+)
+
+// File is a file or directory stored in an LHA archive.
+type File struct {
+	Name           string    // Name of the file using forward slash path separators.
+	Comment        string    // Comment of the file.
+	RawComment     []byte    // RawComment is the comment using its legacy character set.
+	Method         string    // Method of compression, such as -lh5-.
+	Level          int       // Level of the header, either 0, 1 or 2.
+	HostOS         byte      // HostOS is the operating system id used to create the archive.
+	Modified       time.Time // Modified is the last modification time of the file.
+	CompressedSize int64     // CompressedSize of the file data.
+	Size           int64     // Size of the uncompressed file.
+	CRC16          uint16    // CRC16 checksum of the uncompressed file.
+	r              io.ReaderAt
+	offset         int64 // offset of the file data.
+}
+
+// IsDir returns true when the file is a directory.
+func (f *File) IsDir() bool {
+	return f.Method == Directory
+}
+
+// Open returns a reader of the uncompressed file content.
+// The checksum of the content is validated when the end of the file is read.
+func (f *File) Open() (io.ReadCloser, error) {
+	data := io.NewSectionReader(f.r, f.offset, f.CompressedSize)
+	var r io.Reader
+	switch f.Method {
+	case Stored, Directory, LZ4:
+		r = data
+	case LH1:
+		r = newDynamic(data, f.Size)
+	case LH4:
+		r = lzh.NewReader(data, f.Size, lzh.LH4)
+	case LH5:
+		r = lzh.NewReader(data, f.Size, lzh.LH5)
+	case LH6:
+		r = lzh.NewReader(data, f.Size, lzh.LH6)
+	case LH7:
+		r = lzh.NewReader(data, f.Size, lzh.LH7)
+	default:
+		return nil, fmt.Errorf("%w: %s %s", ErrMethod, f.Method, f.Name)
+	}
+	return &checksum{
+		r:    io.LimitReader(r, f.Size),
+		want: f.CRC16,
+		size: f.Size,
+	}, nil
+}
+
+// checksum validates the CRC16 and size of a file when the end is read.
+type checksum struct {
+	r    io.Reader
+	crc  crc16.Digest
+	want uint16
+	size int64
+	read int64
+}
+
+func (c *checksum) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.crc.Write(p[:n])
+	c.read += int64(n)
+	if errors.Is(err, io.EOF) {
+		if c.read != c.size || c.crc.Sum16() != c.want {
+			return n, ErrChecksum
+		}
+	}
+	return n, err //nolint:wrapcheck
+}
+
+func (c *checksum) Close() error {
+	return nil
+}
+
+// Reader is an LHA archive.
+type Reader struct {
+	File []*File // File lists the files and directories in the archive.
+}
+
+// NewReader returns a Reader of the LHA archive read from r, which is size bytes long.
+// Any self-extracting program that precedes the archive is skipped.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	off, err := find(r, size)
+	if err != nil {
+		return nil, err
+	}
+	z := &Reader{}
+	for off < size {
+		f, err := header(r, off)
+		if err != nil {
+			return nil, err
+		}
+		if f == nil {
+			break
+		}
+		if f.offset+f.CompressedSize > size {
+			return nil, fmt.Errorf("%w: %s data is truncated", ErrHeader, f.Name)
+		}
+		z.File = append(z.File, f)
+		off = f.offset + f.CompressedSize
+	}
+	return z, nil
+}
+
+// ReadCloser is an LHA archive file that must be closed after use.
+type ReadCloser struct {
+	Reader
+	f *os.File
+}
+
+// OpenReader opens the named LHA archive file.
+func OpenReader(name string) (*ReadCloser, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("lha open: %w", err)
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("lha stat: %w", err)
+	}
+	r, err := NewReader(f, st.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &ReadCloser{Reader: *r, f: f}, nil
+}
+
+// Close the archive file.
+func (rc *ReadCloser) Close() error {
+	return rc.f.Close()
+}
+
+// find returns the offset of the first valid header of the archive.
+func find(r io.ReaderAt, size int64) (int64, error) {
+	const chunk = 32 * 1024
+	buf := make([]byte, chunk+5)
+	for base := int64(0); base < size; base += chunk {
+		n, err := r.ReadAt(buf, base)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return 0, fmt.Errorf("lha find: %w", err)
+		}
+		for i := 0; i+4 < n; i++ {
+			if buf[i] != '-' || buf[i+1] != 'l' || buf[i+4] != '-' {
+				continue
+			}
+			off := base + int64(i) - 2
+			if off < 0 {
+				continue
+			}
+			if f, err := header(r, off); err == nil && f != nil {
+				return off, nil
+			}
+		}
+	}
+	return 0, ErrFormat
+}
+
+// header reads and returns the file header at the offset.
+// A nil file is returned for the end of archive marker.
+func header(r io.ReaderAt, off int64) (*File, error) {
+	var base [baseSize]byte
+	n, err := r.ReadAt(base[:], off)
+	if n > 0 && base[0] == 0 {
+		return nil, nil
+	}
+	if n == 0 && errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrHeader, err)
+	}
+	le := binary.LittleEndian
+	f := &File{
+		Method:         string(base[2:7]),
+		CompressedSize: int64(le.Uint32(base[7:])),
+		Size:           int64(le.Uint32(base[11:])),
+		Level:          int(base[20]),
+		r:              r,
+	}
+	if f.Method[0] != '-' || f.Method[4] != '-' {
+		return nil, ErrHeader
+	}
+	switch f.Level {
+	case 0, 1:
+		err = f.level01(r, off, base[:])
+	case 2: //nolint:gomnd
+		err = f.level2(r, off)
+	default:
+		return nil, fmt.Errorf("%w: unsupported header level %d", ErrHeader, f.Level)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// level01 reads the rest of a level 0 or level 1 header.
+func (f *File) level01(r io.ReaderAt, off int64, base []byte) error {
+	le := binary.LittleEndian
+	h := make([]byte, int(base[0])+2)
+	if _, err := r.ReadAt(h, off); err != nil {
+		return fmt.Errorf("%w: %w", ErrHeader, err)
+	}
+	sum := byte(0)
+	for _, b := range h[2:] {
+		sum += b
+	}
+	if sum != h[1] {
+		return fmt.Errorf("%w: %w", ErrHeader, ErrChecksum)
+	}
+	const crcSize = 2
+	nameLen := int(h[21])
+	if baseSize+nameLen+crcSize > len(h) {
+		return ErrHeader
+	}
+	f.Modified = dosarc.MSDOS(le.Uint16(h[17:]), le.Uint16(h[15:]))
+	name := h[baseSize : baseSize+nameLen]
+	f.CRC16 = le.Uint16(h[baseSize+nameLen:])
+	f.offset = off + int64(len(h))
+	if f.Level == 0 {
+		f.names(name, nil)
+		return nil
+	}
+	const level1Tail = 3 // host os and the size of the first extended header.
+	if baseSize+nameLen+crcSize+level1Tail > len(h) {
+		return ErrHeader
+	}
+	f.HostOS = h[baseSize+nameLen+crcSize]
+	dir := []byte{}
+	next := int64(le.Uint16(h[len(h)-2:]))
+	for next != 0 {
+		const min = 3
+		if next < min {
+			return ErrHeader
+		}
+		ext := make([]byte, next)
+		if _, err := r.ReadAt(ext, f.offset); err != nil {
+			return fmt.Errorf("%w: %w", ErrHeader, err)
+		}
+		f.offset += next
+		f.CompressedSize -= next
+		switch kind := ext[0]; kind {
+		case extName:
+			name = ext[1 : next-2]
+		case extDir:
+			dir = ext[1 : next-2]
+		default:
+			f.extend(kind, ext[1:next-2])
+		}
+		next = int64(le.Uint16(ext[next-2:]))
+	}
+	if f.CompressedSize < 0 {
+		return ErrHeader
+	}
+	f.names(name, dir)
+	return nil
+}
+
+// level2 reads a level 2 header, which keeps the filename in the extended headers.
+func (f *File) level2(r io.ReaderAt, off int64) error {
+	le := binary.LittleEndian
+	var size [2]byte
+	if _, err := r.ReadAt(size[:], off); err != nil {
+		return fmt.Errorf("%w: %w", ErrHeader, err)
+	}
+	h := make([]byte, le.Uint16(size[:]))
+	if len(h) < level2Min {
+		return ErrHeader
+	}
+	if _, err := r.ReadAt(h, off); err != nil {
+		return fmt.Errorf("%w: %w", ErrHeader, err)
+	}
+	f.Modified = time.Unix(int64(le.Uint32(h[15:])), 0).UTC()
+	f.CRC16 = le.Uint16(h[21:])
+	f.HostOS = h[23]
+	f.offset = off + int64(len(h))
+	var name, dir []byte
+	pos, next := level2Min, int(le.Uint16(h[24:]))
+	for next != 0 {
+		const min = 3
+		if next < min || pos+next > len(h) {
+			return ErrHeader
+		}
+		ext := h[pos : pos+next]
+		switch kind := ext[0]; kind {
+		case extCommon:
+			if err := common(h, pos+1); err != nil {
+				return err
+			}
+		case extName:
+			name = ext[1 : next-2]
+		case extDir:
+			dir = ext[1 : next-2]
+		default:
+			f.extend(kind, ext[1:next-2])
+		}
+		pos += next
+		next = int(le.Uint16(ext[next-2:]))
+	}
+	f.names(name, dir)
+	return nil
+}
+
+// common validates the header checksum stored at the index of the level 2 header.
+func common(h []byte, i int) error {
+	if i+2 > len(h) {
+		return ErrHeader
+	}
+	want := binary.LittleEndian.Uint16(h[i:])
+	cp := make([]byte, len(h))
+	copy(cp, h)
+	cp[i], cp[i+1] = 0, 0
+	if crc16.Checksum(cp) != want {
+		return fmt.Errorf("%w: %w", ErrHeader, ErrChecksum)
+	}
+	return nil
+}
+
+// extend applies the data of an extended header to the file.
+func (f *File) extend(kind byte, data []byte) {
+	switch kind {
+	case extComment:
+		f.RawComment = bytes.Clone(bytes.TrimRight(data, "\x00"))
+		f.Comment = dosarc.Decode(f.RawComment)
+	case extUnix:
+		const size = 4
+		if len(data) >= size {
+			f.Modified = time.Unix(int64(binary.LittleEndian.Uint32(data)), 0).UTC()
+		}
+	}
+}
+
+// names sets the file name using the directory and filename of the headers.
+func (f *File) names(name, dir []byte) {
+	b := make([]byte, 0, len(dir)+len(name))
+	b = append(b, dir...)
+	b = append(b, name...)
+	for i, c := range b {
+		if c == sep || c == '\\' {
+			b[i] = '/'
+		}
+	}
+	f.Name = strings.TrimSuffix(dosarc.Decode(b), "/")
+}
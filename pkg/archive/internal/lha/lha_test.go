@@ -0,0 +1,149 @@
+package lha_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Defacto2/df2/pkg/archive/internal/lha"
+	"github.com/stretchr/testify/assert"
+)
+
+func testDir(name string) string {
+	dir, _ := os.Getwd()
+	return filepath.Join(dir, "..", "..", "..", "..", "testdata", name)
+}
+
+const (
+	hello = "Hello world.\r\n"
+	last  = "00699 The quick brown fox jumps over the lazy dog, line 33."
+)
+
+func TestOpenReader(t *testing.T) {
+	t.Parallel()
+	_, err := lha.OpenReader("")
+	assert.NotNil(t, err)
+	_, err = lha.OpenReader(testDir("demozoo/test.zip"))
+	assert.ErrorIs(t, err, lha.ErrFormat)
+
+	z, err := lha.OpenReader(testDir("lha/methods.lzh"))
+	assert.Nil(t, err)
+	defer z.Close()
+	assert.Len(t, z.File, 9)
+	methods := []string{lha.Stored, lha.LH1, lha.LH5, lha.LH6, lha.LH7, lha.Directory}
+	levels := []int{0, 0, 1, 2, 2, 2}
+	for i, m := range methods {
+		assert.Equal(t, m, z.File[i].Method)
+		assert.Equal(t, levels[i], z.File[i].Level)
+	}
+	assert.True(t, z.File[5].IsDir())
+	assert.Equal(t, "DOCS", z.File[5].Name)
+	assert.Equal(t, "DOCS/README.NFO", z.File[6].Name)
+	assert.Equal(t, "DOCS/LONG1.NFO", z.File[7].Name)
+	stamp := time.Date(1994, 6, 15, 12, 30, 20, 0, time.UTC)
+	for _, f := range z.File {
+		assert.Equal(t, stamp, f.Modified, f.Name)
+	}
+}
+
+func TestFile_Open(t *testing.T) {
+	t.Parallel()
+	z, err := lha.OpenReader(testDir("lha/methods.lzh"))
+	assert.Nil(t, err)
+	defer z.Close()
+	for _, f := range z.File {
+		r, err := f.Open()
+		assert.Nil(t, err, f.Name)
+		b, err := io.ReadAll(r)
+		assert.Nil(t, err, f.Name)
+		assert.Equal(t, f.Size, int64(len(b)), f.Name)
+		switch f.Name {
+		case "STORED.TXT", "LH7.TXT":
+			assert.True(t, strings.HasPrefix(string(b), hello), f.Name)
+		case "LH1.TXT":
+			assert.True(t, strings.HasPrefix(string(b), "        "+hello), f.Name)
+		case "LH5.ANS":
+			assert.Contains(t, string(b), "DEFACTO2")
+		case "DOCS/README.NFO", "DOCS/LONG1.NFO":
+			assert.Contains(t, string(b), last, f.Name)
+		}
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	t.Parallel()
+	b, err := os.ReadFile(testDir("lha/hello.lzh"))
+	assert.Nil(t, err)
+	_, err = lha.NewReader(bytes.NewReader(b[:20]), 20)
+	assert.ErrorIs(t, err, lha.ErrFormat)
+
+	// a self-extracting program stub is skipped
+	sfx := append([]byte("MZ program stub"), b...)
+	z, err := lha.NewReader(bytes.NewReader(sfx), int64(len(sfx)))
+	assert.Nil(t, err)
+	assert.Len(t, z.File, 1)
+
+	// the end of archive marker is optional
+	z, err = lha.NewReader(bytes.NewReader(b[:len(b)-1]), int64(len(b)-1))
+	assert.Nil(t, err)
+	assert.Len(t, z.File, 1)
+
+	// corrupt the compressed data
+	bad := bytes.Clone(b)
+	bad[len(bad)-10] ^= 0xff
+	z, err = lha.NewReader(bytes.NewReader(bad), int64(len(bad)))
+	assert.Nil(t, err)
+	r, err := z.File[0].Open()
+	assert.Nil(t, err)
+	_, err = io.ReadAll(r)
+	assert.NotNil(t, err)
+
+	// corrupt the header
+	bad = bytes.Clone(b)
+	bad[10] ^= 0xff
+	_, err = lha.NewReader(bytes.NewReader(bad), int64(len(bad)))
+	assert.NotNil(t, err)
+}
+
+func TestList(t *testing.T) {
+	t.Parallel()
+	files, err := lha.List(testDir("demozoo/test.lha"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ext dir/test file.text", "test.png", "test.txt"}, files)
+	files, err = lha.List(testDir("lha/methods.lzh"))
+	assert.Nil(t, err)
+	assert.Len(t, files, 8)
+}
+
+func TestExtract(t *testing.T) {
+	t.Parallel()
+	src := testDir("lha/methods.lzh")
+	err := lha.Extract(src, "")
+	assert.ErrorIs(t, err, lha.ErrDest)
+
+	dir := t.TempDir()
+	err = lha.Extract(src, dir)
+	assert.Nil(t, err)
+	b, err := os.ReadFile(filepath.Join(dir, "DOCS", "LONG1.NFO"))
+	assert.Nil(t, err)
+	assert.Len(t, b, 119020)
+	st, err := os.Stat(filepath.Join(dir, "EMPTY.TXT"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), st.Size())
+
+	dir = t.TempDir()
+	err = lha.Extract(src, dir, "lh6.bin")
+	assert.Nil(t, err)
+	b, err = os.ReadFile(filepath.Join(dir, "LH6.BIN"))
+	assert.Nil(t, err)
+	assert.Len(t, b, 3000)
+	_, err = os.Stat(filepath.Join(dir, "STORED.TXT"))
+	assert.NotNil(t, err)
+
+	err = lha.Extract(src, dir, "nothing.txt")
+	assert.ErrorIs(t, err, lha.ErrTarget)
+}
@@ -0,0 +1,319 @@
+// Package lzh decompresses the static Huffman, LZSS compression used by the
+// ARJ methods 1 to 3 and the LHA -lh4- to -lh7- methods.
+// The format was created by Haruhiko Okumura and Haruyasu Yoshizaki for the
+// ar002 and LHarc archivers and then adopted by ARJ with a larger dictionary.
+package lzh
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+var (
+	ErrCode  = errors.New("lzh invalid huffman code")
+	ErrTable = errors.New("lzh invalid huffman table")
+)
+
+const (
+	threshold = 3                              // threshold is the minimum match length.
+	maxMatch  = 256                            // maxMatch is the maximum match length.
+	nc        = 255 + maxMatch + 2 - threshold // nc is the number of literal and length codes.
+	nt        = 16 + 3                         // nt is the number of code length codes.
+	cbit      = 9                              // cbit is the bit size of the literal and length code count.
+	tbit      = 5                              // tbit is the bit size of the code length code count.
+	maxBits   = 16                             // maxBits is the longest huffman code length.
+)
+
+// Method is the dictionary size and position code settings of a compression method.
+type Method struct {
+	Window int // Window is the size of the sliding dictionary in bytes.
+	NP     int // NP is the number of position codes.
+	PBit   int // PBit is the bit size of the position code count.
+}
+
+var (
+	ARJ = Method{Window: 26624, NP: 17, PBit: 5}   // ARJ methods 1, 2 and 3.
+	LH4 = Method{Window: 1 << 12, NP: 13, PBit: 4} // LHA -lh4-.
+	LH5 = Method{Window: 1 << 13, NP: 14, PBit: 4} // LHA -lh5-.
+	LH6 = Method{Window: 1 << 15, NP: 16, PBit: 5} // LHA -lh6-.
+	LH7 = Method{Window: 1 << 16, NP: 17, PBit: 5} // LHA -lh7-.
+)
+
+// Reader decompresses the data read from r.
+type Reader struct {
+	Method
+	bits   Bits
+	window []byte
+	pos    int   // pos is the next write position in the window.
+	remain int64 // remain is the number of bytes left to decompress.
+	copy   int   // copy is the number of match bytes left to copy.
+	from   int   // from is the window position of the next match byte.
+	block  int   // block is the number of codes left in the current block.
+	c, p   *Huffman
+	err    error
+}
+
+// NewReader returns a Reader that decompresses size bytes from r using the method settings.
+func NewReader(r io.Reader, size int64, m Method) *Reader {
+	return &Reader{
+		Method: m,
+		bits:   Bits{r: bufio.NewReader(r)},
+		window: make([]byte, m.Window),
+		remain: size,
+	}
+}
+
+// Read decompresses the data into p.
+func (z *Reader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if z.copy > 0 {
+			b := z.window[z.from]
+			z.from = (z.from + 1) % len(z.window)
+			z.copy--
+			z.put(b)
+			p[n] = b
+			n++
+			continue
+		}
+		if z.err != nil {
+			return n, z.err
+		}
+		if z.remain <= 0 {
+			return n, io.EOF
+		}
+		c, err := z.decodeC()
+		if err != nil {
+			z.err = err
+			continue
+		}
+		if c <= 0xff {
+			z.remain--
+			z.put(byte(c))
+			p[n] = byte(c)
+			n++
+			continue
+		}
+		length := int64(c - (0xff + 1 - threshold))
+		dist, err := z.decodeP()
+		if err != nil {
+			z.err = err
+			continue
+		}
+		if length > z.remain {
+			length = z.remain
+		}
+		z.remain -= length
+		z.copy = int(length)
+		z.from = ((z.pos-dist-1)%len(z.window) + len(z.window)) % len(z.window)
+	}
+	return n, nil
+}
+
+func (z *Reader) put(b byte) {
+	z.window[z.pos] = b
+	z.pos++
+	if z.pos >= len(z.window) {
+		z.pos = 0
+	}
+}
+
+// decodeC returns the next literal byte or match length code.
+func (z *Reader) decodeC() (int, error) {
+	if z.block == 0 {
+		if err := z.header(); err != nil {
+			return 0, err
+		}
+	}
+	z.block--
+	return z.c.Decode(&z.bits)
+}
+
+// decodeP returns the next match position, which is the distance back from the last byte.
+func (z *Reader) decodeP() (int, error) {
+	j, err := z.p.Decode(&z.bits)
+	if err != nil {
+		return 0, err
+	}
+	if j == 0 {
+		return 0, nil
+	}
+	j--
+	return (1 << j) + int(z.bits.Read(uint(j))), nil
+}
+
+// header reads the block size and the huffman tables that start every block.
+func (z *Reader) header() error {
+	z.block = int(z.bits.Read(16))
+	t, err := z.readPT(nt, tbit, 3)
+	if err != nil {
+		return err
+	}
+	if z.c, err = z.readC(t); err != nil {
+		return err
+	}
+	z.p, err = z.readPT(z.NP, z.PBit, -1)
+	return err
+}
+
+// readPT reads the code lengths of the code length or the position huffman table.
+func (z *Reader) readPT(nn, nbit, special int) (*Huffman, error) {
+	n := int(z.bits.Read(uint(nbit)))
+	if n == 0 {
+		return Single(int(z.bits.Read(uint(nbit)))), nil
+	}
+	if n > nn {
+		return nil, ErrTable
+	}
+	lens := make([]uint8, nn)
+	for i := 0; i < n; {
+		c := int(z.bits.Read(3))
+		if c == 7 {
+			for z.bits.Read(1) == 1 {
+				c++
+				if c > maxBits {
+					return nil, ErrTable
+				}
+			}
+		}
+		lens[i] = uint8(c)
+		i++
+		if i == special {
+			zeros := int(z.bits.Read(2))
+			for ; zeros > 0 && i < nn; zeros-- {
+				lens[i] = 0
+				i++
+			}
+		}
+	}
+	return NewHuffman(lens)
+}
+
+// readC reads the code lengths of the literal and length huffman table using the t table.
+func (z *Reader) readC(t *Huffman) (*Huffman, error) {
+	n := int(z.bits.Read(cbit))
+	if n == 0 {
+		return Single(int(z.bits.Read(cbit))), nil
+	}
+	if n > nc {
+		return nil, ErrTable
+	}
+	lens := make([]uint8, nc)
+	for i := 0; i < n; {
+		c, err := t.Decode(&z.bits)
+		if err != nil {
+			return nil, err
+		}
+		if c > 2 {
+			lens[i] = uint8(c - 2)
+			i++
+			continue
+		}
+		zeros := 1
+		switch c {
+		case 1:
+			zeros = int(z.bits.Read(4)) + 3
+		case 2:
+			zeros = int(z.bits.Read(cbit)) + 20
+		}
+		if i+zeros > nc {
+			return nil, ErrTable
+		}
+		i += zeros
+	}
+	return NewHuffman(lens)
+}
+
+// Bits reads the most significant bits first from a byte stream.
+// Reads past the end of the stream return zero bits.
+type Bits struct {
+	r   io.ByteReader
+	buf uint64 // buf holds the unread bits in the high end.
+	n   uint   // n is the number of unread bits in buf.
+}
+
+// NewBits returns a bit reader of r.
+func NewBits(r io.ByteReader) *Bits {
+	return &Bits{r: r}
+}
+
+// Read returns the next n bits, n must be 32 or less.
+func (b *Bits) Read(n uint) uint32 {
+	if n == 0 {
+		return 0
+	}
+	for b.n < n {
+		c, err := b.r.ReadByte()
+		if err != nil {
+			c = 0
+		}
+		b.buf |= uint64(c) << (56 - b.n)
+		b.n += 8
+	}
+	v := uint32(b.buf >> (64 - n))
+	b.buf <<= n
+	b.n -= n
+	return v
+}
+
+// Huffman is a canonical huffman code,
+// where the shorter codes come first and codes of the same length are in symbol order.
+type Huffman struct {
+	single  int              // single is the only symbol of a code without any bits.
+	count   [maxBits + 1]int // count is the number of codes of each length.
+	symbols []int            // symbols in code order.
+}
+
+// Single returns a code of a single symbol that reads no bits.
+func Single(symbol int) *Huffman {
+	return &Huffman{single: symbol}
+}
+
+// NewHuffman returns the canonical huffman code of the code lengths indexed by symbol.
+// A zero length symbol is unused.
+func NewHuffman(lens []uint8) (*Huffman, error) {
+	h := &Huffman{single: -1}
+	for _, l := range lens {
+		if l > maxBits {
+			return nil, ErrTable
+		}
+		h.count[l]++
+	}
+	h.count[0] = 0
+	left := 1
+	for l := 1; l <= maxBits; l++ {
+		left <<= 1
+		left -= h.count[l]
+		if left < 0 {
+			return nil, ErrTable
+		}
+	}
+	for l := 1; l <= maxBits; l++ {
+		for sym, n := range lens {
+			if int(n) == l {
+				h.symbols = append(h.symbols, sym)
+			}
+		}
+	}
+	return h, nil
+}
+
+// Decode reads the next symbol from the bits.
+func (h *Huffman) Decode(b *Bits) (int, error) {
+	if h.single >= 0 {
+		return h.single, nil
+	}
+	code, first, index := 0, 0, 0
+	for l := 1; l <= maxBits; l++ {
+		code |= int(b.Read(1))
+		count := h.count[l]
+		if code-first < count {
+			return h.symbols[index+code-first], nil
+		}
+		index += count
+		first = (first + count) << 1
+		code <<= 1
+	}
+	return 0, ErrCode
+}
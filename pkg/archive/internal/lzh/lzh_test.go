@@ -0,0 +1,88 @@
+package lzh_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/Defacto2/df2/pkg/archive/internal/lzh"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBits(t *testing.T) {
+	t.Parallel()
+	b := lzh.NewBits(bytes.NewReader([]byte{0b1010_1100, 0xff}))
+	assert.Equal(t, uint32(0b101), b.Read(3))
+	assert.Equal(t, uint32(0), b.Read(0))
+	assert.Equal(t, uint32(0b01100_111), b.Read(8))
+	assert.Equal(t, uint32(0b11111), b.Read(5))
+	// reads past the end return zeros
+	assert.Equal(t, uint32(0), b.Read(16))
+}
+
+func TestHuffman(t *testing.T) {
+	t.Parallel()
+	_, err := lzh.NewHuffman([]uint8{1, 1, 1})
+	assert.ErrorIs(t, err, lzh.ErrTable)
+	_, err = lzh.NewHuffman([]uint8{17})
+	assert.ErrorIs(t, err, lzh.ErrTable)
+
+	// symbols a=2 bits, b=1 bit, c=3 bits, d=3 bits
+	// canonical codes b=0, a=10, c=110, d=111
+	h, err := lzh.NewHuffman([]uint8{2, 1, 3, 3})
+	assert.Nil(t, err)
+	b := lzh.NewBits(bytes.NewReader([]byte{0b0_10_110_11, 0b1_0000000}))
+	want := []int{1, 0, 2, 3, 1}
+	for _, w := range want {
+		got, err := h.Decode(b)
+		assert.Nil(t, err)
+		assert.Equal(t, w, got)
+	}
+	s := lzh.Single(42)
+	got, err := s.Decode(b)
+	assert.Nil(t, err)
+	assert.Equal(t, 42, got)
+}
+
+func TestReader(t *testing.T) {
+	t.Parallel()
+	// a block of 2 codes, that uses a single literal and no positions
+	r := lzh.NewReader(bytes.NewReader(single('A')), 2, lzh.LH5)
+	b, err := io.ReadAll(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "AA", string(b))
+}
+
+// single returns a block that only contains the literal c.
+func single(c byte) []byte {
+	w := writer{}
+	w.put(16, 2)      // block size
+	w.put(5, 0)       // t table count
+	w.put(5, 0)       // t table symbol
+	w.put(9, 0)       // c table count
+	w.put(9, uint(c)) // c table symbol
+	w.put(4, 0)       // p table count
+	w.put(4, 0)       // p table symbol
+	return w.bytes()
+}
+
+type writer struct {
+	b []byte
+	n uint
+}
+
+func (w *writer) put(n, v uint) {
+	for i := int(n) - 1; i >= 0; i-- {
+		if w.n%8 == 0 {
+			w.b = append(w.b, 0)
+		}
+		if v>>uint(i)&1 == 1 {
+			w.b[len(w.b)-1] |= 0x80 >> (w.n % 8)
+		}
+		w.n++
+	}
+}
+
+func (w *writer) bytes() []byte {
+	return w.b
+}
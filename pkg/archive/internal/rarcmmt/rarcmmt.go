@@ -0,0 +1,401 @@
+// Package rarcmmt reads the archive comments of RAR archives, which are not supported
+// by the rardecode package. The RAR 2 comments that are embedded in the main header are
+// read when they are stored without compression, while the RAR 3 and RAR 5 comments are
+// saved in a service header named CMT that is decompressed using the rardecode package.
+package rarcmmt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+
+	"github.com/nwaples/rardecode"
+)
+
+var (
+	ErrChecksum = errors.New("rar comment checksum error")
+	ErrEncrypt  = errors.New("rar comment is encrypted")
+	ErrFormat   = errors.New("not a valid rar archive")
+	ErrHeader   = errors.New("rar header is corrupt")
+	ErrMethod   = errors.New("rar comment compression method is not supported")
+)
+
+const (
+	sig15 = "Rar!\x1a\x07\x00"     // sig15 is the signature of RAR 1.5 to 4 archives.
+	sig50 = "Rar!\x1a\x07\x01\x00" // sig50 is the signature of RAR 5 archives.
+	cmt   = "CMT"                  // cmt is the name of the comment service header.
+
+	baseSize = 7    // baseSize is the length of a RAR 1.5 block header.
+	mainSize = 13   // mainSize is the length of a RAR 1.5 main header.
+	commSize = 13   // commSize is the length of a RAR 2 comment header.
+	stored   = 0x30 // stored is the RAR 1.5 method of data without compression.
+	maxSize  = 1 << 20
+)
+
+// RAR 1.5 block types and flags.
+const (
+	blockMain    = 0x73
+	blockFile    = 0x74
+	blockComment = 0x75
+	blockService = 0x7a
+	blockEnd     = 0x7b
+
+	mainComment  = 0x0002 // mainComment is an old style comment within the main header.
+	mainEncrypt  = 0x0080 // mainEncrypt is an archive with encrypted headers.
+	fileLarge    = 0x0100 // fileLarge is a file or service header with 64-bit sizes.
+	blockHasData = 0x8000 // blockHasData is a block that is followed by data.
+)
+
+// RAR 5 header types and flags.
+const (
+	head5Main    = 1
+	head5File    = 2
+	head5Service = 3
+	head5Encrypt = 4
+	head5End     = 5
+
+	head5Extra = 0x0001 // head5Extra is a header with an extra area.
+	head5Data  = 0x0002 // head5Data is a header that is followed by data.
+	file5Time  = 0x0002 // file5Time is a file header with a modification time.
+	file5CRC   = 0x0004 // file5CRC is a file header with a checksum.
+)
+
+// Comment returns the comment of the named RAR archive.
+// The comment is returned as it is stored without decoding any legacy character set,
+// and an empty string is returned when there is no comment.
+func Comment(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", fmt.Errorf("rar comment open: %w", err)
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Read returns the comment of the RAR archive read from r.
+func Read(r io.ReadSeeker) (string, error) {
+	sig := make([]byte, len(sig50))
+	if _, err := io.ReadFull(r, sig); err != nil {
+		return "", ErrFormat
+	}
+	switch {
+	case string(sig) == sig50:
+		return read50(r)
+	case string(sig[:len(sig15)]) == sig15:
+		if _, err := r.Seek(int64(len(sig15)), io.SeekStart); err != nil {
+			return "", fmt.Errorf("rar comment seek: %w", err)
+		}
+		return read15(r)
+	}
+	return "", ErrFormat
+}
+
+// read15 returns the comment of a RAR 1.5 to 4 archive.
+// The comment is either embedded within the main header or saved in a CMT service header
+// that must be located before the first file header.
+func read15(r io.ReadSeeker) (string, error) {
+	for first := true; ; first = false {
+		h, err := block15(r)
+		if errors.Is(err, io.EOF) {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if first != (h[2] == blockMain) {
+			return "", ErrFormat
+		}
+		flags := binary.LittleEndian.Uint16(h[3:])
+		switch h[2] {
+		case blockMain:
+			if flags&mainEncrypt != 0 {
+				return "", ErrEncrypt
+			}
+			if flags&mainComment != 0 && len(h) > mainSize {
+				return comment20(h[mainSize:])
+			}
+			continue
+		case blockService:
+			if name, ok := service15(h); ok && name == cmt {
+				return comment15(r, h)
+			}
+		case blockFile, blockEnd:
+			return "", nil
+		}
+		if _, err := r.Seek(data15(h), io.SeekCurrent); err != nil {
+			return "", fmt.Errorf("rar comment seek: %w", err)
+		}
+	}
+}
+
+// block15 reads and returns the RAR 1.5 block header at the current position of r.
+func block15(r io.Reader) ([]byte, error) {
+	h := make([]byte, baseSize)
+	if n, err := io.ReadFull(r, h); err != nil {
+		if n == 0 {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("%w: %w", ErrHeader, err)
+	}
+	size := int(binary.LittleEndian.Uint16(h[5:]))
+	if size < baseSize {
+		return nil, ErrHeader
+	}
+	h = append(h, make([]byte, size-baseSize)...)
+	if _, err := io.ReadFull(r, h[baseSize:]); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrHeader, err)
+	}
+	if h[2] != blockMain && binary.LittleEndian.Uint16(h) != uint16(crc32.ChecksumIEEE(h[2:])) {
+		return nil, fmt.Errorf("%w: %w", ErrHeader, ErrChecksum)
+	}
+	return h, nil
+}
+
+// data15 returns the size of the data that follows the RAR 1.5 block header h.
+func data15(h []byte) int64 {
+	const addSize, highSize = 7, 32
+	flags := binary.LittleEndian.Uint16(h[3:])
+	if flags&blockHasData == 0 || len(h) < addSize+4 {
+		return 0
+	}
+	size := int64(binary.LittleEndian.Uint32(h[addSize:]))
+	if (h[2] == blockFile || h[2] == blockService) && flags&fileLarge != 0 && len(h) >= highSize+4 {
+		size |= int64(binary.LittleEndian.Uint32(h[highSize:])) << 32
+	}
+	return size
+}
+
+// service15 returns the name of the RAR 1.5 service header h.
+func service15(h []byte) (string, bool) {
+	const nameLen, nameAt, large = 26, 32, 8
+	if len(h) < nameAt {
+		return "", false
+	}
+	n, at := int(binary.LittleEndian.Uint16(h[nameLen:])), nameAt
+	if binary.LittleEndian.Uint16(h[3:])&fileLarge != 0 {
+		at += large
+	}
+	if at+n > len(h) {
+		return "", false
+	}
+	return string(h[at : at+n]), true
+}
+
+// comment20 returns the RAR 2 comment that is embedded within the main header.
+// Comments that are compressed use the RAR 1.5 and 2 methods that are not supported.
+func comment20(h []byte) (string, error) {
+	if len(h) < commSize || h[2] != blockComment {
+		return "", ErrHeader
+	}
+	le := binary.LittleEndian
+	size, unpacked := int(le.Uint16(h[5:])), int(le.Uint16(h[7:]))
+	if size < commSize || size > len(h) {
+		return "", ErrHeader
+	}
+	if h[10] != stored {
+		return "", fmt.Errorf("%w: %#x", ErrMethod, h[10])
+	}
+	b := h[commSize:size]
+	if len(b) != unpacked {
+		return "", ErrHeader
+	}
+	if le.Uint16(h[11:]) != uint16(crc32.ChecksumIEEE(b)) {
+		return "", ErrChecksum
+	}
+	return text(b), nil
+}
+
+// comment15 returns the RAR 3 comment that is saved in the h CMT service header.
+// The service header is rewritten as a file header of a new archive so that
+// the rardecode package can decompress the comment.
+func comment15(r io.Reader, h []byte) (string, error) {
+	size := data15(h)
+	if size > maxSize {
+		return "", ErrHeader
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrHeader, err)
+	}
+	file := append([]byte{}, h...)
+	file[2] = blockFile
+	binary.LittleEndian.PutUint16(file, uint16(crc32.ChecksumIEEE(file[2:])))
+	var buf bytes.Buffer
+	buf.WriteString(sig15)
+	buf.Write(file)
+	buf.Write(data)
+	return decompress(&buf)
+}
+
+// read50 returns the comment of a RAR 5 archive that is saved in a CMT service header,
+// which must be located before the first file header.
+func read50(r io.ReadSeeker) (string, error) {
+	for {
+		raw, h, err := block50(r)
+		if errors.Is(err, io.EOF) {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		switch h.kind {
+		case head5Encrypt:
+			return "", ErrEncrypt
+		case head5File, head5End:
+			return "", nil
+		case head5Service:
+			if h.name == cmt {
+				return comment50(r, raw, h)
+			}
+		case head5Main:
+		}
+		if _, err := r.Seek(h.data, io.SeekCurrent); err != nil {
+			return "", fmt.Errorf("rar comment seek: %w", err)
+		}
+	}
+}
+
+// header50 is a parsed RAR 5 header.
+type header50 struct {
+	kind uint64 // kind of header.
+	at   int    // at is the index of the kind within the raw header.
+	data int64  // data is the size of the data that follows the header.
+	name string // name of the file or service header.
+}
+
+// block50 reads the RAR 5 header at the current position of r and returns it both raw and parsed.
+func block50(r io.Reader) ([]byte, header50, error) {
+	const crcSize, maxVint = 4, 3
+	raw := make([]byte, crcSize+maxVint)
+	if n, err := io.ReadFull(r, raw); err != nil {
+		if n == 0 {
+			return nil, header50{}, io.EOF
+		}
+		return nil, header50{}, fmt.Errorf("%w: %w", ErrHeader, err)
+	}
+	size, n := binary.Uvarint(raw[crcSize:])
+	if n <= 0 || size == 0 || size > maxSize {
+		return nil, header50{}, ErrHeader
+	}
+	at := crcSize + n
+	if at+int(size) < len(raw) {
+		return nil, header50{}, ErrHeader
+	}
+	raw = append(raw, make([]byte, at+int(size)-len(raw))...)
+	if _, err := io.ReadFull(r, raw[crcSize+maxVint:]); err != nil {
+		return nil, header50{}, fmt.Errorf("%w: %w", ErrHeader, err)
+	}
+	if binary.LittleEndian.Uint32(raw) != crc32.ChecksumIEEE(raw[crcSize:]) {
+		return nil, header50{}, fmt.Errorf("%w: %w", ErrHeader, ErrChecksum)
+	}
+	h, err := parse50(raw[at:])
+	h.at = at
+	return raw, h, err
+}
+
+// parse50 parses the b RAR 5 header that starts with the header type.
+func parse50(b []byte) (header50, error) {
+	var h header50
+	v := vints{b: b}
+	h.kind = v.next()
+	flags := v.next()
+	if flags&head5Extra != 0 {
+		v.next()
+	}
+	if flags&head5Data != 0 {
+		h.data = int64(v.next())
+	}
+	if h.kind == head5Service || h.kind == head5File {
+		fileFlags := v.next()
+		v.next() // unpacked size
+		v.next() // attributes
+		if fileFlags&file5Time != 0 {
+			v.skip(4)
+		}
+		if fileFlags&file5CRC != 0 {
+			v.skip(4)
+		}
+		v.next() // compression information
+		v.next() // host os
+		n := int(v.next())
+		h.name = string(v.bytes(n))
+	}
+	if v.err {
+		return header50{}, ErrHeader
+	}
+	return h, nil
+}
+
+// comment50 returns the RAR 5 comment that is saved in the h CMT service header.
+func comment50(r io.Reader, raw []byte, h header50) (string, error) {
+	if h.data > maxSize {
+		return "", ErrHeader
+	}
+	data := make([]byte, h.data)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrHeader, err)
+	}
+	file := append([]byte{}, raw...)
+	file[h.at] = head5File
+	binary.LittleEndian.PutUint32(file, crc32.ChecksumIEEE(file[4:]))
+	var buf bytes.Buffer
+	buf.WriteString(sig50)
+	buf.Write(file)
+	buf.Write(data)
+	return decompress(&buf)
+}
+
+// decompress returns the content of the only file in the r archive.
+func decompress(r io.Reader) (string, error) {
+	rr, err := rardecode.NewReader(r, "")
+	if err != nil {
+		return "", fmt.Errorf("rar comment reader: %w", err)
+	}
+	if _, err := rr.Next(); err != nil {
+		return "", fmt.Errorf("rar comment header: %w", err)
+	}
+	b, err := io.ReadAll(io.LimitReader(rr, maxSize))
+	if err != nil {
+		return "", fmt.Errorf("rar comment decompress: %w", err)
+	}
+	return text(b), nil
+}
+
+// text returns the comment without any trailing null or end of file characters.
+func text(b []byte) string {
+	return string(bytes.TrimRight(b, "\x00\x1a"))
+}
+
+// vints reads the variable length integers of a RAR 5 header.
+type vints struct {
+	b   []byte
+	err bool
+}
+
+func (v *vints) next() uint64 {
+	x, n := binary.Uvarint(v.b)
+	if n <= 0 {
+		v.err = true
+		return 0
+	}
+	v.b = v.b[n:]
+	return x
+}
+
+func (v *vints) skip(n int) {
+	v.bytes(n)
+}
+
+func (v *vints) bytes(n int) []byte {
+	if n < 0 || n > len(v.b) {
+		v.err = true
+		return nil
+	}
+	b := v.b[:n]
+	v.b = v.b[n:]
+	return b
+}
@@ -0,0 +1,49 @@
+package rarcmmt_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Defacto2/df2/pkg/archive/internal/rarcmmt"
+	"github.com/stretchr/testify/assert"
+)
+
+func testDir(name string) string {
+	dir, _ := os.Getwd()
+	return filepath.Join(dir, "..", "..", "..", "..", "testdata", name)
+}
+
+const cmmt = "Greetings from the\r\nRAR comment BBS \xcd\xcd\r\n"
+
+func TestComment(t *testing.T) {
+	t.Parallel()
+	_, err := rarcmmt.Comment("")
+	assert.NotNil(t, err)
+	_, err = rarcmmt.Comment(testDir("demozoo/test.zip"))
+	assert.ErrorIs(t, err, rarcmmt.ErrFormat)
+	for _, name := range []string{"cmt2.rar", "cmt3.rar", "cmt5.rar"} {
+		s, err := rarcmmt.Comment(testDir(filepath.Join("rar", name)))
+		assert.Nil(t, err, name)
+		assert.Equal(t, cmmt, s, name)
+	}
+	for _, name := range []string{"rar/nocmt.rar", "rar/dizzer.rar", "demozoo/test.rar"} {
+		s, err := rarcmmt.Comment(testDir(name))
+		assert.Nil(t, err, name)
+		assert.Equal(t, "", s, name)
+	}
+}
+
+func TestRead(t *testing.T) {
+	t.Parallel()
+	b, err := os.ReadFile(testDir("rar/cmt3.rar"))
+	assert.Nil(t, err)
+	// corrupt the checksum of the comment
+	i := bytes.Index(b, []byte("Greetings"))
+	b[i] = 'g'
+	_, err = rarcmmt.Read(bytes.NewReader(b))
+	assert.NotNil(t, err)
+	_, err = rarcmmt.Read(bytes.NewReader(b[:10]))
+	assert.NotNil(t, err)
+}
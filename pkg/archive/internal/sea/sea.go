@@ -0,0 +1,222 @@
+// Package sea reads and extracts ARC archives, the format created by
+// System Enhancement Associates for the ARC archiver on MS-DOS,
+// including the ARK archives of CP/M and the PAK archives of NoGate Consulting.
+// Files that are stored, packed, squeezed, crunched or squashed are supported,
+// but the PAK crushed and distilled methods can only be listed.
+package sea
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/Defacto2/df2/pkg/archive/internal/crc16"
+	"github.com/Defacto2/df2/pkg/archive/internal/dosarc"
+)
+
+var (
+	ErrChecksum = errors.New("arc checksum error")
+	ErrData     = errors.New("arc compressed data is corrupt")
+	ErrFormat   = errors.New("not a valid arc archive")
+	ErrHeader   = errors.New("arc header is corrupt")
+	ErrMethod   = errors.New("arc compression method is not supported")
+)
+
+const (
+	marker   = 0x1a // marker is the first byte of every header.
+	nameSize = 13   // nameSize is the length of the null terminated filename.
+	oldSize  = 25   // oldSize is the length of a header without the original file size.
+	headSize = 29   // headSize is the length of a header.
+	info     = 20   // info is the first method used for archive information records.
+)
+
+// Compression methods.
+const (
+	End       = 0  // End of the archive.
+	Unpacked  = 1  // Unpacked is stored using an obsolete header without the file size.
+	Stored    = 2  // Stored without any compression.
+	Packed    = 3  // Packed with run-length encoding.
+	Squeezed  = 4  // Squeezed with run-length encoding and huffman codes.
+	Crunched  = 8  // Crunched with run-length encoding and dynamic LZW of up to 12 bits.
+	Squashed  = 9  // Squashed with dynamic LZW of up to 13 bits.
+	Crushed   = 10 // Crushed is a PAK method that cannot be extracted.
+	Distilled = 11 // Distilled is a PAK method that cannot be extracted.
+)
+
+// File is a file stored in an ARC archive.
+type File struct {
+	Name           string    // Name of the file.
+	Method         int       // Method of compression.
+	Modified       time.Time // Modified is the last modification time of the file.
+	CompressedSize int64     // CompressedSize of the file data.
+	Size           int64     // Size of the uncompressed file.
+	CRC16          uint16    // CRC16 checksum of the uncompressed file.
+	r              io.ReaderAt
+	offset         int64 // offset of the file data.
+}
+
+// Open returns a reader of the uncompressed file content.
+// The checksum of the content is validated when the end of the file is read.
+func (f *File) Open() (io.ReadCloser, error) {
+	data := io.NewSectionReader(f.r, f.offset, f.CompressedSize)
+	var r io.Reader
+	switch f.Method {
+	case Unpacked, Stored:
+		r = data
+	case Packed:
+		r = newRLE(data)
+	case Squeezed:
+		r = newRLE(newSqueeze(data))
+	case Crunched:
+		r = newRLE(newLZW(data, crunchBits, true))
+	case Squashed:
+		r = newLZW(data, squashBits, false)
+	default:
+		return nil, fmt.Errorf("%w: %d %s", ErrMethod, f.Method, f.Name)
+	}
+	return &checksum{
+		r:    io.LimitReader(r, f.Size),
+		want: f.CRC16,
+		size: f.Size,
+	}, nil
+}
+
+// checksum validates the CRC16 and size of a file when the end is read.
+type checksum struct {
+	r    io.Reader
+	crc  crc16.Digest
+	want uint16
+	size int64
+	read int64
+}
+
+func (c *checksum) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.crc.Write(p[:n])
+	c.read += int64(n)
+	if errors.Is(err, io.EOF) {
+		if c.read != c.size || c.crc.Sum16() != c.want {
+			return n, ErrChecksum
+		}
+	}
+	return n, err //nolint:wrapcheck
+}
+
+func (c *checksum) Close() error {
+	return nil
+}
+
+// Reader is an ARC archive.
+type Reader struct {
+	File []*File // File lists the files in the archive.
+}
+
+// NewReader returns a Reader of the ARC archive read from r, which is size bytes long.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	z := &Reader{}
+	for off := int64(0); off < size; {
+		f, err := header(r, off)
+		if err != nil {
+			if off == 0 {
+				return nil, ErrFormat
+			}
+			return nil, err
+		}
+		if f == nil {
+			break
+		}
+		if f.offset+f.CompressedSize > size {
+			return nil, fmt.Errorf("%w: %s data is truncated", ErrHeader, f.Name)
+		}
+		off = f.offset + f.CompressedSize
+		if f.Method >= info {
+			continue
+		}
+		z.File = append(z.File, f)
+	}
+	if len(z.File) == 0 {
+		return nil, ErrFormat
+	}
+	return z, nil
+}
+
+// ReadCloser is an ARC archive file that must be closed after use.
+type ReadCloser struct {
+	Reader
+	f *os.File
+}
+
+// OpenReader opens the named ARC archive file.
+func OpenReader(name string) (*ReadCloser, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("arc open: %w", err)
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("arc stat: %w", err)
+	}
+	r, err := NewReader(f, st.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &ReadCloser{Reader: *r, f: f}, nil
+}
+
+// Close the archive file.
+func (rc *ReadCloser) Close() error {
+	return rc.f.Close()
+}
+
+// header reads and returns the file header at the offset.
+// A nil file is returned for the end of archive marker.
+func header(r io.ReaderAt, off int64) (*File, error) {
+	var h [headSize]byte
+	n, err := r.ReadAt(h[:], off)
+	if n < 2 {
+		return nil, fmt.Errorf("%w: %w", ErrHeader, err)
+	}
+	if h[0] != marker {
+		return nil, ErrHeader
+	}
+	method := int(h[1])
+	if method == End {
+		return nil, nil
+	}
+	const maxMethod = 0x7f
+	size := headSize
+	if method == Unpacked {
+		size = oldSize
+	}
+	if method > maxMethod || n < size {
+		return nil, ErrHeader
+	}
+	le := binary.LittleEndian
+	name := h[2 : 2+nameSize]
+	if i := bytes.IndexByte(name, 0); i >= 0 {
+		name = name[:i]
+	}
+	if len(name) == 0 {
+		return nil, ErrHeader
+	}
+	f := &File{
+		Name:           dosarc.Decode(name),
+		Method:         method,
+		CompressedSize: int64(le.Uint32(h[15:])),
+		Modified:       dosarc.MSDOS(le.Uint16(h[19:]), le.Uint16(h[21:])),
+		CRC16:          le.Uint16(h[23:]),
+		r:              r,
+		offset:         off + int64(size),
+	}
+	f.Size = f.CompressedSize
+	if method != Unpacked {
+		f.Size = int64(le.Uint32(h[25:]))
+	}
+	return f, nil
+}
@@ -0,0 +1,147 @@
+package sea_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Defacto2/df2/pkg/archive/internal/sea"
+	"github.com/stretchr/testify/assert"
+)
+
+func testDir(name string) string {
+	dir, _ := os.Getwd()
+	return filepath.Join(dir, "..", "..", "..", "..", "testdata", name)
+}
+
+const (
+	hello = "Hello world.\r\n"
+	last  = "00699 The quick brown fox jumps over the lazy dog, line 33."
+)
+
+func TestOpenReader(t *testing.T) {
+	t.Parallel()
+	_, err := sea.OpenReader("")
+	assert.NotNil(t, err)
+	_, err = sea.OpenReader(testDir("demozoo/test.zip"))
+	assert.ErrorIs(t, err, sea.ErrFormat)
+
+	z, err := sea.OpenReader(testDir("arc/methods.arc"))
+	assert.Nil(t, err)
+	defer z.Close()
+	assert.Len(t, z.File, 7)
+	methods := []int{sea.Unpacked, sea.Stored, sea.Packed, sea.Squeezed, sea.Crunched, sea.Squashed}
+	for i, m := range methods {
+		assert.Equal(t, m, z.File[i].Method)
+	}
+	assert.Equal(t, "SQUEEZED.TXT", z.File[3].Name)
+	assert.Equal(t, time.Date(1994, 6, 15, 12, 30, 20, 0, time.UTC), z.File[0].Modified)
+	assert.Equal(t, int64(len(hello)*20), z.File[0].Size)
+}
+
+func TestFile_Open(t *testing.T) {
+	t.Parallel()
+	z, err := sea.OpenReader(testDir("arc/methods.arc"))
+	assert.Nil(t, err)
+	defer z.Close()
+	for _, f := range z.File {
+		r, err := f.Open()
+		assert.Nil(t, err, f.Name)
+		b, err := io.ReadAll(r)
+		assert.Nil(t, err, f.Name)
+		assert.Equal(t, f.Size, int64(len(b)), f.Name)
+		switch f.Name {
+		case "OLDSTORE.TXT", "STORED.TXT":
+			assert.True(t, strings.HasPrefix(string(b), hello), f.Name)
+		case "PACKED.ANS":
+			assert.Contains(t, string(b), strings.Repeat("=", 600)+strings.Repeat("\x90", 5))
+		case "SQUEEZED.TXT":
+			assert.Contains(t, string(b), hello+"00000 The quick brown fox")
+		case "CRUNCHED.NFO", "SQUASHED.BIN":
+			assert.Contains(t, string(b), last, f.Name)
+		}
+	}
+}
+
+func TestPAK(t *testing.T) {
+	t.Parallel()
+	z, err := sea.OpenReader(testDir("arc/methods.pak"))
+	assert.Nil(t, err)
+	defer z.Close()
+	assert.Len(t, z.File, 3)
+	r, err := z.File[0].Open()
+	assert.Nil(t, err)
+	b, err := io.ReadAll(r)
+	assert.Nil(t, err)
+	assert.Equal(t, strings.Repeat(hello, 20), string(b))
+	_, err = z.File[1].Open()
+	assert.ErrorIs(t, err, sea.ErrMethod)
+	_, err = z.File[2].Open()
+	assert.ErrorIs(t, err, sea.ErrMethod)
+}
+
+func TestNewReader(t *testing.T) {
+	t.Parallel()
+	b, err := os.ReadFile(testDir("arc/hello.arc"))
+	assert.Nil(t, err)
+	_, err = sea.NewReader(bytes.NewReader(b[:20]), 20)
+	assert.ErrorIs(t, err, sea.ErrFormat)
+
+	// the end of archive marker is optional
+	z, err := sea.NewReader(bytes.NewReader(b[:len(b)-2]), int64(len(b)-2))
+	assert.Nil(t, err)
+	assert.Len(t, z.File, 1)
+
+	// corrupt the compressed data
+	bad := bytes.Clone(b)
+	bad[len(bad)-10] ^= 0xff
+	z, err = sea.NewReader(bytes.NewReader(bad), int64(len(bad)))
+	assert.Nil(t, err)
+	r, err := z.File[0].Open()
+	assert.Nil(t, err)
+	_, err = io.ReadAll(r)
+	assert.NotNil(t, err)
+}
+
+func TestList(t *testing.T) {
+	t.Parallel()
+	files, err := sea.List(testDir("arc/methods.pak"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"SQUASHED.TXT", "CRUSHED.TXT", "DISTILL.TXT"}, files)
+	files, err = sea.List(testDir("arc/methods.arc"))
+	assert.Nil(t, err)
+	assert.Len(t, files, 7)
+}
+
+func TestExtract(t *testing.T) {
+	t.Parallel()
+	src := testDir("arc/methods.arc")
+	err := sea.Extract(src, "")
+	assert.ErrorIs(t, err, sea.ErrDest)
+
+	dir := t.TempDir()
+	err = sea.Extract(src, dir)
+	assert.Nil(t, err)
+	b, err := os.ReadFile(filepath.Join(dir, "CRUNCHED.NFO"))
+	assert.Nil(t, err)
+	assert.Len(t, b, 68420)
+	st, err := os.Stat(filepath.Join(dir, "EMPTY.TXT"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), st.Size())
+
+	dir = t.TempDir()
+	err = sea.Extract(src, dir, "squashed.bin")
+	assert.Nil(t, err)
+	b, err = os.ReadFile(filepath.Join(dir, "SQUASHED.BIN"))
+	assert.Nil(t, err)
+	assert.Len(t, b, 70510)
+	_, err = os.Stat(filepath.Join(dir, "STORED.TXT"))
+	assert.NotNil(t, err)
+
+	err = sea.Extract(src, dir, "nothing.txt")
+	assert.ErrorIs(t, err, sea.ErrTarget)
+}
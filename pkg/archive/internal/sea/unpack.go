@@ -0,0 +1,292 @@
+package sea
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const (
+	dle        = 0x90 // dle is the run-length encoding marker.
+	speof      = 256  // speof is the huffman symbol that ends a squeezed stream.
+	maxNodes   = 256  // maxNodes is the largest number of nodes in a squeeze tree.
+	clear      = 256  // clear is the LZW code that resets the string table.
+	first      = 257  // first is the first free LZW code.
+	initBits   = 9    // initBits is the LZW code width after a clear.
+	crunchBits = 12   // crunchBits is the widest LZW code of the crunched method.
+	squashBits = 13   // squashBits is the widest LZW code of the squashed method.
+)
+
+// rle expands the run-length encoding, where the marker byte and a count
+// repeats the previous byte and a count of zero is the marker byte itself.
+type rle struct {
+	r      io.ByteReader
+	last   byte
+	repeat int
+}
+
+func newRLE(r io.Reader) *rle {
+	return &rle{r: bufio.NewReader(r)}
+}
+
+func (z *rle) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if z.repeat > 0 {
+			p[n] = z.last
+			z.repeat--
+			n++
+			continue
+		}
+		b, err := z.r.ReadByte()
+		if err != nil {
+			return n, err //nolint:wrapcheck
+		}
+		if b != dle {
+			z.last = b
+			p[n] = b
+			n++
+			continue
+		}
+		c, err := z.r.ReadByte()
+		if err != nil {
+			return n, io.ErrUnexpectedEOF
+		}
+		if c == 0 {
+			p[n] = dle
+			n++
+			continue
+		}
+		z.repeat = int(c) - 1
+	}
+	return n, nil
+}
+
+// squeeze decodes the huffman codes of a squeezed stream,
+// which starts with the tree of the codes.
+type squeeze struct {
+	r     *bufio.Reader
+	nodes [][2]int16
+	bits  byte // bits holds the unread bits in the low end.
+	n     uint // n is the number of unread bits.
+	done  bool
+	err   error
+}
+
+func newSqueeze(r io.Reader) *squeeze {
+	z := &squeeze{r: bufio.NewReader(r)}
+	var count uint16
+	if err := binary.Read(z.r, binary.LittleEndian, &count); err != nil {
+		z.err = ErrData
+		return z
+	}
+	if count > maxNodes {
+		z.err = ErrData
+		return z
+	}
+	z.nodes = make([][2]int16, count)
+	if err := binary.Read(z.r, binary.LittleEndian, z.nodes); err != nil {
+		z.err = ErrData
+		return z
+	}
+	for _, node := range z.nodes {
+		for _, child := range node {
+			if int(child) >= len(z.nodes) || -int(child)-1 > speof {
+				z.err = ErrData
+				return z
+			}
+		}
+	}
+	z.done = count == 0
+	return z
+}
+
+func (z *squeeze) Read(p []byte) (int, error) {
+	if z.err != nil {
+		return 0, z.err
+	}
+	n := 0
+	for n < len(p) {
+		if z.done {
+			return n, io.EOF
+		}
+		i := 0
+		for i >= 0 {
+			if z.n == 0 {
+				b, err := z.r.ReadByte()
+				if err != nil {
+					z.err = io.ErrUnexpectedEOF
+					return n, z.err
+				}
+				z.bits, z.n = b, 8
+			}
+			bit := z.bits & 1
+			z.bits >>= 1
+			z.n--
+			i = int(z.nodes[i][bit])
+		}
+		c := -(i + 1)
+		if c == speof {
+			z.done = true
+			continue
+		}
+		p[n] = byte(c)
+		n++
+	}
+	return n, nil
+}
+
+// lzw decodes the dynamic LZW codes used by the crunched and squashed methods,
+// which is the algorithm of the Unix compress program.
+// The codes are read in groups of eight and a change of code width skips
+// the remainder of the group.
+type lzw struct {
+	r       io.Reader
+	maxBits int
+	maxMax  int  // maxMax is the number of entries in a full string table.
+	nBits   int  // nBits is the width of the codes.
+	maxCode int  // maxCode is the largest code of the width.
+	freeEnt int  // freeEnt is the next free entry of the string table.
+	reset   bool // reset is set after a clear code.
+	group   []byte
+	offset  int // offset is the bit position in the group.
+	size    int // size is the number of usable bits in the group.
+	prefix  []uint16
+	suffix  []byte
+	oldCode int
+	finChar byte
+	started bool
+	stack   []byte
+	out     []byte // out is the decoded output waiting to be read.
+	err     error
+}
+
+func newLZW(r io.Reader, maxBits int, header bool) *lzw {
+	z := &lzw{
+		r:       bufio.NewReader(r),
+		maxBits: maxBits,
+		maxMax:  1 << maxBits,
+		nBits:   initBits,
+		maxCode: 1<<initBits - 1,
+		freeEnt: first,
+		group:   make([]byte, maxBits),
+		prefix:  make([]uint16, 1<<maxBits),
+		suffix:  make([]byte, 1<<maxBits),
+	}
+	for i := 0; i < clear; i++ {
+		z.suffix[i] = byte(i)
+	}
+	if header {
+		// the crunched method starts with the widest code size
+		b := make([]byte, 1)
+		if _, err := io.ReadFull(z.r, b); err != nil || int(b[0]) != maxBits {
+			z.err = ErrData
+		}
+	}
+	return z
+}
+
+// code returns the next code or io.EOF when there are no more codes.
+func (z *lzw) code() (int, error) {
+	if z.reset || z.offset >= z.size || z.freeEnt > z.maxCode {
+		if z.freeEnt > z.maxCode {
+			z.nBits++
+			z.maxCode = 1<<z.nBits - 1
+			if z.nBits == z.maxBits {
+				z.maxCode = z.maxMax
+			}
+		}
+		if z.reset {
+			z.nBits = initBits
+			z.maxCode = 1<<initBits - 1
+			z.reset = false
+		}
+		n, err := io.ReadFull(z.r, z.group[:z.nBits])
+		if n == 0 {
+			if err == nil || errors.Is(err, io.ErrUnexpectedEOF) {
+				err = io.EOF
+			}
+			return 0, err //nolint:wrapcheck
+		}
+		z.offset = 0
+		z.size = n*8 - (z.nBits - 1)
+	}
+	code := 0
+	for i := 0; i < z.nBits; i++ {
+		bit := z.offset + i
+		code |= int(z.group[bit>>3]>>(bit&7)&1) << i
+	}
+	z.offset += z.nBits
+	return code, nil
+}
+
+func (z *lzw) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(z.out) > 0 {
+			c := copy(p[n:], z.out)
+			z.out = z.out[c:]
+			n += c
+			continue
+		}
+		if z.err != nil {
+			return n, z.err
+		}
+		z.err = z.decode()
+	}
+	return n, nil
+}
+
+// decode the next code into the output.
+func (z *lzw) decode() error {
+	code, err := z.code()
+	if err != nil {
+		return err
+	}
+	if !z.started {
+		z.started = true
+		if code >= clear {
+			return ErrData
+		}
+		z.oldCode, z.finChar = code, byte(code)
+		z.out = append(z.out[:0], z.finChar)
+		return nil
+	}
+	if code == clear {
+		z.reset = true
+		z.freeEnt = first - 1
+		if code, err = z.code(); err != nil {
+			return err
+		}
+	}
+	in := code
+	z.stack = z.stack[:0]
+	if code > z.freeEnt {
+		return ErrData
+	}
+	if code == z.freeEnt {
+		z.stack = append(z.stack, z.finChar)
+		code = z.oldCode
+	}
+	for code >= clear {
+		if len(z.stack) >= z.maxMax {
+			return ErrData
+		}
+		z.stack = append(z.stack, z.suffix[code])
+		code = int(z.prefix[code])
+	}
+	z.finChar = z.suffix[code]
+	z.stack = append(z.stack, z.finChar)
+	z.out = z.out[:0]
+	for i := len(z.stack) - 1; i >= 0; i-- {
+		z.out = append(z.out, z.stack[i])
+	}
+	if z.freeEnt < z.maxMax {
+		z.prefix[z.freeEnt] = uint16(z.oldCode)
+		z.suffix[z.freeEnt] = z.finChar
+		z.freeEnt++
+	}
+	z.oldCode = in
+	return nil
+}
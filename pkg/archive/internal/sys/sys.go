@@ -1,5 +1,7 @@
 // Package sys uses programs installed to the host operating system to handle
 // miscellaneous archives not usable with the Go packages.
+// ARC, ARJ, LHA and ZOO archives are the exception and are handled by the native
+// sea, arj, lha and zoo packages.
 package sys
 
 import (
@@ -8,11 +10,16 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
+
+	"github.com/Defacto2/df2/pkg/archive/internal/arj"
+	"github.com/Defacto2/df2/pkg/archive/internal/lha"
+	"github.com/Defacto2/df2/pkg/archive/internal/sea"
+	"github.com/Defacto2/df2/pkg/archive/internal/zoo"
+	"github.com/Defacto2/df2/pkg/magic"
 )
 
 var (
@@ -20,7 +27,6 @@ var (
 	ErrMagic      = errors.New("no unsupport for magic file type")
 	ErrProg       = errors.New("archive program error")
 	ErrReadr      = errors.New("system could not read the file archive")
-	ErrTypeOut    = errors.New("magic file program result is empty")
 	ErrSilent     = errors.New("archiver program silently failed, it return no output or errors")
 	ErrWrongExt   = errors.New("filename has the wrong file extension")
 	ErrUnknownExt = errors.New("the archive uses an unsupported file extension")
@@ -29,50 +35,36 @@ var (
 const (
 	// permitted archives on the site:
 	// 7z,arc,ark,arj,cab,gz,lha,lzh,rar,tar,tar.gz,zip.
+	arcx = ".arc" // ARC by System Enhancement Associates
 	arjx = ".arj" // Archived by Robert Jung
+	arkx = ".ark" // ARC for CP/M systems
 	lhax = ".lha" // LHarc by Haruyasu Yoshizaki (Yoshi)
+	lzhx = ".lzh" // LHarc alternative extension
+	pakx = ".pak" // PAK by NoGate Consulting, an extension of ARC
 	rarx = ".rar" // Roshal ARchive by Alexander Roshal
 	zipx = ".zip" // Phil Katz's ZIP for MSDOS systems
+	zoox = ".zoo" // Zoo by Rahul Dhesi
 )
 
-// MagicExt uses the Linux file program to determine the src archive file type.
+// MagicExt uses the file signature to determine the src archive file type.
 // The returned string will be a file separator and extension.
-// Note both bzip2 and gzip archives return a .tar extension prefix.
+// Note bzip2 and gzip archives return a .tar extension prefix.
+// Archives that cannot be read, such as 7-Zip or Cabinet, return an error.
 func MagicExt(src string) (string, error) {
-	prog, err := exec.LookPath("file")
-	if err != nil {
-		return "", fmt.Errorf("magic file type: %w", err)
-	}
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	cmd := exec.CommandContext(ctx, prog, "--brief", src)
-	out, err := cmd.Output()
+	t, err := magic.File(src)
 	if err != nil {
 		return "", fmt.Errorf("magic file type: %w", err)
 	}
-	if len(out) == 0 {
-		return "", fmt.Errorf("magic file type: %w", ErrTypeOut)
-	}
-	magics := map[string]string{
-		"7-zip archive data":    ".7z",
-		"arj archive data":      arjx,
-		"bzip2 compressed data": ".tar.bz2",
-		"gzip compressed data":  ".tar.gz",
-		"rar archive data":      ".rar",
-		"posix tar archive":     ".tar",
-		"zip archive data":      zipx,
-	}
-	s := strings.Split(strings.ToLower(string(out)), ",")
-	magic := strings.TrimSpace(s[0])
-	for magic, ext := range magics {
-		if strings.TrimSpace(s[0]) == magic {
-			return ext, nil
-		}
+	if !t.Archive() {
+		return "", fmt.Errorf("%w: %q", ErrMagic, t)
 	}
-	if MagicLHA(magic) {
-		return lhax, nil
+	switch t.Ext {
+	case arcx, arjx, lhax, pakx, rarx, ".tar", zipx, zoox:
+		return t.Ext, nil
+	case ".bz2", ".gz":
+		return ".tar" + t.Ext, nil
 	}
-	return "", fmt.Errorf("%w: %q", ErrMagic, magic)
+	return "", fmt.Errorf("%w: %q", ErrUnknownExt, t)
 }
 
 // MagicLHA returns true if the LHA file type is matched in the magic string.
@@ -94,6 +86,21 @@ func MagicLHA(magic string) bool {
 	return false
 }
 
+// SameExt returns true if the ext file extensions are the same archive format,
+// where the ARK and PAK extensions are ARC archives and the LZH extension is an LHA archive.
+func SameExt(ext1, ext2 string) bool {
+	alias := func(ext string) string {
+		switch ext = strings.ToLower(ext); ext {
+		case arkx, pakx:
+			return arcx
+		case lzhx:
+			return lhax
+		}
+		return ext
+	}
+	return alias(ext1) == alias(ext2)
+}
+
 // Rename the filename by replacing the file extension with the ext string.
 // Leaving ext empty returns the filename without a file extension.
 func Rename(ext, filename string) string {
@@ -119,19 +126,23 @@ func Readr(w io.Writer, src, filename string) ([]string, string, error) {
 	if err != nil {
 		return []string{}, "", fmt.Errorf("system reader: %w", err)
 	}
-	if !strings.EqualFold(ext, filepath.Ext(filename)) {
+	if !SameExt(ext, filepath.Ext(filename)) {
 		// retry using correct filename extension
 		return []string{}, ext, fmt.Errorf("system reader: %w", ErrWrongExt)
 	}
 	switch strings.ToLower(ext) {
+	case arcx, arkx, pakx:
+		return ARCReader(src)
 	case arjx:
 		return ARJReader(src)
-	case lhax:
+	case lhax, lzhx:
 		return LHAReader(src)
 	case rarx:
 		return RarReader(src)
 	case zipx:
 		return ZipReader(w, src)
+	case zoox:
+		return ZOOReader(src)
 	}
 	return []string{}, "", fmt.Errorf("system reader: %w", ErrReadr)
 }
@@ -143,78 +154,53 @@ func Readr(w io.Writer, src, filename string) ([]string, string, error) {
 func Extract(filename, src, targets, dest string) error {
 	ext := strings.ToLower(filepath.Ext(filename))
 	switch ext {
+	case arcx, arkx, pakx:
+		return ARCExtract(src, targets, dest)
 	case arjx:
 		return ARJExtract(src, targets, dest)
-	case lhax:
+	case lhax, lzhx:
 		return LHAExtract(src, targets, dest)
 	case zipx:
 		return ZipExtract(src, targets, dest)
+	case zoox:
+		return ZOOExtract(src, targets, dest)
 	default:
 		return ErrUnknownExt
 	}
 }
 
+// ARCExtract extracts the targets from the src ARC, ARK or PAK archive
+// to the dest directory using the native ARC reader.
+func ARCExtract(src, targets, dest string) error {
+	if err := sea.Extract(src, dest, targets); err != nil {
+		return fmt.Errorf("arc extract: %w", err)
+	}
+	return nil
+}
+
 // ARJExtract extracts the targets from the src ARJ archive
-// to the dest directory using the Linux arj program.
+// to the dest directory using the native ARJ reader.
 func ARJExtract(src, targets, dest string) error {
-	if st, err := os.Stat(dest); err != nil {
-		return fmt.Errorf("%w: %s", err, dest)
-	} else if !st.IsDir() {
-		return fmt.Errorf("%w: %s", ErrDest, dest)
-	}
-	// note: only use arj, as unarj offers limited functionality
-	prog, err := exec.LookPath("arj")
-	if err != nil {
+	if err := arj.Extract(src, dest, targets); err != nil {
 		return fmt.Errorf("arj extract: %w", err)
 	}
-	var b bytes.Buffer
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	// arj x archive destdir/ *
-	const extract = "x"
-	cmd := exec.CommandContext(ctx, prog, extract, src, dest, targets)
-	cmd.Stderr = &b
-	if err = cmd.Run(); err != nil {
-		if b.String() != "" {
-			return fmt.Errorf("%w: %s: %q", ErrProg, prog, strings.TrimSpace(b.String()))
-		}
-		return fmt.Errorf("%w: %s", err, prog)
-	}
 	return nil
 }
 
 // LHAExtract extracts the targets from the src LHA/LZH archive
-// to the dest directory using a Linux lha program.
-// Either jlha-utils or lhasa work.
-// Targets with spaces in their names are ignored by the program.
+// to the dest directory using the native LHA reader.
 func LHAExtract(src, targets, dest string) error {
-	prog, err := exec.LookPath("lha")
-	if err != nil {
+	if err := lha.Extract(src, dest, targets); err != nil {
 		return fmt.Errorf("lha extract: %w", err)
 	}
-	var b bytes.Buffer
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	// lha -eq2w=destdir/ archive *
-	const (
-		extract     = "e"
-		ignorepaths = "i"
-		overwrite   = "f"
-		quiet       = "q1"
-		quieter     = "q2"
-	)
-	params := fmt.Sprintf("-%s%s%sw=%s", extract, overwrite, ignorepaths, dest)
-	cmd := exec.CommandContext(ctx, prog, params, src, targets)
-	cmd.Stderr = &b
-	out, err := cmd.Output()
-	if err != nil {
-		if b.String() != "" {
-			return fmt.Errorf("%w: %s: %s", ErrProg, prog, strings.TrimSpace(b.String()))
-		}
-		return fmt.Errorf("%s: %w", prog, err)
-	}
-	if len(out) == 0 {
-		return ErrSilent
+	return nil
+}
+
+// ZOOExtract extracts the targets from the src ZOO archive
+// to the dest directory using the native ZOO reader.
+func ZOOExtract(src, targets, dest string) error {
+	if err := zoo.Extract(src, dest, targets); err != nil {
+		return fmt.Errorf("zoo extract: %w", err)
 	}
 	return nil
 }
@@ -223,12 +209,18 @@ func LHAExtract(src, targets, dest string) error {
 // to the dest directory using the Linux unzip program.
 // Multiple filenames can be separated by spaces.
 func ZipExtract(src, targets, dest string) error {
+	return ZipExtractContext(context.Background(), src, targets, dest)
+}
+
+// ZipExtractContext extracts the target filenames from the src ZIP archive in the same manner
+// as ZipExtract, but the unzip program is killed when the ctx is done.
+func ZipExtractContext(ctx context.Context, src, targets, dest string) error {
 	prog, err := exec.LookPath("unzip")
 	if err != nil {
 		return fmt.Errorf("unzip extract: %w", err)
 	}
 	var b bytes.Buffer
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 	// [-options]
 	const (
@@ -257,35 +249,22 @@ func ZipExtract(src, targets, dest string) error {
 	return nil
 }
 
-// ARJReader returns the content of the src ARJ archive.
-// There is an internal limit of 999 items.
-func ARJReader(src string) ([]string, string, error) {
-	prog, err := exec.LookPath("arj")
+// ARCReader returns the content of the src ARC, ARK or PAK archive using the native ARC reader.
+func ARCReader(src string) ([]string, string, error) {
+	files, err := sea.List(src)
 	if err != nil {
-		return nil, "", fmt.Errorf("arj reader: %w", err)
+		return nil, "", fmt.Errorf("arc reader: %w", err)
 	}
+	// append empty value to match the other readers
+	files = append(files, "")
+	return files, arcx, nil
+}
 
-	const verboselist = "v"
-	var b bytes.Buffer
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	cmd := exec.CommandContext(ctx, prog, verboselist, src)
-	cmd.Stderr = &b
-	out, err := cmd.Output()
+// ARJReader returns the content of the src ARJ archive using the native ARJ reader.
+func ARJReader(src string) ([]string, string, error) {
+	files, err := arj.List(src)
 	if err != nil {
-		return nil, "", err
-	}
-	if len(out) == 0 {
-		return nil, "", ErrReadr
-	}
-	outs := strings.Split(string(out), "\n")
-	files := []string{}
-	const start = len("001) ")
-	for _, s := range outs {
-		if !ARJItem(s) {
-			continue
-		}
-		files = append(files, s[start:])
+		return nil, "", fmt.Errorf("arj reader: %w", err)
 	}
 	// append empty value to match the other readers
 	files = append(files, "")
@@ -308,49 +287,12 @@ func ARJItem(s string) bool {
 	return true
 }
 
-// LHAReader returns the content of the src LHA/LZH archive.
+// LHAReader returns the content of the src LHA/LZH archive using the native LHA reader.
 func LHAReader(src string) ([]string, string, error) {
-	prog, err := exec.LookPath("lha")
+	files, err := lha.List(src)
 	if err != nil {
 		return nil, "", fmt.Errorf("lha reader: %w", err)
 	}
-
-	const list = "-l"
-	var b bytes.Buffer
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	cmd := exec.CommandContext(ctx, prog, list, src)
-	cmd.Stderr = &b
-	out, err := cmd.Output()
-	if err != nil {
-		return nil, "", err
-	}
-	if len(out) == 0 {
-		return nil, "", ErrReadr
-	}
-	outs := strings.Split(string(out), "\n")
-
-	// LHA list command outputs with a MSDOS era, fixed-width layout table
-	const (
-		sizeS = len("[generic]              ")
-		sizeL = len("-------")
-		start = len("[generic]                   12 100.0% Apr 10 17:03 ")
-		dir   = 0
-	)
-
-	files := []string{}
-	for _, s := range outs {
-		if len(s) < start {
-			continue
-		}
-		size := strings.TrimSpace(s[sizeS : sizeS+sizeL])
-		if i, err := strconv.Atoi(size); err != nil {
-			continue
-		} else if i == dir {
-			continue
-		}
-		files = append(files, s[start:])
-	}
 	// append empty value to match the other readers
 	files = append(files, "")
 	return files, lhax, nil
@@ -382,6 +324,17 @@ func RarReader(src string) ([]string, string, error) {
 	return files, rarx, nil
 }
 
+// ZOOReader returns the content of the src ZOO archive using the native ZOO reader.
+func ZOOReader(src string) ([]string, string, error) {
+	files, err := zoo.List(src)
+	if err != nil {
+		return nil, "", fmt.Errorf("zoo reader: %w", err)
+	}
+	// append empty value to match the other readers
+	files = append(files, "")
+	return files, zoox, nil
+}
+
 // ZipReader returns the content of the src ZIP archive.
 func ZipReader(w io.Writer, src string) ([]string, string, error) {
 	if w == nil {
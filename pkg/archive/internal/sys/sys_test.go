@@ -52,6 +52,27 @@ func TestRename(t *testing.T) {
 // compression methods.
 // The test files were created by me but sourced from:
 // https://github.com/jvilk/browserfs-zipfs-extras/tree/master/test/fixtures
+func TestSameExt(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		ext1, ext2 string
+		want       bool
+	}{
+		{"", "", true},
+		{".zip", ".ZIP", true},
+		{".zip", ".arc", false},
+		{".arc", ".pak", true},
+		{".ARK", ".arc", true},
+		{".lha", ".lzh", true},
+		{".lzh", ".arc", false},
+	}
+	for _, tt := range tests {
+		if got := sys.SameExt(tt.ext1, tt.ext2); got != tt.want {
+			t.Errorf("SameExt(%q, %q) = %v, want %v", tt.ext1, tt.ext2, got, tt.want)
+		}
+	}
+}
+
 func TestPKZip(t *testing.T) {
 	t.Parallel()
 	const okay = "TEST.ANS;TEST.ASC;TEST.BMP;TEST.CAP;TEST.DIZ;TEST.DOC;TEST.EXE;TEST.GIF;" +
@@ -217,6 +238,8 @@ func TestExtract(t *testing.T) {
 	const tgt = "test.png"
 	lha := testDir("demozoo/test.lha")
 	zip := testDir("demozoo/test.zip")
+	arc := testDir("arc/hello.arc")
+	zoo := testDir("zoo/hello.zoo")
 	tests := []struct {
 		name    string
 		args    args
@@ -225,6 +248,8 @@ func TestExtract(t *testing.T) {
 		{"empty", args{}, true},
 		{"lha", args{lha, tgt, tmp}, false},
 		{"zip", args{zip, tgt, tmp}, false},
+		{"arc", args{arc, "hello.txt", tmp}, false},
+		{"zoo", args{zoo, "hello.txt", tmp}, false},
 	}
 	for _, tt := range tests {
 		tt := tt
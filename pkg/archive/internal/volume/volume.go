@@ -0,0 +1,296 @@
+// Package volume finds, lists and extracts the multi-volume RAR and ARJ archives,
+// and the split ZIP archives that are often distributed together within a single upload.
+package volume
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Defacto2/df2/pkg/archive/internal/arj"
+	"github.com/Defacto2/df2/pkg/archive/internal/dosarc"
+	"github.com/nwaples/rardecode"
+)
+
+var (
+	ErrDest    = errors.New("dest directory is empty or points to a file")
+	ErrMissing = errors.New("volume set is missing volumes")
+)
+
+const dirMode = 0o755
+
+// Formats of the volume sets.
+const (
+	ARJ = "arj" // ARJ is a multi-volume ARJ archive, SPLIT.ARJ, SPLIT.A01, SPLIT.A02.
+	RAR = "rar" // RAR is a multi-volume RAR archive, SPLIT.RAR, SPLIT.R00 or SPLIT.PART1.RAR, SPLIT.PART2.RAR.
+	ZIP = "zip" // ZIP is a split ZIP archive, SPLIT.Z01, SPLIT.Z02, SPLIT.ZIP.
+)
+
+// Set is a collection of volumes that together form a single archive.
+type Set struct {
+	Name    string   // Name of the archive, which is the base filename of the set.
+	Format  string   // Format of the archive.
+	Parts   []string // Parts are the paths of the volumes in order.
+	Missing []string // Missing are the names of the volumes that were not found.
+	newRAR  bool     // newRAR is set for the NAME.PART1.RAR naming of RAR volumes.
+}
+
+// Complete returns true when the set is not missing any volumes.
+func (s Set) Complete() bool {
+	return len(s.Missing) == 0 && len(s.Parts) > 1
+}
+
+func (s Set) String() string {
+	return fmt.Sprintf("%s (%s, %d volumes)", s.Name, s.Format, len(s.Parts))
+}
+
+var (
+	reRARNew = regexp.MustCompile(`(?i)^(.+)\.part(\d+)\.rar$`)
+	reRAROld = regexp.MustCompile(`(?i)^(.+)\.r(\d\d)$`)
+	reARJ    = regexp.MustCompile(`(?i)^(.+)\.a(\d\d)$`)
+	reZIP    = regexp.MustCompile(`(?i)^(.+)\.z(\d\d)$`)
+)
+
+// part is a volume that was found in a directory.
+type part struct {
+	path  string
+	num   int
+	width int
+	upper bool
+}
+
+// Find returns the volume sets that are found in the dir directory and its subdirectories.
+func Find(dir string) ([]Set, error) {
+	type key struct{ dir, base, format string }
+	parts := map[key][]part{}
+	mains := map[key]string{}
+	err := filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		name, parent := d.Name(), filepath.Dir(p)
+		add := func(re *regexp.Regexp, format string) bool {
+			m := re.FindStringSubmatch(name)
+			if m == nil {
+				return false
+			}
+			n, _ := strconv.Atoi(m[2])
+			ext := filepath.Ext(name)
+			k := key{parent, strings.ToLower(m[1]), format}
+			parts[k] = append(parts[k], part{path: p, num: n, width: len(m[2]), upper: ext == strings.ToUpper(ext)})
+			return true
+		}
+		if add(reRARNew, RAR+"5") || add(reRAROld, RAR) || add(reARJ, ARJ) || add(reZIP, ZIP) {
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(name))
+		switch ext {
+		case ".rar", ".arj", ".zip":
+			mains[key{parent, strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name))), ext[1:]}] = p
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("volume find: %w", err)
+	}
+	sets := []Set{}
+	for k, ps := range parts {
+		sort.Slice(ps, func(i, j int) bool { return ps[i].num < ps[j].num })
+		var s Set
+		switch k.format {
+		case RAR + "5":
+			s = newRAR(ps)
+		default:
+			s = sequence(k.format, ps, mains[key{k.dir, k.base, k.format}])
+		}
+		if len(s.Parts) < 2 && len(s.Missing) == 0 {
+			continue
+		}
+		sets = append(sets, s)
+	}
+	sort.Slice(sets, func(i, j int) bool { return sets[i].Name < sets[j].Name })
+	return sets, nil
+}
+
+// newRAR returns the set of RAR volumes using the NAME.PART1.RAR naming.
+// A set with a single volume is not a multi-volume archive.
+func newRAR(ps []part) Set {
+	name := filepath.Base(ps[0].path)
+	m := reRARNew.FindStringSubmatch(name)
+	s := Set{Name: m[1] + filepath.Ext(name), Format: RAR, newRAR: true}
+	if ps[len(ps)-1].num < 2 {
+		return Set{}
+	}
+	want := 1
+	for _, p := range ps {
+		for ; want < p.num; want++ {
+			s.Missing = append(s.Missing, fmt.Sprintf("%s.part%0*d%s", m[1], p.width, want, filepath.Ext(name)))
+		}
+		s.Parts = append(s.Parts, p.path)
+		want = p.num + 1
+	}
+	return s
+}
+
+// sequence returns the set of volumes that use a numbered file extension,
+// where main is the path of the volume that uses the archive file extension.
+// The main volume is the first of the RAR and ARJ volumes, but the last of the ZIP volumes.
+func sequence(format string, ps []part, main string) Set {
+	base := strings.TrimSuffix(filepath.Base(ps[0].path), filepath.Ext(ps[0].path))
+	ext := "." + format
+	if ps[0].upper {
+		ext = strings.ToUpper(ext)
+	}
+	s := Set{Name: base + ext, Format: format}
+	letter := strings.TrimPrefix(ext, ".")[:1]
+	first := 1
+	if format == RAR {
+		first = 0
+	}
+	numbered := []string{}
+	want := first
+	for _, p := range ps {
+		for ; want < p.num; want++ {
+			s.Missing = append(s.Missing, fmt.Sprintf("%s.%s%02d", base, letter, want))
+		}
+		numbered = append(numbered, p.path)
+		want = p.num + 1
+	}
+	if main == "" {
+		s.Missing = append([]string{s.Name}, s.Missing...)
+	}
+	switch {
+	case format == ZIP && main != "":
+		s.Parts = append(numbered, main)
+	case format == ZIP:
+		s.Parts = numbered
+	case main != "":
+		s.Parts = append([]string{main}, numbered...)
+	default:
+		s.Parts = numbered
+	}
+	return s
+}
+
+// List returns the names of the files in the complete volume set, excluding any directories.
+func (s Set) List() ([]string, error) {
+	if !s.Complete() {
+		return nil, fmt.Errorf("%w: %s %s", ErrMissing, s.Name, strings.Join(s.Missing, " "))
+	}
+	switch s.Format {
+	case ARJ:
+		files, err := arj.ListVolumes(s.Parts...)
+		if err != nil {
+			return nil, fmt.Errorf("volume list %s: %w", s.Name, err)
+		}
+		return files, nil
+	case RAR:
+		return s.rar(nil, nil)
+	case ZIP:
+		return s.zip(nil, nil)
+	}
+	return nil, nil
+}
+
+// Extract the files of the complete volume set to the dest directory.
+func (s Set) Extract(dest string) error {
+	return s.ExtractLimit(dest, nil)
+}
+
+// ExtractLimit extracts the files of the complete volume set to the dest directory in the same manner
+// as Extract, but stops and returns dosarc.ErrSize or dosarc.ErrFiles when the files break the lim limit.
+func (s Set) ExtractLimit(dest string, lim *dosarc.Limit) error {
+	if !s.Complete() {
+		return fmt.Errorf("%w: %s %s", ErrMissing, s.Name, strings.Join(s.Missing, " "))
+	}
+	if dest == "" {
+		return fmt.Errorf("volume extract %w: %q", ErrDest, dest)
+	}
+	if st, err := os.Stat(dest); err == nil && !st.IsDir() {
+		return fmt.Errorf("volume extract %w: %s", ErrDest, dest)
+	}
+	if err := os.MkdirAll(dest, dirMode); err != nil {
+		return fmt.Errorf("volume extract: %w", err)
+	}
+	var err error
+	switch s.Format {
+	case ARJ:
+		err = arj.ExtractVolumesLimit(dest, s.Parts, lim)
+	case RAR:
+		_, err = s.rar(&dest, lim)
+	case ZIP:
+		_, err = s.zip(&dest, lim)
+	}
+	if err != nil {
+		return fmt.Errorf("volume extract %s: %w", s.Name, err)
+	}
+	return nil
+}
+
+// rar lists the files of the RAR volumes and extracts them within the lim limit when the dest is not nil.
+// The volumes are linked into a temporary directory using the names expected by the reader.
+func (s Set) rar(dest *string, lim *dosarc.Limit) ([]string, error) {
+	tmp, err := os.MkdirTemp("", "volume-")
+	if err != nil {
+		return nil, fmt.Errorf("volume rar: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+	first := ""
+	for i, p := range s.Parts {
+		name := "volume.rar"
+		switch {
+		case s.newRAR:
+			name = fmt.Sprintf("volume.part%d.rar", i+1)
+		case i > 0:
+			name = fmt.Sprintf("volume.r%02d", i-1)
+		}
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return nil, fmt.Errorf("volume rar: %w", err)
+		}
+		if err := os.Symlink(abs, filepath.Join(tmp, name)); err != nil {
+			return nil, fmt.Errorf("volume rar: %w", err)
+		}
+		if i == 0 {
+			first = filepath.Join(tmp, name)
+		}
+	}
+	rc, err := rardecode.OpenReader(first, "")
+	if err != nil {
+		return nil, fmt.Errorf("volume rar: %w", err)
+	}
+	defer rc.Close()
+	files := []string{}
+	for {
+		h, err := rc.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("volume rar: %w", err)
+		}
+		name := strings.ReplaceAll(h.Name, "\\", "/")
+		if !h.IsDir {
+			files = append(files, name)
+		}
+		if dest == nil {
+			continue
+		}
+		e := dosarc.Entry{
+			Name: name, Modified: h.ModificationTime, Dir: h.IsDir,
+			Open: func() (io.ReadCloser, error) { return io.NopCloser(rc), nil },
+		}
+		if err := e.Write(*dest, lim); err != nil {
+			return nil, fmt.Errorf("volume rar: %w", err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package volume_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Defacto2/df2/pkg/archive/internal/dosarc"
+	"github.com/Defacto2/df2/pkg/archive/internal/volume"
+	"github.com/stretchr/testify/assert"
+)
+
+func testDir(name string) string {
+	dir, _ := os.Getwd()
+	return filepath.Join(dir, "..", "..", "..", "..", "testdata", name)
+}
+
+const (
+	hello = "Hello world.\r\n"
+	nfo   = "Multi-volume fixture.\r\n"
+)
+
+func TestFind(t *testing.T) {
+	t.Parallel()
+	sets, err := volume.Find(testDir("volumes"))
+	assert.Nil(t, err)
+	assert.Len(t, sets, 4)
+	names := []string{}
+	for _, s := range sets {
+		names = append(names, s.Name)
+		assert.True(t, s.Complete(), s.Name)
+		assert.GreaterOrEqual(t, len(s.Parts), 3, s.Name)
+	}
+	assert.Equal(t, []string{"SPAN.ZIP", "SPLIT.ARJ", "new.rar", "old.rar"}, names)
+	assert.Equal(t, "SPAN.ZIP", filepath.Base(sets[0].Parts[len(sets[0].Parts)-1]))
+	assert.Equal(t, "SPLIT.ARJ", filepath.Base(sets[1].Parts[0]))
+	assert.Equal(t, "old.rar", filepath.Base(sets[3].Parts[0]))
+
+	_, err = volume.Find(testDir("nothing"))
+	assert.NotNil(t, err)
+}
+
+func TestFind_Missing(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	for _, name := range []string{"DEMO.A01", "DEMO.A03", "intro.part1.rar", "intro.part3.rar", "SINGLE.ZIP", "x.part1.rar"} {
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o644))
+	}
+	sets, err := volume.Find(dir)
+	assert.Nil(t, err)
+	assert.Len(t, sets, 2)
+	assert.Equal(t, []string{"DEMO.ARJ", "DEMO.A02"}, sets[0].Missing)
+	assert.Equal(t, []string{"intro.part2.rar"}, sets[1].Missing)
+	assert.False(t, sets[0].Complete())
+	_, err = sets[0].List()
+	assert.ErrorIs(t, err, volume.ErrMissing)
+	err = sets[1].Extract(t.TempDir())
+	assert.ErrorIs(t, err, volume.ErrMissing)
+}
+
+func TestSet_List(t *testing.T) {
+	t.Parallel()
+	sets, err := volume.Find(testDir("volumes"))
+	assert.Nil(t, err)
+	for _, s := range sets {
+		files, err := s.List()
+		assert.Nil(t, err, s.Name)
+		assert.Equal(t, []string{"SPLIT.NFO", "LONG.TXT", "HELLO.TXT"}, files, s.Name)
+	}
+}
+
+func TestSet_Extract(t *testing.T) {
+	t.Parallel()
+	sets, err := volume.Find(testDir("volumes"))
+	assert.Nil(t, err)
+	for _, s := range sets {
+		dir := t.TempDir()
+		err := s.Extract(dir)
+		assert.Nil(t, err, s.Name)
+		b, err := os.ReadFile(filepath.Join(dir, "HELLO.TXT"))
+		assert.Nil(t, err, s.Name)
+		assert.Equal(t, strings.Repeat(hello, 20), string(b), s.Name)
+		b, err = os.ReadFile(filepath.Join(dir, "SPLIT.NFO"))
+		assert.Nil(t, err, s.Name)
+		assert.Equal(t, strings.Repeat(nfo, 5), string(b), s.Name)
+		st, err := os.Stat(filepath.Join(dir, "LONG.TXT"))
+		assert.Nil(t, err, s.Name)
+		assert.Greater(t, st.Size(), int64(1200), s.Name)
+	}
+	err = sets[0].Extract("")
+	assert.ErrorIs(t, err, volume.ErrDest)
+}
+
+func TestSet_ExtractLimit(t *testing.T) {
+	t.Parallel()
+	sets, err := volume.Find(testDir("volumes"))
+	assert.Nil(t, err)
+	for _, s := range sets {
+		err := s.ExtractLimit(t.TempDir(), &dosarc.Limit{Size: 1000})
+		assert.ErrorIs(t, err, dosarc.ErrSize, s.Name)
+		err = s.ExtractLimit(t.TempDir(), &dosarc.Limit{Files: 1})
+		assert.ErrorIs(t, err, dosarc.ErrFiles, s.Name)
+	}
+}
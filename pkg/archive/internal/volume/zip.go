@@ -0,0 +1,153 @@
+package volume
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Defacto2/df2/pkg/archive/internal/dosarc"
+)
+
+var (
+	ErrEOCD  = errors.New("end of central directory record is not found")
+	ErrZip64 = errors.New("split zip64 archives are not supported")
+)
+
+const (
+	eocdSig  = "PK\x05\x06"
+	eocdLen  = 22
+	centSig  = "PK\x01\x02"
+	centLen  = 46
+	maxField = 0xffff
+)
+
+// zip lists the files of the split ZIP volumes and extracts them within the lim limit when the dest is not nil.
+// The volumes are joined into a single temporary archive with a central directory
+// that is rewritten to use the offsets of the joined archive.
+func (s Set) zip(dest *string, lim *dosarc.Limit) ([]string, error) {
+	tmp, err := os.CreateTemp("", "volume-*.zip")
+	if err != nil {
+		return nil, fmt.Errorf("volume zip: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	base, err := join(tmp, s.Parts)
+	if err != nil {
+		return nil, fmt.Errorf("volume zip: %w", err)
+	}
+	if err := rewrite(tmp, base); err != nil {
+		return nil, fmt.Errorf("volume zip: %w", err)
+	}
+	st, err := tmp.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("volume zip: %w", err)
+	}
+	zr, err := zip.NewReader(tmp, st.Size())
+	if err != nil {
+		return nil, fmt.Errorf("volume zip: %w", err)
+	}
+	files := []string{}
+	for _, f := range zr.File {
+		isDir := strings.HasSuffix(f.Name, "/")
+		if !isDir {
+			files = append(files, f.Name)
+		}
+		if dest == nil {
+			continue
+		}
+		e := dosarc.Entry{Name: f.Name, Modified: f.Modified, Dir: isDir, Open: f.Open}
+		if err := e.Write(*dest, lim); err != nil {
+			return nil, fmt.Errorf("volume zip: %w", err)
+		}
+	}
+	return files, nil
+}
+
+// join copies the volumes to w and returns the offset of each volume in the joined file.
+func join(w io.Writer, parts []string) ([]int64, error) {
+	base := make([]int64, 0, len(parts))
+	var offset int64
+	for _, p := range parts {
+		base = append(base, offset)
+		f, err := os.Open(p)
+		if err != nil {
+			return nil, err
+		}
+		n, err := io.Copy(w, f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		offset += n
+	}
+	return base, nil
+}
+
+// rewrite updates the central directory of the joined archive so that
+// the disk numbers are zero and the offsets are relative to the start of the file.
+func rewrite(f *os.File, base []int64) error {
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	size := st.Size()
+	n := int64(eocdLen + maxField)
+	if n > size {
+		n = size
+	}
+	tail := make([]byte, n)
+	if _, err := f.ReadAt(tail, size-n); err != nil {
+		return err
+	}
+	i := bytes.LastIndex(tail, []byte(eocdSig))
+	if i < 0 || len(tail)-i < eocdLen {
+		return ErrEOCD
+	}
+	le := binary.LittleEndian
+	eocd := tail[i : i+eocdLen]
+	cdDisk, entries := int(le.Uint16(eocd[6:])), le.Uint16(eocd[10:])
+	cdSize, cdOffset := le.Uint32(eocd[12:]), le.Uint32(eocd[16:])
+	if cdDisk == maxField || entries == maxField || cdOffset == 0xffffffff {
+		return ErrZip64
+	}
+	if cdDisk >= len(base) {
+		return fmt.Errorf("%w: central directory disk %d", ErrMissing, cdDisk+1)
+	}
+	start := base[cdDisk] + int64(cdOffset)
+	cd := make([]byte, cdSize)
+	if _, err := f.ReadAt(cd, start); err != nil {
+		return err
+	}
+	for j, pos := 0, 0; j < int(entries); j++ {
+		if pos+centLen > len(cd) || string(cd[pos:pos+4]) != centSig {
+			return fmt.Errorf("%w: central directory entry %d", ErrEOCD, j)
+		}
+		disk := int(le.Uint16(cd[pos+34:]))
+		if disk >= len(base) {
+			return fmt.Errorf("%w: disk %d", ErrMissing, disk+1)
+		}
+		offset := base[disk] + int64(le.Uint32(cd[pos+42:]))
+		if offset > 0xffffffff {
+			return ErrZip64
+		}
+		le.PutUint16(cd[pos+34:], 0)
+		le.PutUint32(cd[pos+42:], uint32(offset))
+		pos += centLen + int(le.Uint16(cd[pos+28:])) + int(le.Uint16(cd[pos+30:])) + int(le.Uint16(cd[pos+32:]))
+	}
+	if _, err := f.WriteAt(cd, start); err != nil {
+		return err
+	}
+	le.PutUint16(eocd[4:], 0)
+	le.PutUint16(eocd[6:], 0)
+	le.PutUint16(eocd[8:], entries)
+	le.PutUint32(eocd[16:], uint32(start))
+	if _, err := f.WriteAt(eocd, size-n+int64(i)); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package zoo
+
+import (
+	"github.com/Defacto2/df2/pkg/archive/internal/dosarc"
+)
+
+var (
+	ErrDest   = dosarc.ErrDest
+	ErrPath   = dosarc.ErrPath
+	ErrTarget = dosarc.ErrTarget
+)
+
+// List returns the names of the files in the src ZOO archive.
+func List(src string) ([]string, error) {
+	z, err := OpenReader(src)
+	if err != nil {
+		return nil, err
+	}
+	defer z.Close()
+	return dosarc.List(z.Entries()), nil
+}
+
+// Extract the targets from the src ZOO archive to the dest directory.
+// The targets are matched against the file paths and names, ignoring case.
+// When no targets are given, or the target is "*", all the files are extracted.
+// The dest directory is created when it does not exist.
+func Extract(src, dest string, targets ...string) error {
+	if err := dosarc.Dest("zoo", dest); err != nil {
+		return err
+	}
+	z, err := OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer z.Close()
+	return dosarc.Extract("zoo", dest, z.Entries(), targets...)
+}
+
+// Entries returns the files of the archive for extraction.
+func (z *Reader) Entries() []dosarc.Entry {
+	entries := make([]dosarc.Entry, 0, len(z.File))
+	for _, f := range z.File {
+		entries = append(entries, dosarc.Entry{Name: f.Name, Modified: f.Modified, Open: f.Open})
+	}
+	return entries
+}
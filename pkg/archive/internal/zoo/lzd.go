@@ -0,0 +1,138 @@
+package zoo
+
+import (
+	"bufio"
+	"io"
+)
+
+const (
+	clear     = 256 // clear is the code that resets the string table.
+	end       = 257 // end is the code that ends the stream.
+	firstFree = 258 // firstFree is the first free code of the string table.
+	initBits  = 9   // initBits is the code width after a clear.
+	maxBits   = 13  // maxBits is the widest code.
+	maxMax    = 1 << maxBits
+)
+
+// lzd decodes the LZW method of zoo, where the codes are packed without any
+// padding and the code width grows as soon as the string table needs it.
+type lzd struct {
+	r       *bufio.Reader
+	bits    uint32 // bits holds the unread bits in the low end.
+	n       int    // n is the number of unread bits.
+	nBits   int
+	maxCode int
+	free    int // free is the next free entry of the string table.
+	fresh   bool
+	prefix  [maxMax]uint16
+	suffix  [maxMax]byte
+	oldCode int
+	finChar byte
+	stack   []byte
+	out     []byte // out is the decoded output waiting to be read.
+	err     error
+}
+
+func newLZW(r io.Reader) *lzd {
+	z := &lzd{r: bufio.NewReader(r)}
+	z.reset()
+	for i := 0; i < clear; i++ {
+		z.suffix[i] = byte(i)
+	}
+	return z
+}
+
+func (z *lzd) reset() {
+	z.nBits = initBits
+	z.maxCode = 1 << initBits
+	z.free = firstFree
+	z.fresh = true
+}
+
+// code returns the next code of the current width.
+func (z *lzd) code() (int, error) {
+	for z.n < z.nBits {
+		b, err := z.r.ReadByte()
+		if err != nil {
+			return 0, io.ErrUnexpectedEOF
+		}
+		z.bits |= uint32(b) << z.n
+		z.n += 8
+	}
+	c := int(z.bits & (1<<z.nBits - 1))
+	z.bits >>= z.nBits
+	z.n -= z.nBits
+	return c, nil
+}
+
+func (z *lzd) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(z.out) > 0 {
+			c := copy(p[n:], z.out)
+			z.out = z.out[c:]
+			n += c
+			continue
+		}
+		if z.err != nil {
+			return n, z.err
+		}
+		z.err = z.decode()
+	}
+	return n, nil
+}
+
+// decode the next code into the output.
+func (z *lzd) decode() error {
+	code, err := z.code()
+	if err != nil {
+		return err
+	}
+	switch {
+	case code == end:
+		return io.EOF
+	case code == clear:
+		z.reset()
+		return nil
+	case z.fresh:
+		if code > clear {
+			return ErrData
+		}
+		z.fresh = false
+		z.oldCode, z.finChar = code, byte(code)
+		z.out = append(z.out[:0], z.finChar)
+		return nil
+	case code > z.free:
+		return ErrData
+	}
+	in := code
+	z.stack = z.stack[:0]
+	if code == z.free {
+		z.stack = append(z.stack, z.finChar)
+		code = z.oldCode
+	}
+	for code > 255 {
+		if len(z.stack) >= maxMax {
+			return ErrData
+		}
+		z.stack = append(z.stack, z.suffix[code])
+		code = int(z.prefix[code])
+	}
+	z.finChar = byte(code)
+	z.stack = append(z.stack, z.finChar)
+	z.out = z.out[:0]
+	for i := len(z.stack) - 1; i >= 0; i-- {
+		z.out = append(z.out, z.stack[i])
+	}
+	if z.free < maxMax {
+		z.prefix[z.free] = uint16(z.oldCode)
+		z.suffix[z.free] = z.finChar
+		z.free++
+		if z.free >= z.maxCode && z.nBits < maxBits {
+			z.nBits++
+			z.maxCode <<= 1
+		}
+	}
+	z.oldCode = in
+	return nil
+}
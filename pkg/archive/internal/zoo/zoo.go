@@ -0,0 +1,249 @@
+// Package zoo reads and extracts ZOO archives, the format created by
+// Rahul Dhesi for the zoo archiver on Unix, VAX/VMS and MS-DOS.
+// Files that are stored or compressed with the LZW and the -lh5- methods are supported.
+package zoo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Defacto2/df2/pkg/archive/internal/crc16"
+	"github.com/Defacto2/df2/pkg/archive/internal/dosarc"
+	"github.com/Defacto2/df2/pkg/archive/internal/lzh"
+)
+
+var (
+	ErrChecksum = errors.New("zoo checksum error")
+	ErrData     = errors.New("zoo compressed data is corrupt")
+	ErrFormat   = errors.New("not a valid zoo archive")
+	ErrHeader   = errors.New("zoo header is corrupt")
+	ErrMethod   = errors.New("zoo compression method is not supported")
+)
+
+const (
+	tag       = 0xfdc4a7dc // tag identifies the archive and every directory entry.
+	textSize  = 20         // textSize is the length of the text that starts the archive.
+	entrySize = 51         // entrySize is the length of a type 1 directory entry.
+	type2Size = 56         // type2Size is the length of a type 2 directory entry.
+	nameSize  = 13         // nameSize is the length of the null terminated MS-DOS filename.
+)
+
+// Compression methods.
+const (
+	Stored = 0 // Stored without any compression.
+	LZW    = 1 // LZW is dynamic LZW of up to 13 bits.
+	LH5    = 2 // LH5 is LZSS with an 8 KB dictionary and static huffman codes.
+)
+
+// File is a file stored in a ZOO archive.
+type File struct {
+	Name           string    // Name of the file using forward slash path separators.
+	Comment        string    // Comment of the file.
+	RawComment     []byte    // RawComment is the comment using its legacy character set.
+	Method         int       // Method of compression.
+	Modified       time.Time // Modified is the last modification time of the file.
+	CompressedSize int64     // CompressedSize of the file data.
+	Size           int64     // Size of the uncompressed file.
+	CRC16          uint16    // CRC16 checksum of the uncompressed file.
+	r              io.ReaderAt
+	offset         int64 // offset of the file data.
+}
+
+// Open returns a reader of the uncompressed file content.
+// The checksum of the content is validated when the end of the file is read.
+func (f *File) Open() (io.ReadCloser, error) {
+	data := io.NewSectionReader(f.r, f.offset, f.CompressedSize)
+	var r io.Reader
+	switch f.Method {
+	case Stored:
+		r = data
+	case LZW:
+		r = newLZW(data)
+	case LH5:
+		r = lzh.NewReader(data, f.Size, lzh.LH5)
+	default:
+		return nil, fmt.Errorf("%w: %d %s", ErrMethod, f.Method, f.Name)
+	}
+	return &checksum{
+		r:    io.LimitReader(r, f.Size),
+		want: f.CRC16,
+		size: f.Size,
+	}, nil
+}
+
+// checksum validates the CRC16 and size of a file when the end is read.
+type checksum struct {
+	r    io.Reader
+	crc  crc16.Digest
+	want uint16
+	size int64
+	read int64
+}
+
+func (c *checksum) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.crc.Write(p[:n])
+	c.read += int64(n)
+	if errors.Is(err, io.EOF) {
+		if c.read != c.size || c.crc.Sum16() != c.want {
+			return n, ErrChecksum
+		}
+	}
+	return n, err //nolint:wrapcheck
+}
+
+func (c *checksum) Close() error {
+	return nil
+}
+
+// Reader is a ZOO archive.
+type Reader struct {
+	File []*File // File lists the files in the archive, excluding any deleted files.
+}
+
+// NewReader returns a Reader of the ZOO archive read from r, which is size bytes long.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	var h [textSize + 8]byte
+	if _, err := r.ReadAt(h[:], 0); err != nil {
+		return nil, ErrFormat
+	}
+	le := binary.LittleEndian
+	if le.Uint32(h[textSize:]) != tag {
+		return nil, ErrFormat
+	}
+	z := &Reader{}
+	seen := map[int64]bool{}
+	for off := int64(le.Uint32(h[textSize+4:])); ; {
+		if off <= 0 || off >= size || seen[off] {
+			return nil, ErrHeader
+		}
+		seen[off] = true
+		f, next, deleted, err := entry(r, off)
+		if err != nil {
+			return nil, err
+		}
+		if next == 0 {
+			return z, nil
+		}
+		if f.offset+f.CompressedSize > size {
+			return nil, fmt.Errorf("%w: %s data is truncated", ErrHeader, f.Name)
+		}
+		if !deleted {
+			z.File = append(z.File, f)
+		}
+		off = next
+	}
+}
+
+// ReadCloser is a ZOO archive file that must be closed after use.
+type ReadCloser struct {
+	Reader
+	f *os.File
+}
+
+// OpenReader opens the named ZOO archive file.
+func OpenReader(name string) (*ReadCloser, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("zoo open: %w", err)
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("zoo stat: %w", err)
+	}
+	r, err := NewReader(f, st.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &ReadCloser{Reader: *r, f: f}, nil
+}
+
+// Close the archive file.
+func (rc *ReadCloser) Close() error {
+	return rc.f.Close()
+}
+
+// entry reads the directory entry at the offset and returns the file,
+// the offset of the next entry and whether the file was deleted.
+// The last entry of the archive has a next offset of zero.
+func entry(r io.ReaderAt, off int64) (*File, int64, bool, error) {
+	h := make([]byte, type2Size)
+	n, err := r.ReadAt(h, off)
+	if n < entrySize {
+		return nil, 0, false, fmt.Errorf("%w: %w", ErrHeader, err)
+	}
+	le := binary.LittleEndian
+	if le.Uint32(h) != tag {
+		return nil, 0, false, ErrHeader
+	}
+	next := int64(le.Uint32(h[6:]))
+	f := &File{
+		Method:         int(h[5]),
+		offset:         int64(le.Uint32(h[10:])),
+		Modified:       dosarc.MSDOS(le.Uint16(h[14:]), le.Uint16(h[16:])),
+		CRC16:          le.Uint16(h[18:]),
+		Size:           int64(le.Uint32(h[20:])),
+		CompressedSize: int64(le.Uint32(h[24:])),
+		r:              r,
+	}
+	deleted := h[30] == 1
+	name := h[38 : 38+nameSize]
+	if i := bytes.IndexByte(name, 0); i >= 0 {
+		name = name[:i]
+	}
+	f.Name = dosarc.Decode(name)
+	if next == 0 {
+		return f, 0, deleted, nil
+	}
+	if cmmt, size := int64(le.Uint32(h[32:])), int(le.Uint16(h[36:])); cmmt > 0 && size > 0 {
+		b := make([]byte, size)
+		if _, err := r.ReadAt(b, cmmt); err != nil {
+			return nil, 0, false, fmt.Errorf("%w: %w", ErrHeader, err)
+		}
+		f.RawComment = bytes.TrimRight(b, "\x00\n")
+		f.Comment = dosarc.Decode(f.RawComment)
+	}
+	const type2 = 2
+	if h[4] == type2 && n == type2Size {
+		if err := f.long(r, off+type2Size, int(le.Uint16(h[51:]))); err != nil {
+			return nil, 0, false, err
+		}
+	}
+	return f, next, deleted, nil
+}
+
+// long reads the long filename and the directory name of a type 2 directory entry.
+func (f *File) long(r io.ReaderAt, off int64, size int) error {
+	const lens = 2
+	if size < lens {
+		return nil
+	}
+	v := make([]byte, size)
+	if _, err := r.ReadAt(v, off); err != nil {
+		return fmt.Errorf("%w: %w", ErrHeader, err)
+	}
+	nameLen, dirLen := int(v[0]), int(v[1])
+	if lens+nameLen+dirLen > size {
+		return ErrHeader
+	}
+	name := f.Name
+	if nameLen > 0 {
+		name = dosarc.Decode(bytes.TrimRight(v[lens:lens+nameLen], "\x00"))
+	}
+	dir := dosarc.Decode(bytes.TrimRight(v[lens+nameLen:lens+nameLen+dirLen], "\x00"))
+	dir = strings.TrimLeft(strings.ReplaceAll(dir, "\\", "/"), "/")
+	if dir != "" {
+		name = path.Join(dir, name)
+	}
+	f.Name = name
+	return nil
+}
@@ -0,0 +1,133 @@
+package zoo_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Defacto2/df2/pkg/archive/internal/zoo"
+	"github.com/stretchr/testify/assert"
+)
+
+func testDir(name string) string {
+	dir, _ := os.Getwd()
+	return filepath.Join(dir, "..", "..", "..", "..", "testdata", name)
+}
+
+const (
+	hello = "Hello world.\r\n"
+	last  = "00699 The quick brown fox jumps over the lazy dog, line 33."
+)
+
+func TestOpenReader(t *testing.T) {
+	t.Parallel()
+	_, err := zoo.OpenReader("")
+	assert.NotNil(t, err)
+	_, err = zoo.OpenReader(testDir("demozoo/test.zip"))
+	assert.ErrorIs(t, err, zoo.ErrFormat)
+
+	z, err := zoo.OpenReader(testDir("zoo/methods.zoo"))
+	assert.Nil(t, err)
+	defer z.Close()
+	assert.Len(t, z.File, 6)
+	methods := []int{zoo.Stored, zoo.LZW, zoo.LH5, zoo.LZW, zoo.LH5}
+	for i, m := range methods {
+		assert.Equal(t, m, z.File[i].Method)
+	}
+	assert.Equal(t, "docs/README.NFO", z.File[3].Name)
+	assert.Equal(t, "docs/more/long file name.text", z.File[4].Name)
+	assert.Equal(t, time.Date(1994, 6, 15, 12, 30, 20, 0, time.UTC), z.File[0].Modified)
+
+	c, err := zoo.OpenReader(testDir("zoo/hello.zoo"))
+	assert.Nil(t, err)
+	defer c.Close()
+	assert.Len(t, c.File, 1)
+	assert.Equal(t, "Hello comment.", c.File[0].Comment)
+	assert.Equal(t, []byte("Hello comment."), c.File[0].RawComment)
+}
+
+func TestFile_Open(t *testing.T) {
+	t.Parallel()
+	z, err := zoo.OpenReader(testDir("zoo/methods.zoo"))
+	assert.Nil(t, err)
+	defer z.Close()
+	for _, f := range z.File {
+		r, err := f.Open()
+		assert.Nil(t, err, f.Name)
+		b, err := io.ReadAll(r)
+		assert.Nil(t, err, f.Name)
+		assert.Equal(t, f.Size, int64(len(b)), f.Name)
+		switch f.Name {
+		case "STORED.TXT", "LZW.TXT":
+			assert.True(t, strings.HasPrefix(string(b), hello), f.Name)
+		case "LH5.ANS":
+			assert.Contains(t, string(b), "DEFACTO2")
+		case "docs/README.NFO":
+			assert.Contains(t, string(b), last)
+		}
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	t.Parallel()
+	b, err := os.ReadFile(testDir("zoo/hello.zoo"))
+	assert.Nil(t, err)
+	_, err = zoo.NewReader(bytes.NewReader(b[:20]), 20)
+	assert.ErrorIs(t, err, zoo.ErrFormat)
+	_, err = zoo.NewReader(bytes.NewReader(b[:100]), 100)
+	assert.ErrorIs(t, err, zoo.ErrHeader)
+
+	// corrupt the compressed data
+	bad := bytes.Clone(b)
+	bad[120] ^= 0xff
+	z, err := zoo.NewReader(bytes.NewReader(bad), int64(len(bad)))
+	assert.Nil(t, err)
+	r, err := z.File[0].Open()
+	assert.Nil(t, err)
+	_, err = io.ReadAll(r)
+	assert.NotNil(t, err)
+}
+
+func TestList(t *testing.T) {
+	t.Parallel()
+	files, err := zoo.List(testDir("zoo/hello.zoo"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"HELLO.TXT"}, files)
+	files, err = zoo.List(testDir("zoo/methods.zoo"))
+	assert.Nil(t, err)
+	assert.Len(t, files, 6)
+	assert.NotContains(t, files, "DELETED.TXT")
+}
+
+func TestExtract(t *testing.T) {
+	t.Parallel()
+	src := testDir("zoo/methods.zoo")
+	err := zoo.Extract(src, "")
+	assert.ErrorIs(t, err, zoo.ErrDest)
+
+	dir := t.TempDir()
+	err = zoo.Extract(src, dir)
+	assert.Nil(t, err)
+	b, err := os.ReadFile(filepath.Join(dir, "docs", "README.NFO"))
+	assert.Nil(t, err)
+	assert.Len(t, b, 67510)
+	st, err := os.Stat(filepath.Join(dir, "EMPTY.TXT"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), st.Size())
+
+	dir = t.TempDir()
+	err = zoo.Extract(src, dir, "long file name.text")
+	assert.Nil(t, err)
+	b, err = os.ReadFile(filepath.Join(dir, "docs", "more", "long file name.text"))
+	assert.Nil(t, err)
+	assert.Len(t, b, 3000)
+	_, err = os.Stat(filepath.Join(dir, "STORED.TXT"))
+	assert.NotNil(t, err)
+
+	err = zoo.Extract(src, dir, "nothing.txt")
+	assert.ErrorIs(t, err, zoo.ErrTarget)
+}
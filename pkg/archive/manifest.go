@@ -0,0 +1,243 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Defacto2/df2/pkg/archive/internal/arc"
+	"github.com/Defacto2/df2/pkg/archive/internal/arj"
+	"github.com/Defacto2/df2/pkg/archive/internal/lha"
+	"github.com/Defacto2/df2/pkg/archive/internal/sea"
+	"github.com/Defacto2/df2/pkg/archive/internal/zoo"
+	"github.com/Defacto2/df2/pkg/charset"
+	"github.com/mholt/archiver"
+	"github.com/nwaples/rardecode"
+)
+
+// ManifestExt is the file extension of the manifest sidecar file saved beside the UUID download.
+const ManifestExt = ".manifest.json"
+
+const fileMode = 0o644
+
+// Manifest is a structured listing of the files within an archive.
+type Manifest struct {
+	Archive string  `json:"archive"` // Archive is the filename of the archive.
+	Files   []Entry `json:"files"`   // Files within the archive, excluding directories.
+}
+
+// Entry is a file within an archive manifest.
+// The values that are not recorded by the archive format are left empty.
+type Entry struct {
+	Name     string     `json:"name"`               // Name of the file including its path.
+	Size     int64      `json:"size"`               // Size of the uncompressed file in bytes.
+	Packed   int64      `json:"packed"`             // Packed size of the compressed file in bytes.
+	Modified *time.Time `json:"modified,omitempty"` // Modified is the last modification time of the file.
+	CRC      string     `json:"crc,omitempty"`      // CRC is the hexadecimal checksum of the uncompressed file.
+	Method   string     `json:"method,omitempty"`   // Method of compression.
+}
+
+func modified(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
+
+func crc32(c uint32) string {
+	return fmt.Sprintf("%08x", c)
+}
+
+func crc16(c uint16) string {
+	return fmt.Sprintf("%04x", c)
+}
+
+// ReadManifest returns the manifest of the src archive.
+// name is the original archive filename and file extension.
+func ReadManifest(src, name string) (Manifest, error) {
+	m := Manifest{Archive: name, Files: []Entry{}}
+	var err error
+	switch strings.ToLower(filepath.Ext(name)) {
+	case arjx:
+		err = m.arj(src)
+	case lhax, lzhx:
+		err = m.lha(src)
+	case arcx, arkx, pakx:
+		err = m.sea(src)
+	case zoox:
+		err = m.zoo(src)
+	case ".zip":
+		err = m.zip(src)
+	default:
+		err = m.walk(src, name)
+	}
+	if err != nil {
+		return Manifest{}, fmt.Errorf("read manifest %s: %w", name, err)
+	}
+	return m, nil
+}
+
+func (m *Manifest) arj(src string) error {
+	z, err := arj.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer z.Close()
+	methods := map[int]string{
+		arj.Stored: "stored", arj.Method1: "method 1", arj.Method2: "method 2",
+		arj.Method3: "method 3", arj.Method4: "fastest",
+	}
+	for _, f := range z.File {
+		if f.IsDir() || f.Type == arj.Label || f.Flags&arj.FlagExtFile != 0 {
+			continue
+		}
+		method, ok := methods[f.Method]
+		if !ok {
+			method = fmt.Sprintf("method %d", f.Method)
+		}
+		m.Files = append(m.Files, Entry{
+			Name: f.Name, Size: f.Size, Packed: f.CompressedSize,
+			Modified: modified(f.Modified), CRC: crc32(f.CRC32), Method: method,
+		})
+	}
+	return nil
+}
+
+func (m *Manifest) lha(src string) error {
+	z, err := lha.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer z.Close()
+	for _, f := range z.File {
+		if f.IsDir() {
+			continue
+		}
+		m.Files = append(m.Files, Entry{
+			Name: f.Name, Size: f.Size, Packed: f.CompressedSize,
+			Modified: modified(f.Modified), CRC: crc16(f.CRC16), Method: f.Method,
+		})
+	}
+	return nil
+}
+
+func (m *Manifest) sea(src string) error {
+	z, err := sea.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer z.Close()
+	methods := map[int]string{
+		sea.Unpacked: "stored", sea.Stored: "stored", sea.Packed: "packed", sea.Squeezed: "squeezed",
+		sea.Crunched: "crunched", sea.Squashed: "squashed", sea.Crushed: "crushed", sea.Distilled: "distilled",
+	}
+	for _, f := range z.File {
+		method, ok := methods[f.Method]
+		if !ok {
+			method = "crunched"
+		}
+		m.Files = append(m.Files, Entry{
+			Name: f.Name, Size: f.Size, Packed: f.CompressedSize,
+			Modified: modified(f.Modified), CRC: crc16(f.CRC16), Method: method,
+		})
+	}
+	return nil
+}
+
+func (m *Manifest) zoo(src string) error {
+	z, err := zoo.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer z.Close()
+	methods := map[int]string{zoo.Stored: "stored", zoo.LZW: "lzw", zoo.LH5: "lh5"}
+	for _, f := range z.File {
+		m.Files = append(m.Files, Entry{
+			Name: f.Name, Size: f.Size, Packed: f.CompressedSize,
+			Modified: modified(f.Modified), CRC: crc16(f.CRC16), Method: methods[f.Method],
+		})
+	}
+	return nil
+}
+
+func (m *Manifest) zip(src string) error {
+	z, err := zip.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer z.Close()
+	methods := map[uint16]string{
+		zip.Store: "store", 1: "shrink", 2: "reduce", 3: "reduce", 4: "reduce", 5: "reduce",
+		6: "implode", zip.Deflate: "deflate", 9: "deflate64", 12: "bzip2", 14: "lzma",
+	}
+	for _, f := range z.File {
+		if strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		method, ok := methods[f.Method]
+		if !ok {
+			method = fmt.Sprintf("method %d", f.Method)
+		}
+		m.Files = append(m.Files, Entry{
+			Name: charset.Name([]byte(f.Name)), Size: int64(f.UncompressedSize64), Packed: int64(f.CompressedSize64),
+			Modified: modified(f.Modified), CRC: crc32(f.CRC32), Method: method,
+		})
+	}
+	return nil
+}
+
+// walk the src archive using the mholt archiver for the tar and rar formats.
+// The packed sizes of the files within compressed tarballs are unknown and left empty.
+func (m *Manifest) walk(src, name string) error {
+	return arc.Walkr(src, name, func(f archiver.File) error {
+		if f.IsDir() {
+			return nil
+		}
+		e := Entry{Name: f.Name(), Size: f.Size(), Packed: f.Size(), Modified: modified(f.ModTime())}
+		switch h := f.Header.(type) {
+		case *tar.Header:
+			e.Name = h.Name
+			e.Packed = 0
+			if strings.EqualFold(filepath.Ext(name), ".tar") {
+				e.Packed, e.Method = h.Size, "store"
+			}
+		case *rardecode.FileHeader:
+			e.Name = h.Name
+			e.Size, e.Packed = h.UnPackedSize, h.PackedSize
+		}
+		e.Name = charset.Name([]byte(e.Name))
+		m.Files = append(m.Files, e)
+		return nil
+	})
+}
+
+// Save the manifest as an indented JSON file to the named path.
+func (m Manifest) Save(name string) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("save manifest: %w", err)
+	}
+	if err := os.WriteFile(name, append(b, '\n'), fileMode); err != nil {
+		return fmt.Errorf("save manifest: %w", err)
+	}
+	return nil
+}
+
+// LoadManifest returns the manifest saved in the named JSON file.
+func LoadManifest(name string) (Manifest, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("load manifest: %w", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return Manifest{}, fmt.Errorf("load manifest %s: %w", filepath.Base(name), err)
+	}
+	return m, nil
+}
@@ -0,0 +1,58 @@
+package archive_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Defacto2/df2/pkg/archive"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadManifest(t *testing.T) {
+	t.Parallel()
+	_, err := archive.ReadManifest("", "")
+	assert.NotNil(t, err)
+	tests := []struct {
+		src    string
+		name   string
+		method string
+	}{
+		{filepath.Join("arj", "hello.arj"), "hello.arj", ""},
+		{filepath.Join("lha", "hello.lzh"), "hello.lzh", ""},
+		{filepath.Join("arc", "hello.arc"), "hello.arc", ""},
+		{filepath.Join("zoo", "hello.zoo"), "hello.zoo", ""},
+		{filepath.Join("demozoo", "test.zip"), "test.zip", ""},
+		{filepath.Join("demozoo", "test.tar"), "test.tar", "store"},
+		{filepath.Join("demozoo", "test.rar"), "test.rar", ""},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			m, err := archive.ReadManifest(testDir(tt.src), tt.name)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.name, m.Archive)
+			assert.NotEmpty(t, m.Files)
+			for _, f := range m.Files {
+				assert.NotEmpty(t, f.Name)
+				assert.NotZero(t, f.Size)
+				if tt.method != "" {
+					assert.Equal(t, tt.method, f.Method)
+				}
+			}
+		})
+	}
+}
+
+func TestManifest_Save(t *testing.T) {
+	t.Parallel()
+	m, err := archive.ReadManifest(testDir(filepath.Join("arj", "hello.arj")), "hello.arj")
+	assert.Nil(t, err)
+	name := filepath.Join(t.TempDir(), "uuid"+archive.ManifestExt)
+	assert.Nil(t, m.Save(name))
+	l, err := archive.LoadManifest(name)
+	assert.Nil(t, err)
+	assert.Equal(t, m, l)
+	_, err = archive.LoadManifest(filepath.Join(t.TempDir(), "missing"+archive.ManifestExt))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,209 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Defacto2/df2/pkg/magic"
+)
+
+const (
+	dirMode   = 0o755
+	NestDepth = 3        // NestDepth is the default number of nested archive levels to read.
+	NestSize  = 64 << 20 // NestSize is the default size limit of a nested archive in bytes.
+)
+
+var ErrNestSize = errors.New("nested archive is larger than the size limit")
+
+// Nest limits the traversal of archives that are packed within other archives,
+// such as a disk-split zip or an inner ARJ archive that is shipped with a file_id.diz.
+type Nest struct {
+	Depth   int   // Depth is the number of nested archive levels to read, zero only reads the top level.
+	MaxSize int64 // MaxSize is the largest nested archive to read in bytes, zero uses NestSize.
+}
+
+// Nested returns the default limits for reading nested archives.
+func Nested() Nest {
+	return Nest{Depth: NestDepth, MaxSize: NestSize}
+}
+
+func (n Nest) limit() int64 {
+	if n.MaxSize <= 0 {
+		return NestSize
+	}
+	return n.MaxSize
+}
+
+// Nestable returns true if the named file uses the file extension of a readable archive.
+func Nestable(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".7z", arcx, arjx, arkx, lhax, lzhx, pakx, ".rar", ".tar", ".tgz", ".zip", zoox:
+		return true
+	case ".bz2", ".gz", ".xz":
+		return strings.HasSuffix(strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name))), ".tar")
+	}
+	return false
+}
+
+// ReadNested returns both a list of files within the archive and a suitable archive filename,
+// in the same manner as Read. The content of any archives within the archive are also listed
+// up to the depth of the nest, with each nested path prefixed by the path of its archive,
+// for example OUTER.ZIP/INNER.ARJ/README.NFO.
+// Nested archives that cannot be read are listed but are otherwise ignored.
+func ReadNested(w io.Writer, src, name string, n Nest) ([]string, string, error) {
+	if w == nil {
+		w = io.Discard
+	}
+	files, fname, err := Read(w, src, name)
+	if err != nil || n.Depth < 1 {
+		return files, fname, err
+	}
+	tmp, err := os.MkdirTemp("", "nested-")
+	if err != nil {
+		return nil, "", fmt.Errorf("read nested tempdir: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+	return nested(src, fname, files, n.Depth, n.limit(), tmp), fname, nil
+}
+
+// nested lists the files and the content of any nested archives found in the files.
+func nested(src, name string, files []string, depth int, limit int64, tmp string) []string {
+	list := make([]string, 0, len(files))
+	for _, file := range files {
+		list = append(list, file)
+		if depth < 1 || !Nestable(file) {
+			continue
+		}
+		sub, err := unpack(name, src, file, tmp, limit)
+		if err != nil {
+			continue
+		}
+		inner, iname, err := Readr(io.Discard, sub, filepath.Base(file))
+		if err != nil {
+			continue
+		}
+		for _, x := range nested(sub, iname, inner, depth-1, limit, tmp) {
+			list = append(list, path.Join(file, x))
+		}
+	}
+	return list
+}
+
+// unpack extracts the target archive from the src archive into a new directory in tmp,
+// and returns the path to the extracted archive.
+// The extraction stops as soon as the target archive is larger than the limit in bytes.
+func unpack(name, src, target, tmp string, limit int64) (string, error) {
+	dir, err := os.MkdirTemp(tmp, "inner-")
+	if err != nil {
+		return "", fmt.Errorf("unpack tempdir: %w", err)
+	}
+	if err := Limited().extract(name, src, target, dir, limit); err != nil {
+		return "", err
+	}
+	for _, p := range []string{filepath.Join(dir, target), filepath.Join(dir, filepath.Base(target))} {
+		st, err := os.Stat(p)
+		if err != nil || st.IsDir() {
+			continue
+		}
+		t, err := magic.File(p)
+		if err != nil {
+			return "", fmt.Errorf("unpack magic: %w", err)
+		}
+		if !t.Archive() {
+			return "", fmt.Errorf("unpack %s: %w", target, ErrArchive)
+		}
+		return p, nil
+	}
+	return "", fmt.Errorf("unpack %s: %w", target, ErrFile)
+}
+
+// ExtractNested extracts the target file from the src archive into the dest directory,
+// where the target can be a path within nested archives, for example INNER.ARJ/README.NFO.
+// The extracted file keeps the complete target path within dest.
+// name is the original archive filename and file extension.
+func ExtractNested(name, src, target, dest string) error {
+	elems := strings.Split(target, "/")
+	tmp, err := os.MkdirTemp("", "nested-")
+	if err != nil {
+		return fmt.Errorf("extract nested tempdir: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+	prefix, start := "", 0
+	for i := 0; i < len(elems)-1; i++ {
+		if !Nestable(elems[i]) {
+			continue
+		}
+		archive := strings.Join(elems[start:i+1], "/")
+		sub, err := unpack(name, src, archive, tmp, NestSize)
+		if err != nil {
+			// the element could be a directory that uses an archive extension
+			continue
+		}
+		name, src = filepath.Base(archive), sub
+		prefix, start = path.Join(prefix, archive), i+1
+	}
+	if prefix == "" {
+		return Extractor(name, src, target, dest)
+	}
+	dir := filepath.Join(dest, filepath.FromSlash(prefix))
+	if err := os.MkdirAll(dir, dirMode); err != nil {
+		return fmt.Errorf("extract nested mkdir: %w", err)
+	}
+	return Extractor(name, src, strings.Join(elems[start:], "/"), dir)
+}
+
+// unnest replaces the nestable archives within the dir directory with directories
+// of the same name that hold the extracted archive content.
+func unnest(dir string, n Nest) error {
+	if n.Depth < 1 {
+		return nil
+	}
+	var archives []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !Nestable(d.Name()) {
+			return nil
+		}
+		st, err := d.Info()
+		if err != nil || st.Size() > n.limit() {
+			return nil //nolint:nilerr
+		}
+		if t, err := magic.File(p); err != nil || !t.Archive() {
+			return nil //nolint:nilerr
+		}
+		archives = append(archives, p)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("unnest walk: %w", err)
+	}
+	for _, p := range archives {
+		tmp := p + ".unnest"
+		if err := os.Rename(p, tmp); err != nil {
+			return fmt.Errorf("unnest rename: %w", err)
+		}
+		if err := Unarchiver(tmp, p, strings.ToLower(filepath.Base(p))); err != nil {
+			// keep the unreadable archive as a file
+			_ = os.RemoveAll(p)
+			if err := os.Rename(tmp, p); err != nil {
+				return fmt.Errorf("unnest restore: %w", err)
+			}
+			continue
+		}
+		if err := os.Remove(tmp); err != nil {
+			return fmt.Errorf("unnest remove: %w", err)
+		}
+		if err := unnest(p, Nest{Depth: n.Depth - 1, MaxSize: n.MaxSize}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
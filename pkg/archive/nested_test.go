@@ -0,0 +1,78 @@
+package archive_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Defacto2/df2/pkg/archive"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNestable(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"", false},
+		{"readme.nfo", false},
+		{"INNER.ARJ", true},
+		{"disk/disk1.zip", true},
+		{"files.tar.gz", true},
+		{"file.gz", false},
+		{"DEEP.LZH", true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, archive.Nestable(tt.name), tt.name)
+	}
+}
+
+func TestReadNested(t *testing.T) {
+	t.Parallel()
+	src := testDir("nested/outer.zip")
+	files, name, err := archive.ReadNested(nil, src, "outer.zip", archive.Nest{})
+	assert.Nil(t, err)
+	assert.Equal(t, "outer.zip", name)
+	assert.Equal(t, []string{"FILE_ID.DIZ", "INNER.ARJ", "DISK/DISK1.ZIP"}, files)
+
+	files, _, err = archive.ReadNested(nil, src, "outer.zip", archive.Nest{Depth: 1})
+	assert.Nil(t, err)
+	assert.Contains(t, files, "INNER.ARJ/HELLO.TXT")
+	assert.Contains(t, files, "DISK/DISK1.ZIP/README.NFO")
+	assert.Contains(t, files, "DISK/DISK1.ZIP/DEEP.LZH")
+	assert.NotContains(t, files, "DISK/DISK1.ZIP/DEEP.LZH/HELLO.TXT")
+
+	files, _, err = archive.ReadNested(nil, src, "outer.zip", archive.Nested())
+	assert.Nil(t, err)
+	assert.Len(t, files, 7)
+	assert.Contains(t, files, "DISK/DISK1.ZIP/DEEP.LZH/HELLO.TXT")
+
+	// the size limit skips the larger nested archives
+	files, _, err = archive.ReadNested(nil, src, "outer.zip", archive.Nest{Depth: 2, MaxSize: 200})
+	assert.Nil(t, err)
+	assert.Contains(t, files, "INNER.ARJ/HELLO.TXT")
+	assert.NotContains(t, files, "DISK/DISK1.ZIP/README.NFO")
+
+	_, _, err = archive.ReadNested(nil, testDir("nested"), "outer.zip", archive.Nested())
+	assert.ErrorIs(t, err, archive.ErrDir)
+}
+
+func TestExtractNested(t *testing.T) {
+	t.Parallel()
+	src := testDir("nested/outer.zip")
+	dir := t.TempDir()
+	err := archive.ExtractNested("outer.zip", src, "DISK/DISK1.ZIP/DEEP.LZH/HELLO.TXT", dir)
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(dir, "DISK", "DISK1.ZIP", "DEEP.LZH", "HELLO.TXT"))
+	assert.Nil(t, err)
+
+	err = archive.ExtractNested("outer.zip", src, "FILE_ID.DIZ", dir)
+	assert.Nil(t, err)
+	b, err := os.ReadFile(filepath.Join(dir, "FILE_ID.DIZ"))
+	assert.Nil(t, err)
+	assert.Equal(t, "Nested archive test\r\n", string(b))
+
+	err = archive.ExtractNested("outer.zip", src, "INNER.ARJ/NOTHING.TXT", dir)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,73 @@
+package archive
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Defacto2/df2/pkg/archive/internal/dosarc"
+	"github.com/Defacto2/df2/pkg/archive/internal/volume"
+)
+
+// Assemble finds the multi-volume and split archives within the dir directory,
+// such as SPLIT.ARJ, SPLIT.A01 or SPLIT.Z01, SPLIT.ZIP, and extracts the content of each
+// complete volume set into the directory that holds its volumes. The volumes are removed
+// after a successful extraction.
+// Any volume sets with missing volumes are reported to the writer and are otherwise ignored.
+// The returned files are the extracted files with paths relative to dir.
+// Volume sets that break the default Limits return a Violation error and nothing is extracted.
+func Assemble(w io.Writer, dir string) ([]string, error) {
+	return Limited().Assemble(w, dir)
+}
+
+// Assemble the volume sets within the dir directory in the same manner as Assemble,
+// but returns a Violation error and extracts nothing from a set that breaks the limits.
+func (l Limits) Assemble(w io.Writer, dir string) ([]string, error) {
+	if w == nil {
+		w = io.Discard
+	}
+	sets, err := volume.Find(dir)
+	if err != nil {
+		return nil, fmt.Errorf("assemble: %w", err)
+	}
+	files := []string{}
+	for _, s := range sets {
+		if !s.Complete() {
+			fmt.Fprintf(w, "  volume set %s is missing: %s\n", s.Name, strings.Join(s.Missing, ", "))
+			continue
+		}
+		names, err := s.List()
+		if err != nil {
+			fmt.Fprintf(w, "  volume set %s is unreadable: %s\n", s.Name, err)
+			continue
+		}
+		if err := l.Names(s.Name, names...); err != nil {
+			return nil, err
+		}
+		var src int64
+		for _, part := range s.Parts {
+			src += size(part)
+		}
+		dest := filepath.Dir(s.Parts[0])
+		if err := l.guard(s.Name, src, dest, func(dir string, lim *dosarc.Limit) error {
+			return s.ExtractLimit(dir, lim)
+		}); err != nil {
+			return nil, fmt.Errorf("assemble %s: %w", s.Name, err)
+		}
+		for _, part := range s.Parts {
+			if err := os.Remove(part); err != nil {
+				return nil, fmt.Errorf("assemble remove: %w", err)
+			}
+		}
+		rel, err := filepath.Rel(dir, dest)
+		if err != nil {
+			return nil, fmt.Errorf("assemble: %w", err)
+		}
+		for _, name := range names {
+			files = append(files, filepath.ToSlash(filepath.Join(rel, name)))
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,51 @@
+package archive_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Defacto2/df2/pkg/archive"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssemble(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "disks")
+	assert.Nil(t, os.Mkdir(sub, 0o755))
+	for _, name := range []string{"SPLIT.ARJ", "SPLIT.A01", "SPLIT.A02", "SPAN.Z01", "SPAN.Z03", "SPAN.ZIP"} {
+		b, err := os.ReadFile(testDir(filepath.Join("volumes", name)))
+		assert.Nil(t, err)
+		assert.Nil(t, os.WriteFile(filepath.Join(sub, name), b, 0o644))
+	}
+	var buf bytes.Buffer
+	files, err := archive.Assemble(&buf, dir)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"disks/SPLIT.NFO", "disks/LONG.TXT", "disks/HELLO.TXT"}, files)
+	assert.Contains(t, buf.String(), "SPAN.ZIP is missing: SPAN.Z02")
+	_, err = os.Stat(filepath.Join(sub, "LONG.TXT"))
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(sub, "SPLIT.A01"))
+	assert.NotNil(t, err)
+	_, err = os.Stat(filepath.Join(sub, "SPAN.Z01"))
+	assert.Nil(t, err)
+}
+
+func TestLimits_Assemble(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	for _, name := range []string{"SPLIT.ARJ", "SPLIT.A01", "SPLIT.A02"} {
+		b, err := os.ReadFile(testDir(filepath.Join("volumes", name)))
+		assert.Nil(t, err)
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, name), b, 0o644))
+	}
+	files, err := archive.Limits{Files: 1}.Assemble(nil, dir)
+	assert.ErrorIs(t, err, archive.ErrFiles)
+	assert.Empty(t, files)
+	_, err = os.Stat(filepath.Join(dir, "SPLIT.NFO"))
+	assert.NotNil(t, err)
+	_, err = os.Stat(filepath.Join(dir, "SPLIT.A01"))
+	assert.Nil(t, err)
+}
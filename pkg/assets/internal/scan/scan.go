@@ -84,7 +84,7 @@ func (s *Scan) archive(skip Files, list *[]os.FileInfo) Files {
 		if _, ok := skip[name]; ok {
 			continue
 		}
-		uuid := strings.TrimSuffix(name, filepath.Ext(name))
+		uuid := Key(name)
 		// search mapped IDs for a UUID value
 		if _, ok := s.IDs[uuid]; !ok {
 			f[name] = database.Empty{}
@@ -93,6 +93,13 @@ func (s *Scan) archive(skip Files, list *[]os.FileInfo) Files {
 	return f
 }
 
+// Key returns the UUID of the named asset file by removing all of its file extensions,
+// so the sidecar files that are saved beside a download, such as uuid.manifest.json, are not orphans.
+func Key(name string) string {
+	uuid, _, _ := strings.Cut(name, ".")
+	return uuid
+}
+
 // scanPath gets a list of filenames located in s.Path and matches the Results
 // against the list generated by CreateUUIDMap.
 func (s Scan) scanPath(w io.Writer, d *directories.Dir) (Results, error) {
@@ -296,7 +303,7 @@ func parse(w io.Writer, s *Scan, ignore Files, list *[]os.FileInfo) (Results, er
 			continue // ignore files
 		}
 		i := item{human: s.Human, name: file.Name()}
-		uuid := strings.TrimSuffix(i.name, filepath.Ext(i.name))
+		uuid := Key(i.name)
 		tw := tabwriter.NewWriter(w, 0, 0, padding, ' ', 0)
 		if _, ok := s.IDs[uuid]; !ok {
 			stat.totals(file)
@@ -103,3 +103,12 @@ func TestBackup(t *testing.T) {
 	err = scan.Backup(io.Discard, skip, &list, &s, &d)
 	assert.Nil(t, err)
 }
+
+func TestKey(t *testing.T) {
+	t.Parallel()
+	const uuid = "0b6d9c4e-3b7f-4a3b-9a9f-2d1c4d6e8f00"
+	assert.Equal(t, "", scan.Key(""))
+	assert.Equal(t, uuid, scan.Key(uuid))
+	assert.Equal(t, uuid, scan.Key(uuid+".png"))
+	assert.Equal(t, uuid, scan.Key(uuid+archive.ManifestExt))
+}
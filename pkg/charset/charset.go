@@ -0,0 +1,157 @@
+// Package charset detects and decodes the legacy character sets used by the filenames
+// that are stored within DOS and Windows era archives. These archive formats do not record
+// the character set of a filename, which was usually the OEM code page of the packer,
+// either IBM Code Page 437 or the Shift-JIS of Japanese systems.
+//
+// It also classifies the character sets of text documents such as NFOs and readmes,
+// which are usually CP437, ISO-8859-1, Windows-1252, Amiga Latin-1 or UTF-8.
+package charset
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bengarrett/retrotxtgo/byter"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+)
+
+// Charset is the character set of a filename or text.
+type Charset int
+
+const (
+	UTF8        Charset = iota // UTF8 is Unicode or plain ASCII.
+	CP437                      // CP437 is the IBM Code Page 437 used by MS-DOS.
+	ShiftJIS                   // ShiftJIS is the Shift Japanese Industrial Standards used by Japanese DOS and Windows.
+	Latin1                     // Latin1 is the ISO-8859-1 character set used by Unix and the early web.
+	Windows1252                // Windows1252 is the Western European code page used by Windows.
+	Amiga                      // Amiga is the ISO-8859-1 based character set of the Commodore Amiga Topaz font.
+)
+
+func (c Charset) String() string {
+	switch c {
+	case UTF8:
+		return "UTF-8"
+	case CP437:
+		return "CP-437"
+	case ShiftJIS:
+		return "Shift-JIS"
+	case Latin1:
+		return "ISO-8859-1"
+	case Windows1252:
+		return "Windows-1252"
+	case Amiga:
+		return "Amiga Latin-1"
+	}
+	return ""
+}
+
+// Detect returns the likely character set of the b filename.
+// Valid UTF-8 is always returned as UTF8 and text that is not Shift-JIS is treated as CP437.
+func Detect(b []byte) Charset {
+	if utf8.Valid(b) {
+		return UTF8
+	}
+	if sjis(b) {
+		return ShiftJIS
+	}
+	return CP437
+}
+
+// Decode returns the b filename or text in the c character set as a UTF-8 string.
+// Any filename that cannot be decoded is returned unchanged.
+func Decode(b []byte, c Charset) string {
+	var s []byte
+	var err error
+	switch c {
+	case CP437:
+		s, err = charmap.CodePage437.NewDecoder().Bytes(b)
+	case ShiftJIS:
+		s, err = japanese.ShiftJIS.NewDecoder().Bytes(b)
+	case Latin1, Windows1252, Amiga:
+		s, err = byter.Decode(c.Charmap(), string(b))
+	default:
+		return string(b)
+	}
+	if err != nil {
+		return string(b)
+	}
+	return string(s)
+}
+
+// Name returns the b filename as a UTF-8 string using the detected character set.
+func Name(b []byte) string {
+	return Decode(b, Detect(b))
+}
+
+// Encode returns the s UTF-8 string encoded to the legacy character set that it was decoded from,
+// either CP437 or Shift-JIS. It is the inverse of Name and plain ASCII is returned unchanged,
+// as is any text that cannot be encoded to either character set.
+func Encode(s string) []byte {
+	if b, err := charmap.CodePage437.NewEncoder().String(s); err == nil {
+		return []byte(b)
+	}
+	if b, err := japanese.ShiftJIS.NewEncoder().String(s); err == nil {
+		return []byte(b)
+	}
+	return []byte(s)
+}
+
+// Repair returns the s filename as a UTF-8 string, where s was previously saved
+// either without decoding or with the Shift-JIS characters mistakenly decoded as CP437.
+// Filenames that do not need a repair are returned unchanged.
+func Repair(s string) string {
+	if !utf8.ValidString(s) {
+		return Name([]byte(s))
+	}
+	b, err := charmap.CodePage437.NewEncoder().String(s)
+	if err != nil || b == s {
+		return s
+	}
+	if !sjis([]byte(b)) {
+		return s
+	}
+	// only repair when the Shift-JIS round-trip is lossless,
+	// otherwise the valid UTF-8 could be a correctly saved European name
+	r, err := japanese.ShiftJIS.NewDecoder().String(b)
+	if err != nil || strings.ContainsRune(r, utf8.RuneError) {
+		return s
+	}
+	if e, err := japanese.ShiftJIS.NewEncoder().String(r); err != nil || e != b {
+		return s
+	}
+	return r
+}
+
+// sjis returns true if the b filename is likely to be Shift-JIS encoded.
+// All the non-ASCII bytes must form valid characters. But a CP437 accented letter followed by
+// an ASCII letter is also a valid Shift-JIS pair, such as the "Üb" of "Über", so there must be
+// at least one pair that cannot be read that way, either because the trail byte is not a letter
+// or because the lead byte is not a CP437 accented letter.
+func sjis(b []byte) bool {
+	const (
+		ascii   = 0x80
+		accent  = 0x9a // last of the CP437 accented letters
+		kanaMin = 0xa1 // half-width katakana
+		kanaMax = 0xdf
+	)
+	lead := func(c byte) bool { return (c >= 0x81 && c <= 0x9f) || (c >= 0xe0 && c <= 0xfc) }
+	trail := func(c byte) bool { return (c >= 0x40 && c <= 0x7e) || (c >= 0x80 && c <= 0xfc) }
+	letter := func(c byte) bool { return (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') }
+	pairs := 0
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case c < ascii:
+		case c >= kanaMin && c <= kanaMax:
+		case lead(c) && i+1 < len(b) && trail(b[i+1]):
+			if !letter(b[i+1]) || c > accent {
+				pairs++
+			}
+			i++
+		default:
+			return false
+		}
+	}
+	return pairs > 0
+}
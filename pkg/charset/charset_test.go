@@ -0,0 +1,68 @@
+package charset_test
+
+import (
+	"testing"
+
+	"github.com/Defacto2/df2/pkg/charset"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		b    string
+		want charset.Charset
+	}{
+		{"", charset.UTF8},
+		{"README.NFO", charset.UTF8},
+		{"CAFÉ.NFO", charset.UTF8},
+		{"CAF\x90.NFO", charset.CP437},
+		{"\xb0\xb1\xb2.NFO", charset.CP437},
+		{"M\x9aLLER.TXT", charset.CP437},
+		{"\x83\x66\x83\x82.NFO", charset.ShiftJIS},
+		{"\x82\xa0.TXT", charset.ShiftJIS},
+		{"\x93\xfa\x96\x7b.TXT", charset.ShiftJIS},
+		{"\x9aBERM\x8eDCHEN.TXT", charset.CP437},
+		{"P\x8are No\x89l.txt", charset.CP437},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, charset.Detect([]byte(tt.b)), tt.b)
+	}
+	assert.Equal(t, "Shift-JIS", charset.ShiftJIS.String())
+}
+
+func TestName(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "README.NFO", charset.Name([]byte("README.NFO")))
+	assert.Equal(t, "CAFÉ.NFO", charset.Name([]byte("CAF\x90.NFO")))
+	assert.Equal(t, "░▒▓.NFO", charset.Name([]byte("\xb0\xb1\xb2.NFO")))
+	assert.Equal(t, "デモ.NFO", charset.Name([]byte("\x83\x66\x83\x82.NFO")))
+	assert.Equal(t, "あ.TXT", charset.Name([]byte("\x82\xa0.TXT")))
+	assert.Equal(t, "ÜBERMÄDCHEN.TXT", charset.Name([]byte("\x9aBERM\x8eDCHEN.TXT")))
+}
+
+func TestEncode(t *testing.T) {
+	t.Parallel()
+	for _, b := range []string{"README.NFO", "CAF\x90.NFO", "\xb0\xb1\xb2.NFO", "\x83\x66\x83\x82.NFO"} {
+		assert.Equal(t, []byte(b), charset.Encode(charset.Name([]byte(b))), b)
+	}
+	assert.Equal(t, []byte("😀"), charset.Encode("😀"))
+}
+
+func TestRepair(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "README.NFO", charset.Repair("README.NFO"))
+	assert.Equal(t, "CAFÉ.NFO", charset.Repair("CAFÉ.NFO"))
+	assert.Equal(t, "░▒▓.NFO", charset.Repair("░▒▓.NFO"))
+	// raw bytes that were never decoded
+	assert.Equal(t, "CAFÉ.NFO", charset.Repair("CAF\x90.NFO"))
+	// Shift-JIS that was decoded as CP437
+	mojibake := charset.Decode([]byte("\x83\x66\x83\x82.NFO"), charset.CP437)
+	assert.Equal(t, "âfâé.NFO", mojibake)
+	assert.Equal(t, "デモ.NFO", charset.Repair(mojibake))
+	assert.Equal(t, "日本.TXT", charset.Repair("日本.TXT"))
+	// European names that are valid Shift-JIS when encoded as CP437
+	for _, s := range []string{"Über Mädchen.txt", "Père Noël.txt", "ÜBERMÄDCHEN.TXT", "Crème brûlée.doc"} {
+		assert.Equal(t, s, charset.Repair(s))
+	}
+}
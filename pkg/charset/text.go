@@ -0,0 +1,128 @@
+package charset
+
+import (
+	"bytes"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/bengarrett/retrotxtgo/byter"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Charmap returns the 8-bit character map of the character set,
+// or nil for UTF-8 and the multi-byte Shift-JIS.
+func (c Charset) Charmap() *charmap.Charmap {
+	switch c {
+	case CP437:
+		return charmap.CodePage437
+	case Latin1, Amiga:
+		return charmap.ISO8859_1
+	case Windows1252:
+		return charmap.Windows1252
+	case UTF8, ShiftJIS:
+	}
+	return nil
+}
+
+// Classify returns the likely character set of the b text document, such as an NFO or readme.
+// Valid UTF-8, which includes plain ASCII, is always returned as UTF8.
+//
+// Any other text is scored as CP437, ISO-8859-1 and Windows-1252 using the characters
+// decoded from the non-ASCII bytes. Accented letters within words, common punctuation and
+// the box drawing characters of text art are plausible, while control codes are not.
+// The scene favoured CP437, so it is returned when the scores are equal.
+//
+// The Amiga had no CP437 font, so when amiga is true, any text that is not UTF-8 is Amiga.
+func Classify(b []byte, amiga bool) Charset {
+	b = byter.TrimEOF(bytes.TrimPrefix(b, byter.BOM()))
+	if utf8.Valid(b) {
+		return UTF8
+	}
+	if amiga {
+		return Amiga
+	}
+	best, high := CP437, 0
+	for i, c := range []Charset{CP437, Latin1, Windows1252} {
+		n := score(b, c.Charmap())
+		if i == 0 || n > high {
+			best, high = c, n
+		}
+	}
+	return best
+}
+
+// score returns the plausibility of the b text when it is decoded using the character map.
+func score(b []byte, cm *charmap.Charmap) int {
+	const ascii = 0x80
+	n := 0
+	for i, c := range b {
+		if c < ascii {
+			continue
+		}
+		var prev, next byte
+		if i > 0 {
+			prev = b[i-1]
+		}
+		if i+1 < len(b) {
+			next = b[i+1]
+		}
+		n += plausible(cm.DecodeByte(c), prev, next)
+	}
+	return n
+}
+
+// plausible returns a score for the decoded r character using the neighbouring prev and next bytes.
+func plausible(r rune, prev, next byte) int {
+	const (
+		likely   = 2
+		possible = 1
+		unlikely = -4
+	)
+	switch {
+	case r == utf8.RuneError, unicode.IsControl(r):
+		return unlikely
+	case unicode.Is(unicode.Latin, r):
+		// an uppercase accented letter following a lowercase letter is unlikely
+		if unicode.IsUpper(r) && lower(prev) {
+			return 0
+		}
+		switch {
+		case letter(prev) && letter(next):
+			return likely
+		case letter(prev) || letter(next):
+			return possible
+		}
+	case r >= '─' && r <= '▟':
+		// box drawing and block elements are used by text art rather than within words
+		if !letter(prev) || !letter(next) {
+			return likely
+		}
+	case punctuation(r):
+		if letter(prev) || letter(next) || space(prev) || space(next) {
+			return likely
+		}
+	}
+	return 0
+}
+
+// punctuation returns true for the common typographic symbols of the 8-bit character sets.
+func punctuation(r rune) bool {
+	switch r {
+	case '‘', '’', '“', '”', '–', '—', '…', '•', '™', '€',
+		'©', '®', '°', '£', '¥', '§', '«', '»', '¢', '±', '½', '¼', '¿', '¡', '·':
+		return true
+	}
+	return false
+}
+
+func letter(c byte) bool {
+	return lower(c) || (c >= 'A' && c <= 'Z')
+}
+
+func lower(c byte) bool {
+	return c >= 'a' && c <= 'z'
+}
+
+func space(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
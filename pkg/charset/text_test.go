@@ -0,0 +1,54 @@
+package charset_test
+
+import (
+	"testing"
+
+	"github.com/Defacto2/df2/pkg/charset"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		b     string
+		amiga bool
+		want  charset.Charset
+	}{
+		{"empty", "", false, charset.UTF8},
+		{"ascii", "Hello world\r\n", false, charset.UTF8},
+		{"utf-8", "Café résumé", false, charset.UTF8},
+		{"bom", "\xef\xbb\xbfCafé", false, charset.UTF8},
+		{"cp437 accents", "Caf\x82 r\x82sum\x82 M\x84rz", false, charset.CP437},
+		{"cp437 art", "\xdb\xdb\xdb\xdf\xdc \xc9\xcd\xcd\xbb\r\n\xba hi \xba", false, charset.CP437},
+		{"cp437 ambiguous", "\xe9", false, charset.CP437},
+		{"latin-1 accents", "Caf\xe9 r\xe9sum\xe9 M\xe4rz", false, charset.Latin1},
+		{"latin-1 symbols", "\xa9 1995 \xb7 \xa3 5", false, charset.Latin1},
+		{"windows quotes", "\x93Hello\x94 don\x92t \x96 wait\x85", false, charset.Windows1252},
+		{"windows euro", "Price \x80 5 \x99", false, charset.Windows1252},
+		{"amiga", "\xb8,\xf8\xb0\xba\xb0\xf8,\xb8 Caf\xe9", true, charset.Amiga},
+		{"amiga ascii", "Hello", true, charset.UTF8},
+		{"eof", "Caf\xe9 r\xe9sum\xe9\x1a\xdb\xdb\xdb\xdb\xdb\xdb", false, charset.Latin1},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, charset.Classify([]byte(tt.b), tt.amiga), tt.name)
+	}
+}
+
+func TestCharset_Charmap(t *testing.T) {
+	t.Parallel()
+	assert.Nil(t, charset.UTF8.Charmap())
+	assert.Nil(t, charset.ShiftJIS.Charmap())
+	assert.NotNil(t, charset.CP437.Charmap())
+	assert.Equal(t, charset.Latin1.Charmap(), charset.Amiga.Charmap())
+	assert.Equal(t, "Amiga Latin-1", charset.Amiga.String())
+	assert.Equal(t, "Windows-1252", charset.Windows1252.String())
+}
+
+func TestDecode_Text(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "Café", charset.Decode([]byte("Caf\xe9"), charset.Latin1))
+	assert.Equal(t, "Café", charset.Decode([]byte("Caf\xe9"), charset.Amiga))
+	assert.Equal(t, "“Hi”", charset.Decode([]byte("\x93Hi\x94"), charset.Windows1252))
+	assert.Equal(t, "Café", charset.Decode([]byte("Caf\x82"), charset.CP437))
+}
@@ -3,6 +3,7 @@
 package conf
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -16,18 +17,28 @@ import (
 	"github.com/gookit/color"
 )
 
+const (
+	mysqlPort = 3306 // mysqlPort is the default TCP port of MySQL servers.
+	psqlPort  = 5432 // psqlPort is the default TCP port of PostgreSQL servers.
+)
+
 const (
 	EnvPrefix  = "DF2_"     // EnvPrefix is the prefix applied to all environment variable names.
+	MySQL      = "mysql"    // MySQL is the DBEngine value to use the MySQL or MariaDB database.
+	Postgres   = "psql"     // Postgres is the DBEngine value to use the PostgreSQL database.
 	LiveServer = "DF2_HOST" // LiveServer is environment variable name to identify the live web server.
 	GapUser    = "df2"      // GapUser is the Go Application Paths username.
 )
 
+var ErrEngine = errors.New("unknown database engine, use either mysql or psql")
+
 // Config environment overrides for the Defacto2 tool.
 // There are no envDefault attributes in this struct,
 // instead they're found in the func Defaults().
 type Config struct {
 	IsProduction  bool   `env:"PRODUCTION" help:"Use the production mode to log all errors and warnings to a file"` //nolint:lll
 	MaxProcs      uint   `env:"MAXPROCS" help:"Limit the number of operating system threads the program can use"`   //nolint:lll
+	DBEngine      string `env:"DBENGINE" help:"Database engine to use, either mysql or psql"`
 	DBName        string `env:"DBNAME" help:"Name of the database to use"`
 	DBUser        string `env:"DBUSER" help:"Database connection user name"`
 	DBPass        string `env:"DBPASS" help:"Database connection password"`
@@ -44,7 +55,10 @@ type Config struct {
 	IncomingImgs  string `env:"INCOMINGIMG" help:"Path containing screenshots of user uploaded files"`
 	HTMLViews     string `env:"VIEWS" help:"Path to save the HTML files generated by this tool"`
 	SQLDumps      string `env:"SQLDUMP" help:"Path containing database data exports as SQL dumps"`
+	SearchIndex   string `env:"INDEX" help:"Path containing the full-text search index of the readmes and NFOs"`
+	ImageFormats  string `env:"IMGFORMATS" help:"Optional image formats to generate with the previews, either avif, jxl or avif,jxl"` //nolint:lll
 	Timeout       uint   `env:"TIMEOUT" help:"The timeout in seconds value for database connections"`
+	MigratePass   string `env:"MIGRATEPASS" help:"Password for the Postgres user of the migrate command, otherwise DBPASS is used"` //nolint:lll
 }
 
 // Defaults for the Config environment struct.
@@ -67,22 +81,20 @@ func Defaults() Config {
 		webRoot = filepath.Join(home, "github", "Defacto2-2020", "ROOT")
 	}
 
-	const (
-		mysqlPort  = 3306
-		timeoutSec = 30
-	)
+	const timeoutSec = 30
 
 	init := Config{
 		// program settings
 		IsProduction: false,
 		MaxProcs:     0,
 		// database connection
-		DBName:  "defacto2-inno",
-		DBUser:  "root",
-		DBPass:  "password",
-		DBHost:  "localhost",
-		DBPort:  mysqlPort,
-		Timeout: timeoutSec, // Timeout value matches the 30s timeout for unit tests.
+		DBEngine: MySQL,
+		DBName:   "defacto2-inno",
+		DBUser:   "root",
+		DBPass:   "password",
+		DBHost:   "localhost",
+		DBPort:   0,          // zero uses the port of the DBEngine
+		Timeout:  timeoutSec, // Timeout value matches the 30s timeout for unit tests.
 		// directory paths
 		WebRoot:       webRoot,
 		Downloads:     filepath.Join(assets, "downloads"),
@@ -95,6 +107,7 @@ func Defaults() Config {
 		IncomingFiles: filepath.Join(incoming, "files"),
 		IncomingImgs:  filepath.Join(incoming, "previews"),
 		SQLDumps:      filepath.Join(opt, "backup"),
+		SearchIndex:   filepath.Join(assets, "index"),
 	}
 	if ok && value != "" {
 		init.DBHost = value
@@ -122,7 +135,33 @@ func TestData() Config {
 		IncomingFiles: filepath.Join(incoming, "files"),
 		IncomingImgs:  filepath.Join(incoming, "previews"),
 		SQLDumps:      filepath.Join(tmp, "backup"),
+		SearchIndex:   filepath.Join(assets, "index"),
+	}
+}
+
+// IsPostgres reports whether the configuration uses the PostgreSQL database engine.
+// An empty engine uses MySQL, while an unknown engine returns an error.
+func (c Config) IsPostgres() (bool, error) {
+	switch s := strings.ToLower(strings.TrimSpace(c.DBEngine)); s {
+	case Postgres, "postgres", "postgresql", "pg":
+		return true, nil
+	case MySQL, "mariadb", "":
+		return false, nil
+	default:
+		return false, fmt.Errorf("%w: %q", ErrEngine, s)
+	}
+}
+
+// Port returns the TCP port of the database connection.
+// An unset DBPort uses the default port of the database engine.
+func (c Config) Port() uint {
+	if c.DBPort != 0 {
+		return c.DBPort
+	}
+	if pg, _ := c.IsPostgres(); pg {
+		return psqlPort
 	}
+	return mysqlPort
 }
 
 func Options() env.Options {
@@ -150,7 +189,7 @@ func (c Config) String(w io.Writer) { //nolint:funlen
 		h4       = "Value type"
 		h5       = "Help"
 		line     = "─"
-		donotuse = 5
+		donotuse = 6
 	)
 
 	// TODO:
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+
+	"github.com/Defacto2/df2/pkg/database/internal/audit"
+	"github.com/Defacto2/df2/pkg/database/internal/dialect"
+	"github.com/gookit/color"
+)
+
+// SetCommand names the df2 command that is saved with the changes to the audit log.
+func SetCommand(name string) {
+	dialect.SetCommand(name)
+}
+
+// History prints the audit log of changes made to the file record.
+// The id string must be either a UUID of the record or an increment ID.
+func History(db *sql.DB, w io.Writer, id string) error {
+	if db == nil {
+		return ErrDB
+	}
+	if w == nil {
+		w = io.Discard
+	}
+	i, err := GetID(db, id)
+	if err != nil {
+		return fmt.Errorf("history: %w", err)
+	}
+	es, err := audit.History(context.Background(), db, Files.String(), int64(i))
+	if err != nil {
+		return fmt.Errorf("history: %w", err)
+	}
+	if len(es) == 0 {
+		fmt.Fprintf(w, "there are no changes in the audit log for the file record %d\n", i)
+		return nil
+	}
+	return audit.Print(w, es...)
+}
+
+// Revert restores the column value that was replaced by the change in the audit log.
+// The revert is refused if the column has been edited since the change.
+func Revert(db *sql.DB, w io.Writer, change int64) error {
+	if db == nil {
+		return ErrDB
+	}
+	if w == nil {
+		w = io.Discard
+	}
+	e, err := audit.Revert(context.Background(), db, w, change)
+	if err != nil {
+		return fmt.Errorf("revert change %d: %w", change, err)
+	}
+	fmt.Fprintf(w, "%s %s id %d %s was restored to %s\n", color.Success.Sprint("✓"),
+		e.Table, e.RecordID, e.Column, restored(e.Old))
+	return nil
+}
+
+func restored(s sql.NullString) string {
+	if !s.Valid {
+		return "NULL"
+	}
+	return fmt.Sprintf("%q", s.String)
+}
+
+// Undo restores the records changed by the changeset in the audit log.
+// Values that were edited after the changeset are not restored and are reported as conflicts.
+// When dry is true, the restored values are printed but not saved.
+func Undo(db *sql.DB, w io.Writer, dry bool, changeset int64) error {
+	if db == nil {
+		return ErrDB
+	}
+	if w == nil {
+		w = io.Discard
+	}
+	cs, conflicts, err := audit.Undo(context.Background(), db, w, dry, changeset)
+	if err != nil {
+		return fmt.Errorf("undo changeset %d: %w", changeset, err)
+	}
+	fmt.Fprintf(w, "undo changeset %d, %s (%s)\n", cs.ID, cs.Label,
+		cs.CreatedAt.Format("2006 Jan 2, 15:04"))
+	if len(conflicts) == 0 {
+		fmt.Fprintf(w, "%s all the changes were restored\n", color.Success.Sprint("✓"))
+		return nil
+	}
+	for _, c := range conflicts {
+		fmt.Fprintf(w, "%s %s\n", color.Danger.Sprint("conflict:"), c)
+	}
+	fmt.Fprintf(w, "%d changes were not restored as the values were edited after the changeset\n",
+		len(conflicts))
+	return nil
+}
@@ -1,8 +1,10 @@
-// Package database interacts with the remote datastore for Defacto2. Currently
-// MySQL is implemented with Postgres to be added later.
+// Package database interacts with the remote datastore for Defacto2.
+// Both MySQL and Postgres are implemented, with the engine selected by
+// the DF2_DBENGINE environment variable.
 package database
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -18,17 +20,22 @@ import (
 	"time"
 
 	"github.com/Defacto2/df2/pkg/conf"
+	"github.com/Defacto2/df2/pkg/database/internal/dialect"
 	"github.com/Defacto2/df2/pkg/database/internal/export"
 	"github.com/Defacto2/df2/pkg/database/internal/recd"
 	"github.com/Defacto2/df2/pkg/database/internal/templ"
 	"github.com/Defacto2/df2/pkg/database/internal/update"
 	"github.com/Defacto2/df2/pkg/database/msql"
+	"github.com/Defacto2/df2/pkg/database/psql"
 	"github.com/google/uuid"
 	"github.com/gookit/color"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 )
 
 var (
 	ErrDB      = errors.New("database handle pointer cannot be nil")
+	ErrMigrate = dialect.ErrMigrate
 	ErrNoID    = errors.New("unique id is does not exist in the database table")
 	ErrPointer = errors.New("pointer value cannot be nil")
 	ErrSynID   = errors.New("id value is not a valid")
@@ -43,11 +50,12 @@ const (
 	// TestID is a generic UUID that can be used for unit tests.
 	TestID = "00000000-0000-0000-0000-000000000000"
 	// UpdateID is a user id to use with the updatedby column.
-	UpdateID = "b66dc282-a029-4e99-85db-2cf2892fffcc"
+	UpdateID = dialect.UpdateID
 
 	WhereAvailable     = templ.WhereAvailable
 	WhereDownloadBlock = templ.WhereDownloadBlock
 	WhereHidden        = templ.WhereHidden
+	WhereViolation     = templ.WhereViolation
 )
 
 // Empty is used as a blank value for search maps.
@@ -76,17 +84,55 @@ func (t Table) String() string {
 	return [...]string{"files", "groupnames", "netresources"}[t]
 }
 
-type Update update.Update
+// File is a typed record of the files table,
+// only the columns requested by the query are populated.
+type File = dialect.File
+
+// Select returns the file records matching the query mods.
+// The queries are built for the engine of the database connection,
+// so qm.Where clauses should use ? placeholders and unquoted column names.
+func Select(db *sql.DB, mods ...qm.QueryMod) ([]File, error) {
+	if db == nil {
+		return nil, ErrDB
+	}
+	return dialect.All(context.Background(), db, mods...)
+}
+
+// Count returns the number of file records matching the query mods.
+func Count(db *sql.DB, mods ...qm.QueryMod) (int, error) {
+	if db == nil {
+		return -1, ErrDB
+	}
+	i, err := dialect.Count(context.Background(), db, mods...)
+	if err != nil {
+		return -1, err
+	}
+	return int(i), nil
+}
 
 // Connect the database and handle any errors.
 // The DB connection must be closed after use.
+// The database engine is chosen using the DBEngine configuration.
 func Connect(cfg conf.Config) (*sql.DB, error) {
-	// In the future this could use either psql or msql.
+	pg, err := cfg.IsPostgres()
+	if err != nil {
+		return nil, err
+	}
+	if pg {
+		return psql.Connect(cfg)
+	}
 	return msql.Connect(cfg)
 }
 
 // ConnDebug will connect to the database and return any errors.
 func ConnDebug(cfg conf.Config) (string, error) {
+	pg, err := cfg.IsPostgres()
+	if err != nil {
+		return "", err
+	}
+	if pg {
+		return psql.ConnDebug(cfg)
+	}
 	return msql.ConnDebug(cfg)
 }
 
@@ -188,10 +234,18 @@ func DateTime(raw sql.RawBytes) (string, error) {
 	if err != nil {
 		return "?", err
 	}
-	if t.UTC().Format("01 2006") != time.Now().Format("01 2006") {
-		return color.Info.Sprint(t.UTC().Format("02 Jan 2006")), nil
+	return Timestamp(null.TimeFrom(t)), nil
+}
+
+// Timestamp colours and formats a nullable date and time.
+func Timestamp(t null.Time) string {
+	if !t.Valid {
+		return ""
+	}
+	if t.Time.UTC().Format("01 2006") != time.Now().Format("01 2006") {
+		return color.Info.Sprint(t.Time.UTC().Format("02 Jan 2006"))
 	}
-	return color.Info.Sprint(t.UTC().Format("02 Jan 15:04")), nil
+	return color.Info.Sprint(t.Time.UTC().Format("02 Jan 15:04"))
 }
 
 // Distinct returns a unique list of values from the table column.
@@ -202,25 +256,33 @@ func Distinct(db *sql.DB, value string) ([]string, error) {
 	if value == "" {
 		return nil, nil
 	}
-	rows, err := db.Query("SELECT DISTINCT ? FROM `files`", value)
+	vals, err := dialect.Distinct(context.Background(), db, value)
 	if err != nil {
-		return nil, fmt.Errorf("distinct query: %w", err)
+		return nil, fmt.Errorf("distinct: %w", err)
 	}
-	if rows.Err() != nil {
-		return nil, fmt.Errorf("distinct rows: %w", rows.Err())
-	}
-	defer rows.Close()
-	res := []string{}
-	dest := sql.NullString{}
-	for rows.Next() {
-		if err = rows.Scan(&dest); err != nil {
-			return nil, fmt.Errorf("distinct scan: %w", err)
-		}
-		res = append(res, strings.ToLower(dest.String))
+	res := make([]string, 0, len(vals))
+	for _, v := range vals {
+		res = append(res, strings.ToLower(v))
 	}
 	return res, nil
 }
 
+// Values returns the unique, non-empty values of the table column
+// that match the optional query mods.
+func Values(db *sql.DB, column string, mods ...qm.QueryMod) ([]string, error) {
+	if db == nil {
+		return nil, ErrDB
+	}
+	if column == "" {
+		return nil, nil
+	}
+	vals, err := dialect.Distinct(context.Background(), db, column, mods...)
+	if err != nil {
+		return nil, fmt.Errorf("values: %w", err)
+	}
+	return vals, nil
+}
+
 // FileUpdate returns true when named file is newer than the database time.
 // True is always returned when the named file does not exist or
 // whenever it is 0 bytes in size.
@@ -241,43 +303,48 @@ func FileUpdate(name string, db time.Time) (bool, error) {
 	return f.ModTime().UTC().After(db.UTC()), nil
 }
 
-// Execute Query and Args to update the database and returns the total number of changes.
-func Execute(db *sql.DB, u Update) (int64, error) {
-	if db == nil {
-		return 0, ErrDB
-	}
-	return update.Update(u).Execute(db)
-}
-
 // Fix any malformed section and platforms found in the database.
-func Fix(db *sql.DB, w io.Writer) error {
+// The fixes are made within a transaction, when dry is true the changes
+// are printed to w and then rolled back.
+func Fix(db *sql.DB, w io.Writer, dry bool) error {
 	if db == nil {
 		return ErrDB
 	}
 	if w == nil {
 		w = io.Discard
 	}
-	if err := update.Filename.NamedTitles(db, w); err != nil {
+	tx, err := Begin(db, w, dry)
+	if err != nil {
+		return fmt.Errorf("fix: %w", err)
+	}
+	if err := fix(tx, w); err != nil {
+		return tx.Cancel(err)
+	}
+	return tx.End()
+}
+
+func fix(tx *Tx, w io.Writer) error {
+	if err := update.Filename.NamedTitles(tx, w); err != nil {
 		return fmt.Errorf("update filenames: %w", err)
 	}
-	if err := update.GroupFor.NamedTitles(db, w); err != nil {
+	if err := update.GroupFor.NamedTitles(tx, w); err != nil {
 		return fmt.Errorf("update groups for: %w", err)
 	}
-	if err := update.GroupBy.NamedTitles(db, w); err != nil {
+	if err := update.GroupBy.NamedTitles(tx, w); err != nil {
 		return fmt.Errorf("update groups by: %w", err)
 	}
-	dist, err := update.Distinct(db, "section")
+	dist, err := update.Distinct(tx, "section")
 	if err != nil {
 		return fmt.Errorf("fix distinct section: %w", err)
 	}
-	if err := update.Sections(db, w, &dist); err != nil {
+	if err := update.Sections(tx, w, &dist); err != nil {
 		return fmt.Errorf("update sections: %w", err)
 	}
-	dist, err = update.Distinct(db, "platform")
+	dist, err = update.Distinct(tx, "platform")
 	if err != nil {
 		return fmt.Errorf("fix distinct platform: %w", err)
 	}
-	if err = update.Platforms(db, w, &dist); err != nil {
+	if err = update.Platforms(tx, w, &dist); err != nil {
 		return fmt.Errorf("update platforms: %w", err)
 	}
 	return nil
@@ -340,7 +407,7 @@ func GetKeys(db *sql.DB, stmt string) ([]int, error) {
 	}
 	count := 0
 	if err := db.QueryRow(query).Scan(&count); err != nil {
-		return nil, err
+		return nil, dialect.Migrate(err)
 	}
 	queryKeys := templ.SelKeys
 	if stmt != "" {
@@ -348,7 +415,7 @@ func GetKeys(db *sql.DB, stmt string) ([]int, error) {
 	}
 	rows, err := db.Query(queryKeys)
 	if err != nil {
-		return nil, fmt.Errorf("get keys query: %w", err)
+		return nil, fmt.Errorf("get keys query: %w", dialect.Migrate(err))
 	}
 	if rows.Err() != nil {
 		return nil, fmt.Errorf("get keys rows: %w", rows.Err())
@@ -393,8 +460,13 @@ func GetFile(db *sql.DB, val string) (string, error) {
 }
 
 // IsDemozoo reports if a fetched demozoo file record is set to unapproved.
-func IsDemozoo(b []sql.RawBytes) (bool, error) {
-	return recd.IsDemozoo(b)
+func IsDemozoo(f File) bool {
+	return recd.IsDemozoo(f)
+}
+
+// IsPostgres reports whether the database connection uses the Postgres engine.
+func IsPostgres(db *sql.DB) bool {
+	return dialect.Use(db) == dialect.Postgres
 }
 
 // IsID reports whether string is an auto-generated record id.
@@ -412,20 +484,9 @@ func IsID(s string) bool {
 }
 
 // IsUnApproved reports if a fetched file record is set to unapproved.
-func IsUnApproved(b []sql.RawBytes) (bool, error) {
-	// SQL column names can be found in the sqlSelect() func in proof.go
-	const deletedat, updatedat = 2, 6
-	if len(b) <= updatedat {
-		return false, nil
-	}
-	if b[deletedat] == nil || b[updatedat] == nil {
-		return false, nil
-	}
-	n, err := recd.Valid(b[deletedat], b[updatedat])
-	if err != nil {
-		return false, err
-	}
-	return n, nil
+// The record requires both the deletedat and updatedat columns.
+func IsUnApproved(f File) bool {
+	return recd.Near(f.Deletedat, f.Updatedat)
 }
 
 // IsUUID reports whether string is a universal unique record id.
@@ -2,7 +2,6 @@ package database_test
 
 import (
 	"context"
-	"database/sql"
 	"io"
 	"strings"
 	"testing"
@@ -34,6 +33,10 @@ func TestConnect(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Nil(t, db)
 
+	db, err = database.Connect(conf.Config{DBEngine: "sqlite"})
+	assert.ErrorIs(t, err, conf.ErrEngine)
+	assert.Nil(t, db)
+
 	db, err = database.Connect(conf.Defaults())
 	assert.Nil(t, err)
 	assert.NotNil(t, db)
@@ -184,13 +187,15 @@ func TestFileUpdate(t *testing.T) {
 
 func TestFix(t *testing.T) {
 	t.Parallel()
-	err := database.Fix(nil, nil)
+	err := database.Fix(nil, nil, false)
 	assert.NotNil(t, err)
 
 	db, err := database.Connect(conf.Defaults())
 	assert.Nil(t, err)
 	defer db.Close()
-	err = database.Fix(db, io.Discard)
+	err = database.Fix(db, io.Discard, false)
+	assert.Nil(t, err)
+	err = database.Fix(db, io.Discard, true)
 	assert.Nil(t, err)
 }
 
@@ -308,25 +313,17 @@ func TestIsID(t *testing.T) {
 
 func TestIsUnApproved(t *testing.T) {
 	t.Parallel()
-	b, err := database.IsUnApproved(nil)
-	assert.Nil(t, err)
-	assert.False(t, b)
+	assert.False(t, database.IsUnApproved(database.File{}))
 
-	raw := make([]sql.RawBytes, 7)
-	b, err = database.IsUnApproved(raw)
-	assert.Nil(t, err)
-	assert.False(t, b)
+	now := time.Now()
+	f := database.File{Deletedat: null.TimeFrom(now)}
+	assert.False(t, database.IsUnApproved(f))
 
-	now := time.Now().Format(time.RFC3339)
-	raw[2] = []byte(now)
-	b, err = database.IsUnApproved(raw)
-	assert.Nil(t, err)
-	assert.False(t, b)
+	f.Updatedat = null.TimeFrom(now.Add(time.Second))
+	assert.True(t, database.IsUnApproved(f))
 
-	raw[6] = []byte(now)
-	b, err = database.IsUnApproved(raw)
-	assert.Nil(t, err)
-	assert.True(t, b)
+	f.Updatedat = null.TimeFrom(now.Add(time.Hour))
+	assert.False(t, database.IsUnApproved(f))
 }
 
 func TestIsUUID(t *testing.T) {
@@ -477,3 +474,23 @@ func TestDeObfuscate(t *testing.T) {
 		})
 	}
 }
+
+func TestMigrateNilDB(t *testing.T) {
+	t.Parallel()
+	err := database.MigrateUp(nil, nil, 0)
+	assert.ErrorIs(t, err, database.ErrDB)
+	err = database.MigrateDown(nil, nil, 0)
+	assert.ErrorIs(t, err, database.ErrDB)
+	err = database.MigrateStatus(nil, nil)
+	assert.ErrorIs(t, err, database.ErrDB)
+}
+
+func TestAuditNilDB(t *testing.T) {
+	t.Parallel()
+	err := database.History(nil, nil, "1")
+	assert.ErrorIs(t, err, database.ErrDB)
+	err = database.Revert(nil, nil, 1)
+	assert.ErrorIs(t, err, database.ErrDB)
+	err = database.Undo(nil, nil, false, 1)
+	assert.ErrorIs(t, err, database.ErrDB)
+}
@@ -0,0 +1,191 @@
+// Package audit reads and reverts the record changes saved to the audit log.
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Defacto2/df2/pkg/database/internal/dialect"
+	"github.com/gookit/color"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+var (
+	ErrChange   = errors.New("change does not exist in the audit log")
+	ErrConflict = errors.New("the value was edited after the change")
+	ErrDB       = errors.New("database handle pointer cannot be nil")
+	ErrDeleted  = errors.New("a deleted row cannot be reverted")
+	ErrInserted = errors.New("an inserted row cannot be reverted")
+	ErrName     = errors.New("audit log contains an invalid table or column name")
+)
+
+// Entry is a change saved to the audit log.
+type Entry struct {
+	ID        int64          // ID of the change.
+	Command   string         // Command is the df2 command that made the change.
+	Action    string         // Action is either update or delete.
+	Table     string         // Table of the changed record.
+	RecordID  int64          // RecordID is the id of the changed record.
+	Column    string         // Column of the changed value.
+	Old       sql.NullString // Old is the value prior to the change.
+	New       sql.NullString // New is the value after the change.
+	ChangedAt time.Time      // ChangedAt is the time of the change.
+}
+
+func columns() []string {
+	return []string{
+		"id", "command", "action", "table_name", "record_id",
+		"column_name", "old_value", "new_value", "changedat",
+	}
+}
+
+// History returns the changes of the table record, in order of the change.
+func History(ctx context.Context, db *sql.DB, table string, id int64) ([]Entry, error) {
+	if db == nil {
+		return nil, ErrDB
+	}
+	return entries(ctx, db,
+		qm.Where("table_name = ?", table),
+		qm.And("record_id = ?", id),
+		qm.OrderBy("id"))
+}
+
+// Get returns the change from the audit log.
+func Get(ctx context.Context, db dialect.Executor, change int64) (Entry, error) {
+	if db == nil {
+		return Entry{}, ErrDB
+	}
+	es, err := entries(ctx, db, qm.Where("id = ?", change))
+	if err != nil {
+		return Entry{}, err
+	}
+	if len(es) == 0 {
+		return Entry{}, fmt.Errorf("%w: %d", ErrChange, change)
+	}
+	return es[0], nil
+}
+
+func entries(ctx context.Context, db dialect.Executor, mods ...qm.QueryMod) ([]Entry, error) {
+	mods = append([]qm.QueryMod{qm.Select(columns()...), qm.From(dialect.AuditLog)}, mods...)
+	rows, err := dialect.New(db, mods...).QueryContext(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("audit log query: %w", err)
+	}
+	defer rows.Close()
+	es := []Entry{}
+	for rows.Next() {
+		e := Entry{}
+		if err := rows.Scan(&e.ID, &e.Command, &e.Action, &e.Table, &e.RecordID,
+			&e.Column, &e.Old, &e.New, &e.ChangedAt); err != nil {
+			return nil, fmt.Errorf("audit log scan: %w", err)
+		}
+		es = append(es, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("audit log rows: %w", err)
+	}
+	return es, nil
+}
+
+// Print the changes as a table to the writer.
+func Print(w io.Writer, es ...Entry) error {
+	if w == nil {
+		w = io.Discard
+	}
+	const padding = 2
+	buf := strings.Builder{}
+	tw := tabwriter.NewWriter(&buf, 0, 0, padding, ' ', 0)
+	fmt.Fprintln(tw, "Change\tDate\tCommand\tColumn\tValue\t")
+	for _, e := range es {
+		val := fmt.Sprintf("%s %s %s", value(e.Old), color.Question.Sprint("⟫"), color.Info.Sprint(value(e.New)))
+		switch e.Action {
+		case dialect.ActDelete:
+			val = fmt.Sprintf("%s %s", value(e.Old), color.Danger.Sprint("deleted"))
+		case dialect.ActInsert:
+			val = fmt.Sprintf("%s %s", color.Info.Sprint(value(e.New)), color.Question.Sprint("inserted"))
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t\n", e.ID, e.ChangedAt.Format("2006 Jan 2, 15:04"),
+			e.Command, e.Column, val)
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("print flush tab writer: %w", err)
+	}
+	fmt.Fprint(w, buf.String())
+	return nil
+}
+
+func value(s sql.NullString) string {
+	if !s.Valid {
+		return "NULL"
+	}
+	const max = 50
+	v := s.String
+	if r := []rune(v); len(r) > max {
+		v = string(r[:max]) + "…"
+	}
+	return fmt.Sprintf("%q", v)
+}
+
+// Revert the change by restoring the old value of the column.
+// The revert is refused when the column has been edited since the change,
+// and the revert itself is saved to the audit log as a new change.
+func Revert(ctx context.Context, db *sql.DB, w io.Writer, change int64) (Entry, error) {
+	if db == nil {
+		return Entry{}, ErrDB
+	}
+	if w == nil {
+		w = io.Discard
+	}
+	tx, err := dialect.Begin(ctx, db, w, false)
+	if err != nil {
+		return Entry{}, err
+	}
+	e, err := revert(ctx, tx, change)
+	if err != nil {
+		return e, tx.Cancel(err)
+	}
+	return e, tx.End()
+}
+
+var name = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+func revert(ctx context.Context, tx *dialect.Tx, change int64) (Entry, error) {
+	e, err := Get(ctx, tx, change)
+	if err != nil {
+		return Entry{}, err
+	}
+	switch e.Action {
+	case dialect.ActDelete:
+		return e, fmt.Errorf("%w: change %d", ErrDeleted, e.ID)
+	case dialect.ActInsert:
+		return e, fmt.Errorf("%w: change %d", ErrInserted, e.ID)
+	}
+	if !name.MatchString(e.Table) || !name.MatchString(e.Column) {
+		return e, fmt.Errorf("%w: %s.%s", ErrName, e.Table, e.Column)
+	}
+	q := dialect.New(tx, qm.Select(e.Column), qm.From(e.Table), qm.Where("id = ?", e.RecordID))
+	var v any
+	if err := q.QueryRowContext(ctx, tx).Scan(&v); err != nil {
+		return e, fmt.Errorf("revert current value: %w", err)
+	}
+	if now := dialect.String(v); now != e.New {
+		return e, fmt.Errorf("%w, %s id %d %s is now %s",
+			ErrConflict, e.Table, e.RecordID, e.Column, value(now))
+	}
+	var old any
+	if e.Old.Valid {
+		old = e.Old.String
+	}
+	if _, err := dialect.UpdateTable(ctx, tx, e.Table, map[string]any{e.Column: old},
+		qm.Where("id = ?", e.RecordID)); err != nil {
+		return e, fmt.Errorf("revert: %w", err)
+	}
+	return e, nil
+}
@@ -0,0 +1,175 @@
+package audit_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Defacto2/df2/pkg/database/internal/audit"
+	"github.com/Defacto2/df2/pkg/database/internal/dialect"
+	"github.com/stretchr/testify/assert"
+)
+
+// fake is a minimal database driver that returns queued rows to queries.
+type fake struct {
+	rows  []*rows
+	stmts []string
+}
+
+func (f *fake) Open(string) (driver.Conn, error) { return &conn{f}, nil }
+
+type conn struct{ f *fake }
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) { return &stmt{c.f, query}, nil }
+func (c *conn) Close() error                              { return nil }
+func (c *conn) Begin() (driver.Tx, error)                 { return tx{}, nil } //nolint:staticcheck
+
+type tx struct{}
+
+func (tx) Commit() error   { return nil }
+func (tx) Rollback() error { return nil }
+
+type stmt struct {
+	f     *fake
+	query string
+}
+
+func (s *stmt) Close() error  { return nil }
+func (s *stmt) NumInput() int { return -1 }
+
+func (s *stmt) Exec([]driver.Value) (driver.Result, error) {
+	s.f.stmts = append(s.f.stmts, s.query)
+	return result{}, nil
+}
+
+// result reports one affected row and an insert id of 1.
+type result struct{}
+
+func (result) LastInsertId() (int64, error) { return 1, nil }
+func (result) RowsAffected() (int64, error) { return 1, nil }
+
+func (s *stmt) Query([]driver.Value) (driver.Rows, error) {
+	s.f.stmts = append(s.f.stmts, s.query)
+	if len(s.f.rows) == 0 {
+		return &rows{cols: []string{"id"}}, nil
+	}
+	r := s.f.rows[0]
+	s.f.rows = s.f.rows[1:]
+	return r, nil
+}
+
+type rows struct {
+	cols []string
+	vals [][]driver.Value
+}
+
+func (r *rows) Columns() []string { return r.cols }
+func (r *rows) Close() error      { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if len(r.vals) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.vals[0])
+	r.vals = r.vals[1:]
+	return nil
+}
+
+func open(t *testing.T, name string, f *fake) *sql.DB {
+	t.Helper()
+	sql.Register(name, f)
+	db, err := sql.Open(name, "")
+	assert.Nil(t, err)
+	return db
+}
+
+func entry(action string) *rows {
+	return &rows{
+		cols: []string{
+			"id", "command", "action", "table_name", "record_id",
+			"column_name", "old_value", "new_value", "changedat",
+		},
+		vals: [][]driver.Value{{
+			int64(7), "df2 fix database", action, "files", int64(1),
+			"platform", "DOS", "dos", time.Now(),
+		}},
+	}
+}
+
+func TestNilDB(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	_, err := audit.History(ctx, nil, "files", 1)
+	assert.ErrorIs(t, err, audit.ErrDB)
+	_, err = audit.Get(ctx, nil, 1)
+	assert.ErrorIs(t, err, audit.ErrDB)
+	_, err = audit.Revert(ctx, nil, nil, 1)
+	assert.ErrorIs(t, err, audit.ErrDB)
+}
+
+func TestGet(t *testing.T) {
+	t.Parallel()
+	db := open(t, "fake-audit-get", &fake{rows: []*rows{entry(dialect.ActUpdate)}})
+	defer db.Close()
+	e, err := audit.Get(context.Background(), db, 7)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), e.ID)
+	assert.Equal(t, "platform", e.Column)
+	assert.Equal(t, "DOS", e.Old.String)
+	_, err = audit.Get(context.Background(), db, 8)
+	assert.ErrorIs(t, err, audit.ErrChange)
+}
+
+func TestPrint(t *testing.T) {
+	t.Parallel()
+	b := strings.Builder{}
+	err := audit.Print(&b, audit.Entry{
+		ID: 7, Command: "df2 fix database", Action: dialect.ActUpdate, Column: "platform",
+		Old: sql.NullString{String: "DOS", Valid: true},
+	})
+	assert.Nil(t, err)
+	assert.Contains(t, b.String(), "df2 fix database")
+	assert.Contains(t, b.String(), "NULL")
+}
+
+func TestRevert(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	f := &fake{rows: []*rows{entry(dialect.ActDelete)}}
+	db := open(t, "fake-audit-deleted", f)
+	defer db.Close()
+	_, err := audit.Revert(ctx, db, nil, 7)
+	assert.ErrorIs(t, err, audit.ErrDeleted)
+
+	f = &fake{rows: []*rows{entry(dialect.ActInsert)}}
+	db = open(t, "fake-audit-inserted", f)
+	defer db.Close()
+	_, err = audit.Revert(ctx, db, nil, 7)
+	assert.ErrorIs(t, err, audit.ErrInserted)
+
+	f = &fake{rows: []*rows{
+		entry(dialect.ActUpdate),
+		{cols: []string{"platform"}, vals: [][]driver.Value{{"text"}}},
+	}}
+	db = open(t, "fake-audit-conflict", f)
+	defer db.Close()
+	_, err = audit.Revert(ctx, db, nil, 7)
+	assert.ErrorIs(t, err, audit.ErrConflict)
+
+	f = &fake{rows: []*rows{
+		entry(dialect.ActUpdate),
+		{cols: []string{"platform"}, vals: [][]driver.Value{{"dos"}}},
+		{cols: []string{"id", "platform"}, vals: [][]driver.Value{{int64(1), "dos"}}},
+		{cols: []string{"id", "platform"}, vals: [][]driver.Value{{int64(1), "DOS"}}},
+	}}
+	db = open(t, "fake-audit-revert", f)
+	defer db.Close()
+	e, err := audit.Revert(ctx, db, nil, 7)
+	assert.Nil(t, err)
+	assert.Equal(t, "DOS", e.Old.String)
+	assert.Contains(t, f.stmts[len(f.stmts)-1], "INSERT INTO "+dialect.AuditLog)
+}
@@ -0,0 +1,220 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Defacto2/df2/pkg/database/internal/dialect"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+var ErrChangeset = errors.New("changeset does not exist")
+
+// Changeset is a named group of changes in the audit log that can be undone together.
+type Changeset struct {
+	ID        int64     // ID of the changeset.
+	Command   string    // Command is the df2 command that made the changes.
+	Label     string    // Label describes the changes.
+	CreatedAt time.Time // CreatedAt is the time of the changes.
+}
+
+// Conflict is a change of a changeset that could not be undone.
+type Conflict struct {
+	Entry
+	Now     sql.NullString // Now is the current value of the column.
+	Missing bool           // Missing is true when the record no longer exists.
+}
+
+func (c Conflict) String() string {
+	switch {
+	case c.Action == dialect.ActDelete:
+		return fmt.Sprintf("%s id %d was deleted and cannot be restored", c.Table, c.RecordID)
+	case c.Action == dialect.ActInsert:
+		return fmt.Sprintf("%s id %d was inserted and cannot be removed", c.Table, c.RecordID)
+	case c.Missing:
+		return fmt.Sprintf("%s id %d no longer exists", c.Table, c.RecordID)
+	}
+	return fmt.Sprintf("%s id %d %s was edited to %s, expected %s",
+		c.Table, c.RecordID, c.Column, value(c.Now), value(c.New))
+}
+
+// Undo restores the values of the records changed by the changeset.
+// Only the values that have not been edited since the changeset are restored,
+// the others are returned as conflicts.
+// The undo is saved to the audit log as a new changeset, unless dry is true.
+func Undo(ctx context.Context, db *sql.DB, w io.Writer, dry bool, id int64) (Changeset, []Conflict, error) {
+	if db == nil {
+		return Changeset{}, nil, ErrDB
+	}
+	if w == nil {
+		w = io.Discard
+	}
+	tx, err := dialect.Begin(ctx, db, w, dry)
+	if err != nil {
+		return Changeset{}, nil, err
+	}
+	cs, conflicts, err := undo(ctx, tx, id)
+	if err != nil {
+		return cs, nil, tx.Cancel(err)
+	}
+	tx.Label = fmt.Sprintf("undo changeset %d, %s", cs.ID, cs.Label)
+	return cs, conflicts, tx.End()
+}
+
+// GetChangeset returns the changeset.
+func GetChangeset(ctx context.Context, db dialect.Executor, id int64) (Changeset, error) {
+	if db == nil {
+		return Changeset{}, ErrDB
+	}
+	q := dialect.New(db, qm.Select("id", "command", "label", "createdat"),
+		qm.From(dialect.Changesets), qm.Where("id = ?", id))
+	cs := Changeset{}
+	err := q.QueryRowContext(ctx, db).Scan(&cs.ID, &cs.Command, &cs.Label, &cs.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Changeset{}, fmt.Errorf("%w: %d", ErrChangeset, id)
+	}
+	if err != nil {
+		return Changeset{}, fmt.Errorf("changeset query: %w", err)
+	}
+	return cs, nil
+}
+
+// cell is a column value of a record.
+type cell struct {
+	table  string
+	id     int64
+	column string
+}
+
+// restore is a column value to change from the new value back to the old value.
+type restore struct {
+	table  string
+	column string
+	old    sql.NullString
+	new    sql.NullString
+}
+
+func undo(ctx context.Context, tx *dialect.Tx, id int64) (Changeset, []Conflict, error) {
+	cs, err := GetChangeset(ctx, tx, id)
+	if err != nil {
+		return Changeset{}, nil, err
+	}
+	es, err := entries(ctx, tx, qm.Where("changeset = ?", id), qm.OrderBy("id DESC"))
+	if err != nil {
+		return cs, nil, err
+	}
+	// a cell changed more than once is restored to its earliest value,
+	// so long as it still holds its latest value
+	conflicts := []Conflict{}
+	cells := map[cell]Entry{}
+	order := []cell{}
+	for _, e := range es {
+		if e.Action == dialect.ActDelete || e.Action == dialect.ActInsert {
+			conflicts = append(conflicts, Conflict{Entry: e})
+			continue
+		}
+		if !name.MatchString(e.Table) || !name.MatchString(e.Column) {
+			return cs, nil, fmt.Errorf("%w: %s.%s", ErrName, e.Table, e.Column)
+		}
+		c := cell{table: e.Table, id: e.RecordID, column: e.Column}
+		latest, ok := cells[c]
+		if !ok {
+			cells[c] = e
+			order = append(order, c)
+			continue
+		}
+		latest.Old = e.Old
+		cells[c] = latest
+	}
+	groups := map[restore][]Entry{}
+	keys := []restore{}
+	for _, c := range order {
+		e := cells[c]
+		r := restore{table: e.Table, column: e.Column, old: e.Old, new: e.New}
+		if _, ok := groups[r]; !ok {
+			keys = append(keys, r)
+		}
+		groups[r] = append(groups[r], e)
+	}
+	for _, r := range keys {
+		c, err := r.apply(ctx, tx, groups[r])
+		if err != nil {
+			return cs, nil, err
+		}
+		conflicts = append(conflicts, c...)
+	}
+	return cs, conflicts, nil
+}
+
+// apply restores the old value to the records that still hold the new value.
+func (r restore) apply(ctx context.Context, tx *dialect.Tx, es []Entry) ([]Conflict, error) {
+	const chunk = 500
+	conflicts := []Conflict{}
+	for i := 0; i < len(es); i += chunk {
+		j := i + chunk
+		if j > len(es) {
+			j = len(es)
+		}
+		ids := make([]any, 0, j-i)
+		for _, e := range es[i:j] {
+			ids = append(ids, e.RecordID)
+		}
+		now, err := current(ctx, tx, r.table, r.column, ids...)
+		if err != nil {
+			return nil, err
+		}
+		ok := make([]any, 0, len(ids))
+		for _, e := range es[i:j] {
+			val, exists := now[e.RecordID]
+			switch {
+			case !exists:
+				conflicts = append(conflicts, Conflict{Entry: e, Missing: true})
+			case val != r.new:
+				conflicts = append(conflicts, Conflict{Entry: e, Now: val})
+			default:
+				ok = append(ok, e.RecordID)
+			}
+		}
+		if len(ok) == 0 {
+			continue
+		}
+		var old any
+		if r.old.Valid {
+			old = r.old.String
+		}
+		if _, err := dialect.UpdateTable(ctx, tx, r.table, map[string]any{r.column: old},
+			qm.WhereIn("id IN ?", ok...)); err != nil {
+			return nil, fmt.Errorf("undo: %w", err)
+		}
+	}
+	return conflicts, nil
+}
+
+// current returns the column values of the table records keyed by id.
+func current(ctx context.Context, tx *dialect.Tx, table, column string, ids ...any) (
+	map[int64]sql.NullString, error,
+) {
+	q := dialect.New(tx, qm.Select("id", column), qm.From(table), qm.WhereIn("id IN ?", ids...))
+	rows, err := q.QueryContext(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("undo current values: %w", err)
+	}
+	defer rows.Close()
+	vals := make(map[int64]sql.NullString, len(ids))
+	for rows.Next() {
+		var id int64
+		var v any
+		if err := rows.Scan(&id, &v); err != nil {
+			return nil, fmt.Errorf("undo current values scan: %w", err)
+		}
+		vals[id] = dialect.String(v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("undo current values rows: %w", err)
+	}
+	return vals, nil
+}
@@ -0,0 +1,71 @@
+package audit_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Defacto2/df2/pkg/database/internal/audit"
+	"github.com/Defacto2/df2/pkg/database/internal/dialect"
+	"github.com/stretchr/testify/assert"
+)
+
+func changes() *rows {
+	r := entry(dialect.ActUpdate)
+	r.vals = [][]driver.Value{
+		{int64(12), "df2 fix rename", "update", "files", int64(2), "group_brand_for", "A", "B", time.Now()},
+		{int64(11), "df2 fix rename", "update", "files", int64(1), "group_brand_for", "A", "B", time.Now()},
+	}
+	return r
+}
+
+func TestConflict_String(t *testing.T) {
+	t.Parallel()
+	c := audit.Conflict{Entry: audit.Entry{Table: "files", RecordID: 1, Column: "group_brand_for",
+		New: sql.NullString{String: "B", Valid: true}}}
+	assert.Contains(t, c.String(), "expected \"B\"")
+	c.Missing = true
+	assert.Contains(t, c.String(), "no longer exists")
+	c.Action = dialect.ActDelete
+	assert.Contains(t, c.String(), "deleted")
+	c.Action = dialect.ActInsert
+	assert.Contains(t, c.String(), "inserted")
+}
+
+func TestUndo(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	_, _, err := audit.Undo(ctx, nil, nil, false, 1)
+	assert.ErrorIs(t, err, audit.ErrDB)
+
+	db := open(t, "fake-undo-missing", &fake{})
+	defer db.Close()
+	_, _, err = audit.Undo(ctx, db, nil, false, 1)
+	assert.ErrorIs(t, err, audit.ErrChangeset)
+
+	f := &fake{rows: []*rows{
+		{
+			cols: []string{"id", "command", "label", "createdat"},
+			vals: [][]driver.Value{{int64(3), "df2 fix rename", "rename group A to B", time.Now()}},
+		},
+		changes(),
+		{cols: []string{"id", "group_brand_for"}, vals: [][]driver.Value{{int64(2), "C"}, {int64(1), "B"}}},
+		{cols: []string{"id", "group_brand_for"}, vals: [][]driver.Value{{int64(1), "B"}}},
+		{cols: []string{"id", "group_brand_for"}, vals: [][]driver.Value{{int64(1), "A"}}},
+	}}
+	db = open(t, "fake-undo", f)
+	defer db.Close()
+	b := strings.Builder{}
+	cs, conflicts, err := audit.Undo(ctx, db, &b, false, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), cs.ID)
+	assert.Len(t, conflicts, 1)
+	assert.Equal(t, int64(2), conflicts[0].RecordID)
+	assert.Equal(t, "C", conflicts[0].Now.String)
+	assert.Contains(t, f.stmts[4], "UPDATE `files` SET `group_brand_for` = ?")
+	assert.Contains(t, f.stmts[len(f.stmts)-2], "INSERT INTO "+dialect.Changesets)
+	assert.Contains(t, b.String(), "changeset 1")
+}
@@ -0,0 +1,161 @@
+// Package dialect builds SQL queries that run on both the MySQL and
+// the Postgres database engines.
+// Queries are created with the sqlboiler query mods, the SQL dialect is
+// chosen using the driver of the database connection and the results are
+// bound to the typed File struct.
+package dialect
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Defacto2/df2/pkg/database/psql"
+	mysql "github.com/Defacto2/df2/pkg/models/mysql"
+	pgsql "github.com/Defacto2/df2/pkg/models/psql"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/queries"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+var (
+	ErrDB     = errors.New("database handle pointer cannot be nil")
+	ErrID     = errors.New("table has no id column")
+	ErrNoCols = errors.New("no column values to insert")
+	// ErrMigrate is returned when the tables or columns added by the df2 migrations are missing from the database.
+	ErrMigrate = errors.New("the database tables are missing or out of date, run df2 db migrate up")
+)
+
+// Engine is the SQL database engine.
+type Engine int
+
+const (
+	MySQL    Engine = iota // MySQL or MariaDB database.
+	Postgres               // Postgres database.
+)
+
+func (e Engine) String() string {
+	if e > Postgres {
+		return ""
+	}
+	return [...]string{"mysql", "postgres"}[e]
+}
+
+// Use returns the Engine used by the database connection.
+func Use(db *sql.DB) Engine {
+	if db == nil {
+		return MySQL
+	}
+	if _, ok := db.Driver().(*psql.Driver); ok {
+		return Postgres
+	}
+	return MySQL
+}
+
+// Files is the files table.
+const Files = "files"
+
+// File is a typed record of the files table.
+// Only the columns requested by the query are populated.
+type File struct {
+	ID                  int64       `boil:"id"`
+	UUID                null.String `boil:"uuid"`
+	Createdat           null.Time   `boil:"createdat"`
+	Updatedat           null.Time   `boil:"updatedat"`
+	Deletedat           null.Time   `boil:"deletedat"`
+	Filename            null.String `boil:"filename"`
+	Filesize            null.Int64  `boil:"filesize"`
+	WebIDDemozoo        null.Int64  `boil:"web_id_demozoo"`
+	WebIDPouet          null.Int64  `boil:"web_id_pouet"`
+	FileZipContent      null.String `boil:"file_zip_content"`
+	Platform            null.String `boil:"platform"`
+	Section             null.String `boil:"section"`
+	FileIntegrityStrong null.String `boil:"file_integrity_strong"`
+	FileIntegrityWeak   null.String `boil:"file_integrity_weak"`
+	GroupBrandFor       null.String `boil:"group_brand_for"`
+	GroupBrandBy        null.String `boil:"group_brand_by"`
+	RecordTitle         null.String `boil:"record_title"`
+	DateIssuedYear      null.Int16  `boil:"date_issued_year"`
+	DateIssuedMonth     null.Int16  `boil:"date_issued_month"`
+	DateIssuedDay       null.Int16  `boil:"date_issued_day"`
+	CreditIllustration  null.String `boil:"credit_illustration"`
+	CreditAudio         null.String `boil:"credit_audio"`
+	CreditProgram       null.String `boil:"credit_program"`
+	CreditText          null.String `boil:"credit_text"`
+	RetrotxtEncoding    null.String `boil:"retrotxt_encoding"`
+	RetrotxtReadme      null.String `boil:"retrotxt_readme"`
+}
+
+// New initializes a new query for the database connection or transaction using the query mods.
+func New(exec Executor, mods ...qm.QueryMod) *queries.Query {
+	if engine(exec) == Postgres {
+		return pgsql.NewQuery(mods...)
+	}
+	return mysql.NewQuery(mods...)
+}
+
+// All returns the file records matching the query mods.
+// A qm.Select query mod should be used to limit the columns returned.
+func All(ctx context.Context, db *sql.DB, mods ...qm.QueryMod) ([]File, error) {
+	if db == nil {
+		return nil, ErrDB
+	}
+	mods = append([]qm.QueryMod{qm.From(Files)}, mods...)
+	var files []File
+	if err := New(db, mods...).Bind(ctx, db, &files); err != nil {
+		return nil, fmt.Errorf("dialect all: %w", err)
+	}
+	return files, nil
+}
+
+// Count returns the number of file records matching the query mods.
+func Count(ctx context.Context, db *sql.DB, mods ...qm.QueryMod) (int64, error) {
+	if db == nil {
+		return 0, ErrDB
+	}
+	mods = append([]qm.QueryMod{qm.From(Files)}, mods...)
+	q := New(db, mods...)
+	queries.SetCount(q)
+	var count int64
+	if err := q.QueryRowContext(ctx, db).Scan(&count); err != nil {
+		return 0, fmt.Errorf("dialect count: %w", err)
+	}
+	return count, nil
+}
+
+// Distinct returns the unique, non-empty values of the column in the files table.
+func Distinct(ctx context.Context, db Executor, column string, mods ...qm.QueryMod) ([]string, error) {
+	if isNil(db) {
+		return nil, ErrDB
+	}
+	mods = append([]qm.QueryMod{
+		qm.Distinct(column),
+		qm.From(Files),
+		qm.Where(column + " IS NOT NULL"),
+		qm.And(column + " <> ''"),
+	}, mods...)
+	rows, err := New(db, mods...).QueryContext(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("dialect distinct %q: %w", column, err)
+	}
+	defer rows.Close()
+	values := []string{}
+	s := ""
+	for rows.Next() {
+		if err := rows.Scan(&s); err != nil {
+			return nil, fmt.Errorf("dialect distinct scan %q: %w", column, err)
+		}
+		values = append(values, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("dialect distinct rows %q: %w", column, err)
+	}
+	return values, nil
+}
+
+// Update sets the columns of the file records matching the query mods.
+// The number of rows affected is returned.
+func Update(ctx context.Context, exec Executor, cols map[string]any, mods ...qm.QueryMod) (int64, error) {
+	return UpdateTable(ctx, exec, Files, cols, mods...)
+}
@@ -0,0 +1,61 @@
+package dialect_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/Defacto2/df2/pkg/database/internal/dialect"
+	"github.com/Defacto2/df2/pkg/database/psql"
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/sqlboiler/v4/queries"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+func TestEngine_String(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "mysql", dialect.MySQL.String())
+	assert.Equal(t, "postgres", dialect.Postgres.String())
+	assert.Equal(t, "", dialect.Engine(99).String())
+}
+
+func TestUse(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, dialect.MySQL, dialect.Use(nil))
+	db, err := sql.Open(psql.DriverName, psql.Connection{}.String())
+	assert.Nil(t, err)
+	defer db.Close()
+	assert.Equal(t, dialect.Postgres, dialect.Use(db))
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	mods := []qm.QueryMod{
+		qm.Select("id", "uuid"),
+		qm.From(dialect.Files),
+		qm.Where("uuid = ?", "x"),
+	}
+	s, args := queries.BuildQuery(dialect.New(nil, mods...))
+	assert.Equal(t, "SELECT `id`, `uuid` FROM `files` WHERE (uuid = ?);", s)
+	assert.Len(t, args, 1)
+
+	db, err := sql.Open(psql.DriverName, psql.Connection{}.String())
+	assert.Nil(t, err)
+	defer db.Close()
+	s, args = queries.BuildQuery(dialect.New(db, mods...))
+	assert.Equal(t, `SELECT "id", "uuid" FROM "files" WHERE (uuid = $1);`, s)
+	assert.Len(t, args, 1)
+}
+
+func TestNilDB(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	_, err := dialect.All(ctx, nil)
+	assert.ErrorIs(t, err, dialect.ErrDB)
+	_, err = dialect.Count(ctx, nil)
+	assert.ErrorIs(t, err, dialect.ErrDB)
+	_, err = dialect.Distinct(ctx, nil, "section")
+	assert.ErrorIs(t, err, dialect.ErrDB)
+	_, err = dialect.Update(ctx, nil, nil)
+	assert.ErrorIs(t, err, dialect.ErrDB)
+}
@@ -0,0 +1,607 @@
+package dialect
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/gookit/color"
+	"github.com/lib/pq"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+const (
+	// AuditLog is the table that records the changes made by df2.
+	AuditLog = "audit_log"
+	// Changesets is the table that names the reversible groups of changes in the audit log.
+	Changesets = "changesets"
+	// UpdateID is a user id to use with the updatedby and changedby columns.
+	UpdateID = "b66dc282-a029-4e99-85db-2cf2892fffcc"
+	// Datetime is the format used for time values in the audit log.
+	Datetime = "2006-01-02 15:04:05"
+)
+
+// Actions recorded by the audit log.
+const (
+	ActUpdate = "update"
+	ActDelete = "delete"
+	ActInsert = "insert"
+)
+
+var command = struct {
+	sync.RWMutex
+	name string
+}{}
+
+// SetCommand sets the name of the df2 command that is saved with each change to the audit log.
+func SetCommand(name string) {
+	command.Lock()
+	defer command.Unlock()
+	command.name = name
+}
+
+// Command returns the name of the df2 command that is saved with each change to the audit log.
+func Command() string {
+	command.RLock()
+	defer command.RUnlock()
+	return command.name
+}
+
+// Executor runs queries using either a database connection or a transaction.
+type Executor interface {
+	boil.ContextExecutor
+}
+
+// Tx is a database transaction.
+// Every column changed by Insert, Update or Delete is recorded and saved
+// to the audit log when the transaction is committed by End.
+// A dry run transaction instead writes the before and after values of every
+// changed row to W and is always rolled back by End.
+// When a Label is given, the saved changes are grouped as a changeset that can be undone.
+type Tx struct {
+	*sql.Tx
+	Engine    Engine    // Engine of the database connection.
+	Dry       bool      // Dry run rolls back all the changes.
+	W         io.Writer // W is the writer for the dry run changes.
+	Changes   []Change  // Changes are the column values changed by the transaction.
+	Label     string    // Label describes the changes to save them as a changeset.
+	Changeset int64     // Changeset is the id of the saved changeset.
+}
+
+// Change is the before and after value of a column in a changed row.
+type Change struct {
+	Table    string         // Table name.
+	ID       int64          // ID of the row.
+	Column   string         // Column name.
+	Before   sql.NullString // Before is the value of the column prior to the change.
+	After    sql.NullString // After is the value of the column after the change.
+	Deleted  bool           // Deleted is true when the row was removed.
+	Inserted bool           // Inserted is true when the row was added.
+}
+
+func (c Change) String() string {
+	if c.Inserted {
+		return fmt.Sprintf("%s %d %s %s %s", c.Table, c.ID, c.Column,
+			color.Info.Sprint(value(c.After)), color.Question.Sprint("inserted"))
+	}
+	if c.Deleted {
+		return fmt.Sprintf("%s %d %s %s %s", c.Table, c.ID, c.Column,
+			value(c.Before), color.Danger.Sprint("deleted"))
+	}
+	return fmt.Sprintf("%s %d %s %s %s %s", c.Table, c.ID, c.Column,
+		value(c.Before), color.Question.Sprint("⟫"), color.Info.Sprint(value(c.After)))
+}
+
+func value(s sql.NullString) string {
+	if !s.Valid {
+		return "NULL"
+	}
+	return fmt.Sprintf("%q", s.String)
+}
+
+// Begin starts a transaction.
+// When dry is true, the changes are written to w and the transaction is never committed.
+func Begin(ctx context.Context, db *sql.DB, w io.Writer, dry bool) (*Tx, error) {
+	if db == nil {
+		return nil, ErrDB
+	}
+	if w == nil {
+		w = io.Discard
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("dialect begin: %w", err)
+	}
+	return &Tx{Tx: tx, Engine: Use(db), Dry: dry, W: w}, nil
+}
+
+// End saves the changes to the audit log and commits the transaction,
+// or rolls back a dry run.
+func (tx *Tx) End() error {
+	if tx == nil || tx.Tx == nil {
+		return ErrDB
+	}
+	if !tx.Dry {
+		if err := tx.audit(context.Background()); err != nil {
+			return tx.Cancel(err)
+		}
+		return tx.Commit()
+	}
+	if err := tx.Rollback(); err != nil {
+		return fmt.Errorf("dialect dry run rollback: %w", err)
+	}
+	fmt.Fprintf(tx.W, "%s %d column changes were rolled back\n",
+		color.Warn.Sprint("dry run:"), len(tx.Changes))
+	return nil
+}
+
+// Cancel rolls back the transaction after an error.
+// The err is returned, joined with any rollback error.
+func (tx *Tx) Cancel(err error) error {
+	if tx == nil || tx.Tx == nil {
+		return err
+	}
+	if e := tx.Rollback(); e != nil {
+		return errors.Join(err, e)
+	}
+	return err
+}
+
+// audit inserts the changes into the audit log table.
+func (tx *Tx) audit(ctx context.Context) error {
+	if len(tx.Changes) == 0 {
+		return nil
+	}
+	cmd, now := Command(), time.Now()
+	set := sql.NullInt64{}
+	if tx.Label != "" {
+		id, err := tx.changeset(ctx, cmd, now)
+		if err != nil {
+			return err
+		}
+		tx.Changeset = id
+		set = sql.NullInt64{Int64: id, Valid: true}
+	}
+	const stmt = "INSERT INTO " + AuditLog + " (command, changeset, action, table_name, record_id, " +
+		"column_name, old_value, new_value, changedby, changedat) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	prep, err := tx.PrepareContext(ctx, stmt)
+	if err != nil {
+		return fmt.Errorf("audit log prepare: %w", Migrate(err))
+	}
+	defer prep.Close()
+	for _, c := range tx.Changes {
+		act := ActUpdate
+		switch {
+		case c.Deleted:
+			act = ActDelete
+		case c.Inserted:
+			act = ActInsert
+		}
+		if _, err := prep.ExecContext(ctx, cmd, set, act, c.Table, c.ID, c.Column,
+			c.Before, c.After, UpdateID, now); err != nil {
+			return fmt.Errorf("audit log exec: %w", Migrate(err))
+		}
+	}
+	if set.Valid {
+		fmt.Fprintf(tx.W, "saved %d column changes as changeset %d\n", len(tx.Changes), tx.Changeset)
+	}
+	return nil
+}
+
+// changeset inserts the label into the changesets table and returns the new id.
+func (tx *Tx) changeset(ctx context.Context, cmd string, now time.Time) (int64, error) {
+	const stmt = "INSERT INTO " + Changesets + " (command, label, createdat) VALUES (?, ?, ?)"
+	id, err := insertID(ctx, tx, stmt, cmd, tx.Label, now)
+	if err != nil {
+		return 0, fmt.Errorf("changeset insert: %w", Migrate(err))
+	}
+	return id, nil
+}
+
+// insertID runs the INSERT statement and returns the id of the new row.
+// Postgres has no last insert id, so the statement instead returns the id column.
+func insertID(ctx context.Context, exec Executor, stmt string, args ...any) (int64, error) {
+	if engine(exec) == Postgres {
+		var id int64
+		if err := exec.QueryRowContext(ctx, stmt+" RETURNING id", args...).Scan(&id); err != nil {
+			return 0, err
+		}
+		return id, nil
+	}
+	res, err := exec.ExecContext(ctx, stmt, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// Migrate returns ErrMigrate joined with err when the error is caused by a missing table or column,
+// which happens when the df2 migrations have not been applied to the database.
+func Migrate(err error) error {
+	const (
+		noTable, noColumn    = 1146, 1054       // MySQL error numbers.
+		undefTable, undefCol = "42P01", "42703" // Postgres error codes.
+	)
+	var me *mysql.MySQLError
+	if errors.As(err, &me) && (me.Number == noTable || me.Number == noColumn) {
+		return fmt.Errorf("%w: %w", ErrMigrate, err)
+	}
+	var pe *pq.Error
+	if errors.As(err, &pe) && (pe.Code == undefTable || pe.Code == undefCol) {
+		return fmt.Errorf("%w: %w", ErrMigrate, err)
+	}
+	return err
+}
+
+// engine returns the Engine used by the database connection or transaction.
+func engine(exec Executor) Engine {
+	switch v := exec.(type) {
+	case *sql.DB:
+		return Use(v)
+	case *Tx:
+		if v != nil {
+			return v.Engine
+		}
+	}
+	return MySQL
+}
+
+func isNil(exec Executor) bool {
+	switch v := exec.(type) {
+	case nil:
+		return true
+	case *sql.DB:
+		return v == nil
+	case *Tx:
+		return v == nil || v.Tx == nil
+	}
+	return false
+}
+
+// Insert adds a row with the column values to the table and returns the id of the new row.
+func Insert(ctx context.Context, exec Executor, table string, cols map[string]any) (int64, error) {
+	if isNil(exec) {
+		return 0, ErrDB
+	}
+	if len(cols) == 0 {
+		return 0, fmt.Errorf("dialect insert: %w", ErrNoCols)
+	}
+	switch v := exec.(type) {
+	case *sql.DB:
+		return implicit(ctx, v, func(tx *Tx) (int64, error) {
+			return Insert(ctx, tx, table, cols)
+		})
+	case *Tx:
+		return v.insert(ctx, table, cols)
+	}
+	names, args := columns(cols)
+	return insert(ctx, exec, table, names, args)
+}
+
+// insert the row and record the column values that are not NULL.
+func (tx *Tx) insert(ctx context.Context, table string, cols map[string]any) (int64, error) {
+	names, args := columns(cols)
+	id, err := insert(ctx, tx, table, names, args)
+	if err != nil {
+		return 0, err
+	}
+	for i, col := range names {
+		v := args[i]
+		if val, ok := v.(driver.Valuer); ok {
+			if dv, err := val.Value(); err == nil {
+				v = dv
+			}
+		}
+		if after := String(v); after.Valid {
+			tx.change(Change{Table: table, ID: id, Column: col, After: after, Inserted: true})
+		}
+	}
+	return id, nil
+}
+
+func insert(ctx context.Context, exec Executor, table string, names []string, args []any) (int64, error) {
+	vals := make([]string, len(names))
+	for i := range vals {
+		vals[i] = "?"
+	}
+	stmt := "INSERT INTO " + table + " (" + strings.Join(names, ", ") + ") VALUES (" + strings.Join(vals, ", ") + ")"
+	id, err := insertID(ctx, exec, stmt, args...)
+	if err != nil {
+		return 0, fmt.Errorf("dialect insert: %w", err)
+	}
+	return id, nil
+}
+
+// columns returns the sorted column names and their values.
+func columns(cols map[string]any) ([]string, []any) {
+	names := make([]string, 0, len(cols))
+	for col := range cols {
+		names = append(names, col)
+	}
+	sort.Strings(names)
+	args := make([]any, 0, len(names))
+	for _, col := range names {
+		args = append(args, cols[col])
+	}
+	return names, args
+}
+
+// Delete removes the rows of the table matching the query mods.
+// The number of rows affected is returned.
+func Delete(ctx context.Context, exec Executor, table string, mods ...qm.QueryMod) (int64, error) {
+	if isNil(exec) {
+		return 0, ErrDB
+	}
+	switch v := exec.(type) {
+	case *sql.DB:
+		return implicit(ctx, v, func(tx *Tx) (int64, error) {
+			return Delete(ctx, tx, table, mods...)
+		})
+	case *Tx:
+		before, err := snapshot(ctx, v, table, nil, mods...)
+		if err != nil {
+			return 0, fmt.Errorf("dialect delete: %w", err)
+		}
+		for _, id := range before.ids {
+			for i, col := range before.cols {
+				if val := before.rows[id][i]; val.Valid {
+					v.change(Change{Table: table, ID: id, Column: col, Before: val, Deleted: true})
+				}
+			}
+		}
+	}
+	mods = append([]qm.QueryMod{qm.From(table)}, mods...)
+	q := New(exec, mods...)
+	queries.SetDelete(q)
+	return run(ctx, exec, q, "delete")
+}
+
+// UpdateTable sets the columns of the table rows matching the query mods.
+// The updatedat and updatedby columns of the files table are also set,
+// but only for the rows with a column value that is changed.
+// The number of rows affected is returned.
+func UpdateTable(ctx context.Context, exec Executor, table string, cols map[string]any,
+	mods ...qm.QueryMod,
+) (int64, error) {
+	if isNil(exec) {
+		return 0, ErrDB
+	}
+	set := make(map[string]any, len(cols))
+	names := make([]string, 0, len(cols))
+	for col, val := range cols {
+		set[col] = val
+		names = append(names, col)
+	}
+	sort.Strings(names)
+	if table == Files {
+		if _, ok := set["updatedat"]; !ok {
+			set["updatedat"] = time.Now()
+		}
+		if _, ok := set["updatedby"]; !ok {
+			set["updatedby"] = UpdateID
+		}
+	}
+	switch v := exec.(type) {
+	case *sql.DB:
+		return implicit(ctx, v, func(tx *Tx) (int64, error) {
+			return UpdateTable(ctx, tx, table, cols, mods...)
+		})
+	case *Tx:
+		return v.update(ctx, table, set, names, mods...)
+	}
+	return update(ctx, exec, table, set, mods...)
+}
+
+// implicit runs fn within a transaction that is committed on success.
+func implicit(ctx context.Context, db *sql.DB, fn func(tx *Tx) (int64, error)) (int64, error) {
+	tx, err := Begin(ctx, db, nil, false)
+	if err != nil {
+		return 0, err
+	}
+	i, err := fn(tx)
+	if err != nil {
+		return 0, tx.Cancel(err)
+	}
+	if err := tx.End(); err != nil {
+		return 0, err
+	}
+	return i, nil
+}
+
+// update the table and record the changes to the named columns.
+// Only the rows with a column value that differs from cols are updated,
+// so the rows that would be left unchanged keep their updatedat and updatedby values.
+func (tx *Tx) update(ctx context.Context, table string, cols map[string]any, names []string,
+	mods ...qm.QueryMod,
+) (int64, error) {
+	before, err := snapshot(ctx, tx, table, names, mods...)
+	if err != nil {
+		return 0, fmt.Errorf("dialect update: %w", err)
+	}
+	changed := before.changed(cols)
+	after := result{cols: before.cols, rows: map[int64][]sql.NullString{}}
+	count := int64(0)
+	const chunk = 500
+	for i := 0; i < len(changed); i += chunk {
+		j := i + chunk
+		if j > len(changed) {
+			j = len(changed)
+		}
+		ids := make([]any, 0, j-i)
+		for _, id := range changed[i:j] {
+			ids = append(ids, id)
+		}
+		c, err := update(ctx, tx, table, cols, qm.WhereIn("id IN ?", ids...))
+		if err != nil {
+			return 0, err
+		}
+		count += c
+		res, err := snapshot(ctx, tx, table, names, qm.WhereIn("id IN ?", ids...))
+		if err != nil {
+			return 0, fmt.Errorf("dialect update: %w", err)
+		}
+		for id, row := range res.rows {
+			after.rows[id] = row
+		}
+	}
+	for _, id := range changed {
+		for i, col := range before.cols {
+			b, a := before.rows[id][i], sql.NullString{}
+			if row, ok := after.rows[id]; ok {
+				a = row[i]
+			}
+			if b == a {
+				continue
+			}
+			tx.change(Change{Table: table, ID: id, Column: col, Before: b, After: a})
+		}
+	}
+	return count, nil
+}
+
+func update(ctx context.Context, exec Executor, table string, cols map[string]any,
+	mods ...qm.QueryMod,
+) (int64, error) {
+	mods = append([]qm.QueryMod{qm.From(table)}, mods...)
+	q := New(exec, mods...)
+	queries.SetUpdate(q, cols)
+	return run(ctx, exec, q, "update")
+}
+
+func run(ctx context.Context, exec Executor, q *queries.Query, name string) (int64, error) {
+	res, err := q.ExecContext(ctx, exec)
+	if err != nil {
+		return 0, fmt.Errorf("dialect %s: %w", name, err)
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("dialect %s rows affected: %w", name, err)
+	}
+	return count, nil
+}
+
+// result is a snapshot of table rows.
+type result struct {
+	ids  []int64                    // ids of the rows in order.
+	cols []string                   // cols are the column names.
+	rows map[int64][]sql.NullString // rows are the column values keyed by id.
+}
+
+// snapshot returns the id and column values of the table rows matching the query mods.
+// When no columns are named, all the columns of the table are returned.
+func snapshot(ctx context.Context, tx *Tx, table string, cols []string,
+	mods ...qm.QueryMod,
+) (result, error) {
+	sel := qm.Select("*")
+	if len(cols) > 0 {
+		sel = qm.Select(append([]string{"id"}, cols...)...)
+	}
+	mods = append([]qm.QueryMod{sel, qm.From(table), qm.OrderBy("id")}, mods...)
+	rows, err := New(tx, mods...).QueryContext(ctx, tx)
+	if err != nil {
+		return result{}, fmt.Errorf("snapshot %s: %w", table, err)
+	}
+	defer rows.Close()
+	names, err := rows.Columns()
+	if err != nil {
+		return result{}, fmt.Errorf("snapshot %s columns: %w", table, err)
+	}
+	key := -1
+	for i, name := range names {
+		if name == "id" {
+			key = i
+		}
+	}
+	if key < 0 {
+		return result{}, fmt.Errorf("snapshot %s: %w", table, ErrID)
+	}
+	res := result{rows: map[int64][]sql.NullString{}}
+	for i, name := range names {
+		if i != key {
+			res.cols = append(res.cols, name)
+		}
+	}
+	for rows.Next() {
+		vals := make([]any, len(names))
+		dest := make([]any, len(names))
+		var id int64
+		for i := range vals {
+			dest[i] = &vals[i]
+		}
+		dest[key] = &id
+		if err := rows.Scan(dest...); err != nil {
+			return result{}, fmt.Errorf("snapshot %s scan: %w", table, err)
+		}
+		row := make([]sql.NullString, 0, len(res.cols))
+		for i, v := range vals {
+			if i != key {
+				row = append(row, String(v))
+			}
+		}
+		res.ids = append(res.ids, id)
+		res.rows[id] = row
+	}
+	if err := rows.Err(); err != nil {
+		return result{}, fmt.Errorf("snapshot %s rows: %w", table, err)
+	}
+	return res, nil
+}
+
+// changed returns the ids of the rows with a column value that differs from the value in cols.
+// The values are compared as strings, so a value that cannot be matched is treated as a change.
+func (r result) changed(cols map[string]any) []int64 {
+	ids := make([]int64, 0, len(r.ids))
+	for _, id := range r.ids {
+		for i, col := range r.cols {
+			v := cols[col]
+			if val, ok := v.(driver.Valuer); ok {
+				if dv, err := val.Value(); err == nil {
+					v = dv
+				}
+			}
+			if r.rows[id][i] != String(v) {
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+	return ids
+}
+
+// String returns the scanned database value as a string,
+// which is NULL for a nil value.
+// Time values use the Datetime format, which both database engines accept.
+func String(v any) sql.NullString {
+	switch val := v.(type) {
+	case nil:
+		return sql.NullString{}
+	case []byte:
+		return sql.NullString{String: string(val), Valid: true}
+	case string:
+		return sql.NullString{String: val, Valid: true}
+	case int64:
+		return sql.NullString{String: strconv.FormatInt(val, 10), Valid: true}
+	case time.Time:
+		return sql.NullString{String: val.Format(Datetime), Valid: true}
+	}
+	return sql.NullString{String: fmt.Sprint(v), Valid: true}
+}
+
+// change records the change and prints it for a dry run.
+func (tx *Tx) change(c Change) {
+	tx.Changes = append(tx.Changes, c)
+	if tx.Dry {
+		fmt.Fprintln(tx.W, " ", c)
+	}
+}
@@ -0,0 +1,358 @@
+package dialect_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Defacto2/df2/pkg/database/internal/dialect"
+	"github.com/Defacto2/df2/pkg/database/psql"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// fake is a minimal database driver that returns queued rows to queries.
+type fake struct {
+	mu       sync.Mutex
+	rows     []*rows
+	stmts    []string
+	rollback bool
+	commit   bool
+	fail     string // fail is a part of the statements that return err.
+	err      error
+}
+
+func (f *fake) Open(string) (driver.Conn, error) { return &conn{f}, nil }
+
+type conn struct{ f *fake }
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) { return &stmt{c.f, query}, nil }
+func (c *conn) Close() error                              { return nil }
+func (c *conn) Begin() (driver.Tx, error)                 { return &tx{c.f}, nil } //nolint:staticcheck
+
+type tx struct{ f *fake }
+
+func (t *tx) Commit() error   { t.f.commit = true; return nil }
+func (t *tx) Rollback() error { t.f.rollback = true; return nil }
+
+type stmt struct {
+	f     *fake
+	query string
+}
+
+func (s *stmt) Close() error  { return nil }
+func (s *stmt) NumInput() int { return -1 }
+
+func (s *stmt) Exec([]driver.Value) (driver.Result, error) {
+	s.f.mu.Lock()
+	defer s.f.mu.Unlock()
+	s.f.stmts = append(s.f.stmts, s.query)
+	if s.f.fail != "" && strings.Contains(s.query, s.f.fail) {
+		return nil, s.f.err
+	}
+	return result{}, nil
+}
+
+// result reports one affected row and an insert id of 1.
+type result struct{}
+
+func (result) LastInsertId() (int64, error) { return 1, nil }
+func (result) RowsAffected() (int64, error) { return 1, nil }
+
+func (s *stmt) Query([]driver.Value) (driver.Rows, error) {
+	s.f.mu.Lock()
+	defer s.f.mu.Unlock()
+	s.f.stmts = append(s.f.stmts, s.query)
+	if len(s.f.rows) == 0 {
+		return &rows{cols: []string{"id"}}, nil
+	}
+	r := s.f.rows[0]
+	s.f.rows = s.f.rows[1:]
+	return r, nil
+}
+
+type rows struct {
+	cols []string
+	vals [][]driver.Value
+}
+
+func (r *rows) Columns() []string { return r.cols }
+func (r *rows) Close() error      { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if len(r.vals) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.vals[0])
+	r.vals = r.vals[1:]
+	return nil
+}
+
+func open(t *testing.T, name string, f *fake) *sql.DB {
+	t.Helper()
+	sql.Register(name, f)
+	db, err := sql.Open(name, "")
+	assert.Nil(t, err)
+	return db
+}
+
+func TestChange_String(t *testing.T) {
+	t.Parallel()
+	c := dialect.Change{
+		Table: "files", ID: 1, Column: "section",
+		Before: sql.NullString{String: "Intro", Valid: true},
+	}
+	s := c.String()
+	assert.Contains(t, s, "files 1 section \"Intro\"")
+	assert.Contains(t, s, "NULL")
+	c.Deleted = true
+	assert.Contains(t, c.String(), "deleted")
+}
+
+func TestBegin(t *testing.T) {
+	t.Parallel()
+	_, err := dialect.Begin(context.Background(), nil, nil, false)
+	assert.ErrorIs(t, err, dialect.ErrDB)
+	var tx *dialect.Tx
+	assert.ErrorIs(t, tx.End(), dialect.ErrDB)
+	assert.ErrorIs(t, tx.Cancel(dialect.ErrID), dialect.ErrID)
+	_, err = dialect.UpdateTable(context.Background(), tx, dialect.Files, nil)
+	assert.ErrorIs(t, err, dialect.ErrDB)
+	_, err = dialect.Delete(context.Background(), nil, dialect.Files)
+	assert.ErrorIs(t, err, dialect.ErrDB)
+}
+
+func TestTx_Update(t *testing.T) {
+	t.Parallel()
+	f := &fake{rows: []*rows{
+		{cols: []string{"id", "section"}, vals: [][]driver.Value{{int64(1), "Intro"}, {int64(2), "intro"}}},
+		{cols: []string{"id", "section"}, vals: [][]driver.Value{{int64(1), "intro"}, {int64(2), "intro"}}},
+	}}
+	db := open(t, "fake-update", f)
+	defer db.Close()
+	ctx := context.Background()
+	b := strings.Builder{}
+	tx, err := dialect.Begin(ctx, db, &b, true)
+	assert.Nil(t, err)
+	i, err := dialect.Update(ctx, tx, map[string]any{"section": "intro"}, qm.Where("section = ?", "Intro"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), i)
+	assert.Len(t, tx.Changes, 1)
+	assert.Equal(t, int64(1), tx.Changes[0].ID)
+	assert.Equal(t, "section", tx.Changes[0].Column)
+	assert.Equal(t, "Intro", tx.Changes[0].Before.String)
+	assert.Equal(t, "intro", tx.Changes[0].After.String)
+	assert.Nil(t, tx.End())
+	assert.True(t, f.rollback)
+	assert.False(t, f.commit)
+	assert.Contains(t, b.String(), "dry run")
+	assert.Len(t, f.stmts, 3)
+	assert.Contains(t, f.stmts[1], "UPDATE `files` SET `section` = ?")
+	assert.Contains(t, f.stmts[1], "WHERE (`id` IN (?))")
+}
+
+func TestTx_Update_Unchanged(t *testing.T) {
+	t.Parallel()
+	f := &fake{rows: []*rows{
+		{cols: []string{"id", "section"}, vals: [][]driver.Value{{int64(1), "intro"}, {int64(2), "intro"}}},
+	}}
+	db := open(t, "fake-unchanged", f)
+	defer db.Close()
+	i, err := dialect.Update(context.Background(), db, map[string]any{"section": "intro"},
+		qm.Where("section = ?", "intro"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), i)
+	assert.True(t, f.commit)
+	// the rows are not updated, so updatedat and updatedby are kept and nothing is audited
+	assert.Len(t, f.stmts, 1)
+	assert.Contains(t, f.stmts[0], "SELECT")
+}
+
+func TestTx_Delete(t *testing.T) {
+	t.Parallel()
+	f := &fake{rows: []*rows{
+		{cols: []string{"id", "pubname", "initialisms"}, vals: [][]driver.Value{{int64(3), "", nil}}},
+	}}
+	db := open(t, "fake-delete", f)
+	defer db.Close()
+	ctx := context.Background()
+	tx, err := dialect.Begin(ctx, db, nil, true)
+	assert.Nil(t, err)
+	i, err := dialect.Delete(ctx, tx, "groupnames", qm.Where("pubname = ?", ""))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), i)
+	assert.Len(t, tx.Changes, 1)
+	assert.True(t, tx.Changes[0].Deleted)
+	assert.Equal(t, "pubname", tx.Changes[0].Column)
+	assert.Nil(t, tx.End())
+	assert.True(t, f.rollback)
+
+	f = &fake{}
+	db = open(t, "fake-commit", f)
+	defer db.Close()
+	tx, err = dialect.Begin(ctx, db, nil, false)
+	assert.Nil(t, err)
+	_, err = dialect.Delete(ctx, tx, "groupnames", qm.Where("pubname = ?", ""))
+	assert.Nil(t, err)
+	assert.Empty(t, tx.Changes)
+	assert.Nil(t, tx.End())
+	assert.True(t, f.commit)
+	assert.Len(t, f.stmts, 2)
+}
+
+func TestInsert(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	_, err := dialect.Insert(ctx, nil, dialect.Files, map[string]any{"uuid": "abc"})
+	assert.ErrorIs(t, err, dialect.ErrDB)
+
+	f := &fake{}
+	db := open(t, "fake-insert", f)
+	defer db.Close()
+	_, err = dialect.Insert(ctx, db, dialect.Files, nil)
+	assert.ErrorIs(t, err, dialect.ErrNoCols)
+	id, err := dialect.Insert(ctx, db, dialect.Files,
+		map[string]any{"uuid": "abc", "platform": "dos", "deletedat": nil})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), id)
+	assert.True(t, f.commit)
+	assert.Len(t, f.stmts, 3)
+	assert.Equal(t, "INSERT INTO files (deletedat, platform, uuid) VALUES (?, ?, ?)", f.stmts[0])
+	assert.Contains(t, f.stmts[1], "INSERT INTO "+dialect.AuditLog)
+}
+
+func TestTx_Insert(t *testing.T) {
+	t.Parallel()
+	f := &fake{rows: []*rows{{cols: []string{"id"}, vals: [][]driver.Value{{int64(42)}}}}}
+	sql.Register("fake-insert-postgres", psql.NewDriver(f.Open))
+	db, err := sql.Open("fake-insert-postgres", "")
+	assert.Nil(t, err)
+	defer db.Close()
+	ctx := context.Background()
+	tx, err := dialect.Begin(ctx, db, nil, true)
+	assert.Nil(t, err)
+	id, err := dialect.Insert(ctx, tx, dialect.Files, map[string]any{"uuid": "abc", "deletedat": nil})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(42), id)
+	assert.Equal(t, "INSERT INTO files (deletedat, uuid) VALUES ($1, $2) RETURNING id", f.stmts[0])
+	assert.Len(t, tx.Changes, 1)
+	assert.True(t, tx.Changes[0].Inserted)
+	assert.Equal(t, int64(42), tx.Changes[0].ID)
+	assert.Contains(t, tx.Changes[0].String(), "inserted")
+	assert.Nil(t, tx.End())
+	assert.True(t, f.rollback)
+}
+
+func TestTx_Audit(t *testing.T) {
+	t.Parallel()
+	f := &fake{rows: []*rows{
+		{cols: []string{"id", "platform"}, vals: [][]driver.Value{{int64(5), "DOS"}}},
+		{cols: []string{"id", "platform"}, vals: [][]driver.Value{{int64(5), "dos"}}},
+	}}
+	db := open(t, "fake-audit", f)
+	defer db.Close()
+	i, err := dialect.Update(context.Background(), db, map[string]any{"platform": "dos"},
+		qm.Where("platform = ?", "DOS"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), i)
+	assert.True(t, f.commit)
+	assert.False(t, f.rollback)
+	assert.Len(t, f.stmts, 4)
+	assert.Contains(t, f.stmts[1], "`updatedby` = ?")
+	assert.Contains(t, f.stmts[3], "INSERT INTO "+dialect.AuditLog)
+}
+
+func TestTx_Audit_Migrate(t *testing.T) {
+	t.Parallel()
+	f := &fake{
+		rows: []*rows{
+			{cols: []string{"id", "platform"}, vals: [][]driver.Value{{int64(5), "DOS"}}},
+			{cols: []string{"id", "platform"}, vals: [][]driver.Value{{int64(5), "dos"}}},
+		},
+		fail: dialect.AuditLog,
+		err:  &mysql.MySQLError{Number: 1146, Message: "Table 'defacto2-inno.audit_log' doesn't exist"},
+	}
+	db := open(t, "fake-migrate", f)
+	defer db.Close()
+	_, err := dialect.Update(context.Background(), db, map[string]any{"platform": "dos"},
+		qm.Where("platform = ?", "DOS"))
+	assert.ErrorIs(t, err, dialect.ErrMigrate)
+	assert.Contains(t, err.Error(), "df2 db migrate up")
+	assert.True(t, f.rollback)
+	assert.False(t, f.commit)
+}
+
+func TestString(t *testing.T) {
+	t.Parallel()
+	assert.False(t, dialect.String(nil).Valid)
+	assert.Equal(t, "abc", dialect.String([]byte("abc")).String)
+	assert.Equal(t, "-5", dialect.String(int64(-5)).String)
+	tm := time.Date(1999, 12, 31, 23, 59, 1, 0, time.UTC)
+	assert.Equal(t, "1999-12-31 23:59:01", dialect.String(tm).String)
+	assert.Equal(t, "true", dialect.String(true).String)
+}
+
+func TestSetCommand(t *testing.T) {
+	dialect.SetCommand("df2 test")
+	assert.Equal(t, "df2 test", dialect.Command())
+	dialect.SetCommand("")
+}
+
+func TestTx_Changeset(t *testing.T) {
+	t.Parallel()
+	f := &fake{rows: []*rows{
+		{cols: []string{"id", "group_brand_for"}, vals: [][]driver.Value{{int64(9), "a"}}},
+		{cols: []string{"id", "group_brand_for"}, vals: [][]driver.Value{{int64(9), "b"}}},
+	}}
+	db := open(t, "fake-changeset", f)
+	defer db.Close()
+	ctx := context.Background()
+	b := strings.Builder{}
+	tx, err := dialect.Begin(ctx, db, &b, false)
+	assert.Nil(t, err)
+	tx.Label = "rename group a to b"
+	_, err = dialect.Update(ctx, tx, map[string]any{"group_brand_for": "b"},
+		qm.Where("group_brand_for = ?", "a"))
+	assert.Nil(t, err)
+	assert.Nil(t, tx.End())
+	assert.Equal(t, int64(1), tx.Changeset)
+	assert.Contains(t, b.String(), "changeset 1")
+	assert.Len(t, f.stmts, 5)
+	assert.Contains(t, f.stmts[3], "INSERT INTO "+dialect.Changesets)
+	assert.Contains(t, f.stmts[4], "INSERT INTO "+dialect.AuditLog)
+}
+
+func TestTx_Changeset_Postgres(t *testing.T) {
+	t.Parallel()
+	f := &fake{rows: []*rows{{cols: []string{"id"}, vals: [][]driver.Value{{int64(7)}}}}}
+	sql.Register("fake-changeset-postgres", psql.NewDriver(f.Open))
+	db, err := sql.Open("fake-changeset-postgres", "")
+	assert.Nil(t, err)
+	defer db.Close()
+	tx, err := dialect.Begin(context.Background(), db, nil, false)
+	assert.Nil(t, err)
+	assert.Equal(t, dialect.Postgres, tx.Engine)
+	tx.Label = "rename group a to b"
+	tx.Changes = []dialect.Change{{Table: dialect.Files, ID: 9, Column: "group_brand_for"}}
+	assert.Nil(t, tx.End())
+	assert.Equal(t, int64(7), tx.Changeset)
+	assert.Len(t, f.stmts, 2)
+	assert.Equal(t, "INSERT INTO "+dialect.Changesets+
+		" (command, label, createdat) VALUES ($1, $2, $3) RETURNING id", f.stmts[0])
+	assert.NotContains(t, f.stmts[1], "RETURNING")
+}
+
+func TestMigrate(t *testing.T) {
+	t.Parallel()
+	err := dialect.Migrate(&mysql.MySQLError{Number: 1054, Message: "Unknown column 'file_security_violation'"})
+	assert.ErrorIs(t, err, dialect.ErrMigrate)
+	err = dialect.Migrate(sql.ErrNoRows)
+	assert.NotErrorIs(t, err, dialect.ErrMigrate)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -2,6 +2,7 @@
 package recd
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -14,11 +15,13 @@ import (
 	"time"
 
 	"github.com/Defacto2/df2/pkg/conf"
-	"github.com/Defacto2/df2/pkg/database/internal/templ"
+	"github.com/Defacto2/df2/pkg/database/internal/dialect"
 	"github.com/Defacto2/df2/pkg/directories"
 	"github.com/Defacto2/df2/pkg/str"
 	"github.com/dustin/go-humanize"
 	"github.com/gookit/color"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 )
 
 var (
@@ -31,20 +34,10 @@ const (
 	// Datetime MySQL format.
 	Datetime = "2006-01-02T15:04:05Z"
 	// UpdateID is a user id to use with the updatedby column.
-	UpdateID = "b66dc282-a029-4e99-85db-2cf2892fffcc"
+	UpdateID = dialect.UpdateID
 
 	fm os.FileMode = 0o666
 
-	filename       = 4
-	filesize       = 5
-	filezipcontent = 7
-	platform       = 9
-	hashstrong     = 10
-	hashweak       = 11
-	groupbrandfor  = 13
-	groupbrandby   = 14
-	section        = 15
-
 	z7  = ".7z"
 	arj = ".arj"
 	png = ".png"
@@ -85,13 +78,10 @@ func (r *Record) Approve(db *sql.DB) error {
 	if db == nil {
 		return ErrDB
 	}
-	update, err := db.Prepare("UPDATE files SET updatedat=NOW(),updatedby=?,deletedat=NULL,deletedby=NULL WHERE id=?")
-	if err != nil {
-		return fmt.Errorf("record approve prepare: %w", err)
-	}
-	defer update.Close()
-	if _, err := update.Exec(UpdateID, r.ID); err != nil {
-		return fmt.Errorf("record approve exec: %w", err)
+	if _, err := dialect.Update(context.Background(), db,
+		map[string]any{"deletedat": nil, "deletedby": nil},
+		qm.Where("id = ?", r.ID)); err != nil {
+		return fmt.Errorf("record approve: %w", err)
 	}
 	return nil
 }
@@ -106,38 +96,35 @@ func (r *Record) AutoID(data string) uint {
 	return id
 }
 
-func (r *Record) Check(w io.Writer, incoming string, values []sql.RawBytes, dir *directories.Dir) (bool, error) {
+func (r *Record) Check(w io.Writer, incoming string, f dialect.File, dir *directories.Dir) (bool, error) {
 	if dir == nil {
 		return false, fmt.Errorf("dir %w", ErrPointer)
 	}
-	if len(values) < platform {
-		return false, fmt.Errorf("expect %d, have %d: %w", section, len(values), ErrRawBytes)
-	}
 	if w == nil {
 		w = io.Discard
 	}
 	v := r.Verbose
-	if !r.checkFileName(string(values[filename])) {
+	if !r.checkFileName(f.Filename.String) {
 		verbose(w, v, "!filename")
 		return false, nil
 	}
-	if !r.CheckFileSize(string(values[filesize])) {
+	if !r.CheckFileSize(size(f.Filesize)) {
 		verbose(w, v, "!filesize")
 		return false, nil
 	}
-	if !r.checkHash(string(values[hashstrong]), string(values[hashweak])) {
+	if !r.checkHash(f.FileIntegrityStrong.String, f.FileIntegrityWeak.String) {
 		verbose(w, v, "!hash")
 		return false, nil
 	}
-	if !r.CheckFileContent(string(values[filezipcontent])) {
+	if !r.CheckFileContent(f.FileZipContent.String) {
 		verbose(w, v, "!file content")
 		return false, nil
 	}
-	if !r.CheckGroups(string(values[groupbrandby]), string(values[groupbrandfor])) {
+	if !r.CheckGroups(f.GroupBrandBy.String, f.GroupBrandFor.String) {
 		verbose(w, v, "!group")
 		return false, nil
 	}
-	if !r.checkTags(string(values[platform]), string(values[section])) {
+	if !r.checkTags(f.Platform.String, f.Section.String) {
 		verbose(w, v, "!tag")
 		return false, nil
 	}
@@ -145,7 +132,7 @@ func (r *Record) Check(w io.Writer, incoming string, values []sql.RawBytes, dir
 		verbose(w, v, "!download")
 		return false, nil
 	}
-	if string(values[platform]) != "audio" {
+	if f.Platform.String != "audio" {
 		if !r.CheckImage(dir.Img000) {
 			verbose(w, v, "!000x")
 			return false, nil
@@ -158,6 +145,14 @@ func (r *Record) Check(w io.Writer, incoming string, values []sql.RawBytes, dir
 	return true, nil
 }
 
+// size returns the filesize as a string or an empty value when null.
+func size(i null.Int64) string {
+	if !i.Valid {
+		return ""
+	}
+	return strconv.FormatInt(i.Int64, 10)
+}
+
 func (r *Record) CheckDownload(w io.Writer, incoming, path string) bool {
 	if w == nil {
 		w = io.Discard
@@ -326,28 +321,32 @@ func dupe(name, dest string) (int64, error) {
 }
 
 // NewApprove reports if a new file record is set to unapproved.
-func NewApprove(b []sql.RawBytes) (bool, error) {
-	// SQL column names can be found in the newFilesSQL statement in approve.go
-	const deletedat, createdat = 2, 3
-	if len(b) <= createdat {
-		return false, fmt.Errorf("have %d, but want %d %w", len(b), createdat, ErrRawBytes)
-	}
-	if b[deletedat] == nil {
-		return false, nil
-	}
-	n, err := Valid(b[deletedat], b[createdat])
-	if err != nil {
-		return false, err
+func NewApprove(f dialect.File) bool {
+	return Near(f.Deletedat, f.Createdat)
+}
+
+// Near reports whether the deletedat and the other timestamp are within
+// a few seconds of each other, which is the sign of an unapproved new record.
+func Near(deletedat, t null.Time) bool {
+	if !deletedat.Valid || !t.Valid {
+		return false
 	}
-	return n, nil
+	return near(deletedat.Time, t.Time)
 }
 
-func Valid(deletedat, updatedat sql.RawBytes) (bool, error) {
+func near(deletedat, updatedat time.Time) bool {
 	const (
 		min = -5
 		max = 5
 	)
 	// normalise the date values as sometimes updatedat & deletedat can be off by a second.
+	if diff := updatedat.Sub(deletedat); diff.Seconds() > max || diff.Seconds() < min {
+		return false
+	}
+	return true
+}
+
+func Valid(deletedat, updatedat sql.RawBytes) (bool, error) {
 	del, err := time.Parse(time.RFC3339, string(deletedat))
 	if err != nil {
 		return false, fmt.Errorf("valid deleted time: %w", err)
@@ -356,10 +355,7 @@ func Valid(deletedat, updatedat sql.RawBytes) (bool, error) {
 	if err != nil {
 		return false, fmt.Errorf("valid updated time: %w", err)
 	}
-	if diff := upd.Sub(del); diff.Seconds() > max || diff.Seconds() < min {
-		return false, nil
-	}
-	return true, nil
+	return near(del, upd), nil
 }
 
 func ColLen(s *sql.ColumnType) string {
@@ -407,7 +403,7 @@ func Verbose(w io.Writer, v bool, i any) {
 	}
 }
 
-// queries parses all records waiting for approval skipping those that
+// Queries parses all records waiting for approval skipping those that
 // are missing expected data or assets such as thumbnails.
 func Queries(db *sql.DB, w io.Writer, cfg conf.Config, v bool) error {
 	if db == nil {
@@ -416,40 +412,36 @@ func Queries(db *sql.DB, w io.Writer, cfg conf.Config, v bool) error {
 	if w == nil {
 		w = io.Discard
 	}
-	rows, err := db.Query(templ.SelNewFiles)
+	files, err := dialect.All(context.Background(), db,
+		qm.Select(newFiles()...),
+		qm.Where("deletedby IS NULL"),
+		qm.And("deletedat IS NOT NULL"))
 	if err != nil {
 		return fmt.Errorf("queries query: %w", err)
 	}
-	if rows.Err() != nil {
-		return fmt.Errorf("queries query rows: %w", rows.Err())
-	}
-	defer rows.Close()
-	cols, err := rows.Columns()
-	if err != nil {
-		return fmt.Errorf("queries columns: %w", err)
+	return query(db, w, cfg, v, files)
+}
+
+// newFiles are the columns required to check and approve new file records.
+func newFiles() []string {
+	return []string{
+		"id", "uuid", "deletedat", "createdat", "filename", "filesize",
+		"web_id_demozoo", "file_zip_content", "updatedat", "platform", "file_integrity_strong",
+		"file_integrity_weak", "web_id_pouet", "group_brand_for", "group_brand_by", "section",
 	}
-	return query(db, w, cfg, v, rows, cols)
 }
 
 func x() string {
 	return fmt.Sprintf(" %s", str.X())
 }
 
-func query(db *sql.DB, w io.Writer, cfg conf.Config, v bool, rows *sql.Rows, columns []string) error {
+func query(db *sql.DB, w io.Writer, cfg conf.Config, v bool, files []dialect.File) error {
 	if db == nil {
 		return ErrDB
 	}
-	if rows == nil {
-		return fmt.Errorf("rows %w", ErrPointer)
-	}
 	if w == nil {
 		w = io.Discard
 	}
-	values := make([]sql.RawBytes, len(columns))
-	args := make([]any, len(values))
-	for i := range values {
-		args[i] = &values[i]
-	}
 	dir, err := directories.Init(cfg, false)
 	if err != nil {
 		return err
@@ -459,25 +451,22 @@ func query(db *sql.DB, w io.Writer, cfg conf.Config, v bool, rows *sql.Rows, col
 		Verbose: v,
 	}
 	rowCnt := 0
-	for rows.Next() {
+	for _, f := range files {
 		rowCnt++
-		if err := rows.Scan(args...); err != nil {
-			return fmt.Errorf("queries row scan: %w", err)
-		}
 		Verbose(w, v, fmt.Sprintf("\nitem %04d (%v) %s %s ",
-			rowCnt, string(values[0]), color.Primary.Sprint(r.UUID), color.Info.Sprint(r.Filename)))
-		if skip(w, values, v) {
+			rowCnt, f.ID, color.Primary.Sprint(r.UUID), color.Info.Sprint(r.Filename)))
+		if skip(w, f, v) {
 			continue
 		}
-		r.UUID = string(values[1])
-		if ok, err := r.Check(w, cfg.IncomingFiles, values, &dir); err != nil {
+		r.UUID = f.UUID.String
+		if ok, err := r.Check(w, cfg.IncomingFiles, f, &dir); err != nil {
 			return err
 		} else if !ok {
 			Verbose(w, v, x())
 			continue
 		}
 		r.Save = true
-		if r.AutoID(string(values[0])) == 0 {
+		if r.AutoID(strconv.FormatInt(f.ID, 10)) == 0 {
 			r.Save = false
 		} else if err := r.Approve(db); err != nil {
 			Verbose(w, v, x())
@@ -494,16 +483,8 @@ func query(db *sql.DB, w io.Writer, cfg conf.Config, v bool, rows *sql.Rows, col
 	return nil
 }
 
-func skip(w io.Writer, values []sql.RawBytes, v bool) bool {
-	na, err := NewApprove(values)
-	if err != nil {
-		fmt.Fprintln(w, err)
-	}
-	dz, err := IsDemozoo(values)
-	if err != nil {
-		fmt.Fprintln(w, err)
-	}
-	if !na && !dz {
+func skip(w io.Writer, f dialect.File, v bool) bool {
+	if !NewApprove(f) && !IsDemozoo(f) {
 		Verbose(w, v, x())
 		return true
 	}
@@ -511,15 +492,6 @@ func skip(w io.Writer, values []sql.RawBytes, v bool) bool {
 }
 
 // IsDemozoo reports if a fetched demozoo file record is set to unapproved.
-func IsDemozoo(b []sql.RawBytes) (bool, error) {
-	// SQL column names can be found in the selectSQL statement in database.go
-	const deletedat, updatedat = 2, 8
-	if b[deletedat] == nil {
-		return false, nil
-	}
-	n, err := Valid(b[deletedat], b[updatedat])
-	if err != nil {
-		return false, err
-	}
-	return n, nil
+func IsDemozoo(f dialect.File) bool {
+	return Near(f.Deletedat, f.Updatedat)
 }
@@ -2,7 +2,6 @@ package recd_test
 
 import (
 	"bytes"
-	"database/sql"
 	"io"
 	"os"
 	"testing"
@@ -14,6 +13,7 @@ import (
 	"github.com/Defacto2/df2/pkg/directories"
 	"github.com/Defacto2/df2/pkg/internal"
 	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
 )
 
 func TestRecord_String(t *testing.T) {
@@ -49,18 +49,14 @@ func TestRecord_Approve(t *testing.T) {
 func TestRecord_Check(t *testing.T) {
 	t.Parallel()
 	r := recd.Record{}
-	b, err := r.Check(nil, "", nil, nil)
+	b, err := r.Check(nil, "", database.File{}, nil)
 	assert.NotNil(t, err)
 	assert.False(t, b)
 
 	dir, err := directories.Init(conf.Defaults(), false)
 	assert.Nil(t, err)
 	bb := &bytes.Buffer{}
-	b, err = r.Check(bb, "", nil, &dir)
-	assert.NotNil(t, err)
-	assert.False(t, b)
-	vals := make([]sql.RawBytes, 15)
-	b, err = r.Check(bb, "", vals, &dir)
+	b, err = r.Check(bb, "", database.File{}, &dir)
 	assert.Nil(t, err)
 	assert.False(t, b)
 }
@@ -109,19 +105,24 @@ func TestRecord_Summary(t *testing.T) {
 
 func TestNewApprove(t *testing.T) {
 	t.Parallel()
-	b, err := recd.NewApprove(nil)
-	assert.NotNil(t, err)
+	b := recd.NewApprove(database.File{})
 	assert.False(t, b)
-	raw := make([]sql.RawBytes, 4)
-	b, err = recd.NewApprove(raw)
-	assert.Nil(t, err)
+	now := null.TimeFrom(time.Now())
+	b = recd.NewApprove(database.File{Deletedat: now, Createdat: now})
+	assert.True(t, b)
+	b = recd.NewApprove(database.File{Deletedat: now, Createdat: null.TimeFrom(now.Time.Add(time.Minute))})
 	assert.False(t, b)
-	now := time.Now().Format(time.RFC3339)
-	raw[2] = []byte(now)
-	raw[3] = []byte(now)
-	b, err = recd.NewApprove(raw)
-	assert.Nil(t, err)
+}
+
+func TestIsDemozoo(t *testing.T) {
+	t.Parallel()
+	b := recd.IsDemozoo(database.File{})
+	assert.False(t, b)
+	now := null.TimeFrom(time.Now())
+	b = recd.IsDemozoo(database.File{Deletedat: now, Updatedat: null.TimeFrom(now.Time.Add(-time.Second))})
 	assert.True(t, b)
+	b = recd.IsDemozoo(database.File{Deletedat: now})
+	assert.False(t, b)
 }
 
 func TestVerbose(t *testing.T) {
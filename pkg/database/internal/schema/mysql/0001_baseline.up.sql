@@ -0,0 +1,92 @@
+-- The baseline schema of the Defacto2 database tables used by df2.
+-- Existing tables are left untouched.
+
+CREATE TABLE IF NOT EXISTS `files` (
+  `id` int(11) NOT NULL AUTO_INCREMENT COMMENT 'Primary key',
+  `uuid` char(36) DEFAULT NULL COMMENT 'Global identifier',
+  `list_relations` varchar(255) DEFAULT NULL COMMENT 'List of associated records',
+  `web_id_16colors` varchar(1024) DEFAULT NULL COMMENT 'URI for a 16colo.rs page',
+  `web_id_github` varchar(1024) DEFAULT NULL COMMENT 'Id for a GitHub repository',
+  `web_id_youtube` char(11) DEFAULT NULL COMMENT 'Id for a related YouTube video',
+  `web_id_pouet` int(6) DEFAULT NULL COMMENT 'Id for a Pouët record',
+  `web_id_demozoo` int(6) DEFAULT NULL COMMENT 'Id for a Demozoo record',
+  `group_brand_for` varchar(100) DEFAULT NULL COMMENT 'Group or brand used to credit the file',
+  `group_brand_by` varchar(100) DEFAULT NULL COMMENT 'Optional alternative Group or brand used to credit the file',
+  `record_title` varchar(100) DEFAULT NULL COMMENT 'Display title or magazine edition',
+  `date_issued_year` smallint(4) DEFAULT NULL COMMENT 'Published date year',
+  `date_issued_month` tinyint(2) DEFAULT NULL COMMENT 'Published date month',
+  `date_issued_day` tinyint(2) DEFAULT NULL COMMENT 'Published date day',
+  `credit_text` varchar(1024) DEFAULT NULL COMMENT 'Writing credits',
+  `credit_program` varchar(100) DEFAULT NULL COMMENT 'Programming credits',
+  `credit_illustration` varchar(1024) DEFAULT NULL COMMENT 'Artist credits',
+  `credit_audio` varchar(100) DEFAULT NULL COMMENT 'Composer credits',
+  `filename` varchar(255) DEFAULT NULL COMMENT 'File name',
+  `filesize` int(11) DEFAULT NULL COMMENT 'Size of the file in bytes',
+  `list_links` varchar(2048) DEFAULT NULL COMMENT 'List of URLs related to this file',
+  `file_security_alert_url` varchar(256) DEFAULT NULL COMMENT 'URL showing results of a virus scan',
+  `file_zip_content` longtext COMMENT 'Content of archive',
+  `file_magic_type` varchar(255) DEFAULT NULL COMMENT 'File type meta data',
+  `preview_image` varchar(1024) DEFAULT NULL COMMENT 'Internal file to use as a screenshot',
+  `file_integrity_strong` char(96) DEFAULT NULL COMMENT 'SHA384 hash of file',
+  `file_integrity_weak` char(32) DEFAULT NULL COMMENT 'MD5 hash of file',
+  `file_last_modified` datetime DEFAULT NULL COMMENT 'Date last modified attribute saved to file',
+  `platform` char(25) DEFAULT NULL COMMENT 'Computer platform',
+  `section` char(25) DEFAULT NULL COMMENT 'Category',
+  `comment` text COMMENT 'Description',
+  `createdat` datetime DEFAULT NULL COMMENT 'Timestamp when record was created',
+  `updatedat` datetime DEFAULT NULL COMMENT 'Timestamp when record was revised',
+  `deletedat` datetime DEFAULT NULL COMMENT 'Timestamp used to ignore record',
+  `updatedby` char(36) DEFAULT NULL COMMENT 'UUID of the user who last updated this record',
+  `deletedby` char(36) DEFAULT NULL COMMENT 'UUID of the user who removed this record',
+  `retrotxt_readme` varchar(255) DEFAULT NULL COMMENT 'Text file contained in archive to display',
+  `retrotxt_no_readme` tinyint(2) DEFAULT NULL COMMENT 'Disable the use of RetroTxt',
+  `dosee_run_program` varchar(255) DEFAULT NULL COMMENT 'Program contained in archive to run in DOSBox',
+  `dosee_hardware_cpu` varchar(6) DEFAULT NULL COMMENT 'DOSee turn off expanded memory (EMS)',
+  `dosee_hardware_graphic` varchar(8) DEFAULT NULL COMMENT 'DOSee graphics/machine override',
+  `dosee_hardware_audio` varchar(9) DEFAULT NULL COMMENT 'DOSee audio override',
+  `dosee_no_aspect_ratio_fix` tinyint(2) DEFAULT NULL COMMENT 'DOSee disable aspect ratio corrections',
+  `dosee_incompatible` tinyint(2) DEFAULT NULL COMMENT 'Flag DOS program as incompatible for DOSBox',
+  `dosee_no_ems` tinyint(2) DEFAULT NULL COMMENT 'DOSBox turn off EMS',
+  `dosee_no_xms` tinyint(2) DEFAULT NULL COMMENT 'DOSee turn off extended memory (XMS)',
+  `dosee_no_umb` tinyint(2) DEFAULT NULL COMMENT 'DOSee turn off upper memory block access (UMB)',
+  `dosee_load_utilities` tinyint(2) DEFAULT NULL COMMENT 'DOSee load utilities',
+  PRIMARY KEY (`id`),
+  KEY `Browsing` (`date_issued_year`,`date_issued_month`,`date_issued_day`,`section`,`platform`,`filename`(191),`createdat`),
+  FULLTEXT KEY `pubfor_pubby_pubedition_filename_comment` (`group_brand_for`,`group_brand_by`,`record_title`,`filename`,`comment`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COMMENT='This database is the complete collection of files for download';
+
+CREATE TABLE IF NOT EXISTS `groupnames` (
+  `id` smallint(6) NOT NULL AUTO_INCREMENT COMMENT 'Primary key',
+  `pubname` varchar(100) NOT NULL COMMENT 'Group or brand',
+  `initialisms` varchar(255) NOT NULL COMMENT 'Initialisms or acronym',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `pubname` (`pubname`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COMMENT='Initialism for groups';
+
+CREATE TABLE IF NOT EXISTS `netresources` (
+  `id` int(11) NOT NULL AUTO_INCREMENT COMMENT 'Primary key',
+  `uuid` char(36) DEFAULT NULL COMMENT 'Global identifier',
+  `legacyid` int(11) DEFAULT NULL COMMENT 'Former ids from defacto2net database',
+  `httpstatuscode` int(3) DEFAULT NULL COMMENT 'Status code definition',
+  `httpstatustext` varchar(255) DEFAULT NULL COMMENT 'Status code text',
+  `httplocation` varchar(255) DEFAULT NULL COMMENT 'URI given by 301,302,303 codes',
+  `httpetag` varchar(50) DEFAULT NULL COMMENT 'Hash key used for cache',
+  `httplastmodified` varchar(100) DEFAULT NULL COMMENT 'Date used for cache',
+  `metatitle` varchar(1000) DEFAULT NULL COMMENT 'Title metadata',
+  `metadescription` varchar(1000) DEFAULT NULL COMMENT 'Description metadata',
+  `metaauthors` varchar(1000) DEFAULT NULL COMMENT 'Authors metadata',
+  `metakeywords` varchar(1000) DEFAULT NULL COMMENT 'Keywords metadata',
+  `uriref` varchar(255) DEFAULT NULL COMMENT 'URL of the resource',
+  `title` varchar(255) DEFAULT NULL COMMENT 'Title of resource',
+  `date_issued_year` smallint(4) DEFAULT NULL,
+  `date_issued_month` tinyint(2) DEFAULT NULL,
+  `date_issued_day` tinyint(2) DEFAULT NULL,
+  `comment` mediumtext COMMENT 'Default description when metadescription is empty',
+  `categorykey` varchar(25) DEFAULT NULL COMMENT 'Category',
+  `categorysort` varchar(25) DEFAULT NULL COMMENT 'Sorting category',
+  `deletedat` datetime DEFAULT NULL COMMENT 'Timestamp used to disable record',
+  `deletedatcomment` varchar(255) DEFAULT NULL COMMENT 'Reason for record to be disabled',
+  `createdat` datetime DEFAULT NULL COMMENT 'Timestamp when record was created',
+  `updatedat` datetime DEFAULT NULL COMMENT 'Timestamp when record was revised',
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COMMENT='Scene websites';
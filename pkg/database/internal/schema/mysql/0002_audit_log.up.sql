@@ -0,0 +1,15 @@
+-- The audit log records every column changed by df2.
+CREATE TABLE IF NOT EXISTS `audit_log` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+  `command` varchar(255) NOT NULL DEFAULT '' COMMENT 'df2 command that made the change',
+  `action` varchar(10) NOT NULL COMMENT 'Either update or delete',
+  `table_name` varchar(64) NOT NULL COMMENT 'Table of the changed record',
+  `record_id` bigint(20) NOT NULL COMMENT 'Id of the changed record',
+  `column_name` varchar(64) NOT NULL COMMENT 'Column of the changed value',
+  `old_value` longtext COMMENT 'Value prior to the change',
+  `new_value` longtext COMMENT 'Value after the change',
+  `changedby` char(36) DEFAULT NULL COMMENT 'UUID of the user who made the change',
+  `changedat` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Timestamp of the change',
+  PRIMARY KEY (`id`),
+  KEY `record` (`table_name`,`record_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COMMENT='Record changes';
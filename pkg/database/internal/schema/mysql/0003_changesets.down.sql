@@ -0,0 +1,5 @@
+ALTER TABLE `audit_log`
+  DROP KEY `changeset`,
+  DROP COLUMN `changeset`;
+
+DROP TABLE IF EXISTS `changesets`;
@@ -0,0 +1,12 @@
+-- A changeset groups the audit log changes of a rename so they can be undone together.
+CREATE TABLE IF NOT EXISTS `changesets` (
+  `id` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+  `command` varchar(255) NOT NULL DEFAULT '' COMMENT 'df2 command that made the changes',
+  `label` varchar(255) NOT NULL DEFAULT '' COMMENT 'Description of the changes',
+  `createdat` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT 'Timestamp of the changes',
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COMMENT='Reversible sets of record changes';
+
+ALTER TABLE `audit_log`
+  ADD COLUMN `changeset` bigint(20) unsigned DEFAULT NULL COMMENT 'Id of the changeset' AFTER `command`,
+  ADD KEY `changeset` (`changeset`);
@@ -0,0 +1,2 @@
+ALTER TABLE `files`
+  DROP COLUMN `retrotxt_encoding`;
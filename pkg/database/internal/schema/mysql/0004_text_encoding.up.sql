@@ -0,0 +1,3 @@
+-- The detected character encoding of the text or readme of a file record.
+ALTER TABLE `files`
+  ADD COLUMN `retrotxt_encoding` varchar(32) DEFAULT NULL COMMENT 'Character encoding of the text file' AFTER `retrotxt_no_readme`;
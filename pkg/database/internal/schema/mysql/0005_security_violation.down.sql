@@ -0,0 +1,2 @@
+ALTER TABLE `files`
+  DROP COLUMN `file_security_violation`;
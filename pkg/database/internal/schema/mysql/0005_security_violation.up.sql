@@ -0,0 +1,3 @@
+-- The reason a file record was flagged as unsafe to extract.
+ALTER TABLE `files`
+  ADD COLUMN `file_security_violation` varchar(256) DEFAULT NULL COMMENT 'Reason the archive is unsafe to extract' AFTER `file_security_alert_url`;
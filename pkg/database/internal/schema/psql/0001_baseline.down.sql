@@ -0,0 +1,2 @@
+-- The baseline tables hold the complete collection of files,
+-- so they are never dropped by a migration.
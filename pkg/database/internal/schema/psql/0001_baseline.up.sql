@@ -0,0 +1,89 @@
+-- The baseline schema of the Defacto2 database tables used by df2.
+-- Existing tables are left untouched.
+
+CREATE TABLE IF NOT EXISTS files (
+  id BIGSERIAL PRIMARY KEY,
+  uuid CHAR(36) DEFAULT NULL,
+  list_relations VARCHAR(255) DEFAULT NULL,
+  web_id_16colors VARCHAR(1024) DEFAULT NULL,
+  web_id_github VARCHAR(1024) DEFAULT NULL,
+  web_id_youtube CHAR(11) DEFAULT NULL,
+  web_id_pouet BIGINT DEFAULT NULL,
+  web_id_demozoo BIGINT DEFAULT NULL,
+  group_brand_for VARCHAR(100) DEFAULT NULL,
+  group_brand_by VARCHAR(100) DEFAULT NULL,
+  record_title VARCHAR(100) DEFAULT NULL,
+  date_issued_year SMALLINT DEFAULT NULL,
+  date_issued_month SMALLINT DEFAULT NULL,
+  date_issued_day SMALLINT DEFAULT NULL,
+  credit_text VARCHAR(1024) DEFAULT NULL,
+  credit_program VARCHAR(100) DEFAULT NULL,
+  credit_illustration VARCHAR(1024) DEFAULT NULL,
+  credit_audio VARCHAR(100) DEFAULT NULL,
+  filename VARCHAR(255) DEFAULT NULL,
+  filesize BIGINT DEFAULT NULL,
+  list_links VARCHAR(2048) DEFAULT NULL,
+  file_security_alert_url VARCHAR(256) DEFAULT NULL,
+  file_zip_content TEXT DEFAULT NULL,
+  file_magic_type VARCHAR(255) DEFAULT NULL,
+  preview_image VARCHAR(1024) DEFAULT NULL,
+  file_integrity_strong CHAR(96) DEFAULT NULL,
+  file_integrity_weak CHAR(32) DEFAULT NULL,
+  file_last_modified TIMESTAMP DEFAULT NULL,
+  platform CHAR(25) DEFAULT NULL,
+  section CHAR(25) DEFAULT NULL,
+  comment TEXT DEFAULT NULL,
+  createdat TIMESTAMP DEFAULT NULL,
+  updatedat TIMESTAMP DEFAULT NULL,
+  deletedat TIMESTAMP DEFAULT NULL,
+  updatedby CHAR(36) DEFAULT NULL,
+  deletedby CHAR(36) DEFAULT NULL,
+  retrotxt_readme VARCHAR(255) DEFAULT NULL,
+  retrotxt_no_readme SMALLINT DEFAULT NULL,
+  dosee_run_program VARCHAR(255) DEFAULT NULL,
+  dosee_hardware_cpu VARCHAR(6) DEFAULT NULL,
+  dosee_hardware_graphic VARCHAR(8) DEFAULT NULL,
+  dosee_hardware_audio VARCHAR(9) DEFAULT NULL,
+  dosee_no_aspect_ratio_fix SMALLINT DEFAULT NULL,
+  dosee_incompatible SMALLINT DEFAULT NULL,
+  dosee_no_ems SMALLINT DEFAULT NULL,
+  dosee_no_xms SMALLINT DEFAULT NULL,
+  dosee_no_umb SMALLINT DEFAULT NULL,
+  dosee_load_utilities SMALLINT DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS browsing ON files (date_issued_year, date_issued_month, date_issued_day,
+  section, platform, filename, createdat);
+
+CREATE TABLE IF NOT EXISTS groupnames (
+  id SERIAL PRIMARY KEY,
+  pubname VARCHAR(100) NOT NULL UNIQUE,
+  initialisms VARCHAR(255) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS netresources (
+  id BIGSERIAL PRIMARY KEY,
+  uuid CHAR(36) DEFAULT NULL,
+  legacyid BIGINT DEFAULT NULL,
+  httpstatuscode BIGINT DEFAULT NULL,
+  httpstatustext VARCHAR(255) DEFAULT NULL,
+  httplocation VARCHAR(255) DEFAULT NULL,
+  httpetag VARCHAR(50) DEFAULT NULL,
+  httplastmodified VARCHAR(100) DEFAULT NULL,
+  metatitle VARCHAR(1000) DEFAULT NULL,
+  metadescription VARCHAR(1000) DEFAULT NULL,
+  metaauthors VARCHAR(1000) DEFAULT NULL,
+  metakeywords VARCHAR(1000) DEFAULT NULL,
+  uriref VARCHAR(255) DEFAULT NULL,
+  title VARCHAR(255) DEFAULT NULL,
+  date_issued_year SMALLINT DEFAULT NULL,
+  date_issued_month SMALLINT DEFAULT NULL,
+  date_issued_day SMALLINT DEFAULT NULL,
+  comment TEXT DEFAULT NULL,
+  categorykey VARCHAR(25) DEFAULT NULL,
+  categorysort VARCHAR(25) DEFAULT NULL,
+  deletedat TIMESTAMP DEFAULT NULL,
+  deletedatcomment VARCHAR(255) DEFAULT NULL,
+  createdat TIMESTAMP DEFAULT NULL,
+  updatedat TIMESTAMP DEFAULT NULL
+);
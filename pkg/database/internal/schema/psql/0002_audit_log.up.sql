@@ -0,0 +1,15 @@
+-- The audit log records every column changed by df2.
+CREATE TABLE IF NOT EXISTS audit_log (
+  id BIGSERIAL PRIMARY KEY,
+  command VARCHAR(255) NOT NULL DEFAULT '',
+  action VARCHAR(10) NOT NULL,
+  table_name VARCHAR(64) NOT NULL,
+  record_id BIGINT NOT NULL,
+  column_name VARCHAR(64) NOT NULL,
+  old_value TEXT DEFAULT NULL,
+  new_value TEXT DEFAULT NULL,
+  changedby CHAR(36) DEFAULT NULL,
+  changedat TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_record ON audit_log (table_name, record_id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS audit_log_changeset;
+
+ALTER TABLE audit_log DROP COLUMN IF EXISTS changeset;
+
+DROP TABLE IF EXISTS changesets;
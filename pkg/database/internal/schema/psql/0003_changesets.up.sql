@@ -0,0 +1,11 @@
+-- A changeset groups the audit log changes of a rename so they can be undone together.
+CREATE TABLE IF NOT EXISTS changesets (
+  id BIGSERIAL PRIMARY KEY,
+  command VARCHAR(255) NOT NULL DEFAULT '',
+  label VARCHAR(255) NOT NULL DEFAULT '',
+  createdat TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS changeset BIGINT DEFAULT NULL;
+
+CREATE INDEX IF NOT EXISTS audit_log_changeset ON audit_log (changeset);
@@ -0,0 +1,2 @@
+-- The detected character encoding of the text or readme of a file record.
+ALTER TABLE files ADD COLUMN IF NOT EXISTS retrotxt_encoding VARCHAR(32) DEFAULT NULL;
@@ -0,0 +1,2 @@
+-- The reason a file record was flagged as unsafe to extract.
+ALTER TABLE files ADD COLUMN IF NOT EXISTS file_security_violation VARCHAR(256) DEFAULT NULL;
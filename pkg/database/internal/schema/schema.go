@@ -0,0 +1,329 @@
+// Package schema applies the versioned database schema migrations.
+// The migrations are embedded, numbered SQL files with an up and a down
+// file for each database engine, for example mysql/0001_baseline.up.sql.
+// Applied migrations are recorded in the schema_migrations table.
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Defacto2/df2/pkg/database/internal/dialect"
+	"github.com/gookit/color"
+)
+
+//go:embed mysql/*.sql psql/*.sql
+var migrations embed.FS
+
+var (
+	ErrDB      = errors.New("database handle pointer cannot be nil")
+	ErrName    = errors.New("migration filename is invalid")
+	ErrMissing = errors.New("migration is missing its up or down file")
+	ErrUnknown = errors.New("applied migration is unknown to this version of df2")
+)
+
+// Table is the name of the database table that records the applied migrations.
+const Table = "schema_migrations"
+
+const (
+	up   = ".up.sql"
+	down = ".down.sql"
+)
+
+// Migration is a numbered schema change.
+type Migration struct {
+	Version int    // Version is the unique number of the migration.
+	Name    string // Name is the description of the migration.
+	Up      string // Up are the SQL statements to apply the migration.
+	Down    string // Down are the SQL statements to revert the migration.
+}
+
+// Status of a migration.
+type Status struct {
+	Migration
+	Applied time.Time // Applied is the time of the migration, or a zero value when not applied.
+}
+
+// Load the embedded migrations of the database engine sorted by version.
+func Load(e dialect.Engine) ([]Migration, error) {
+	return load(migrations, dir(e))
+}
+
+func dir(e dialect.Engine) string {
+	if e == dialect.Postgres {
+		return "psql"
+	}
+	return "mysql"
+}
+
+func load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("load migrations: %w", err)
+	}
+	found := map[int]*Migration{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		name := e.Name()
+		ver, desc, isUp, err := parse(name)
+		if err != nil {
+			return nil, err
+		}
+		b, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("load migration %s: %w", name, err)
+		}
+		m, ok := found[ver]
+		if !ok {
+			m = &Migration{Version: ver, Name: desc}
+			found[ver] = m
+		}
+		if isUp {
+			m.Up = string(b)
+			continue
+		}
+		m.Down = string(b)
+	}
+	ms := make([]Migration, 0, len(found))
+	for _, m := range found {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("%w: %04d_%s", ErrMissing, m.Version, m.Name)
+		}
+		ms = append(ms, *m)
+	}
+	sort.Slice(ms, func(i, j int) bool {
+		return ms[i].Version < ms[j].Version
+	})
+	return ms, nil
+}
+
+// parse the version number and description of a migration filename,
+// such as 0001_baseline.up.sql.
+func parse(name string) (int, string, bool, error) {
+	isUp := strings.HasSuffix(name, up)
+	if !isUp && !strings.HasSuffix(name, down) {
+		return 0, "", false, fmt.Errorf("%w: %s", ErrName, name)
+	}
+	s := strings.TrimSuffix(strings.TrimSuffix(name, up), down)
+	num, desc, ok := strings.Cut(s, "_")
+	if !ok || desc == "" {
+		return 0, "", false, fmt.Errorf("%w: %s", ErrName, name)
+	}
+	ver, err := strconv.Atoi(num)
+	if err != nil || ver < 1 {
+		return 0, "", false, fmt.Errorf("%w: %s", ErrName, name)
+	}
+	return ver, desc, isUp, nil
+}
+
+// Statements splits the SQL into individual statements.
+// Statements are separated by semicolons, those within quoted values are ignored,
+// as are lines that only contain a -- comment.
+func Statements(s string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(s, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	s = strings.Join(lines, "\n")
+	stmts := []string{}
+	var b strings.Builder
+	var quote rune
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'', r == '"', r == '`':
+			quote = r
+		case r == ';':
+			if stmt := strings.TrimSpace(b.String()); stmt != "" {
+				stmts = append(stmts, stmt)
+			}
+			b.Reset()
+			continue
+		}
+		b.WriteRune(r)
+	}
+	if stmt := strings.TrimSpace(b.String()); stmt != "" {
+		stmts = append(stmts, stmt)
+	}
+	return stmts
+}
+
+// create the schema_migrations table when it does not exist.
+func create(ctx context.Context, db *sql.DB) error {
+	const stmt = "CREATE TABLE IF NOT EXISTS " + Table + " (" +
+		"version BIGINT NOT NULL PRIMARY KEY, " +
+		"name VARCHAR(255) NOT NULL, " +
+		"applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP)"
+	if _, err := db.ExecContext(ctx, stmt); err != nil {
+		return fmt.Errorf("create %s: %w", Table, err)
+	}
+	return nil
+}
+
+// applied returns the versions and times of the applied migrations.
+func applied(ctx context.Context, db *sql.DB) (map[int]time.Time, error) {
+	if err := create(ctx, db); err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(ctx, "SELECT version, applied_at FROM "+Table)
+	if err != nil {
+		return nil, fmt.Errorf("applied query: %w", err)
+	}
+	defer rows.Close()
+	vers := map[int]time.Time{}
+	for rows.Next() {
+		var v int
+		var t time.Time
+		if err := rows.Scan(&v, &t); err != nil {
+			return nil, fmt.Errorf("applied scan: %w", err)
+		}
+		vers[v] = t
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("applied rows: %w", err)
+	}
+	return vers, nil
+}
+
+// List the status of every migration, including any applied migrations
+// that are unknown to this version of df2.
+func List(ctx context.Context, db *sql.DB) ([]Status, error) {
+	if db == nil {
+		return nil, ErrDB
+	}
+	ms, err := Load(dialect.Use(db))
+	if err != nil {
+		return nil, err
+	}
+	vers, err := applied(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Status, 0, len(ms))
+	for _, m := range ms {
+		list = append(list, Status{Migration: m, Applied: vers[m.Version]})
+		delete(vers, m.Version)
+	}
+	for v, t := range vers {
+		list = append(list, Status{Migration: Migration{Version: v, Name: "unknown"}, Applied: t})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+	return list, nil
+}
+
+// Up applies the pending migrations in order of their version.
+// Steps limits the number of migrations to apply, a zero value applies them all.
+// The number of applied migrations is returned.
+func Up(ctx context.Context, db *sql.DB, w io.Writer, steps int) (int, error) {
+	if db == nil {
+		return 0, ErrDB
+	}
+	if w == nil {
+		w = io.Discard
+	}
+	list, err := List(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	cnt := 0
+	for _, s := range list {
+		if steps > 0 && cnt >= steps {
+			break
+		}
+		if !s.Applied.IsZero() {
+			continue
+		}
+		fmt.Fprintf(w, "%s %04d %s\n", color.Info.Sprint("up"), s.Version, s.Name)
+		if err := exec(ctx, db, s.Up, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "INSERT INTO "+Table+" (version, name) VALUES (?, ?)", s.Version, s.Name)
+			return err
+		}); err != nil {
+			return cnt, fmt.Errorf("up %04d_%s: %w", s.Version, s.Name, err)
+		}
+		cnt++
+	}
+	return cnt, nil
+}
+
+// Down reverts the applied migrations in reverse order of their version.
+// Steps limits the number of migrations to revert, a zero value reverts only the last migration.
+// The number of reverted migrations is returned.
+func Down(ctx context.Context, db *sql.DB, w io.Writer, steps int) (int, error) {
+	if db == nil {
+		return 0, ErrDB
+	}
+	if w == nil {
+		w = io.Discard
+	}
+	if steps < 1 {
+		steps = 1
+	}
+	list, err := List(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	cnt := 0
+	for i := len(list) - 1; i >= 0 && cnt < steps; i-- {
+		s := list[i]
+		if s.Applied.IsZero() {
+			continue
+		}
+		if s.Down == "" {
+			return cnt, fmt.Errorf("%w: %04d", ErrUnknown, s.Version)
+		}
+		fmt.Fprintf(w, "%s %04d %s\n", color.Info.Sprint("down"), s.Version, s.Name)
+		if err := exec(ctx, db, s.Down, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "DELETE FROM "+Table+" WHERE version = ?", s.Version)
+			return err
+		}); err != nil {
+			return cnt, fmt.Errorf("down %04d_%s: %w", s.Version, s.Name, err)
+		}
+		cnt++
+	}
+	return cnt, nil
+}
+
+// exec runs the SQL statements and the record func within a transaction.
+// MySQL implicitly commits most schema changes, so a failed MySQL
+// migration may need to be repaired by hand.
+func exec(ctx context.Context, db *sql.DB, sql string, record func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range Statements(sql) {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			if e := tx.Rollback(); e != nil {
+				return errors.Join(err, e)
+			}
+			return err
+		}
+	}
+	if err := record(tx); err != nil {
+		if e := tx.Rollback(); e != nil {
+			return errors.Join(err, e)
+		}
+		return err
+	}
+	return tx.Commit()
+}
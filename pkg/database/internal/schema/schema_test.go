@@ -0,0 +1,137 @@
+package schema_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Defacto2/df2/pkg/database/internal/dialect"
+	"github.com/Defacto2/df2/pkg/database/internal/schema"
+	"github.com/Defacto2/df2/pkg/database/psql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+	for _, e := range []dialect.Engine{dialect.MySQL, dialect.Postgres} {
+		ms, err := schema.Load(e)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, ms)
+		for i, m := range ms {
+			assert.Equal(t, i+1, m.Version, "migration versions must be sequential")
+			assert.NotEmpty(t, m.Name)
+			assert.NotEmpty(t, m.Up)
+			assert.NotEmpty(t, m.Down)
+		}
+		assert.Equal(t, "baseline", ms[0].Name)
+	}
+	my, err := schema.Load(dialect.MySQL)
+	assert.Nil(t, err)
+	pg, err := schema.Load(dialect.Postgres)
+	assert.Nil(t, err)
+	assert.Equal(t, len(my), len(pg), "each engine requires the same migrations")
+}
+
+func TestStatements(t *testing.T) {
+	t.Parallel()
+	assert.Empty(t, schema.Statements(""))
+	assert.Empty(t, schema.Statements("-- a comment;\n  -- another;"))
+	s := schema.Statements("SELECT 1;\nSELECT 2")
+	assert.Equal(t, []string{"SELECT 1", "SELECT 2"}, s)
+	s = schema.Statements("INSERT INTO x VALUES ('a;b');\n-- skip;\nSELECT `c;d`;")
+	assert.Equal(t, []string{"INSERT INTO x VALUES ('a;b')", "SELECT `c;d`"}, s)
+	ms, err := schema.Load(dialect.Postgres)
+	assert.Nil(t, err)
+	for _, stmt := range schema.Statements(ms[0].Up) {
+		assert.False(t, strings.HasSuffix(stmt, ";"))
+	}
+	assert.Empty(t, schema.Statements(ms[0].Down))
+}
+
+func TestNilDB(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	_, err := schema.List(ctx, nil)
+	assert.ErrorIs(t, err, schema.ErrDB)
+	_, err = schema.Up(ctx, nil, nil, 0)
+	assert.ErrorIs(t, err, schema.ErrDB)
+	_, err = schema.Down(ctx, nil, nil, 0)
+	assert.ErrorIs(t, err, schema.ErrDB)
+}
+
+// pgConn is a fake Postgres connection that records the queries
+// and rejects those that a Postgres server would also reject.
+type pgConn struct {
+	mu      *sync.Mutex
+	queries *[]string
+}
+
+func (c pgConn) record(query string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.queries = append(*c.queries, query)
+	if strings.Contains(query, "?") || strings.Contains(query, "`") {
+		return errors.New("syntax error")
+	}
+	if strings.Contains(query, schema.Table) && strings.Contains(query, "RETURNING") {
+		return errors.New(`column "id" does not exist`)
+	}
+	return nil
+}
+
+func (c pgConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c pgConn) Close() error                        { return nil }
+func (c pgConn) Begin() (driver.Tx, error)           { return pgTx{}, nil }
+
+func (c pgConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	if err := c.record(query); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (c pgConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if err := c.record(query); err != nil {
+		return nil, err
+	}
+	return pgRows{}, nil
+}
+
+type pgTx struct{}
+
+func (pgTx) Commit() error   { return nil }
+func (pgTx) Rollback() error { return nil }
+
+// pgRows are the empty results of a query.
+type pgRows struct{}
+
+func (pgRows) Columns() []string              { return []string{"version", "applied_at"} }
+func (pgRows) Close() error                   { return nil }
+func (pgRows) Next(dest []driver.Value) error { return io.EOF }
+
+func TestUp_Postgres(t *testing.T) {
+	t.Parallel()
+	queries, mu := []string{}, &sync.Mutex{}
+	sql.Register("df2-postgres-test", psql.NewDriver(func(string) (driver.Conn, error) {
+		return pgConn{mu: mu, queries: &queries}, nil
+	}))
+	db, err := sql.Open("df2-postgres-test", "")
+	assert.Nil(t, err)
+	defer db.Close()
+	assert.Equal(t, dialect.Postgres, dialect.Use(db))
+	ms, err := schema.Load(dialect.Postgres)
+	assert.Nil(t, err)
+	cnt, err := schema.Up(context.Background(), db, nil, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, len(ms), cnt)
+	assert.Contains(t, queries, "INSERT INTO "+schema.Table+" (version, name) VALUES ($1, $2)")
+	// inserts are only rebound and never rewritten to return values
+	_, err = db.Exec("INSERT INTO files (uuid) VALUES (?)", "abc")
+	assert.Nil(t, err)
+	assert.Equal(t, `INSERT INTO files (uuid) VALUES ($1)`, queries[len(queries)-1])
+}
@@ -38,14 +38,10 @@ const (
 	WhereDownloadBlock = "WHERE `file_security_alert_url` IS NOT NULL AND `file_security_alert_url` != ''"
 	WhereAvailable     = "WHERE `deletedat` IS NULL"
 	WhereHidden        = "WHERE `deletedat` IS NOT NULL"
+	// WhereViolation requires the file_security_violation column of the 0005 migration.
+	WhereViolation = "WHERE `file_security_violation` IS NOT NULL AND `file_security_violation` != ''"
 )
 
-const SelNewFiles = "SELECT `id`,`uuid`,`deletedat`,`createdat`,`filename`,`filesize`," +
-	"`web_id_demozoo`,`file_zip_content`,`updatedat`,`platform`,`file_integrity_strong`," +
-	"`file_integrity_weak`,`web_id_pouet`,`group_brand_for`,`group_brand_by`,`section`\n" +
-	"FROM `files`\n" +
-	"WHERE `deletedby` IS NULL AND `deletedat` IS NOT NULL"
-
 const Table = `
 -- df2 v{{.VER}} Defacto2 MySQL {{.TABLE}} dump
 -- source:        https://defacto2.net/sql
@@ -1,9 +1,11 @@
 package update_test
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
 	"io"
 	"testing"
-	"time"
 
 	"github.com/Defacto2/df2/pkg/conf"
 	"github.com/Defacto2/df2/pkg/database"
@@ -11,29 +13,6 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func TestUpdate_Execute(t *testing.T) {
-	t.Parallel()
-	u := update.Update{}
-	i, err := u.Execute(nil)
-	assert.NotNil(t, err)
-	assert.Equal(t, int64(0), i)
-
-	db, err := database.Connect(conf.Defaults())
-	assert.Nil(t, err)
-	defer db.Close()
-	i, err = u.Execute(db)
-	assert.NotNil(t, err)
-	assert.Equal(t, int64(0), i)
-
-	u = update.Update{
-		Query: "UPDATE files SET updatedat=? WHERE id=?",
-		Args:  []any{time.Now(), 1},
-	}
-	i, err = u.Execute(db)
-	assert.Nil(t, err)
-	assert.Equal(t, int64(1), i)
-}
-
 func TestColumn_NamedTitles(t *testing.T) {
 	t.Parallel()
 	var c update.Column
@@ -47,6 +26,42 @@ func TestColumn_NamedTitles(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+// recorder is an executor that saves the queries and their arguments.
+type recorder struct {
+	query string
+	args  []any
+}
+
+func (r *recorder) Exec(query string, args ...any) (sql.Result, error) {
+	r.query, r.args = query, args
+	return driver.RowsAffected(0), nil
+}
+
+func (r *recorder) Query(string, ...any) (*sql.Rows, error) { return nil, sql.ErrNoRows }
+func (r *recorder) QueryRow(string, ...any) *sql.Row        { return nil }
+
+func (r *recorder) ExecContext(_ context.Context, query string, args ...any) (sql.Result, error) {
+	return r.Exec(query, args...)
+}
+
+func (r *recorder) QueryContext(_ context.Context, query string, args ...any) (*sql.Rows, error) {
+	return r.Query(query, args...)
+}
+
+func (r *recorder) QueryRowContext(_ context.Context, query string, args ...any) *sql.Row {
+	return r.QueryRow(query, args...)
+}
+
+func TestColumn_NamedTitles_Bind(t *testing.T) {
+	t.Parallel()
+	r := &recorder{}
+	err := update.Filename.NamedTitles(r, io.Discard)
+	assert.Nil(t, err)
+	assert.Contains(t, r.query, "record_title = ?")
+	assert.NotContains(t, r.query, string(update.Filename))
+	assert.Contains(t, r.args, string(update.Filename))
+}
+
 func TestDistinct(t *testing.T) {
 	t.Parallel()
 	s, err := update.Distinct(nil, "")
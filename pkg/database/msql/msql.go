@@ -156,7 +156,7 @@ func Connect(cfg conf.Config) (*sql.DB, error) {
 		Pass:      cfg.DBPass,
 		Database:  cfg.DBName,
 		Host:      cfg.DBHost,
-		Port:      cfg.DBPort,
+		Port:      cfg.Port(),
 		NoSSLMode: !cfg.IsProduction,
 		Timeout:   time.Duration(cfg.Timeout) * time.Second,
 	}
@@ -196,7 +196,7 @@ func ConnDebug(cfg conf.Config) (string, error) {
 		if strings.Contains(err.Error(), "connect: connection refused") {
 			return fmt.Sprintf("%s '%v' %s",
 				color.Danger.Sprint("database server"),
-				color.Primary.Sprintf("tcp(%s:%d)", cfg.DBHost, cfg.DBPort),
+				color.Primary.Sprintf("tcp(%s:%d)", cfg.DBHost, cfg.Port()),
 				color.Danger.Sprint("is either down or the port is blocked")), nil
 		}
 		return color.Danger.Sprint(err), nil
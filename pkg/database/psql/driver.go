@@ -0,0 +1,150 @@
+package psql
+
+// driver.go wraps the lib/pq driver so the MySQL flavoured SQL statements
+// used by df2 can run unmodified against a Postgres server.
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// DriverName of the database.
+// It is a wrapper of the lib/pq "postgres" driver that rebinds queries.
+const DriverName = "df2-postgres"
+
+//nolint:gochecknoinits
+func init() {
+	sql.Register(DriverName, &Driver{})
+}
+
+// Driver is a Postgres driver that rebinds MySQL syntax queries.
+type Driver struct {
+	open func(name string) (driver.Conn, error)
+}
+
+// NewDriver returns a Driver that rebinds the queries of the connections returned by open,
+// instead of the connections of the lib/pq driver.
+// It allows the Postgres flavoured queries to be tested without a server.
+func NewDriver(open func(name string) (driver.Conn, error)) *Driver {
+	return &Driver{open: open}
+}
+
+// Open returns a new connection to the Postgres database.
+func (d *Driver) Open(name string) (driver.Conn, error) {
+	open := pq.Open
+	if d.open != nil {
+		open = d.open
+	}
+	c, err := open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{c}, nil
+}
+
+// Rebind converts a MySQL syntax SQL query to use Postgres syntax.
+// The ? placeholders are replaced with ordinal $1, $2... markers
+// and the `backtick` quoted identifiers are replaced with "double quotes".
+// The content of 'single quoted' string literals and "double quoted"
+// identifiers is left untouched, so Postgres queries are returned as is.
+func Rebind(query string) string {
+	if !strings.ContainsAny(query, "?`") {
+		return query
+	}
+	var b strings.Builder
+	b.Grow(len(query) + 8) //nolint:gomnd
+	n, literal, ident := 0, false, false
+	for _, r := range query {
+		switch {
+		case literal:
+			if r == '\'' {
+				literal = false
+			}
+			b.WriteRune(r)
+		case ident:
+			if r == '"' {
+				ident = false
+			}
+			b.WriteRune(r)
+		case r == '\'':
+			literal = true
+			b.WriteRune(r)
+		case r == '"':
+			ident = true
+			b.WriteRune(r)
+		case r == '`':
+			b.WriteRune('"')
+		case r == '?':
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// conn is a connection to the Postgres server that rebinds all queries.
+type conn struct {
+	driver.Conn
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if pc, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return pc.PrepareContext(ctx, Rebind(query))
+	}
+	return c.Conn.Prepare(Rebind(query))
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if bt, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return bt.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin() //nolint:staticcheck
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	qc, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return qc.QueryContext(ctx, Rebind(query), args)
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	ec, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return ec.ExecContext(ctx, Rebind(query), args)
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if sr, ok := c.Conn.(driver.SessionResetter); ok {
+		return sr.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Defacto2/df2/pkg/database/internal/schema"
+	"github.com/gookit/color"
+)
+
+// MigrateUp applies the pending schema migrations to the database.
+// Steps limits the number of migrations to apply, a zero value applies them all.
+func MigrateUp(db *sql.DB, w io.Writer, steps int) error {
+	if db == nil {
+		return ErrDB
+	}
+	if w == nil {
+		w = io.Discard
+	}
+	i, err := schema.Up(context.Background(), db, w, steps)
+	if err != nil {
+		return fmt.Errorf("migrate up: %w", err)
+	}
+	if i == 0 {
+		fmt.Fprintln(w, "the database schema is up to date")
+		return nil
+	}
+	fmt.Fprintf(w, "applied %d schema migrations\n", i)
+	return nil
+}
+
+// MigrateDown reverts the applied schema migrations of the database.
+// Steps limits the number of migrations to revert, a zero value reverts only the last migration.
+func MigrateDown(db *sql.DB, w io.Writer, steps int) error {
+	if db == nil {
+		return ErrDB
+	}
+	if w == nil {
+		w = io.Discard
+	}
+	i, err := schema.Down(context.Background(), db, w, steps)
+	if err != nil {
+		return fmt.Errorf("migrate down: %w", err)
+	}
+	if i == 0 {
+		fmt.Fprintln(w, "there are no schema migrations to revert")
+		return nil
+	}
+	fmt.Fprintf(w, "reverted %d schema migrations\n", i)
+	return nil
+}
+
+// MigrateStatus lists the schema migrations and when they were applied to the database.
+func MigrateStatus(db *sql.DB, w io.Writer) error {
+	if db == nil {
+		return ErrDB
+	}
+	if w == nil {
+		w = io.Discard
+	}
+	list, err := schema.List(context.Background(), db)
+	if err != nil {
+		return fmt.Errorf("migrate status: %w", err)
+	}
+	const padding = 3
+	buf := strings.Builder{}
+	tw := tabwriter.NewWriter(&buf, 0, 0, padding, ' ', 0)
+	fmt.Fprintln(tw, "Version\tName\tApplied\t")
+	for _, s := range list {
+		applied := color.Warn.Sprint("pending")
+		if !s.Applied.IsZero() {
+			applied = s.Applied.Format("2006 Jan 2, 15:04")
+		}
+		fmt.Fprintf(tw, "%04d\t%s\t%s\t\n", s.Version, s.Name, applied)
+	}
+	if err = tw.Flush(); err != nil {
+		return fmt.Errorf("migrate status flush tab writer: %w", err)
+	}
+	fmt.Fprint(w, buf.String())
+	return nil
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+
+	"github.com/Defacto2/df2/pkg/database/internal/dialect"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// Executor runs queries using either a database connection or a transaction.
+type Executor = dialect.Executor
+
+// Tx is a database transaction.
+// A dry run transaction prints the before and after values of every
+// changed column and is always rolled back by End.
+type Tx = dialect.Tx
+
+// Change is the before and after value of a column in a changed row.
+type Change = dialect.Change
+
+// Begin starts a transaction, the transaction must be closed using End or Cancel.
+// When dry is true, the changes are written to w and the transaction is never committed.
+func Begin(db *sql.DB, w io.Writer, dry bool) (*Tx, error) {
+	if db == nil {
+		return nil, ErrDB
+	}
+	return dialect.Begin(context.Background(), db, w, dry)
+}
+
+// UpdateFiles sets the columns of the file records matching the query mods
+// and returns the number of rows affected.
+func UpdateFiles(exec Executor, cols map[string]any, mods ...qm.QueryMod) (int64, error) {
+	i, err := dialect.Update(context.Background(), exec, cols, mods...)
+	if err != nil {
+		return 0, fmt.Errorf("update files: %w", err)
+	}
+	return i, nil
+}
+
+// Delete removes the rows of the table matching the query mods
+// and returns the number of rows affected.
+func Delete(exec Executor, t Table, mods ...qm.QueryMod) (int64, error) {
+	i, err := dialect.Delete(context.Background(), exec, t.String(), mods...)
+	if err != nil {
+		return 0, fmt.Errorf("delete %s: %w", t, err)
+	}
+	return i, nil
+}
+
+// Insert adds a row with the column values to the table
+// and returns the id of the new row.
+func Insert(exec Executor, t Table, cols map[string]any) (int64, error) {
+	id, err := dialect.Insert(context.Background(), exec, t.String(), cols)
+	if err != nil {
+		return 0, fmt.Errorf("insert %s: %w", t, err)
+	}
+	return id, nil
+}
@@ -18,12 +18,10 @@ import (
 	"github.com/Defacto2/df2/pkg/demozoo/internal/prods"
 	"github.com/Defacto2/df2/pkg/demozoo/internal/releaser"
 	"github.com/Defacto2/df2/pkg/demozoo/internal/releases"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 )
 
-var (
-	ErrRequest = errors.New("unknown request value")
-	ErrValues  = errors.New("too few record values")
-)
+var ErrRequest = errors.New("unknown request value")
 
 // Product is a Demozoo production item.
 type Product struct {
@@ -148,65 +146,57 @@ func (m *WindowsProducts) Get(db *sql.DB, w io.Writer) error {
 }
 
 // Fix any Demozoo data import conflicts.
-func Fix(db *sql.DB, w io.Writer) error {
+// When dry is true, the changes are printed and then rolled back.
+func Fix(db *sql.DB, w io.Writer, dry bool) error {
 	if db == nil {
 		return database.ErrDB
 	}
 	if w == nil {
 		w = io.Discard
 	}
-	return fix.Configs(db, w)
+	tx, err := database.Begin(db, w, dry)
+	if err != nil {
+		return err
+	}
+	if err := fix.Configs(tx, w); err != nil {
+		return tx.Cancel(err)
+	}
+	return tx.End()
 }
 
 // NewRecord initialises a new file record.
-func NewRecord(count int, values []sql.RawBytes) (Record, error) {
-	const sep, want = ",", 21
-	if l := len(values); l < want {
-		return Record{}, fmt.Errorf("new records = %d, want %d: %w", l, want, ErrValues)
-	}
-	const id, uuid, createdat, filename, filesize, webiddemozoo = 0, 1, 3, 4, 5, 6
-	const filezipcontent, updatedat, platform, fileintegritystrong, fileintegrityweak = 7, 8, 9, 10, 11
-	const webidpouet, groupbrandfor, groupbrandby, recordtitle, section = 12, 13, 14, 15, 16
-	const creditillustration, creditaudio, creditprogram, credittext = 17, 18, 19, 20
+func NewRecord(count int, f database.File) Record {
+	const sep = ","
 	r := Record{
-		Count: count,
-		ID:    string(values[id]),
-		UUID:  string(values[uuid]),
-		// deletedat placeholder
-		Filename: string(values[filename]),
-		Filesize: string(values[filesize]),
-		// web_id_demozoo placeholder
-		FileZipContent: string(values[filezipcontent]),
-		Platform:       string(values[platform]),
-		Sum384:         string(values[fileintegritystrong]),
-		SumMD5:         string(values[fileintegrityweak]),
-		// web_id_pouet placeholder
-		GroupFor:    string(values[groupbrandfor]),
-		GroupBy:     string(values[groupbrandby]),
-		Title:       string(values[recordtitle]),
-		Section:     string(values[section]),
-		CreditArt:   strings.Split(string(values[creditillustration]), sep),
-		CreditAudio: strings.Split(string(values[creditaudio]), sep),
-		CreditCode:  strings.Split(string(values[creditprogram]), sep),
-		CreditText:  strings.Split(string(values[credittext]), sep),
+		Count:          count,
+		ID:             strconv.FormatInt(f.ID, 10),
+		UUID:           f.UUID.String,
+		Filename:       f.Filename.String,
+		FileZipContent: f.FileZipContent.String,
+		CreatedAt:      database.Timestamp(f.Createdat),
+		UpdatedAt:      database.Timestamp(f.Updatedat),
+		Platform:       f.Platform.String,
+		Sum384:         f.FileIntegrityStrong.String,
+		SumMD5:         f.FileIntegrityWeak.String,
+		GroupFor:       f.GroupBrandFor.String,
+		GroupBy:        f.GroupBrandBy.String,
+		Title:          f.RecordTitle.String,
+		Section:        f.Section.String,
+		CreditArt:      strings.Split(f.CreditIllustration.String, sep),
+		CreditAudio:    strings.Split(f.CreditAudio.String, sep),
+		CreditCode:     strings.Split(f.CreditProgram.String, sep),
+		CreditText:     strings.Split(f.CreditText.String, sep),
 	}
-	ca, err := database.DateTime(values[createdat])
-	if err != nil {
-		return Record{}, fmt.Errorf("create date for new record %d: %w", count, err)
+	if f.Filesize.Valid {
+		r.Filesize = strconv.FormatInt(f.Filesize.Int64, 10)
 	}
-	r.CreatedAt = ca
-	ua, err := database.DateTime(values[updatedat])
-	if err != nil {
-		return Record{}, fmt.Errorf("update date for new record %d: %w", count, err)
-	}
-	r.UpdatedAt = ua
-	if i, err := strconv.Atoi(string(values[webiddemozoo])); err == nil {
-		r.WebIDDemozoo = uint(i)
+	if f.WebIDDemozoo.Valid && f.WebIDDemozoo.Int64 > 0 {
+		r.WebIDDemozoo = uint(f.WebIDDemozoo.Int64)
 	}
-	if i, err := strconv.Atoi(string(values[webidpouet])); err == nil {
-		r.WebIDPouet = uint(i)
+	if f.WebIDPouet.Valid && f.WebIDPouet.Int64 > 0 {
+		r.WebIDPouet = uint(f.WebIDPouet.Int64)
 	}
-	return r, nil
+	return r
 }
 
 type request uint
@@ -246,34 +236,22 @@ func refresh(db *sql.DB, w io.Writer, r request) error { //nolint:cyclop
 	if err := Counter(db, w, r); err != nil {
 		return err
 	}
-	rows, err := db.Query(selectByID(""))
+	files, err := database.Select(db, selectByID("")...)
 	if err != nil {
 		return fmt.Errorf("meta query: %w", err)
-	} else if rows.Err() != nil {
-		return fmt.Errorf("meta rows: %w", rows.Err())
-	}
-	defer rows.Close()
-	cols, err := rows.Columns()
-	if err != nil {
-		return fmt.Errorf("meta columns: %w", err)
-	}
-	values := make([]sql.RawBytes, len(cols))
-	args := make([]any, len(values))
-	for i := range values {
-		args[i] = &values[i]
 	}
 	// fetch the rows
 	var st Stat
 	switch r {
 	case meta:
-		for rows.Next() {
-			if err := st.NextRefresh(db, w, Records{rows, args, values}); err != nil {
+		for _, f := range files {
+			if err := st.NextRefresh(db, w, f); err != nil {
 				fmt.Fprintf(w, "meta rows: %s\n", err)
 			}
 		}
 	case pouet:
-		for rows.Next() {
-			if err := st.NextPouet(db, w, Records{rows, args, values}); err != nil {
+		for _, f := range files {
+			if err := st.NextPouet(db, w, f); err != nil {
 				fmt.Fprintf(w, "meta rows: %s\n", err)
 			}
 		}
@@ -290,17 +268,17 @@ func Counter(db *sql.DB, w io.Writer, r request) error {
 	if w == nil {
 		w = io.Discard
 	}
-	cnt := 0
-	var stmt string
+	var where qm.QueryMod
 	switch r {
 	case meta:
-		stmt = countDemozoo()
+		where = countDemozoo()
 	case pouet:
-		stmt = countPouet()
+		where = countPouet()
 	default:
 		return ErrRequest
 	}
-	if err := db.QueryRow(stmt).Scan(&cnt); err != nil {
+	cnt, err := database.Count(db, where)
+	if err != nil {
 		return fmt.Errorf("counter row query: %w", err)
 	}
 	fmt.Fprintf(w, "There are %d records with %s links\n", cnt, r)
@@ -2,7 +2,6 @@ package demozoo_test
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"io"
 	"os"
@@ -18,30 +17,31 @@ import (
 	"github.com/Defacto2/df2/pkg/demozoo/internal/prods"
 	"github.com/gookit/color"
 	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
 	"go.uber.org/zap"
 )
 
 func TestStat_NextRefresh(t *testing.T) {
 	t.Parallel()
 	s := demozoo.Stat{}
-	err := s.NextRefresh(nil, nil, demozoo.Records{})
+	err := s.NextRefresh(nil, nil, database.File{})
 	assert.NotNil(t, err)
 	db, err := database.Connect(conf.Defaults())
 	assert.Nil(t, err)
 	defer db.Close()
-	err = s.NextRefresh(db, io.Discard, demozoo.Records{})
+	err = s.NextRefresh(db, io.Discard, database.File{})
 	assert.NotNil(t, err)
 }
 
 func TestStat_NewPouet(t *testing.T) {
 	t.Parallel()
 	s := demozoo.Stat{}
-	err := s.NextPouet(nil, nil, demozoo.Records{})
+	err := s.NextPouet(nil, nil, database.File{})
 	assert.NotNil(t, err)
 	db, err := database.Connect(conf.Defaults())
 	assert.Nil(t, err)
 	defer db.Close()
-	err = s.NextPouet(db, io.Discard, demozoo.Records{})
+	err = s.NextPouet(db, io.Discard, database.File{})
 	assert.NotNil(t, err)
 }
 
@@ -115,7 +115,7 @@ func TestWindowsProducts_Get(t *testing.T) {
 
 func TestFix(t *testing.T) {
 	t.Parallel()
-	err := demozoo.Fix(nil, nil)
+	err := demozoo.Fix(nil, nil, false)
 	assert.NotNil(t, err)
 }
 
@@ -148,44 +148,36 @@ func TestRequest_Query(t *testing.T) {
 	assert.Nil(t, err, "record by uuid has a Demozoo association")
 }
 
-func values() []sql.RawBytes {
-	v := []sql.RawBytes{
-		[]byte("1"), // id
-		[]byte("41224f41-0262-4750-956a-893fd7f0f082"), // uuid
-		[]byte(""),             // deletedat
-		[]byte(""),             // createdat
-		[]byte("somefile.zip"), // filename
-		[]byte("123456789"),    // filesize
-		[]byte(""),             // demozoo id
-		[]byte("some.jpg\nsome.nfo\nfile_id.diz"), // file_zip_content
-		[]byte(""),    // updatedat
-		[]byte("dos"), // platform
-		[]byte("6b447ced6d6f919a4b18a8b850442862908cd3eb35cfe1fc01c01b5" +
-			"aea6b25c53414fcbba989460b5423b6a29a429078"), // hash strong
-		[]byte("3327792e5825386498ac00cd960a6b17"), // hash weak
-		[]byte(""),                  // pouet id
-		[]byte("Test Group"),        // group for
-		[]byte("Fake Group"),        // group by
-		[]byte("A test production"), // title
-		[]byte("releaseadvert"),     // section
-		[]byte("Jack,Jane,Jules"),   // art
-		[]byte("Sam,Sock"),          // audio
-		[]byte("Joe Blogs,Doe"),     // code
-		[]byte("Lisa,Linus"),        // text
+func file() database.File {
+	return database.File{
+		ID:             1,
+		UUID:           null.StringFrom("41224f41-0262-4750-956a-893fd7f0f082"),
+		Filename:       null.StringFrom("somefile.zip"),
+		Filesize:       null.Int64From(123456789),
+		FileZipContent: null.StringFrom("some.jpg\nsome.nfo\nfile_id.diz"),
+		Platform:       null.StringFrom("dos"),
+		FileIntegrityStrong: null.StringFrom("6b447ced6d6f919a4b18a8b850442862908cd3eb35cfe1fc01c01b5" +
+			"aea6b25c53414fcbba989460b5423b6a29a429078"),
+		FileIntegrityWeak:  null.StringFrom("3327792e5825386498ac00cd960a6b17"),
+		GroupBrandFor:      null.StringFrom("Test Group"),
+		GroupBrandBy:       null.StringFrom("Fake Group"),
+		RecordTitle:        null.StringFrom("A test production"),
+		Section:            null.StringFrom("releaseadvert"),
+		CreditIllustration: null.StringFrom("Jack,Jane,Jules"),
+		CreditAudio:        null.StringFrom("Sam,Sock"),
+		CreditProgram:      null.StringFrom("Joe Blogs,Doe"),
+		CreditText:         null.StringFrom("Lisa,Linus"),
 	}
-	return v
 }
 
 func TestNewRecord(t *testing.T) {
 	t.Parallel()
 	type args struct {
-		c      int
-		values []sql.RawBytes
+		c int
+		f database.File
 	}
-	short := values()
-	short = short[:len(short)-1]
-	pouet := values()
-	pouet[12] = []byte("50")
+	pouet := file()
+	pouet.WebIDPouet = null.Int64From(50)
 	tests := []struct {
 		name         string
 		args         args
@@ -194,21 +186,16 @@ func TestNewRecord(t *testing.T) {
 		wantPlatform string
 		wantText     []string
 		wantPoeut    uint
-		wantErr      bool
 	}{
-		{"empty", args{0, nil}, "", "", "", nil, 0, true},
-		{"short", args{0, short}, "", "", "", nil, 0, true},
-		{"ok", args{0, values()}, "1", "somefile.zip", "dos", []string{"Lisa", "Linus"}, 0, false},
-		{"pouet", args{0, pouet}, "1", "somefile.zip", "dos", []string{"Lisa", "Linus"}, 50, false},
+		{"empty", args{0, database.File{}}, "0", "", "", []string{""}, 0},
+		{"ok", args{0, file()}, "1", "somefile.zip", "dos", []string{"Lisa", "Linus"}, 0},
+		{"pouet", args{0, pouet}, "1", "somefile.zip", "dos", []string{"Lisa", "Linus"}, 50},
 	}
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			gotR, gotErr := demozoo.NewRecord(tt.args.c, tt.args.values)
-			if (gotErr != nil) != tt.wantErr {
-				t.Errorf("newRecord() error = %v, wantErr %v", gotErr, tt.wantErr)
-			}
+			gotR := demozoo.NewRecord(tt.args.c, tt.args.f)
 			if gotR.ID != tt.wantID {
 				t.Errorf("newRecord().ID = %v, want %v", gotR.ID, tt.wantID)
 			}
@@ -326,89 +313,6 @@ type fields struct {
 	CreditAudio    []string
 }
 
-func TestSQL(t *testing.T) { //nolint:funlen
-	t.Parallel()
-	const where string = " WHERE id=?"
-	now := time.Now()
-	tests := []struct {
-		name   string
-		fields fields
-		want   string
-		want1  int
-	}{
-		{name: "empty", fields: fields{}, want: "", want1: 0},
-		{
-			"filename",
-			fields{ID: "1", Filename: "hi.txt"},
-			"UPDATE files SET filename=?,web_id_demozoo=?,updatedat=?,updatedby=?" + where, 5,
-		},
-		{
-			"filesize",
-			fields{ID: "1", Filesize: "54321"},
-			"UPDATE files SET filesize=?,web_id_demozoo=?,updatedat=?,updatedby=?" + where, 5,
-		},
-		{
-			"zip content",
-			fields{ID: "1", FileZipContent: "HI.TXT\nHI.EXE"},
-			"UPDATE files SET file_zip_content=?,web_id_demozoo=?,updatedat=?,updatedby=?" + where, 5,
-		},
-		{
-			"md5",
-			fields{ID: "1", SumMD5: "md5placeholder"},
-			"UPDATE files SET file_integrity_weak=?,web_id_demozoo=?,updatedat=?,updatedby=?" + where, 5,
-		},
-		{
-			"sha386",
-			fields{ID: "1", Sum384: "shaplaceholder"},
-			"UPDATE files SET file_integrity_strong=?,web_id_demozoo=?,updatedat=?,updatedby=?" + where, 5,
-		},
-		{
-			"lastmod",
-			fields{ID: "1", LastMod: now},
-			"UPDATE files SET file_last_modified=?,web_id_demozoo=?,updatedat=?,updatedby=?" + where, 5,
-		},
-		{
-			"a file",
-			fields{ID: "1", Filename: "some.gif", Filesize: "5012352"},
-			"UPDATE files SET filename=?,filesize=?,web_id_demozoo=?,updatedat=?,updatedby=?" + where, 6,
-		},
-	}
-	for _, tt := range tests {
-		tt := tt
-		t.Run(tt.name, func(t *testing.T) {
-			t.Parallel()
-			r := demozoo.Record{
-				Count:          tt.fields.count,
-				FilePath:       tt.fields.FilePath,
-				ID:             tt.fields.ID,
-				UUID:           tt.fields.UUID,
-				WebIDDemozoo:   tt.fields.WebIDDemozoo,
-				WebIDPouet:     tt.fields.WebIDPouet,
-				Filename:       tt.fields.Filename,
-				Filesize:       tt.fields.Filesize,
-				FileZipContent: tt.fields.FileZipContent,
-				CreatedAt:      tt.fields.CreatedAt,
-				UpdatedAt:      tt.fields.UpdatedAt,
-				SumMD5:         tt.fields.SumMD5,
-				Sum384:         tt.fields.Sum384,
-				LastMod:        tt.fields.LastMod,
-				Readme:         tt.fields.Readme,
-				DOSeeBinary:    tt.fields.DOSeeBinary,
-				Platform:       tt.fields.Platform,
-				GroupFor:       tt.fields.GroupFor,
-				GroupBy:        tt.fields.GroupBy,
-			}
-			got, got1 := r.Stmt()
-			if got != tt.want {
-				t.Errorf("Stmt() got = %v, want %v", got, tt.want)
-			}
-			if !reflect.DeepEqual(len(got1), tt.want1) {
-				t.Errorf("Stmt() got1 = %v, want %v", len(got1), tt.want1)
-			}
-		})
-	}
-}
-
 func TestZipContent(t *testing.T) {
 	t.Parallel()
 	pwd, err := os.Getwd()
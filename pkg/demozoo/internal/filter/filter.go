@@ -16,6 +16,7 @@ import (
 	"github.com/Defacto2/df2/pkg/demozoo/internal/releases"
 	"github.com/Defacto2/df2/pkg/download"
 	"github.com/Defacto2/df2/pkg/str"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 )
 
 const (
@@ -214,10 +215,8 @@ func sync(db *sql.DB, w io.Writer, demozooID, recordID int) error {
 }
 
 func update(db *sql.DB, demozooID, recordID int) (int64, error) {
-	up := database.Update{}
-	up.Query = "UPDATE files SET web_id_demozoo=? WHERE `id` = ?"
-	up.Args = []any{demozooID, recordID}
-	count, err := database.Execute(db, up)
+	count, err := database.UpdateFiles(db, map[string]any{"web_id_demozoo": demozooID},
+		qm.Where("id = ?", recordID))
 	if err != nil {
 		return 0, fmt.Errorf("update installers: %w", err)
 	}
@@ -3,16 +3,16 @@
 package fix
 
 import (
-	"database/sql"
 	"fmt"
 	"io"
 
 	"github.com/Defacto2/df2/pkg/database"
 	"github.com/Defacto2/df2/pkg/str"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 )
 
 // Fix repairs imported Demozoo data conflicts.
-func Configs(db *sql.DB, w io.Writer) error {
+func Configs(db database.Executor, w io.Writer) error {
 	if db == nil {
 		return database.ErrDB
 	}
@@ -39,32 +39,32 @@ func Configs(db *sql.DB, w io.Writer) error {
 	return nil
 }
 
-func updateApps(db *sql.DB) (int64, error) {
+func updateApps(db database.Executor) (int64, error) {
 	if db == nil {
 		return 0, database.ErrDB
 	}
-	app := database.Update{}
-	app.Query = "UPDATE files SET section=? WHERE `section` = \"releaseadvert\" " +
-		"AND `web_id_demozoo` IS NOT NULL AND `record_title` LIKE '%application%'"
-	app.Args = []any{"groupapplication"}
-	count, err := database.Execute(db, app)
+	count, err := move(db, "groupapplication", "%application%")
 	if err != nil {
 		return 0, fmt.Errorf("update applications: %w", err)
 	}
 	return count, nil
 }
 
-func updateInstallers(db *sql.DB) (int64, error) {
+func updateInstallers(db database.Executor) (int64, error) {
 	if db == nil {
 		return 0, database.ErrDB
 	}
-	inst := database.Update{}
-	inst.Query = "UPDATE files SET section=? WHERE `section` = \"releaseadvert\" " +
-		"AND `web_id_demozoo` IS NOT NULL AND `record_title` LIKE '%installer%'"
-	inst.Args = []any{"releaseinstall"}
-	count, err := database.Execute(db, inst)
+	count, err := move(db, "releaseinstall", "%installer%")
 	if err != nil {
 		return 0, fmt.Errorf("update installers: %w", err)
 	}
 	return count, nil
 }
+
+// move the Demozoo release advert records with titles matching like to the section.
+func move(db database.Executor, section, like string) (int64, error) {
+	return database.UpdateFiles(db, map[string]any{"section": section},
+		qm.Where("section = ?", "releaseadvert"),
+		qm.And("web_id_demozoo IS NOT NULL"),
+		qm.And("record_title LIKE ?", like))
+}
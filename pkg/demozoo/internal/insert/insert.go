@@ -2,7 +2,6 @@
 package insert
 
 import (
-	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -18,14 +17,11 @@ import (
 
 var (
 	ErrID      = errors.New("production id must be 1 or higher")
-	ErrNoQuery = errors.New("query statement is empty")
+	ErrNoQuery = errors.New("production has no values to insert")
 	ErrProd    = errors.New("productions pointer cannot be nil")
 )
 
-const (
-	sep     = ","
-	timeout = 10 * time.Second
-)
+const sep = ","
 
 // Record contains the values for a new Demozoo releaser production to be added to the database file table.
 type Record struct {
@@ -46,31 +42,25 @@ type Record struct {
 	IssuedDay    uint8
 }
 
-// Insert the new Demozoo releaser production into the database.
-func (r *Record) Insert(db *sql.DB) (sql.Result, error) {
-	if db == nil {
-		return nil, database.ErrDB
+// Insert the new Demozoo releaser production into the database and return the id of the new record.
+// The insert is saved to the audit log.
+func (r *Record) Insert(exec database.Executor) (int64, error) {
+	if exec == nil {
+		return 0, database.ErrDB
 	}
 	id, err := uuid.NewRandom()
 	if err != nil {
-		return nil, fmt.Errorf("insert uuid: %w", err)
-	}
-	query, args, err := r.stmt(id)
-	if err != nil {
-		return nil, fmt.Errorf("insert stmt: %w", err)
+		return 0, fmt.Errorf("insert uuid: %w", err)
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-	stmt, err := db.PrepareContext(ctx, query)
+	cols, err := r.cols(id)
 	if err != nil {
-		return nil, fmt.Errorf("insert prepare: %w", err)
+		return 0, fmt.Errorf("insert cols: %w", err)
 	}
-	defer stmt.Close()
-	res, err := stmt.ExecContext(ctx, args...)
+	newID, err := database.Insert(exec, database.Files, cols)
 	if err != nil {
-		return nil, fmt.Errorf("insert exec: %w", err)
+		return 0, fmt.Errorf("insert: %w", err)
 	}
-	return res, nil
+	return newID, nil
 }
 
 // Prods adds the Demozoo releasers productions to the database.
@@ -96,11 +86,7 @@ func Prods(db *sql.DB, w io.Writer, prods *releases.Productions) error {
 		if reflect.DeepEqual(rec, Record{}) {
 			continue
 		}
-		res, err := rec.Insert(db)
-		if err != nil {
-			return err
-		}
-		newID, err := res.LastInsertId()
+		newID, err := rec.Insert(db)
 		if err != nil {
 			return err
 		}
@@ -177,27 +163,25 @@ func Prod(db *sql.DB, w io.Writer, prod releases.ProductionV1) (Record, error) {
 	}, nil
 }
 
-// stmt creates the SQL prepare statement and values to insert a new Demozoo releaser production.
-func (r *Record) stmt(id uuid.UUID) (string, []any, error) {
+// cols returns the columns and values to insert a new Demozoo releaser production.
+func (r *Record) cols(id uuid.UUID) (map[string]any, error) {
 	set, args := inserts(r)
 	if len(set) == 0 {
-		return "", args, ErrNoQuery
+		return nil, ErrNoQuery
+	}
+	cols := make(map[string]any, len(set)+4)
+	for i, s := range set {
+		cols[s] = args[i]
 	}
 	// create an uuid that's required by the file table.
-	set = append(set, "uuid")
-	args = append(args, id.String())
+	cols["uuid"] = id.String()
 	// create time values for the new record.
 	// setting createdat, updatedat and deletedat tells the webapp that the record is new, unmodifed and not public.
 	now := time.Now()
-	set = append(set, "createdat")
-	args = append(args, []any{now}...)
-	set = append(set, "updatedat")
-	args = append(args, []any{now}...)
-	set = append(set, "deletedat")
-	args = append(args, []any{now}...)
-	vals := strings.Split(strings.TrimSpace(strings.Repeat("? ", len(args))), " ")
-	query := "INSERT INTO files (" + strings.Join(set, sep) + ") VALUES (" + strings.Join(vals, sep) + ")"
-	return query, args, nil
+	cols["createdat"] = now
+	cols["updatedat"] = now
+	cols["deletedat"] = now
+	return cols, nil
 }
 
 func inserts(r *Record) ([]string, []any) {
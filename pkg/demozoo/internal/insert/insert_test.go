@@ -17,22 +17,20 @@ import (
 func TestRecord_Insert(t *testing.T) {
 	t.Parallel()
 	r := insert.Record{}
-	res, err := r.Insert(nil)
+	id, err := r.Insert(nil)
 	assert.NotNil(t, err)
-	assert.Nil(t, res)
+	assert.Zero(t, id)
 
 	db, err := database.Connect(conf.Defaults())
 	assert.Nil(t, err)
 	defer db.Close()
 	r = insert.Record{}
-	res, err = r.Insert(db)
-	assert.NotNil(t, err)
-	assert.Nil(t, res)
+	id, err = r.Insert(db)
+	assert.ErrorIs(t, err, insert.ErrNoQuery)
+	assert.Zero(t, id)
 
 	r = insert.Record{Title: "placeholder"}
-	res, err = r.Insert(db)
-	assert.Nil(t, err)
-	id, err := res.LastInsertId()
+	id, err = r.Insert(db)
 	assert.Nil(t, err)
 	assert.NotEqual(t, 0, id)
 	// remove newly inserted record
@@ -61,13 +61,10 @@ func (r Request) Queries(db *sql.DB, w io.Writer) error { //nolint:cyclop,funlen
 	if w == nil {
 		w = io.Discard
 	}
-	stmt, start := selectByID(r.ByID), time.Now()
-	values, args, rows, err := values(db, stmt)
+	start := time.Now()
+	files, err := database.Select(db, selectByID(r.ByID)...)
 	if err != nil {
-		return err
-	}
-	if rows.Err() != nil {
-		return err
+		return fmt.Errorf("queries query: %w", err)
 	}
 	dir, err := directories.Init(r.Config, false)
 	if err != nil {
@@ -75,31 +72,14 @@ func (r Request) Queries(db *sql.DB, w io.Writer) error { //nolint:cyclop,funlen
 	}
 	storage := dir.UUID
 	st := Stat{}
-	if err = st.sumTotal(Records{rows, args, values}, r); err != nil {
-		return fmt.Errorf("queries sumtotal: %w", err)
-	}
+	st.sumTotal(files, r)
 	str.Total(w, st.Total, "records")
-	rows, err = db.Query(stmt)
-	if err != nil {
-		return fmt.Errorf("queries query 2: %w", err)
-	}
-	if rows.Err() != nil {
-		return fmt.Errorf("queries rows 2: %w", rows.Err())
-	}
-	defer rows.Close()
-	for rows.Next() {
+	for _, f := range files {
 		st.Fetched++
-		if skip, err := st.nextResult(Records{rows, args, values}, r); err != nil {
-			r.Logger.Errorf("queries nextrow: %s", err)
-			continue
-		} else if skip {
-			continue
-		}
-		rec, err := NewRecord(st.Count, values)
-		if err != nil {
-			r.Logger.Errorf("queries new: %s", err)
+		if skip := st.nextResult(f, r); skip {
 			continue
 		}
+		rec := NewRecord(st.Count, f)
 		logger.PrintfCR(w, rec.String())
 		if update := rec.check(w); !update {
 			continue
@@ -132,29 +112,6 @@ func (r Request) Queries(db *sql.DB, w io.Writer) error { //nolint:cyclop,funlen
 	return nil
 }
 
-func values(db *sql.DB, stmt string) ([]sql.RawBytes, []any, *sql.Rows, error) {
-	if db == nil {
-		return nil, nil, nil, database.ErrDB
-	}
-	rows, err := db.Query(stmt)
-	if err != nil {
-		return nil, nil, nil, fmt.Errorf("queries query 1: %w", err)
-	} else if err = rows.Err(); err != nil {
-		return nil, nil, nil, fmt.Errorf("queries rows 1: %w", rows.Err())
-	}
-	defer rows.Close()
-	cols, err := rows.Columns()
-	if err != nil {
-		return nil, nil, nil, fmt.Errorf("queries columns: %w", err)
-	}
-	values := make([]sql.RawBytes, len(cols))
-	args := make([]any, len(values))
-	for i := range values {
-		args[i] = &values[i]
-	}
-	return values, args, rows, nil
-}
-
 // Skip the Request.
 func (r Request) skip() bool {
 	if !r.All && !r.Refresh && !r.Overwrite {
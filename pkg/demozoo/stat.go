@@ -25,7 +25,7 @@ var (
 	ErrDownload  = errors.New("no suitable downloads found")
 	ErrProdAPI   = errors.New("productions api pointer cannot be nil")
 	ErrRecord    = errors.New("pointer to the record cannot be nil")
-	ErrRecords   = errors.New("pointer to the rows of records cannot be nil")
+	ErrEmpty     = errors.New("file record is empty and cannot be used")
 	ErrUUID      = errors.New("uuid is empty and cannot be used")
 )
 
@@ -57,45 +57,31 @@ func (st *Stat) FileExist(r *Record) (bool, error) {
 	return true, nil
 }
 
-// Records contain more than one Record.
-type Records struct {
-	Rows   *sql.Rows      // Rows is the result of a query.
-	Args   []any          // Args are data types.
-	Values []sql.RawBytes // Values are byte encoded values.
-}
-
-// NextRefresh iterates over the Records to update sync their Demozoo data to the database.
-func (st *Stat) NextRefresh(db *sql.DB, w io.Writer, rec Records) error {
+// NextRefresh fetches the Demozoo production of the file record and saves any changes to the database.
+func (st *Stat) NextRefresh(db *sql.DB, w io.Writer, f database.File) error {
 	if db == nil {
 		return database.ErrDB
 	}
-	if rec.Rows == nil {
-		return ErrRecords
+	if f.ID == 0 {
+		return ErrEmpty
 	}
 	if w == nil {
 		w = io.Discard
 	}
-	if err := rec.Rows.Scan(rec.Args...); err != nil {
-		return fmt.Errorf("next scan: %w", err)
-	}
 	st.Count++
-	r, err := NewRecord(st.Count, rec.Values)
-	if err != nil {
-		return fmt.Errorf("next record 1: %w", err)
-	}
+	r := NewRecord(st.Count, f)
 	logger.PrintfCR(w, r.String())
-	var f Product
-	err = f.Get(r.WebIDDemozoo)
-	if err != nil {
+	var p Product
+	if err := p.Get(r.WebIDDemozoo); err != nil {
 		return fmt.Errorf("next fetch: %w", err)
 	}
-	code, status, api := f.Code, f.Status, f.API
+	code, status, api := p.Code, p.Status, p.API
 	if ok, err := r.confirm(db, w, code, status); err != nil {
 		return fmt.Errorf("next confirm: %w", err)
 	} else if !ok {
 		return nil
 	}
-	if err = r.pouet(w, &api); err != nil {
+	if err := r.pouet(w, &api); err != nil {
 		return fmt.Errorf("next pouet: %w", err)
 	}
 	if err := r.title(w, &api); err != nil {
@@ -105,15 +91,11 @@ func (st *Stat) NextRefresh(db *sql.DB, w io.Writer, rec Records) error {
 	if err := r.authors(w, &a); err != nil {
 		return err
 	}
-	nr, err := NewRecord(st.Count, rec.Values)
-	if err != nil {
-		return fmt.Errorf("next record 2: %w", err)
-	}
-	if reflect.DeepEqual(nr, r) {
+	if nr := NewRecord(st.Count, f); reflect.DeepEqual(nr, r) {
 		fmt.Fprintf(w, "• skipped %v", str.Y())
 		return nil
 	}
-	if err = r.Save(db); err != nil {
+	if err := r.Save(db); err != nil {
 		fmt.Fprintf(w, "• saved %v ", str.X())
 		return fmt.Errorf("next save: %w", err)
 	}
@@ -121,52 +103,41 @@ func (st *Stat) NextRefresh(db *sql.DB, w io.Writer, rec Records) error {
 	return nil
 }
 
-// NextPouet iterates over the linked Demozoo records and sync any linked Pouet data to the local files table.
-func (st *Stat) NextPouet(db *sql.DB, w io.Writer, rec Records) error {
+// NextPouet syncs any linked Pouet data of the Demozoo linked file record to the local files table.
+func (st *Stat) NextPouet(db *sql.DB, w io.Writer, f database.File) error {
 	if db == nil {
 		return database.ErrDB
 	}
-	if rec.Rows == nil {
-		return ErrRecords
+	if f.ID == 0 {
+		return ErrEmpty
 	}
 	if w == nil {
 		w = io.Discard
 	}
-	if err := rec.Rows.Scan(rec.Args...); err != nil {
-		return fmt.Errorf("next scan: %w", err)
-	}
 	st.Count++
-	r, err := NewRecord(st.Count, rec.Values)
-	if err != nil {
-		return fmt.Errorf("next record 1: %w", err)
-	}
+	r := NewRecord(st.Count, f)
 	if r.WebIDPouet > 0 {
 		return nil
 	}
 	logger.PrintfCR(w, r.String())
-	f := Product{}
-	err = f.Get(r.WebIDDemozoo)
-	if err != nil {
+	p := Product{}
+	if err := p.Get(r.WebIDDemozoo); err != nil {
 		return fmt.Errorf("next fetch: %w", err)
 	}
-	code, status, api := f.Code, f.Status, f.API
+	code, status, api := p.Code, p.Status, p.API
 	if ok, err := r.confirm(db, w, code, status); err != nil {
 		return fmt.Errorf("next confirm: %w", err)
 	} else if !ok {
 		return nil
 	}
-	if err = r.pouet(w, &api); err != nil {
+	if err := r.pouet(w, &api); err != nil {
 		return fmt.Errorf("next refresh: %w", err)
 	}
-	nr, err := NewRecord(st.Count, rec.Values)
-	if err != nil {
-		return fmt.Errorf("next record 2: %w", err)
-	}
-	if reflect.DeepEqual(nr, r) {
+	if nr := NewRecord(st.Count, f); reflect.DeepEqual(nr, r) {
 		fmt.Fprintf(w, "• skipped %v", str.Y())
 		return nil
 	}
-	if err = r.Save(db); err != nil {
+	if err := r.Save(db); err != nil {
 		fmt.Fprintf(w, "• saved %v ", str.X())
 		return fmt.Errorf("next save: %w", err)
 	}
@@ -175,22 +146,12 @@ func (st *Stat) NextPouet(db *sql.DB, w io.Writer, rec Records) error {
 }
 
 // nextResult checks for the next new record.
-func (st *Stat) nextResult(rec Records, req Request) (bool, error) {
-	if rec.Rows == nil {
-		return false, ErrRecords
-	}
-	if err := rec.Rows.Scan(rec.Args...); err != nil {
-		return false, fmt.Errorf("next result rows scan: %w", err)
-	}
-	n, err := database.IsDemozoo(rec.Values)
-	if err != nil {
-		return false, err
-	}
-	if !n && req.skip() {
-		return true, nil
+func (st *Stat) nextResult(f database.File, req Request) bool {
+	if !database.IsDemozoo(f) && req.skip() {
+		return true
 	}
 	st.Count++
-	return false, nil
+	return false
 }
 
 func (st Stat) printer(w io.Writer) {
@@ -224,24 +185,13 @@ func (st Stat) summary(w io.Writer, elapsed time.Duration) {
 }
 
 // sumTotal calculates the total number of conditional rows.
-func (st *Stat) sumTotal(rec Records, req Request) error {
-	if rec.Rows == nil {
-		return ErrRecords
-	}
-	for rec.Rows.Next() {
-		if err := rec.Rows.Scan(rec.Args...); err != nil {
-			return fmt.Errorf("sum total rows scan: %w", err)
-		}
-		n, err := database.IsDemozoo(rec.Values)
-		if err != nil {
-			return err
-		}
-		if !n && req.skip() {
+func (st *Stat) sumTotal(files []database.File, req Request) {
+	for _, f := range files {
+		if !database.IsDemozoo(f) && req.skip() {
 			continue
 		}
 		st.Total++
 	}
-	return nil
 }
 
 // Download the first available remote file linked in the Demozoo production record.
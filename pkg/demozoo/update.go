@@ -1,7 +1,6 @@
 package demozoo
 
 import (
-	"context"
 	"crypto/md5" //nolint:gosec
 	"crypto/sha512"
 	"database/sql"
@@ -27,6 +26,7 @@ import (
 	"github.com/Defacto2/df2/pkg/groups"
 	"github.com/Defacto2/df2/pkg/str"
 	"github.com/gookit/color"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 )
 
 var (
@@ -39,10 +39,9 @@ var (
 )
 
 const (
-	dos     = "dos"
-	win     = "windows"
-	sep     = ","
-	timeout = 10 * time.Second
+	dos = "dos"
+	win = "windows"
+	sep = ","
 )
 
 // Category are tags for production imports.
@@ -159,35 +158,24 @@ func (r *Record) Save(db *sql.DB) error {
 	if db == nil {
 		return database.ErrDB
 	}
-	query, args := r.Stmt()
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-	stmt, err := db.PrepareContext(ctx, query)
-	if err != nil {
-		return fmt.Errorf("save prepare: %w", err)
+	cols := r.Cols()
+	if len(cols) == 0 {
+		return nil
 	}
-	defer stmt.Close()
-	_, err = stmt.ExecContext(ctx, args...)
-	if err != nil {
-		return fmt.Errorf("save exec: %w", err)
+	if _, err := database.UpdateFiles(db, cols, qm.Where("id = ?", r.ID)); err != nil {
+		return fmt.Errorf("save: %w", err)
 	}
 	return nil
 }
 
-// Stmt creates the SQL prepare statement and values to update a Demozoo production.
-func (r *Record) Stmt() (string, []any) {
-	// a range map iternation is not used due to the varied comparisons
+// Cols returns the columns and values to update a Demozoo production.
+func (r *Record) Cols() map[string]any {
 	set, args := updates(r)
-	if len(set) == 0 {
-		return "", args
-	}
-	set = append(set, "updatedat=?")
-	args = append(args, []any{time.Now()}...)
-	set = append(set, "updatedby=?")
-	args = append(args, []any{database.UpdateID}...)
-	query := "UPDATE files SET " + strings.Join(set, sep) + " WHERE id=?"
-	args = append(args, []any{r.ID}...)
-	return query, args
+	cols := make(map[string]any, len(set))
+	for i, s := range set {
+		cols[strings.TrimSuffix(s, "=?")] = args[i]
+	}
+	return cols
 }
 
 // ZipContent reads an archive and saves its content to the database.
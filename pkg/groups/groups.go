@@ -92,7 +92,8 @@ func Exact(db *sql.DB, name string) (int, error) {
 }
 
 // Fix any malformed group names found in the database.
-func Fix(db *sql.DB, w io.Writer) error {
+// A dry run prints the changes and then rolls them back.
+func Fix(db *sql.DB, w io.Writer, dry bool) error {
 	if db == nil {
 		return database.ErrDB
 	}
@@ -104,11 +105,16 @@ func Fix(db *sql.DB, w io.Writer) error {
 	if err != nil {
 		return err
 	}
+	tx, err := database.Begin(db, w, dry)
+	if err != nil {
+		return err
+	}
+	tx.Label = "clean the names of groups"
 	c := 0
 	for _, name := range names {
-		r, err := rename.Clean(db, w, name)
+		r, err := rename.Clean(tx, w, name)
 		if err != nil {
-			return err
+			return tx.Cancel(err)
 		}
 		if r {
 			c++
@@ -116,12 +122,12 @@ func Fix(db *sql.DB, w io.Writer) error {
 	}
 	str.Total(w, c, "group fixes applied")
 	// fix initialisms stored in the groupnames table
-	i, err := acronym.Fix(db)
+	i, err := acronym.Fix(tx)
 	if err != nil {
-		return err
+		return tx.Cancel(err)
 	}
 	str.Total(w, int(i), "initialism entries removed")
-	return nil
+	return tx.End()
 }
 
 // Format returns a copy of name with custom formatting.
@@ -152,7 +158,7 @@ func Slug(s string) string {
 }
 
 // Update replaces all instances of the group name with the new group name.
-func Update(db *sql.DB, newName, group string) (int64, error) {
+func Update(db database.Executor, newName, group string) (int64, error) {
 	return rename.Update(db, newName, group)
 }
 
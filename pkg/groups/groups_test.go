@@ -56,13 +56,13 @@ func TestExact(t *testing.T) {
 
 func TestFix(t *testing.T) {
 	t.Parallel()
-	err := groups.Fix(nil, nil)
+	err := groups.Fix(nil, nil, false)
 	assert.NotNil(t, err)
 
 	db, err := database.Connect(conf.Defaults())
 	assert.Nil(t, err)
 	defer db.Close()
-	err = groups.Fix(db, io.Discard)
+	err = groups.Fix(db, io.Discard, false)
 	assert.Nil(t, err)
 }
 
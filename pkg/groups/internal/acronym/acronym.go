@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/Defacto2/df2/pkg/database"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 )
 
 var ErrName = errors.New("group name cannot be empty")
@@ -34,15 +35,15 @@ func (g *Group) Get(db *sql.DB) error {
 }
 
 // Fix deletes any malformed initialisms in the database and returns the number of rows affected.
-func Fix(db *sql.DB) (int64, error) {
+func Fix(db database.Executor) (int64, error) {
 	if db == nil {
 		return 0, database.ErrDB
 	}
-	row, err := db.Exec("DELETE FROM `groupnames` WHERE `pubname`=? OR `initialisms`=?", "", "")
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		return 0, fmt.Errorf("fix exec: %w", err)
+	i, err := database.Delete(db, database.Groups, qm.Where("pubname = ? OR initialisms = ?", "", ""))
+	if err != nil {
+		return 0, fmt.Errorf("fix: %w", err)
 	}
-	return row.RowsAffected()
+	return i, nil
 }
 
 // Get a group's initialism or acronym.
@@ -2,7 +2,6 @@
 package rename
 
 import (
-	"database/sql"
 	"fmt"
 	"io"
 	"regexp"
@@ -12,6 +11,7 @@ import (
 	"github.com/Defacto2/df2/pkg/database"
 	"github.com/Defacto2/df2/pkg/str"
 	"github.com/gookit/color"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 )
@@ -19,7 +19,7 @@ import (
 const space = " "
 
 // Clean a malformed group name and save the fix to the database.
-func Clean(db *sql.DB, w io.Writer, name string) (bool, error) {
+func Clean(db database.Executor, w io.Writer, name string) (bool, error) {
 	if db == nil {
 		return false, database.ErrDB
 	}
@@ -134,20 +134,16 @@ func TrimThe(s string) string {
 }
 
 // Update replaces all instances of the group name with a new group name.
-func Update(db *sql.DB, newName, group string) (int64, error) {
-	stmt, err := db.Prepare("UPDATE `files` SET group_brand_for=?," +
-		" group_brand_by=? WHERE (group_brand_for=? OR group_brand_by=?)")
-	if err != nil {
-		return 0, fmt.Errorf("rename statement: %w", err)
-	}
-	defer stmt.Close()
-	res, err := stmt.Exec(newName, newName, group, group)
-	if err != nil {
-		return 0, fmt.Errorf("rename exec: %w", err)
+func Update(db database.Executor, newName, group string) (int64, error) {
+	if db == nil {
+		return 0, database.ErrDB
 	}
-	count, err := res.RowsAffected()
+	count, err := database.UpdateFiles(db, map[string]any{
+		"group_brand_for": newName,
+		"group_brand_by":  newName,
+	}, qm.Where("group_brand_for = ? OR group_brand_by = ?", group, group))
 	if err != nil {
-		return 0, fmt.Errorf("rename rows affected: %w", err)
+		return 0, fmt.Errorf("rename: %w", err)
 	}
 	return count, nil
 }
@@ -0,0 +1,224 @@
+package images
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Defacto2/df2/pkg/conf"
+	"github.com/Defacto2/df2/pkg/database"
+	"github.com/Defacto2/df2/pkg/directories"
+	"github.com/Defacto2/df2/pkg/images/internal/format"
+	"github.com/Defacto2/df2/pkg/str"
+	"github.com/dustin/go-humanize"
+	"github.com/gookit/color"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+var ErrNoFormat = errors.New("no optional image formats were requested")
+
+const (
+	previews = "previews"
+	thumbs   = "thumbnails"
+)
+
+// Formats returns the names of the optional image formats, AVIF and JPEG XL.
+func Formats() []string {
+	return format.All()
+}
+
+// ValidFormats returns an error when any of the named optional image formats are unsupported.
+func ValidFormats(names ...string) error {
+	_, err := format.Parse(names...)
+	return err
+}
+
+// Extras generates the optional image formats of the PNG preview and thumbnail of the named UUID.
+// The formats are a comma-separated list of names, such as "avif,jxl",
+// and any existing images in those formats are kept.
+func Extras(w io.Writer, cfg conf.Config, id string, formats ...string) (string, error) {
+	if w == nil {
+		w = io.Discard
+	}
+	fmts, err := format.Parse(formats...)
+	if err != nil {
+		return "", err
+	}
+	f, err := directories.Files(cfg, id)
+	if err != nil {
+		return "", err
+	}
+	s := ""
+	for _, name := range [2]string{f.Img000, f.Img400} {
+		src := ReplaceExt(_png, name)
+		if _, err := os.Stat(src); err != nil {
+			continue
+		}
+		for _, fm := range fmts {
+			dest := ReplaceExt(fm.Ext(), name)
+			if _, err := os.Stat(dest); err == nil {
+				continue
+			}
+			if err := fm.Convert(w, src, dest); err != nil {
+				return s, err
+			}
+			s += "»" + string(fm)
+		}
+	}
+	return s, nil
+}
+
+// Backfill generates the optional image formats for the existing previews and thumbnails
+// of every file record, and then prints a comparison of the format sizes against
+// the PNG and WebP images, to judge their bandwidth savings.
+func Backfill(db *sql.DB, w io.Writer, cfg conf.Config, formats ...string) error {
+	if db == nil {
+		return database.ErrDB
+	}
+	if w == nil {
+		w = io.Discard
+	}
+	fmts, err := format.Parse(formats...)
+	if err != nil {
+		return err
+	}
+	if len(fmts) == 0 {
+		return ErrNoFormat
+	}
+	if err := format.Installed(fmts...); err != nil {
+		return fmt.Errorf("images backfill: %w", err)
+	}
+	dir, err := directories.Init(cfg, false)
+	if err != nil {
+		return err
+	}
+	files, err := database.Select(db,
+		qm.Select("id", "uuid", "filename"),
+		qm.OrderBy("id ASC"))
+	if err != nil {
+		return fmt.Errorf("images backfill select: %w", err)
+	}
+	names := make([]string, 0, len(fmts))
+	for _, f := range fmts {
+		names = append(names, string(f))
+	}
+	sav, c := Savings{}, 0
+	for _, f := range files {
+		id := f.UUID.String
+		if id == "" {
+			continue
+		}
+		s, err := Extras(w, cfg, id, formats...)
+		if s != "" || err != nil {
+			c++
+			fmt.Fprintf(w, "%s%d. %s  %s %s", str.PrePad, c, color.Primary.Sprint(f.ID), f.Filename.String, s)
+			if err != nil {
+				fmt.Fprintf(w, " %s", err)
+			}
+			fmt.Fprintln(w)
+		}
+		sav.Add(dir.Img000, previews, id, names...)
+		sav.Add(dir.Img400, thumbs, id, names...)
+	}
+	if c == 0 {
+		fmt.Fprintf(w, "%s%s\n", str.PrePad, str.NothingToDo)
+	}
+	sav.Print(w)
+	return nil
+}
+
+// Sizes are the combined file sizes of the images that have a copy in an optional format.
+type Sizes struct {
+	Images int   // Images is the number of images with a copy in the format.
+	PNG    int64 // PNG is the combined size of the PNG images.
+	Size   int64 // Size is the combined size of the copies in the format.
+	WebPs  int   // WebPs is the number of the images that also have a WebP copy.
+	WebP   int64 // WebP is the combined size of the WebP copies.
+	Versus int64 // Versus is the combined size of the copies in the format of the images with a WebP copy.
+}
+
+// Savings are the sizes of the optional formats, keyed by the format and kind of image.
+type Savings map[string]*Sizes
+
+// Add the file sizes of the PNG, WebP and named optional format images of the UUID that are stored in dir.
+// Images without a copy in the format are ignored.
+func (sav Savings) Add(dir, kind, id string, names ...string) {
+	size := func(ext string) int64 {
+		st, err := os.Stat(filepath.Join(dir, id+ext))
+		if err != nil || st.IsDir() {
+			return 0
+		}
+		return st.Size()
+	}
+	p := size(_png)
+	if p == 0 {
+		return
+	}
+	wp := size(_webp)
+	for _, f := range names {
+		n := size("." + f)
+		if n == 0 {
+			continue
+		}
+		key := fmt.Sprintf("%s %s", strings.ToUpper(f), kind)
+		if sav[key] == nil {
+			sav[key] = &Sizes{}
+		}
+		s := sav[key]
+		s.Images++
+		s.PNG += p
+		s.Size += n
+		if wp > 0 {
+			s.WebPs++
+			s.WebP += wp
+			s.Versus += n
+		}
+	}
+}
+
+// Print the size comparisons of the savings to w.
+func (sav Savings) Print(w io.Writer) {
+	if w == nil {
+		w = io.Discard
+	}
+	keys := make([]string, 0, len(sav))
+	for k := range sav {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s%s, %s\n", str.PrePad, k, sav[k])
+	}
+}
+
+// String returns the sizes and their savings compared to the PNG and WebP images.
+func (s Sizes) String() string {
+	if s.Images == 0 {
+		return "no images"
+	}
+	x := fmt.Sprintf("%d images of %s are %s than PNG (%s)",
+		s.Images, humanize.Bytes(uint64(s.Size)), saving(s.PNG, s.Size), humanize.Bytes(uint64(s.PNG)))
+	if s.WebPs == 0 {
+		return x
+	}
+	return x + fmt.Sprintf(", %d of %s are %s than WebP (%s)",
+		s.WebPs, humanize.Bytes(uint64(s.Versus)), saving(s.WebP, s.Versus), humanize.Bytes(uint64(s.WebP)))
+}
+
+// saving returns the percentage difference of the new size compared to the old size.
+func saving(old, size int64) string {
+	if old <= 0 {
+		return "n/a"
+	}
+	const percent = 100
+	pct := float64(old-size) / float64(old) * percent
+	if pct < 0 {
+		return fmt.Sprintf("%.1f%% larger", -pct)
+	}
+	return fmt.Sprintf("%.1f%% smaller", pct)
+}
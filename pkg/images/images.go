@@ -20,15 +20,19 @@ import (
 	"github.com/Defacto2/df2/pkg/conf"
 	"github.com/Defacto2/df2/pkg/database"
 	"github.com/Defacto2/df2/pkg/directories"
+	_ "github.com/Defacto2/df2/pkg/images/internal/bmp" // register BMP decoding, including the OS/2 and RLE variants.
 	"github.com/Defacto2/df2/pkg/images/internal/file"
+	_ "github.com/Defacto2/df2/pkg/images/internal/ilbm" // register IFF ILBM and LBM decoding.
 	"github.com/Defacto2/df2/pkg/images/internal/imagemagick"
 	"github.com/Defacto2/df2/pkg/images/internal/netpbm"
+	_ "github.com/Defacto2/df2/pkg/images/internal/pcx" // register PCX decoding.
+	_ "github.com/Defacto2/df2/pkg/images/internal/pic" // register PC Paint and Pictor PIC decoding.
+	_ "github.com/Defacto2/df2/pkg/images/internal/tga" // register TGA decoding.
+	"github.com/Defacto2/df2/pkg/images/internal/webp"
 	"github.com/Defacto2/df2/pkg/str"
 	"github.com/disintegration/imaging"
 	"github.com/gabriel-vasile/mimetype"
-	"github.com/nickalie/go-webpbin"
 	"github.com/yusukebe/go-pngquant"
-	_ "golang.org/x/image/bmp"  // register BMP decoding.
 	_ "golang.org/x/image/tiff" // register TIFF decoding.
 	_ "golang.org/x/image/webp" // register WebP decoding.
 )
@@ -42,13 +46,8 @@ const (
 	fperm      os.FileMode = 0o666
 	fmode                  = os.O_RDWR | os.O_CREATE
 
-	gif  = ".gif"
-	jpg  = ".jpg"
-	jpeg = ".jpeg"
-	_png = ".png"
-	tif  = ".tif"
-	tiff = ".tiff"
-	webp = ".webp"
+	_png  = ".png"
+	_webp = ".webp"
 )
 
 // Fix generates any missing assets from downloads that are images.
@@ -64,7 +63,7 @@ func Fix(db *sql.DB, w io.Writer, cfg conf.Config) error {
 		return err
 	}
 	rows, err := db.Query(`SELECT id, uuid, filename, filesize ` +
-		`FROM files WHERE platform="image" ORDER BY id ASC`)
+		`FROM files WHERE platform='image' ORDER BY id ASC`)
 	if err != nil {
 		return fmt.Errorf("images fix query: %w", err)
 	} else if rows.Err() != nil {
@@ -139,14 +138,14 @@ func Generate(w io.Writer, cfg conf.Config, src, id string, remove bool) error {
 	}
 	// these funcs use dependencies that are not thread safe
 	// convert to png
-	pngDest, webpDest := ReplaceExt(_png, f.Img000), ReplaceExt(webp, f.Img000)
+	pngDest, webpDest := ReplaceExt(_png, f.Img000), ReplaceExt(_webp, f.Img000)
 	const width = 1500
 	s, err := ToPNG(src, pngDest, width, width)
 	if err != nil {
 		return fmt.Errorf("could not generate from %s: %s: %w", src, pngDest, err)
 	}
 	fmt.Fprintf(w, " %s", s)
-	// use netpbm or imagemagick to convert unsupported image formats into PNG
+	// use netpbm or imagemagick to convert the image formats without a native decoder into PNG
 	if !file.Check(pngDest, err) {
 		if err := Libraries(w, src, pngDest, remove); err != nil {
 			if err := file.Remove(remove, src); err != nil {
@@ -157,12 +156,12 @@ func Generate(w io.Writer, cfg conf.Config, src, id string, remove bool) error {
 		}
 	}
 	// convert to webp
-	s, err = ToWebp(w, src, webpDest, true)
+	s, err = ToWebp(w, src, webpDest, nil)
 	if err != nil {
 		if !errors.Is(err, ErrFormat) {
 			return fmt.Errorf("could not generate webp from %s: %s: %w", src, webpDest, err)
 		}
-		s, err = ToWebp(w, pngDest, webpDest, true)
+		s, err = ToWebp(w, pngDest, webpDest, nil)
 		if err != nil {
 			return fmt.Errorf("could not generate webp from %s: %s: %w", pngDest, webpDest, err)
 		}
@@ -180,6 +179,14 @@ func Generate(w io.Writer, cfg conf.Config, src, id string, remove bool) error {
 		return fmt.Errorf("could not generate thumbs from any sources: %s: %w", src, err)
 	}
 	fmt.Fprintf(w, "  %s", s)
+	// make the optional avif and jpeg xl images
+	if cfg.ImageFormats != "" {
+		s, err = Extras(w, cfg, id, cfg.ImageFormats)
+		if err != nil {
+			s = err.Error()
+		}
+		fmt.Fprintf(w, "  %s", s)
+	}
 	return file.Remove(remove, src)
 }
 
@@ -362,111 +369,62 @@ func ToThumb(src, dest string, sizeSquared int) (string, error) {
 	return fmt.Sprintf("»%vx", sizeSquared), nil
 }
 
-// ToWebp converts any supported format to a WebP image using a 3rd party library.
-// Input format can be either GIF, PNG, JPEG, TIFF, WebP or raw Y'CbCr samples.
-func ToWebp(w io.Writer, src, dest string, vendorTempDir bool) (string, error) {
+// Encoder encodes an image to the WebP format.
+type Encoder interface {
+	Encode(w io.Writer, m image.Image) error
+}
+
+// Lossless is the pure Go encoder of lossless WebP images, which is the default Encoder.
+type Lossless struct{}
+
+// Encode writes the m image to w as a lossless WebP image.
+func (Lossless) Encode(w io.Writer, m image.Image) error {
+	return webp.Encode(w, m)
+}
+
+// ToWebp converts any supported format to a WebP image using the encoder, or Lossless when it is nil.
+// Input format can be either GIF, PNG, JPEG, BMP, TIFF, WebP, IFF ILBM, LBM, PCX, PIC or TGA.
+// Existing WebP images are skipped and images that are too large are resized to fit.
+func ToWebp(w io.Writer, src, dest string, enc Encoder) (string, error) {
 	if w == nil {
 		w = io.Discard
 	}
-	input, rm, err := checkWebP(src)
+	if enc == nil {
+		enc = Lossless{}
+	}
+	m, err := mimetype.DetectFile(src)
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("to webp mimetype detect: %w", err)
 	}
-	if rm {
-		defer os.Remove(input)
+	if m.Extension() == _webp {
+		return "", nil
+	}
+	f, err := os.Open(src)
+	if err != nil {
+		return "", fmt.Errorf("to webp open: %w", err)
 	}
-	input, err = cropWebP(w, input)
+	defer f.Close()
+	img, _, err := image.Decode(f)
 	if err != nil {
-		return "", fmt.Errorf("to webp crop: %w", err)
+		return "", fmt.Errorf("to webp decode %q: %w", m.Extension(), ErrFormat)
 	}
-	const percent = 70
-	webp := webpbin.NewCWebP().
-		Quality(percent).
-		InputFile(input).
-		OutputFile(dest)
-	if vendorTempDir {
-		webp.Dest(file.Vendor())
+	if b := img.Bounds(); b.Dx() > WebpMaxSize || b.Dy() > WebpMaxSize {
+		img = imaging.Fit(img, WebpMaxSize, WebpMaxSize, imaging.Lanczos)
+		fmt.Fprintf(w, "resize to %dx%d", img.Bounds().Dx(), img.Bounds().Dy())
 	}
-	if strings.HasSuffix(input, "-cropped.png") {
-		defer os.Remove(input)
+	buf := &bytes.Buffer{}
+	if err := enc.Encode(buf, img); err != nil {
+		return "", fmt.Errorf("to webp encode: %w", err)
 	}
-	if err = webp.Run(); err != nil {
+	if err := os.WriteFile(dest, buf.Bytes(), fperm); err != nil {
 		if err1 := file.Remove0byte(dest); err1 != nil {
 			return "", fmt.Errorf("to webp cleanup: %w", err1)
 		}
-		return "", fmt.Errorf("to webp run: %w", err)
+		return "", fmt.Errorf("to webp write: %w", err)
 	}
 	return "»webp", nil
 }
 
-// checkWebP checks the validity of and returns the absolute path of the input source image,
-// a returned true value means the input source is temporary and after use, it should be deleted.
-func checkWebP(src string) (string, bool, error) {
-	valid := func(a []string, x string) bool {
-		for _, n := range a {
-			if x == n {
-				return true
-			}
-		}
-		return false
-	}
-	v := []string{_png, jpg, jpeg, tif, tiff, webp}
-	input := src
-	// skip if already a webp image, or handle all other errors
-	m, err := mimetype.DetectFile(src)
-	switch {
-	case m.Extension() == gif:
-		// Dec 2022, https://github.com/nickalie/go-webpbin
-		// currently does not support the library,
-		// gif2webp -- Tool for converting GIF images to WebP
-		f, err := os.CreateTemp("", "df2-gifToWebp.png")
-		if err != nil {
-			return "", false, err
-		}
-		_, err = ToPNG(src, f.Name(), 0, 0)
-		if err != nil {
-			return "", true, fmt.Errorf("to webp gif-topng: %w", err)
-		}
-		input = f.Name()
-	case m.Extension() == webp:
-		return "", false, nil
-	case err != nil:
-		return "", false, fmt.Errorf("to webp mimetype detect: %w", err)
-	case m.Extension() == "":
-		return "", false, fmt.Errorf("no extension for webp mimetype: %w", ErrFormat)
-	case !valid(v, m.Extension()):
-		return "", false, fmt.Errorf("to webp mimetype %q != %s: %w",
-			m.Extension(), strings.Join(v, " "), ErrFormat)
-	}
-	if m.Extension() == gif {
-		return input, true, nil
-	}
-	return input, false, nil
-}
-
-// cropWebP crops an image to be usable size for WebP conversion.
-func cropWebP(w io.Writer, name string) (string, error) {
-	if w == nil {
-		w = io.Discard
-	}
-	wp, hp, _, err := Info(name)
-	if err != nil {
-		return "", fmt.Errorf("to webp size: %w", err)
-	}
-	if wp+hp > WebpMaxSize {
-		fmt.Fprintf(w, "crop to %dx%d", w, hp)
-		cropW, cropH := WebPCalc(wp, hp)
-		ext := filepath.Ext(name)
-		fn := strings.TrimSuffix(name, ext)
-		crop := fn + "-cropped" + ext
-		if _, err := ToPNG(name, crop, cropW, cropH); err != nil {
-			return "", fmt.Errorf("webp crop: %w", err)
-		}
-		return crop, nil
-	}
-	return name, nil
-}
-
 // WebPCalc calculates the largest permitted sizes for a valid WebP crop.
 func WebPCalc(width, height int) (w, h int) { //nolint:nonamedreturns
 	if width+height <= WebpMaxSize {
@@ -2,6 +2,7 @@ package images_test
 
 import (
 	"fmt"
+	"image"
 	_ "image/gif"
 	_ "image/jpeg"
 	"io"
@@ -13,7 +14,6 @@ import (
 	"github.com/Defacto2/df2/pkg/database"
 	"github.com/Defacto2/df2/pkg/images"
 	"github.com/stretchr/testify/assert"
-	_ "golang.org/x/image/bmp"
 	_ "golang.org/x/image/tiff"
 	_ "golang.org/x/image/webp"
 )
@@ -104,6 +104,11 @@ func TestInfo(t *testing.T) {
 	assert.Equal(t, 1280, w)
 	assert.Equal(t, 32, x)
 	assert.Equal(t, p, f)
+	w, x, f, err = images.Info(testImg("iff"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1280, w)
+	assert.Equal(t, 32, x)
+	assert.Equal(t, "ilbm", f)
 }
 
 func TestGenerate(t *testing.T) {
@@ -269,7 +274,7 @@ func TestToWebxp(t *testing.T) {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			_, err := images.ToWebp(nil, tt.args.src, tt.args.dest, false)
+			_, err := images.ToWebp(nil, tt.args.src, tt.args.dest, images.Lossless{})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ToWebp() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -302,7 +307,7 @@ func TestToWebp(t *testing.T) {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			gotPrint, err := images.ToWebp(nil, tt.args.src, tt.args.dest, true)
+			gotPrint, err := images.ToWebp(nil, tt.args.src, tt.args.dest, nil)
 			if (err != nil) != tt.wantErr {
 				fmt.Fprintf(os.Stderr, "%s -> %s\n", tt.args.src, tt.args.dest)
 				t.Errorf("ToWebp() error = %v, wantErr %v", err, tt.wantErr)
@@ -315,6 +320,65 @@ func TestToWebp(t *testing.T) {
 	}
 }
 
+func TestToWebp_ILBM(t *testing.T) {
+	t.Parallel()
+	dest := filepath.Join(t.TempDir(), "test.webp")
+	s, err := images.ToWebp(nil, testImg("iff"), dest, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "»webp", s)
+	w, h, f, err := images.Info(dest)
+	assert.Nil(t, err)
+	assert.Equal(t, 1280, w)
+	assert.Equal(t, 32, h)
+	assert.Equal(t, "webp", f)
+}
+
+func TestToWebp_BMP(t *testing.T) {
+	t.Parallel()
+	// an OS/2 1.x bitmap, which the golang.org/x/image/bmp package refuses
+	b := []byte{'B', 'M', 0, 0, 0, 0, 0, 0, 0, 0, 32, 0, 0, 0, 12, 0, 0, 0, 2, 0, 1, 0, 1, 0, 1, 0}
+	b = append(b, 0, 0, 0, 0xff, 0xff, 0xff, 0x40, 0, 0, 0)
+	dir := t.TempDir()
+	src, dest := filepath.Join(dir, "test.bmp"), filepath.Join(dir, "test.webp")
+	assert.Nil(t, os.WriteFile(src, b, 0o600))
+	s, err := images.ToWebp(nil, src, dest, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "»webp", s)
+	w, h, f, err := images.Info(dest)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, w)
+	assert.Equal(t, 1, h)
+	assert.Equal(t, "webp", f)
+}
+
+// failEncoder is an Encoder that always fails.
+type failEncoder struct{}
+
+func (failEncoder) Encode(w io.Writer, m image.Image) error {
+	return images.ErrFormat
+}
+
+func TestToWebp_Encoder(t *testing.T) {
+	t.Parallel()
+	dest := filepath.Join(t.TempDir(), "test.webp")
+	_, err := images.ToWebp(nil, testImg(p), dest, failEncoder{})
+	assert.ErrorIs(t, err, images.ErrFormat)
+	_, err = images.ToWebp(nil, testTxt(), dest, nil)
+	assert.ErrorIs(t, err, images.ErrFormat)
+	s, err := images.ToWebp(nil, testImg(p), dest, images.Lossless{})
+	assert.Nil(t, err)
+	assert.Equal(t, "»webp", s)
+	width, height, format, err := images.Info(dest)
+	assert.Nil(t, err)
+	assert.Equal(t, w, format)
+	assert.Equal(t, 1280, width)
+	assert.Equal(t, 32, height)
+	// existing webp images are skipped
+	s, err = images.ToWebp(nil, dest, dest, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "", s)
+}
+
 func TestWebPCalc(t *testing.T) {
 	t.Parallel()
 	const long = 15000
@@ -378,3 +442,44 @@ func TestMove(t *testing.T) {
 	assert.Nil(t, err)
 	defer os.Remove(dst)
 }
+
+func TestValidFormats(t *testing.T) {
+	t.Parallel()
+	assert.Nil(t, images.ValidFormats())
+	assert.Nil(t, images.ValidFormats("avif", "jxl"))
+	assert.NotNil(t, images.ValidFormats("avif,webm"))
+}
+
+func TestBackfill(t *testing.T) {
+	t.Parallel()
+	err := images.Backfill(nil, nil, conf.Defaults(), "avif")
+	assert.NotNil(t, err)
+}
+
+func TestSavings(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	write := func(name string, size int) {
+		err := os.WriteFile(filepath.Join(dir, name), make([]byte, size), 0o600)
+		assert.Nil(t, err)
+	}
+	write("a.png", 1000)
+	write("a.webp", 600)
+	write("a.avif", 300)
+	write("b.png", 1000)
+	write("b.avif", 1500)
+	write("c.webp", 100)
+	write("c.avif", 100)
+	sav := images.Savings{}
+	for _, id := range []string{"a", "b", "c", "d"} {
+		sav.Add(dir, "previews", id, "avif", "jxl")
+	}
+	assert.Len(t, sav, 1)
+	s := sav["AVIF previews"]
+	assert.Equal(t, images.Sizes{Images: 2, PNG: 2000, Size: 1800, WebPs: 1, WebP: 600, Versus: 300}, *s)
+	assert.Equal(t, "2 images of 1.8 kB are 10.0% smaller than PNG (2.0 kB), "+
+		"1 of 300 B are 50.0% smaller than WebP (600 B)", s.String())
+	s = &images.Sizes{Images: 1, PNG: 100, Size: 150}
+	assert.Equal(t, "1 images of 150 B are 50.0% larger than PNG (100 B)", s.String())
+	assert.Equal(t, "no images", images.Sizes{}.String())
+}
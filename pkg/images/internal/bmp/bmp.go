@@ -0,0 +1,422 @@
+// Package bmp decodes the Microsoft Windows and IBM OS/2 bitmap images.
+//
+// The decoder supports the variants that the golang.org/x/image/bmp package refuses,
+// such as the OS/2 headers, the 1, 2, 4 and 16-bit images, the run-length encoded
+// 4 and 8-bit images and the bit field masks, as well as the common 8, 24 and 32-bit images.
+//
+// Importing this package registers the bmp format with the image package.
+package bmp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math/bits"
+)
+
+var (
+	ErrHeader      = errors.New("not a bmp image")
+	ErrDepth       = errors.New("unsupported bmp color depth")
+	ErrCompression = errors.New("unsupported bmp compression")
+)
+
+const (
+	fileLen = 14     // fileLen is the size of the file header.
+	coreLen = 12     // coreLen is the size of the OS/2 1.x bitmap core header.
+	infoLen = 40     // infoLen is the size of the Windows 3 bitmap info header.
+	os2Len  = 64     // os2Len is the size of the OS/2 2.x bitmap header.
+	v4Len   = 108    // v4Len is the size of the Windows 95 bitmap header that adds the color space.
+	maxLen  = 0x1000 // maxLen is the largest accepted size of a bitmap header.
+	maxSide = 0x7fff // maxSide is the largest accepted width or height, to refuse the corrupt headers.
+
+	rgb       = 0 // rgb is the compression value of the uncompressed images.
+	rle8      = 1 // rle8 is the compression value of the run-length encoded 8-bit images.
+	rle4      = 2 // rle4 is the compression value of the run-length encoded 4-bit images.
+	bitfields = 3 // bitfields is the compression value of the images that use color masks.
+	alphabits = 6 // alphabits is the compression value of the images that use color and alpha masks.
+)
+
+func init() { //nolint:gochecknoinits
+	image.RegisterFormat("bmp", "BM", Decode, DecodeConfig)
+}
+
+// header is the combined file and bitmap header.
+type header struct {
+	Offset      int       // Offset is the position of the pixel data from the start of the file.
+	Size        int       // Size is the length of the bitmap header.
+	Width       int       // Width of the image in pixels.
+	Height      int       // Height of the image in pixels.
+	TopDown     bool      // TopDown is true when the rows are stored from the top, instead of the bottom.
+	Bits        int       // Bits per pixel.
+	Compression uint32    // Compression method.
+	Colors      int       // Colors is the number of palette entries.
+	Entry       int       // Entry is the size of a palette entry, which is 3 for OS/2 1.x and otherwise 4.
+	Masks       [4]uint32 // Masks of the red, green, blue and alpha channels.
+	read        int       // read is the number of bytes read, including the masks that follow the header.
+}
+
+// paletted returns true when the pixels are indexes of the palette.
+func (h header) paletted() bool {
+	return h.Bits <= 8
+}
+
+func readHeader(r io.Reader) (header, error) {
+	h := header{}
+	var b [fileLen + 4]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return h, fmt.Errorf("%w: %w", ErrHeader, err)
+	}
+	if b[0] != 'B' || b[1] != 'M' {
+		return h, ErrHeader
+	}
+	h.Offset = int(binary.LittleEndian.Uint32(b[10:]))
+	h.Size = int(binary.LittleEndian.Uint32(b[14:]))
+	if h.Size != coreLen && (h.Size < 16 || h.Size > maxLen) {
+		return h, fmt.Errorf("%w: %d byte header", ErrHeader, h.Size)
+	}
+	// the optional fields of the shorter headers are zero
+	info := make([]byte, max(h.Size-4, v4Len))
+	if _, err := io.ReadFull(r, info[:h.Size-4]); err != nil {
+		return h, fmt.Errorf("%w: %w", ErrHeader, err)
+	}
+	h.read = fileLen + h.Size
+	le := binary.LittleEndian
+	if h.Size == coreLen {
+		h.Width, h.Height = int(le.Uint16(info[0:])), int(le.Uint16(info[2:]))
+		h.Bits, h.Entry = int(le.Uint16(info[6:])), 3
+	} else {
+		h.Width, h.Height = int(int32(le.Uint32(info[0:]))), int(int32(le.Uint32(info[4:])))
+		h.Bits, h.Entry = int(le.Uint16(info[10:])), 4
+		h.Compression = le.Uint32(info[12:])
+		h.Colors = int(le.Uint32(info[28:]))
+		h.Masks = [4]uint32{le.Uint32(info[36:]), le.Uint32(info[40:]), le.Uint32(info[44:]), le.Uint32(info[48:])}
+	}
+	if h.Height < 0 {
+		h.Height, h.TopDown = -h.Height, true
+	}
+	if h.Width <= 0 || h.Height <= 0 || h.Width > maxSide || h.Height > maxSide {
+		return h, fmt.Errorf("%w: %dx%d pixels", ErrHeader, h.Width, h.Height)
+	}
+	if err := h.check(); err != nil {
+		return h, err
+	}
+	if err := h.masks(r); err != nil {
+		return h, err
+	}
+	if h.paletted() {
+		n := 1 << h.Bits
+		if h.Colors > 0 && h.Colors < n {
+			n = h.Colors
+		}
+		// some writers save fewer colors than the depth, which is found by the offset of the pixels
+		if gap := (h.Offset - h.read) / h.Entry; h.Offset >= h.read && gap < n {
+			n = gap
+		}
+		h.Colors = n
+	}
+	return h, nil
+}
+
+// check returns an error when the color depth and compression combination is unsupported.
+func (h header) check() error {
+	os2 := h.Size == os2Len || (h.Size > coreLen && h.Size < infoLen)
+	switch h.Compression {
+	case rgb:
+		switch h.Bits {
+		case 1, 2, 4, 8, 16, 24, 32:
+			return nil
+		}
+		return fmt.Errorf("%w: %d bits", ErrDepth, h.Bits)
+	case rle8:
+		if h.Bits == 8 && !h.TopDown {
+			return nil
+		}
+	case rle4:
+		if h.Bits == 4 && !h.TopDown {
+			return nil
+		}
+	case bitfields, alphabits:
+		// the OS/2 2.x headers use these values for the Huffman and 24-bit run-length encodings
+		if !os2 && (h.Bits == 16 || h.Bits == 32) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: method %d of %d bits", ErrCompression, h.Compression, h.Bits)
+}
+
+// masks sets the color masks of the 16 and 32-bit images, which follow the Windows 3 header
+// or otherwise use the defaults of 5 bits and 8 bits for each channel.
+func (h *header) masks(r io.Reader) error {
+	if h.Bits != 16 && h.Bits != 32 {
+		return nil
+	}
+	if h.Compression == rgb {
+		h.Masks = [4]uint32{0xff0000, 0xff00, 0xff, 0}
+		if h.Bits == 16 {
+			h.Masks = [4]uint32{0x7c00, 0x3e0, 0x1f, 0}
+		}
+		return nil
+	}
+	if h.Size > infoLen {
+		return nil
+	}
+	n := 3
+	if h.Compression == alphabits {
+		n = 4
+	}
+	b := make([]byte, n*4)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return fmt.Errorf("bmp masks: %w", err)
+	}
+	h.read += len(b)
+	for i := 0; i < n; i++ {
+		h.Masks[i] = binary.LittleEndian.Uint32(b[i*4:])
+	}
+	return nil
+}
+
+// palette reads the color table of the header, which is padded with black to the color depth.
+func palette(r io.Reader, h header) (color.Palette, error) {
+	b := make([]byte, h.Colors*h.Entry)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, fmt.Errorf("bmp palette: %w", err)
+	}
+	pal := make(color.Palette, 1<<h.Bits)
+	for i := range pal {
+		if i >= h.Colors {
+			pal[i] = color.RGBA{0, 0, 0, 0xff}
+			continue
+		}
+		p := b[i*h.Entry:]
+		pal[i] = color.RGBA{p[2], p[1], p[0], 0xff}
+	}
+	return pal, nil
+}
+
+// Decode reads a BMP image from r.
+// Palette images are returned as an *image.Paletted and the other images are an *image.NRGBA.
+func Decode(r io.Reader) (image.Image, error) {
+	h, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(r)
+	var pal color.Palette
+	if h.paletted() {
+		if pal, err = palette(br, h); err != nil {
+			return nil, err
+		}
+		h.read += h.Colors * h.Entry
+	}
+	if skip := h.Offset - h.read; skip > 0 {
+		if _, err := br.Discard(skip); err != nil {
+			return nil, fmt.Errorf("bmp offset: %w", err)
+		}
+	}
+	rect := image.Rect(0, 0, h.Width, h.Height)
+	if h.paletted() {
+		img := image.NewPaletted(rect, pal)
+		switch h.Compression {
+		case rle8, rle4:
+			// a truncated image is still decoded
+			unpack(br, img, h.Compression == rle4)
+		default:
+			indexes(br, img, h)
+		}
+		return img, nil
+	}
+	img := image.NewNRGBA(rect)
+	stride := (h.Width*h.Bits + 31) / 32 * 4
+	row := make([]byte, stride)
+	px := h.Bits / 8
+	chans := [4]channel{}
+	for i, m := range h.Masks {
+		chans[i] = newChannel(m)
+	}
+	for i := 0; i < h.Height; i++ {
+		if _, err := io.ReadFull(br, row); err != nil {
+			break
+		}
+		y := h.y(i)
+		for x := 0; x < h.Width; x++ {
+			p := row[x*px:]
+			if px == 3 {
+				img.SetNRGBA(x, y, color.NRGBA{p[2], p[1], p[0], 0xff})
+				continue
+			}
+			v := uint32(p[0]) | uint32(p[1])<<8
+			if px == 4 {
+				v |= uint32(p[2])<<16 | uint32(p[3])<<24
+			}
+			a := uint8(0xff)
+			if h.Masks[3] != 0 {
+				a = chans[3].value(v)
+			}
+			img.SetNRGBA(x, y, color.NRGBA{chans[0].value(v), chans[1].value(v), chans[2].value(v), a})
+		}
+	}
+	if h.Masks[3] != 0 {
+		opaque(img)
+	}
+	return img, nil
+}
+
+// DecodeConfig returns the color model and dimensions of a BMP image without decoding the bitmap.
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	h, err := readHeader(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	var model color.Model = color.NRGBAModel
+	if h.paletted() {
+		pal, err := palette(r, h)
+		if err != nil {
+			return image.Config{}, err
+		}
+		model = pal
+	}
+	return image.Config{ColorModel: model, Width: h.Width, Height: h.Height}, nil
+}
+
+// y returns the image row of the stored row r.
+func (h header) y(r int) int {
+	if h.TopDown {
+		return r
+	}
+	return h.Height - 1 - r
+}
+
+// indexes reads the uncompressed palette indexes of r into the image,
+// where each row is padded to a multiple of 4 bytes.
+func indexes(r io.Reader, img *image.Paletted, h header) {
+	stride := (h.Width*h.Bits + 31) / 32 * 4
+	row := make([]byte, stride)
+	for i := 0; i < h.Height; i++ {
+		if _, err := io.ReadFull(r, row); err != nil {
+			return
+		}
+		pix := img.Pix[h.y(i)*img.Stride:]
+		for x := 0; x < h.Width; x++ {
+			bit := x * h.Bits
+			shift := 8 - h.Bits - bit&7
+			pix[x] = row[bit>>3] >> shift & (1<<h.Bits - 1)
+		}
+	}
+}
+
+// unpack decompresses the run-length encoded palette indexes of r into the image,
+// where the rows are stored from the bottom. A pair of bytes is either a count and an index
+// to repeat, or a zero followed by an escape code for the end of the row, the end of the bitmap,
+// a move of the position, or the number of the literal indexes that follow.
+// The 4-bit images store two alternating indexes in each byte.
+func unpack(r io.ByteReader, img *image.Paletted, nibbles bool) {
+	const endRow, endBitmap, delta = 0, 1, 2
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	x, y := 0, 0
+	set := func(i int, v byte) {
+		if nibbles {
+			v >>= 4 * (1 - i&1)
+			v &= 0x0f
+		}
+		if x < w && y < h {
+			img.Pix[(h-1-y)*img.Stride+x] = v
+		}
+		x++
+	}
+	for y < h {
+		n, err := r.ReadByte()
+		if err != nil {
+			return
+		}
+		v, err := r.ReadByte()
+		if err != nil {
+			return
+		}
+		if n > 0 {
+			for i := 0; i < int(n); i++ {
+				set(i, v)
+			}
+			continue
+		}
+		switch v {
+		case endRow:
+			x, y = 0, y+1
+		case endBitmap:
+			return
+		case delta:
+			dx, err := r.ReadByte()
+			if err != nil {
+				return
+			}
+			dy, err := r.ReadByte()
+			if err != nil {
+				return
+			}
+			x, y = x+int(dx), y+int(dy)
+		default:
+			size := int(v)
+			if nibbles {
+				size = (size + 1) / 2
+			}
+			var b byte
+			for i := 0; i < int(v); i++ {
+				if !nibbles || i&1 == 0 {
+					if b, err = r.ReadByte(); err != nil {
+						return
+					}
+				}
+				set(i, b)
+			}
+			// the literals are padded to a 16-bit boundary
+			if size&1 != 0 {
+				if _, err := r.ReadByte(); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// channel is a color mask of a 16 or 32-bit pixel.
+type channel struct {
+	shift int
+	bits  int
+	mask  uint32
+}
+
+func newChannel(mask uint32) channel {
+	if mask == 0 {
+		return channel{}
+	}
+	shift := bits.TrailingZeros32(mask)
+	return channel{shift: shift, bits: bits.OnesCount32(mask), mask: mask}
+}
+
+// value returns the 8-bit channel value of the pixel v.
+func (c channel) value(v uint32) uint8 {
+	if c.bits == 0 {
+		return 0
+	}
+	x := (v & c.mask) >> c.shift
+	if c.bits >= 8 {
+		return uint8(x >> (c.bits - 8))
+	}
+	return uint8(x * 0xff / (1<<c.bits - 1))
+}
+
+// opaque sets the alpha channel of the image to opaque when every pixel is transparent,
+// as many programs save an alpha mask without using it.
+func opaque(img *image.NRGBA) {
+	for i := 3; i < len(img.Pix); i += 4 {
+		if img.Pix[i] != 0 {
+			return
+		}
+	}
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 0xff
+	}
+}
@@ -0,0 +1,153 @@
+package bmp_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/Defacto2/df2/pkg/images/internal/bmp"
+	"github.com/stretchr/testify/assert"
+)
+
+// info returns a BMP file and Windows 3 bitmap info header, followed by the palette and pixel data.
+func info(w, h, bits, compression int, pal, data []byte) []byte {
+	b := make([]byte, 54)
+	b[0], b[1] = 'B', 'M'
+	binary.LittleEndian.PutUint32(b[10:], uint32(54+len(pal)))
+	binary.LittleEndian.PutUint32(b[14:], 40)
+	binary.LittleEndian.PutUint32(b[18:], uint32(int32(w)))
+	binary.LittleEndian.PutUint32(b[22:], uint32(int32(h)))
+	binary.LittleEndian.PutUint16(b[26:], 1)
+	binary.LittleEndian.PutUint16(b[28:], uint16(bits))
+	binary.LittleEndian.PutUint32(b[30:], uint32(compression))
+	b = append(b, pal...)
+	return append(b, data...)
+}
+
+func rgba(m image.Image, x, y int) color.RGBA {
+	c, _ := color.RGBAModel.Convert(m.At(x, y)).(color.RGBA)
+	return c
+}
+
+var (
+	black = color.RGBA{0, 0, 0, 0xff}
+	white = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	red   = color.RGBA{0xff, 0, 0, 0xff}
+)
+
+func TestDecode_OS2(t *testing.T) {
+	t.Parallel()
+	// the OS/2 1.x core header uses 16-bit dimensions and a palette of 3 byte entries
+	b := make([]byte, 26)
+	b[0], b[1] = 'B', 'M'
+	binary.LittleEndian.PutUint32(b[10:], 32)
+	binary.LittleEndian.PutUint32(b[14:], 12)
+	binary.LittleEndian.PutUint16(b[18:], 3)
+	binary.LittleEndian.PutUint16(b[20:], 2)
+	binary.LittleEndian.PutUint16(b[22:], 1)
+	binary.LittleEndian.PutUint16(b[24:], 1)
+	b = append(b, 0, 0, 0, 0xff, 0xff, 0xff)
+	// the bottom row is stored first and every row is padded to 4 bytes
+	b = append(b, 0xa0, 0, 0, 0, 0x40, 0, 0, 0)
+	m, name, err := image.Decode(bytes.NewReader(b))
+	assert.Nil(t, err)
+	assert.Equal(t, "bmp", name)
+	assert.Equal(t, image.Rect(0, 0, 3, 2), m.Bounds())
+	assert.Equal(t, black, rgba(m, 0, 0))
+	assert.Equal(t, white, rgba(m, 1, 0))
+	assert.Equal(t, white, rgba(m, 0, 1))
+	assert.Equal(t, black, rgba(m, 1, 1))
+	assert.Equal(t, white, rgba(m, 2, 1))
+}
+
+func TestDecode_RLE8(t *testing.T) {
+	t.Parallel()
+	pal := make([]byte, 4*256)
+	pal[4*1+2] = 0xff                   // red
+	pal[4*2], pal[4*2+1] = 0xff, 0xff   // cyan
+	pal[4*3+2], pal[4*3+1] = 0xff, 0xff // yellow
+	data := []byte{
+		0x03, 0x01, // a run of 3 red pixels
+		0x00, 0x00, // end of the bottom row
+		0x00, 0x03, 0x02, 0x03, 0x02, 0x00, // 3 literal pixels padded to 16-bits
+		0x00, 0x02, 0x00, 0x01, // a move to the next row
+		0x01, 0x03, // a yellow pixel
+		0x00, 0x01, // end of the bitmap
+	}
+	m, err := bmp.Decode(bytes.NewReader(info(4, 3, 8, 1, pal, data)))
+	assert.Nil(t, err)
+	assert.Equal(t, red, rgba(m, 0, 2))
+	assert.Equal(t, red, rgba(m, 2, 2))
+	assert.Equal(t, black, rgba(m, 3, 2))
+	assert.Equal(t, color.RGBA{0, 0xff, 0xff, 0xff}, rgba(m, 0, 1))
+	assert.Equal(t, color.RGBA{0xff, 0xff, 0, 0xff}, rgba(m, 1, 1))
+	assert.Equal(t, color.RGBA{0, 0xff, 0xff, 0xff}, rgba(m, 2, 1))
+	assert.Equal(t, black, rgba(m, 0, 0))
+	assert.Equal(t, color.RGBA{0xff, 0xff, 0, 0xff}, rgba(m, 3, 0))
+}
+
+func TestDecode_RLE4(t *testing.T) {
+	t.Parallel()
+	// only 2 palette entries are stored, the other colors are black
+	pal := []byte{0, 0, 0, 0, 0xff, 0xff, 0xff, 0}
+	data := []byte{
+		0x03, 0x10, // a run of 3 alternating white and black pixels
+		0x00, 0x03, 0x01, 0x10, // 3 literal pixels padded to 16-bits
+		0x00, 0x01,
+	}
+	b := info(6, 1, 4, 2, pal, data)
+	binary.LittleEndian.PutUint32(b[46:], 2)
+	m, err := bmp.Decode(bytes.NewReader(b))
+	assert.Nil(t, err)
+	assert.Equal(t, white, rgba(m, 0, 0))
+	assert.Equal(t, black, rgba(m, 1, 0))
+	assert.Equal(t, white, rgba(m, 2, 0))
+	assert.Equal(t, black, rgba(m, 3, 0))
+	assert.Equal(t, white, rgba(m, 4, 0))
+	assert.Equal(t, white, rgba(m, 5, 0))
+	p, ok := m.(*image.Paletted)
+	assert.True(t, ok)
+	assert.Len(t, p.Palette, 16)
+}
+
+func TestDecode_Bitfields(t *testing.T) {
+	t.Parallel()
+	// the 16-bit 5-6-5 masks follow the header
+	masks := make([]byte, 12)
+	binary.LittleEndian.PutUint32(masks[0:], 0xf800)
+	binary.LittleEndian.PutUint32(masks[4:], 0x07e0)
+	binary.LittleEndian.PutUint32(masks[8:], 0x001f)
+	data := []byte{0x00, 0xf8, 0xe0, 0x07}
+	m, err := bmp.Decode(bytes.NewReader(info(2, 1, 16, 3, masks, data)))
+	assert.Nil(t, err)
+	assert.Equal(t, red, rgba(m, 0, 0))
+	assert.Equal(t, color.RGBA{0, 0xff, 0, 0xff}, rgba(m, 1, 0))
+}
+
+func TestDecode_TopDown(t *testing.T) {
+	t.Parallel()
+	// the negative height stores the rows from the top, and the unused alpha bytes are opaque
+	data := []byte{0, 0, 0xff, 0, 0xff, 0xff, 0xff, 0}
+	m, err := bmp.Decode(bytes.NewReader(info(1, -2, 32, 0, nil, data)))
+	assert.Nil(t, err)
+	assert.Equal(t, image.Rect(0, 0, 1, 2), m.Bounds())
+	assert.Equal(t, red, rgba(m, 0, 0))
+	assert.Equal(t, white, rgba(m, 0, 1))
+}
+
+func TestDecodeConfig(t *testing.T) {
+	t.Parallel()
+	c, err := bmp.DecodeConfig(bytes.NewReader(info(320, 200, 24, 0, nil, nil)))
+	assert.Nil(t, err)
+	assert.Equal(t, 320, c.Width)
+	assert.Equal(t, 200, c.Height)
+	assert.Equal(t, color.NRGBAModel, c.ColorModel)
+	_, err = bmp.DecodeConfig(bytes.NewReader(info(320, 200, 24, 1, nil, nil)))
+	assert.ErrorIs(t, err, bmp.ErrCompression)
+	_, err = bmp.DecodeConfig(bytes.NewReader(info(0, 200, 24, 0, nil, nil)))
+	assert.ErrorIs(t, err, bmp.ErrHeader)
+	_, err = bmp.DecodeConfig(bytes.NewReader([]byte("not an image")))
+	assert.ErrorIs(t, err, bmp.ErrHeader)
+}
@@ -5,26 +5,29 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
-	"log"
 	"os"
-	"path"
 	"path/filepath"
 	"strings"
 
-	"github.com/Defacto2/df2/pkg/conf"
 	"github.com/Defacto2/df2/pkg/directories"
 	"github.com/dustin/go-humanize"
 	"github.com/gookit/color"
-	gap "github.com/muesli/go-app-paths"
 )
 
 var ErrPointer = errors.New("pointer value cannot be nil")
 
 const (
+	bmp  = ".bmp"
 	gif  = ".gif"
+	iff  = ".iff"
+	ilbm = ".ilbm"
 	jpg  = ".jpg"
 	jpeg = ".jpeg"
+	lbm  = ".lbm"
+	pcx  = ".pcx"
+	pic  = ".pic"
 	_png = ".png"
+	tga  = ".tga"
 	tif  = ".tif"
 	tiff = ".tiff"
 )
@@ -48,7 +51,7 @@ func (i Image) String() string {
 
 func (i Image) IsExt() bool {
 	switch filepath.Ext(strings.ToLower(i.Name)) {
-	case gif, jpg, jpeg, _png, tif, tiff:
+	case bmp, gif, iff, ilbm, jpg, jpeg, lbm, pcx, pic, _png, tga, tif, tiff:
 		return true
 	}
 	return false
@@ -111,16 +114,3 @@ func Remove0byte(name string) error {
 	}
 	return nil
 }
-
-// Vendor is the absolute path to store webpbin vendor downloads.
-func Vendor() string {
-	fp, err := gap.NewScope(gap.User, conf.GapUser).CacheDir()
-	if err != nil {
-		h, err := os.UserHomeDir()
-		if err != nil {
-			log.Print(fmt.Errorf("vendorPath userhomedir: %w", err))
-		}
-		return path.Join(h, ".vendor/df2")
-	}
-	return fp
-}
@@ -53,6 +53,10 @@ func TestIsExt(t *testing.T) {
 		{"png", file.Image{Name: "some.png"}, true},
 		{"jpeg", file.Image{Name: "some other.jpeg"}, true},
 		{"jpeg", file.Image{Name: "some.other.jpeg"}, true},
+		{"lbm", file.Image{Name: "DPAINT.LBM"}, true},
+		{"pcx", file.Image{Name: "screen.pcx"}, true},
+		{"bmp", file.Image{Name: "OS2LOGO.BMP"}, true},
+		{"pic", file.Image{Name: "title.pic"}, true},
 	}
 	for _, tt := range tests {
 		tt := tt
@@ -95,9 +99,3 @@ func TestRemove(t *testing.T) {
 	err = file.Remove0byte(src)
 	assert.Nil(t, err)
 }
-
-func TestVendor(t *testing.T) {
-	t.Parallel()
-	s := file.Vendor()
-	assert.NotEqual(t, "", s)
-}
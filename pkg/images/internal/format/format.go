@@ -0,0 +1,156 @@
+// Package format encodes the optional AVIF and JPEG XL image formats
+// using their reference command-line encoders.
+package format
+
+/*
+format requires the installation of the libavif and libjxl tools.
+ubuntu: sudo apt install libavif-bin libjxl-tools
+
+AVIF is the AV1 Image File Format.
+https://github.com/AOMediaCodec/libavif
+
+JPEG XL is the successor to the JPEG format.
+https://github.com/libjxl/libjxl
+*/
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+var (
+	ErrFmt  = errors.New("unsupported image format")
+	ErrProg = errors.New("image format encoder is not installed")
+	ErrSrc  = errors.New("src file does not exist")
+)
+
+// Format is an optional image format that is generated alongside the PNG and WebP images.
+type Format string
+
+const (
+	AVIF Format = "avif" // AVIF is the AV1 Image File Format.
+	JXL  Format = "jxl"  // JXL is the JPEG XL image format.
+)
+
+// timeout is the maximum duration of an encode, large previews using the slower efforts can take a while.
+const timeout = 2 * time.Minute
+
+// All returns the names of the supported formats.
+func All() []string {
+	return []string{string(AVIF), string(JXL)}
+}
+
+// Parse returns the formats of the named values, which can also be comma-separated lists.
+// The names are case-insensitive, duplicates are ignored and an empty value returns no formats.
+func Parse(names ...string) ([]Format, error) {
+	fmts := []Format{}
+	seen := map[Format]bool{}
+	for _, name := range names {
+		for _, s := range strings.Split(name, ",") {
+			s = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(s), "."))
+			if s == "" {
+				continue
+			}
+			f := Format(s)
+			switch f {
+			case AVIF, JXL:
+			case "jpegxl", "jpeg-xl":
+				f = JXL
+			default:
+				return nil, fmt.Errorf("%w: %q", ErrFmt, s)
+			}
+			if seen[f] {
+				continue
+			}
+			seen[f] = true
+			fmts = append(fmts, f)
+		}
+	}
+	return fmts, nil
+}
+
+// Ext returns the filename extension of the format.
+func (f Format) Ext() string {
+	return "." + string(f)
+}
+
+// Program returns the name of the command-line encoder of the format.
+func (f Format) Program() string {
+	switch f {
+	case AVIF:
+		return "avifenc"
+	case JXL:
+		return "cjxl"
+	}
+	return ""
+}
+
+// Installed returns an error listing the encoder programs of the formats that cannot be found.
+func Installed(fmts ...Format) error {
+	missing := []string{}
+	for _, f := range fmts {
+		prog := f.Program()
+		if prog == "" {
+			return fmt.Errorf("%w: %q", ErrFmt, f)
+		}
+		if _, err := exec.LookPath(prog); err != nil {
+			missing = append(missing, prog)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrProg, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// args returns the encoder arguments to convert the src image to dest.
+func (f Format) args(src, dest string) []string {
+	switch f {
+	case AVIF:
+		// quantizers of 10-30 are a good quality for screenshots and pixel art
+		return []string{"--speed", "6", "--min", "10", "--max", "30", src, dest}
+	case JXL:
+		// distance 1 is visually lossless
+		return []string{src, dest, "--distance", "1", "--effort", "7"}
+	}
+	return nil
+}
+
+// Convert uses the encoder of the format to convert the src PNG or JPEG image to dest.
+func (f Format) Convert(w io.Writer, src, dest string) error {
+	if w == nil {
+		w = io.Discard
+	}
+	prog := f.Program()
+	if prog == "" {
+		return fmt.Errorf("%w: %q", ErrFmt, f)
+	}
+	if _, err := os.Stat(src); errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrSrc, src)
+	} else if err != nil {
+		return err
+	}
+	path, err := exec.LookPath(prog)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	args := f.args(src, dest)
+	cmd := exec.CommandContext(ctx, path, args...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		fmt.Fprintf(w, "%s: %s", prog, string(out))
+		// remove any incomplete image
+		_ = os.Remove(dest)
+		return fmt.Errorf("%s %s: %w", prog, f, err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package format_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Defacto2/df2/pkg/images/internal/format"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		names   []string
+		want    []format.Format
+		wantErr bool
+	}{
+		{"none", nil, []format.Format{}, false},
+		{"empty", []string{""}, []format.Format{}, false},
+		{"avif", []string{"avif"}, []format.Format{format.AVIF}, false},
+		{"list", []string{"AVIF, .jxl"}, []format.Format{format.AVIF, format.JXL}, false},
+		{"alias", []string{"jpegxl"}, []format.Format{format.JXL}, false},
+		{"dupes", []string{"jxl", "avif,jxl"}, []format.Format{format.JXL, format.AVIF}, false},
+		{"unknown", []string{"avif", "webm"}, nil, true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := format.Parse(tt.names...)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, ".avif", format.AVIF.Ext())
+	assert.Equal(t, "avifenc", format.AVIF.Program())
+	assert.Equal(t, ".jxl", format.JXL.Ext())
+	assert.Equal(t, "cjxl", format.JXL.Program())
+	assert.Equal(t, "", format.Format("webm").Program())
+}
+
+func TestInstalled(t *testing.T) {
+	t.Setenv("PATH", "")
+	assert.Nil(t, format.Installed())
+	err := format.Installed(format.Format("webm"))
+	assert.ErrorIs(t, err, format.ErrFmt)
+	err = format.Installed(format.AVIF, format.JXL)
+	assert.ErrorIs(t, err, format.ErrProg)
+	assert.Contains(t, err.Error(), "avifenc, cjxl")
+}
+
+func TestConvert(t *testing.T) {
+	t.Parallel()
+	png := filepath.Join("..", "..", "..", "..", "testdata", "images", "test.png")
+	dest := filepath.Join(os.TempDir(), "test_format.avif")
+	err := format.Format("webm").Convert(nil, png, dest)
+	assert.ErrorIs(t, err, format.ErrFmt)
+	err = format.AVIF.Convert(nil, "abcde", dest)
+	assert.ErrorIs(t, err, format.ErrSrc)
+	err = format.JXL.Convert(nil, "", dest)
+	assert.ErrorIs(t, err, format.ErrSrc)
+}
@@ -0,0 +1,403 @@
+// Package ilbm decodes the IFF ILBM interleaved bitmap images of the Commodore Amiga
+// and the PBM chunky bitmap images of Deluxe Paint, which are both often saved as LBM files.
+//
+// The decoder supports the ByteRun1 compression, 1 to 8 bitplane palette images,
+// the Extra Half-Brite (EHB) and Hold And Modify (HAM6 and HAM8) display modes,
+// and the 24-bit and 32-bit true color images.
+//
+// Importing this package registers the ilbm and lbm formats with the image package.
+package ilbm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+var (
+	ErrBody     = errors.New("ilbm body chunk is missing")
+	ErrForm     = errors.New("not an iff ilbm or pbm image")
+	ErrHeader   = errors.New("ilbm bitmap header is missing or invalid")
+	ErrPlanes   = errors.New("unsupported number of ilbm bitplanes")
+	ErrCompress = errors.New("unsupported ilbm compression")
+)
+
+const (
+	ilbm = "ILBM" // ilbm is the form type of the interleaved bitmap.
+	pbm  = "PBM " // pbm is the form type of the Deluxe Paint chunky bitmap.
+
+	ehb = 0x80  // ehb is the Extra Half-Brite flag of the CAMG viewport mode.
+	ham = 0x800 // ham is the Hold And Modify flag of the CAMG viewport mode.
+
+	hasMask  = 1 // hasMask is the masking value of images with an extra mask bitplane.
+	byteRun1 = 1 // byteRun1 is the compression value of the ByteRun1 run-length encoding.
+)
+
+func init() { //nolint:gochecknoinits
+	image.RegisterFormat("ilbm", "FORM????ILBM", Decode, DecodeConfig)
+	image.RegisterFormat("lbm", "FORM????PBM ", Decode, DecodeConfig)
+}
+
+// header is the BMHD bitmap header chunk.
+type header struct {
+	Width       uint16
+	Height      uint16
+	X, Y        int16
+	Planes      uint8
+	Masking     uint8
+	Compression uint8
+	Pad         uint8
+	Transparent uint16
+	XAspect     uint8
+	YAspect     uint8
+	PageWidth   int16
+	PageHeight  int16
+}
+
+// form is the parsed content of the IFF file.
+type form struct {
+	kind string
+	bmhd header
+	cmap []byte
+	camg uint32
+	body []byte
+}
+
+// Decode reads an IFF ILBM or PBM image from r.
+// Palette images are returned as an *image.Paletted, while HAM and true color images are an *image.RGBA.
+func Decode(r io.Reader) (image.Image, error) {
+	f, err := parse(r, true)
+	if err != nil {
+		return nil, err
+	}
+	return f.decode()
+}
+
+// DecodeConfig returns the color model and dimensions of an IFF ILBM or PBM image without decoding the bitmap.
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	f, err := parse(r, false)
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{
+		ColorModel: f.model(),
+		Width:      int(f.bmhd.Width),
+		Height:     int(f.bmhd.Height),
+	}, nil
+}
+
+// parse reads the chunks of the IFF form, the BODY chunk is only read when body is true.
+func parse(r io.Reader, body bool) (form, error) {
+	f := form{}
+	var head [12]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return f, fmt.Errorf("%w: %w", ErrForm, err)
+	}
+	if string(head[0:4]) != "FORM" {
+		return f, ErrForm
+	}
+	f.kind = string(head[8:12])
+	if f.kind != ilbm && f.kind != pbm {
+		return f, ErrForm
+	}
+	found := false
+	for {
+		var ch [8]byte
+		if _, err := io.ReadFull(r, ch[:]); err != nil {
+			if found && !body {
+				return f, nil
+			}
+			if found {
+				return f, ErrBody
+			}
+			return f, ErrHeader
+		}
+		id, size := string(ch[0:4]), int64(binary.BigEndian.Uint32(ch[4:8]))
+		if id == "BODY" && !body {
+			if !found {
+				return f, ErrHeader
+			}
+			return f, nil
+		}
+		data, err := chunk(r, id, size)
+		if err != nil {
+			return f, err
+		}
+		switch id {
+		case "BMHD":
+			if err := binary.Read(bytes.NewReader(data), binary.BigEndian, &f.bmhd); err != nil {
+				return f, fmt.Errorf("%w: %w", ErrHeader, err)
+			}
+			if f.bmhd.Width == 0 || f.bmhd.Height == 0 {
+				return f, ErrHeader
+			}
+			found = true
+		case "CMAP":
+			f.cmap = data
+		case "CAMG":
+			if len(data) >= 4 {
+				f.camg = binary.BigEndian.Uint32(data)
+			}
+		case "BODY":
+			if !found {
+				return f, ErrHeader
+			}
+			f.body = data
+			return f, nil
+		}
+	}
+}
+
+// chunk reads the data of a chunk and skips the pad byte of chunks with an odd size.
+// Unused chunks are skipped without being kept.
+func chunk(r io.Reader, id string, size int64) ([]byte, error) {
+	n := size + size&1
+	switch id {
+	case "BMHD", "CMAP", "CAMG", "BODY":
+	default:
+		if _, err := io.CopyN(io.Discard, r, n); err != nil {
+			return nil, fmt.Errorf("ilbm %s chunk: %w", id, err)
+		}
+		return nil, nil
+	}
+	buf := &bytes.Buffer{}
+	// a truncated body is still decoded
+	if _, err := io.CopyN(buf, r, n); err != nil && !(id == "BODY" && errors.Is(err, io.EOF)) {
+		return nil, fmt.Errorf("ilbm %s chunk: %w", id, err)
+	}
+	b := buf.Bytes()
+	if int64(len(b)) > size {
+		b = b[:size]
+	}
+	return b, nil
+}
+
+// model returns the color model of the decoded image.
+func (f form) model() color.Model {
+	switch {
+	case f.ham(), f.bmhd.Planes > 8:
+		return color.RGBAModel
+	}
+	return f.palette()
+}
+
+// ham returns true when the image uses the Hold And Modify display mode.
+func (f form) ham() bool {
+	if f.kind != ilbm {
+		return false
+	}
+	p := f.bmhd.Planes
+	if f.camg&ham != 0 {
+		return p == 6 || p == 8
+	}
+	// images without a CAMG chunk that have 6 bitplanes but only 16 colors are HAM6
+	return f.camg == 0 && p == 6 && len(f.cmap)/3 == 16
+}
+
+// ehb returns true when the image uses the Extra Half-Brite display mode.
+func (f form) ehb() bool {
+	if f.kind != ilbm || f.bmhd.Planes != 6 || f.ham() {
+		return false
+	}
+	return f.camg&ehb != 0 || len(f.cmap)/3 <= 32
+}
+
+// palette returns the colors of the CMAP chunk, or a grayscale palette when it is missing.
+func (f form) palette() color.Palette {
+	planes := min(int(f.bmhd.Planes), 8)
+	size := 1 << planes
+	pal := make(color.Palette, size)
+	n := len(f.cmap) / 3
+	if n == 0 {
+		for i := range pal {
+			v := uint8(i * 0xff / max(size-1, 1))
+			pal[i] = color.RGBA{v, v, v, 0xff}
+		}
+		return pal
+	}
+	// early Amiga programs only saved the 4-bit high nibble of each color
+	nibbles := true
+	for _, c := range f.cmap[:n*3] {
+		if c&0x0f != 0 {
+			nibbles = false
+			break
+		}
+	}
+	for i := range pal {
+		if i >= n {
+			pal[i] = color.RGBA{0, 0, 0, 0xff}
+			continue
+		}
+		r, g, b := f.cmap[i*3], f.cmap[i*3+1], f.cmap[i*3+2]
+		if nibbles {
+			r, g, b = r|r>>4, g|g>>4, b|b>>4
+		}
+		pal[i] = color.RGBA{r, g, b, 0xff}
+	}
+	if f.ehb() {
+		// the upper 32 colors are the lower 32 colors at half brightness
+		for i := 32; i < 64; i++ {
+			c, _ := pal[i-32].(color.RGBA)
+			pal[i] = color.RGBA{c.R >> 1, c.G >> 1, c.B >> 1, 0xff}
+		}
+	}
+	return pal
+}
+
+// rows returns the uncompressed body as rows of rowSize bytes.
+func (f form) rows(rowSize int) ([]byte, error) {
+	size := rowSize * int(f.bmhd.Height)
+	switch f.bmhd.Compression {
+	case 0:
+		b := make([]byte, size)
+		copy(b, f.body)
+		return b, nil
+	case byteRun1:
+		return unpack(f.body, size), nil
+	}
+	return nil, fmt.Errorf("%w: %d", ErrCompress, f.bmhd.Compression)
+}
+
+// unpack decompresses the ByteRun1 run-length encoded src into size bytes.
+// Truncated data is padded with zeros.
+func unpack(src []byte, size int) []byte {
+	dst := make([]byte, 0, size)
+	for i := 0; i < len(src) && len(dst) < size; {
+		n := int(int8(src[i]))
+		i++
+		switch {
+		case n >= 0:
+			end := min(i+n+1, len(src))
+			dst = append(dst, src[i:end]...)
+			i = end
+		case n != -128:
+			if i >= len(src) {
+				break
+			}
+			for j := 0; j < 1-n; j++ {
+				dst = append(dst, src[i])
+			}
+			i++
+		}
+	}
+	if len(dst) > size {
+		return dst[:size]
+	}
+	return append(dst, make([]byte, size-len(dst))...)
+}
+
+// decode the bitmap of the body chunk.
+func (f form) decode() (image.Image, error) {
+	w, h := int(f.bmhd.Width), int(f.bmhd.Height)
+	rect := image.Rect(0, 0, w, h)
+	if f.kind == pbm {
+		if f.bmhd.Planes != 8 {
+			return nil, fmt.Errorf("%w: %d", ErrPlanes, f.bmhd.Planes)
+		}
+		// chunky pixels with each row padded to an even length
+		stride := w + w&1
+		data, err := f.rows(stride)
+		if err != nil {
+			return nil, err
+		}
+		img := image.NewPaletted(rect, f.palette())
+		for y := 0; y < h; y++ {
+			copy(img.Pix[y*img.Stride:y*img.Stride+w], data[y*stride:])
+		}
+		return img, nil
+	}
+	planes := int(f.bmhd.Planes)
+	switch {
+	case planes >= 1 && planes <= 8, planes == 24, planes == 32:
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrPlanes, planes)
+	}
+	stored := planes
+	if f.bmhd.Masking == hasMask {
+		stored++
+	}
+	rowBytes := (w + 15) / 16 * 2
+	data, err := f.rows(rowBytes * stored)
+	if err != nil {
+		return nil, err
+	}
+	// combine the bitplanes of each row into chunky pixel values
+	px := make([]uint32, w)
+	chunky := func(y int) {
+		clear(px)
+		row := data[y*rowBytes*stored:]
+		for p := 0; p < planes; p++ {
+			plane := row[p*rowBytes : (p+1)*rowBytes]
+			for x := 0; x < w; x++ {
+				if plane[x>>3]&(0x80>>(x&7)) != 0 {
+					px[x] |= 1 << p
+				}
+			}
+		}
+	}
+	switch {
+	case planes > 8:
+		img := image.NewRGBA(rect)
+		for y := 0; y < h; y++ {
+			chunky(y)
+			for x, v := range px {
+				a := uint8(0xff)
+				if planes == 32 {
+					a = uint8(v >> 24)
+				}
+				img.SetRGBA(x, y, color.RGBA{uint8(v), uint8(v >> 8), uint8(v >> 16), a})
+			}
+		}
+		return img, nil
+	case f.ham():
+		return f.hold(rect, chunky, px), nil
+	}
+	img := image.NewPaletted(rect, f.palette())
+	for y := 0; y < h; y++ {
+		chunky(y)
+		for x, v := range px {
+			img.Pix[y*img.Stride+x] = uint8(v)
+		}
+	}
+	return img, nil
+}
+
+// hold decodes the Hold And Modify pixels, where the top two bitplanes either select a palette color,
+// or hold the color of the previous pixel and modify its blue, red or green value.
+func (f form) hold(rect image.Rectangle, chunky func(int), px []uint32) *image.RGBA {
+	const (
+		set, blue, red, green = 0, 1, 2, 3
+	)
+	bits := uint(f.bmhd.Planes - 2)
+	mask := uint32(1)<<bits - 1
+	pal := f.palette()
+	// the modified value replaces the most significant bits of the color
+	modify := func(v uint32) uint8 {
+		c := uint8(v << (8 - bits))
+		return c | c>>bits
+	}
+	img := image.NewRGBA(rect)
+	for y := 0; y < rect.Dy(); y++ {
+		chunky(y)
+		// each row starts with the background color
+		c, _ := color.RGBAModel.Convert(pal[0]).(color.RGBA)
+		for x, v := range px {
+			switch v >> bits {
+			case set:
+				c, _ = color.RGBAModel.Convert(pal[v&mask]).(color.RGBA)
+			case blue:
+				c.B = modify(v & mask)
+			case red:
+				c.R = modify(v & mask)
+			case green:
+				c.G = modify(v & mask)
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
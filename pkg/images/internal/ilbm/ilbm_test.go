@@ -0,0 +1,174 @@
+package ilbm_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Defacto2/df2/pkg/images/internal/ilbm"
+	"github.com/stretchr/testify/assert"
+)
+
+func testIff() string {
+	return filepath.Join("..", "..", "..", "..", "testdata", "images", "test.iff")
+}
+
+// iff returns an IFF form of the chunks, which are pairs of chunk ids and data.
+func iff(kind string, chunks ...any) []byte {
+	body := &bytes.Buffer{}
+	body.WriteString(kind)
+	for i := 0; i < len(chunks); i += 2 {
+		id, _ := chunks[i].(string)
+		data, _ := chunks[i+1].([]byte)
+		body.WriteString(id)
+		_ = binary.Write(body, binary.BigEndian, uint32(len(data)))
+		body.Write(data)
+		if len(data)%2 == 1 {
+			body.WriteByte(0)
+		}
+	}
+	b := &bytes.Buffer{}
+	b.WriteString("FORM")
+	_ = binary.Write(b, binary.BigEndian, uint32(body.Len()))
+	b.Write(body.Bytes())
+	return b.Bytes()
+}
+
+// bmhd returns a bitmap header of an uncompressed image.
+func bmhd(w, h, planes int) []byte {
+	b := make([]byte, 20)
+	binary.BigEndian.PutUint16(b[0:], uint16(w))
+	binary.BigEndian.PutUint16(b[2:], uint16(h))
+	b[8] = uint8(planes)
+	return b
+}
+
+// planar returns the interleaved bitplanes of a row of chunky pixel values.
+func planar(planes int, px ...int) []byte {
+	rowBytes := (len(px) + 15) / 16 * 2
+	b := make([]byte, rowBytes*planes)
+	for p := 0; p < planes; p++ {
+		for x, v := range px {
+			if v&(1<<p) != 0 {
+				b[p*rowBytes+x/8] |= 0x80 >> (x % 8)
+			}
+		}
+	}
+	return b
+}
+
+func rgba(m image.Image, x, y int) color.RGBA {
+	c, _ := color.RGBAModel.Convert(m.At(x, y)).(color.RGBA)
+	return c
+}
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+	f, err := os.Open(testIff())
+	assert.Nil(t, err)
+	defer f.Close()
+	m, name, err := image.Decode(f)
+	assert.Nil(t, err)
+	assert.Equal(t, "ilbm", name)
+	assert.Equal(t, image.Rect(0, 0, 1280, 32), m.Bounds())
+	assert.Equal(t, color.RGBA{0, 0, 0, 0xff}, rgba(m, 0, 0))
+	lit := 0
+	for x := 0; x < 200; x++ {
+		if rgba(m, x, 8) == (color.RGBA{0xaa, 0xaa, 0xaa, 0xff}) {
+			lit++
+		}
+	}
+	assert.Greater(t, lit, 0)
+
+	_, err = ilbm.Decode(bytes.NewReader(nil))
+	assert.ErrorIs(t, err, ilbm.ErrForm)
+	_, err = ilbm.Decode(bytes.NewReader(iff("ILBM", "BODY", []byte{0})))
+	assert.ErrorIs(t, err, ilbm.ErrHeader)
+	_, err = ilbm.Decode(bytes.NewReader(iff("ILBM", "BMHD", bmhd(2, 1, 9), "BODY", make([]byte, 36))))
+	assert.ErrorIs(t, err, ilbm.ErrPlanes)
+}
+
+func TestDecodeConfig(t *testing.T) {
+	t.Parallel()
+	f, err := os.Open(testIff())
+	assert.Nil(t, err)
+	defer f.Close()
+	c, name, err := image.DecodeConfig(f)
+	assert.Nil(t, err)
+	assert.Equal(t, "ilbm", name)
+	assert.Equal(t, 1280, c.Width)
+	assert.Equal(t, 32, c.Height)
+	pal, ok := c.ColorModel.(color.Palette)
+	assert.True(t, ok)
+	assert.Len(t, pal, 4)
+}
+
+func TestDecode_ByteRun1(t *testing.T) {
+	t.Parallel()
+	hdr := bmhd(16, 1, 1)
+	hdr[10] = 1 // ByteRun1 compression
+	// a run of 2 bytes of 0xf0 in the bitplane
+	b := iff("ILBM", "BMHD", hdr, "CMAP", []byte{0, 0, 0, 0xf0, 0xf0, 0xf0}, "BODY", []byte{0xff, 0xf0})
+	m, err := ilbm.Decode(bytes.NewReader(b))
+	assert.Nil(t, err)
+	white, black := color.RGBA{0xff, 0xff, 0xff, 0xff}, color.RGBA{0, 0, 0, 0xff}
+	assert.Equal(t, white, rgba(m, 0, 0))
+	assert.Equal(t, white, rgba(m, 3, 0))
+	assert.Equal(t, black, rgba(m, 4, 0))
+	assert.Equal(t, white, rgba(m, 8, 0))
+}
+
+func TestDecode_EHB(t *testing.T) {
+	t.Parallel()
+	cmap := make([]byte, 32*3)
+	cmap[3], cmap[4], cmap[5] = 0xc8, 0x64, 0x20
+	b := iff("ILBM", "BMHD", bmhd(2, 1, 6), "CMAP", cmap, "CAMG", []byte{0, 0, 0, 0x80},
+		"BODY", planar(6, 1, 33))
+	m, err := ilbm.Decode(bytes.NewReader(b))
+	assert.Nil(t, err)
+	assert.Equal(t, color.RGBA{0xc8, 0x64, 0x20, 0xff}, rgba(m, 0, 0))
+	assert.Equal(t, color.RGBA{0x64, 0x32, 0x10, 0xff}, rgba(m, 1, 0))
+}
+
+func TestDecode_HAM(t *testing.T) {
+	t.Parallel()
+	cmap := make([]byte, 16*3)
+	cmap[3] = 0xf0 // color 1 is red
+	// set color 1, modify blue to 0xf, modify green to 0x8, modify red to 0x0
+	px := []int{0<<4 | 1, 1<<4 | 0xf, 3<<4 | 0x8, 2<<4 | 0x0}
+	b := iff("ILBM", "BMHD", bmhd(4, 1, 6), "CMAP", cmap, "CAMG", []byte{0, 0, 0x08, 0},
+		"BODY", planar(6, px...))
+	m, err := ilbm.Decode(bytes.NewReader(b))
+	assert.Nil(t, err)
+	assert.Equal(t, color.RGBA{0xff, 0, 0, 0xff}, rgba(m, 0, 0))
+	assert.Equal(t, color.RGBA{0xff, 0, 0xff, 0xff}, rgba(m, 1, 0))
+	assert.Equal(t, color.RGBA{0xff, 0x88, 0xff, 0xff}, rgba(m, 2, 0))
+	assert.Equal(t, color.RGBA{0, 0x88, 0xff, 0xff}, rgba(m, 3, 0))
+}
+
+func TestDecode_TrueColor(t *testing.T) {
+	t.Parallel()
+	b := iff("ILBM", "BMHD", bmhd(2, 1, 24), "BODY", planar(24, 0x102030, 0xffeedd))
+	m, err := ilbm.Decode(bytes.NewReader(b))
+	assert.Nil(t, err)
+	assert.Equal(t, color.RGBA{0x30, 0x20, 0x10, 0xff}, rgba(m, 0, 0))
+	assert.Equal(t, color.RGBA{0xdd, 0xee, 0xff, 0xff}, rgba(m, 1, 0))
+}
+
+func TestDecode_PBM(t *testing.T) {
+	t.Parallel()
+	cmap := make([]byte, 256*3)
+	cmap[5*3], cmap[5*3+1], cmap[5*3+2] = 0x11, 0x22, 0x33
+	// chunky rows are padded to an even length
+	b := iff("PBM ", "BMHD", bmhd(3, 2, 8), "CMAP", cmap, "BODY", []byte{5, 0, 0, 0, 0, 0, 5, 0})
+	m, name, err := image.Decode(bytes.NewReader(b))
+	assert.Nil(t, err)
+	assert.Equal(t, "lbm", name)
+	assert.Equal(t, color.RGBA{0x11, 0x22, 0x33, 0xff}, rgba(m, 0, 0))
+	assert.Equal(t, color.RGBA{0, 0, 0, 0xff}, rgba(m, 1, 0))
+	assert.Equal(t, color.RGBA{0x11, 0x22, 0x33, 0xff}, rgba(m, 2, 1))
+}
@@ -0,0 +1,283 @@
+// Package pcx decodes the ZSoft PC Paintbrush PCX images that were common on DOS.
+//
+// The decoder supports the run-length encoded monochrome, CGA, EGA and VGA palette images,
+// as well as the 24-bit and 32-bit true color images.
+//
+// Importing this package registers the pcx format with the image package.
+package pcx
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+var (
+	ErrHeader = errors.New("not a pcx image")
+	ErrDepth  = errors.New("unsupported pcx color depth")
+)
+
+const (
+	manufacturer = 0x0a // manufacturer is the ZSoft identifier of the first byte.
+	vgaMarker    = 0x0c // vgaMarker precedes the 256 color palette at the end of the file.
+	vgaLen       = 768  // vgaLen is the size of the 256 color palette.
+	noPalette    = 3    // noPalette is the version of PC Paintbrush 2.8 that saves without a palette.
+)
+
+func init() { //nolint:gochecknoinits
+	// the versions are 0, 2, 3, 4 and 5 using the run-length encoding
+	for _, v := range []string{"\x00", "\x02", "\x03", "\x04", "\x05"} {
+		image.RegisterFormat("pcx", "\x0a"+v+"\x01", Decode, DecodeConfig)
+	}
+}
+
+// header is the PCX file header.
+type header struct {
+	Manufacturer uint8
+	Version      uint8
+	Encoding     uint8
+	BitsPerPixel uint8
+	XMin, YMin   uint16
+	XMax, YMax   uint16
+	HDPI, VDPI   uint16
+	Palette      [48]byte
+	Reserved     uint8
+	Planes       uint8
+	BytesPerLine uint16
+	PaletteInfo  uint16
+	HScreenSize  uint16
+	VScreenSize  uint16
+	Filler       [54]byte
+}
+
+func (h header) width() int  { return int(h.XMax) - int(h.XMin) + 1 }
+func (h header) height() int { return int(h.YMax) - int(h.YMin) + 1 }
+
+// truecolor returns true when the planes are the red, green, blue and alpha channels.
+func (h header) truecolor() bool {
+	return h.BitsPerPixel == 8 && (h.Planes == 3 || h.Planes == 4)
+}
+
+func readHeader(r io.Reader) (header, error) {
+	h := header{}
+	if err := binary.Read(r, binary.LittleEndian, &h); err != nil {
+		return h, fmt.Errorf("%w: %w", ErrHeader, err)
+	}
+	if h.Manufacturer != manufacturer || h.Encoding > 1 || h.XMax < h.XMin || h.YMax < h.YMin {
+		return h, ErrHeader
+	}
+	switch {
+	case h.truecolor():
+	case h.Planes == 1 && (h.BitsPerPixel == 1 || h.BitsPerPixel == 2 ||
+		h.BitsPerPixel == 4 || h.BitsPerPixel == 8):
+	case h.BitsPerPixel == 1 && h.Planes <= 4:
+	default:
+		return h, fmt.Errorf("%w: %d bits and %d planes", ErrDepth, h.BitsPerPixel, h.Planes)
+	}
+	if need := (h.width()*int(h.BitsPerPixel) + 7) / 8; int(h.BytesPerLine) < need {
+		return h, ErrHeader
+	}
+	return h, nil
+}
+
+// Decode reads a PCX image from r.
+// Palette images are returned as an *image.Paletted and true color images are an *image.NRGBA.
+func Decode(r io.Reader) (image.Image, error) {
+	h, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(r)
+	w, ht := h.width(), h.height()
+	line := int(h.BytesPerLine) * int(h.Planes)
+	data := make([]byte, line*ht)
+	if h.Encoding == 0 {
+		if _, err := io.ReadFull(br, data); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("pcx data: %w", err)
+		}
+	} else {
+		// a truncated image is still decoded
+		data = unpack(br, data)
+	}
+	rect := image.Rect(0, 0, w, ht)
+	if h.truecolor() {
+		img := image.NewNRGBA(rect)
+		bpl := int(h.BytesPerLine)
+		for y := 0; y < ht; y++ {
+			row := data[y*line:]
+			for x := 0; x < w; x++ {
+				a := uint8(0xff)
+				if h.Planes == 4 {
+					a = row[3*bpl+x]
+				}
+				img.SetNRGBA(x, y, color.NRGBA{row[x], row[bpl+x], row[2*bpl+x], a})
+			}
+		}
+		return img, nil
+	}
+	pal := palette(h)
+	if h.BitsPerPixel == 8 {
+		pal = vga(br)
+	}
+	img := image.NewPaletted(rect, pal)
+	for y := 0; y < ht; y++ {
+		row := data[y*line : (y+1)*line]
+		for x := 0; x < w; x++ {
+			img.Pix[y*img.Stride+x] = index(h, row, x)
+		}
+	}
+	return img, nil
+}
+
+// DecodeConfig returns the color model and dimensions of a PCX image without decoding the bitmap.
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	h, err := readHeader(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	var model color.Model = color.NRGBAModel
+	if !h.truecolor() {
+		model = palette(h)
+	}
+	return image.Config{ColorModel: model, Width: h.width(), Height: h.height()}, nil
+}
+
+// unpack decompresses the run-length encoding of r into data,
+// where a byte with the two high bits set is the repeat count of the next byte.
+func unpack(r io.ByteReader, data []byte) []byte {
+	for i := 0; i < len(data); {
+		b, err := r.ReadByte()
+		if err != nil {
+			break
+		}
+		n := 1
+		if b&0xc0 == 0xc0 {
+			n = int(b & 0x3f)
+			if b, err = r.ReadByte(); err != nil {
+				break
+			}
+		}
+		for ; n > 0 && i < len(data); n-- {
+			data[i] = b
+			i++
+		}
+	}
+	return data
+}
+
+// index returns the palette index of the x pixel in the row of the image.
+func index(h header, row []byte, x int) uint8 {
+	bpp := int(h.BitsPerPixel)
+	if h.Planes == 1 {
+		bit := x * bpp
+		shift := 8 - bpp - bit&7
+		return row[bit>>3] >> shift & (1<<bpp - 1)
+	}
+	// the bitplanes of EGA images
+	v := uint8(0)
+	for p := 0; p < int(h.Planes); p++ {
+		if row[p*int(h.BytesPerLine)+x>>3]&(0x80>>(x&7)) != 0 {
+			v |= 1 << p
+		}
+	}
+	return v
+}
+
+// palette returns the 16 color palette of the header, or the default palettes of the color depth.
+func palette(h header) color.Palette {
+	colors := 1 << (int(h.BitsPerPixel) * int(h.Planes))
+	switch {
+	case colors == 2:
+		return color.Palette{color.Black, color.White}
+	case colors == 4 && h.Planes == 1:
+		return cga(h)
+	case colors > 16:
+		return grays()
+	}
+	blank := true
+	for _, c := range h.Palette {
+		if c != 0 {
+			blank = false
+			break
+		}
+	}
+	pal := make(color.Palette, colors)
+	for i := range pal {
+		if h.Version == noPalette || blank {
+			pal[i] = ega[i]
+			continue
+		}
+		pal[i] = color.RGBA{h.Palette[i*3], h.Palette[i*3+1], h.Palette[i*3+2], 0xff}
+	}
+	return pal
+}
+
+// cga returns the CGA palette of the 4 color images, where the high nibble of the first
+// palette byte is the background color and the fourth byte selects the foreground colors.
+func cga(h header) color.Palette {
+	const selectPal, intensity = 0x40, 0x20
+	bg := ega[h.Palette[0]>>4]
+	sel := h.Palette[3]
+	if sel == 0 {
+		// the common bright cyan, magenta and white palette
+		return color.Palette{bg, ega[11], ega[13], ega[15]}
+	}
+	fg := []int{2, 4, 6} // green, red and brown
+	if sel&selectPal != 0 {
+		fg = []int{3, 5, 7} // cyan, magenta and light gray
+	}
+	pal := color.Palette{bg}
+	for _, i := range fg {
+		if sel&intensity != 0 {
+			i += 8
+		}
+		pal = append(pal, ega[i])
+	}
+	return pal
+}
+
+// vga returns the 256 color palette that follows the run-length encoded data.
+func vga(r io.Reader) color.Palette {
+	b, err := io.ReadAll(r)
+	if err != nil || len(b) < vgaLen+1 || b[len(b)-vgaLen-1] != vgaMarker {
+		return grays()
+	}
+	b = b[len(b)-vgaLen:]
+	pal := make(color.Palette, 256)
+	for i := range pal {
+		pal[i] = color.RGBA{b[i*3], b[i*3+1], b[i*3+2], 0xff}
+	}
+	return pal
+}
+
+func grays() color.Palette {
+	pal := make(color.Palette, 256)
+	for i := range pal {
+		pal[i] = color.RGBA{uint8(i), uint8(i), uint8(i), 0xff}
+	}
+	return pal
+}
+
+// ega is the default 16 color palette of the EGA and VGA text modes.
+var ega = color.Palette{ //nolint:gochecknoglobals
+	color.RGBA{0x00, 0x00, 0x00, 0xff},
+	color.RGBA{0x00, 0x00, 0xaa, 0xff},
+	color.RGBA{0x00, 0xaa, 0x00, 0xff},
+	color.RGBA{0x00, 0xaa, 0xaa, 0xff},
+	color.RGBA{0xaa, 0x00, 0x00, 0xff},
+	color.RGBA{0xaa, 0x00, 0xaa, 0xff},
+	color.RGBA{0xaa, 0x55, 0x00, 0xff},
+	color.RGBA{0xaa, 0xaa, 0xaa, 0xff},
+	color.RGBA{0x55, 0x55, 0x55, 0xff},
+	color.RGBA{0x55, 0x55, 0xff, 0xff},
+	color.RGBA{0x55, 0xff, 0x55, 0xff},
+	color.RGBA{0x55, 0xff, 0xff, 0xff},
+	color.RGBA{0xff, 0x55, 0x55, 0xff},
+	color.RGBA{0xff, 0x55, 0xff, 0xff},
+	color.RGBA{0xff, 0xff, 0x55, 0xff},
+	color.RGBA{0xff, 0xff, 0xff, 0xff},
+}
@@ -0,0 +1,100 @@
+package pcx_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/Defacto2/df2/pkg/images/internal/pcx"
+	"github.com/stretchr/testify/assert"
+)
+
+// header returns a run-length encoded PCX header.
+func header(w, h, bits, planes, bytesPerLine int) []byte {
+	b := make([]byte, 128)
+	b[0], b[1], b[2], b[3] = 0x0a, 5, 1, uint8(bits)
+	binary.LittleEndian.PutUint16(b[8:], uint16(w-1))
+	binary.LittleEndian.PutUint16(b[10:], uint16(h-1))
+	b[65] = uint8(planes)
+	binary.LittleEndian.PutUint16(b[66:], uint16(bytesPerLine))
+	return b
+}
+
+func rgba(m image.Image, x, y int) color.RGBA {
+	c, _ := color.RGBAModel.Convert(m.At(x, y)).(color.RGBA)
+	return c
+}
+
+func TestDecode_VGA(t *testing.T) {
+	t.Parallel()
+	b := header(4, 2, 8, 1, 4)
+	// a run of 6 pixels of color 7, then the literals 1 and 2
+	b = append(b, 0xc6, 7, 1, 2)
+	pal := make([]byte, 768)
+	pal[7*3], pal[7*3+1], pal[7*3+2] = 0x10, 0x20, 0x30
+	pal[2*3] = 0xff
+	b = append(b, 0x0c)
+	b = append(b, pal...)
+	m, name, err := image.Decode(bytes.NewReader(b))
+	assert.Nil(t, err)
+	assert.Equal(t, "pcx", name)
+	assert.Equal(t, image.Rect(0, 0, 4, 2), m.Bounds())
+	assert.Equal(t, color.RGBA{0x10, 0x20, 0x30, 0xff}, rgba(m, 0, 0))
+	assert.Equal(t, color.RGBA{0x10, 0x20, 0x30, 0xff}, rgba(m, 1, 1))
+	assert.Equal(t, color.RGBA{0, 0, 0, 0xff}, rgba(m, 2, 1))
+	assert.Equal(t, color.RGBA{0xff, 0, 0, 0xff}, rgba(m, 3, 1))
+}
+
+func TestDecode_Mono(t *testing.T) {
+	t.Parallel()
+	b := header(10, 1, 1, 1, 2)
+	b = append(b, 0xa0, 0x40)
+	m, err := pcx.Decode(bytes.NewReader(b))
+	assert.Nil(t, err)
+	white, black := color.RGBA{0xff, 0xff, 0xff, 0xff}, color.RGBA{0, 0, 0, 0xff}
+	assert.Equal(t, white, rgba(m, 0, 0))
+	assert.Equal(t, black, rgba(m, 1, 0))
+	assert.Equal(t, white, rgba(m, 2, 0))
+	assert.Equal(t, white, rgba(m, 9, 0))
+}
+
+func TestDecode_EGA(t *testing.T) {
+	t.Parallel()
+	b := header(2, 1, 1, 4, 2)
+	// the pixels are colors 5 and 10 of the default palette
+	b = append(b, 0x80, 0, 0x40, 0, 0x80, 0, 0x40, 0)
+	m, err := pcx.Decode(bytes.NewReader(b))
+	assert.Nil(t, err)
+	assert.Equal(t, color.RGBA{0xaa, 0, 0xaa, 0xff}, rgba(m, 0, 0))
+	assert.Equal(t, color.RGBA{0x55, 0xff, 0x55, 0xff}, rgba(m, 1, 0))
+}
+
+func TestDecode_TrueColor(t *testing.T) {
+	t.Parallel()
+	b := header(1, 1, 8, 3, 2)
+	b = append(b, 0x11, 0, 0x22, 0, 0xc1, 0xc3, 0)
+	m, err := pcx.Decode(bytes.NewReader(b))
+	assert.Nil(t, err)
+	assert.Equal(t, color.RGBA{0x11, 0x22, 0xc3, 0xff}, rgba(m, 0, 0))
+}
+
+func TestDecodeConfig(t *testing.T) {
+	t.Parallel()
+	c, name, err := image.DecodeConfig(bytes.NewReader(header(320, 200, 2, 1, 80)))
+	assert.Nil(t, err)
+	assert.Equal(t, "pcx", name)
+	assert.Equal(t, 320, c.Width)
+	assert.Equal(t, 200, c.Height)
+	pal, ok := c.ColorModel.(color.Palette)
+	assert.True(t, ok)
+	assert.Len(t, pal, 4)
+
+	_, err = pcx.DecodeConfig(bytes.NewReader(header(320, 200, 16, 1, 640)))
+	assert.ErrorIs(t, err, pcx.ErrDepth)
+	_, err = pcx.DecodeConfig(bytes.NewReader(header(320, 200, 8, 1, 100)))
+	assert.ErrorIs(t, err, pcx.ErrHeader)
+	_, err = pcx.DecodeConfig(bytes.NewReader([]byte("GIF89a")))
+	assert.ErrorIs(t, err, pcx.ErrHeader)
+}
@@ -0,0 +1,285 @@
+// Package pic decodes the PC Paint and Pictor PIC images by John Bridges that were common on DOS.
+//
+// The decoder supports the run-length encoded and uncompressed CGA, EGA and VGA images,
+// that use packed pixels of 1, 2, 4 or 8 bits, or up to 4 bitplanes.
+//
+// Importing this package registers the pic format with the image package.
+package pic
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+var (
+	ErrHeader = errors.New("not a pic image")
+	ErrDepth  = errors.New("unsupported pic color depth")
+)
+
+const (
+	magic     = 0x1234 // magic is the identifier of the first two bytes.
+	extended  = 0xff   // extended is the palette flag of the header that is followed by a palette.
+	blockLen  = 5      // blockLen is the size of the header of a run-length encoded block.
+	cgaPal    = 1      // cgaPal is the palette type of the CGA palette and intensity selection.
+	pcjrPal   = 2      // pcjrPal is the palette type of the PCjr and Tandy 16 color indexes.
+	egaPal    = 3      // egaPal is the palette type of the EGA 64 color indexes.
+	vgaPal    = 4      // vgaPal is the palette type of the VGA 18-bit colors.
+	vgaPalAlt = 5      // vgaPalAlt is the palette type of the VGA 18-bit colors saved by some programs.
+)
+
+func init() { //nolint:gochecknoinits
+	image.RegisterFormat("pic", "\x34\x12", Decode, DecodeConfig)
+}
+
+// header is the PIC file header.
+type header struct {
+	Magic     uint16
+	Width     uint16
+	Height    uint16
+	XOffset   uint16
+	YOffset   uint16
+	PlaneInfo uint8
+}
+
+// bits returns the number of bits of a pixel in each plane.
+func (h header) bits() int { return int(h.PlaneInfo & 0x0f) }
+
+// planes returns the number of bitplanes.
+func (h header) planes() int { return int(h.PlaneInfo>>4) + 1 }
+
+// palette is the optional header that follows the PIC file header.
+type palette struct {
+	Flag      uint8
+	VideoMode uint8
+	Type      uint16
+	Size      uint16
+}
+
+// readHeader reads the headers and the palette of r.
+func readHeader(r *bufio.Reader) (header, color.Palette, error) {
+	h := header{}
+	if err := binary.Read(r, binary.LittleEndian, &h); err != nil {
+		return h, nil, fmt.Errorf("%w: %w", ErrHeader, err)
+	}
+	if h.Magic != magic || h.Width == 0 || h.Height == 0 {
+		return h, nil, ErrHeader
+	}
+	bits, planes := h.bits(), h.planes()
+	switch {
+	case bits == 8 && planes == 1:
+	case (bits == 1 || bits == 2 || bits == 4) && bits*planes <= 8:
+	default:
+		return h, nil, fmt.Errorf("%w: %d bits and %d planes", ErrDepth, bits, planes)
+	}
+	p := palette{}
+	// the original PC Paint images have no palette header
+	bpp := bits * planes
+	if b, err := r.Peek(1); err == nil && (b[0] == extended || bpp == 1 || bpp == 4 || bpp == 8) {
+		if err := binary.Read(r, binary.LittleEndian, &p); err != nil {
+			return h, nil, fmt.Errorf("%w: %w", ErrHeader, err)
+		}
+	}
+	b := make([]byte, p.Size)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return h, nil, fmt.Errorf("pic palette: %w", err)
+	}
+	return h, colors(p.Type, b, bpp), nil
+}
+
+// colors returns the palette of the palette type and data, which is padded with black to the color depth.
+func colors(kind uint16, b []byte, bpp int) color.Palette {
+	pal := color.Palette{}
+	switch {
+	case kind == cgaPal && len(b) > 0 && int(b[0]) < len(mode45):
+		for _, i := range mode45[b[0]] {
+			pal = append(pal, cga(i))
+		}
+	case kind == pcjrPal:
+		for _, i := range b[:min(len(b), 16)] {
+			pal = append(pal, cga(min(int(i), 15)))
+		}
+	case kind == egaPal:
+		for _, i := range b[:min(len(b), 16)] {
+			pal = append(pal, ega(i))
+		}
+	case kind == vgaPal || kind == vgaPalAlt:
+		six := func(c byte) uint8 {
+			c &= 0x3f
+			return c<<2 | c>>4
+		}
+		for i := 0; i+2 < len(b) && len(pal) < 256; i += 3 {
+			pal = append(pal, color.RGBA{six(b[i]), six(b[i+1]), six(b[i+2]), 0xff})
+		}
+	case bpp == 1:
+		pal = color.Palette{color.Black, color.White}
+	case bpp == 2:
+		for _, i := range mode45[0] {
+			pal = append(pal, cga(i))
+		}
+	default:
+		for i := 0; i < 16; i++ {
+			pal = append(pal, cga(i))
+		}
+	}
+	n := 1 << bpp
+	if len(pal) > n {
+		return pal[:n]
+	}
+	for len(pal) < n {
+		pal = append(pal, color.RGBA{0, 0, 0, 0xff})
+	}
+	return pal
+}
+
+// Decode reads a PIC image from r as an *image.Paletted.
+func Decode(r io.Reader) (image.Image, error) {
+	br := bufio.NewReader(r)
+	h, pal, err := readHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	var count uint16
+	if err := binary.Read(br, binary.LittleEndian, &count); err != nil {
+		return nil, fmt.Errorf("pic blocks: %w", err)
+	}
+	img := image.NewPaletted(image.Rect(0, 0, int(h.Width), int(h.Height)), pal)
+	p := newPixels(img, h)
+	if count == 0 {
+		// a truncated image is still decoded
+		for !p.done {
+			b, err := br.ReadByte()
+			if err != nil {
+				break
+			}
+			p.put(b)
+		}
+		return img, nil
+	}
+	for i := 0; i < int(count) && !p.done; i++ {
+		if !block(br, p) {
+			break
+		}
+	}
+	return img, nil
+}
+
+// DecodeConfig returns the color model and dimensions of a PIC image without decoding the bitmap.
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	h, pal, err := readHeader(bufio.NewReader(r))
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{ColorModel: pal, Width: int(h.Width), Height: int(h.Height)}, nil
+}
+
+// block decompresses a run-length encoded block of r into the pixels and returns false at the end of the data.
+// The block header is the size of the block, the size of the unencoded data and a marker byte,
+// where the marker is followed by an 8-bit count and the byte to repeat,
+// or a zero, a 16-bit count and the byte to repeat.
+func block(r io.Reader, p *pixels) bool {
+	var head [blockLen]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return false
+	}
+	size := int(binary.LittleEndian.Uint16(head[0:]))
+	if size < blockLen {
+		return false
+	}
+	marker := head[4]
+	b := make([]byte, size-blockLen)
+	n, _ := io.ReadFull(r, b)
+	full := n == len(b)
+	b = b[:n]
+	for i := 0; i < len(b) && !p.done; {
+		v, run := b[i], 1
+		i++
+		if v == marker {
+			if i >= len(b) {
+				break
+			}
+			run = int(b[i])
+			i++
+			if run == 0 {
+				if i+1 >= len(b) {
+					break
+				}
+				run = int(binary.LittleEndian.Uint16(b[i:]))
+				i += 2
+			}
+			if i >= len(b) {
+				break
+			}
+			v = b[i]
+			i++
+		}
+		for ; run > 0 && !p.done; run-- {
+			p.put(v)
+		}
+	}
+	return full
+}
+
+// pixels writes the packed pixels into the image, where the rows are stored from the bottom
+// and each bitplane holds every row, before the rows of the next bitplane.
+type pixels struct {
+	img    *image.Paletted
+	bits   int
+	planes int
+	x      int
+	y      int
+	plane  int
+	done   bool
+}
+
+func newPixels(img *image.Paletted, h header) *pixels {
+	return &pixels{img: img, bits: h.bits(), planes: h.planes(), y: int(h.Height) - 1}
+}
+
+// put writes the pixels of the data byte v.
+func (p *pixels) put(v byte) {
+	w, mask := p.img.Rect.Dx(), byte(1<<p.bits-1)
+	for j := 8 - p.bits; j >= 0 && !p.done; j -= p.bits {
+		p.img.Pix[p.y*p.img.Stride+p.x] |= (v >> j & mask) << (p.plane * p.bits)
+		p.x++
+		if p.x < w {
+			continue
+		}
+		p.x, p.y = 0, p.y-1
+		if p.y >= 0 {
+			continue
+		}
+		p.y, p.plane = p.img.Rect.Dy()-1, p.plane+1
+		p.done = p.plane >= p.planes
+	}
+}
+
+// mode45 are the CGA colors of the 4 color palettes of the video modes 4 and 5,
+// using the low and then the high intensities.
+var mode45 = [6][4]int{ //nolint:gochecknoglobals
+	{0, 3, 5, 7},
+	{0, 2, 4, 6},
+	{0, 3, 4, 7},
+	{0, 11, 13, 15},
+	{0, 10, 12, 14},
+	{0, 11, 12, 15},
+}
+
+// cga returns the color of the 16 color CGA palette, which is the default palette of the EGA.
+func cga(i int) color.RGBA {
+	defaults := [16]byte{0, 1, 2, 3, 4, 5, 20, 7, 56, 57, 58, 59, 60, 61, 62, 63}
+	return ega(defaults[i&0x0f])
+}
+
+// ega returns the color of the 64 color EGA palette, where the low 3 bits of i
+// are the blue, green and red at two-thirds intensity and the next 3 bits are at one-third.
+func ega(i byte) color.RGBA {
+	c := func(hi, lo byte) uint8 {
+		return (i>>hi&1)*0xaa + (i>>lo&1)*0x55
+	}
+	return color.RGBA{c(2, 5), c(1, 4), c(0, 3), 0xff}
+}
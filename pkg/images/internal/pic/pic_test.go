@@ -0,0 +1,109 @@
+package pic_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/Defacto2/df2/pkg/images/internal/pic"
+	"github.com/stretchr/testify/assert"
+)
+
+// header returns a PIC header followed by the palette header and palette data.
+func header(w, h int, planeInfo uint8, kind int, pal []byte) []byte {
+	b := make([]byte, 17)
+	binary.LittleEndian.PutUint16(b[0:], 0x1234)
+	binary.LittleEndian.PutUint16(b[2:], uint16(w))
+	binary.LittleEndian.PutUint16(b[4:], uint16(h))
+	b[10], b[11] = planeInfo, 0xff
+	binary.LittleEndian.PutUint16(b[13:], uint16(kind))
+	binary.LittleEndian.PutUint16(b[15:], uint16(len(pal)))
+	return append(b, pal...)
+}
+
+// blocks returns the run-length encoded data in a single block using the marker.
+func blocks(marker byte, data ...byte) []byte {
+	b := []byte{1, 0}
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(data)+5))
+	b = binary.LittleEndian.AppendUint16(b, 0)
+	b = append(b, marker)
+	return append(b, data...)
+}
+
+func rgba(m image.Image, x, y int) color.RGBA {
+	c, _ := color.RGBAModel.Convert(m.At(x, y)).(color.RGBA)
+	return c
+}
+
+func TestDecode_VGA(t *testing.T) {
+	t.Parallel()
+	// the 18-bit palette uses 6-bit values
+	pal := make([]byte, 768)
+	pal[3], pal[4], pal[5] = 0x3f, 0, 0
+	pal[6], pal[7], pal[8] = 0x20, 0x10, 0x3f
+	b := header(3, 2, 0x08, 4, pal)
+	// a run of 4 pixels of color 1, then the literals 2 and 0
+	b = append(b, blocks(0xaa, 0xaa, 4, 1, 2, 0)...)
+	m, name, err := image.Decode(bytes.NewReader(b))
+	assert.Nil(t, err)
+	assert.Equal(t, "pic", name)
+	assert.Equal(t, image.Rect(0, 0, 3, 2), m.Bounds())
+	// the rows are stored from the bottom
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	assert.Equal(t, red, rgba(m, 0, 1))
+	assert.Equal(t, red, rgba(m, 2, 1))
+	assert.Equal(t, red, rgba(m, 0, 0))
+	assert.Equal(t, color.RGBA{0x82, 0x41, 0xff, 0xff}, rgba(m, 1, 0))
+	assert.Equal(t, color.RGBA{0, 0, 0, 0xff}, rgba(m, 2, 0))
+}
+
+func TestDecode_EGA(t *testing.T) {
+	t.Parallel()
+	// 4 bitplanes of 1 bit using the default palette
+	b := header(8, 1, 0x31, 0, nil)
+	// the blue and green planes are set by a 16-bit run, the red plane is the literal 0xf0
+	// and the intensity plane is empty
+	b = append(b, blocks(0x99, 0x99, 0, 2, 0, 0xff, 0xf0, 0x00)...)
+	m, err := pic.Decode(bytes.NewReader(b))
+	assert.Nil(t, err)
+	assert.Equal(t, color.RGBA{0xaa, 0xaa, 0xaa, 0xff}, rgba(m, 0, 0))
+	assert.Equal(t, color.RGBA{0xaa, 0xaa, 0xaa, 0xff}, rgba(m, 3, 0))
+	assert.Equal(t, color.RGBA{0, 0xaa, 0xaa, 0xff}, rgba(m, 4, 0))
+	assert.Equal(t, color.RGBA{0, 0xaa, 0xaa, 0xff}, rgba(m, 7, 0))
+}
+
+func TestDecode_CGA(t *testing.T) {
+	t.Parallel()
+	// the original 4 color images have no palette header and the data is uncompressed
+	b := header(4, 2, 0x02, 0, nil)[:11]
+	b = append(b, 0, 0, 0x1b, 0xe4)
+	m, err := pic.Decode(bytes.NewReader(b))
+	assert.Nil(t, err)
+	black, white := color.RGBA{0, 0, 0, 0xff}, color.RGBA{0xaa, 0xaa, 0xaa, 0xff}
+	assert.Equal(t, black, rgba(m, 0, 1))
+	assert.Equal(t, color.RGBA{0, 0xaa, 0xaa, 0xff}, rgba(m, 1, 1))
+	assert.Equal(t, color.RGBA{0xaa, 0, 0xaa, 0xff}, rgba(m, 2, 1))
+	assert.Equal(t, white, rgba(m, 3, 1))
+	assert.Equal(t, white, rgba(m, 0, 0))
+	assert.Equal(t, black, rgba(m, 3, 0))
+}
+
+func TestDecodeConfig(t *testing.T) {
+	t.Parallel()
+	c, err := pic.DecodeConfig(bytes.NewReader(header(320, 200, 0x02, 1, []byte{3})))
+	assert.Nil(t, err)
+	assert.Equal(t, 320, c.Width)
+	assert.Equal(t, 200, c.Height)
+	pal, ok := c.ColorModel.(color.Palette)
+	assert.True(t, ok)
+	assert.Len(t, pal, 4)
+	assert.Equal(t, color.RGBA{0x55, 0xff, 0xff, 0xff}, pal[1])
+	_, err = pic.DecodeConfig(bytes.NewReader(header(320, 200, 0x18, 0, nil)))
+	assert.ErrorIs(t, err, pic.ErrDepth)
+	_, err = pic.DecodeConfig(bytes.NewReader(header(0, 200, 0x08, 0, nil)))
+	assert.ErrorIs(t, err, pic.ErrHeader)
+	_, err = pic.DecodeConfig(bytes.NewReader([]byte("not an image")))
+	assert.ErrorIs(t, err, pic.ErrHeader)
+}
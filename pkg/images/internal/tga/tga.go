@@ -0,0 +1,272 @@
+// Package tga decodes the Truevision TGA, also known as TARGA, images.
+//
+// The decoder supports the uncompressed and run-length encoded color-mapped,
+// true color and grayscale images, using 8, 15, 16, 24 or 32 bits per pixel.
+//
+// Importing this package registers the tga format with the image package.
+// TGA files have no signature, so only the common combinations of the color map
+// and image types of the header are registered.
+package tga
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+var (
+	ErrHeader = errors.New("not a tga image")
+	ErrDepth  = errors.New("unsupported tga pixel depth")
+)
+
+const (
+	colorMapped = 1 // colorMapped is the image type of palette images.
+	trueColor   = 2 // trueColor is the image type of the RGB images.
+	grayscale   = 3 // grayscale is the image type of the black and white images.
+	rle         = 8 // rle is added to the image type of the run-length encoded images.
+
+	rightToLeft = 0x10 // rightToLeft is the descriptor bit of images stored from the right.
+	topToBottom = 0x20 // topToBottom is the descriptor bit of images stored from the top.
+	alphaBits   = 0x0f // alphaBits are the descriptor bits of the number of alpha channel bits.
+)
+
+func init() { //nolint:gochecknoinits
+	for _, magic := range []string{
+		"?\x01\x01", "?\x01\x09", // color-mapped
+		"?\x00\x02", "?\x00\x0a", // true color
+		"?\x00\x03", "?\x00\x0b", // grayscale
+	} {
+		image.RegisterFormat("tga", magic, Decode, DecodeConfig)
+	}
+}
+
+// header is the TGA file header.
+type header struct {
+	IDLength      uint8
+	ColorMapType  uint8
+	ImageType     uint8
+	ColorMapFirst uint16
+	ColorMapLen   uint16
+	ColorMapDepth uint8
+	XOrigin       uint16
+	YOrigin       uint16
+	Width         uint16
+	Height        uint16
+	Depth         uint8
+	Descriptor    uint8
+}
+
+// kind returns the image type without the run-length encoding.
+func (h header) kind() uint8 {
+	return h.ImageType &^ rle
+}
+
+func readHeader(r io.Reader) (header, error) {
+	h := header{}
+	if err := binary.Read(r, binary.LittleEndian, &h); err != nil {
+		return h, fmt.Errorf("%w: %w", ErrHeader, err)
+	}
+	if h.Width == 0 || h.Height == 0 || h.ColorMapType > 1 {
+		return h, ErrHeader
+	}
+	switch h.kind() {
+	case colorMapped:
+		if h.ColorMapType != 1 || h.ColorMapLen == 0 || (h.Depth != 8 && h.Depth != 16) {
+			return h, fmt.Errorf("%w: %d bit color-mapped", ErrDepth, h.Depth)
+		}
+		if !depth(h.ColorMapDepth) {
+			return h, fmt.Errorf("%w: %d bit color map", ErrDepth, h.ColorMapDepth)
+		}
+	case trueColor:
+		if !depth(h.Depth) || h.Depth == 8 {
+			return h, fmt.Errorf("%w: %d bit true color", ErrDepth, h.Depth)
+		}
+	case grayscale:
+		if h.Depth != 8 && h.Depth != 16 {
+			return h, fmt.Errorf("%w: %d bit grayscale", ErrDepth, h.Depth)
+		}
+	default:
+		return h, fmt.Errorf("%w: image type %d", ErrHeader, h.ImageType)
+	}
+	return h, nil
+}
+
+func depth(d uint8) bool {
+	switch d {
+	case 8, 15, 16, 24, 32:
+		return true
+	}
+	return false
+}
+
+// Decode reads a TGA image from r.
+// Color-mapped images are returned as an *image.Paletted, grayscale images are an *image.Gray
+// and true color images are an *image.NRGBA.
+func Decode(r io.Reader) (image.Image, error) {
+	h, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(r)
+	if _, err := br.Discard(int(h.IDLength)); err != nil {
+		return nil, fmt.Errorf("tga id: %w", err)
+	}
+	pal, err := colorMap(br, h)
+	if err != nil {
+		return nil, err
+	}
+	w, ht := int(h.Width), int(h.Height)
+	bpp := (int(h.Depth) + 7) / 8
+	data := make([]byte, w*ht*bpp)
+	if h.ImageType&rle != 0 {
+		// a truncated image is still decoded
+		data = unpack(br, data, bpp)
+	} else if _, err := io.ReadFull(br, data); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("tga data: %w", err)
+	}
+	// pos returns the offset of the pixel data of the x and y coordinates
+	pos := func(x, y int) int {
+		if h.Descriptor&topToBottom == 0 {
+			y = ht - 1 - y
+		}
+		if h.Descriptor&rightToLeft != 0 {
+			x = w - 1 - x
+		}
+		return (y*w + x) * bpp
+	}
+	rect := image.Rect(0, 0, w, ht)
+	switch h.kind() {
+	case colorMapped:
+		img := image.NewPaletted(rect, pal)
+		first := int(h.ColorMapFirst)
+		for y := 0; y < ht; y++ {
+			for x := 0; x < w; x++ {
+				i := int(data[pos(x, y)])
+				if bpp == 2 {
+					i |= int(data[pos(x, y)+1]) << 8
+				}
+				img.Pix[y*img.Stride+x] = uint8(min(max(i-first, 0), len(pal)-1))
+			}
+		}
+		return img, nil
+	case grayscale:
+		img := image.NewGray(rect)
+		for y := 0; y < ht; y++ {
+			for x := 0; x < w; x++ {
+				img.Pix[y*img.Stride+x] = data[pos(x, y)]
+			}
+		}
+		return img, nil
+	}
+	alpha := h.Descriptor&alphaBits != 0
+	img := image.NewNRGBA(rect)
+	for y := 0; y < ht; y++ {
+		for x := 0; x < w; x++ {
+			p := pos(x, y)
+			img.SetNRGBA(x, y, pixel(data[p:p+bpp], alpha))
+		}
+	}
+	return img, nil
+}
+
+// DecodeConfig returns the color model and dimensions of a TGA image without decoding the bitmap.
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	h, err := readHeader(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	var model color.Model = color.NRGBAModel
+	switch h.kind() {
+	case colorMapped:
+		br := bufio.NewReader(r)
+		if _, err := br.Discard(int(h.IDLength)); err != nil {
+			return image.Config{}, fmt.Errorf("tga id: %w", err)
+		}
+		pal, err := colorMap(br, h)
+		if err != nil {
+			return image.Config{}, err
+		}
+		model = pal
+	case grayscale:
+		model = color.GrayModel
+	}
+	return image.Config{ColorModel: model, Width: int(h.Width), Height: int(h.Height)}, nil
+}
+
+// colorMap reads the palette of the color map, which is also skipped for images that do not use it.
+func colorMap(r io.Reader, h header) (color.Palette, error) {
+	if h.ColorMapType == 0 {
+		return nil, nil
+	}
+	bpp := (int(h.ColorMapDepth) + 7) / 8
+	b := make([]byte, int(h.ColorMapLen)*bpp)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, fmt.Errorf("tga color map: %w", err)
+	}
+	if h.kind() != colorMapped {
+		return nil, nil
+	}
+	// the image package only supports palettes of up to 256 colors
+	n := min(int(h.ColorMapLen), 256)
+	pal := make(color.Palette, n)
+	for i := range pal {
+		pal[i] = pixel(b[i*bpp:(i+1)*bpp], false)
+	}
+	return pal, nil
+}
+
+// unpack decompresses the run-length encoded packets of r into data, where the high bit of the packet header
+// is set for a run of a repeated pixel, otherwise the header is followed by the raw pixels.
+func unpack(r io.Reader, data []byte, bpp int) []byte {
+	px := make([]byte, bpp)
+	var head [1]byte
+	for i := 0; i < len(data); {
+		if _, err := io.ReadFull(r, head[:]); err != nil {
+			break
+		}
+		n := int(head[0]&0x7f) + 1
+		if head[0]&0x80 != 0 {
+			if _, err := io.ReadFull(r, px); err != nil {
+				break
+			}
+			for ; n > 0 && i < len(data); n-- {
+				i += copy(data[i:], px)
+			}
+			continue
+		}
+		end := min(i+n*bpp, len(data))
+		c, _ := io.ReadFull(r, data[i:end])
+		if c < end-i {
+			break
+		}
+		i = end
+	}
+	return data
+}
+
+// pixel returns the color of the little-endian BGR or BGRA pixel value.
+// The 15 and 16-bit pixels use 5 bits for each channel.
+func pixel(b []byte, alpha bool) color.NRGBA {
+	switch len(b) {
+	case 1:
+		return color.NRGBA{b[0], b[0], b[0], 0xff}
+	case 2:
+		v := uint16(b[0]) | uint16(b[1])<<8
+		five := func(c uint16) uint8 {
+			c &= 0x1f
+			return uint8(c<<3 | c>>2)
+		}
+		return color.NRGBA{five(v >> 10), five(v >> 5), five(v), 0xff}
+	case 3:
+		return color.NRGBA{b[2], b[1], b[0], 0xff}
+	}
+	a := uint8(0xff)
+	if alpha {
+		a = b[3]
+	}
+	return color.NRGBA{b[2], b[1], b[0], a}
+}
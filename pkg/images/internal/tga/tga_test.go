@@ -0,0 +1,97 @@
+package tga_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/Defacto2/df2/pkg/images/internal/tga"
+	"github.com/stretchr/testify/assert"
+)
+
+// header returns a TGA header without a color map.
+func header(kind, w, h, depth, descriptor int) []byte {
+	b := make([]byte, 18)
+	b[2] = uint8(kind)
+	binary.LittleEndian.PutUint16(b[12:], uint16(w))
+	binary.LittleEndian.PutUint16(b[14:], uint16(h))
+	b[16], b[17] = uint8(depth), uint8(descriptor)
+	return b
+}
+
+func rgba(m image.Image, x, y int) color.RGBA {
+	c, _ := color.RGBAModel.Convert(m.At(x, y)).(color.RGBA)
+	return c
+}
+
+func TestDecode_TrueColor(t *testing.T) {
+	t.Parallel()
+	// the rows are stored from the bottom using blue, green and red values
+	b := header(2, 2, 2, 24, 0)
+	b = append(b, 1, 2, 3, 4, 5, 6, 0xff, 0, 0, 0, 0xff, 0)
+	m, name, err := image.Decode(bytes.NewReader(b))
+	assert.Nil(t, err)
+	assert.Equal(t, "tga", name)
+	assert.Equal(t, color.RGBA{0, 0, 0xff, 0xff}, rgba(m, 0, 0))
+	assert.Equal(t, color.RGBA{0, 0xff, 0, 0xff}, rgba(m, 1, 0))
+	assert.Equal(t, color.RGBA{3, 2, 1, 0xff}, rgba(m, 0, 1))
+	assert.Equal(t, color.RGBA{6, 5, 4, 0xff}, rgba(m, 1, 1))
+}
+
+func TestDecode_RLE(t *testing.T) {
+	t.Parallel()
+	// the rows are stored from the top, with an 8-bit alpha channel
+	b := header(10, 3, 1, 32, 0x28)
+	// a run of 2 pixels, then 1 raw pixel
+	b = append(b, 0x81, 0, 0, 0xff, 0xff, 0x00, 0xff, 0, 0, 0)
+	m, err := tga.Decode(bytes.NewReader(b))
+	assert.Nil(t, err)
+	assert.Equal(t, color.RGBA{0xff, 0, 0, 0xff}, rgba(m, 0, 0))
+	assert.Equal(t, color.RGBA{0xff, 0, 0, 0xff}, rgba(m, 1, 0))
+	assert.Equal(t, color.RGBA{0, 0, 0, 0}, rgba(m, 2, 0))
+}
+
+func TestDecode_ColorMapped(t *testing.T) {
+	t.Parallel()
+	b := header(1, 2, 1, 8, 0x20)
+	b[1] = 1                                    // color map type
+	binary.LittleEndian.PutUint16(b[5:], 2)     // color map length
+	b[7] = 16                                   // color map depth
+	b = append(b, 0x00, 0x7c, 0x1f, 0x00, 1, 0) // red, blue
+	m, err := tga.Decode(bytes.NewReader(b))
+	assert.Nil(t, err)
+	_, ok := m.(*image.Paletted)
+	assert.True(t, ok)
+	assert.Equal(t, color.RGBA{0, 0, 0xff, 0xff}, rgba(m, 0, 0))
+	assert.Equal(t, color.RGBA{0xff, 0, 0, 0xff}, rgba(m, 1, 0))
+}
+
+func TestDecode_Grayscale(t *testing.T) {
+	t.Parallel()
+	b := header(3, 2, 1, 8, 0x30)
+	b = append(b, 0x10, 0x80)
+	m, err := tga.Decode(bytes.NewReader(b))
+	assert.Nil(t, err)
+	// the pixels are stored from the right
+	assert.Equal(t, color.RGBA{0x80, 0x80, 0x80, 0xff}, rgba(m, 0, 0))
+	assert.Equal(t, color.RGBA{0x10, 0x10, 0x10, 0xff}, rgba(m, 1, 0))
+}
+
+func TestDecodeConfig(t *testing.T) {
+	t.Parallel()
+	c, name, err := image.DecodeConfig(bytes.NewReader(header(2, 640, 480, 24, 0)))
+	assert.Nil(t, err)
+	assert.Equal(t, "tga", name)
+	assert.Equal(t, 640, c.Width)
+	assert.Equal(t, 480, c.Height)
+	assert.Equal(t, color.NRGBAModel, c.ColorModel)
+
+	_, err = tga.DecodeConfig(bytes.NewReader(header(2, 640, 480, 12, 0)))
+	assert.ErrorIs(t, err, tga.ErrDepth)
+	_, err = tga.DecodeConfig(bytes.NewReader(header(2, 0, 480, 24, 0)))
+	assert.ErrorIs(t, err, tga.ErrHeader)
+	_, err = tga.DecodeConfig(bytes.NewReader(header(5, 640, 480, 24, 0)))
+	assert.ErrorIs(t, err, tga.ErrHeader)
+}
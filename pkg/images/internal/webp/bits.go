@@ -0,0 +1,28 @@
+package webp
+
+// bitWriter writes the least significant bits first, which is the bit order of a VP8L bitstream.
+type bitWriter struct {
+	buf []byte // buf are the completed bytes.
+	acc uint64 // acc are the pending bits.
+	n   uint   // n is the number of pending bits.
+}
+
+// write the n least significant bits of v.
+func (b *bitWriter) write(v uint32, n uint) {
+	b.acc |= uint64(v&(1<<n-1)) << b.n
+	b.n += n
+	for b.n >= 8 {
+		b.buf = append(b.buf, byte(b.acc))
+		b.acc >>= 8
+		b.n -= 8
+	}
+}
+
+// bytes returns the written bits padded with zeros to a whole byte.
+func (b *bitWriter) bytes() []byte {
+	if b.n > 0 {
+		b.buf = append(b.buf, byte(b.acc))
+		b.acc, b.n = 0, 0
+	}
+	return b.buf
+}
@@ -0,0 +1,286 @@
+package webp
+
+import (
+	"container/heap"
+	"sort"
+)
+
+const (
+	maxCodeLength     = 15 // maxCodeLength is the longest permitted prefix code.
+	maxCodeLengthCode = 7  // maxCodeLengthCode is the longest permitted code of the code length code.
+	codeLengthCodes   = 19 // codeLengthCodes is the size of the code length alphabet.
+)
+
+// codeLengthOrder is the order that the code lengths of the code length code are written.
+var codeLengthOrder = [codeLengthCodes]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// prefixCode is a canonical prefix code, also known as a Huffman code.
+type prefixCode struct {
+	lengths []uint8  // lengths are the bit lengths of each symbol, 0 for unused symbols.
+	codes   []uint16 // codes are the bit-reversed codes of each symbol.
+	single  bool     // single is true when there is only one symbol, which is written using zero bits.
+}
+
+// newPrefixCode builds the prefix code of the symbol frequencies, with codes no longer than limit bits.
+func newPrefixCode(freq []int, limit int) prefixCode {
+	used := 0
+	for _, f := range freq {
+		if f > 0 {
+			used++
+		}
+	}
+	p := prefixCode{
+		lengths: make([]uint8, len(freq)),
+		codes:   make([]uint16, len(freq)),
+	}
+	switch used {
+	case 0:
+		p.lengths[0], p.single = 1, true
+		return p
+	case 1:
+		for s, f := range freq {
+			if f > 0 {
+				p.lengths[s], p.single = 1, true
+			}
+		}
+		return p
+	}
+	f := append([]int{}, freq...)
+	for {
+		p.lengths = lengths(f)
+		if longest(p.lengths) <= limit {
+			break
+		}
+		// flatten the frequencies until the tree is shallow enough
+		for i, n := range f {
+			if n > 0 {
+				f[i] = (n + 1) / 2
+			}
+		}
+	}
+	p.codes = canonical(p.lengths)
+	return p
+}
+
+// symbols returns the symbols with a code.
+func (p prefixCode) symbols() []int {
+	s := []int{}
+	for i, l := range p.lengths {
+		if l > 0 {
+			s = append(s, i)
+		}
+	}
+	return s
+}
+
+// put writes the code of the symbol.
+func (p prefixCode) put(b *bitWriter, symbol int) {
+	if p.single {
+		return
+	}
+	b.write(uint32(p.codes[symbol]), uint(p.lengths[symbol]))
+}
+
+// write the prefix code description to b.
+// Codes of 1 or 2 symbols of less than 256 use the simple code, otherwise the normal code is used.
+func (p prefixCode) write(b *bitWriter) {
+	const simple, normal = 1, 0
+	if s := p.symbols(); len(s) <= 2 && s[len(s)-1] < 256 {
+		b.write(simple, 1)
+		b.write(uint32(len(s)-1), 1)
+		if s[0] < 2 {
+			b.write(0, 1)
+			b.write(uint32(s[0]), 1)
+		} else {
+			b.write(1, 1)
+			b.write(uint32(s[0]), 8)
+		}
+		if len(s) == 2 {
+			b.write(uint32(s[1]), 8)
+		}
+		return
+	}
+	b.write(normal, 1)
+	tokens := runLengths(p.lengths)
+	freq := make([]int, codeLengthCodes)
+	for _, t := range tokens {
+		freq[t.code]++
+	}
+	clc := newPrefixCode(freq, maxCodeLengthCode)
+	n := codeLengthCodes
+	for n > 4 && clc.lengths[codeLengthOrder[n-1]] == 0 {
+		n--
+	}
+	b.write(uint32(n-4), 4)
+	for _, c := range codeLengthOrder[:n] {
+		b.write(uint32(clc.lengths[c]), 3)
+	}
+	const allSymbols = 0
+	b.write(allSymbols, 1)
+	for _, t := range tokens {
+		clc.put(b, t.code)
+		switch t.code {
+		case 16:
+			b.write(uint32(t.extra), 2)
+		case 17:
+			b.write(uint32(t.extra), 3)
+		case 18:
+			b.write(uint32(t.extra), 7)
+		}
+	}
+}
+
+// lengthToken is a run-length encoded code length.
+type lengthToken struct {
+	code  int // code is a code length of 0-15, or a repeat code of 16-18.
+	extra int // extra is the value of the extra bits of a repeat code.
+}
+
+// runLengths returns the code lengths encoded using the repeat codes.
+// Code 16 repeats the previous non-zero length 3-6 times,
+// code 17 repeats a zero length 3-10 times and code 18 repeats a zero length 11-138 times.
+func runLengths(lengths []uint8) []lengthToken {
+	tokens := []lengthToken{}
+	prev := uint8(8)
+	for i := 0; i < len(lengths); {
+		v, run := lengths[i], 1
+		for i+run < len(lengths) && lengths[i+run] == v {
+			run++
+		}
+		i += run
+		if v == 0 {
+			for run >= 11 {
+				n := min(run, 138)
+				tokens = append(tokens, lengthToken{18, n - 11})
+				run -= n
+			}
+			if run >= 3 {
+				tokens = append(tokens, lengthToken{17, run - 3})
+				run = 0
+			}
+			for ; run > 0; run-- {
+				tokens = append(tokens, lengthToken{0, 0})
+			}
+			continue
+		}
+		if v != prev {
+			tokens = append(tokens, lengthToken{int(v), 0})
+			prev = v
+			run--
+		}
+		for run >= 3 {
+			n := min(run, 6)
+			tokens = append(tokens, lengthToken{16, n - 3})
+			run -= n
+		}
+		for ; run > 0; run-- {
+			tokens = append(tokens, lengthToken{int(v), 0})
+		}
+	}
+	return tokens
+}
+
+// node is a node of a Huffman tree.
+type node struct {
+	freq        int
+	symbol      int // symbol is the leaf symbol, or -1 for a branch.
+	left, right *node
+}
+
+type nodes []*node
+
+func (h nodes) Len() int { return len(h) }
+func (h nodes) Less(i, j int) bool {
+	if h[i].freq != h[j].freq {
+		return h[i].freq < h[j].freq
+	}
+	return h[i].symbol > h[j].symbol
+}
+func (h nodes) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *nodes) Push(x any)   { *h = append(*h, x.(*node)) } //nolint:forcetypeassert
+func (h *nodes) Pop() any {
+	old := *h
+	n := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return n
+}
+
+// lengths returns the Huffman code lengths of the symbol frequencies, which must have two or more symbols.
+func lengths(freq []int) []uint8 {
+	h := nodes{}
+	for s, f := range freq {
+		if f > 0 {
+			h = append(h, &node{freq: f, symbol: s})
+		}
+	}
+	heap.Init(&h)
+	for h.Len() > 1 {
+		a, _ := heap.Pop(&h).(*node)
+		b, _ := heap.Pop(&h).(*node)
+		heap.Push(&h, &node{freq: a.freq + b.freq, symbol: -1, left: a, right: b})
+	}
+	l := make([]uint8, len(freq))
+	var walk func(n *node, depth int)
+	walk = func(n *node, depth int) {
+		if n.symbol >= 0 {
+			l[n.symbol] = uint8(min(depth, 0xff))
+			return
+		}
+		walk(n.left, depth+1)
+		walk(n.right, depth+1)
+	}
+	walk(h[0], 0)
+	return l
+}
+
+func longest(lengths []uint8) int {
+	m := 0
+	for _, l := range lengths {
+		m = max(m, int(l))
+	}
+	return m
+}
+
+// canonical returns the bit-reversed, canonical codes of the code lengths.
+func canonical(lengths []uint8) []uint16 {
+	count := make([]int, maxCodeLength+2)
+	for _, l := range lengths {
+		count[l]++
+	}
+	count[0] = 0
+	next := make([]int, maxCodeLength+2)
+	code := 0
+	for l := 1; l <= maxCodeLength+1; l++ {
+		code = (code + count[l-1]) << 1
+		next[l] = code
+	}
+	codes := make([]uint16, len(lengths))
+	for s, l := range lengths {
+		if l == 0 {
+			continue
+		}
+		codes[s] = reverse(uint16(next[l]), l)
+		next[l]++
+	}
+	return codes
+}
+
+// reverse returns the n least significant bits of v in reverse order.
+func reverse(v uint16, n uint8) uint16 {
+	r := uint16(0)
+	for i := uint8(0); i < n; i++ {
+		r = r<<1 | v&1
+		v >>= 1
+	}
+	return r
+}
+
+// sortedKeys returns the keys of the m map in ascending order.
+func sortedKeys(m map[uint32]int) []uint32 {
+	keys := make([]uint32, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
@@ -0,0 +1,135 @@
+package webp
+
+const (
+	minMatch    = 3                  // minMatch is the shortest backward reference.
+	maxMatch    = 4096               // maxMatch is the longest backward reference.
+	maxDistance = 1<<20 - 120        // maxDistance is the furthest backward reference.
+	hashBits    = 16                 // hashBits is the size of the hash table used to find matches.
+	maxChain    = 8                  // maxChain is the number of earlier matches that are compared.
+	planeCodes  = 120                // planeCodes are the number of distance codes that are two-dimensional.
+	hashMul     = uint32(0x1e35a7bd) // hashMul is the multiplier of the hash function.
+	hashMul2    = uint32(0x9e3779b1) // hashMul2 is the multiplier of the hash function for the next pixel.
+)
+
+// planeTable maps the two-dimensional distance codes to their x and y offsets.
+var planeTable = [planeCodes]uint8{
+	0x18, 0x07, 0x17, 0x19, 0x28, 0x06, 0x27, 0x29, 0x16, 0x1a,
+	0x26, 0x2a, 0x38, 0x05, 0x37, 0x39, 0x15, 0x1b, 0x36, 0x3a,
+	0x25, 0x2b, 0x48, 0x04, 0x47, 0x49, 0x14, 0x1c, 0x35, 0x3b,
+	0x46, 0x4a, 0x24, 0x2c, 0x58, 0x45, 0x4b, 0x34, 0x3c, 0x03,
+	0x57, 0x59, 0x13, 0x1d, 0x56, 0x5a, 0x23, 0x2d, 0x44, 0x4c,
+	0x55, 0x5b, 0x33, 0x3d, 0x68, 0x02, 0x67, 0x69, 0x12, 0x1e,
+	0x66, 0x6a, 0x22, 0x2e, 0x54, 0x5c, 0x43, 0x4d, 0x65, 0x6b,
+	0x32, 0x3e, 0x78, 0x01, 0x77, 0x79, 0x53, 0x5d, 0x11, 0x1f,
+	0x64, 0x6c, 0x42, 0x4e, 0x76, 0x7a, 0x21, 0x2f, 0x75, 0x7b,
+	0x31, 0x3f, 0x63, 0x6d, 0x52, 0x5e, 0x00, 0x74, 0x7c, 0x41,
+	0x4f, 0x10, 0x20, 0x62, 0x6e, 0x30, 0x73, 0x7d, 0x51, 0x5f,
+	0x40, 0x72, 0x7e, 0x61, 0x6f, 0x50, 0x71, 0x7f, 0x60, 0x70,
+}
+
+// token is either a literal ARGB pixel or a backward reference to earlier pixels.
+type token struct {
+	argb   uint32 // argb is the literal pixel.
+	length int    // length is the number of pixels copied by a backward reference, or 0 for a literal.
+	dist   int    // dist is the distance code of a backward reference.
+}
+
+// distanceCodes returns the distance codes of the pixel distances for an image width,
+// the two-dimensional codes are used for the nearby pixels of the rows above.
+func distanceCodes(width int) map[int]int {
+	m := map[int]int{}
+	for i := planeCodes - 1; i >= 0; i-- {
+		e := int(planeTable[i])
+		d := (e>>4)*width + 8 - e&0xf
+		m[max(d, 1)] = i + 1
+	}
+	return m
+}
+
+// tokenize finds the backward references in the pixels of an image width using hash chains.
+func tokenize(pix []uint32, width int) []token {
+	n := len(pix)
+	codes := distanceCodes(width)
+	head := make([]int32, 1<<hashBits)
+	for i := range head {
+		head[i] = -1
+	}
+	prev := make([]int32, n)
+	hash := func(i int) uint32 {
+		return (pix[i]*hashMul ^ pix[i+1]*hashMul2) >> (32 - hashBits)
+	}
+	insert := func(i int) {
+		if i+1 >= n {
+			return
+		}
+		h := hash(i)
+		prev[i] = head[h]
+		head[h] = int32(i)
+	}
+	match := func(j, i int) int {
+		limit := min(maxMatch, n-i)
+		l := 0
+		for l < limit && pix[j+l] == pix[i+l] {
+			l++
+		}
+		return l
+	}
+	tokens := make([]token, 0, n/2)
+	for i := 0; i < n; {
+		best, bestDist := 0, 0
+		if i+minMatch <= n {
+			// the previous pixel and the pixel above have the shortest distance codes
+			for _, d := range []int{1, width} {
+				if d <= i {
+					if l := match(i-d, i); l > best {
+						best, bestDist = l, d
+					}
+				}
+			}
+			j := head[hash(i)]
+			for c := 0; c < maxChain && j >= 0 && i-int(j) <= maxDistance; c++ {
+				// only a match that is longer than the best can replace it
+				if k := int(j); best >= n-i || pix[k+best] != pix[i+best] || pix[k] != pix[i] {
+					j = prev[j]
+					continue
+				}
+				if l := match(int(j), i); l > best {
+					best, bestDist = l, i-int(j)
+				}
+				j = prev[j]
+			}
+		}
+		if best < minMatch {
+			tokens = append(tokens, token{argb: pix[i]})
+			insert(i)
+			i++
+			continue
+		}
+		code, ok := codes[bestDist]
+		if !ok {
+			code = bestDist + planeCodes
+		}
+		tokens = append(tokens, token{length: best, dist: code})
+		for k := 0; k < best; k++ {
+			insert(i + k)
+		}
+		i += best
+	}
+	return tokens
+}
+
+// prefix returns the prefix code, the number of extra bits and the extra bits value of the v value,
+// which is a backward reference length or a distance code.
+func prefix(v int) (int, uint, uint32) {
+	if v <= 4 {
+		return v - 1, 0, 0
+	}
+	x := v - 1
+	h := 0
+	for t := x; t > 1; t >>= 1 {
+		h++
+	}
+	second := (x >> (h - 1)) & 1
+	extra := uint(h - 1)
+	return 2*h + second, extra, uint32(x & (1<<extra - 1))
+}
@@ -0,0 +1,406 @@
+// Package webp is a pure Go encoder of lossless WebP images.
+// It writes the VP8L bitstream that is specified by RFC 9649, WebP Image Format.
+//
+// Images with 256 or fewer colors, such as the previews of text files, use the color indexing
+// transform with pixel bundling, while all other images use the subtract green and predictor transforms.
+// The pixels are compressed using LZ77 backward references and canonical prefix codes.
+package webp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+var (
+	ErrEmpty = errors.New("image has no pixels")
+	ErrSize  = errors.New("image is too large for the webp format")
+)
+
+const (
+	MaxSize = 16384 // MaxSize is the maximum width or height of a WebP image.
+
+	signature    = 0x2f
+	opaque       = 0xff000000 // opaque is the ARGB value of opaque black.
+	literals     = 256        // literals is the size of the red, blue, alpha and green literal alphabets.
+	lengthCodes  = 24         // lengthCodes is the number of prefix codes of backward reference lengths.
+	distCodes    = 40         // distCodes is the number of prefix codes of the distances.
+	maxPalette   = 256        // maxPalette is the most colors of the color indexing transform.
+	predictBits  = 4          // predictBits is the log2 size of the predictor transform tiles.
+	predictModes = 14         // predictModes is the number of predictor modes.
+
+	predictor     = 0 // predictor is the transform type that codes the pixels as predicted residuals.
+	subtractGreen = 2 // subtractGreen is the transform type that subtracts the green from the red and blue.
+	colorIndexing = 3 // colorIndexing is the transform type that replaces the pixels with palette indexes.
+)
+
+// Encode writes the m image to w in the lossless WebP format.
+func Encode(w io.Writer, m image.Image) error {
+	b := m.Bounds()
+	if b.Empty() {
+		return ErrEmpty
+	}
+	if b.Dx() > MaxSize || b.Dy() > MaxSize {
+		return fmt.Errorf("%w: %dx%d", ErrSize, b.Dx(), b.Dy())
+	}
+	pix, alpha := argb(m)
+	bw := &bitWriter{}
+	bw.write(signature, 8)
+	bw.write(uint32(b.Dx()-1), 14)
+	bw.write(uint32(b.Dy()-1), 14)
+	bw.write(alpha, 1)
+	const version = 0
+	bw.write(version, 3)
+	width := b.Dx()
+	if palette := colors(pix); palette != nil {
+		width, pix = indexed(bw, pix, width, palette)
+	} else {
+		subtract(bw, pix)
+		pix = predict(bw, pix, width)
+	}
+	const noTransform = 0
+	bw.write(noTransform, 1)
+	entropy(bw, pix, width, true)
+	return riff(w, bw.bytes())
+}
+
+// riff writes the VP8L data to w using the RIFF container of a simple format, lossless WebP file.
+func riff(w io.Writer, data []byte) error {
+	pad := len(data) & 1
+	head := make([]byte, 0, 20)
+	head = append(head, "RIFF"...)
+	head = binary.LittleEndian.AppendUint32(head, uint32(4+8+len(data)+pad))
+	head = append(head, "WEBPVP8L"...)
+	head = binary.LittleEndian.AppendUint32(head, uint32(len(data)))
+	if pad > 0 {
+		data = append(data, 0)
+	}
+	if _, err := w.Write(head); err != nil {
+		return fmt.Errorf("webp write: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("webp write: %w", err)
+	}
+	return nil
+}
+
+// argb returns the non-premultiplied ARGB pixels of the m image,
+// and 1 when any of the pixels are not opaque.
+func argb(m image.Image) ([]uint32, uint32) {
+	b := m.Bounds()
+	pix := make([]uint32, 0, b.Dx()*b.Dy())
+	alpha := uint32(0)
+	add := func(c color.NRGBA) {
+		if c.A != 0xff {
+			alpha = 1
+		}
+		pix = append(pix, uint32(c.A)<<24|uint32(c.R)<<16|uint32(c.G)<<8|uint32(c.B))
+	}
+	switch img := m.(type) {
+	case *image.NRGBA:
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			i := img.PixOffset(b.Min.X, y)
+			for s := img.Pix[i : i+4*b.Dx()]; len(s) >= 4; s = s[4:] {
+				add(color.NRGBA{s[0], s[1], s[2], s[3]})
+			}
+		}
+	case *image.Paletted:
+		palette := make([]color.NRGBA, len(img.Palette))
+		for i, c := range img.Palette {
+			palette[i], _ = color.NRGBAModel.Convert(c).(color.NRGBA)
+		}
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			i := img.PixOffset(b.Min.X, y)
+			for _, p := range img.Pix[i : i+b.Dx()] {
+				if int(p) < len(palette) {
+					add(palette[p])
+					continue
+				}
+				add(color.NRGBA{})
+			}
+		}
+	default:
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				c, _ := color.NRGBAModel.Convert(m.At(x, y)).(color.NRGBA)
+				add(c)
+			}
+		}
+	}
+	return pix, alpha
+}
+
+// colors returns the sorted palette of the pixels, or nil when there are too many colors.
+func colors(pix []uint32) []uint32 {
+	m := map[uint32]int{}
+	for _, p := range pix {
+		if _, ok := m[p]; ok {
+			continue
+		}
+		if len(m) == maxPalette {
+			return nil
+		}
+		m[p] = 0
+	}
+	return sortedKeys(m)
+}
+
+// indexed writes the color indexing transform and returns the width and pixels of the indexed image.
+// Images of 16 or fewer colors bundle multiple pixel indexes into each pixel.
+func indexed(bw *bitWriter, pix []uint32, width int, palette []uint32) (int, []uint32) {
+	bw.write(1, 1)
+	bw.write(colorIndexing, 2)
+	bw.write(uint32(len(palette)-1), 8)
+	// the palette is coded as the difference of each color from the previous color
+	deltas := make([]uint32, len(palette))
+	index := make(map[uint32]uint32, len(palette))
+	for i, c := range palette {
+		index[c] = uint32(i)
+		if i == 0 {
+			deltas[i] = c
+			continue
+		}
+		deltas[i] = sub(c, palette[i-1])
+	}
+	entropy(bw, deltas, len(deltas), false)
+	bits := 0
+	switch n := len(palette); {
+	case n <= 2:
+		bits = 3
+	case n <= 4:
+		bits = 2
+	case n <= 16:
+		bits = 1
+	}
+	per, depth := 1<<bits, 8>>bits
+	packed := (width + per - 1) / per
+	height := len(pix) / width
+	out := make([]uint32, 0, packed*height)
+	for y := 0; y < height; y++ {
+		row := pix[y*width : (y+1)*width]
+		for x := 0; x < width; x += per {
+			g := uint32(0)
+			for k := 0; k < per && x+k < width; k++ {
+				g |= index[row[x+k]] << (k * depth)
+			}
+			out = append(out, opaque|g<<8)
+		}
+	}
+	return packed, out
+}
+
+// subtract writes the subtract green transform and subtracts the green from the red and blue of the pixels.
+func subtract(bw *bitWriter, pix []uint32) {
+	bw.write(1, 1)
+	bw.write(subtractGreen, 2)
+	for i, p := range pix {
+		g := p >> 8 & 0xff
+		r := (p>>16 - g) & 0xff
+		b := (p - g) & 0xff
+		pix[i] = p&0xff00ff00 | r<<16 | b
+	}
+}
+
+// predict writes the predictor transform and returns the residuals of the pixels.
+// Each tile uses the predictor mode with the smallest residuals.
+func predict(bw *bitWriter, pix []uint32, width int) []uint32 {
+	const size = 1 << predictBits
+	height := len(pix) / width
+	tw, th := (width+size-1)/size, (height+size-1)/size
+	modes := make([]uint32, tw*th)
+	for ty := 0; ty < th; ty++ {
+		for tx := 0; tx < tw; tx++ {
+			best, low := 0, -1
+			for mode := 0; mode < predictModes; mode++ {
+				cost := 0
+				for y := ty * size; y < min((ty+1)*size, height); y++ {
+					for x := tx * size; x < min((tx+1)*size, width); x++ {
+						cost += residualCost(sub(pix[y*width+x], predicted(pix, width, x, y, mode)))
+					}
+				}
+				if low < 0 || cost < low {
+					best, low = mode, cost
+				}
+			}
+			modes[ty*tw+tx] = opaque | uint32(best)<<8
+		}
+	}
+	bw.write(1, 1)
+	bw.write(predictor, 2)
+	bw.write(predictBits-2, 3)
+	entropy(bw, modes, tw, false)
+	res := make([]uint32, len(pix))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			mode := int(modes[(y>>predictBits)*tw+x>>predictBits] >> 8 & 0xf)
+			res[y*width+x] = sub(pix[y*width+x], predicted(pix, width, x, y, mode))
+		}
+	}
+	return res
+}
+
+// predicted returns the predicted value of the pixel at x, y using the mode.
+// The top-left pixel is predicted as opaque black, the top row by the left pixel
+// and the left column by the pixel above.
+func predicted(pix []uint32, width, x, y, mode int) uint32 {
+	i := y*width + x
+	switch {
+	case x == 0 && y == 0:
+		return opaque
+	case y == 0:
+		return pix[i-1]
+	case x == 0:
+		return pix[i-width]
+	}
+	// the top-right pixel of the rightmost column is the leftmost pixel of the current row
+	l, t, tl, tr := pix[i-1], pix[i-width], pix[i-width-1], pix[i-width+1]
+	switch mode {
+	case 0:
+		return opaque
+	case 1:
+		return l
+	case 2:
+		return t
+	case 3:
+		return tr
+	case 4:
+		return tl
+	case 5:
+		return avg2(avg2(l, tr), t)
+	case 6:
+		return avg2(l, tl)
+	case 7:
+		return avg2(l, t)
+	case 8:
+		return avg2(tl, t)
+	case 9:
+		return avg2(t, tr)
+	case 10:
+		return avg2(avg2(l, tl), avg2(t, tr))
+	case 11:
+		return selected(l, t, tl)
+	case 12:
+		return clampFull(l, t, tl)
+	case 13:
+		return clampHalf(avg2(l, t), tl)
+	}
+	return opaque
+}
+
+// entropy writes the pixels of an image width using the prefix codes of a single group.
+// Only the top level, main image describes whether it uses meta prefix codes.
+func entropy(bw *bitWriter, pix []uint32, width int, top bool) {
+	const noCache, noMeta = 0, 0
+	bw.write(noCache, 1)
+	if top {
+		bw.write(noMeta, 1)
+	}
+	tokens := tokenize(pix, width)
+	green := make([]int, literals+lengthCodes)
+	red, blue, alpha := make([]int, literals), make([]int, literals), make([]int, literals)
+	dist := make([]int, distCodes)
+	for _, t := range tokens {
+		if t.length == 0 {
+			green[t.argb>>8&0xff]++
+			red[t.argb>>16&0xff]++
+			blue[t.argb&0xff]++
+			alpha[t.argb>>24]++
+			continue
+		}
+		l, _, _ := prefix(t.length)
+		d, _, _ := prefix(t.dist)
+		green[literals+l]++
+		dist[d]++
+	}
+	codes := [5]prefixCode{}
+	for i, freq := range [][]int{green, red, blue, alpha, dist} {
+		codes[i] = newPrefixCode(freq, maxCodeLength)
+		codes[i].write(bw)
+	}
+	for _, t := range tokens {
+		if t.length == 0 {
+			codes[0].put(bw, int(t.argb>>8&0xff))
+			codes[1].put(bw, int(t.argb>>16&0xff))
+			codes[2].put(bw, int(t.argb&0xff))
+			codes[3].put(bw, int(t.argb>>24))
+			continue
+		}
+		l, lbits, lextra := prefix(t.length)
+		codes[0].put(bw, literals+l)
+		bw.write(lextra, lbits)
+		d, dbits, dextra := prefix(t.dist)
+		codes[4].put(bw, d)
+		bw.write(dextra, dbits)
+	}
+}
+
+// sub returns the difference of each channel of the a and b pixels, modulo 256.
+func sub(a, b uint32) uint32 {
+	ag := 0x00ff00ff + (a & 0xff00ff00) - (b & 0xff00ff00)
+	rb := 0xff00ff00 + (a & 0x00ff00ff) - (b & 0x00ff00ff)
+	return ag&0xff00ff00 | rb&0x00ff00ff
+}
+
+// residualCost returns the cost of a residual pixel, which is the sum of the magnitudes of its channels.
+func residualCost(p uint32) int {
+	cost := 0
+	for s := 0; s < 32; s += 8 {
+		c := int(p >> s & 0xff)
+		cost += min(c, 256-c)
+	}
+	return cost
+}
+
+// avg2 returns the average of each channel of the a and b pixels, rounded down.
+func avg2(a, b uint32) uint32 {
+	return ((a^b)&0xfefefefe)>>1 + a&b
+}
+
+// selected returns the l or t pixel, whichever is closest to the gradient estimate of l + t - tl.
+func selected(l, t, tl uint32) uint32 {
+	pl, pt := 0, 0
+	for s := 0; s < 32; s += 8 {
+		cl, ct, ctl := int(l>>s&0xff), int(t>>s&0xff), int(tl>>s&0xff)
+		pl += abs(ct - ctl)
+		pt += abs(cl - ctl)
+	}
+	if pl < pt {
+		return l
+	}
+	return t
+}
+
+// clampFull returns a + b - c for each channel, clamped to 0-255.
+func clampFull(a, b, c uint32) uint32 {
+	p := uint32(0)
+	for s := 0; s < 32; s += 8 {
+		v := int(a>>s&0xff) + int(b>>s&0xff) - int(c>>s&0xff)
+		p |= uint32(clamp(v)) << s
+	}
+	return p
+}
+
+// clampHalf returns a + (a - b) / 2 for each channel, clamped to 0-255.
+func clampHalf(a, b uint32) uint32 {
+	p := uint32(0)
+	for s := 0; s < 32; s += 8 {
+		ca := int(a >> s & 0xff)
+		v := ca + (ca-int(b>>s&0xff))/2
+		p |= uint32(clamp(v)) << s
+	}
+	return p
+}
+
+func clamp(v int) int {
+	return min(max(v, 0), 0xff)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,137 @@
+package webp_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"math/rand"
+	"testing"
+
+	"github.com/Defacto2/df2/pkg/images/internal/webp"
+	"github.com/stretchr/testify/assert"
+	xwebp "golang.org/x/image/webp"
+)
+
+// roundTrip encodes the m image and returns it decoded.
+func roundTrip(t *testing.T, m image.Image) image.Image {
+	t.Helper()
+	buf := &bytes.Buffer{}
+	err := webp.Encode(buf, m)
+	assert.Nil(t, err)
+	assert.Equal(t, "RIFF", buf.String()[:4])
+	assert.Equal(t, 0, buf.Len()%2)
+	got, err := xwebp.Decode(buf)
+	assert.Nil(t, err)
+	return got
+}
+
+// equal compares the non-premultiplied colors of every pixel.
+func equal(t *testing.T, want, got image.Image) {
+	t.Helper()
+	if !assert.Equal(t, want.Bounds().Size(), got.Bounds().Size()) {
+		return
+	}
+	wb, gb := want.Bounds(), got.Bounds()
+	for y := 0; y < wb.Dy(); y++ {
+		for x := 0; x < wb.Dx(); x++ {
+			w := color.NRGBAModel.Convert(want.At(wb.Min.X+x, wb.Min.Y+y))
+			g := color.NRGBAModel.Convert(got.At(gb.Min.X+x, gb.Min.Y+y))
+			if w != g {
+				t.Fatalf("pixel %d,%d = %v, want %v", x, y, g, w)
+			}
+		}
+	}
+}
+
+func noise(w, h, colors int, alpha bool) *image.NRGBA {
+	r := rand.New(rand.NewSource(int64(w*h + colors))) //nolint:gosec
+	palette := make([]color.NRGBA, colors)
+	for i := range palette {
+		a := uint8(0xff)
+		if alpha {
+			a = uint8(r.Intn(256))
+		}
+		palette[i] = color.NRGBA{uint8(r.Intn(256)), uint8(r.Intn(256)), uint8(r.Intn(256)), a}
+	}
+	m := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// runs of colors so the backward references are used
+			if x > 0 && r.Intn(3) == 0 {
+				m.Set(x, y, m.At(x-1, y))
+				continue
+			}
+			m.Set(x, y, palette[r.Intn(colors)])
+		}
+	}
+	return m
+}
+
+func gradient(w, h int) *image.NRGBA {
+	m := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			m.Set(x, y, color.NRGBA{uint8(x * 3), uint8(y * 5), uint8(x + y), 0xff})
+		}
+	}
+	return m
+}
+
+func TestEncode(t *testing.T) {
+	t.Parallel()
+	err := webp.Encode(&bytes.Buffer{}, image.NewNRGBA(image.Rect(0, 0, 0, 0)))
+	assert.ErrorIs(t, err, webp.ErrEmpty)
+	err = webp.Encode(&bytes.Buffer{}, image.NewNRGBA(image.Rect(0, 0, webp.MaxSize+1, 1)))
+	assert.ErrorIs(t, err, webp.ErrSize)
+	tests := []struct {
+		name string
+		img  image.Image
+	}{
+		{"1 pixel", noise(1, 1, 1, false)},
+		{"1 color", image.NewUniform(color.White)},
+		{"2 colors", noise(37, 11, 2, false)},
+		{"4 colors", noise(33, 17, 4, false)},
+		{"16 colors", noise(40, 40, 16, false)},
+		{"256 colors", noise(64, 64, 256, true)},
+		{"noise", noise(97, 53, 4096, true)},
+		{"gradient", gradient(300, 200)},
+		{"column", gradient(1, 300)},
+		{"row", gradient(300, 1)},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			img := tt.img
+			if _, ok := img.(*image.Uniform); ok {
+				m := image.NewRGBA(image.Rect(0, 0, 20, 20))
+				draw.Draw(m, m.Bounds(), img, image.Point{}, draw.Src)
+				img = m
+			}
+			equal(t, img, roundTrip(t, img))
+		})
+	}
+}
+
+func TestEncode_Paletted(t *testing.T) {
+	t.Parallel()
+	m := image.NewPaletted(image.Rect(10, 10, 90, 50), color.Palette{
+		color.Black, color.White, color.NRGBA{0xaa, 0, 0, 0x80},
+	})
+	for i := range m.Pix {
+		m.Pix[i] = uint8(i % 7 % 3)
+	}
+	equal(t, m, roundTrip(t, m))
+	// a sub image
+	sub := gradient(64, 64).SubImage(image.Rect(10, 20, 50, 30))
+	equal(t, sub, roundTrip(t, sub))
+}
+
+func TestEncode_Compression(t *testing.T) {
+	t.Parallel()
+	m := gradient(256, 256)
+	buf := &bytes.Buffer{}
+	assert.Nil(t, webp.Encode(buf, m))
+	assert.Less(t, buf.Len(), len(m.Pix)/10)
+}
@@ -9,13 +9,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"path/filepath"
 	"strings"
 	"text/tabwriter"
 	"time"
 
+	"github.com/Defacto2/df2/pkg/archive"
 	"github.com/Defacto2/df2/pkg/conf"
 	"github.com/Defacto2/df2/pkg/importer/a6581"
 	"github.com/Defacto2/df2/pkg/importer/adsr"
@@ -49,8 +49,10 @@ var (
 )
 
 const (
-	input  = "dizzer-input"
-	output = "dizzer-dest"
+	input     = "dizzer-input"
+	output    = "dizzer-dest"
+	packFiles = 500000   // packFiles is the file limit of a group pack, which holds thousands of releases.
+	packSize  = 64 << 30 // packSize is the size limit in bytes of the extracted group pack.
 )
 
 // SubDirectory of the RAR archive.
@@ -118,14 +120,10 @@ func (im Importer) Import(db *sql.DB, w io.Writer) error {
 	}
 	st.DestOpen = dest
 	defer os.RemoveAll(dest)
-	// Unarchive prints useless errors, but is much faster than using archiver.Extract().
-	// so set SetOutput to discard all logged errors.
-	log.SetOutput(io.Discard)
-	rar := rar()
-	if err := rar.Unarchive(im.RARFile, dest); err != nil {
+	lim := archive.Limits{Size: packSize, Files: packFiles}
+	if err := lim.Unarchive(im.RARFile, dest, im.RARFile); err != nil {
 		return err
 	}
-	log.SetOutput(os.Stderr)
 	// Store the subdirectories as UUID archives.
 	if err := st.Store(w, im.Logger); err != nil {
 		return err
@@ -476,6 +474,7 @@ func (st *Stat) Create() (records record.Records, err error) { //nolint:nonamedr
 		records[i].HashStrong = d.HashStrong
 		records[i].HashWeak = d.HashWeak
 		records[i].Published = d.ReadDate
+		records[i].Sauce(meta.Nfo)
 		if len(meta.Files) > 1 {
 			records[i].LastMod = time.Now()
 			records[i].ZipContent = strings.Join(meta.Files, "\n")
@@ -1,16 +1,15 @@
 package record
 
 import (
-	"context"
 	"crypto/md5" //nolint:gosec
 	"crypto/sha512"
 	"errors"
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"strings"
-	"time"
+
+	"github.com/Defacto2/df2/pkg/magic"
 )
 
 var ErrFile = errors.New("os file cannot be nil")
@@ -38,30 +37,11 @@ func Copy(dst, src string) (written int64, err error) { //nolint:nonamedreturns
 
 // Determine the magic file definition of the named file.
 func Determine(name string) (string, error) {
-	const file = "file" // file — determine file type
-	path, err := exec.LookPath(file)
-	if err != nil {
-		return "", fmt.Errorf("determine: %w", err)
-	}
-	_, err = os.Stat(name)
+	t, err := magic.File(name)
 	if err != nil {
 		return "", fmt.Errorf("determine: %w", err)
 	}
-	const ten = 10 * time.Second
-	ctx, cancel := context.WithTimeout(context.Background(), ten)
-	defer cancel()
-	args := []string{name}
-	cmd := exec.CommandContext(ctx, path, args...)
-	out, err := cmd.Output()
-	if err != nil {
-		return "", fmt.Errorf("determine: %w", err)
-	}
-	s := strings.TrimSpace(string(out))
-	ss := strings.Split(s, ":")
-	if len(ss) > 1 {
-		return strings.TrimSpace(ss[1]), nil
-	}
-	return s, nil
+	return t.String(), nil
 }
 
 // Sum386 returns the SHA-386 checksum value of the open file.
@@ -13,7 +13,7 @@ import (
 const (
 	sha384 = "749b328f5284e5c196f932a07194894e0fc50c1d9c414457883bc2d79a5ee8a94ac2981e5168ad4df1f4a6405dce99c7"
 	summd5 = "7c7d17c6faec74918f4a7047e1c50412"
-	magic  = "RAR archive data, v4, os"
+	magic  = "RAR archive data, v4, os: Win32"
 )
 
 func dir() string {
@@ -33,6 +33,7 @@ import (
 	"github.com/Defacto2/df2/pkg/importer/zone"
 	"github.com/Defacto2/df2/pkg/importer/zwt"
 	models "github.com/Defacto2/df2/pkg/models/mysql"
+	"github.com/Defacto2/df2/pkg/sauce"
 	"github.com/google/uuid"
 	"github.com/volatiletech/null/v8"
 	"github.com/volatiletech/sqlboiler/v4/boil"
@@ -58,6 +59,7 @@ type Record struct {
 	Slug       string    `json:"slug"`
 	Title      string    `json:"record_title"`
 	Group      string    `json:"group_brand_for"`
+	Credit     string    `json:"credit_illustration"`
 	FileName   string    `json:"filename"`
 	FileSize   int64     `json:"filesize"`
 	FileMagic  string    `json:"file_magic_type"`
@@ -81,6 +83,9 @@ func (rec Record) Insert(ctx context.Context, db *sql.DB, newpath string) error
 	f1.UUID = null.NewString(rec.UUID, true)
 	f1.RecordTitle = null.NewString(rec.Title, true)
 	f1.GroupBrandFor = null.NewString(rec.Group, true)
+	if rec.Credit != "" {
+		f1.CreditIllustration = null.NewString(rec.Credit, true)
+	}
 	if !rec.Published.IsZero() {
 		f1.DateIssuedYear = null.Int16From(int16(rec.Published.Year()))
 		f1.DateIssuedMonth = null.Int8From(int8(rec.Published.Month()))
@@ -112,6 +117,25 @@ func (rec Record) Insert(ctx context.Context, db *sql.DB, newpath string) error
 	return nil
 }
 
+// Sauce fills the empty title, artist credit and publish date of the record
+// using any SAUCE metadata found at the end of the b textfile.
+// The group is never replaced, as it is always the formal release-group name.
+func (rec *Record) Sauce(b []byte) {
+	r, err := sauce.Decode(b)
+	if err != nil && !errors.Is(err, sauce.ErrComments) {
+		return
+	}
+	if rec.Title == "" {
+		rec.Title = r.Title
+	}
+	if rec.Credit == "" {
+		rec.Credit = r.Author
+	}
+	if rec.Published.IsZero() {
+		rec.Published = r.Date
+	}
+}
+
 // Records are a collection of Record items to insert into the database.
 type Records []Record
 
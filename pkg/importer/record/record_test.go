@@ -108,3 +108,22 @@ func TestDownload_ReadDIZ(t *testing.T) {
 	assert.Equal(t, 14, dl.ReadDate.Day())
 	assert.Equal(t, "Disk Director Suite v10.0.2077 by Acronis", dl.ReadTitle)
 }
+
+func TestRecord_Sauce(t *testing.T) {
+	t.Parallel()
+	b, err := os.ReadFile(filepath.Join(dir(), "text", "test.ans"))
+	assert.Nil(t, err)
+	r := record.Record{Group: grp}
+	r.Sauce(nil)
+	assert.Equal(t, record.Record{Group: grp}, r)
+	r.Sauce(b)
+	assert.Equal(t, "Renderer test", r.Title)
+	assert.Equal(t, "Defacto2", r.Credit)
+	assert.Equal(t, grp, r.Group)
+	assert.Equal(t, 1994, r.Published.Year())
+
+	r = record.Record{Title: "Title", Published: time.Date(2005, 1, 1, 0, 0, 0, 0, time.UTC)}
+	r.Sauce(b)
+	assert.Equal(t, "Title", r.Title)
+	assert.Equal(t, 2005, r.Published.Year())
+}
@@ -0,0 +1,269 @@
+// Package index builds and searches the full-text index of the readmes, NFOs
+// and file_id.diz descriptions of the file records, so curators can find
+// releases using the text of their couriers, BBS adverts and crack notes.
+//
+// The index is stored in the SearchIndex configuration directory and is updated incrementally,
+// only the records with new, changed or removed texts are reindexed.
+package index
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Defacto2/df2/pkg/archive"
+	"github.com/Defacto2/df2/pkg/charset"
+	"github.com/Defacto2/df2/pkg/conf"
+	"github.com/Defacto2/df2/pkg/database"
+	"github.com/Defacto2/df2/pkg/directories"
+	"github.com/Defacto2/df2/pkg/index/internal/inverted"
+	"github.com/Defacto2/df2/pkg/magic"
+	"github.com/Defacto2/df2/pkg/sauce"
+	"github.com/Defacto2/df2/pkg/str"
+	"github.com/Defacto2/df2/pkg/text"
+	"github.com/gookit/color"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+var (
+	ErrDir   = errors.New("search index directory is not set")
+	ErrEmpty = inverted.ErrEmpty
+)
+
+const (
+	diz = "file_id.diz"
+	txt = ".txt"
+)
+
+// Result is a file record that matches a search.
+type Result struct {
+	ID      int64   // ID of the file record.
+	UUID    string  // UUID of the file record.
+	Name    string  // Name is the filename of the file record.
+	Score   float64 // Score is the relevance of the record to the search.
+	Line    int     // Line is the line number of the snippet within the text.
+	Snippet string  // Snippet is the line of the text that best matches the search.
+}
+
+// Update the full-text index of the readmes, NFOs and file_id.diz texts of every file record.
+// Only the records with new or changed texts are read and indexed,
+// while the records that are deleted or that no longer have a text are removed.
+// When rebuild is true, the existing index is discarded and every record is indexed.
+func Update(db *sql.DB, w io.Writer, cfg conf.Config, rebuild bool) error {
+	if db == nil {
+		return database.ErrDB
+	}
+	if w == nil {
+		w = io.Discard
+	}
+	if cfg.SearchIndex == "" {
+		return ErrDir
+	}
+	dir, err := directories.Init(cfg, false)
+	if err != nil {
+		return err
+	}
+	open := inverted.Open
+	if rebuild {
+		open = inverted.Create
+	}
+	ix, err := open(cfg.SearchIndex)
+	if err != nil {
+		return err
+	}
+	files, err := database.Select(db,
+		qm.Select("id", "uuid", "filename", "platform", "file_zip_content"),
+		qm.OrderBy("id ASC"))
+	if err != nil {
+		return fmt.Errorf("index select: %w", err)
+	}
+	keep := make(map[int64]bool, len(files))
+	added, removed := 0, 0
+	for _, f := range files {
+		src := Sources(dir.UUID, f)
+		if src.Empty() {
+			continue
+		}
+		keep[f.ID] = true
+		sum := src.Sum()
+		if d, ok := ix.Doc(f.ID); ok && d.Sum == sum {
+			continue
+		}
+		// texts that cannot be read are still added, so they are not read again until they change
+		d := inverted.Doc{UUID: f.UUID.String, Name: f.Filename.String, Sum: sum}
+		if err := ix.Add(f.ID, d, src.Text()); err != nil {
+			return err
+		}
+		added++
+		fmt.Fprintf(w, "%s %s %s\n", color.Primary.Sprint(f.ID), f.Filename.String, str.Y())
+	}
+	for _, id := range ix.IDs() {
+		if keep[id] {
+			continue
+		}
+		if err := ix.Remove(id); err != nil {
+			return err
+		}
+		removed++
+	}
+	if err := ix.Save(); err != nil {
+		return err
+	}
+	str.Total(w, added, "texts indexed")
+	if removed > 0 {
+		str.Total(w, removed, "texts removed from the index")
+	}
+	fmt.Fprintf(w, "The search index of %d texts is in %s\n", ix.Len(), cfg.SearchIndex)
+	return nil
+}
+
+// Search the full-text index in the SearchIndex configuration directory for the records
+// with texts that contain every word of the query, with the most relevant records first.
+// A limit of 0 returns every matching record.
+func Search(cfg conf.Config, query string, limit int) ([]Result, error) {
+	if cfg.SearchIndex == "" {
+		return nil, ErrDir
+	}
+	ix, err := inverted.Open(cfg.SearchIndex)
+	if err != nil {
+		return nil, err
+	}
+	hits, err := ix.Search(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]Result, 0, len(hits))
+	for _, h := range hits {
+		results = append(results, Result{
+			ID: h.ID, UUID: h.UUID, Name: h.Name, Score: h.Score,
+			Line: h.Line, Snippet: h.Snippet,
+		})
+	}
+	return results, nil
+}
+
+// Source are the text files of a file record that are indexed.
+type Source struct {
+	Name     string // Name is the filename of the file record.
+	Readme   string // Readme is the path to the UTF-8 sidecar, extracted readme or text download.
+	UTF8     bool   // UTF8 is true when the Readme is the normalized, UTF-8 sidecar.
+	Amiga    bool   // Amiga is true when the Readme is an Amiga text.
+	Archive  string // Archive is the path to the download that contains the Diz.
+	Diz      string // Diz is the path of the file_id.diz within the Archive.
+	infos    []fs.FileInfo
+	download bool
+}
+
+// Sources returns the text files of the f file record that are stored in the dir directory.
+// The normalized UTF-8 sidecar is preferred over the extracted readme of an archive,
+// which is preferred over the download of a text record.
+// A file_id.diz is used when it is listed in the content of an archive download.
+func Sources(dir string, f database.File) Source {
+	src := Source{Name: f.Filename.String, Amiga: f.Platform.String == "textamiga"}
+	if f.UUID.String == "" {
+		return src
+	}
+	name := filepath.Join(dir, f.UUID.String)
+	stat := func(name string) bool {
+		st, err := os.Stat(name)
+		if err != nil || st.IsDir() || st.Size() == 0 {
+			return false
+		}
+		src.infos = append(src.infos, st)
+		return true
+	}
+	switch platform := strings.TrimSpace(f.Platform.String); {
+	case stat(name + text.Sidecar):
+		src.Readme, src.UTF8 = name+text.Sidecar, true
+	case stat(name + txt):
+		src.Readme = name + txt
+	case platform == "text" || platform == "textamiga" || platform == "ansi":
+		if stat(name) {
+			src.Readme, src.download = name, true
+		}
+	}
+	for _, entry := range strings.Split(f.FileZipContent.String, "\n") {
+		entry = strings.TrimSpace(entry)
+		if !strings.EqualFold(filepath.Base(filepath.ToSlash(entry)), diz) {
+			continue
+		}
+		if stat(name) {
+			src.Archive, src.Diz = name, entry
+		}
+		break
+	}
+	return src
+}
+
+// Empty returns true when there are no text files.
+func (src Source) Empty() bool {
+	return src.Readme == "" && src.Diz == ""
+}
+
+// Sum returns the signature of the text files using their names, sizes and modification times.
+func (src Source) Sum() string {
+	s := make([]string, 0, len(src.infos)+1)
+	s = append(s, src.Name)
+	for _, st := range src.infos {
+		s = append(s, fmt.Sprintf("%s:%d:%d", st.Name(), st.Size(), st.ModTime().Unix()))
+	}
+	return strings.Join(s, "|")
+}
+
+// Text returns the combined UTF-8 plain text of the readme and the file_id.diz.
+// Text files that cannot be read or extracted are skipped.
+func (src Source) Text() string {
+	texts := []string{}
+	if src.Readme != "" && src.readable() {
+		if b, err := os.ReadFile(src.Readme); err == nil {
+			texts = append(texts, plain(b, src.UTF8, src.Amiga))
+		}
+	}
+	if src.Diz != "" {
+		if b, err := src.extract(); err == nil {
+			texts = append(texts, plain(b, false, src.Amiga))
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// readable returns false when the Readme is a text record download that is not a text file.
+func (src Source) readable() bool {
+	if !src.download {
+		return true
+	}
+	t, err := magic.File(src.Readme)
+	return err == nil && t.Kind == magic.Text
+}
+
+// extract returns the content of the file_id.diz within the archive.
+func (src Source) extract() ([]byte, error) {
+	tmp, err := os.MkdirTemp("", "df2-index-")
+	if err != nil {
+		return nil, fmt.Errorf("index extract: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+	if err := archive.Extractor(src.Name, src.Archive, src.Diz, tmp); err != nil {
+		return nil, fmt.Errorf("index extract: %w", err)
+	}
+	b, err := os.ReadFile(filepath.Join(tmp, src.Diz))
+	if err != nil {
+		return nil, fmt.Errorf("index extract: %w", err)
+	}
+	return b, nil
+}
+
+// plain returns the b text as UTF-8 plain text, using the classified character set
+// of the text unless it is already a normalized UTF-8 sidecar.
+func plain(b []byte, utf8, amiga bool) string {
+	if utf8 {
+		return string(b)
+	}
+	b = b[:sauce.Index(b)]
+	return text.Plain(b, charset.Classify(b, amiga))
+}
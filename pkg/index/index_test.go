@@ -0,0 +1,87 @@
+package index_test
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Defacto2/df2/pkg/conf"
+	"github.com/Defacto2/df2/pkg/database"
+	"github.com/Defacto2/df2/pkg/index"
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+const uuid = "00000000-0000-0000-0000-000000000000"
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+	err := index.Update(nil, nil, conf.Config{}, false)
+	assert.ErrorIs(t, err, database.ErrDB)
+}
+
+func TestSearch(t *testing.T) {
+	t.Parallel()
+	_, err := index.Search(conf.Config{}, "razor", 0)
+	assert.ErrorIs(t, err, index.ErrDir)
+	cfg := conf.Config{SearchIndex: t.TempDir()}
+	_, err = index.Search(cfg, "", 0)
+	assert.ErrorIs(t, err, index.ErrEmpty)
+	r, err := index.Search(cfg, "razor", 0)
+	assert.Nil(t, err)
+	assert.Empty(t, r)
+}
+
+func TestSources(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	f := database.File{
+		ID:       1,
+		UUID:     null.StringFrom(uuid),
+		Filename: null.StringFrom("razor.nfo"),
+		Platform: null.StringFrom("text"),
+	}
+	src := index.Sources(dir, f)
+	assert.True(t, src.Empty())
+
+	// a text download
+	name := filepath.Join(dir, uuid)
+	err := os.WriteFile(name, []byte("RAZOR 1911 \xdb\xb2\xb1\xb0 Caf\x82"), 0o644)
+	assert.Nil(t, err)
+	src = index.Sources(dir, f)
+	assert.False(t, src.Empty())
+	assert.Equal(t, name, src.Readme)
+	assert.Equal(t, "RAZOR 1911 █▓▒░ Café", src.Text())
+	sum := src.Sum()
+	assert.Contains(t, sum, "razor.nfo|")
+
+	// the utf-8 sidecar is preferred
+	err = os.WriteFile(name+".utf8.txt", []byte("Dream Team"), 0o644)
+	assert.Nil(t, err)
+	src = index.Sources(dir, f)
+	assert.True(t, src.UTF8)
+	assert.Equal(t, "Dream Team", src.Text())
+	assert.NotEqual(t, sum, src.Sum())
+
+	// an archive with a file_id.diz
+	f.Platform = null.StringFrom("dos")
+	f.Filename = null.StringFrom("game.zip")
+	f.FileZipContent = null.StringFrom("GAME.EXE\nFILE_ID.DIZ")
+	src = index.Sources(dir, f)
+	assert.Equal(t, name, src.Archive)
+	assert.Equal(t, "FILE_ID.DIZ", src.Diz)
+	assert.Equal(t, "Dream Team", src.Text())
+	z, err := os.Create(name)
+	assert.Nil(t, err)
+	zw := zip.NewWriter(z)
+	fw, err := zw.Create("FILE_ID.DIZ")
+	assert.Nil(t, err)
+	_, err = fw.Write([]byte("Game of the Year\r\nsupplied by Razor"))
+	assert.Nil(t, err)
+	assert.Nil(t, zw.Close())
+	assert.Nil(t, z.Close())
+	assert.Nil(t, os.Remove(name+".utf8.txt"))
+	src = index.Sources(dir, f)
+	assert.Equal(t, "Game of the Year\nsupplied by Razor", src.Text())
+}
@@ -0,0 +1,415 @@
+// Package inverted is an on-disk, inverted index of terms to the text documents that contain them.
+//
+// The index directory contains a catalog.gob file of the indexed documents,
+// a shards directory of term to posting lists that are split by the hash of the term,
+// and a text directory with a UTF-8 copy of each document that is used for the search snippets.
+// Only the shards of the terms that are changed or searched are ever read.
+package inverted
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	ErrEmpty   = errors.New("search query has no searchable terms")
+	ErrVersion = errors.New("index was created by an incompatible version, it must be rebuilt")
+)
+
+const (
+	Version = 1 // Version of the index file format.
+
+	catalogName = "catalog.gob"
+	shardsDir   = "shards"
+	textDir     = "text"
+	shards      = 256 // shards is the number of posting list files.
+	snipLen     = 96  // snipLen is the maximum number of characters in a snippet.
+	dirMode     = 0o755
+	fileMode    = 0o644
+)
+
+// Doc is an indexed text document.
+type Doc struct {
+	UUID  string // UUID of the file record.
+	Name  string // Name is the filename of the file record.
+	Sum   string // Sum is the signature of the text sources, a changed sum requires the document to be reindexed.
+	Terms int    // Terms is the number of searchable terms in the document.
+}
+
+// Posting is a document that contains a term.
+type Posting struct {
+	ID   int64 // ID of the document and file record.
+	Freq int   // Freq is the number of times the term appears in the document.
+}
+
+// Hit is a document that matches a search query.
+type Hit struct {
+	Doc
+	ID      int64   // ID of the document and file record.
+	Score   float64 // Score is the relevance of the document to the query.
+	Line    int     // Line is the line number of the snippet.
+	Snippet string  // Snippet is the line of text that best matches the query.
+}
+
+// catalog is the stored list of the indexed documents.
+type catalog struct {
+	Version int
+	Docs    map[int64]Doc
+}
+
+// Index is an inverted, full-text index stored in a directory.
+type Index struct {
+	dir    string
+	docs   map[int64]Doc
+	shards map[int]map[string][]Posting
+	dirty  map[int]bool
+}
+
+// Open the index stored in the dir directory, which is created if it does not exist.
+func Open(dir string) (*Index, error) {
+	for _, d := range []string{dir, filepath.Join(dir, shardsDir), filepath.Join(dir, textDir)} {
+		if err := os.MkdirAll(d, dirMode); err != nil {
+			return nil, fmt.Errorf("index open: %w", err)
+		}
+	}
+	ix := &Index{
+		dir:    dir,
+		docs:   map[int64]Doc{},
+		shards: map[int]map[string][]Posting{},
+		dirty:  map[int]bool{},
+	}
+	c := catalog{}
+	err := load(filepath.Join(dir, catalogName), &c)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ix, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("index open: %w", err)
+	}
+	if c.Version != Version {
+		return nil, fmt.Errorf("index open %q: %w", dir, ErrVersion)
+	}
+	if c.Docs != nil {
+		ix.docs = c.Docs
+	}
+	return ix, nil
+}
+
+// Create a new, empty index in the dir directory and remove any existing index.
+func Create(dir string) (*Index, error) {
+	for _, name := range []string{catalogName, shardsDir, textDir} {
+		if err := os.RemoveAll(filepath.Join(dir, name)); err != nil {
+			return nil, fmt.Errorf("index create: %w", err)
+		}
+	}
+	return Open(dir)
+}
+
+// Len returns the number of indexed documents.
+func (ix *Index) Len() int {
+	return len(ix.docs)
+}
+
+// Doc returns the indexed document of the id.
+func (ix *Index) Doc(id int64) (Doc, bool) {
+	d, ok := ix.docs[id]
+	return d, ok
+}
+
+// IDs returns the ids of every indexed document in ascending order.
+func (ix *Index) IDs() []int64 {
+	ids := make([]int64, 0, len(ix.docs))
+	for id := range ix.docs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// Add the text document of the id to the index, replacing any existing document of the id.
+func (ix *Index) Add(id int64, d Doc, text string) error {
+	if err := ix.Remove(id); err != nil {
+		return err
+	}
+	freq := map[string]int{}
+	for _, t := range Tokens(text) {
+		freq[t]++
+		d.Terms++
+	}
+	for t, n := range freq {
+		m, err := ix.change(t)
+		if err != nil {
+			return err
+		}
+		p := m[t]
+		i := sort.Search(len(p), func(i int) bool { return p[i].ID >= id })
+		p = append(p, Posting{})
+		copy(p[i+1:], p[i:])
+		p[i] = Posting{ID: id, Freq: n}
+		m[t] = p
+	}
+	if err := os.WriteFile(ix.text(id), []byte(text), fileMode); err != nil {
+		return fmt.Errorf("index add %d: %w", id, err)
+	}
+	ix.docs[id] = d
+	return nil
+}
+
+// Remove the document of the id from the index.
+// The terms to remove are read from the stored text of the document,
+// and when it is missing, every shard of the index is searched.
+func (ix *Index) Remove(id int64) error {
+	if _, ok := ix.docs[id]; !ok {
+		return nil
+	}
+	b, err := os.ReadFile(ix.text(id))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("index remove %d: %w", id, err)
+	}
+	if err == nil {
+		for _, t := range unique(Tokens(string(b))) {
+			m, err := ix.change(t)
+			if err != nil {
+				return err
+			}
+			remove(m, t, id)
+		}
+	} else {
+		for n := 0; n < shards; n++ {
+			m, err := ix.load(n)
+			if err != nil {
+				return err
+			}
+			ix.dirty[n] = true
+			for t := range m {
+				remove(m, t, id)
+			}
+		}
+	}
+	if err := os.Remove(ix.text(id)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("index remove %d: %w", id, err)
+	}
+	delete(ix.docs, id)
+	return nil
+}
+
+// remove the id posting from the term of the m shard.
+func remove(m map[string][]Posting, t string, id int64) {
+	p := m[t]
+	i := sort.Search(len(p), func(i int) bool { return p[i].ID >= id })
+	if i == len(p) || p[i].ID != id {
+		return
+	}
+	p = append(p[:i], p[i+1:]...)
+	if len(p) == 0 {
+		delete(m, t)
+		return
+	}
+	m[t] = p
+}
+
+// Save writes the changed shards and the catalog of documents to the index directory.
+func (ix *Index) Save() error {
+	for n := range ix.dirty {
+		if err := save(ix.shardName(n), ix.shards[n]); err != nil {
+			return fmt.Errorf("index save shard: %w", err)
+		}
+		delete(ix.dirty, n)
+	}
+	if err := save(filepath.Join(ix.dir, catalogName), catalog{Version: Version, Docs: ix.docs}); err != nil {
+		return fmt.Errorf("index save catalog: %w", err)
+	}
+	return nil
+}
+
+// Search returns the documents that contain every term of the query,
+// with the most relevant documents first. A limit of 0 returns every match.
+//
+// Documents are ranked using the frequency of each term in the document,
+// weighted by the rarity of the term across the index.
+func (ix *Index) Search(query string, limit int) ([]Hit, error) {
+	terms := unique(Tokens(query))
+	if len(terms) == 0 {
+		return nil, ErrEmpty
+	}
+	scores := map[int64]float64{}
+	n := float64(len(ix.docs))
+	for i, t := range terms {
+		m, err := ix.shard(t)
+		if err != nil {
+			return nil, err
+		}
+		p := m[t]
+		idf := math.Log(1 + n/float64(max(len(p), 1)))
+		next := map[int64]float64{}
+		for _, post := range p {
+			score, ok := scores[post.ID]
+			if i > 0 && !ok {
+				continue
+			}
+			next[post.ID] = score + (1+math.Log(float64(post.Freq)))*idf
+		}
+		scores = next
+		if len(scores) == 0 {
+			return []Hit{}, nil
+		}
+	}
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{ID: id, Doc: ix.docs[id], Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	for i, h := range hits {
+		b, err := os.ReadFile(ix.text(h.ID))
+		if err != nil {
+			continue
+		}
+		hits[i].Line, hits[i].Snippet = Snippet(string(b), terms)
+	}
+	return hits, nil
+}
+
+// Snippet returns the line number and a shortened copy of the line of the text
+// that contains the most search terms. A zero line is returned when there is no match.
+func Snippet(text string, terms []string) (int, string) {
+	want := map[string]bool{}
+	for _, t := range terms {
+		want[t] = true
+	}
+	best, line, offset, snip := 0, 0, 0, ""
+	for i, s := range strings.Split(text, "\n") {
+		found, first := map[string]bool{}, -1
+		scan(s, func(t string, o int) {
+			if !want[t] {
+				return
+			}
+			if first < 0 {
+				first = o
+			}
+			found[t] = true
+		})
+		if len(found) > best {
+			best, line, offset, snip = len(found), i+1, first, s
+		}
+		if best == len(want) {
+			break
+		}
+	}
+	if line == 0 {
+		return 0, ""
+	}
+	return line, shorten(snip, offset)
+}
+
+// shorten returns the s line with the whitespace collapsed and when it is too long,
+// shortened around the byte offset of the first matching term.
+func shorten(s string, offset int) string {
+	const lead = snipLen / 4
+	prefix := utf8.RuneCountInString(s[:offset])
+	r := []rune(s)
+	start := max(prefix-lead, 0)
+	end := min(start+snipLen, len(r))
+	start = max(end-snipLen, 0)
+	snip := strings.Join(strings.FieldsFunc(string(r[start:end]), unicode.IsSpace), " ")
+	if start > 0 {
+		snip = "…" + snip
+	}
+	if end < len(r) {
+		snip += "…"
+	}
+	return snip
+}
+
+// unique returns the terms without any duplicates.
+func unique(terms []string) []string {
+	seen, u := map[string]bool{}, []string{}
+	for _, t := range terms {
+		if !seen[t] {
+			seen[t] = true
+			u = append(u, t)
+		}
+	}
+	return u
+}
+
+// shard returns the loaded shard that contains the term.
+func (ix *Index) shard(t string) (map[string][]Posting, error) {
+	return ix.load(shardOf(t))
+}
+
+// change returns the loaded shard that contains the term and marks it to be saved.
+func (ix *Index) change(t string) (map[string][]Posting, error) {
+	n := shardOf(t)
+	ix.dirty[n] = true
+	return ix.load(n)
+}
+
+// shardOf returns the number of the shard that contains the term.
+func shardOf(t string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(t))
+	return int(h.Sum32() % shards)
+}
+
+// load returns the n shard, which is read from the index directory when it is not yet loaded.
+func (ix *Index) load(n int) (map[string][]Posting, error) {
+	if m, ok := ix.shards[n]; ok {
+		return m, nil
+	}
+	m := map[string][]Posting{}
+	if err := load(ix.shardName(n), &m); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("index shard %d: %w", n, err)
+	}
+	ix.shards[n] = m
+	return m, nil
+}
+
+func (ix *Index) shardName(n int) string {
+	return filepath.Join(ix.dir, shardsDir, fmt.Sprintf("%02x.gob", n))
+}
+
+func (ix *Index) text(id int64) string {
+	return filepath.Join(ix.dir, textDir, strconv.FormatInt(id, 10)+".txt")
+}
+
+// load decodes the named gob file into v.
+func load(name string, v any) error {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	return gob.NewDecoder(bytes.NewReader(b)).Decode(v)
+}
+
+// save encodes v to the named gob file, which is replaced only once it is written.
+func save(name string, v any) error {
+	buf := &bytes.Buffer{}
+	if err := gob.NewEncoder(buf).Encode(v); err != nil {
+		return err
+	}
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), fileMode); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
@@ -0,0 +1,118 @@
+package inverted_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Defacto2/df2/pkg/index/internal/inverted"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	nfo = `  ░▒▓█ RAZOR 1911 █▓▒░
+   Courier ........ Dream Team
+   Call our WHQ ──── The Pharmacy BBS`
+	diz = `Game of the Year (c) 1995
+supplied by Razor`
+)
+
+func TestTokens(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		s    string
+		want []string
+	}{
+		{"empty", "", []string{}},
+		{"words", "Hello World", []string{"hello", "world"}},
+		{"short", "a b cd", []string{"cd"}},
+		{"box", "──RAZOR══1911▓▓", []string{"razor", "1911"}},
+		{"accents", "Café Über", []string{"cafe", "uber"}},
+		{"lookalikes", "ΣLiTΣ ßßS", []string{"elite", "bbs"}},
+		{"phone", "+1 (555) 123-4567", []string{"555", "123", "4567"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, inverted.Tokens(tt.s))
+		})
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	t.Parallel()
+	line, s := inverted.Snippet(nfo, []string{"nothing"})
+	assert.Equal(t, 0, line)
+	assert.Equal(t, "", s)
+	line, s = inverted.Snippet(nfo, []string{"dream", "courier"})
+	assert.Equal(t, 2, line)
+	assert.Equal(t, "Courier ........ Dream Team", s)
+	long := "x word filler text that goes on and on and on " +
+		"and on and on and on and on and on and on and on and on and on and on and on and the end"
+	_, s = inverted.Snippet(long, []string{"end"})
+	assert.Contains(t, s, "the end")
+	assert.Contains(t, s, "…")
+}
+
+func TestIndex(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	ix, err := inverted.Open(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, ix.Len())
+	err = ix.Add(1, inverted.Doc{Name: "rzr.nfo", Sum: "a"}, nfo)
+	assert.Nil(t, err)
+	err = ix.Add(2, inverted.Doc{Name: "game.zip", Sum: "b"}, diz)
+	assert.Nil(t, err)
+	_, err = ix.Search("...", 0)
+	assert.ErrorIs(t, err, inverted.ErrEmpty)
+	hits, err := ix.Search("razor", 0)
+	assert.Nil(t, err)
+	assert.Len(t, hits, 2)
+	hits, err = ix.Search("RAZOR bbs", 0)
+	assert.Nil(t, err)
+	assert.Len(t, hits, 1)
+	assert.Equal(t, int64(1), hits[0].ID)
+	assert.Equal(t, "rzr.nfo", hits[0].Name)
+	assert.Equal(t, 1, hits[0].Line)
+	hits, err = ix.Search("razor missing", 0)
+	assert.Nil(t, err)
+	assert.Empty(t, hits)
+	assert.Nil(t, ix.Save())
+
+	// reopen the saved index
+	ix, err = inverted.Open(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, ix.Len())
+	d, ok := ix.Doc(2)
+	assert.True(t, ok)
+	assert.Equal(t, "b", d.Sum)
+	assert.Equal(t, 8, d.Terms)
+	hits, err = ix.Search("razor", 1)
+	assert.Nil(t, err)
+	assert.Len(t, hits, 1)
+
+	// replace and remove documents
+	err = ix.Add(2, inverted.Doc{Name: "game.zip", Sum: "c"}, "a new description")
+	assert.Nil(t, err)
+	assert.Nil(t, ix.Remove(1))
+	assert.Nil(t, ix.Remove(99))
+	assert.Nil(t, ix.Save())
+	ix, err = inverted.Open(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{2}, ix.IDs())
+	hits, err = ix.Search("razor", 0)
+	assert.Nil(t, err)
+	assert.Empty(t, hits)
+	hits, err = ix.Search("description", 0)
+	assert.Nil(t, err)
+	assert.Len(t, hits, 1)
+	_, err = os.Stat(filepath.Join(dir, "text", "1.txt"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	ix, err = inverted.Create(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, ix.Len())
+}
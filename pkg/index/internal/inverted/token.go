@@ -0,0 +1,98 @@
+package inverted
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	minToken = 2  // minToken is the minimum number of characters in a searchable term.
+	maxToken = 32 // maxToken is the maximum number of characters in a searchable term, longer words are truncated.
+)
+
+// lookalikes are the CP437 glyphs that the scene used in place of Latin letters,
+// such as ΣLiTΣ for ELITE or ßßS for BBS, which are folded to the letter they represent.
+var lookalikes = map[rune]rune{
+	'α': 'a', 'ß': 'b', 'Γ': 'r', 'π': 'n', 'Σ': 'e', 'σ': 'o', 'µ': 'u', 'τ': 't',
+	'Φ': 'o', 'Θ': 'o', 'Ω': 'o', 'δ': 'd', 'φ': 'o', 'ε': 'e', '∩': 'n', '¥': 'y',
+	'£': 'l', '¢': 'c', 'ƒ': 'f', '₧': 'p',
+}
+
+// Tokens returns the searchable terms of the UTF-8 text s in their order of appearance.
+//
+// The text is split using any character that is not a letter or a number,
+// which includes the CP437 box drawing, block and shade characters of text art.
+// Terms are lowercased, accents are removed and the CP437 look-alike glyphs are
+// folded to their Latin letters. Terms with fewer than 2 characters are dropped.
+func Tokens(s string) []string {
+	terms := []string{}
+	scan(s, func(t string, _ int) {
+		terms = append(terms, t)
+	})
+	return terms
+}
+
+// scan calls fn with every searchable term of the s text and the byte offset of the word it was found.
+func scan(s string, fn func(t string, offset int)) {
+	var b strings.Builder
+	start := 0
+	flush := func() {
+		if t := term(b.String()); t != "" {
+			fn(t, start)
+		}
+		b.Reset()
+	}
+	for i, r := range s {
+		if b.Len() == 0 {
+			start = i
+		}
+		if l, ok := lookalikes[r]; ok {
+			b.WriteRune(l)
+			continue
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) {
+			b.WriteRune(r)
+			continue
+		}
+		flush()
+	}
+	flush()
+}
+
+// Fold returns the s text lowercased and with the accents removed.
+func Fold(s string) string {
+	if ascii(s) {
+		return strings.ToLower(s)
+	}
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	f, _, err := transform.String(t, s)
+	if err != nil {
+		f = s
+	}
+	return strings.ToLower(f)
+}
+
+// term returns the folded s word as a searchable term, or an empty string when it is too short.
+func term(s string) string {
+	s = Fold(s)
+	if r := []rune(s); len(r) > maxToken {
+		s = string(r[:maxToken])
+	} else if len(r) < minToken {
+		return ""
+	}
+	return s
+}
+
+func ascii(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
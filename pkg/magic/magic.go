@@ -0,0 +1,217 @@
+// Package magic determines the type of a file using the signatures found in
+// its content, in place of the file program and its libmagic database.
+// It recognizes the archives, DOS executables, images, audio and text files
+// that are commonly found in the scene collections of the website.
+// The titles of the types follow the descriptions used by the file program.
+package magic
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+var ErrDir = errors.New("named file is a directory")
+
+// Kind is the category of a file type.
+type Kind int
+
+const (
+	Data       Kind = iota // Data is an unknown binary file.
+	Archive                // Archive is a file archive or a compressed file.
+	Executable             // Executable is an MS-DOS or Windows program.
+	Image                  // Image is a bitmap or a photo.
+	Audio                  // Audio is a music module or a sampled sound.
+	Text                   // Text is a plain text file, which includes ANSI art.
+	Empty                  // Empty is a file with no content.
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Data:
+		return "data"
+	case Archive:
+		return "archive"
+	case Executable:
+		return "executable"
+	case Image:
+		return "image"
+	case Audio:
+		return "audio"
+	case Text:
+		return "text"
+	case Empty:
+		return "empty"
+	}
+	return fmt.Sprintf("kind(%d)", int(k))
+}
+
+// Type is the detected type of a file.
+type Type struct {
+	Kind  Kind   // Kind is the category of the file type.
+	Title string // Title describes the file type using the style of the file program.
+	Ext   string // Ext is the common file extension of the type, or empty when there is none.
+}
+
+// String returns the title of the type.
+func (t Type) String() string {
+	return t.Title
+}
+
+// Archive returns true if the type is a file archive or a compressed file.
+func (t Type) Archive() bool {
+	return t.Kind == Archive
+}
+
+const (
+	headSize = 4096 // headSize is the number of bytes read from the start of a file.
+	tailSize = 26   // tailSize is the length of the footer of a TGA image.
+	comSize  = 0xff00
+)
+
+var (
+	data  = Type{Kind: Data, Title: "data"}
+	empty = Type{Kind: Empty, Title: "empty"}
+)
+
+// File returns the type of the named file.
+// The file extension is only used to recognize MS-DOS COM programs,
+// which have no signature of their own.
+func File(name string) (Type, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return Type{}, fmt.Errorf("magic file: %w", err)
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return Type{}, fmt.Errorf("magic file: %w", err)
+	}
+	if st.IsDir() {
+		return Type{}, fmt.Errorf("magic file: %w: %s", ErrDir, name)
+	}
+	t, err := Detect(f, st.Size())
+	if err != nil {
+		return Type{}, err
+	}
+	if strings.EqualFold(filepath.Ext(name), ".com") && t.Kind == Data && st.Size() <= comSize {
+		return Type{Kind: Executable, Title: "DOS executable (COM)", Ext: ".com"}, nil
+	}
+	return t, nil
+}
+
+// Detect returns the type of the content read from r, which is size bytes long.
+func Detect(r io.ReaderAt, size int64) (Type, error) {
+	if size <= 0 {
+		return empty, nil
+	}
+	head := make([]byte, min(size, headSize))
+	if _, err := r.ReadAt(head, 0); err != nil && !errors.Is(err, io.EOF) {
+		return Type{}, fmt.Errorf("magic detect: %w", err)
+	}
+	if t, ok := signature(head); ok {
+		return t, nil
+	}
+	if size >= tailSize {
+		tail := make([]byte, tailSize)
+		if _, err := r.ReadAt(tail, size-tailSize); err != nil && !errors.Is(err, io.EOF) {
+			return Type{}, fmt.Errorf("magic detect: %w", err)
+		}
+		if t, ok := footer(tail); ok {
+			return t, nil
+		}
+	}
+	if t, ok := text(head); ok {
+		return t, nil
+	}
+	return data, nil
+}
+
+// Bytes returns the type of the content in b,
+// which should either be the complete file or at least its first 4 KB.
+func Bytes(b []byte) Type {
+	t, _ := Detect(bytes.NewReader(b), int64(len(b)))
+	return t
+}
+
+// signature returns the type matched by the signatures at the start of the file.
+func signature(b []byte) (Type, bool) {
+	for _, fn := range []func([]byte) (Type, bool){archive, executable, image, audio} {
+		if t, ok := fn(b); ok {
+			return t, true
+		}
+	}
+	return Type{}, false
+}
+
+// text returns a text type when b only contains printable characters and common controls.
+// A DOS end-of-file character marks the end of the text, which is often followed by SAUCE metadata.
+func text(b []byte) (Type, bool) {
+	const sub = 0x1a
+	if i := bytes.IndexByte(b, sub); i > 0 {
+		b = b[:i]
+	}
+	ascii := true
+	for _, c := range b {
+		switch {
+		case c >= 0x80:
+			ascii = false
+		case c >= 0x20, c == '\t', c == '\n', c == '\r', c == '\f', c == '\b', c == 0x1b, c == 0x07:
+		default:
+			return Type{}, false
+		}
+	}
+	title := "ASCII text"
+	switch {
+	case ascii:
+	case utf8.Valid(b):
+		title = "UTF-8 Unicode text"
+	default:
+		title = "Non-ISO extended-ASCII text"
+	}
+	return Type{Kind: Text, Title: title + lines(b), Ext: ".txt"}, true
+}
+
+// lines returns the description of the line terminators and the escape sequences used by the text.
+func lines(b []byte) string {
+	const veryLong = 300
+	s := ""
+	long, n := false, 0
+	for _, c := range b {
+		if c == '\n' || c == '\r' {
+			n = 0
+			continue
+		}
+		n++
+		if n > veryLong {
+			long = true
+		}
+	}
+	if long {
+		s += ", with very long lines"
+	}
+	crlf := bytes.Count(b, []byte("\r\n"))
+	lf := bytes.Count(b, []byte("\n")) - crlf
+	cr := bytes.Count(b, []byte("\r")) - crlf
+	switch {
+	case crlf == 0 && lf == 0 && cr == 0:
+		s += ", with no line terminators"
+	case crlf > 0 && lf == 0 && cr == 0:
+		s += ", with CRLF line terminators"
+	case cr > 0 && lf == 0 && crlf == 0:
+		s += ", with CR line terminators"
+	case crlf > 0 && lf > 0:
+		s += ", with CRLF, LF line terminators"
+	case cr > 0:
+		s += ", with CR, LF line terminators"
+	}
+	if bytes.IndexByte(b, 0x1b) >= 0 {
+		s += ", with escape sequences"
+	}
+	return s
+}
@@ -0,0 +1,154 @@
+package magic_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Defacto2/df2/pkg/internal"
+	"github.com/Defacto2/df2/pkg/magic"
+	"github.com/stretchr/testify/assert"
+)
+
+func testDir(name string) string {
+	return filepath.Join(internal.Testdata(2), name)
+}
+
+func TestFile(t *testing.T) {
+	t.Parallel()
+	_, err := magic.File("")
+	assert.NotNil(t, err)
+	_, err = magic.File(testDir("demozoo"))
+	assert.ErrorIs(t, err, magic.ErrDir)
+
+	tests := []struct {
+		name  string
+		kind  magic.Kind
+		title string
+		ext   string
+	}{
+		{"arc/methods.arc", magic.Archive, "ARC archive data, uncompressed", ".arc"},
+		{"arj/hello.arj", magic.Archive, "ARJ archive data, os: MS-DOS", ".arj"},
+		{"demozoo/test.7z", magic.Archive, "7-zip archive data, version 0.4", ".7z"},
+		{"demozoo/test.invalid.ext.rar", magic.Archive,
+			"Zip archive data, at least v1.0 to extract, compression method=store", ".zip"},
+		{"demozoo/test.lha", magic.Archive, "LHa archive data [lhd]", ".lha"},
+		{"demozoo/test.rar", magic.Archive, "RAR archive data, v5", ".rar"},
+		{"demozoo/test.tar", magic.Archive, "POSIX tar archive", ".tar"},
+		{"demozoo/test.tar.bz2", magic.Archive, "bzip2 compressed data, block size = 900k", ".bz2"},
+		{"demozoo/test.tar.gz", magic.Archive, "gzip compressed data", ".gz"},
+		{"demozoo/test.tar.xz", magic.Archive, "XZ compressed data", ".xz"},
+		{"lha/hello.lzh", magic.Archive, "LHa archive data [lh5]", ".lha"},
+		{"pkzip/PKZ204EF.ZIP", magic.Archive,
+			"Zip archive data, at least v2.0 to extract, compression method=deflate", ".zip"},
+		{"rar/dizzer.rar", magic.Archive, "RAR archive data, v4, os: Win32", ".rar"},
+		{"zoo/hello.zoo", magic.Archive, "Zoo archive data", ".zoo"},
+		{"images/test.gif", magic.Image, "GIF image data, version 89a, 1280 x 32", ".gif"},
+		{"images/test.iff", magic.Image, "IFF data, ILBM interleaved image", ".iff"},
+		{"images/test.jpg", magic.Image, "JPEG image data", ".jpg"},
+		{"images/test.png", magic.Image, "PNG image data, 1280 x 32", ".png"},
+		{"images/test.wbm", magic.Data, "data", ""},
+		{"text/file_id.diz", magic.Text, "ASCII text, with CRLF line terminators", ".txt"},
+	}
+	for _, tt := range tests {
+		got, err := magic.File(testDir(tt.name))
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, tt.kind, got.Kind, tt.name)
+		assert.Equal(t, tt.title, got.String(), tt.name)
+		assert.Equal(t, tt.ext, got.Ext, tt.name)
+	}
+}
+
+func TestFile_COM(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	name := filepath.Join(dir, "RUN.COM")
+	err := os.WriteFile(name, []byte{0xb4, 0x09, 0xba, 0x0e, 0x01, 0xcd, 0x21, 0x00}, 0o644)
+	assert.Nil(t, err)
+	got, err := magic.File(name)
+	assert.Nil(t, err)
+	assert.Equal(t, magic.Executable, got.Kind)
+	assert.Equal(t, "DOS executable (COM)", got.Title)
+}
+
+// exe returns an MS-DOS program stub followed by the new executable signature.
+func exe(sig string) []byte {
+	b := make([]byte, 0x100)
+	copy(b, "MZ")
+	binary.LittleEndian.PutUint16(b[0x18:], 0x40)
+	binary.LittleEndian.PutUint32(b[0x3c:], 0x80)
+	copy(b[0x80:], sig)
+	return b
+}
+
+func TestBytes(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, magic.Empty, magic.Bytes(nil).Kind)
+	assert.Equal(t, "empty", magic.Bytes([]byte{}).Title)
+
+	mz := make([]byte, 64)
+	copy(mz, "MZ")
+	assert.Equal(t, "MS-DOS executable", magic.Bytes(mz).Title)
+	assert.Equal(t, "MS-DOS executable, NE", magic.Bytes(exe("NE")).Title)
+	assert.Equal(t, "MS-DOS executable, LE", magic.Bytes(exe("LE")).Title)
+	assert.Equal(t, "MS-DOS executable, LX for OS/2", magic.Bytes(exe("LX")).Title)
+	pe := exe("PE\x00\x00")
+	binary.LittleEndian.PutUint16(pe[0x80+24:], 0x10b)
+	binary.LittleEndian.PutUint16(pe[0x80+24+68:], 3)
+	assert.Equal(t, "PE32 executable (console) for MS Windows", magic.Bytes(pe).Title)
+	assert.Equal(t, magic.Executable, magic.Bytes(pe).Kind)
+
+	mod := make([]byte, 2048)
+	copy(mod[1080:], "M.K.")
+	assert.Equal(t, "4-channel Protracker module sound data", magic.Bytes(mod).Title)
+	s3m := make([]byte, 96)
+	copy(s3m[44:], "SCRM")
+	assert.Equal(t, magic.Audio, magic.Bytes(s3m).Kind)
+	assert.Equal(t, ".xm", magic.Bytes([]byte("Extended Module: song")).Ext)
+	assert.Equal(t, ".it", magic.Bytes([]byte("IMPMsong")).Ext)
+
+	tga := append(make([]byte, 64), []byte("\x00\x00\x00\x00\x00\x00\x00\x00TRUEVISION-XFILE.\x00")...)
+	assert.Equal(t, ".tga", magic.Bytes(tga).Ext)
+	assert.Equal(t, ".webp", magic.Bytes([]byte("RIFF\x00\x00\x00\x00WEBPVP8 ")).Ext)
+	assert.Equal(t, ".xb", magic.Bytes([]byte("XBIN\x1a\x50\x00")).Ext)
+	assert.Equal(t, ".cab", magic.Bytes([]byte("MSCF\x00\x00\x00\x00")).Ext)
+	assert.True(t, magic.Bytes([]byte("Rar!\x1a\x07\x01\x00")).Archive())
+}
+
+func TestBytes_Text(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"hello", "ASCII text, with no line terminators"},
+		{"hello\nworld\n", "ASCII text"},
+		{"hello\r\nworld\r\n", "ASCII text, with CRLF line terminators"},
+		{"\x1b[0;1mhello\r\n", "ASCII text, with CRLF line terminators, with escape sequences"},
+		{"héllo\n", "UTF-8 Unicode text"},
+		{"\xdb\xdb\xb2\xb1\xb0\n", "Non-ISO extended-ASCII text"},
+		{strings.Repeat("x", 400) + "\n", "ASCII text, with very long lines"},
+		{"hello\n\x1aSAUCE00\x00\x00", "ASCII text"},
+		{"hello\x00world", "data"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, magic.Bytes([]byte(tt.s)).Title, tt.s)
+	}
+	assert.Equal(t, magic.Text, magic.Bytes([]byte("hello")).Kind)
+}
+
+func TestDetect(t *testing.T) {
+	t.Parallel()
+	b, err := os.ReadFile(testDir("images/test.png"))
+	assert.Nil(t, err)
+	got, err := magic.Detect(bytes.NewReader(b), int64(len(b)))
+	assert.Nil(t, err)
+	assert.Equal(t, magic.Image, got.Kind)
+	assert.Equal(t, "image", got.Kind.String())
+	got, err = magic.Detect(bytes.NewReader(b), 0)
+	assert.Nil(t, err)
+	assert.Equal(t, magic.Empty, got.Kind)
+}
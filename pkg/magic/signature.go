@@ -0,0 +1,391 @@
+package magic
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+)
+
+var le = binary.LittleEndian
+
+// archive matches the signatures of file archives and compressed files.
+func archive(b []byte) (Type, bool) {
+	arc := func(title, ext string) (Type, bool) {
+		return Type{Kind: Archive, Title: title, Ext: ext}, true
+	}
+	switch {
+	case bytes.HasPrefix(b, []byte("PK\x03\x04")):
+		return zip(b)
+	case bytes.HasPrefix(b, []byte("PK\x05\x06")):
+		return arc("Zip archive data (empty)", ".zip")
+	case bytes.HasPrefix(b, []byte("PK\x07\x08")):
+		return arc("Zip multi-volume archive data", ".zip")
+	case bytes.HasPrefix(b, []byte("Rar!\x1a\x07\x00")):
+		return rar(b)
+	case bytes.HasPrefix(b, []byte("Rar!\x1a\x07\x01\x00")):
+		return arc("RAR archive data, v5", ".rar")
+	case bytes.HasPrefix(b, []byte("7z\xbc\xaf\x27\x1c")) && len(b) > 7:
+		return arc(fmt.Sprintf("7-zip archive data, version %d.%d", b[6], b[7]), ".7z")
+	case bytes.HasPrefix(b, []byte("MSCF\x00\x00\x00\x00")):
+		return arc("Microsoft Cabinet archive data", ".cab")
+	case bytes.HasPrefix(b, []byte("\x1f\x8b\x08")):
+		return arc("gzip compressed data", ".gz")
+	case bytes.HasPrefix(b, []byte("BZh")) && len(b) > 3 && b[3] >= '1' && b[3] <= '9':
+		return arc(fmt.Sprintf("bzip2 compressed data, block size = %c00k", b[3]), ".bz2")
+	case bytes.HasPrefix(b, []byte("\xfd7zXZ\x00")):
+		return arc("XZ compressed data", ".xz")
+	case len(b) > 20+4 && le.Uint32(b[20:]) == 0xfdc4a7dc:
+		return arc("Zoo archive data", ".zoo")
+	}
+	const ustar = 257
+	if len(b) >= ustar+8 {
+		switch string(b[ustar : ustar+8]) {
+		case "ustar\x0000":
+			return arc("POSIX tar archive", ".tar")
+		case "ustar  \x00":
+			return arc("POSIX tar archive (GNU)", ".tar")
+		}
+	}
+	if t, ok := arj(b); ok {
+		return t, true
+	}
+	if t, ok := lha(b); ok {
+		return t, true
+	}
+	return sea(b)
+}
+
+// zip describes the first local file header of a zip archive.
+func zip(b []byte) (Type, bool) {
+	const size = 10
+	if len(b) < size {
+		return Type{Kind: Archive, Title: "Zip archive data", Ext: ".zip"}, true
+	}
+	methods := map[uint16]string{
+		0: "store", 1: "Shrinking", 2: "Reduce", 3: "Reduce", 4: "Reduce", 5: "Reduce",
+		6: "Implode", 8: "deflate", 9: "deflate64", 12: "bzip2", 14: "lzma",
+		93: "zstd", 95: "xz", 96: "jpeg", 97: "WavPack", 98: "PPMd", 99: "AES Encrypted",
+	}
+	v := b[4]
+	title := fmt.Sprintf("Zip archive data, at least v%d.%d to extract", v/10, v%10)
+	method := le.Uint16(b[8:])
+	if m, ok := methods[method]; ok {
+		title += ", compression method=" + m
+	} else {
+		title += fmt.Sprintf(", compression method=%d", method)
+	}
+	return Type{Kind: Archive, Title: title, Ext: ".zip"}, true
+}
+
+// rar describes a RAR v4 archive, using the host operating system of the first file.
+func rar(b []byte) (Type, bool) {
+	const (
+		fileHead = 0x74 // fileHead is the type of a file header.
+		headType = 22   // headType is the offset of the first file header type.
+		hostOS   = 35   // hostOS is the offset of the host operating system in the first file header.
+	)
+	title := "RAR archive data, v4"
+	oses := []string{"MS-DOS", "OS/2", "Win32", "Unix", "Mac OS", "BeOS"}
+	if len(b) > hostOS && b[headType] == fileHead && int(b[hostOS]) < len(oses) {
+		title += ", os: " + oses[b[hostOS]]
+	}
+	return Type{Kind: Archive, Title: title, Ext: ".rar"}, true
+}
+
+// arj matches the main header of an ARJ archive, which must have a valid CRC-32.
+func arj(b []byte) (Type, bool) {
+	const maxHeader = 2600
+	if len(b) < 8 || b[0] != 0x60 || b[1] != 0xea {
+		return Type{}, false
+	}
+	size := int(le.Uint16(b[2:]))
+	if size == 0 || size > maxHeader || len(b) < 4+size+4 {
+		return Type{}, false
+	}
+	if crc32.ChecksumIEEE(b[4:4+size]) != le.Uint32(b[4+size:]) {
+		return Type{}, false
+	}
+	title := "ARJ archive data"
+	oses := []string{
+		"MS-DOS", "PRIMOS", "Unix", "Amiga", "Mac OS", "OS/2",
+		"Apple GS", "Atari ST", "NeXT", "VAX VMS", "Win95", "Win32",
+	}
+	if host := int(b[7]); host < len(oses) {
+		title += ", os: " + oses[host]
+	}
+	return Type{Kind: Archive, Title: title, Ext: ".arj"}, true
+}
+
+// lha matches the first header of an LHA archive, which names the compression method.
+func lha(b []byte) (Type, bool) {
+	const level = 20
+	if len(b) <= level || b[2] != '-' || b[3] != 'l' || b[6] != '-' || b[level] > 2 {
+		return Type{}, false
+	}
+	m := string(b[3:6])
+	switch m[1] {
+	case 'h', 'z':
+	default:
+		return Type{}, false
+	}
+	for _, c := range m[1:] {
+		if c < '0' || c > 'z' {
+			return Type{}, false
+		}
+	}
+	return Type{Kind: Archive, Title: fmt.Sprintf("LHa archive data [%s]", m), Ext: ".lha"}, true
+}
+
+// sea matches the first header of an ARC archive, or a PAK archive when it uses
+// the methods that were only supported by the PAK archiver.
+func sea(b []byte) (Type, bool) {
+	const name = 2
+	if len(b) < name+13 || b[0] != 0x1a {
+		return Type{}, false
+	}
+	methods := []string{
+		"", "uncompressed", "uncompressed", "packed", "squeezed", "crunched", "crunched",
+		"crunched", "crunched", "squashed", "crushed", "distilled",
+	}
+	m := int(b[1])
+	if m == 0 || m >= len(methods) {
+		return Type{}, false
+	}
+	n := bytes.IndexByte(b[name:name+13], 0)
+	if n < 1 {
+		return Type{}, false
+	}
+	for _, c := range b[name : name+n] {
+		if c <= ' ' || c == 0x7f {
+			return Type{}, false
+		}
+	}
+	const crushed = 10
+	if m >= crushed {
+		return Type{Kind: Archive, Title: "PAK archive data, " + methods[m], Ext: ".pak"}, true
+	}
+	return Type{Kind: Archive, Title: "ARC archive data, " + methods[m], Ext: ".arc"}, true
+}
+
+// executable matches MS-DOS programs and the new executable formats that extend them.
+func executable(b []byte) (Type, bool) {
+	if len(b) < 2 || !(b[0] == 'M' && b[1] == 'Z' || b[0] == 'Z' && b[1] == 'M') {
+		return Type{}, false
+	}
+	exe := func(title string) (Type, bool) {
+		return Type{Kind: Executable, Title: title, Ext: ".exe"}, true
+	}
+	const (
+		relocs   = 0x18 // relocs is the offset of the relocation table offset.
+		lfanew   = 0x3c // lfanew is the offset of the new executable header offset.
+		newStyle = 0x40 // newStyle is the relocation table offset used by new executables.
+	)
+	if len(b) < lfanew+4 || le.Uint16(b[relocs:]) < newStyle {
+		return exe("MS-DOS executable")
+	}
+	off := int(le.Uint32(b[lfanew:]))
+	if off < newStyle || off+4 > len(b) {
+		return exe("MS-DOS executable")
+	}
+	switch string(b[off : off+2]) {
+	case "PE":
+		if b[off+2] != 0 || b[off+3] != 0 {
+			break
+		}
+		return exe(pe(b[off:]))
+	case "NE":
+		return exe("MS-DOS executable, NE")
+	case "LE":
+		return exe("MS-DOS executable, LE")
+	case "LX":
+		return exe("MS-DOS executable, LX for OS/2")
+	}
+	return exe("MS-DOS executable")
+}
+
+// pe describes the portable executable header in b.
+func pe(b []byte) string {
+	const (
+		optional  = 24 // optional is the offset of the optional header.
+		subsystem = optional + 68
+	)
+	if len(b) < optional+2 {
+		return "PE executable for MS Windows"
+	}
+	title := "PE32 executable"
+	if le.Uint16(b[optional:]) == 0x20b {
+		title = "PE32+ executable"
+	}
+	if len(b) >= subsystem+2 {
+		switch le.Uint16(b[subsystem:]) {
+		case 2:
+			title += " (GUI)"
+		case 3:
+			title += " (console)"
+		}
+	}
+	return title + " for MS Windows"
+}
+
+// image matches bitmap and photo images.
+func image(b []byte) (Type, bool) {
+	img := func(title, ext string) (Type, bool) {
+		return Type{Kind: Image, Title: title, Ext: ext}, true
+	}
+	be := binary.BigEndian
+	switch {
+	case bytes.HasPrefix(b, []byte("\x89PNG\r\n\x1a\n")):
+		if len(b) >= 24 {
+			return img(fmt.Sprintf("PNG image data, %d x %d", be.Uint32(b[16:]), be.Uint32(b[20:])), ".png")
+		}
+		return img("PNG image data", ".png")
+	case bytes.HasPrefix(b, []byte("GIF87a")), bytes.HasPrefix(b, []byte("GIF89a")):
+		if len(b) >= 10 {
+			return img(fmt.Sprintf("GIF image data, version %s, %d x %d",
+				b[3:6], le.Uint16(b[6:]), le.Uint16(b[8:])), ".gif")
+		}
+		return img(fmt.Sprintf("GIF image data, version %s", b[3:6]), ".gif")
+	case bytes.HasPrefix(b, []byte("\xff\xd8\xff")):
+		return img("JPEG image data", ".jpg")
+	case bytes.HasPrefix(b, []byte("\xff\x0a")):
+		return img("JPEG XL codestream", ".jxl")
+	case bytes.HasPrefix(b, []byte("\x00\x00\x00\x0cJXL \r\n\x87\n")):
+		return img("JPEG XL container", ".jxl")
+	case bytes.HasPrefix(b, []byte("II*\x00")):
+		return img("TIFF image data, little-endian", ".tif")
+	case bytes.HasPrefix(b, []byte("MM\x00*")):
+		return img("TIFF image data, big-endian", ".tif")
+	case bytes.HasPrefix(b, []byte("XBIN\x1a")):
+		return img("XBin image data", ".xb")
+	case len(b) >= 12 && string(b[4:12]) == "ftypavif", len(b) >= 12 && string(b[4:12]) == "ftypavis":
+		return img("ISO Media, AVIF Image", ".avif")
+	case len(b) >= 12 && string(b[:4]) == "RIFF" && string(b[8:12]) == "WEBP":
+		return img("RIFF (little-endian) data, Web/P image", ".webp")
+	case len(b) >= 12 && string(b[:4]) == "FORM":
+		switch string(b[8:12]) {
+		case "ILBM":
+			return img("IFF data, ILBM interleaved image", ".iff")
+		case "PBM ":
+			return img("IFF data, PBM image", ".lbm")
+		case "ANIM":
+			return img("IFF data, ANIM animation", ".anim")
+		}
+	}
+	if t, ok := bmp(b); ok {
+		return t, true
+	}
+	return pcx(b)
+}
+
+// bmp matches a Windows or OS/2 bitmap using the size of its information header.
+func bmp(b []byte) (Type, bool) {
+	const info = 14
+	if len(b) < info+12 || b[0] != 'B' || b[1] != 'M' {
+		return Type{}, false
+	}
+	switch le.Uint32(b[info:]) {
+	case 12:
+		return Type{Kind: Image, Title: fmt.Sprintf("PC bitmap, OS/2 1.x format, %d x %d",
+			le.Uint16(b[info+4:]), le.Uint16(b[info+6:])), Ext: ".bmp"}, true
+	case 40, 52, 56, 64, 108, 124:
+		return Type{Kind: Image, Title: fmt.Sprintf("PC bitmap, Windows format, %d x %d",
+			int32(le.Uint32(b[info+4:])), abs(int32(le.Uint32(b[info+8:])))), Ext: ".bmp"}, true
+	}
+	return Type{}, false
+}
+
+// pcx matches a ZSoft PC Paintbrush image.
+func pcx(b []byte) (Type, bool) {
+	const header = 128
+	if len(b) < header || b[0] != 0x0a || b[1] > 5 || b[1] == 1 || b[2] > 1 {
+		return Type{}, false
+	}
+	switch b[3] {
+	case 1, 2, 4, 8:
+	default:
+		return Type{}, false
+	}
+	x0, y0, x1, y1 := le.Uint16(b[4:]), le.Uint16(b[6:]), le.Uint16(b[8:]), le.Uint16(b[10:])
+	if x1 < x0 || y1 < y0 || b[64] != 0 {
+		return Type{}, false
+	}
+	return Type{Kind: Image, Title: fmt.Sprintf("PCX image data, %d x %d",
+		x1-x0+1, y1-y0+1), Ext: ".pcx"}, true
+}
+
+// footer matches the signature at the end of a TrueVision Targa image.
+func footer(b []byte) (Type, bool) {
+	const sig = "TRUEVISION-XFILE.\x00"
+	if !bytes.HasSuffix(b, []byte(sig)) {
+		return Type{}, false
+	}
+	return Type{Kind: Image, Title: "Targa image data", Ext: ".tga"}, true
+}
+
+// audio matches music modules and sampled sound.
+func audio(b []byte) (Type, bool) {
+	snd := func(title, ext string) (Type, bool) {
+		return Type{Kind: Audio, Title: title, Ext: ext}, true
+	}
+	switch {
+	case bytes.HasPrefix(b, []byte("Extended Module: ")):
+		return snd("Fasttracker II module sound data", ".xm")
+	case bytes.HasPrefix(b, []byte("IMPM")):
+		return snd("Impulse Tracker module sound data", ".it")
+	case bytes.HasPrefix(b, []byte("MTM")) && len(b) > 3 && b[3] < 0x20:
+		return snd("MultiTracker Module sound file", ".mtm")
+	case bytes.HasPrefix(b, []byte("MThd")):
+		return snd("Standard MIDI data", ".mid")
+	case bytes.HasPrefix(b, []byte("Creative Voice File\x1a")):
+		return snd("Creative Labs voice data", ".voc")
+	case bytes.HasPrefix(b, []byte("OggS")):
+		return snd("Ogg data", ".ogg")
+	case bytes.HasPrefix(b, []byte("fLaC")):
+		return snd("FLAC audio bitstream data", ".flac")
+	case bytes.HasPrefix(b, []byte("ID3")) && len(b) > 4:
+		return snd(fmt.Sprintf("Audio file with ID3 version 2.%d.%d", b[3], b[4]), ".mp3")
+	case len(b) > 1 && b[0] == 0xff && b[1]&0xfe == 0xfa:
+		return snd("MPEG ADTS, layer III, v1", ".mp3")
+	case len(b) >= 12 && string(b[:4]) == "RIFF" && string(b[8:12]) == "WAVE":
+		return snd("RIFF (little-endian) data, WAVE audio", ".wav")
+	case len(b) >= 12 && string(b[:4]) == "FORM" && string(b[8:12]) == "8SVX":
+		return snd("IFF data, 8SVX 8-bit sampled sound voice", ".8svx")
+	case len(b) >= 48 && string(b[44:48]) == "SCRM":
+		return snd("ScreamTracker III Module sound data", ".s3m")
+	}
+	return mod(b)
+}
+
+// mod matches the signature of a Protracker module and its many clones.
+func mod(b []byte) (Type, bool) {
+	const sig = 1080
+	if len(b) < sig+4 {
+		return Type{}, false
+	}
+	s := string(b[sig : sig+4])
+	channels := 0
+	switch s {
+	case "M.K.", "M!K!", "FLT4", "4CHN":
+		channels = 4
+	case "6CHN":
+		channels = 6
+	case "8CHN", "FLT8", "CD81", "OKTA":
+		channels = 8
+	default:
+		if s[2:] == "CH" && s[0] >= '1' && s[0] <= '3' && s[1] >= '0' && s[1] <= '9' {
+			channels = int(s[0]-'0')*10 + int(s[1]-'0')
+		}
+	}
+	if channels == 0 {
+		return Type{}, false
+	}
+	return Type{Kind: Audio, Title: fmt.Sprintf("%d-channel Protracker module sound data", channels), Ext: ".mod"}, true
+}
+
+func abs(i int32) int32 {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
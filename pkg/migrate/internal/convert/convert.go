@@ -0,0 +1,220 @@
+// Package convert translates the MySQL model records into Postgres model records.
+package convert
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	mysql "github.com/Defacto2/df2/pkg/models/mysql"
+	pgsql "github.com/Defacto2/df2/pkg/models/psql"
+	"github.com/volatiletech/null/v8"
+)
+
+var ErrRecord = errors.New("record must be a struct")
+
+const nul = "NULL"
+
+// File returns the Postgres copy of the MySQL files record.
+func File(f *mysql.File) *pgsql.File {
+	if f == nil {
+		return nil
+	}
+	return &pgsql.File{
+		ID:                    int64(f.ID),
+		UUID:                  f.UUID,
+		ListRelations:         f.ListRelations,
+		WebID16colors:         f.WebID16colors,
+		WebIDGithub:           f.WebIDGithub,
+		WebIDYoutube:          f.WebIDYoutube,
+		WebIDPouet:            Int(f.WebIDPouet),
+		WebIDDemozoo:          Int(f.WebIDDemozoo),
+		GroupBrandFor:         f.GroupBrandFor,
+		GroupBrandBy:          f.GroupBrandBy,
+		RecordTitle:           f.RecordTitle,
+		DateIssuedYear:        Year(f.DateIssuedYear),
+		DateIssuedMonth:       Date(f.DateIssuedMonth),
+		DateIssuedDay:         Date(f.DateIssuedDay),
+		CreditText:            f.CreditText,
+		CreditProgram:         f.CreditProgram,
+		CreditIllustration:    f.CreditIllustration,
+		CreditAudio:           f.CreditAudio,
+		Filename:              f.Filename,
+		Filesize:              Int(f.Filesize),
+		ListLinks:             f.ListLinks,
+		FileSecurityAlertURL:  f.FileSecurityAlertURL,
+		FileZipContent:        f.FileZipContent,
+		FileMagicType:         f.FileMagicType,
+		PreviewImage:          f.PreviewImage,
+		FileIntegrityStrong:   f.FileIntegrityStrong,
+		FileIntegrityWeak:     f.FileIntegrityWeak,
+		FileLastModified:      Time(f.FileLastModified),
+		Platform:              f.Platform,
+		Section:               f.Section,
+		Comment:               f.Comment,
+		Createdat:             Time(f.Createdat),
+		Updatedat:             Time(f.Updatedat),
+		Deletedat:             Time(f.Deletedat),
+		Updatedby:             f.Updatedby,
+		Deletedby:             f.Deletedby,
+		RetrotxtReadme:        f.RetrotxtReadme,
+		RetrotxtNoReadme:      Flag(f.RetrotxtNoReadme),
+		DoseeRunProgram:       f.DoseeRunProgram,
+		DoseeHardwareCPU:      f.DoseeHardwareCPU,
+		DoseeHardwareGraphic:  f.DoseeHardwareGraphic,
+		DoseeHardwareAudio:    f.DoseeHardwareAudio,
+		DoseeNoAspectRatioFix: Flag(f.DoseeNoAspectRatioFix),
+		DoseeIncompatible:     Flag(f.DoseeIncompatible),
+		DoseeNoEms:            Flag(f.DoseeNoEms),
+		DoseeNoXMS:            Flag(f.DoseeNoXMS),
+		DoseeNoUmb:            Flag(f.DoseeNoUmb),
+		DoseeLoadUtilities:    Flag(f.DoseeLoadUtilities),
+	}
+}
+
+// Groupname returns the Postgres copy of the MySQL groupnames record.
+func Groupname(g *mysql.Groupname) *pgsql.Groupname {
+	if g == nil {
+		return nil
+	}
+	return &pgsql.Groupname{
+		ID:          int(g.ID),
+		Pubname:     g.Pubname,
+		Initialisms: g.Initialisms,
+	}
+}
+
+// Netresource returns the Postgres copy of the MySQL netresources record.
+func Netresource(n *mysql.Netresource) *pgsql.Netresource {
+	if n == nil {
+		return nil
+	}
+	return &pgsql.Netresource{
+		ID:               int64(n.ID),
+		UUID:             n.UUID,
+		Legacyid:         Int(n.Legacyid),
+		Httpstatuscode:   Int(n.Httpstatuscode),
+		Httpstatustext:   n.Httpstatustext,
+		Httplocation:     n.Httplocation,
+		Httpetag:         n.Httpetag,
+		Httplastmodified: n.Httplastmodified,
+		Metatitle:        n.Metatitle,
+		Metadescription:  n.Metadescription,
+		Metaauthors:      n.Metaauthors,
+		Metakeywords:     n.Metakeywords,
+		Uriref:           n.Uriref,
+		Title:            n.Title,
+		DateIssuedYear:   Year(n.DateIssuedYear),
+		DateIssuedMonth:  Date(n.DateIssuedMonth),
+		DateIssuedDay:    Date(n.DateIssuedDay),
+		Comment:          n.Comment,
+		Categorykey:      n.Categorykey,
+		Categorysort:     n.Categorysort,
+		Deletedat:        Time(n.Deletedat),
+		Deletedatcomment: n.Deletedatcomment,
+		Createdat:        Time(n.Createdat),
+		Updatedat:        Time(n.Updatedat),
+	}
+}
+
+// Date returns the month or day part of a date, a zero value is returned as NULL.
+func Date(i null.Int8) null.Int16 {
+	if !i.Valid || i.Int8 == 0 {
+		return null.Int16{}
+	}
+	return null.Int16From(int16(i.Int8))
+}
+
+// Flag returns the MySQL tinyint flag as a boolean 0 or 1 value.
+// Any non-zero value is treated as true.
+func Flag(i null.Int8) null.Int16 {
+	if !i.Valid {
+		return null.Int16{}
+	}
+	if i.Int8 != 0 {
+		return null.Int16From(1)
+	}
+	return null.Int16From(0)
+}
+
+// Int returns the integer as a 64-bit integer.
+func Int(i null.Int) null.Int64 {
+	if !i.Valid {
+		return null.Int64{}
+	}
+	return null.Int64From(int64(i.Int))
+}
+
+// Time returns the timestamp in UTC.
+// The MySQL zero date, 0000-00-00 00:00:00, is returned as NULL.
+func Time(t null.Time) null.Time {
+	if !t.Valid || t.Time.IsZero() || t.Time.Year() <= 0 {
+		return null.Time{}
+	}
+	return null.TimeFrom(t.Time.UTC())
+}
+
+// Year returns the year part of a date, a zero value is returned as NULL.
+func Year(i null.Int16) null.Int16 {
+	if !i.Valid || i.Int16 == 0 {
+		return null.Int16{}
+	}
+	return i
+}
+
+// Columns returns the column names of a model columns struct, such as pgsql.FileColumns.
+func Columns(cols any) []string {
+	v := reflect.Indirect(reflect.ValueOf(cols))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	names := make([]string, 0, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		if s, ok := v.Field(i).Interface().(string); ok {
+			names = append(names, s)
+		}
+	}
+	return names
+}
+
+// Sum writes the values of the model record to the writer,
+// which is intended to be a hash used to create a checksum.
+// The timestamps are written in UTC, so the time zone of the
+// database connection does not change the result.
+func Sum(w io.Writer, record any) error {
+	v := reflect.Indirect(reflect.ValueOf(record))
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("sum %T: %w", record, ErrRecord)
+	}
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		if tag := t.Field(i).Tag.Get("boil"); tag == "" || tag == "-" {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%s|", value(v.Field(i).Interface())); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+func value(x any) string {
+	if t, ok := x.(null.Time); ok {
+		if t = Time(t); !t.Valid {
+			return nul
+		}
+		return t.Time.Truncate(time.Second).Format(time.RFC3339)
+	}
+	if v, ok := x.(driver.Valuer); ok {
+		val, err := v.Value()
+		if err != nil || val == nil {
+			return nul
+		}
+		return fmt.Sprint(val)
+	}
+	return fmt.Sprint(x)
+}
@@ -0,0 +1,85 @@
+package convert_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/Defacto2/df2/pkg/migrate/internal/convert"
+	mysql "github.com/Defacto2/df2/pkg/models/mysql"
+	pgsql "github.com/Defacto2/df2/pkg/models/psql"
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+func TestFlag(t *testing.T) {
+	t.Parallel()
+	assert.False(t, convert.Flag(null.Int8{}).Valid)
+	assert.Equal(t, null.Int16From(0), convert.Flag(null.Int8From(0)))
+	assert.Equal(t, null.Int16From(1), convert.Flag(null.Int8From(1)))
+	assert.Equal(t, null.Int16From(1), convert.Flag(null.Int8From(-1)))
+}
+
+func TestTime(t *testing.T) {
+	t.Parallel()
+	assert.False(t, convert.Time(null.Time{}).Valid)
+	assert.False(t, convert.Time(null.TimeFrom(time.Time{})).Valid, "zero date")
+	est := time.FixedZone("EST", -5*60*60)
+	tm := time.Date(2020, 4, 6, 15, 51, 36, 0, est)
+	got := convert.Time(null.TimeFrom(tm))
+	assert.True(t, got.Valid)
+	assert.Equal(t, time.UTC, got.Time.Location())
+	assert.True(t, tm.Equal(got.Time))
+}
+
+func TestDate(t *testing.T) {
+	t.Parallel()
+	assert.False(t, convert.Date(null.Int8From(0)).Valid)
+	assert.Equal(t, null.Int16From(12), convert.Date(null.Int8From(12)))
+	assert.False(t, convert.Year(null.Int16From(0)).Valid)
+	assert.Equal(t, null.Int16From(1990), convert.Year(null.Int16From(1990)))
+}
+
+func TestFile(t *testing.T) {
+	t.Parallel()
+	assert.Nil(t, convert.File(nil))
+	f := convert.File(&mysql.File{
+		ID:               5,
+		Filesize:         null.IntFrom(1024),
+		DateIssuedMonth:  null.Int8From(0),
+		DoseeNoEms:       null.Int8From(1),
+		FileLastModified: null.TimeFrom(time.Time{}),
+	})
+	assert.Equal(t, int64(5), f.ID)
+	assert.Equal(t, null.Int64From(1024), f.Filesize)
+	assert.False(t, f.DateIssuedMonth.Valid)
+	assert.Equal(t, null.Int16From(1), f.DoseeNoEms)
+	assert.False(t, f.FileLastModified.Valid)
+}
+
+func TestColumns(t *testing.T) {
+	t.Parallel()
+	assert.Nil(t, convert.Columns(""))
+	cols := convert.Columns(pgsql.GroupnameColumns)
+	assert.Equal(t, []string{"id", "pubname", "initialisms"}, cols)
+}
+
+func TestSum(t *testing.T) {
+	t.Parallel()
+	err := convert.Sum(&bytes.Buffer{}, "")
+	assert.ErrorIs(t, err, convert.ErrRecord)
+
+	tm := time.Date(2020, 4, 6, 15, 51, 36, 0, time.UTC)
+	src := convert.Netresource(&mysql.Netresource{ID: 1, Legacyid: null.IntFrom(9), Createdat: null.TimeFrom(tm)})
+	dst := &pgsql.Netresource{ID: 1, Legacyid: null.Int64From(9), Createdat: null.TimeFrom(tm.In(time.Local))}
+	a, b := bytes.Buffer{}, bytes.Buffer{}
+	assert.Nil(t, convert.Sum(&a, src))
+	assert.Nil(t, convert.Sum(&b, dst))
+	assert.Equal(t, a.String(), b.String())
+	assert.Contains(t, a.String(), "2020-04-06T15:51:36Z")
+
+	dst.Title = null.StringFrom("changed")
+	b.Reset()
+	assert.Nil(t, convert.Sum(&b, dst))
+	assert.NotEqual(t, a.String(), b.String())
+}
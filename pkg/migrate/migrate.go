@@ -0,0 +1,432 @@
+// Package migrate copies the files, groupnames and netresources tables
+// from a MySQL database into a Postgres database.
+// The rows are copied in batches using the ascending primary key,
+// so an interrupted migration can be resumed by running it again.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"text/tabwriter"
+
+	"github.com/Defacto2/df2/pkg/database"
+	"github.com/Defacto2/df2/pkg/logger"
+	"github.com/Defacto2/df2/pkg/migrate/internal/convert"
+	mysql "github.com/Defacto2/df2/pkg/models/mysql"
+	pgsql "github.com/Defacto2/df2/pkg/models/psql"
+	"github.com/Defacto2/df2/pkg/str"
+	"github.com/gookit/color"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+var (
+	ErrDst   = errors.New("destination database must use the postgres engine")
+	ErrSrc   = errors.New("source database must use the mysql engine")
+	ErrTable = errors.New("table cannot be migrated")
+)
+
+const (
+	// Batch is the default number of rows copied in each transaction.
+	Batch = 1000
+
+	files        = "files"
+	groupnames   = "groupnames"
+	netresources = "netresources"
+)
+
+// Tables returns the names of the tables that can be migrated.
+func Tables() []string {
+	return []string{files, groupnames, netresources}
+}
+
+// Migrate copies the tables of the MySQL database into a Postgres database.
+type Migrate struct {
+	Batch  int      // Batch is the number of rows copied in each transaction.
+	Tables []string // Tables to copy, when empty all the Tables are copied.
+}
+
+// Report is the comparison of a copied table.
+type Report struct {
+	Table  string // Table name.
+	Copied int64  // Copied is the number of rows inserted by this migration.
+	Src    int64  // Src is the number of rows in the MySQL table.
+	Dst    int64  // Dst is the number of rows in the Postgres table.
+	SrcSum string // SrcSum is the checksum of the converted MySQL rows.
+	DstSum string // DstSum is the checksum of the Postgres rows.
+}
+
+// OK reports whether both the row counts and checksums of the tables match.
+func (r Report) OK() bool {
+	return r.Src == r.Dst && r.SrcSum == r.DstSum
+}
+
+// Run copies the tables from the src MySQL database to the dst Postgres database.
+// Rows with a primary key that already exist in the dst table are skipped,
+// so a failed or cancelled migration will resume from the last copied batch.
+func (m Migrate) Run(src, dst *sql.DB, w io.Writer) ([]Report, error) {
+	if src == nil || dst == nil {
+		return nil, database.ErrDB
+	}
+	if database.IsPostgres(src) {
+		return nil, ErrSrc
+	}
+	if !database.IsPostgres(dst) {
+		return nil, ErrDst
+	}
+	if w == nil {
+		w = io.Discard
+	}
+	if m.Batch < 1 {
+		m.Batch = Batch
+	}
+	tables := m.Tables
+	if len(tables) == 0 {
+		tables = Tables()
+	}
+	ctx := context.Background()
+	reports := make([]Report, 0, len(tables))
+	for _, name := range tables {
+		t, err := newTable(name)
+		if err != nil {
+			return reports, err
+		}
+		r, err := m.table(ctx, src, dst, w, t)
+		if err != nil {
+			return reports, fmt.Errorf("migrate %s: %w", name, err)
+		}
+		reports = append(reports, r)
+	}
+	return reports, nil
+}
+
+func (m Migrate) table(ctx context.Context, src, dst *sql.DB, w io.Writer, t table) (Report, error) {
+	r := Report{Table: t.name}
+	last, err := maxID(ctx, dst, t.name)
+	if err != nil {
+		return r, err
+	}
+	if last > 0 {
+		fmt.Fprintf(w, "%s resume after id %d\n", color.Primary.Sprint(t.name), last)
+	}
+	for {
+		n, id, err := t.copy(ctx, src, dst, last, m.Batch)
+		if err != nil {
+			return r, err
+		}
+		if n == 0 {
+			break
+		}
+		r.Copied += n
+		last = id
+		logger.PrintfCR(w, "%s copied %d rows, up to id %d", color.Primary.Sprint(t.name), r.Copied, last)
+	}
+	if r.Copied > 0 {
+		fmt.Fprintln(w)
+	}
+	if err := sequence(ctx, dst, t.name); err != nil {
+		return r, err
+	}
+	if r.Src, r.SrcSum, err = t.sumSrc(ctx, src, m.Batch); err != nil {
+		return r, err
+	}
+	if r.Dst, r.DstSum, err = t.sumDst(ctx, dst, m.Batch); err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
+// Print the reports as a table to the writer.
+func Print(w io.Writer, reports ...Report) {
+	if w == nil {
+		w = io.Discard
+	}
+	const padding = 3
+	tw := tabwriter.NewWriter(w, 0, 0, padding, ' ', 0)
+	defer tw.Flush()
+	fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t\n", "table", "copied", "mysql", "postgres", "checksum", "")
+	for _, r := range reports {
+		ok := str.Y()
+		if !r.OK() {
+			ok = str.X()
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%s\t%s\t\n", r.Table, r.Copied, r.Src, r.Dst, short(r.DstSum), ok)
+	}
+}
+
+func short(sum string) string {
+	const l = 12
+	if len(sum) <= l {
+		return sum
+	}
+	return sum[:l]
+}
+
+// maxID returns the largest primary key in the Postgres table.
+func maxID(ctx context.Context, db *sql.DB, name string) (int64, error) {
+	var id sql.NullInt64
+	if err := pgsql.NewQuery(qm.Select("MAX(id)"), qm.From(name)).QueryRowContext(ctx, db).Scan(&id); err != nil {
+		return 0, fmt.Errorf("max id: %w", err)
+	}
+	return id.Int64, nil
+}
+
+// sequence sets the Postgres primary key sequence to follow the copied ids,
+// otherwise new records would reuse the ids of the copied rows.
+func sequence(ctx context.Context, db *sql.DB, name string) error {
+	q := fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%s', 'id'), MAX(id)) FROM %s HAVING MAX(id) IS NOT NULL",
+		name, name)
+	if _, err := db.ExecContext(ctx, q); err != nil {
+		return fmt.Errorf("sequence: %w", err)
+	}
+	return nil
+}
+
+// table copies and sums the rows of a single table.
+type table struct {
+	name string
+	// copy inserts a batch of rows with an id greater than after,
+	// and returns the number of rows and the last id copied.
+	copy func(ctx context.Context, src, dst *sql.DB, after int64, limit int) (int64, int64, error)
+	// sumSrc and sumDst return the number of rows and the checksum of the table.
+	sumSrc func(ctx context.Context, db *sql.DB, limit int) (int64, string, error)
+	sumDst func(ctx context.Context, db *sql.DB, limit int) (int64, string, error)
+}
+
+func newTable(name string) (table, error) {
+	switch name {
+	case files:
+		return table{name: name, copy: copyFiles, sumSrc: sumSrcFiles, sumDst: sumDstFiles}, nil
+	case groupnames:
+		return table{name: name, copy: copyGroups, sumSrc: sumSrcGroups, sumDst: sumDstGroups}, nil
+	case netresources:
+		return table{name: name, copy: copyNets, sumSrc: sumSrcNets, sumDst: sumDstNets}, nil
+	}
+	return table{}, fmt.Errorf("%w: %q", ErrTable, name)
+}
+
+// batch returns the query mods to select the next rows after the id.
+func batch(after int64, limit int) []qm.QueryMod {
+	return []qm.QueryMod{
+		qm.Where("id > ?", after),
+		qm.OrderBy("id ASC"),
+		qm.Limit(limit),
+	}
+}
+
+// insert the records into the Postgres database within a single transaction.
+func insert(ctx context.Context, db *sql.DB, records func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	if err := records(tx); err != nil {
+		if e := tx.Rollback(); e != nil {
+			return errors.Join(err, e)
+		}
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+func copyFiles(ctx context.Context, src, dst *sql.DB, after int64, limit int) (int64, int64, error) {
+	rows, err := mysql.Files(batch(after, limit)...).All(ctx, src)
+	if err != nil {
+		return 0, 0, fmt.Errorf("select: %w", err)
+	}
+	if len(rows) == 0 {
+		return 0, after, nil
+	}
+	cols := boil.Whitelist(convert.Columns(pgsql.FileColumns)...)
+	err = insert(ctx, dst, func(tx *sql.Tx) error {
+		for _, row := range rows {
+			if err := convert.File(row).Insert(ctx, tx, cols); err != nil {
+				return fmt.Errorf("insert id %d: %w", row.ID, err)
+			}
+		}
+		return nil
+	})
+	return int64(len(rows)), int64(rows[len(rows)-1].ID), err
+}
+
+func copyGroups(ctx context.Context, src, dst *sql.DB, after int64, limit int) (int64, int64, error) {
+	rows, err := mysql.Groupnames(batch(after, limit)...).All(ctx, src)
+	if err != nil {
+		return 0, 0, fmt.Errorf("select: %w", err)
+	}
+	if len(rows) == 0 {
+		return 0, after, nil
+	}
+	cols := boil.Whitelist(convert.Columns(pgsql.GroupnameColumns)...)
+	err = insert(ctx, dst, func(tx *sql.Tx) error {
+		for _, row := range rows {
+			if err := convert.Groupname(row).Insert(ctx, tx, cols); err != nil {
+				return fmt.Errorf("insert id %d: %w", row.ID, err)
+			}
+		}
+		return nil
+	})
+	return int64(len(rows)), int64(rows[len(rows)-1].ID), err
+}
+
+func copyNets(ctx context.Context, src, dst *sql.DB, after int64, limit int) (int64, int64, error) {
+	rows, err := mysql.Netresources(batch(after, limit)...).All(ctx, src)
+	if err != nil {
+		return 0, 0, fmt.Errorf("select: %w", err)
+	}
+	if len(rows) == 0 {
+		return 0, after, nil
+	}
+	cols := boil.Whitelist(convert.Columns(pgsql.NetresourceColumns)...)
+	err = insert(ctx, dst, func(tx *sql.Tx) error {
+		for _, row := range rows {
+			if err := convert.Netresource(row).Insert(ctx, tx, cols); err != nil {
+				return fmt.Errorf("insert id %d: %w", row.ID, err)
+			}
+		}
+		return nil
+	})
+	return int64(len(rows)), int64(rows[len(rows)-1].ID), err
+}
+
+// checksum is a running count and hash of table rows.
+type checksum struct {
+	rows int64
+	h    hash.Hash
+}
+
+func newChecksum() checksum {
+	return checksum{h: sha256.New()}
+}
+
+func (c *checksum) add(record any) error {
+	c.rows++
+	return convert.Sum(c.h, record)
+}
+
+func (c checksum) String() string {
+	return hex.EncodeToString(c.h.Sum(nil))
+}
+
+func sumSrcFiles(ctx context.Context, db *sql.DB, limit int) (int64, string, error) {
+	c, after := newChecksum(), int64(0)
+	for {
+		rows, err := mysql.Files(batch(after, limit)...).All(ctx, db)
+		if err != nil {
+			return 0, "", fmt.Errorf("sum: %w", err)
+		}
+		if len(rows) == 0 {
+			return c.rows, c.String(), nil
+		}
+		for _, row := range rows {
+			if err := c.add(convert.File(row)); err != nil {
+				return 0, "", err
+			}
+		}
+		after = int64(rows[len(rows)-1].ID)
+	}
+}
+
+func sumDstFiles(ctx context.Context, db *sql.DB, limit int) (int64, string, error) {
+	c, after := newChecksum(), int64(0)
+	for {
+		rows, err := pgsql.Files(batch(after, limit)...).All(ctx, db)
+		if err != nil {
+			return 0, "", fmt.Errorf("sum: %w", err)
+		}
+		if len(rows) == 0 {
+			return c.rows, c.String(), nil
+		}
+		for _, row := range rows {
+			if err := c.add(row); err != nil {
+				return 0, "", err
+			}
+		}
+		after = rows[len(rows)-1].ID
+	}
+}
+
+func sumSrcGroups(ctx context.Context, db *sql.DB, limit int) (int64, string, error) {
+	c, after := newChecksum(), int64(0)
+	for {
+		rows, err := mysql.Groupnames(batch(after, limit)...).All(ctx, db)
+		if err != nil {
+			return 0, "", fmt.Errorf("sum: %w", err)
+		}
+		if len(rows) == 0 {
+			return c.rows, c.String(), nil
+		}
+		for _, row := range rows {
+			if err := c.add(convert.Groupname(row)); err != nil {
+				return 0, "", err
+			}
+		}
+		after = int64(rows[len(rows)-1].ID)
+	}
+}
+
+func sumDstGroups(ctx context.Context, db *sql.DB, limit int) (int64, string, error) {
+	c, after := newChecksum(), int64(0)
+	for {
+		rows, err := pgsql.Groupnames(batch(after, limit)...).All(ctx, db)
+		if err != nil {
+			return 0, "", fmt.Errorf("sum: %w", err)
+		}
+		if len(rows) == 0 {
+			return c.rows, c.String(), nil
+		}
+		for _, row := range rows {
+			if err := c.add(row); err != nil {
+				return 0, "", err
+			}
+		}
+		after = int64(rows[len(rows)-1].ID)
+	}
+}
+
+func sumSrcNets(ctx context.Context, db *sql.DB, limit int) (int64, string, error) {
+	c, after := newChecksum(), int64(0)
+	for {
+		rows, err := mysql.Netresources(batch(after, limit)...).All(ctx, db)
+		if err != nil {
+			return 0, "", fmt.Errorf("sum: %w", err)
+		}
+		if len(rows) == 0 {
+			return c.rows, c.String(), nil
+		}
+		for _, row := range rows {
+			if err := c.add(convert.Netresource(row)); err != nil {
+				return 0, "", err
+			}
+		}
+		after = int64(rows[len(rows)-1].ID)
+	}
+}
+
+func sumDstNets(ctx context.Context, db *sql.DB, limit int) (int64, string, error) {
+	c, after := newChecksum(), int64(0)
+	for {
+		rows, err := pgsql.Netresources(batch(after, limit)...).All(ctx, db)
+		if err != nil {
+			return 0, "", fmt.Errorf("sum: %w", err)
+		}
+		if len(rows) == 0 {
+			return c.rows, c.String(), nil
+		}
+		for _, row := range rows {
+			if err := c.add(row); err != nil {
+				return 0, "", err
+			}
+		}
+		after = rows[len(rows)-1].ID
+	}
+}
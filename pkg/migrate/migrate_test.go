@@ -0,0 +1,61 @@
+package migrate_test
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/Defacto2/df2/pkg/database/psql"
+	"github.com/Defacto2/df2/pkg/migrate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTables(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, []string{"files", "groupnames", "netresources"}, migrate.Tables())
+}
+
+func TestReport_OK(t *testing.T) {
+	t.Parallel()
+	r := migrate.Report{}
+	assert.True(t, r.OK())
+	r = migrate.Report{Src: 2, Dst: 2, SrcSum: "abc", DstSum: "abc"}
+	assert.True(t, r.OK())
+	r.Dst = 1
+	assert.False(t, r.OK())
+	r.Dst = 2
+	r.DstSum = "abd"
+	assert.False(t, r.OK())
+}
+
+func TestPrint(t *testing.T) {
+	t.Parallel()
+	migrate.Print(nil)
+	w := strings.Builder{}
+	migrate.Print(&w, migrate.Report{Table: "files", Copied: 5, Src: 5, Dst: 5})
+	assert.Contains(t, w.String(), "files")
+	assert.Contains(t, w.String(), "postgres")
+}
+
+func TestMigrate_Run(t *testing.T) {
+	t.Parallel()
+	m := migrate.Migrate{}
+	_, err := m.Run(nil, nil, nil)
+	assert.NotNil(t, err)
+
+	pg, err := sql.Open(psql.DriverName, psql.Connection{}.String())
+	assert.Nil(t, err)
+	defer pg.Close()
+	_, err = m.Run(pg, pg, nil)
+	assert.ErrorIs(t, err, migrate.ErrSrc)
+
+	my, err := sql.Open("mysql", "")
+	assert.Nil(t, err)
+	defer my.Close()
+	_, err = m.Run(my, my, nil)
+	assert.ErrorIs(t, err, migrate.ErrDst)
+
+	m.Tables = []string{"users"}
+	_, err = m.Run(my, pg, nil)
+	assert.ErrorIs(t, err, migrate.ErrTable)
+}
@@ -6,11 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 
 	"github.com/Defacto2/df2/pkg/database"
 	"github.com/Defacto2/df2/pkg/str"
 	"github.com/gookit/color"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 )
 
 var (
@@ -64,70 +66,46 @@ func List(db *sql.DB, w io.Writer, role Role) ([]string, int, error) {
 	if w == nil {
 		w = io.Discard
 	}
-	s := PeopleStmt(role, false)
-	if s == "" {
-		return nil, 0, fmt.Errorf("list statement %v: %w", role, ErrRole)
+	cols := Columns(role)
+	if cols == nil {
+		return nil, 0, fmt.Errorf("list columns %v: %w", role, ErrRole)
 	}
-	total, err := database.Total(db, w, &s)
-	if err != nil {
-		return nil, 0, fmt.Errorf("list totals: %w", err)
-	}
-	// interate through records
-	rows, err := db.Query(s)
-	if err != nil {
-		return nil, 0, fmt.Errorf("list query: %w", err)
-	} else if rows.Err() != nil {
-		return nil, 0, fmt.Errorf("list rows: %w", rows.Err())
-	}
-	defer rows.Close()
-	p := sql.NullString{}
-	people := []string{}
-	i := 0
-	for rows.Next() {
-		if err = rows.Scan(&p); err != nil {
-			return nil, 0, fmt.Errorf("list row scan: %w", err)
+	unique := map[string]struct{}{}
+	for _, col := range cols {
+		vals, err := database.Values(db, col, qm.And("deletedat IS NULL"))
+		if err != nil {
+			return nil, 0, fmt.Errorf("list query: %w", err)
 		}
-		if _, err = p.Value(); err != nil {
-			continue
+		for _, v := range vals {
+			unique[v] = struct{}{}
 		}
-		i++
-		people = append(people, fmt.Sprintf("%v", p.String))
 	}
-	return people, total, nil
+	people := make([]string, 0, len(unique))
+	for p := range unique {
+		people = append(people, p)
+	}
+	sort.Slice(people, func(i, j int) bool {
+		return strings.ToLower(people[i]) < strings.ToLower(people[j])
+	})
+	return people, len(people), nil
 }
 
-// PeopleStmt returns a complete SQL WHERE statement for people that are filtered by roles.
-func PeopleStmt(role Role, softDel bool) string {
-	del := func() string {
-		if softDel {
-			return ""
-		}
-		return "AND `deletedat` IS NULL"
-	}
-	union := func(s string) string {
-		return fmt.Sprintf(" UNION (SELECT DISTINCT %s AS pubCombined FROM files WHERE Length(%s) <> 0 %s)",
-			s, s, del())
-	}
-	s := ""
+// Columns returns the files table columns used to credit people of the role.
+func Columns(role Role) []string {
 	switch role {
 	case Writers:
-		s += union("credit_text")
+		return []string{"credit_text"}
 	case Musicians:
-		s += union("credit_audio")
+		return []string{"credit_audio"}
 	case Coders:
-		s += union("credit_program")
+		return []string{"credit_program"}
 	case Artists:
-		s += union("credit_illustration")
+		return []string{"credit_illustration"}
 	case Everyone:
-		s += union("credit_text")
-		s += union("credit_audio")
-		s += union("credit_program")
-		s += union("credit_illustration")
+		return []string{"credit_text", "credit_audio", "credit_program", "credit_illustration"}
 	default:
-		return ""
+		return nil
 	}
-	s += " ORDER BY pubCombined"
-	return strings.Replace(s, "UNION (SELECT DISTINCT ", "(SELECT DISTINCT ", 1)
 }
 
 func Roles(r string) Role {
@@ -148,7 +126,7 @@ func Roles(r string) Role {
 
 // Rename replaces the persons using name with the replacement.
 // The task must be limited names associated to a Role.
-func Rename(db *sql.DB, replacement, name string, r Role) (int64, error) {
+func Rename(db database.Executor, replacement, name string, r Role) (int64, error) {
 	if db == nil {
 		return 0, database.ErrDB
 	}
@@ -158,37 +136,25 @@ func Rename(db *sql.DB, replacement, name string, r Role) (int64, error) {
 	if name == "" {
 		return 0, ErrNoName
 	}
-	query := ""
+	col := ""
 	switch r {
-	case Artists:
-		query = "UPDATE `files` SET credit_illustration=? WHERE credit_illustration=?"
-	case Coders:
-		query = "UPDATE `files` SET credit_program=? WHERE credit_program=?"
-	case Musicians:
-		query = "UPDATE `files` SET credit_audio=? WHERE credit_audio=?"
-	case Writers:
-		query = "UPDATE `files` SET credit_text=? WHERE credit_text=?"
+	case Artists, Coders, Musicians, Writers:
+		col = Columns(r)[0]
 	case Everyone:
 		return 0, ErrRenAll
+	default:
+		return 0, ErrRole
 	}
-	stmt, err := db.Prepare(query)
-	if err != nil {
-		return 0, fmt.Errorf("rename people statement: %w", err)
-	}
-	defer stmt.Close()
-	res, err := stmt.Exec(replacement, name)
-	if err != nil {
-		return 0, fmt.Errorf("rename people exec: %w", err)
-	}
-	count, err := res.RowsAffected()
+	count, err := database.UpdateFiles(db, map[string]any{col: replacement},
+		qm.Where(col+" = ?", name))
 	if err != nil {
-		return 0, fmt.Errorf("rename people rows affected: %w", err)
+		return 0, fmt.Errorf("rename people: %w", err)
 	}
 	return count, nil
 }
 
 // Clean and save a malformed name.
-func Clean(db *sql.DB, w io.Writer, name string, r Role) (bool, error) {
+func Clean(db database.Executor, w io.Writer, name string, r Role) (bool, error) {
 	if db == nil {
 		return false, database.ErrDB
 	}
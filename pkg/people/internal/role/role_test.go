@@ -40,33 +40,28 @@ func TestList(t *testing.T) {
 	assert.Greater(t, len(s), 1)
 }
 
-func TestPeopleStmt(t *testing.T) {
+func TestColumns(t *testing.T) {
 	t.Parallel()
-	type args struct {
-		role               string
-		includeSoftDeletes bool
-	}
 	tests := []struct {
 		name string
-		args args
-		want bool
+		role string
+		want int
 	}{
-		{"error", args{"text", false}, false},
-		{"empty f", args{"", false}, true},
-		{"empty t", args{"", true}, true},
-		{"writers", args{"writers", true}, true},
-		{"writers", args{"w", true}, true},
-		{"musicians", args{"m", true}, true},
-		{"coders", args{"c", true}, true},
-		{"artists", args{"a", true}, true},
-		{"all", args{"", true}, true},
+		{"error", "text", 0},
+		{"empty", "", 4},
+		{"writers", "writers", 1},
+		{"writers", "w", 1},
+		{"musicians", "m", 1},
+		{"coders", "c", 1},
+		{"artists", "a", 1},
+		{"all", "all", 4},
 	}
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			if got := role.PeopleStmt(role.Roles(tt.args.role), tt.args.includeSoftDeletes); len(got) > 0 != tt.want {
-				t.Errorf("sqlPeople() = %v, want = %v", len(got) > 0, tt.want)
+			if got := role.Columns(role.Roles(tt.role)); len(got) != tt.want {
+				t.Errorf("Columns() = %v, want = %v", len(got), tt.want)
 			}
 		})
 	}
@@ -251,22 +251,28 @@ func parse(db *sql.DB, w io.Writer, dest, tmpl string, f Flags) error {
 }
 
 // Fix any malformed names found in the database.
-func Fix(db *sql.DB, w io.Writer) error {
+// A dry run prints the changes and then rolls them back.
+func Fix(db *sql.DB, w io.Writer, dry bool) error {
 	if db == nil {
 		return database.ErrDB
 	}
 	if w == nil {
 		w = io.Discard
 	}
+	tx, err := database.Begin(db, w, dry)
+	if err != nil {
+		return err
+	}
+	tx.Label = "clean the names of people"
 	c, start := 0, time.Now()
 	for _, r := range []role.Role{role.Artists, role.Coders, role.Musicians, role.Writers} {
 		credits, _, err := role.List(db, w, r)
 		if err != nil {
-			return err
+			return tx.Cancel(err)
 		}
 		for _, credit := range credits {
-			if r, err := role.Clean(db, w, credit, r); err != nil {
-				return err
+			if r, err := role.Clean(tx, w, credit, r); err != nil {
+				return tx.Cancel(err)
 			} else if r {
 				c++
 			}
@@ -274,7 +280,7 @@ func Fix(db *sql.DB, w io.Writer) error {
 	}
 	str.Total(w, c, "people fixes")
 	str.TimeTaken(w, time.Since(start).Seconds())
-	return nil
+	return tx.End()
 }
 
 // Tags are categories of people.
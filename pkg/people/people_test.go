@@ -78,13 +78,13 @@ func TestPrint(t *testing.T) {
 
 func TestFix(t *testing.T) {
 	t.Parallel()
-	err := people.Fix(nil, nil)
+	err := people.Fix(nil, nil, false)
 	assert.NotNil(t, err)
 	db, err := database.Connect(conf.Defaults())
 	assert.Nil(t, err)
 	defer db.Close()
 	bb := &bytes.Buffer{}
-	err = people.Fix(db, bb)
+	err = people.Fix(db, bb, false)
 	assert.Nil(t, err)
 	assert.Contains(t, bb.String(), `time taken`)
 }
@@ -19,12 +19,15 @@ import (
 	"github.com/Defacto2/df2/pkg/database"
 	"github.com/Defacto2/df2/pkg/directories"
 	"github.com/Defacto2/df2/pkg/logger"
+	"github.com/Defacto2/df2/pkg/sauce"
 	"github.com/Defacto2/df2/pkg/str"
+	"github.com/Defacto2/df2/pkg/text"
 	"github.com/gookit/color"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 )
 
 var (
-	ErrColVals = errors.New("each database value must have a matching table column")
 	ErrID      = errors.New("record id cannot be empty")
 	ErrProof   = errors.New("proof structure cannot be empty")
 	ErrRecord  = errors.New("record structure cannot be empty")
@@ -33,15 +36,13 @@ var (
 
 // Proof data.
 type Proof struct {
-	Base      string          // Base is the relative path to file downloads which use UUID as filenames.
-	BasePath  string          // BasePath to file downloads which use UUID as filenames.
-	Columns   []string        // Column names.
-	Count     int             // Count row index.
-	Missing   int             // Missing UUID files count.
-	Overwrite bool            // Overwrite flag (--overwrite) value.
-	Total     int             // Total rows.
-	Values    *[]sql.RawBytes // Values of the rows.
-	start     time.Time       // processing time
+	Base      string    // Base is the relative path to file downloads which use UUID as filenames.
+	BasePath  string    // BasePath to file downloads which use UUID as filenames.
+	Count     int       // Count row index.
+	Missing   int       // Missing UUID files count.
+	Overwrite bool      // Overwrite flag (--overwrite) value.
+	Total     int       // Total rows.
+	start     time.Time // processing time
 }
 
 func Init(cfg conf.Config) (Proof, error) {
@@ -73,23 +74,24 @@ func (p *Proof) Summary(id string) string {
 
 // Record of a file item.
 type Record struct {
-	ID   string // ID is a database generated, auto increment identifier.
-	UUID string // Universal unique ID.
-	File string // File is an absolute path to the hosted file download.
-	Name string // Name is the original filename of the download.
+	ID      string    // ID is a database generated, auto increment identifier.
+	UUID    string    // Universal unique ID.
+	File    string    // File is an absolute path to the hosted file download.
+	Name    string    // Name is the original filename of the download.
+	Created null.Time // Created is the time the record was created.
 }
 
 // New returns a file record using values from the database.
-func New(values []sql.RawBytes, path string) Record {
-	const id, uuid, filename = 0, 1, 4
-	if len(values) <= filename {
+func New(f database.File, path string) Record {
+	if f.ID < 1 {
 		return Record{}
 	}
 	return Record{
-		ID:   string(values[id]),
-		UUID: string(values[uuid]),
-		Name: string(values[filename]),
-		File: filepath.Join(path, string(values[uuid])),
+		ID:      strconv.FormatInt(f.ID, 10),
+		UUID:    f.UUID.String,
+		Name:    f.Filename.String,
+		File:    filepath.Join(path, f.UUID.String),
+		Created: f.Createdat,
 	}
 }
 
@@ -104,19 +106,12 @@ func (r Record) Approve(db *sql.DB, w io.Writer) error {
 	if w == nil {
 		w = io.Discard
 	}
-	const stmt = "UPDATE files SET updatedat=NOW(),updatedby=?,deletedat=NULL,deletedby=NULL WHERE id=?"
-	prep, err := db.Prepare(stmt)
+	i, err := database.UpdateFiles(db, map[string]any{"deletedat": nil, "deletedby": nil},
+		qm.Where("id = ?", r.ID))
 	if err != nil {
-		return fmt.Errorf("approve prepare: %w", err)
+		return fmt.Errorf("approve: %w", err)
 	}
-	defer prep.Close()
-	res, err := prep.Exec(database.UpdateID, r.ID)
-	if err != nil {
-		return fmt.Errorf("approve exec: %w", err)
-	}
-	if i, err := res.RowsAffected(); err != nil {
-		return fmt.Errorf("approve rows affected: %w", err)
-	} else if i == 0 {
+	if i == 0 {
 		fmt.Fprintf(w, " %s", str.X())
 		return nil
 	}
@@ -124,8 +119,8 @@ func (r Record) Approve(db *sql.DB, w io.Writer) error {
 	return nil
 }
 
-// Iterate through each stat value.
-func (r Record) Iterate(db *sql.DB, w io.Writer, cfg conf.Config, p Proof) error { //nolint:cyclop
+// Iterate prints the record and then handles its archive, SAUCE metadata and text encoding.
+func (r Record) Iterate(db *sql.DB, w io.Writer, cfg conf.Config, p Proof) error {
 	if db == nil {
 		return database.ErrDB
 	}
@@ -135,33 +130,60 @@ func (r Record) Iterate(db *sql.DB, w io.Writer, cfg conf.Config, p Proof) error
 	if reflect.DeepEqual(p, Proof{}) {
 		return ErrProof
 	}
-	if p.Values == nil {
-		return nil
+	if err := r.Prefix(w, &p); err != nil {
+		return err
 	}
-	if len(*p.Values) != len(p.Columns) {
-		return ErrColVals
-	}
-	for i, raw := range *p.Values {
-		s := database.Val(raw)
-		switch p.Columns[i] {
-		case "id":
-			if err := r.Prefix(w, &p); err != nil {
-				return err
-			}
-		case "createdat":
-			s, err := database.DateTime(raw)
-			if err != nil {
-				return err
-			}
-			fmt.Fprintf(w, "%v", s)
-		case "filename":
-			fmt.Fprintf(w, "%v", s)
-		case "file_zip_content":
-			if err := r.Zip(db, w, cfg, p.Overwrite); err != nil {
-				return err
-			}
-		default:
-		}
+	fmt.Fprintf(w, "%v", database.Timestamp(r.Created))
+	fmt.Fprintf(w, "%v", r.Name)
+	if err := r.Zip(db, w, cfg, p.Overwrite); err != nil {
+		return err
+	}
+	if err := r.Sauce(db, w); err != nil {
+		return err
+	}
+	return r.Encoding(db, w)
+}
+
+// Encoding classifies the character encoding of a text file and saves a UTF-8 copy beside the file.
+func (r Record) Encoding(db *sql.DB, w io.Writer) error {
+	if db == nil {
+		return database.ErrDB
+	}
+	if w == nil {
+		w = io.Discard
+	}
+	id, err := strconv.ParseInt(r.ID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("encoding id %q: %w", r.ID, err)
+	}
+	enc, err := text.Normalize(db, id, r.File, r.File, false)
+	if err != nil {
+		return fmt.Errorf("encoding: %w", err)
+	}
+	if enc != "" {
+		fmt.Fprintf(w, " encoding %s %s", enc, str.Y())
+	}
+	return nil
+}
+
+// Sauce fills the empty columns of the record using the SAUCE metadata of the file.
+func (r Record) Sauce(db *sql.DB, w io.Writer) error {
+	if db == nil {
+		return database.ErrDB
+	}
+	if w == nil {
+		w = io.Discard
+	}
+	id, err := strconv.ParseInt(r.ID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("sauce id %q: %w", r.ID, err)
+	}
+	names, err := sauce.Update(db, id, r.File)
+	if err != nil {
+		return fmt.Errorf("sauce: %w", err)
+	}
+	if len(names) > 0 {
+		fmt.Fprintf(w, " sauce %s %s", strings.Join(names, ", "), str.Y())
 	}
 	return nil
 }
@@ -177,14 +199,12 @@ func UpdateZipContent(db *sql.DB, w io.Writer, id, filename, content string, ite
 	if w == nil {
 		w = io.Discard
 	}
-	const stmt = "UPDATE files SET filename=?,file_zip_content=?,updatedat=NOW(),updatedby=?,platform=? WHERE id=?"
-	prep, err := db.Prepare(stmt)
-	if err != nil {
-		return fmt.Errorf("updatezip prepare: %w", err)
-	}
-	defer prep.Close()
-	if _, err := prep.Exec(filename, content, database.UpdateID, "image", id); err != nil {
-		return fmt.Errorf("updatezip exec: %w", err)
+	if _, err := database.UpdateFiles(db, map[string]any{
+		"filename":         filename,
+		"file_zip_content": content,
+		"platform":         "image",
+	}, qm.Where("id = ?", id)); err != nil {
+		return fmt.Errorf("updatezip: %w", err)
 	}
 	fmt.Fprintf(w, "%d items", items)
 	return nil
@@ -235,6 +255,9 @@ func (r Record) Zip(db *sql.DB, w io.Writer, cfg conf.Config, overwrite bool) er
 		Config: cfg,
 	}
 	if err := proof.Decompress(w); err != nil {
+		if err := archive.Flag(db, w, r.UUID, err); err != nil {
+			return fmt.Errorf("zip proof flag: %w", err)
+		}
 		return fmt.Errorf("zip proof: %w", err)
 	}
 	if err := r.Approve(db, w); err != nil {
@@ -1,7 +1,6 @@
 package record_test
 
 import (
-	"database/sql"
 	"io"
 	"os"
 	"path/filepath"
@@ -13,6 +12,7 @@ import (
 	"github.com/Defacto2/df2/pkg/proof/internal/record"
 	"github.com/gookit/color"
 	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
 	"go.uber.org/zap/buffer"
 )
 
@@ -36,17 +36,17 @@ func TestProof_Summary(t *testing.T) {
 
 func TestNew(t *testing.T) {
 	t.Parallel()
-	r := record.New(nil, "")
+	r := record.New(database.File{}, "")
 	assert.Empty(t, r)
-	v := []sql.RawBytes{
-		sql.RawBytes("1"),
-		sql.RawBytes(uuid),
-		sql.RawBytes("placeholder"),
-		sql.RawBytes("placeholder"),
-		sql.RawBytes("file.txt"),
+	f := database.File{
+		ID:       1,
+		UUID:     null.StringFrom(uuid),
+		Filename: null.StringFrom("file.txt"),
 	}
-	r = record.New(v, "somePath")
-	assert.NotEmpty(t, r)
+	r = record.New(f, "somePath")
+	assert.Equal(t, "1", r.ID)
+	assert.Equal(t, "file.txt", r.Name)
+	assert.Equal(t, filepath.Join("somePath", uuid), r.File)
 }
 
 func TestRecord_Approve(t *testing.T) {
@@ -83,16 +83,9 @@ func TestRecord_Iterate(t *testing.T) {
 	r = record.Record{
 		ID: "1",
 	}
-	raw := []sql.RawBytes{
-		sql.RawBytes("1"),
-		sql.RawBytes(time.Now().Format("2006-01-02T15:04:05Z")),
-		sql.RawBytes("file.txt"),
-		sql.RawBytes("readme.txt,prog.exe"),
-	}
-	p := record.Proof{
-		Columns: []string{"id", "createdat", "filename", "file_zip_content"},
-		Values:  &raw,
-	}
+	r.Name = "file.txt"
+	r.Created = null.TimeFrom(time.Now())
+	p := record.Proof{Total: 1, Count: 1}
 	err = r.Iterate(db, io.Discard, conf.Config{}, p)
 	assert.Nil(t, err)
 }
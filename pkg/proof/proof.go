@@ -10,6 +10,7 @@ import (
 	"github.com/Defacto2/df2/pkg/conf"
 	"github.com/Defacto2/df2/pkg/database"
 	"github.com/Defacto2/df2/pkg/proof/internal/record"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 )
 
 var ErrPointer = errors.New("pointer value cannot be nil")
@@ -41,7 +42,7 @@ func (request *Request) Query(db *sql.DB, w io.Writer, cfg conf.Config, id strin
 }
 
 // Queries parses all proofs.
-func (request Request) Queries(db *sql.DB, w io.Writer, cfg conf.Config) error { //nolint:cyclop,funlen
+func (request Request) Queries(db *sql.DB, w io.Writer, cfg conf.Config) error {
 	if db == nil {
 		return database.ErrDB
 	}
@@ -52,53 +53,22 @@ func (request Request) Queries(db *sql.DB, w io.Writer, cfg conf.Config) error {
 	if err != nil {
 		return err
 	}
-	rows, err := db.Query(Select(request.ByID))
+	files, err := database.Select(db, Select(request.ByID)...)
 	if err != nil {
 		return err
 	}
-	if rows.Err() != nil {
-		return rows.Err()
-	}
-	defer rows.Close()
-	cols, err := rows.Columns()
-	if err != nil {
-		return err
-	}
-	values := make([]sql.RawBytes, len(cols))
-	// more information: https://github.com/go-sql-driver/mysql/wiki/Examples
-	args := make([]any, len(values))
-	for i := range values {
-		args[i] = &values[i]
-	}
-	for rows.Next() {
-		s.Total++
-	}
+	s.Total = len(files)
 	sum, err := Total(&s, request)
 	if err != nil {
 		return err
 	}
 	fmt.Fprint(w, sum)
-	rows, err = db.Query(Select(request.ByID))
-	if err != nil {
-		return err
-	}
-	if rows.Err() != nil {
-		return rows.Err()
-	}
-	defer rows.Close()
-	for rows.Next() {
-		if err := rows.Scan(args...); err != nil {
-			return err
-		}
-		b, err := request.Skip(w, values)
-		if err != nil {
-			return err
-		}
-		if b {
+	for _, f := range files {
+		if request.Skip(w, f) {
 			continue
 		}
 		s.Count++
-		r := record.New(values, s.BasePath)
+		r := record.New(f, s.BasePath)
 		nw := w
 		if request.HideMissing {
 			nw = io.Discard
@@ -108,9 +78,7 @@ func (request Request) Queries(db *sql.DB, w io.Writer, cfg conf.Config) error {
 		} else if err != nil {
 			return err
 		}
-		s.Columns = cols
 		s.Overwrite = request.Overwrite
-		s.Values = &values
 		if err := r.Iterate(db, w, cfg, s); err != nil {
 			return err
 		}
@@ -120,38 +88,37 @@ func (request Request) Queries(db *sql.DB, w io.Writer, cfg conf.Config) error {
 }
 
 // Skip uses argument flags to check if a record is to be ignored.
-func (request Request) Skip(w io.Writer, values []sql.RawBytes) (bool, error) {
+func (request Request) Skip(w io.Writer, f database.File) bool {
 	if w == nil {
 		w = io.Discard
 	}
 	if request.ByID != "" && request.Overwrite {
-		return false, nil
+		return false
 	}
-	n, err := database.IsUnApproved(values)
-	if err != nil {
-		return false, err
-	}
-	if !n && !request.All {
+	if !database.IsUnApproved(f) && !request.All {
 		if request.ByID != "" {
 			fmt.Fprintf(w, "skip record id '%s', as it is not new\n", request.ByID)
 		}
-		return true, nil
+		return true
 	}
-	return false, nil
+	return false
 }
 
-func Select(id string) string {
-	s := "SELECT `id`,`uuid`,`deletedat`,`createdat`,`filename`,`file_zip_content`,`updatedat`,`platform`"
-	w := " WHERE `section` = 'releaseproof'"
-	if id != "" {
-		switch {
-		case database.IsUUID(id):
-			w = fmt.Sprintf("%v AND `uuid`=%q", w, id)
-		case database.IsID(id):
-			w = fmt.Sprintf("%v AND `id`=%q", w, id)
-		}
-	}
-	return s + " FROM `files`" + w
+// Select returns the query mods of the release proof file records,
+// or of the single proof with the id or uuid.
+func Select(id string) []qm.QueryMod {
+	mods := []qm.QueryMod{
+		qm.Select("id", "uuid", "deletedat", "createdat", "filename", "file_zip_content", "updatedat", "platform"),
+		qm.Where("section = ?", "releaseproof"),
+	}
+	switch {
+	case id == "":
+	case database.IsUUID(id):
+		mods = append(mods, qm.Where("uuid = ?", id))
+	case database.IsID(id):
+		mods = append(mods, qm.Where("id = ?", id))
+	}
+	return mods
 }
 
 // Total returns the sum of the records.
@@ -1,14 +1,15 @@
 package proof_test
 
 import (
-	"database/sql"
 	"io"
 	"testing"
+	"time"
 
 	"github.com/Defacto2/df2/pkg/conf"
 	"github.com/Defacto2/df2/pkg/database"
 	"github.com/Defacto2/df2/pkg/proof"
 	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
 )
 
 const uuid = "10000000-0000-0000-0000-000000000000"
@@ -34,44 +35,32 @@ func TestQuery(t *testing.T) {
 
 func Test_Select(t *testing.T) {
 	t.Parallel()
-	s := proof.Select("")
-	assert.Contains(t, s, "FROM `files` WHERE `section` = 'releaseproof'")
-	s = proof.Select("1")
-	assert.Contains(t, s, `="1"`)
-	s = proof.Select(uuid)
-	assert.Contains(t, s, `uuid`)
+	assert.Len(t, proof.Select(""), 2)
+	assert.Len(t, proof.Select("1"), 3)
+	assert.Len(t, proof.Select(uuid), 3)
+	assert.Len(t, proof.Select("not-an-id"), 2)
 }
 
 func TestRequest_Skip(t *testing.T) {
 	t.Parallel()
-	type fields struct {
-		Overwrite   bool
-		All         bool
-		HideMissing bool
-		ByID        string
-	}
+	now := time.Now()
+	unapproved := database.File{Deletedat: null.TimeFrom(now), Updatedat: null.TimeFrom(now)}
 	tests := []struct {
-		name   string
-		fields fields
-		values []sql.RawBytes
-		want   bool
+		name    string
+		request proof.Request
+		file    database.File
+		want    bool
 	}{
-		{"empty", fields{}, nil, true},
-		{"false", fields{ByID: "1", Overwrite: true}, nil, false},
+		{"empty", proof.Request{}, database.File{}, true},
+		{"overwrite", proof.Request{ByID: "1", Overwrite: true}, database.File{}, false},
+		{"all", proof.Request{All: true}, database.File{}, false},
+		{"unapproved", proof.Request{}, unapproved, false},
 	}
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			request := proof.Request{
-				Overwrite:   tt.fields.Overwrite,
-				All:         tt.fields.All,
-				HideMissing: tt.fields.HideMissing,
-				ByID:        tt.fields.ByID,
-			}
-			if got, _ := request.Skip(nil, tt.values); got != tt.want {
-				t.Errorf("Request.Skip() = %v, want %v", got, tt.want)
-			}
+			assert.Equal(t, tt.want, tt.request.Skip(nil, tt.file))
 		})
 	}
 }
@@ -16,23 +16,13 @@ import (
 
 	"github.com/Defacto2/df2/pkg/database"
 	"github.com/hako/durafmt"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 )
 
 var ErrJSON = errors.New("data fails json validation")
 
 type data [3]string
 
-const (
-	id = iota
-	uuid
-	recordtitle
-	groupbrandfor
-	groupbrandby
-	filename
-	dateissuedyear
-	createdat
-)
-
 // Files data for a JSON document.
 type Files struct {
 	Cols [3]string `json:"COLUMNS"`
@@ -51,36 +41,31 @@ type Thumb struct {
 }
 
 // Scan the thumbnail for usable JSON metadata.
-func (f *Thumb) Scan(values []sql.RawBytes) {
-	if len(values) < createdat+1 {
+func (f *Thumb) Scan(file database.File) {
+	if file.ID < 1 {
 		return
 	}
-	if id := string(values[id]); id != "" {
-		f.URLID = database.ObfuscateParam(id)
-	}
-	f.UUID = strings.ToLower(string(values[uuid]))
-	if t, err := time.Parse(time.RFC3339, string(values[createdat])); err != nil {
+	f.URLID = database.ObfuscateParam(strconv.FormatInt(file.ID, 10))
+	f.UUID = strings.ToLower(file.UUID.String)
+	if !file.Createdat.Valid {
 		f.timeAgo = "Sometime"
 	} else {
-		f.timeAgo = fmt.Sprint(durafmt.Parse(time.Since(t)).LimitFirstN(1))
+		f.timeAgo = fmt.Sprint(durafmt.Parse(time.Since(file.Createdat.Time)).LimitFirstN(1))
 	}
-	if rt := string(values[recordtitle]); rt != "" {
-		f.title = fmt.Sprintf("%s (%s)", values[recordtitle], values[filename])
+	if rt := file.RecordTitle.String; rt != "" {
+		f.title = fmt.Sprintf("%s (%s)", rt, file.Filename.String)
 	} else {
-		f.title = string(values[filename])
+		f.title = file.Filename.String
 	}
-	if g := string(values[groupbrandfor]); g != "" {
+	if g := file.GroupBrandFor.String; g != "" {
 		f.group = g
-	} else if g := string(values[groupbrandby]); g != "" {
+	} else if g := file.GroupBrandBy.String; g != "" {
 		f.group = g
 	} else {
 		f.group = "an unknown group"
 	}
-	if y := string(values[dateissuedyear]); y != "" {
-		i, err := strconv.Atoi(y)
-		if err == nil {
-			f.year = i
-		}
+	if file.DateIssuedYear.Valid {
+		f.year = int(file.DateIssuedYear.Int16)
 	}
 	f.Title = fmt.Sprintf("%s ago, %s for %s", f.timeAgo, f.title, f.group)
 	const min = 1980
@@ -97,30 +82,14 @@ func List(db *sql.DB, w io.Writer, limit uint, compress bool) error {
 	if w == nil {
 		w = io.Discard
 	}
-	query := sqlRecent(limit, false)
-	rows, err := db.Query(query)
+	files, err := database.Select(db, recent(limit, false)...)
 	if err != nil {
 		return fmt.Errorf("list query: %w", err)
-	} else if rows.Err() != nil {
-		return fmt.Errorf("list rows: %w", rows.Err())
-	}
-	defer rows.Close()
-	cols, err := rows.Columns()
-	if err != nil {
-		return fmt.Errorf("list columns: %w", err)
-	}
-	values := make([]sql.RawBytes, len(cols))
-	args := make([]any, len(values))
-	for i := range values {
-		args[i] = &values[i]
 	}
 	f := Files{Cols: [...]string{"uuid", "urlid", "title"}}
-	for rows.Next() {
-		if err = rows.Scan(args...); err != nil {
-			return fmt.Errorf("list rows next: %w", err)
-		}
+	for _, file := range files {
 		th := Thumb{}
-		th.Scan(values)
+		th.Scan(file)
 		f.Data = append(f.Data, [...]string{th.UUID, th.URLID, th.Title})
 	}
 	return list(w, f, compress)
@@ -155,16 +124,13 @@ func list(w io.Writer, f Files, compress bool) error {
 	return nil
 }
 
-func sqlRecent(limit uint, includeSoftDeletes bool) string {
-	const (
-		sel = "SELECT id,uuid,record_title,group_brand_for,group_brand_by,filename," +
-			"date_issued_year,createdat,updatedat FROM files"
-		where = " WHERE deletedat IS NULL"
-		order = " ORDER BY createdat DESC"
-	)
-	stmt := sel
+func recent(limit uint, includeSoftDeletes bool) []qm.QueryMod {
+	mods := []qm.QueryMod{
+		qm.Select("id", "uuid", "record_title", "group_brand_for", "group_brand_by", "filename",
+			"date_issued_year", "createdat", "updatedat"),
+	}
 	if includeSoftDeletes {
-		stmt += where
+		mods = append(mods, qm.Where("deletedat IS NULL"))
 	}
-	return stmt + order + " LIMIT " + strconv.Itoa(int(limit))
+	return append(mods, qm.OrderBy("createdat DESC"), qm.Limit(int(limit)))
 }
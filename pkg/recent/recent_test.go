@@ -2,7 +2,6 @@ package recent_test
 
 import (
 	"bytes"
-	"database/sql"
 	"io"
 	"testing"
 	"time"
@@ -11,6 +10,7 @@ import (
 	"github.com/Defacto2/df2/pkg/database"
 	"github.com/Defacto2/df2/pkg/recent"
 	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
 )
 
 const uuid = "d37e5b5f-f5bf-4138-9078-891e41b10a12"
@@ -18,21 +18,21 @@ const uuid = "d37e5b5f-f5bf-4138-9078-891e41b10a12"
 func TestThumb_Scan(t *testing.T) {
 	t.Parallel()
 	f := recent.Thumb{}
-	f.Scan(nil)
+	f.Scan(database.File{})
 	assert.Equal(t, "", f.URLID)
-	n := time.Now().Format(time.RFC3339)
-	v := []sql.RawBytes{
-		sql.RawBytes("1"),
-		sql.RawBytes(uuid),
-		sql.RawBytes("Placeholder title"),
-		sql.RawBytes("For some group"),
-		sql.RawBytes("By some group"),
-		sql.RawBytes("file.txt"),
-		sql.RawBytes("1990"),
-		sql.RawBytes(n),
+	v := database.File{
+		ID:             1,
+		UUID:           null.StringFrom(uuid),
+		RecordTitle:    null.StringFrom("Placeholder title"),
+		GroupBrandFor:  null.StringFrom("For some group"),
+		GroupBrandBy:   null.StringFrom("By some group"),
+		Filename:       null.StringFrom("file.txt"),
+		DateIssuedYear: null.Int16From(1990),
+		Createdat:      null.TimeFrom(time.Now()),
 	}
 	f.Scan(v)
-	assert.NotEmpty(t, f)
+	assert.NotEmpty(t, f.URLID)
+	assert.Contains(t, f.Title, "Placeholder title (file.txt) for For some group in 1990")
 }
 
 func TestList(t *testing.T) {
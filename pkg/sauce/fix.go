@@ -0,0 +1,138 @@
+package sauce
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Defacto2/df2/pkg/conf"
+	"github.com/Defacto2/df2/pkg/database"
+	"github.com/Defacto2/df2/pkg/directories"
+	"github.com/Defacto2/df2/pkg/str"
+	"github.com/gookit/color"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// columns are the file record columns that can be filled using the SAUCE metadata.
+var columns = []string{
+	"id", "uuid", "filename", "platform", "record_title", "group_brand_for",
+	"credit_illustration", "credit_audio",
+	"date_issued_year", "date_issued_month", "date_issued_day",
+}
+
+// Fill returns the columns and values of the f file record that are empty
+// and can be set using the SAUCE metadata. Existing values are never replaced.
+// The author of an audio file is credited as a musician, otherwise as an artist.
+func (r Record) Fill(f database.File) map[string]any {
+	cols := map[string]any{}
+	if r.Title != "" && strings.TrimSpace(f.RecordTitle.String) == "" {
+		cols["record_title"] = r.Title
+	}
+	if r.Group != "" && strings.TrimSpace(f.GroupBrandFor.String) == "" {
+		cols["group_brand_for"] = r.Group
+	}
+	credit, val := "credit_illustration", f.CreditIllustration.String
+	if r.DataType == Audio {
+		credit, val = "credit_audio", f.CreditAudio.String
+	}
+	if r.Author != "" && strings.TrimSpace(val) == "" {
+		cols[credit] = r.Author
+	}
+	if !r.Date.IsZero() && f.DateIssuedYear.Int16 == 0 {
+		cols["date_issued_year"] = r.Date.Year()
+		cols["date_issued_month"] = int(r.Date.Month())
+		cols["date_issued_day"] = r.Date.Day()
+	}
+	return cols
+}
+
+// Update fills the empty columns of the file record id using the SAUCE metadata
+// of the named file and returns the names of the updated columns.
+// A file without any SAUCE metadata is not an error.
+func Update(db *sql.DB, id int64, name string) ([]string, error) {
+	if db == nil {
+		return nil, database.ErrDB
+	}
+	r, err := Open(name)
+	if errors.Is(err, ErrNoRecord) || errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil && !errors.Is(err, ErrComments) {
+		return nil, err
+	}
+	files, err := database.Select(db, qm.Select(columns...), qm.Where("id = ?", id))
+	if err != nil {
+		return nil, fmt.Errorf("sauce select %d: %w", id, err)
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+	return update(db, r, files[0])
+}
+
+func update(exec database.Executor, r Record, f database.File) ([]string, error) {
+	cols := r.Fill(f)
+	if len(cols) == 0 {
+		return nil, nil
+	}
+	if _, err := database.UpdateFiles(exec, cols, qm.Where("id = ?", f.ID)); err != nil {
+		return nil, fmt.Errorf("sauce update %d: %w", f.ID, err)
+	}
+	names := make([]string, 0, len(cols))
+	for name := range cols {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Fix reads the SAUCE metadata of the text, ANSI and music downloads
+// and uses it to fill the empty record title, artist credit, group and publish date columns.
+// A dry run prints the changes and then rolls them back.
+func Fix(db *sql.DB, w io.Writer, cfg conf.Config, dry bool) error {
+	if db == nil {
+		return database.ErrDB
+	}
+	if w == nil {
+		w = io.Discard
+	}
+	dir, err := directories.Init(cfg, false)
+	if err != nil {
+		return err
+	}
+	files, err := database.Select(db, qm.Select(columns...),
+		qm.WhereIn("platform IN ?", "text", "textamiga", "ansi", "audio"),
+		qm.OrderBy("id DESC"))
+	if err != nil {
+		return fmt.Errorf("sauce fix select: %w", err)
+	}
+	tx, err := database.Begin(db, w, dry)
+	if err != nil {
+		return err
+	}
+	tx.Label = "fill empty records using sauce metadata"
+	i := 0
+	for _, f := range files {
+		r, err := Open(filepath.Join(dir.UUID, f.UUID.String))
+		if err != nil && !errors.Is(err, ErrComments) {
+			continue
+		}
+		names, err := update(tx, r, f)
+		if err != nil {
+			return tx.Cancel(err)
+		}
+		if len(names) == 0 {
+			continue
+		}
+		i++
+		fmt.Fprintf(w, "%s %s %s %s\n", color.Primary.Sprint(f.ID), f.Filename.String,
+			color.Secondary.Sprint(strings.Join(names, ", ")), str.Y())
+	}
+	str.Total(w, i, "records filled using sauce metadata")
+	return tx.End()
+}
@@ -0,0 +1,220 @@
+// Package sauce reads the SAUCE metadata record that is appended to the end of
+// many text, ANSI art, bitmap and music files.
+//
+// SAUCE, the Standard Architecture for Universal Comment Extensions,
+// was created in 1994 by the ACiD art group and records the title, author, group
+// and date of a work, along with the font, dimensions and display flags of the text.
+// The specification is at https://www.acid.org/info/sauce/sauce.htm.
+package sauce
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Defacto2/df2/pkg/charset"
+)
+
+var (
+	ErrNoRecord = errors.New("no sauce record found")
+	ErrComments = errors.New("sauce comment block is invalid")
+)
+
+const (
+	ID      = "SAUCE" // ID is the identifier that begins a SAUCE record.
+	Size    = 128     // Size in bytes of a SAUCE record.
+	comntID = "COMNT" // comntID is the identifier that begins a comment block.
+	comntLn = 64      // comntLn is the fixed length of each comment line.
+	eof     = 0x1a    // eof is the MS-DOS end-of-file marker that precedes the metadata.
+	layout  = "20060102"
+)
+
+// DataType is the type of the data in the file.
+type DataType uint8
+
+const (
+	None       DataType = iota // None is undefined data.
+	Character                  // Character is a text based file, such as ASCII or ANSI art.
+	Bitmap                     // Bitmap is a graphic image or animation.
+	Vector                     // Vector is a vector graphic.
+	Audio                      // Audio is a music or sound file.
+	BinaryText                 // BinaryText is raw character and attribute memory pairs.
+	XBin                       // XBin is an extended binary text file.
+	Archive                    // Archive is a compressed file archive.
+	Executable                 // Executable is a program.
+)
+
+func (d DataType) String() string {
+	if d > Executable {
+		return ""
+	}
+	return [...]string{
+		"none", "character", "bitmap", "vector", "audio",
+		"binary text", "xbin", "archive", "executable",
+	}[d]
+}
+
+// The file types of the character data type.
+const (
+	ASCII      uint8 = iota // ASCII is plain text.
+	ANSI                    // ANSI is text with ANSI escape sequences.
+	ANSiMation              // ANSiMation is an animated ANSI.
+)
+
+// Record is the SAUCE metadata of a file.
+// The text fields are decoded from CP437 into UTF-8 with any padding removed.
+type Record struct {
+	Version  string    // Version of the SAUCE record, which should always be 00.
+	Title    string    // Title of the work.
+	Author   string    // Author or the handle of the author.
+	Group    string    // Group or company of the author.
+	Date     time.Time // Date of creation, or the zero time when it is invalid.
+	FileSize uint32    // FileSize of the original file without the metadata.
+	DataType DataType  // DataType of the file.
+	FileType uint8     // FileType is the type of file within the data type.
+	TInfo    [4]uint16 // TInfo is the type dependent information, such as the width and lines of the text.
+	Flags    uint8     // Flags are the type dependent display flags.
+	Font     string    // Font is the name of the font used by the text.
+	Comments []string  // Comments are the optional lines of comments.
+}
+
+// Open reads the SAUCE record of the named file.
+func Open(name string) (Record, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return Record{}, fmt.Errorf("sauce open: %w", err)
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Read returns the SAUCE record at the end of r.
+func Read(r io.ReadSeeker) (Record, error) {
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return Record{}, fmt.Errorf("sauce seek: %w", err)
+	}
+	if end < Size {
+		return Record{}, ErrNoRecord
+	}
+	// the comment block uses at most 255 lines
+	n := min(end, Size+int64(len(comntID))+comntLn*255)
+	if _, err := r.Seek(end-n, io.SeekStart); err != nil {
+		return Record{}, fmt.Errorf("sauce seek: %w", err)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return Record{}, fmt.Errorf("sauce read: %w", err)
+	}
+	return Decode(b)
+}
+
+// Decode returns the SAUCE record at the end of the b file content.
+func Decode(b []byte) (Record, error) {
+	if len(b) < Size {
+		return Record{}, ErrNoRecord
+	}
+	s := b[len(b)-Size:]
+	if !bytes.HasPrefix(s, []byte(ID)) {
+		return Record{}, ErrNoRecord
+	}
+	r := Record{
+		Version:  string(s[5:7]),
+		Title:    text(s[7:42]),
+		Author:   text(s[42:62]),
+		Group:    text(s[62:82]),
+		FileSize: binary.LittleEndian.Uint32(s[90:94]),
+		DataType: DataType(s[94]),
+		FileType: s[95],
+		Flags:    s[105],
+		Font:     text(s[106:128]),
+	}
+	if t, err := time.Parse(layout, string(s[82:90])); err == nil {
+		r.Date = t
+	}
+	for i := range r.TInfo {
+		r.TInfo[i] = binary.LittleEndian.Uint16(s[96+i*2:])
+	}
+	lines := int(s[104])
+	if lines == 0 {
+		return r, nil
+	}
+	start := len(b) - Size - len(comntID) - lines*comntLn
+	if start < 0 || !bytes.HasPrefix(b[start:], []byte(comntID)) {
+		return r, ErrComments
+	}
+	block := b[start+len(comntID) : len(b)-Size]
+	for i := 0; i < lines; i++ {
+		r.Comments = append(r.Comments, text(block[i*comntLn:(i+1)*comntLn]))
+	}
+	return r, nil
+}
+
+// Index returns the index of the first byte of the SAUCE metadata in the b file content,
+// which includes any comment block and the end-of-file marker that precedes it.
+// If there is no SAUCE record, the length of b is returned.
+func Index(b []byte) int {
+	r, err := Decode(b)
+	if err != nil && !errors.Is(err, ErrComments) {
+		return len(b)
+	}
+	i := len(b) - Size
+	if n := len(r.Comments); n > 0 {
+		i -= len(comntID) + n*comntLn
+	}
+	if i > 0 && b[i-1] == eof {
+		i--
+	}
+	return i
+}
+
+// ICE returns true when the text should use iCE colors,
+// where the blink attribute is used for high intensity background colors.
+func (r Record) ICE() bool {
+	const nonBlink = 1
+	switch r.DataType { //nolint:exhaustive
+	case Character, BinaryText:
+		return r.Flags&nonBlink != 0
+	}
+	return false
+}
+
+// Width returns the character width of the text or 0 when it is unknown.
+func (r Record) Width() int {
+	switch r.DataType { //nolint:exhaustive
+	case Character:
+		if r.FileType <= ANSiMation {
+			return int(r.TInfo[0])
+		}
+	case BinaryText:
+		// the file type of binary text stores half the width
+		return int(r.FileType) * 2
+	case XBin:
+		return int(r.TInfo[0])
+	}
+	return 0
+}
+
+// Lines returns the number of lines of the text or 0 when it is unknown.
+func (r Record) Lines() int {
+	switch r.DataType { //nolint:exhaustive
+	case Character:
+		if r.FileType <= ANSiMation {
+			return int(r.TInfo[1])
+		}
+	case XBin:
+		return int(r.TInfo[1])
+	}
+	return 0
+}
+
+// text returns the padded, CP437 field as a trimmed UTF-8 string.
+func text(b []byte) string {
+	b = bytes.TrimRight(b, "\x00")
+	return strings.TrimSpace(charset.Decode(b, charset.CP437))
+}
@@ -0,0 +1,127 @@
+package sauce_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Defacto2/df2/pkg/conf"
+	"github.com/Defacto2/df2/pkg/database"
+	"github.com/Defacto2/df2/pkg/sauce"
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+func testAns() string { return filepath.Join("..", "..", "testdata", "text", "test.ans") }
+
+func TestOpen(t *testing.T) {
+	t.Parallel()
+	_, err := sauce.Open("")
+	assert.NotNil(t, err)
+	_, err = sauce.Open(filepath.Join("..", "..", "testdata", "text", "test.txt"))
+	assert.ErrorIs(t, err, sauce.ErrNoRecord)
+
+	r, err := sauce.Open(testAns())
+	assert.Nil(t, err)
+	assert.Equal(t, "00", r.Version)
+	assert.Equal(t, "Renderer test", r.Title)
+	assert.Equal(t, "Defacto2", r.Author)
+	assert.Equal(t, "Defacto2 Ç Group", r.Group, "the group should be decoded from cp437")
+	assert.Equal(t, time.Date(1994, 8, 31, 0, 0, 0, 0, time.UTC), r.Date)
+	assert.Equal(t, sauce.Character, r.DataType)
+	assert.Equal(t, sauce.ANSI, r.FileType)
+	assert.Equal(t, "IBM VGA", r.Font)
+	assert.Equal(t, 80, r.Width())
+	assert.Equal(t, 6, r.Lines())
+	assert.False(t, r.ICE())
+	assert.Equal(t, []string{"Rendered by the df2 native ANSI renderer test suite."}, r.Comments)
+}
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+	_, err := sauce.Decode(nil)
+	assert.ErrorIs(t, err, sauce.ErrNoRecord)
+	_, err = sauce.Decode(bytes.Repeat([]byte("x"), 200))
+	assert.ErrorIs(t, err, sauce.ErrNoRecord)
+
+	b, err := os.ReadFile(testAns())
+	assert.Nil(t, err)
+	r, err := sauce.Decode(b)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(sauce.Index(b)), r.FileSize, "the file size should match the data before the sauce")
+	// remove the comment block
+	i := sauce.Index(b) + 1
+	_, err = sauce.Decode(append(b[:i:i], b[len(b)-sauce.Size:]...))
+	assert.ErrorIs(t, err, sauce.ErrComments)
+}
+
+func TestIndex(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, 0, sauce.Index(nil))
+	assert.Equal(t, 4, sauce.Index([]byte("text")))
+	b, err := os.ReadFile(testAns())
+	assert.Nil(t, err)
+	i := sauce.Index(b)
+	assert.Less(t, i, len(b))
+	assert.NotEqual(t, byte(0x1a), b[i-1], "the index should include the end-of-file marker")
+	assert.Equal(t, byte(0x1a), b[i])
+}
+
+func TestRecord_Width(t *testing.T) {
+	t.Parallel()
+	r := sauce.Record{DataType: sauce.BinaryText, FileType: 80}
+	assert.Equal(t, 160, r.Width())
+	r = sauce.Record{DataType: sauce.Audio, TInfo: [4]uint16{80}}
+	assert.Equal(t, 0, r.Width())
+	assert.Equal(t, 0, r.Lines())
+	assert.False(t, r.ICE())
+	assert.Equal(t, "audio", r.DataType.String())
+}
+
+func TestRecord_Fill(t *testing.T) {
+	t.Parallel()
+	r := sauce.Record{
+		Title:    "Title",
+		Author:   "Artist",
+		Group:    "Group",
+		Date:     time.Date(1996, 2, 3, 0, 0, 0, 0, time.UTC),
+		DataType: sauce.Character,
+	}
+	cols := r.Fill(database.File{})
+	assert.Equal(t, map[string]any{
+		"record_title":        "Title",
+		"group_brand_for":     "Group",
+		"credit_illustration": "Artist",
+		"date_issued_year":    1996,
+		"date_issued_month":   2,
+		"date_issued_day":     3,
+	}, cols)
+
+	f := database.File{
+		RecordTitle:        null.StringFrom("Existing"),
+		GroupBrandFor:      null.StringFrom("Existing"),
+		CreditIllustration: null.StringFrom("Existing"),
+		DateIssuedYear:     null.Int16From(1990),
+	}
+	assert.Empty(t, r.Fill(f), "existing values should never be replaced")
+
+	r.DataType = sauce.Audio
+	cols = r.Fill(f)
+	assert.Equal(t, map[string]any{"credit_audio": "Artist"}, cols)
+	assert.Empty(t, sauce.Record{}.Fill(database.File{}))
+}
+
+func TestUpdate(t *testing.T) {
+	t.Parallel()
+	s, err := sauce.Update(nil, 1, testAns())
+	assert.ErrorIs(t, err, database.ErrDB)
+	assert.Empty(t, s)
+}
+
+func TestFix(t *testing.T) {
+	t.Parallel()
+	err := sauce.Fix(nil, nil, conf.Defaults(), true)
+	assert.ErrorIs(t, err, database.ErrDB)
+}
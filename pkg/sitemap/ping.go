@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -302,7 +303,9 @@ func ColorCode(i int) string {
 	}
 }
 
-// GetBlocked returns all the primary keys of the records with blocked file downloads.
+// GetBlocked returns all the primary keys of the records with blocked file downloads,
+// which are the records with a security alert or a security violation.
+// Databases without the security violation column return an error that matches database.ErrMigrate.
 func GetBlocked(db *sql.DB) (IDs, error) {
 	if db == nil {
 		return nil, database.ErrDB
@@ -311,6 +314,15 @@ func GetBlocked(db *sql.DB) (IDs, error) {
 	if err != nil {
 		return nil, fmt.Errorf("%w: blocked downloads", err)
 	}
+	violations, err := database.GetKeys(db, database.WhereViolation)
+	if err != nil {
+		return nil, fmt.Errorf("%w: security violations", err)
+	}
+	for _, id := range violations {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
 	return IDs(ids), nil
 }
 
@@ -10,10 +10,12 @@ import (
 	"fmt"
 	"io"
 	"net/url"
-	"strings"
+	"strconv"
 
 	"github.com/Defacto2/df2/pkg/database"
 	"github.com/Defacto2/df2/pkg/sitemap/internal/urlset"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 )
 
 var ErrPointer = errors.New("pointer value cannot be nil")
@@ -40,46 +42,32 @@ func Create(db *sql.DB, w io.Writer, dir string) error {
 	if w == nil {
 		w = io.Discard
 	}
-	id := ""
 	tmpl := &urlset.Set{XMLNS: Namespace}
-	var createdat, updatedat sql.NullString
 	count, err := nullsDeleteAt(db)
 	if err != nil {
 		return err
 	}
-	rows, err := db.Query("SELECT `id`,`createdat`,`updatedat` FROM `files` WHERE `deletedat` IS NULL")
+	files, err := database.Select(db,
+		qm.Select("id", "createdat", "updatedat"),
+		qm.Where("deletedat IS NULL"))
 	if err != nil {
 		return fmt.Errorf("create db query: %w", err)
 	}
-	if rows.Err() != nil {
-		return fmt.Errorf("create db rows: %w", rows.Err())
-	}
-	defer rows.Close()
 	// handle static urls
 	const paths = 29
 	tmpl.URLs = make([]urlset.Tag, paths+count)
 	c, i := tmpl.StaticURLs(dir)
 	// handle query results.
-	for rows.Next() {
+	for _, f := range files {
 		i++
-		if err = rows.Scan(&id, &createdat, &updatedat); err != nil {
-			return fmt.Errorf("create rows next: %w", err)
-		}
-		// check for valid createdat and updatedat entries.
-		if _, err = updatedat.Value(); err != nil {
-			continue
-		}
-		if _, err = createdat.Value(); err != nil {
-			continue
-		}
 		loc, err := url.JoinPath(Location, "f")
 		if err != nil {
 			return err
 		}
 		tmpl.URLs[i] = urlset.Tag{
 			Location:     loc,
-			LastModified: database.ObfuscateParam(id),
-			ChangeFreq:   lastmodValue(createdat, updatedat),
+			LastModified: database.ObfuscateParam(strconv.FormatInt(f.ID, 10)),
+			ChangeFreq:   lastmodValue(f.Createdat, f.Updatedat),
 			Priority:     "",
 		}
 		c++
@@ -124,27 +112,19 @@ func nullsDeleteAt(db *sql.DB) (int, error) {
 	if db == nil {
 		return 0, database.ErrDB
 	}
-	count := 0
-	if err := db.QueryRow("SELECT COUNT(*) FROM `files` WHERE `deletedat` IS NULL").Scan(&count); err != nil {
-		return 0, err
-	}
-	return count, nil
+	return database.Count(db, qm.Where("deletedat IS NULL"))
 }
 
 // lastmodValue parse createdat and updatedat to use in the <lastmod> tag.
-func lastmodValue(createdat, updatedat sql.NullString) string {
-	lm := ""
-	if ok := updatedat.Valid; ok {
-		lm = updatedat.String
-	} else if ok := createdat.Valid; ok {
-		lm = createdat.String
-	}
+func lastmodValue(createdat, updatedat null.Time) string {
 	// NOTE: most search engines do not bother with the lastmod value so it could be removed to improve size.
 	// blank by default; <lastmod> tag has `omitempty` set, so it won't display if no value is given.
-	s := ""
-	if f := strings.Fields(lm); len(f) > 0 {
-		t := strings.Split(f[0], "T") // example value: 2020-04-06T20:51:36Z
-		s = t[0]
+	const date = "2006-01-02" // example value: 2020-04-06
+	if updatedat.Valid {
+		return updatedat.Time.Format(date)
+	}
+	if createdat.Valid {
+		return createdat.Time.Format(date)
 	}
-	return s
+	return ""
 }
@@ -0,0 +1,90 @@
+package text
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/Defacto2/df2/pkg/charset"
+	"github.com/Defacto2/df2/pkg/database"
+	"github.com/Defacto2/df2/pkg/sauce"
+	"github.com/Defacto2/df2/pkg/text/internal/img"
+	"github.com/bengarrett/retrotxtgo/byter"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// Sidecar is the file extension of the normalized, UTF-8 copy of a text that is saved beside the download.
+const Sidecar = ".utf8.txt"
+
+// escapes matches the ANSI escape sequences that are removed from the normalized text.
+var escapes = regexp.MustCompile(`\x1b\[[0-9;?=>]*[@-~]`)
+
+// Normalize classifies the character encoding of the named text file and saves it to the id file record.
+// A normalized, UTF-8 copy of the text is also saved to the dest path using the Sidecar extension,
+// which has any SAUCE metadata, ANSI escape sequences and control codes removed.
+// When amiga is true, the text is treated as an Amiga text that cannot use CP437.
+//
+// The name of the encoding is returned, or an empty string when the named file is missing,
+// is not a text file or when the record has already been normalized.
+func Normalize(db *sql.DB, id int64, name, dest string, amiga bool) (string, error) {
+	if db == nil {
+		return "", database.ErrDB
+	}
+	files, err := database.Select(db, qm.Select("id", "retrotxt_encoding"), qm.Where("id = ?", id))
+	if err != nil {
+		return "", fmt.Errorf("normalize select %d: %w", id, err)
+	}
+	if len(files) == 0 {
+		return "", nil
+	}
+	sidecar := dest + Sidecar
+	if files[0].RetrotxtEncoding.String != "" {
+		if _, err := os.Stat(sidecar); err == nil {
+			return "", nil
+		}
+	}
+	b, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("normalize read: %w", err)
+	}
+	if err := img.Type(name); err != nil {
+		return "", nil //nolint:nilerr
+	}
+	b = b[:sauce.Index(b)]
+	cs := charset.Classify(b, amiga)
+	if err := os.WriteFile(sidecar, []byte(Plain(b, cs)), 0o644); err != nil { //nolint:gosec
+		return "", fmt.Errorf("normalize write: %w", err)
+	}
+	if _, err := database.UpdateFiles(db, map[string]any{"retrotxt_encoding": cs.String()},
+		qm.Where("id = ?", id)); err != nil {
+		return "", fmt.Errorf("normalize update %d: %w", id, err)
+	}
+	return cs.String(), nil
+}
+
+// Plain returns the b text in the cs character set as UTF-8 plain text.
+// The end-of-file marker and anything that follows it, ANSI escape sequences,
+// carriage returns and all the other control codes except for tabs and newlines are removed.
+func Plain(b []byte, cs charset.Charset) string {
+	b = byter.TrimEOF(bytes.TrimPrefix(b, byter.BOM()))
+	s := escapes.ReplaceAllString(charset.Decode(b, cs), "")
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t', r == '\n':
+			return r
+		case r == '\r':
+			return '\n'
+		case r < ' ', r == 0x7f:
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,46 @@
+package text_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Defacto2/df2/pkg/charset"
+	"github.com/Defacto2/df2/pkg/database"
+	"github.com/Defacto2/df2/pkg/text"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+	s, err := text.Normalize(nil, 1, "", "", false)
+	assert.ErrorIs(t, err, database.ErrDB)
+	assert.Equal(t, "", s)
+}
+
+func TestPlain(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		b    string
+		cs   charset.Charset
+		want string
+	}{
+		{"empty", "", charset.UTF8, ""},
+		{"utf-8", "\xef\xbb\xbfCafé\r\n", charset.UTF8, "Café\n"},
+		{"cp437", "\x1b[1;31mCaf\x82\x1b[0m\r\n\xdb\xdb\r\n", charset.CP437, "Café\n██\n"},
+		{"latin-1", "Caf\xe9\tbar", charset.Latin1, "Café\tbar"},
+		{"windows", "\x93Hi\x94", charset.Windows1252, "“Hi”"},
+		{"amiga", "Caf\xe9\rbar", charset.Amiga, "Café\nbar"},
+		{"eof", "text\x1aSAUCE00", charset.CP437, "text"},
+		{"controls", "a\x00b\x07c\x1b[2Jd", charset.UTF8, "abcd"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, text.Plain([]byte(tt.b), tt.cs), tt.name)
+	}
+	b, err := os.ReadFile(filepath.Join("..", "..", "testdata", "text", "test.ans"))
+	assert.Nil(t, err)
+	s := text.Plain(b, charset.Classify(b, false))
+	assert.NotContains(t, s, "\x1b")
+	assert.NotContains(t, s, "SAUCE")
+}
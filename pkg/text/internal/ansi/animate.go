@@ -0,0 +1,103 @@
+package ansi
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+)
+
+const (
+	Baud = 14400 // Baud is the default modem speed in bits per second used to playback an animation.
+
+	bits      = 10   // bits are the number of bits used to send a byte, using 8 data bits, 1 start and 1 stop bit.
+	fps       = 10   // fps is the number of animation frames drawn each second.
+	maxFrames = 1500 // maxFrames is the maximum number of frames, longer animations are played back faster.
+	maxCanvas = 2000 // maxCanvas is the maximum number of rows drawn to the screen of an animation.
+	vgaHeight = 400  // vgaHeight is the pixel height of the MS-DOS, VGA text mode screen.
+)
+
+// APNG renders the text read from r as an animated PNG that plays back the text
+// as it would appear on a terminal that is receiving it at the baud rate of a modem.
+// A baud rate of 0 uses the default Baud.
+//
+// Many ANSI art animations rely on the slow playback of the text with cursor movements
+// to redraw the same area of the screen. The animation is drawn using a viewport
+// of the Rows option, or the number of rows that fit the VGA text mode screen,
+// and the viewport scrolls to follow the cursor.
+func APNG(w io.Writer, r io.Reader, o Options, baud int) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("ansi apng: %w", err)
+	}
+	if i := bytes.IndexByte(b, sub); i >= 0 {
+		b = b[:i]
+	}
+	if baud < 1 {
+		baud = Baud
+	}
+	cps := max(baud/bits, 1)
+	per := max(cps/fps, 1)
+	if n := (len(b) + per - 1) / per; n > maxFrames {
+		per = (len(b) + maxFrames - 1) / maxFrames
+	}
+	const ms = 1000
+	delay := per * ms / cps
+	s := New(o)
+	lines := s.opts.Rows
+	if lines < 1 {
+		lines = max(vgaHeight/s.opts.Font.Height, 1)
+	}
+	s.opts.Rows = maxCanvas
+	a, top := apng{}, 0
+	var prev *image.Paletted
+	for i := 0; i == 0 || i < len(b); i += per {
+		_, _ = s.Write(b[i:min(i+per, len(b))])
+		// scroll the viewport to keep the cursor visible
+		if s.y >= top+lines {
+			top = s.y - lines + 1
+		}
+		top = min(top, s.y)
+		img := s.View(top, lines)
+		if prev == nil {
+			if err := a.add(img, img.Bounds(), delay); err != nil {
+				return err
+			}
+			prev = img
+			continue
+		}
+		r := changed(prev, img)
+		if r.Empty() {
+			a.frames[len(a.frames)-1].delay += delay
+			continue
+		}
+		if err := a.add(img, r, delay); err != nil {
+			return err
+		}
+		prev = img
+	}
+	return a.encode(w)
+}
+
+// changed returns the smallest area that contains all the different pixels of the
+// two images that must share the same bounds, or an empty rectangle when they match.
+func changed(a, b *image.Paletted) image.Rectangle {
+	bounds := a.Bounds()
+	r := image.Rectangle{}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		i := a.PixOffset(bounds.Min.X, y)
+		ra, rb := a.Pix[i:i+bounds.Dx()], b.Pix[i:i+bounds.Dx()]
+		if bytes.Equal(ra, rb) {
+			continue
+		}
+		x0, x1 := 0, len(ra)
+		for x0 < x1 && ra[x0] == rb[x0] {
+			x0++
+		}
+		for x1 > x0 && ra[x1-1] == rb[x1-1] {
+			x1--
+		}
+		r = r.Union(image.Rect(bounds.Min.X+x0, y, bounds.Min.X+x1, y+1))
+	}
+	return r
+}
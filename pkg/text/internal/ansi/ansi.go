@@ -0,0 +1,83 @@
+// Package ansi renders CP437 text and ANSI art into images without any external dependencies.
+//
+// The text is drawn using the bundled IBM VGA and Amiga Topaz bitmap fonts
+// and supports the common ANSI escape sequences used by the BBS art scene,
+// including SGR colors, cursor movement and iCE colors.
+package ansi
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+const (
+	Columns = 80 // Columns is the default character width of a screen.
+
+	sub = 0x1a // sub is the MS-DOS end-of-file marker that is followed by any SAUCE metadata.
+)
+
+// Palette of the 16 colors used by the IBM VGA text mode,
+// the order matches the color bits of a VGA attribute byte.
+var Palette = color.Palette{
+	color.RGBA{0x00, 0x00, 0x00, 0xff}, // black
+	color.RGBA{0x00, 0x00, 0xaa, 0xff}, // blue
+	color.RGBA{0x00, 0xaa, 0x00, 0xff}, // green
+	color.RGBA{0x00, 0xaa, 0xaa, 0xff}, // cyan
+	color.RGBA{0xaa, 0x00, 0x00, 0xff}, // red
+	color.RGBA{0xaa, 0x00, 0xaa, 0xff}, // magenta
+	color.RGBA{0xaa, 0x55, 0x00, 0xff}, // brown
+	color.RGBA{0xaa, 0xaa, 0xaa, 0xff}, // light gray
+	color.RGBA{0x55, 0x55, 0x55, 0xff}, // dark gray
+	color.RGBA{0x55, 0x55, 0xff, 0xff}, // light blue
+	color.RGBA{0x55, 0xff, 0x55, 0xff}, // light green
+	color.RGBA{0x55, 0xff, 0xff, 0xff}, // light cyan
+	color.RGBA{0xff, 0x55, 0x55, 0xff}, // light red
+	color.RGBA{0xff, 0x55, 0xff, 0xff}, // light magenta
+	color.RGBA{0xff, 0xff, 0x55, 0xff}, // yellow
+	color.RGBA{0xff, 0xff, 0xff, 0xff}, // white
+}
+
+// Options to render the text.
+type Options struct {
+	Font    Font // Font used to draw the text, the default is the VGA 80x25 font.
+	Columns int  // Columns is the character width of the screen, the default is 80.
+	Rows    int  // Rows is the maximum number of rows to draw, the default of 0 is unlimited.
+	Scale   int  // Scale multiplies the pixel size of the image, the default is 1.
+	ICE     bool // ICE colors use the blink attribute to draw high intensity backgrounds.
+}
+
+// Image renders the text read from r into a paletted image.
+func Image(r io.Reader, o Options) (*image.Paletted, error) {
+	s := New(o)
+	if _, err := io.Copy(s, r); err != nil {
+		return nil, fmt.Errorf("ansi image: %w", err)
+	}
+	return s.Image(), nil
+}
+
+// PNG renders the text read from r and writes it to w as a PNG image.
+// The same text and options always encode to the same PNG bytes.
+func PNG(w io.Writer, r io.Reader, o Options) error {
+	img, err := Image(r, o)
+	if err != nil {
+		return err
+	}
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := enc.Encode(w, img); err != nil {
+		return fmt.Errorf("ansi png: %w", err)
+	}
+	return nil
+}
+
+// Bytes renders the text b and returns it as a PNG image.
+func Bytes(b []byte, o Options) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := PNG(buf, bytes.NewReader(b), o); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,328 @@
+package ansi_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Defacto2/df2/pkg/text/internal/ansi"
+	"github.com/stretchr/testify/assert"
+)
+
+func testDir() string { return filepath.Join("..", "..", "..", "..", "testdata", "text") }
+
+func TestBytes(t *testing.T) {
+	t.Parallel()
+	src, err := os.ReadFile(filepath.Join(testDir(), "test.ans"))
+	assert.Nil(t, err)
+	tests := []struct {
+		name   string
+		golden string
+		opts   ansi.Options
+	}{
+		{"80x25", "test-80x25.png", ansi.Options{}},
+		{"80x50 ice", "test-80x50.png", ansi.Options{Font: ansi.VGA50, ICE: true}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			want, err := os.ReadFile(filepath.Join(testDir(), tt.golden))
+			assert.Nil(t, err)
+			got, err := ansi.Bytes(src, tt.opts)
+			assert.Nil(t, err)
+			assert.True(t, bytes.Equal(want, got), "the rendered png should match the golden file byte for byte")
+		})
+	}
+}
+
+func TestImage(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		src    string
+		opts   ansi.Options
+		wx, wy int
+	}{
+		{"empty", "", ansi.Options{}, 640, 16},
+		{"80x25", "hello", ansi.Options{}, 640, 16},
+		{"80x50", "hello", ansi.Options{Font: ansi.VGA50}, 640, 8},
+		{"topaz", "hello", ansi.Options{Font: ansi.Topaz}, 640, 16},
+		{"scale", "hello", ansi.Options{Scale: 2}, 1280, 32},
+		{"columns", "hello", ansi.Options{Columns: 40}, 320, 16},
+		{"lines", "1\r\n2\n3\r\n", ansi.Options{}, 640, 48},
+		{"wrap", strings.Repeat("x", 81), ansi.Options{}, 640, 32},
+		{"no wrap", strings.Repeat("x", 80) + "\r\nx", ansi.Options{}, 640, 32},
+		{"max rows", "1\n2\n3\n4", ansi.Options{Rows: 2}, 640, 32},
+		{"cursor", "\x1b[3;10Hx", ansi.Options{}, 640, 48},
+		{"cursor down", "\x1b[2Bx\x1b[Ax", ansi.Options{}, 640, 48},
+		{"clear", "1\n2\n3\x1b[2Jx", ansi.Options{}, 640, 16},
+		{"eof", "1\n2\x1a\n3\n4", ansi.Options{}, 640, 32},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			img, err := ansi.Image(strings.NewReader(tt.src), tt.opts)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wx, img.Bounds().Dx())
+			assert.Equal(t, tt.wy, img.Bounds().Dy())
+		})
+	}
+}
+
+func TestScreen_Colors(t *testing.T) {
+	t.Parallel()
+	const (
+		black     = 0
+		blue      = 1
+		lightGray = 7
+		lightBlue = 9
+		lightRed  = 12
+	)
+	// the full block character, 0xdb fills every pixel with the foreground color
+	tests := []struct {
+		name string
+		src  string
+		opts ansi.Options
+		want uint8
+	}{
+		{"default", "\xdb", ansi.Options{}, lightGray},
+		{"bold red", "\x1b[1;31m\xdb", ansi.Options{}, lightRed},
+		{"aixterm", "\x1b[91m\xdb", ansi.Options{}, lightRed},
+		{"reset", "\x1b[1;31m\x1b[m\xdb", ansi.Options{}, lightGray},
+		{"background", "\x1b[44m ", ansi.Options{}, blue},
+		{"blink", "\x1b[5;44m ", ansi.Options{}, blue},
+		{"ice", "\x1b[5;44m ", ansi.Options{ICE: true}, lightBlue},
+		{"ice mode", "\x1b[?33h\x1b[5;44m ", ansi.Options{}, lightBlue},
+		{"reverse", "\x1b[7m ", ansi.Options{}, lightGray},
+		{"extended", "\x1b[38;5;200;1;31m\xdb", ansi.Options{}, lightRed},
+		{"blank", " ", ansi.Options{}, black},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s := ansi.New(tt.opts)
+			_, err := s.Write([]byte(tt.src))
+			assert.Nil(t, err)
+			assert.Equal(t, 1, s.Rows())
+			assert.Equal(t, tt.want, s.Image().ColorIndexAt(4, 8))
+		})
+	}
+}
+
+func TestFont_Glyph(t *testing.T) {
+	t.Parallel()
+	assert.Len(t, ansi.VGA.Glyph('A'), 16)
+	assert.Len(t, ansi.VGA50.Glyph('A'), 8)
+	assert.Len(t, ansi.Topaz.Glyph('A'), 16)
+	assert.Equal(t, bytes.Repeat([]byte{0xff}, 16), ansi.VGA.Glyph(0xdb))
+	assert.Equal(t, make([]byte, 8), ansi.VGA50.Glyph(' '))
+	assert.Len(t, ansi.Font{}.Glyph('A'), 16, "an empty font should fallback to VGA")
+}
+
+func TestLookup(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		want string
+		ok   bool
+	}{
+		{"IBM VGA", ansi.VGA.Name, true},
+		{"IBM VGA 437", ansi.VGA.Name, true},
+		{"IBM VGA50 437", ansi.VGA50.Name, true},
+		{"IBM EGA", ansi.VGA.Name, true},
+		{"IBM EGA43", ansi.VGA50.Name, true},
+		{"Amiga Topaz 1+", ansi.Topaz.Name, true},
+		{"Amiga MicroKnight", ansi.Topaz.Name, true},
+		{"C64 PETSCII unshifted", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		f, ok := ansi.Lookup(tt.name)
+		assert.Equal(t, tt.ok, ok, tt.name)
+		assert.Equal(t, tt.want, f.Name, tt.name)
+	}
+}
+
+func TestAPNG(t *testing.T) {
+	t.Parallel()
+	src, err := os.ReadFile(filepath.Join(testDir(), "test.ans"))
+	assert.Nil(t, err)
+	tests := []struct {
+		name string
+		src  []byte
+		opts ansi.Options
+		baud int
+	}{
+		{"empty", nil, ansi.Options{}, 0},
+		{"default baud", src, ansi.Options{}, 0},
+		{"2400 baud", src, ansi.Options{}, 2400},
+		{"300 baud vga50", src, ansi.Options{Font: ansi.VGA50, ICE: true}, 300},
+		{"cursor", []byte("\x1b[2J1\x1b[H2\x1b[H3\x1b[H4"), ansi.Options{Rows: 2}, 10},
+		{"scroll", []byte(strings.Repeat("line\r\n", 30)), ansi.Options{}, 300},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			buf := &bytes.Buffer{}
+			err := ansi.APNG(buf, bytes.NewReader(tt.src), tt.opts, tt.baud)
+			assert.Nil(t, err)
+			frames, delay, err := decodeAPNG(buf.Bytes())
+			assert.Nil(t, err)
+			assert.NotZero(t, frames)
+			assert.NotZero(t, delay)
+			// a decoder without apng support only shows the first frame
+			first, err := png.Decode(bytes.NewReader(buf.Bytes()))
+			assert.Nil(t, err)
+			assert.Equal(t, frames[len(frames)-1].Bounds(), first.Bounds())
+		})
+	}
+}
+
+func TestAPNG_Playback(t *testing.T) {
+	t.Parallel()
+	src, err := os.ReadFile(filepath.Join(testDir(), "test.ans"))
+	assert.Nil(t, err)
+	buf := &bytes.Buffer{}
+	err = ansi.APNG(buf, bytes.NewReader(src), ansi.Options{}, 2400)
+	assert.Nil(t, err)
+	frames, delay, err := decodeAPNG(buf.Bytes())
+	assert.Nil(t, err)
+	assert.Greater(t, len(frames), 2)
+	// at 2400 baud, 240 bytes are sent each second
+	const cps = 240
+	assert.InDelta(t, len(src)*1000/cps, delay, float64(len(frames)*100), "the playback time should match the baud rate")
+	// the last frame should match the static render of the text
+	want, err := ansi.Image(bytes.NewReader(src), ansi.Options{})
+	assert.Nil(t, err)
+	last := frames[len(frames)-1]
+	for y := 0; y < want.Bounds().Dy(); y++ {
+		for x := 0; x < want.Bounds().Dx(); x++ {
+			if want.ColorIndexAt(x, y) != last.ColorIndexAt(x, y) {
+				t.Fatalf("the last frame does not match the static image at %d,%d", x, y)
+			}
+		}
+	}
+	// the rows below the text are never drawn
+	assert.Equal(t, uint8(0), last.ColorIndexAt(0, last.Bounds().Dy()-1))
+}
+
+// decodeAPNG verifies the chunks of the animated PNG and returns the full canvas
+// of every frame and the total playback time in milliseconds.
+func decodeAPNG(b []byte) ([]*image.Paletted, int, error) {
+	const sig = "\x89PNG\r\n\x1a\n"
+	if !bytes.HasPrefix(b, []byte(sig)) {
+		return nil, 0, errAPNG
+	}
+	b = b[len(sig):]
+	var (
+		ihdr, plte []byte
+		frames     []*image.Paletted
+		canvas     *image.Paletted
+		fctl       []byte
+		data       []byte
+		seq        uint32
+		playback   int
+		numFrames  uint32
+	)
+	flush := func() error {
+		if fctl == nil {
+			return nil
+		}
+		w, h := binary.BigEndian.Uint32(fctl[4:]), binary.BigEndian.Uint32(fctl[8:])
+		x, y := binary.BigEndian.Uint32(fctl[12:]), binary.BigEndian.Uint32(fctl[16:])
+		num, den := binary.BigEndian.Uint16(fctl[20:]), binary.BigEndian.Uint16(fctl[22:])
+		playback += int(num) * 1000 / int(den)
+		hdr := append([]byte{}, ihdr...)
+		binary.BigEndian.PutUint32(hdr, w)
+		binary.BigEndian.PutUint32(hdr[4:], h)
+		p := &bytes.Buffer{}
+		p.WriteString(sig)
+		writeChunk(p, "IHDR", hdr)
+		writeChunk(p, "PLTE", plte)
+		writeChunk(p, "IDAT", data)
+		writeChunk(p, "IEND", nil)
+		img, err := png.Decode(p)
+		if err != nil {
+			return err
+		}
+		pi, ok := img.(*image.Paletted)
+		if !ok {
+			return errAPNG
+		}
+		if canvas == nil {
+			canvas = pi
+		}
+		next := image.NewPaletted(canvas.Bounds(), canvas.Palette)
+		copy(next.Pix, canvas.Pix)
+		draw.Draw(next, image.Rect(int(x), int(y), int(x+w), int(y+h)), pi, image.Point{}, draw.Src)
+		canvas = next
+		frames = append(frames, next)
+		fctl, data = nil, nil
+		return nil
+	}
+	for len(b) >= 12 {
+		n := int(binary.BigEndian.Uint32(b))
+		if len(b) < 12+n {
+			return nil, 0, errAPNG
+		}
+		name, body := string(b[4:8]), b[8:8+n]
+		if crc32.ChecksumIEEE(b[4:8+n]) != binary.BigEndian.Uint32(b[8+n:]) {
+			return nil, 0, errAPNG
+		}
+		b = b[12+n:]
+		switch name {
+		case "IHDR":
+			ihdr = body
+		case "PLTE":
+			plte = body
+		case "acTL":
+			numFrames = binary.BigEndian.Uint32(body)
+		case "fcTL", "fdAT":
+			if binary.BigEndian.Uint32(body) != seq {
+				return nil, 0, errAPNG
+			}
+			seq++
+			if name == "fdAT" {
+				data = append(data, body[4:]...)
+				continue
+			}
+			if err := flush(); err != nil {
+				return nil, 0, err
+			}
+			fctl = body
+		case "IDAT":
+			data = append(data, body...)
+		case "IEND":
+			if err := flush(); err != nil {
+				return nil, 0, err
+			}
+		}
+	}
+	if int(numFrames) != len(frames) {
+		return nil, 0, errAPNG
+	}
+	return frames, playback, nil
+}
+
+var errAPNG = errors.New("invalid apng")
+
+func writeChunk(w io.Writer, name string, data []byte) {
+	b := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	b = append(b, name...)
+	b = append(b, data...)
+	b = binary.BigEndian.AppendUint32(b, crc32.ChecksumIEEE(b[4:]))
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,146 @@
+package ansi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+)
+
+var ErrChunk = errors.New("png chunk is malformed")
+
+const pngSig = "\x89PNG\r\n\x1a\n" // pngSig is the signature that begins every PNG image.
+
+// frame is an encoded animation frame that replaces the area of the canvas.
+type frame struct {
+	rect  image.Rectangle // rect is the area of the canvas that is replaced by the frame.
+	delay int             // delay is the display time of the frame in milliseconds.
+	idat  [][]byte        // idat are the compressed image data chunks of the frame.
+}
+
+// apng builds an animated PNG, the Animated Portable Network Graphics format
+// that is supported by all the common web browsers.
+// The specification is at https://wiki.mozilla.org/APNG_Specification.
+type apng struct {
+	ihdr   []byte  // ihdr is the image header of the first, full canvas frame.
+	plte   []byte  // plte is the shared palette of every frame.
+	frames []frame // frames are the encoded frames in playback order.
+}
+
+// add encodes the area r of the img as a new frame.
+func (a *apng) add(img *image.Paletted, r image.Rectangle, delay int) error {
+	buf := &bytes.Buffer{}
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	sub, ok := img.SubImage(r).(*image.Paletted)
+	if !ok {
+		return fmt.Errorf("apng frame %v: %w", r, ErrChunk)
+	}
+	if err := enc.Encode(buf, sub); err != nil {
+		return fmt.Errorf("apng frame: %w", err)
+	}
+	f := frame{rect: r, delay: delay}
+	b := buf.Bytes()[len(pngSig):]
+	for len(b) > 0 {
+		const head, crc = 8, 4
+		if len(b) < head+crc {
+			return ErrChunk
+		}
+		n := int(binary.BigEndian.Uint32(b))
+		if len(b) < head+n+crc {
+			return ErrChunk
+		}
+		name, data := string(b[4:8]), b[head:head+n]
+		switch name {
+		case "IHDR":
+			if a.ihdr == nil {
+				a.ihdr = data
+			}
+		case "PLTE":
+			if a.plte == nil {
+				a.plte = data
+			}
+		case "IDAT":
+			f.idat = append(f.idat, data)
+		}
+		b = b[head+n+crc:]
+	}
+	a.frames = append(a.frames, f)
+	return nil
+}
+
+// encode writes the animation to w, the animation plays once and then holds the last frame.
+func (a *apng) encode(w io.Writer) error {
+	if len(a.frames) == 0 || a.ihdr == nil {
+		return fmt.Errorf("apng encode: %w", ErrChunk)
+	}
+	if _, err := io.WriteString(w, pngSig); err != nil {
+		return fmt.Errorf("apng encode: %w", err)
+	}
+	const once = 1
+	actl := binary.BigEndian.AppendUint32(nil, uint32(len(a.frames)))
+	actl = binary.BigEndian.AppendUint32(actl, once)
+	chunks := [][2][]byte{
+		{[]byte("IHDR"), a.ihdr},
+		{[]byte("acTL"), actl},
+	}
+	if a.plte != nil {
+		chunks = append(chunks, [2][]byte{[]byte("PLTE"), a.plte})
+	}
+	seq := uint32(0)
+	for i, f := range a.frames {
+		chunks = append(chunks, [2][]byte{[]byte("fcTL"), f.control(seq)})
+		seq++
+		for _, data := range f.idat {
+			if i == 0 {
+				// the first frame is also the default image used by decoders without APNG support
+				chunks = append(chunks, [2][]byte{[]byte("IDAT"), data})
+				continue
+			}
+			fdat := binary.BigEndian.AppendUint32(nil, seq)
+			chunks = append(chunks, [2][]byte{[]byte("fdAT"), append(fdat, data...)})
+			seq++
+		}
+	}
+	chunks = append(chunks, [2][]byte{[]byte("IEND"), nil})
+	for _, c := range chunks {
+		if err := chunk(w, c[0], c[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// control returns the data of the fcTL frame control chunk.
+func (f frame) control(seq uint32) []byte {
+	const (
+		ms          = 1000
+		disposeNone = 0
+		blendSource = 0
+	)
+	b := binary.BigEndian.AppendUint32(nil, seq)
+	b = binary.BigEndian.AppendUint32(b, uint32(f.rect.Dx()))
+	b = binary.BigEndian.AppendUint32(b, uint32(f.rect.Dy()))
+	b = binary.BigEndian.AppendUint32(b, uint32(f.rect.Min.X))
+	b = binary.BigEndian.AppendUint32(b, uint32(f.rect.Min.Y))
+	b = binary.BigEndian.AppendUint16(b, uint16(clamp(f.delay, 0xffff)))
+	b = binary.BigEndian.AppendUint16(b, ms)
+	return append(b, disposeNone, blendSource)
+}
+
+// chunk writes a PNG chunk of the named type and data to w.
+func chunk(w io.Writer, name, data []byte) error {
+	b := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	b = append(b, name...)
+	b = append(b, data...)
+	crc := crc32.NewIEEE()
+	crc.Write(b[4:])
+	b = binary.BigEndian.AppendUint32(b, crc.Sum32())
+	if _, err := w.Write(b); err != nil {
+		return fmt.Errorf("apng chunk %s: %w", name, err)
+	}
+	return nil
+}
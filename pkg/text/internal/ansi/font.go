@@ -0,0 +1,69 @@
+package ansi
+
+import (
+	_ "embed"
+	"strings"
+)
+
+var (
+	//go:embed font/ibm-vga-8x16.f16
+	vga16 []byte
+	//go:embed font/ibm-vga-8x8.f08
+	vga8 []byte
+	//go:embed font/topaz-8x8.f08
+	topaz8 []byte
+)
+
+// Font is a bitmap font of 256, 8 pixel wide glyphs.
+// Each glyph is stored as Height bytes with one byte for each row of pixels,
+// and the most significant bit is the leftmost pixel.
+type Font struct {
+	Name   string // Name of the font.
+	Width  int    // Width of a glyph in pixels.
+	Height int    // Height of a glyph in pixels.
+	glyphs []byte
+}
+
+var (
+	// VGA is the IBM VGA 8x16 font used by the MS-DOS 80x25 text mode, the glyphs use the CP437 character set.
+	VGA = Font{Name: "IBM VGA", Width: 8, Height: 16, glyphs: vga16}
+	// VGA50 is the IBM VGA 8x8 font used by the MS-DOS 80x50 text mode, the glyphs use the CP437 character set.
+	VGA50 = Font{Name: "IBM VGA50", Width: 8, Height: 8, glyphs: vga8}
+	// Topaz is the Amiga Topaz 8 font using the ISO-8859-1 character set,
+	// the rows are doubled to match the aspect ratio of the Amiga high resolution, non-interlaced screen.
+	Topaz = Font{Name: "Amiga Topaz 1+", Width: 8, Height: 16, glyphs: double(topaz8)}
+)
+
+// Lookup returns the bundled font that best matches the SAUCE font name,
+// such as "IBM VGA", "IBM VGA50 437" or "Amiga Topaz 1+".
+// All the Amiga fonts use Topaz, while the IBM fonts use the VGA font of the nearest height.
+func Lookup(name string) (Font, bool) {
+	n := strings.ToLower(strings.TrimSpace(name))
+	switch {
+	case strings.HasPrefix(n, "amiga"):
+		return Topaz, true
+	case strings.HasPrefix(n, "ibm vga50"), strings.HasPrefix(n, "ibm ega43"):
+		return VGA50, true
+	case strings.HasPrefix(n, "ibm vga"), strings.HasPrefix(n, "ibm ega"):
+		return VGA, true
+	}
+	return Font{}, false
+}
+
+// Glyph returns the rows of pixels used to draw the character c.
+func (f Font) Glyph(c byte) []byte {
+	if len(f.glyphs) == 0 {
+		return VGA.Glyph(c)
+	}
+	i := int(c) * f.Height
+	return f.glyphs[i : i+f.Height]
+}
+
+// double the height of the glyphs by repeating each row of pixels.
+func double(b []byte) []byte {
+	d := make([]byte, 0, len(b)*2)
+	for _, row := range b {
+		d = append(d, row, row)
+	}
+	return d
+}
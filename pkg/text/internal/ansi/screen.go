@@ -0,0 +1,385 @@
+package ansi
+
+import (
+	"image"
+	"strconv"
+	"strings"
+)
+
+const (
+	esc      = 0x1b // esc is the escape control character that begins an ANSI escape sequence.
+	tab      = 8    // tab is the width of a horizontal tab stop.
+	maxSeq   = 64   // maxSeq is the maximum length of a control sequence before it is discarded.
+	ice      = 33   // ice is the private mode to toggle iCE colors, ESC[?33h.
+	normal   = 0x07 // normal is the VGA attribute for light gray text on a black background.
+	blinkBit = 0x80 // blinkBit is the VGA attribute bit for blinking text or iCE colors.
+)
+
+// vga maps the ANSI color numbers to the VGA attribute colors.
+var vga = [8]byte{0, 4, 2, 6, 1, 5, 3, 7}
+
+type state int
+
+const (
+	ground state = iota // ground state draws the characters.
+	escape              // escape state follows the escape character.
+	csi                 // csi state collects the parameters of a control sequence.
+)
+
+// cell is a character on the screen and its VGA attribute.
+// Bits 0-3 of the attribute are the foreground color, bits 4-6 are the background color
+// and bit 7 is either blinking text or an iCE colors high intensity background.
+type cell struct {
+	char byte
+	attr byte
+}
+
+// Screen is a virtual text mode terminal that interprets the written bytes
+// as characters and ANSI escape sequences.
+// The screen has a fixed number of columns and grows downward as rows are written.
+type Screen struct {
+	opts    Options
+	cells   [][]cell
+	x, y    int // x and y are the cursor column and row.
+	sx, sy  int // sx and sy are the saved cursor column and row.
+	fg, bg  byte
+	bold    bool
+	blink   bool
+	reverse bool
+	wrap    bool // wrap is a pending line wrap after writing to the last column.
+	eof     bool
+	st      state
+	seq     []byte
+}
+
+// New returns an empty screen using the options.
+func New(o Options) *Screen {
+	if o.Columns < 1 {
+		o.Columns = Columns
+	}
+	if o.Scale < 1 {
+		o.Scale = 1
+	}
+	if o.Font.Height == 0 {
+		o.Font = VGA
+	}
+	s := &Screen{opts: o}
+	s.sgr(0, nil)
+	return s
+}
+
+// Rows returns the number of rows used by the screen.
+func (s *Screen) Rows() int {
+	return len(s.cells)
+}
+
+// Write interprets p as characters and ANSI escape sequences and draws them to the screen.
+// Everything after an end-of-file, SUB control character is ignored.
+func (s *Screen) Write(p []byte) (int, error) {
+	for _, c := range p {
+		if s.eof {
+			break
+		}
+		switch s.st {
+		case escape:
+			s.st = ground
+			if c == '[' {
+				s.st = csi
+				s.seq = s.seq[:0]
+			}
+			continue
+		case csi:
+			if c >= 0x40 && c <= 0x7e {
+				s.st = ground
+				s.control(c)
+				continue
+			}
+			if s.seq = append(s.seq, c); len(s.seq) > maxSeq {
+				s.st = ground
+			}
+			continue
+		case ground:
+		}
+		s.char(c)
+	}
+	return len(p), nil
+}
+
+func (s *Screen) char(c byte) {
+	switch c {
+	case '\r':
+		s.x, s.wrap = 0, false
+	case '\n':
+		s.x, s.wrap = 0, false
+		s.y++
+	case '\t':
+		s.wrap = false
+		s.x = min((s.x/tab+1)*tab, s.opts.Columns-1)
+	case esc:
+		s.st = escape
+	case sub:
+		s.eof = true
+	default:
+		s.put(c)
+	}
+}
+
+// put draws the character at the cursor and moves the cursor to the next column.
+func (s *Screen) put(c byte) {
+	if s.wrap {
+		s.x, s.wrap = 0, false
+		s.y++
+	}
+	if row := s.row(s.y); row != nil {
+		row[s.x] = cell{char: c, attr: s.attr()}
+	}
+	if s.x+1 < s.opts.Columns {
+		s.x++
+		return
+	}
+	s.wrap = true
+}
+
+// row returns the cells of row y, growing the screen when needed.
+// A nil value is returned when y is beyond the maximum number of rows.
+func (s *Screen) row(y int) []cell {
+	if s.opts.Rows > 0 && y >= s.opts.Rows {
+		return nil
+	}
+	for len(s.cells) <= y {
+		row := make([]cell, s.opts.Columns)
+		for i := range row {
+			row[i] = cell{char: ' ', attr: normal}
+		}
+		s.cells = append(s.cells, row)
+	}
+	return s.cells[y]
+}
+
+// attr returns the VGA attribute of the current graphic rendition.
+func (s *Screen) attr() byte {
+	fg, bg := s.fg, s.bg
+	if s.bold {
+		fg |= 0x08
+	}
+	if s.reverse {
+		fg, bg = bg, fg&0x07
+	}
+	a := fg&0x0f | (bg&0x07)<<4
+	if s.blink || bg&0x08 != 0 {
+		a |= blinkBit
+	}
+	return a
+}
+
+// control runs the control sequence using the final byte.
+func (s *Screen) control(final byte) {
+	raw := string(s.seq)
+	private := strings.HasPrefix(raw, "?")
+	params := parse(strings.TrimPrefix(raw, "?"))
+	n := param(params, 0, 1)
+	cols := s.opts.Columns
+	if final != 'm' {
+		s.wrap = false
+	}
+	switch final {
+	case 'A':
+		s.y = max(s.y-n, 0)
+	case 'B':
+		s.y += n
+	case 'C':
+		s.x = min(s.x+n, cols-1)
+	case 'D':
+		s.x = max(s.x-n, 0)
+	case 'E':
+		s.x, s.y = 0, s.y+n
+	case 'F':
+		s.x, s.y = 0, max(s.y-n, 0)
+	case 'G':
+		s.x = clamp(n-1, cols-1)
+	case 'H', 'f':
+		s.y = max(param(params, 0, 1)-1, 0)
+		s.x = clamp(param(params, 1, 1)-1, cols-1)
+	case 'J':
+		s.eraseDisplay(param(params, 0, 0))
+	case 'K':
+		s.eraseLine(param(params, 0, 0))
+	case 'm':
+		if len(params) == 0 {
+			params = []int{0}
+		}
+		for i := 0; i < len(params); i++ {
+			i += s.sgr(params[i], params[i+1:])
+		}
+	case 's':
+		s.sx, s.sy = s.x, s.y
+	case 'u':
+		s.x, s.y = s.sx, s.sy
+	case 'h', 'l':
+		if private && param(params, 0, 0) == ice {
+			s.opts.ICE = final == 'h'
+		}
+	}
+}
+
+// sgr sets the select graphic rendition parameter p.
+// The extended 256 and 24-bit colors are not supported,
+// so the returned value is the number of the following, next parameters to skip.
+func (s *Screen) sgr(p int, next []int) int {
+	const fgExt, bgExt, rgb, indexed = 38, 48, 2, 5
+	switch {
+	case p <= 0:
+		s.fg, s.bg = normal, 0
+		s.bold, s.blink, s.reverse = false, false, false
+	case p == 1:
+		s.bold = true
+	case p == 5, p == 6:
+		s.blink = true
+	case p == 7:
+		s.reverse = true
+	case p == 22:
+		s.bold = false
+	case p == 25:
+		s.blink = false
+	case p == 27:
+		s.reverse = false
+	case p >= 30 && p <= 37:
+		s.fg = vga[p-30]
+	case p == 39:
+		s.fg = normal
+	case p >= 40 && p <= 47:
+		s.bg = vga[p-40]
+	case p == 49:
+		s.bg = 0
+	case p >= 90 && p <= 97:
+		s.fg = vga[p-90] | 0x08
+	case p >= 100 && p <= 107:
+		s.bg = vga[p-100] | 0x08
+	case p == fgExt, p == bgExt:
+		switch param(next, 0, 0) {
+		case indexed:
+			return min(2, len(next))
+		case rgb:
+			return min(4, len(next))
+		}
+	}
+	return 0
+}
+
+func (s *Screen) eraseDisplay(n int) {
+	switch n {
+	case 0:
+		s.eraseLine(0)
+		if s.y+1 < len(s.cells) {
+			s.cells = s.cells[:s.y+1]
+		}
+	case 1:
+		for y := 0; y < s.y && y < len(s.cells); y++ {
+			s.erase(y, 0, s.opts.Columns)
+		}
+		s.eraseLine(1)
+	case 2:
+		s.cells = nil
+		s.x, s.y = 0, 0
+	}
+}
+
+func (s *Screen) eraseLine(n int) {
+	switch n {
+	case 0:
+		s.erase(s.y, s.x, s.opts.Columns)
+	case 1:
+		s.erase(s.y, 0, s.x+1)
+	case 2:
+		s.erase(s.y, 0, s.opts.Columns)
+	}
+}
+
+// erase the columns from x0 up to x1 of row y that has already been drawn.
+func (s *Screen) erase(y, x0, x1 int) {
+	if y >= len(s.cells) {
+		return
+	}
+	a := s.attr()
+	row := s.cells[y]
+	for x := x0; x < x1 && x < len(row); x++ {
+		row[x] = cell{char: ' ', attr: a}
+	}
+}
+
+// Image draws the screen into a paletted image that uses the VGA palette.
+func (s *Screen) Image() *image.Paletted {
+	return s.View(0, max(len(s.cells), 1))
+}
+
+// View draws the rows of the screen starting at row top into a paletted image
+// that is always the height of the number of rows.
+// Any rows that have not been written are drawn as black.
+func (s *Screen) View(top, rows int) *image.Paletted {
+	f, scale := s.opts.Font, s.opts.Scale
+	cw, ch := f.Width*scale, f.Height*scale
+	img := image.NewPaletted(image.Rect(0, 0, s.opts.Columns*cw, rows*ch), Palette)
+	for y := top; y < top+rows && y < len(s.cells); y++ {
+		for x, c := range s.cells[y] {
+			s.draw(img, x*cw, (y-top)*ch, c)
+		}
+	}
+	return img
+}
+
+// draw the cell c with the top-left pixel at px, py.
+func (s *Screen) draw(img *image.Paletted, px, py int, c cell) {
+	f, scale := s.opts.Font, s.opts.Scale
+	fg := c.attr & 0x0f
+	bg := c.attr >> 4 & 0x07
+	if s.opts.ICE && c.attr&blinkBit != 0 {
+		bg |= 0x08
+	}
+	for gy, bits := range f.Glyph(c.char) {
+		for gx := 0; gx < f.Width; gx++ {
+			i := bg
+			if bits&(0x80>>gx) != 0 {
+				i = fg
+			}
+			for sy := 0; sy < scale; sy++ {
+				o := img.PixOffset(px+gx*scale, py+gy*scale+sy)
+				for sx := 0; sx < scale; sx++ {
+					img.Pix[o+sx] = i
+				}
+			}
+		}
+	}
+}
+
+// parse the semicolon separated numeric parameters of a control sequence,
+// an empty parameter uses the value of -1.
+func parse(s string) []int {
+	if s == "" {
+		return nil
+	}
+	fields := strings.Split(s, ";")
+	p := make([]int, 0, len(fields))
+	for _, field := range fields {
+		i, err := strconv.Atoi(field)
+		if err != nil {
+			i = -1
+		}
+		p = append(p, i)
+	}
+	return p
+}
+
+// param returns the parameter at index i or the default value def when it is missing or empty.
+func param(p []int, i, def int) int {
+	if i >= len(p) || p[i] < 0 {
+		return def
+	}
+	if p[i] == 0 && def == 1 {
+		return def
+	}
+	return p[i]
+}
+
+func clamp(i, hi int) int {
+	return min(max(i, 0), hi)
+}
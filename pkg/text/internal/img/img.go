@@ -2,50 +2,47 @@
 package img
 
 import (
-	"bufio"
 	"bytes"
-	"context"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"net/http"
 	"os"
-	"os/exec"
 	"strings"
-	"time"
 
+	"github.com/Defacto2/df2/pkg/charset"
 	"github.com/Defacto2/df2/pkg/conf"
 	"github.com/Defacto2/df2/pkg/directories"
 	"github.com/Defacto2/df2/pkg/images"
+	"github.com/Defacto2/df2/pkg/sauce"
+	"github.com/Defacto2/df2/pkg/text/internal/ansi"
 	"github.com/dustin/go-humanize"
-	"github.com/gookit/color"
+	"golang.org/x/text/encoding/charmap"
 )
 
 var (
-	ErrNamed     = errors.New("named path cannot be empty")
-	ErrDest      = errors.New("dest path cannot be empty")
-	ErrSharedLib = errors.New("cannot access shared libraries: libansilove.so.1")
-	ErrType      = errors.New("mimetype is not a known text file")
+	ErrNamed = errors.New("named path cannot be empty")
+	ErrDest  = errors.New("dest path cannot be empty")
+	ErrType  = errors.New("mimetype is not a known text file")
 
 	ErrResize = errors.New("resize only works with the png image format")
 )
 
 const (
+	apng = ".apng"
 	png  = ".png"
 	webp = ".webp"
 
-	noExePath = `execute ansilove: executable file not found in $PATH`
-	killed    = "signal: killed"
-	note      = `
-this command requires the installation of AnsiLove/C
-installation instructions: https://github.com/ansilove/ansilove`
+	maxRows = 500 // maxRows is the maximum number of text rows drawn to an image.
+	maxCols = 320 // maxCols is the maximum character width of the text drawn to an image.
+	retina  = 2   // retina doubles the pixel size of the image.
 )
 
 // Make both PNG and Webp preview images and a 400x PNG thumbnail from the named text files.
 // Name is the source text file required for conversion to an image.
 // UUID is the universal ID used for the image filename.
-// When the amiga bool is true the image text will use an Amiga era Topaz+, 80x50 font.
+// When the amiga bool is true the image text will use an Amiga era Topaz font.
 func Make(w io.Writer, cfg conf.Config, name, uuid string, amiga bool) error {
 	if w == nil {
 		w = io.Discard
@@ -58,26 +55,8 @@ func Make(w io.Writer, cfg conf.Config, name, uuid string, amiga bool) error {
 		return err
 	}
 	s, err := Export(name, f.Img000, amiga)
-	if err != nil { //nolint:nestif
-		if err.Error() == noExePath {
-			fmt.Fprintln(w, note)
-			return fmt.Errorf("generate, ansilove not found: %w", err)
-		}
-		if errors.Unwrap(err).Error() == killed {
-			tmp, err1 := Reduce(w, f.UUID, uuid)
-			if err1 != nil {
-				return fmt.Errorf("ansilove reduce: %w", err1)
-			}
-			s, err = Export(tmp, f.Img000, amiga)
-			if err != nil {
-				return fmt.Errorf("generate: %w", err)
-			}
-			defer os.Remove(tmp)
-			err = nil
-		}
-		if err != nil {
-			return fmt.Errorf("generate: %w", err)
-		}
+	if err != nil {
+		return fmt.Errorf("generate: %w", err)
 	}
 	fmt.Fprintf(w, "  %s", s)
 	const thumbMedium = 400
@@ -85,7 +64,7 @@ func Make(w io.Writer, cfg conf.Config, name, uuid string, amiga bool) error {
 	if err := Resize(w, src); err != nil {
 		return err
 	}
-	s, err = images.ToWebp(w, src, images.ReplaceExt(webp, src), true)
+	s, err = images.ToWebp(w, src, images.ReplaceExt(webp, src), nil)
 	if err != nil {
 		return fmt.Errorf("generate webp: %w", err)
 	}
@@ -158,47 +137,10 @@ func Resize(w io.Writer, name string) error {
 	return nil
 }
 
-// Reduce the length of the named textfile so it can be parsed by AnsiLove.
-func Reduce(w io.Writer, named, uuid string) (string, error) {
-	if w == nil {
-		w = io.Discard
-	}
-	fmt.Fprint(w, " will attempt to reduce the length of file")
-
-	f, err := os.Open(named)
-	if err != nil {
-		return "", err
-	}
-	defer f.Close()
-
-	dest, err := os.CreateTemp(os.TempDir(), uuid+"_reduce")
-	if err != nil {
-		return "", err
-	}
-	defer dest.Close()
-
-	scan, nw := bufio.NewScanner(f), bufio.NewWriter(dest)
-	scan.Split(bufio.ScanLines)
-
-	const maxLines = 500
-	line := 0
-	for scan.Scan() {
-		line++
-		if line > maxLines {
-			break
-		}
-		if _, err := nw.WriteString(scan.Text() + "\n"); err != nil {
-			os.Remove(dest.Name())
-			return "", err
-		}
-	}
-	nw.Flush()
-	return dest.Name(), nil
-}
-
 // Export any supported text based named file to a compressed PNG image.
-// helpful: https://www.programming-books.io/essential/go
-// /images-png-jpeg-bmp-tiff-webp-vp8-gif-c84a45304ec3498081c67aa1ea0d9c49.
+// The text is drawn using the IBM VGA font or when amiga is true, the Amiga Topaz font
+// and only the first 500 rows of text are drawn.
+// Any SAUCE metadata in the file selects the font, the iCE colors and the character width.
 func Export(name, dest string, amiga bool) (string, error) {
 	if name == "" {
 		return "", fmt.Errorf("makepng: %w", ErrNamed)
@@ -212,34 +154,150 @@ func Export(name, dest string, amiga bool) (string, error) {
 		return "", fmt.Errorf("makepng stat: %w", err)
 	}
 
-	const ten = 10 * time.Second
-	ctx, cancel := context.WithTimeout(context.Background(), ten)
-	defer cancel()
-	const (
-		suppressOutput = "-q" // ansilove -q # suppress output messages
-		retina2xImage  = "-r" // ansilove -r # create Retina @2x output file
-		destination    = "-o" // ansilove -o # specify output filename/path
-		selectFont     = "-f" // ansilove -f # select font for supported formats: 80x25 (default), topaz+, 80x50, ...
-	)
-	saveAs := dest + png
-	args := []string{suppressOutput, retina2xImage, destination, saveAs}
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return "", fmt.Errorf("makepng read: %w", err)
+	}
+	opts := ansi.Options{Font: ansi.VGA, Rows: maxRows, Scale: retina}
 	if amiga {
-		args = append(args, selectFont, "topaz+")
+		opts.Font = ansi.Topaz
 	}
-	args = append(args, name)
-	cmd := exec.CommandContext(ctx, "ansilove", args...)
-	out, err := cmd.Output()
-	if err != nil && err.Error() == "exit status 127" {
-		return "", fmt.Errorf("make ansilove %q: %w", args, ErrSharedLib)
-	} else if err != nil {
-		return "", fmt.Errorf("make ansilove %q: %q %w", args, out, err)
+	b, opts = prepare(b, opts, amiga)
+	saveAs := dest + png
+	dst, err := os.Create(saveAs)
+	if err != nil {
+		return "", fmt.Errorf("makepng create: %w", err)
+	}
+	defer dst.Close()
+	if err := ansi.PNG(dst, bytes.NewReader(b), opts); err != nil {
+		return "", fmt.Errorf("makepng render: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		return "", fmt.Errorf("makepng close: %w", err)
+	}
+	i, err := Bytes(saveAs)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("✓ text » png %v", humanize.Bytes(i)), nil
+}
+
+// Animate any supported text based named file to an animated PNG image.
+// The animation plays back the text as it would be received by a terminal at the baud rate,
+// which is useful for ANSI art animations that rely on cursor movements.
+// Any SAUCE metadata in the file selects the font, the iCE colors and the character width.
+func Animate(name, dest string, baud int) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("animate: %w", ErrNamed)
+	}
+	if dest == "" {
+		return "", fmt.Errorf("animate: %w", ErrDest)
+	}
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return "", fmt.Errorf("animate read: %w", err)
+	}
+	b, opts := prepare(b, ansi.Options{Font: ansi.VGA}, false)
+	saveAs := dest + apng
+	dst, err := os.Create(saveAs)
+	if err != nil {
+		return "", fmt.Errorf("animate create: %w", err)
+	}
+	defer dst.Close()
+	if err := ansi.APNG(dst, bytes.NewReader(b), opts, baud); err != nil {
+		return "", fmt.Errorf("animate render: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		return "", fmt.Errorf("animate close: %w", err)
 	}
 	i, err := Bytes(saveAs)
 	if err != nil {
 		return "", err
 	}
-	return fmt.Sprintf("✓ text » png %v %s",
-		humanize.Bytes(i), color.Secondary.Sprintf("%s", out)), nil
+	return fmt.Sprintf("✓ ansi » apng %v", humanize.Bytes(i)), nil
+}
+
+// prepare removes any SAUCE metadata from the b file content and returns the text and the render options.
+// A font named by the SAUCE metadata is always used, otherwise the font is chosen using the character set.
+func prepare(b []byte, o ansi.Options, amiga bool) ([]byte, ansi.Options) {
+	rec, err := sauce.Decode(b)
+	meta := err == nil || errors.Is(err, sauce.ErrComments)
+	b = b[:sauce.Index(b)]
+	if _, ok := ansi.Lookup(rec.Font); meta && ok {
+		return b, Sauce(o, rec)
+	}
+	b, o = Font(b, o, charset.Classify(b, amiga))
+	if meta {
+		o = Sauce(o, rec)
+	}
+	return b, o
+}
+
+// Font returns the b text and the render options to draw text in the cs character set.
+// CP437 uses the VGA font and Amiga Latin-1 uses the Topaz font,
+// while the other character sets are converted to CP437 to use the VGA font.
+// UTF-8 text is converted to the character set of the font in the options.
+func Font(b []byte, o ansi.Options, cs charset.Charset) ([]byte, ansi.Options) {
+	switch cs {
+	case charset.CP437:
+		if o.Font.Name == ansi.Topaz.Name {
+			o.Font = ansi.VGA
+		}
+	case charset.Amiga:
+		o.Font = ansi.Topaz
+	case charset.Latin1, charset.Windows1252:
+		if o.Font.Name == ansi.Topaz.Name {
+			o.Font = ansi.VGA
+		}
+		b = encode(charset.Decode(b, cs), charmap.CodePage437)
+	case charset.UTF8:
+		cm := charmap.CodePage437
+		if o.Font.Name == ansi.Topaz.Name {
+			cm = charmap.ISO8859_1
+		}
+		b = encode(string(bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))), cm)
+	case charset.ShiftJIS:
+	}
+	return b, o
+}
+
+// fallback are the ASCII replacements for the typographic punctuation that is missing from the character maps.
+var fallback = map[rune]string{
+	'‘': "'", '’': "'", '‚': ",", '“': `"`, '”': `"`, '„': `"`,
+	'–': "-", '—': "-", '…': "...", '™': "TM", '€': "EUR",
+}
+
+// encode the s text to the character map, any characters that cannot be encoded
+// are replaced by an ASCII fallback or a question mark.
+func encode(s string, cm *charmap.Charmap) []byte {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if c, ok := cm.EncodeRune(r); ok {
+			b = append(b, c)
+			continue
+		}
+		if f, ok := fallback[r]; ok {
+			b = append(b, f...)
+			continue
+		}
+		b = append(b, '?')
+	}
+	return b
+}
+
+// Sauce returns the render options using the font, iCE colors and character width
+// of the SAUCE metadata, any values that are missing from the metadata are kept.
+func Sauce(o ansi.Options, rec sauce.Record) ansi.Options {
+	if f, ok := ansi.Lookup(rec.Font); ok {
+		o.Font = f
+	}
+	if rec.ICE() {
+		o.ICE = true
+	}
+	if w := rec.Width(); w > 0 && w <= maxCols {
+		o.Columns = w
+	}
+	return o
 }
 
 func Bytes(name string) (uint64, error) {
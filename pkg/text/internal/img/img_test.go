@@ -1,7 +1,6 @@
 package img_test
 
 import (
-	"bufio"
 	"fmt"
 	"image"
 	"image/draw"
@@ -11,8 +10,11 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/Defacto2/df2/pkg/charset"
 	"github.com/Defacto2/df2/pkg/conf"
 	"github.com/Defacto2/df2/pkg/images"
+	"github.com/Defacto2/df2/pkg/sauce"
+	"github.com/Defacto2/df2/pkg/text/internal/ansi"
 	"github.com/Defacto2/df2/pkg/text/internal/img"
 	"github.com/stretchr/testify/assert"
 )
@@ -117,42 +119,104 @@ func TestResize(t *testing.T) {
 	assert.Less(t, st.Size(), size, "the resized png should be a small file size than the original drawn png")
 }
 
-func TestReduce(t *testing.T) {
+func TestExport(t *testing.T) {
 	t.Parallel()
-	s, err := img.Reduce(nil, "", "")
+	txt := filepath.Join(testDir(), "text", "test.txt")
+	ans := filepath.Join(testDir(), "text", "test.ans")
+	dir := t.TempDir()
+
+	s, err := img.Export("", "", false)
 	assert.NotNil(t, err)
 	assert.Equal(t, "", s)
-	s, err = img.Reduce(io.Discard, "no-such-file", "")
+	s, err = img.Export(txt, "", false)
 	assert.NotNil(t, err)
 	assert.Equal(t, "", s)
-	// create a new file with 600 lines of text
-	f, err := os.CreateTemp(os.TempDir(), "test-reduce-textfile")
-	assert.Nil(t, err)
-	defer f.Close()
-	defer os.Remove(f.Name())
-	n := 0
-	for n < 600 {
-		n++
-		_, err := f.WriteString(fmt.Sprintf("line %d\n", n))
+	s, err = img.Export("no-such-file", filepath.Join(dir, "missing"), false)
+	assert.NotNil(t, err)
+	assert.Equal(t, "", s)
+
+	for _, amiga := range []bool{false, true} {
+		dest := filepath.Join(dir, fmt.Sprintf("export-%v", amiga))
+		s, err = img.Export(ans, dest, amiga)
 		assert.Nil(t, err)
+		assert.NotEqual(t, "", s)
+		wpx, hpx, format, err := images.Info(dest + ".png")
+		assert.Nil(t, err)
+		assert.Equal(t, "png", format)
+		const retinaWidth, retinaRows = 80 * 8 * 2, 6 * 16 * 2
+		assert.Equal(t, retinaWidth, wpx)
+		assert.Equal(t, retinaRows, hpx)
 	}
-	err = f.Sync()
-	assert.Nil(t, err)
-	// Reduce file
-	s, err = img.Reduce(io.Discard, f.Name(), uuid)
+}
+
+func TestAnimate(t *testing.T) {
+	t.Parallel()
+	ans := filepath.Join(testDir(), "text", "test.ans")
+	dir := t.TempDir()
+
+	s, err := img.Animate("", "", 0)
+	assert.NotNil(t, err)
+	assert.Equal(t, "", s)
+	s, err = img.Animate("no-such-file", filepath.Join(dir, "missing"), 0)
+	assert.NotNil(t, err)
+	assert.Equal(t, "", s)
+
+	dest := filepath.Join(dir, "animate")
+	s, err = img.Animate(ans, dest, 2400)
 	assert.Nil(t, err)
-	assert.NotEqual(t, "", s)
-	// count the lines in the reduced files
-	r, err := os.Open(s)
+	assert.Contains(t, s, "apng")
+	wpx, hpx, format, err := images.Info(dest + ".apng")
 	assert.Nil(t, err)
-	defer r.Close()
-	defer os.Remove(s)
-	scan := bufio.NewScanner(r)
-	scan.Split(bufio.ScanLines)
-	lines := 0
-	for scan.Scan() {
-		lines++
+	assert.Equal(t, "png", format)
+	const vgaWidth, vgaHeight = 80 * 8, 25 * 16
+	assert.Equal(t, vgaWidth, wpx)
+	assert.Equal(t, vgaHeight, hpx)
+}
+
+func TestSauce(t *testing.T) {
+	t.Parallel()
+	o := img.Sauce(ansi.Options{}, sauce.Record{})
+	assert.Equal(t, ansi.Options{}, o)
+	rec := sauce.Record{
+		DataType: sauce.Character,
+		FileType: sauce.ANSI,
+		TInfo:    [4]uint16{132, 25},
+		Flags:    1,
+		Font:     "Amiga Topaz 1+",
+	}
+	o = img.Sauce(ansi.Options{Scale: 2}, rec)
+	assert.Equal(t, ansi.Topaz.Name, o.Font.Name)
+	assert.Equal(t, 132, o.Columns)
+	assert.Equal(t, 2, o.Scale)
+	assert.True(t, o.ICE)
+	rec.TInfo[0] = 9999
+	o = img.Sauce(ansi.Options{}, rec)
+	assert.Equal(t, 0, o.Columns, "an oversized width should be ignored")
+}
+
+func TestFont(t *testing.T) {
+	t.Parallel()
+	vga := ansi.Options{Font: ansi.VGA}
+	topaz := ansi.Options{Font: ansi.Topaz}
+	tests := []struct {
+		name  string
+		b     string
+		opts  ansi.Options
+		cs    charset.Charset
+		want  string
+		wantF string
+	}{
+		{"cp437", "\xdb\x82", vga, charset.CP437, "\xdb\x82", ansi.VGA.Name},
+		{"cp437 misfiled amiga", "\xdb\x82", topaz, charset.CP437, "\xdb\x82", ansi.VGA.Name},
+		{"amiga", "Caf\xe9", vga, charset.Amiga, "Caf\xe9", ansi.Topaz.Name},
+		{"latin-1", "Caf\xe9", vga, charset.Latin1, "Caf\x82", ansi.VGA.Name},
+		{"windows", "\x93Hi\x94\x1b[0m", vga, charset.Windows1252, "\"Hi\"\x1b[0m", ansi.VGA.Name},
+		{"utf-8 vga", "\xef\xbb\xbf█ Café ☃", vga, charset.UTF8, "\xdb Caf\x82 ?", ansi.VGA.Name},
+		{"utf-8 topaz", "Café", topaz, charset.UTF8, "Caf\xe9", ansi.Topaz.Name},
+	}
+	for _, tt := range tests {
+		b, o := img.Font([]byte(tt.b), tt.opts, tt.cs)
+		assert.Equal(t, tt.want, string(b), tt.name)
+		assert.Equal(t, tt.wantF, o.Font.Name, tt.name)
 	}
-	const expected = 500
-	assert.Equal(t, expected, lines, "reduce should have created a file with no more than 500 lines of text")
 }
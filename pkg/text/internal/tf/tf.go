@@ -31,6 +31,7 @@ var (
 
 const (
 	// Images.
+	apng = ".apng"
 	png  = ".png"
 	webp = ".webp"
 	// Texts.
@@ -50,6 +51,7 @@ const (
 	zip = ".zip"
 
 	amigaTxt = "textamiga"
+	ansiArt  = "ansi"
 )
 
 // TextFile is a text file object.
@@ -208,7 +210,7 @@ func (t *TextFile) WebP(w io.Writer, c int, imgDir string) (int, error) {
 		fmt.Fprintf(w, "%s (no src png)\n", str.X())
 		return c, nil
 	}
-	s, err := images.ToWebp(w, src, name, true)
+	s, err := images.ToWebp(w, src, name, nil)
 	if err != nil {
 		fmt.Fprintf(w, "%s\n", str.X())
 		return c, fmt.Errorf("txtwebp: %w", err)
@@ -216,3 +218,45 @@ func (t *TextFile) WebP(w io.Writer, c int, imgDir string) (int, error) {
 	fmt.Fprintf(w, "%s %s\n", s, str.Y())
 	return c, nil
 }
+
+// APNG finds and generates missing animated PNG images from text files that are tagged as ANSI art.
+// The animation plays back the text at the baud rate and is saved beside the PNG preview image.
+func (t *TextFile) APNG(w io.Writer, c int, dir *directories.Dir, baud int) (int, error) {
+	if dir == nil {
+		return c, fmt.Errorf("dir %w", ErrPointer)
+	}
+	if t.UUID == "" {
+		return c, ErrReadmeBlank
+	}
+	if t.Platform != ansiArt {
+		return c, nil
+	}
+	if w == nil {
+		w = io.Discard
+	}
+	dest := filepath.Join(dir.Img000, t.UUID)
+	if st, err := os.Stat(dest + apng); err == nil && st.Size() > 0 {
+		// skip any existing animations
+		return c, nil
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return c, fmt.Errorf("apng stat %w: %s", err, dest)
+	}
+	src := filepath.Join(dir.UUID, t.UUID)
+	if t.Archive() {
+		// the readme extracted from the archive
+		src += txt
+	}
+	if st, err := os.Stat(src); errors.Is(err, fs.ErrNotExist) || (err == nil && st.Size() == 0) {
+		return c, nil
+	} else if err != nil {
+		return c, fmt.Errorf("apng stat %w: %s", err, src)
+	}
+	c++
+	s, err := img.Animate(src, dest, baud)
+	if err != nil {
+		fmt.Fprintf(w, "%s\n", str.X())
+		return c, fmt.Errorf("txtapng: %w", err)
+	}
+	fmt.Fprintf(w, "%s%s %s %s\n", str.PrePad, t.String(), s, str.Y())
+	return c, nil
+}
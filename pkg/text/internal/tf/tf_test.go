@@ -135,3 +135,35 @@ func TestTextFile_Webp(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, 1, i)
 }
+
+func TestTextFile_APNG(t *testing.T) {
+	t.Parallel()
+	s := tf.TextFile{}
+	i, err := s.APNG(nil, 0, nil, 0)
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, i)
+
+	tmp := t.TempDir()
+	src := filepath.Join("..", "..", "..", "..", "testdata", "text")
+	dir := directories.Dir{UUID: src, Img000: tmp}
+	i, err = s.APNG(io.Discard, 0, &dir, 0)
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, i)
+
+	s = tf.TextFile{ID: 1, UUID: "test.ans", Name: "test.ans", Platform: "text"}
+	i, err = s.APNG(io.Discard, 0, &dir, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, i, "text that is not tagged as ansi should be skipped")
+
+	s.Platform = "ansi"
+	i, err = s.APNG(io.Discard, 0, &dir, 9600)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, i)
+	st, err := os.Stat(filepath.Join(tmp, "test.ans.apng"))
+	assert.Nil(t, err)
+	assert.NotZero(t, st.Size())
+
+	i, err = s.APNG(io.Discard, 0, &dir, 9600)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, i, "an existing animation should be skipped")
+}
@@ -1,5 +1,5 @@
 // Package text generates preview images and thumbnails from text files using
-// the system installed Ansilove/C program.
+// a native renderer of CP437 text and ANSI art with the bundled IBM VGA and Amiga Topaz fonts.
 package text
 
 import (
@@ -7,23 +7,35 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"path/filepath"
+	"strings"
 
 	"github.com/Defacto2/df2/pkg/conf"
 	"github.com/Defacto2/df2/pkg/database"
 	"github.com/Defacto2/df2/pkg/directories"
+	"github.com/Defacto2/df2/pkg/sauce"
 	"github.com/Defacto2/df2/pkg/str"
+	"github.com/Defacto2/df2/pkg/text/internal/ansi"
 	"github.com/Defacto2/df2/pkg/text/internal/img"
 	"github.com/Defacto2/df2/pkg/text/internal/tf"
 	"go.uber.org/zap"
 )
 
 const (
+	amigaTxt = "textamiga"
+	txt      = ".txt"
+
 	stmt = "SELECT id, uuid, filename, filesize, retrotxt_no_readme, retrotxt_readme, platform " +
-		"FROM files WHERE platform=\"text\" OR platform=\"textamiga\" OR platform=\"ansi\" ORDER BY id DESC"
+		"FROM files WHERE platform='text' OR platform='textamiga' OR platform='ansi' ORDER BY id DESC"
 )
 
+// Baud is the default modem speed in bits per second used to playback the ANSI art animations.
+const Baud = ansi.Baud
+
 // Fix generates any missing assets from downloads that are text based.
-func Fix(db *sql.DB, w io.Writer, l *zap.SugaredLogger, cfg conf.Config) error {
+// When animate is true, animated previews of the ANSI art are also generated
+// that playback the text at the baud rate.
+func Fix(db *sql.DB, w io.Writer, l *zap.SugaredLogger, cfg conf.Config, animate bool, baud int) error {
 	if db == nil {
 		return database.ErrDB
 	}
@@ -43,7 +55,10 @@ func Fix(db *sql.DB, w io.Writer, l *zap.SugaredLogger, cfg conf.Config) error {
 	defer rows.Close()
 	i, c := 0, 0
 	for rows.Next() {
-		if _, i, c, err = fixRow(w, cfg, i, c, &dir, rows); err != nil {
+		var t tf.TextFile
+		t, i, c, err = fixRow(db, w, cfg, i, c, &dir, rows)
+		normalize(db, w, t, &dir)
+		if err != nil {
 			if errors.Is(tf.ErrReadmeOff, err) {
 				// website admin has disabled the display of a readme
 				continue
@@ -57,6 +72,12 @@ func Fix(db *sql.DB, w io.Writer, l *zap.SugaredLogger, cfg conf.Config) error {
 				continue
 			}
 		}
+		if !animate {
+			continue
+		}
+		if c, err = t.APNG(w, c, &dir, baud); err != nil {
+			fmt.Fprintf(w, "%s %s", str.X(), err)
+		}
 	}
 	fmt.Fprintln(w)
 	str.Total(w, i, fmt.Sprintf("attempted to fix %d text files", i))
@@ -64,7 +85,7 @@ func Fix(db *sql.DB, w io.Writer, l *zap.SugaredLogger, cfg conf.Config) error {
 }
 
 func fixRow(
-	w io.Writer, cfg conf.Config, i, c int, dir *directories.Dir, rows *sql.Rows,
+	db *sql.DB, w io.Writer, cfg conf.Config, i, c int, dir *directories.Dir, rows *sql.Rows,
 ) (tf.TextFile, int, int, error) {
 	t := tf.TextFile{}
 	i++
@@ -75,6 +96,14 @@ func fixRow(
 	if err != nil {
 		return t, i, c, fmt.Errorf("fix exist: %w", err)
 	}
+	// fill any empty record columns with the sauce metadata of the textfile
+	names, err := sauce.Update(db, int64(t.ID), filepath.Join(dir.UUID, t.UUID))
+	if err != nil {
+		return t, i, c, fmt.Errorf("fix sauce: %w", err)
+	}
+	if len(names) > 0 {
+		fmt.Fprintf(w, "\n%s%d. %s sauce %s %s", str.PrePad, i, t.String(), strings.Join(names, ", "), str.Y())
+	}
 	if !ok {
 		fmt.Fprintf(w, "\n%s%d. %s", str.PrePad, i, t.String())
 	}
@@ -100,6 +129,27 @@ func fixRow(
 	return t, i, c, nil
 }
 
+// normalize classifies the encoding of the textfile and saves a UTF-8 copy beside the download.
+func normalize(db *sql.DB, w io.Writer, t tf.TextFile, dir *directories.Dir) {
+	if t.UUID == "" {
+		return
+	}
+	name := filepath.Join(dir.UUID, t.UUID)
+	src := name
+	if t.Archive() {
+		// the readme extracted from the archive
+		src += txt
+	}
+	enc, err := Normalize(db, int64(t.ID), src, name, t.Platform == amigaTxt)
+	if err != nil {
+		fmt.Fprintf(w, "%s %s", str.X(), err)
+		return
+	}
+	if enc != "" {
+		fmt.Fprintf(w, "\n%s%s encoding %s %s", str.PrePad, t.String(), enc, str.Y())
+	}
+}
+
 func extract(w io.Writer, cfg conf.Config, t tf.TextFile, dir *directories.Dir) error {
 	if dir == nil {
 		return fmt.Errorf("dir %w", tf.ErrPointer)
@@ -1,8 +1,7 @@
-// Package cmmt discovers and stores any found zip comments.
+// Package cmmt discovers and stores any found zip, rar, arj, lha and zoo archive comments.
 package cmmt
 
 import (
-	"archive/zip"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -12,6 +11,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/Defacto2/df2/pkg/archive"
 	"github.com/bengarrett/retrotxtgo/byter"
 	"go.uber.org/zap/buffer"
 	"golang.org/x/text/encoding/charmap"
@@ -32,7 +32,7 @@ const (
 	resetCmd = "\033[0m" // ansi command to reset colors and styles.
 )
 
-// Zipfile structure for files archived or compressed with a ZIP format.
+// Zipfile structure for files archived or compressed with a ZIP, RAR, ARJ, LHA or ZOO format.
 type Zipfile struct {
 	ID        uint           // ID is the database auto increment ID.
 	UUID      string         // Universal Unique ID.
@@ -79,7 +79,8 @@ func (z *Zipfile) Exist(path string) (bool, error) {
 	return true, nil
 }
 
-// Save an embedded zipfile, text comment to the path.
+// Save an embedded archive, text comment to the path.
+// The comments of all archive formats use the same SuffixName file sidecar.
 func (z *Zipfile) Save(w io.Writer, path string) (string, error) {
 	if z.UUID == "" {
 		return "", ErrUUID
@@ -92,14 +93,16 @@ func (z *Zipfile) Save(w io.Writer, path string) (string, error) {
 	}
 	src := filepath.Join(path, z.UUID)
 	dest := src + SuffixName
-	// Open a zip archive for reading.
-	r, err := zip.OpenReader(src)
+	// Read the archive comment, a filename without an extension is treated as a zip archive
+	name := z.Name
+	if filepath.Ext(name) == "" {
+		name += ".zip"
+	}
+	cmmt, err := archive.Comment(src, name)
 	if err != nil {
 		return "", fmt.Errorf("%w: %s", err, src)
 	}
-	defer r.Close()
-	// Parse and save zip comment
-	cmmt := r.Comment
+	// Parse and save the comment
 	if cmmt == "" {
 		return "", nil
 	}
@@ -2,6 +2,7 @@ package cmmt_test
 
 import (
 	"io"
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -69,6 +70,39 @@ func TestZipfile_Save(t *testing.T) {
 	assert.NotEqual(t, "", s)
 }
 
+func TestZipfile_Save_Archives(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		src  string
+		name string
+		want string
+	}{
+		{filepath.Join("rar", "cmt5.rar"), "bbs.rar", "Greetings from the\r\nRAR comment BBS \xcd\xcd\r\n"},
+		{filepath.Join("arj", "methods.arj"), "METHODS.ARJ", "ARJ fixture for methods 0 to 4."},
+		{filepath.Join("lha", "comment.lzh"), "bbs.lzh", "Greetings from the LHA comment BBS \xcd\xcd"},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		b, err := os.ReadFile(filepath.Join("..", "..", "..", "..", "testdata", tt.src))
+		assert.Nil(t, err)
+		err = os.WriteFile(filepath.Join(dir, uuid), b, 0o644)
+		assert.Nil(t, err)
+		z := cmmt.Zipfile{ID: 1, UUID: uuid, Name: tt.name, CP437: true}
+		ok, err := z.Exist(dir)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		s, err := z.Save(io.Discard, dir)
+		assert.Nil(t, err, tt.name)
+		assert.Equal(t, filepath.Join(dir, uuid+cmmt.SuffixName), s)
+		b, err = os.ReadFile(s)
+		assert.Nil(t, err)
+		assert.Equal(t, tt.want, string(b))
+		ok, err = z.Exist(dir)
+		assert.Nil(t, err)
+		assert.False(t, ok)
+	}
+}
+
 func TestZipfile_Format(t *testing.T) {
 	t.Parallel()
 	z := cmmt.Zipfile{}
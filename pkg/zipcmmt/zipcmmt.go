@@ -1,5 +1,5 @@
 // Package zipcmmt processes the text adverts that are sometimes embedded
-// into Zip file archives.
+// into Zip file archives, and the comments of RAR, ARJ, LHA and ZOO archives.
 package zipcmmt
 
 import (
@@ -17,12 +17,26 @@ import (
 )
 
 const (
-	errPrefix = "zipcmmt"
-	fixStmt   = `SELECT id, uuid, filename, filesize, file_magic_type FROM` +
-		` files WHERE filename LIKE "%.zip"`
+	errPrefix  = "zipcmmt"
+	selectStmt = `SELECT id, uuid, filename, filesize, file_magic_type FROM files WHERE `
+	fixStmt    = selectStmt + `filename LIKE '%.zip'`
+	cmmtStmt   = selectStmt + `(filename LIKE '%.zip' OR filename LIKE '%.rar' OR filename LIKE '%.arj'` +
+		` OR filename LIKE '%.lha' OR filename LIKE '%.lzh' OR filename LIKE '%.zoo')`
 )
 
+// Fix extracts and saves the missing comments of zip archives.
 func Fix(db *sql.DB, w io.Writer, cfg conf.Config, unicode, overwrite, stdout bool) error {
+	return scan(db, w, cfg, fixStmt, "zip archives", unicode, overwrite, stdout)
+}
+
+// Comments extracts and saves the missing comments of zip, rar, arj, lha and zoo archives.
+// The comments use the same file sidecar as the zip comments.
+func Comments(db *sql.DB, w io.Writer, cfg conf.Config, unicode, overwrite, stdout bool) error {
+	return scan(db, w, cfg, cmmtStmt, "archives", unicode, overwrite, stdout)
+}
+
+// scan the archives returned by the stmt query for comments.
+func scan(db *sql.DB, w io.Writer, cfg conf.Config, stmt, label string, unicode, overwrite, stdout bool) error {
 	if db == nil {
 		return database.ErrDB
 	}
@@ -34,7 +48,7 @@ func Fix(db *sql.DB, w io.Writer, cfg conf.Config, unicode, overwrite, stdout bo
 	if err != nil {
 		return err
 	}
-	rows, err := db.Query(fixStmt)
+	rows, err := db.Query(stmt)
 	if err != nil {
 		return fmt.Errorf("%s, db query: %w", errPrefix, err)
 	} else if rows.Err() != nil {
@@ -66,7 +80,7 @@ func Fix(db *sql.DB, w io.Writer, cfg conf.Config, unicode, overwrite, stdout bo
 		}
 	}
 	fmt.Fprintln(w)
-	fmt.Fprintf(w, "%d zip archives scanned for comments", i)
+	fmt.Fprintf(w, "%d %s scanned for comments", i, label)
 	str.TimeTaken(w, time.Since(start).Seconds())
 	return nil
 }
@@ -29,3 +29,9 @@ func TestFix(t *testing.T) {
 		unicode, overwrite, summary)
 	assert.Nil(t, err)
 }
+
+func TestComments(t *testing.T) {
+	t.Parallel()
+	err := zipcmmt.Comments(nil, nil, conf.Defaults(), false, false, false)
+	assert.NotNil(t, err)
+}
@@ -9,14 +9,18 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/Defacto2/df2/pkg/archive"
 	"github.com/Defacto2/df2/pkg/database"
 	"github.com/Defacto2/df2/pkg/logger"
+	"github.com/Defacto2/df2/pkg/magic"
 	"github.com/Defacto2/df2/pkg/str"
 	"github.com/Defacto2/df2/pkg/zipcontent/internal/scan"
 	"github.com/gookit/color"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 )
 
 const (
@@ -24,74 +28,57 @@ const (
 )
 
 var (
-	ErrID       = errors.New("record does not contain a valid value for the id column")
-	ErrUUID     = errors.New("record does not contain a valid value for the uuid column")
-	ErrRawBytes = errors.New("sql rawbytes is missing an expected table columns")
-	ErrStatNil  = errors.New("scan stats pointer is nil or the stats.values field is missing")
-	ErrValues   = errors.New("the number of values is not the same as the number of columns")
+	ErrID      = errors.New("record does not contain a valid value for the id column")
+	ErrUUID    = errors.New("record does not contain a valid value for the uuid column")
+	ErrStatNil = errors.New("scan stats pointer is nil")
 )
 
 // Record object.
 type Record struct {
-	ID    string   // ID is the database auto increment ID.
-	UUID  string   // Universal unique Id.
-	File  string   // File is the absolute path to file archive.
-	Name  string   // Name of the file archive.
-	Files []string // Files contained in the archive.
-	NFO   string   // NFO or textfile to display on the site.
+	ID      string    // ID is the database auto increment ID.
+	UUID    string    // Universal unique Id.
+	File    string    // File is the absolute path to file archive.
+	Name    string    // Name of the file archive.
+	Files   []string  // Files contained in the archive.
+	NFO     string    // NFO or textfile to display on the site.
+	Magic   string    // Magic is the detected file type of the archive.
+	Created null.Time // Created is the time the record was created.
 }
 
-// New returns a Record generated from the sql rawbyte values.
-func New(values []sql.RawBytes, path string) (Record, error) {
-	const id, uuid, filename, readme = 0, 1, 4, 6
-	if len(values) < readme+1 {
-		return Record{}, ErrRawBytes
+// New returns a Record generated from the file record.
+func New(f database.File, path string) (Record, error) {
+	if f.ID < 1 {
+		return Record{}, ErrID
 	}
 	return Record{
-		ID:   string(values[id]),
-		UUID: string(values[uuid]),
-		Name: string(values[filename]),
-		NFO:  string(values[readme]),
-		File: filepath.Join(path, string(values[uuid])),
+		ID:      strconv.FormatInt(f.ID, 10),
+		UUID:    f.UUID.String,
+		Name:    f.Filename.String,
+		NFO:     f.RetrotxtReadme.String,
+		File:    filepath.Join(path, f.UUID.String),
+		Created: f.Createdat,
 	}, nil
 }
 
-// Iterate through each sql rawbyte value.
+// Iterate prints the record and then reads and saves the archive content.
 func (r *Record) Iterate(db *sql.DB, w io.Writer, s *scan.Stats) error {
 	if db == nil {
 		return database.ErrDB
 	}
-	if s == nil || s.Values == nil {
+	if s == nil {
 		return ErrStatNil
 	}
 	if w == nil {
 		w = io.Discard
 	}
-	if len(s.Columns) != len(*s.Values) {
-		return fmt.Errorf("%w, columns: %d, values: %d",
-			ErrValues, len(s.Columns), len(*s.Values))
-	}
-	for i, raw := range *s.Values {
-		value := database.Val(raw)
-		switch s.Columns[i] {
-		case "id":
-			if err := r.id(w, s); err != nil {
-				return err
-			}
-		case "createdat":
-			s, err := database.DateTime(raw)
-			if err == nil {
-				fmt.Fprintf(w, "  %v", s)
-			}
-		case "filename":
-			fmt.Fprintf(w, "  %v", value)
-			if err := r.Archive(db, w, s); err != nil {
-				return err
-			}
-		default:
-		}
+	if err := r.id(w, s); err != nil {
+		return err
 	}
-	return nil
+	if ts := database.Timestamp(r.Created); ts != "" {
+		fmt.Fprintf(w, "  %v", ts)
+	}
+	fmt.Fprintf(w, "  %v", r.Name)
+	return r.Archive(db, w, s)
 }
 
 // Archive reads and saves the archive content to the database.
@@ -108,18 +95,29 @@ func (r *Record) Archive(db *sql.DB, w io.Writer, s *scan.Stats) error {
 	if w == nil {
 		w = io.Discard
 	}
+	if t, err := magic.File(r.File); err == nil {
+		r.Magic = t.String()
+	}
 	var err error
-	r.Files, r.Name, err = archive.Read(w, r.File, r.Name)
+	nest := archive.Nested()
+	nest.Depth = s.Depth
+	r.Files, r.Name, err = archive.ReadNested(w, r.File, r.Name, nest)
 	if err != nil {
 		s.Missing++
 		return fmt.Errorf("%s archive read: %w", errPrefix, err)
 	}
 	fmt.Fprintf(w, "%d items", len(r.Files))
+	if err := r.Manifest(w); err != nil {
+		fmt.Fprintf(w, " %s %s", str.X(), err)
+	}
 	if err := r.Textfile(w, s); err != nil {
 		// instead of returning the error, print it.
 		// otherwise the results of archive.Read will never be saved
 		fmt.Fprintf(w, " %s %s", str.X(), err)
-		// str.X()
+		// flag archives that are unsafe to extract
+		if err := archive.Flag(db, w, r.UUID, err); err != nil {
+			return err
+		}
 	}
 	updates, err := r.Save(db)
 	if err != nil {
@@ -134,6 +132,22 @@ func (r *Record) Archive(db *sql.DB, w io.Writer, s *scan.Stats) error {
 	return nil
 }
 
+// Manifest saves a JSON manifest of the archive content to a sidecar file beside the archive.
+func (r *Record) Manifest(w io.Writer) error {
+	if w == nil {
+		w = io.Discard
+	}
+	m, err := archive.ReadManifest(r.File, r.Name)
+	if err != nil {
+		return err
+	}
+	if err := m.Save(r.File + archive.ManifestExt); err != nil {
+		return err
+	}
+	fmt.Fprint(w, ", manifest")
+	return nil
+}
+
 // Textfile finds an appropriate text or NFO file and saves it to the database.
 func (r *Record) Textfile(w io.Writer, s *scan.Stats) error {
 	if s == nil {
@@ -154,7 +168,7 @@ func (r *Record) Textfile(w io.Writer, s *scan.Stats) error {
 			return err1
 		}
 		defer os.RemoveAll(tmp)
-		if err2 := archive.Extractor(r.Name, r.File, r.NFO, tmp); err2 != nil {
+		if err2 := archive.ExtractNested(r.Name, r.File, r.NFO, tmp); err2 != nil {
 			return err2
 		}
 		src := filepath.Join(tmp, r.NFO)
@@ -189,46 +203,34 @@ func (r *Record) Save(db *sql.DB) (int64, error) {
 	if r.ID == "" {
 		return 0, ErrID
 	}
-	const (
-		files = "UPDATE files SET filename=?,file_zip_content=?,updatedat=NOW(),updatedby=? WHERE id=?"
-		nfo   = "UPDATE files SET filename=?,file_zip_content=?,updatedat=NOW(),updatedby=?," +
-			"retrotxt_readme=?,retrotxt_no_readme=? WHERE id=?"
-	)
-	var err error
-	var stmt *sql.Stmt
-	if r.NFO == "" {
-		stmt, err = db.Prepare(files)
-	} else {
-		stmt, err = db.Prepare(nfo)
+	cols := map[string]any{
+		"filename":         r.Name,
+		"file_zip_content": strings.Join(r.Files, "\n"),
+	}
+	if r.NFO != "" {
+		cols["retrotxt_readme"] = r.NFO
+		cols["retrotxt_no_readme"] = 0
 	}
+	tx, err := database.Begin(db, nil, false)
 	if err != nil {
-		return 0, fmt.Errorf("%s db prepare: %w", errPrefix, err)
-	}
-	defer stmt.Close()
-	content := strings.Join(r.Files, "\n")
-	var args []any
-	switch r.NFO {
-	case "":
-		args = []any{r.Name, content, database.UpdateID, r.ID}
-	default:
-		args = []any{r.Name, content, database.UpdateID, r.NFO, 0, r.ID}
-	}
-	res, err := stmt.Exec(args...)
+		return 0, fmt.Errorf("%s db begin: %w", errPrefix, err)
+	}
+	rows, err := database.UpdateFiles(tx, cols, qm.Where("id = ?", r.ID))
 	if err != nil {
-		return 0, fmt.Errorf("%s db exec: %w", errPrefix, err)
-	}
-	// RowsAffected & LastInsertId behaves differently depending on the database server.
-	// On MariaDB DB, RowsAffected returns a 1 value for an invalid record primary key.
-	// But LastInsertId returns an expected 0 value.
-	if rows, err := res.RowsAffected(); err != nil {
-		return 0, err
-	} else if rows > 0 {
-		return rows, nil
-	}
-	if id, err := res.LastInsertId(); err != nil {
-		return 0, fmt.Errorf("%s db last insert: %w", errPrefix, err)
-	} else if id == 0 {
-		return 0, ErrID
+		return 0, tx.Cancel(fmt.Errorf("%s db update: %w", errPrefix, err))
+	}
+	if r.Magic != "" {
+		// only fill in a missing magic type, as existing values may have been set by hand.
+		i, err := database.UpdateFiles(tx, map[string]any{"file_magic_type": r.Magic},
+			qm.Where("id = ?", r.ID),
+			qm.Where("(file_magic_type IS NULL OR file_magic_type = '')"))
+		if err != nil {
+			return 0, tx.Cancel(fmt.Errorf("%s db update magic: %w", errPrefix, err))
+		}
+		rows += i
+	}
+	if err := tx.End(); err != nil {
+		return 0, fmt.Errorf("%s db commit: %w", errPrefix, err)
 	}
-	return 1, nil
+	return rows, nil
 }
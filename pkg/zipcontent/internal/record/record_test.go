@@ -1,7 +1,6 @@
 package record_test
 
 import (
-	"database/sql"
 	"io"
 	"os"
 	"path/filepath"
@@ -13,6 +12,7 @@ import (
 	"github.com/Defacto2/df2/pkg/zipcontent/internal/record"
 	"github.com/Defacto2/df2/pkg/zipcontent/internal/scan"
 	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
 )
 
 func dzDir() string {
@@ -21,21 +21,22 @@ func dzDir() string {
 
 func TestNew(t *testing.T) {
 	t.Parallel()
-	r, err := record.New(nil, "")
-	assert.NotNil(t, err)
+	r, err := record.New(database.File{}, "")
+	assert.ErrorIs(t, err, record.ErrID)
 	assert.Empty(t, r)
 
-	const ids, uuids = "345674", "b4ef0174-57b4-11ec-bf63-0242ac130002"
-	const id, uuid, filename, readme = 0, 1, 4, 6
-	vals := make([]sql.RawBytes, 7)
-	vals[id] = sql.RawBytes(ids)
-	vals[uuid] = sql.RawBytes(uuids)
-	vals[filename] = sql.RawBytes("somefile.zip")
-	vals[readme] = sql.RawBytes("readme.txt")
-
-	r, err = record.New(vals, "some-directory")
+	const uuid = "b4ef0174-57b4-11ec-bf63-0242ac130002"
+	f := database.File{
+		ID:             345674,
+		UUID:           null.StringFrom(uuid),
+		Filename:       null.StringFrom("somefile.zip"),
+		RetrotxtReadme: null.StringFrom("readme.txt"),
+	}
+	r, err = record.New(f, "some-directory")
 	assert.Nil(t, err)
-	assert.NotEmpty(t, r)
+	assert.Equal(t, "345674", r.ID)
+	assert.Equal(t, "readme.txt", r.NFO)
+	assert.Equal(t, filepath.Join("some-directory", uuid), r.File)
 }
 
 func TestRecord_Iterate(t *testing.T) {
@@ -51,24 +52,8 @@ func TestRecord_Iterate(t *testing.T) {
 	err = r.Iterate(db, io.Discard, nil)
 	assert.NotNil(t, err)
 
-	vals := []sql.RawBytes{
-		sql.RawBytes("0"),
-		sql.RawBytes(time.Now().String()),
-		sql.RawBytes("somefile.zip"),
-		sql.RawBytes(""),
-	}
-	badSt := scan.Stats{
-		Columns: []string{"1ee21218-5898-11ec-bf63-0242ac130002"},
-		Values:  &vals,
-	}
-	err = r.Iterate(db, io.Discard, &badSt)
-	assert.NotNil(t, err)
-
-	okSt := scan.Stats{
-		Columns: []string{"id", "createdat", "filename", "uuid"},
-		Values:  &vals,
-	}
-	err = r.Iterate(db, io.Discard, &okSt)
+	r = record.Record{ID: "1", Name: "somefile.zip", Created: null.TimeFrom(time.Now())}
+	err = r.Iterate(db, io.Discard, &scan.Stats{})
 	assert.NotNil(t, err)
 }
 
@@ -85,31 +70,16 @@ func TestRecord_Archive(t *testing.T) {
 	err = r.Archive(db, io.Discard, nil)
 	assert.NotNil(t, err)
 
-	vals := []sql.RawBytes{
-		sql.RawBytes("0"),
-		sql.RawBytes(time.Now().String()),
-		sql.RawBytes("somefile.zip"),
-		sql.RawBytes(""),
-	}
-	badSt := scan.Stats{
-		Columns: []string{uuid},
-		Values:  &vals,
-	}
-	err = r.Archive(db, io.Discard, &badSt)
+	err = r.Archive(db, io.Discard, &scan.Stats{})
 	assert.NotNil(t, err)
 
-	okSt := scan.Stats{
-		Columns: []string{"id", "createdat", "filename", "uuid"},
-		Values:  &vals,
-	}
-
 	r = record.Record{
 		ID:   "1",
 		UUID: uuid,
 		File: filepath.Join(dzDir(), "test.zip"),
 		Name: "test.png",
 	}
-	err = r.Archive(db, io.Discard, &okSt)
+	err = r.Archive(db, io.Discard, &scan.Stats{})
 	assert.Nil(t, err)
 	defer os.Remove(uuid + ".txt")
 }
@@ -2,7 +2,6 @@
 package scan
 
 import (
-	"database/sql"
 	"io"
 	"time"
 
@@ -13,13 +12,12 @@ import (
 
 // Stats contain the statistics of the archive scan.
 type Stats struct {
-	BasePath string          // BasePath is the path to the file downloads directory.
-	Count    int             // Count the database table row index.
-	Missing  int             // Missing UUID as files count.
-	Total    int             // Total rows in the database table.
-	Columns  []string        // Columns are column names from the database table.
-	Values   *[]sql.RawBytes // Values of the database table.
-	start    time.Time       // Processing duration.
+	BasePath string    // BasePath is the path to the file downloads directory.
+	Count    int       // Count the database table row index.
+	Missing  int       // Missing UUID as files count.
+	Total    int       // Total rows in the database table.
+	Depth    int       // Depth of the nested archives to list, zero only lists the top level.
+	start    time.Time // Processing duration.
 }
 
 // Init initializes the archive scan statistics.
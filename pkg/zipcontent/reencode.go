@@ -0,0 +1,82 @@
+package zipcontent
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Defacto2/df2/pkg/charset"
+	"github.com/Defacto2/df2/pkg/database"
+	"github.com/Defacto2/df2/pkg/str"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+const reencodeStmt = "SELECT `id`,`file_zip_content`,`retrotxt_readme` FROM `files`" +
+	" WHERE `file_zip_content` IS NOT NULL AND `file_zip_content` != ''"
+
+// Reencode repairs the archive listings and the readme filenames saved to the database,
+// that contain legacy CP437 or Shift-JIS filenames which were never decoded to UTF-8,
+// or Shift-JIS filenames that were mistakenly decoded as CP437.
+// A dry run prints the changes and then rolls them back.
+func Reencode(db *sql.DB, w io.Writer, dry bool) error {
+	if db == nil {
+		return database.ErrDB
+	}
+	if w == nil {
+		w = io.Discard
+	}
+	rows, err := db.Query(reencodeStmt)
+	if err != nil {
+		return fmt.Errorf("reencode query: %w", err)
+	} else if rows.Err() != nil {
+		return fmt.Errorf("reencode rows: %w", rows.Err())
+	}
+	defer rows.Close()
+	type fix struct {
+		id      int64
+		content string
+		readme  sql.NullString
+	}
+	fixes := []fix{}
+	for rows.Next() {
+		var f fix
+		if err := rows.Scan(&f.id, &f.content, &f.readme); err != nil {
+			return fmt.Errorf("reencode scan: %w", err)
+		}
+		content, readme := Repair(f.content), charset.Repair(f.readme.String)
+		if content == f.content && readme == f.readme.String {
+			continue
+		}
+		f.content, f.readme.String = content, readme
+		fixes = append(fixes, f)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("reencode rows: %w", err)
+	}
+	tx, err := database.Begin(db, w, dry)
+	if err != nil {
+		return err
+	}
+	tx.Label = "reencode the filenames of archive listings"
+	for _, f := range fixes {
+		cols := map[string]any{"file_zip_content": f.content}
+		if f.readme.Valid {
+			cols["retrotxt_readme"] = f.readme.String
+		}
+		if _, err := database.UpdateFiles(tx, cols, qm.Where("id = ?", f.id)); err != nil {
+			return tx.Cancel(fmt.Errorf("reencode update %d: %w", f.id, err))
+		}
+	}
+	str.Total(w, len(fixes), "archive listings reencoded")
+	return tx.End()
+}
+
+// Repair returns the content of an archive listing with each filename repaired to UTF-8.
+func Repair(content string) string {
+	names := strings.Split(content, "\n")
+	for i, name := range names {
+		names[i] = charset.Repair(name)
+	}
+	return strings.Join(names, "\n")
+}
@@ -12,19 +12,25 @@ import (
 	"github.com/Defacto2/df2/pkg/database"
 	"github.com/Defacto2/df2/pkg/zipcontent/internal/record"
 	"github.com/Defacto2/df2/pkg/zipcontent/internal/scan"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 	"go.uber.org/zap"
 )
 
-func stmt() string {
-	const s = "SELECT `id`,`uuid`,`deletedat`,`createdat`,`filename`,`updatedat`,`retrotxt_readme`"
-	const w = " WHERE file_zip_content IS NULL AND (`filename` LIKE '%.zip' OR `filename`" +
-		" LIKE '%.rar' OR `filename` LIKE '%.7z')"
-	return fmt.Sprintf("%s FROM `files` %s", s, w)
+// archives returns the query mods of the file records with an unlisted archive.
+func archives() []qm.QueryMod {
+	return []qm.QueryMod{
+		qm.Select("id", "uuid", "deletedat", "createdat", "filename", "updatedat", "retrotxt_readme"),
+		qm.Where("file_zip_content IS NULL"),
+		qm.Where("(filename LIKE '%.zip' OR filename LIKE '%.rar' OR filename LIKE '%.7z'" +
+			" OR filename LIKE '%.arc' OR filename LIKE '%.ark' OR filename LIKE '%.pak'" +
+			" OR filename LIKE '%.zoo')"),
+	}
 }
 
 // Fix the content of zip archives within in the database.
-func Fix( //nolint:cyclop,funlen
-	db *sql.DB, w io.Writer, l *zap.SugaredLogger, cfg conf.Config, summary bool,
+// The depth is the number of nested archive levels to list, zero only lists the top level.
+func Fix(
+	db *sql.DB, w io.Writer, l *zap.SugaredLogger, cfg conf.Config, depth int, summary bool,
 ) error {
 	if db == nil {
 		return database.ErrDB
@@ -36,43 +42,18 @@ func Fix( //nolint:cyclop,funlen
 	if err != nil {
 		return err
 	}
-	rows, err := db.Query(stmt())
-	if err != nil {
-		return err
-	} else if rows.Err() != nil {
-		return rows.Err()
-	}
-	defer rows.Close()
-	columns, err := rows.Columns()
+	s.Depth = depth
+	files, err := database.Select(db, archives()...)
 	if err != nil {
 		return err
 	}
-	values := make([]sql.RawBytes, len(columns))
-	args := make([]any, len(values))
-	for i := range values {
-		args[i] = &values[i]
-	}
-	for rows.Next() {
-		s.Total++
-	}
-	rows, err = db.Query(stmt())
-	if err != nil {
-		return err
-	} else if rows.Err() != nil {
-		return rows.Err()
-	}
-	defer rows.Close()
-	for rows.Next() {
-		if err := rows.Scan(args...); err != nil {
-			return err
-		}
+	s.Total = len(files)
+	for _, f := range files {
 		s.Count++
-		r, err := record.New(values, s.BasePath)
+		r, err := record.New(f, s.BasePath)
 		if err != nil {
 			return err
 		}
-		s.Columns = columns
-		s.Values = &values
 		if err := r.Iterate(db, w, &s); err != nil {
 			if errors.Is(err, archive.ErrFile) {
 				fmt.Fprint(w, " ✗ file not found\n")
@@ -12,7 +12,7 @@ import (
 
 func TestFix(t *testing.T) {
 	t.Parallel()
-	err := zipcontent.Fix(nil, nil, nil, conf.Config{}, false)
+	err := zipcontent.Fix(nil, nil, nil, conf.Config{}, 0, false)
 	assert.NotNil(t, err)
 
 	cfg := conf.Defaults()
@@ -21,12 +21,26 @@ func TestFix(t *testing.T) {
 	defer db.Close()
 
 	bb := &bytes.Buffer{}
-	err = zipcontent.Fix(db, bb, nil, cfg, false)
+	err = zipcontent.Fix(db, bb, nil, cfg, 0, false)
 	assert.Nil(t, err)
 	assert.NotContains(t, bb.String(), "Total archives scanned")
 
 	bb = &bytes.Buffer{}
-	err = zipcontent.Fix(db, bb, nil, cfg, true)
+	err = zipcontent.Fix(db, bb, nil, cfg, 0, true)
 	assert.Nil(t, err)
 	assert.Contains(t, bb.String(), "Total archives scanned")
 }
+
+func TestRepair(t *testing.T) {
+	t.Parallel()
+	const listing = "README.NFO\nCAF\x90.NFO\nâfâé.TXT\n░▒▓.ANS"
+	assert.Equal(t, "README.NFO\nCAFÉ.NFO\nデモ.TXT\n░▒▓.ANS", zipcontent.Repair(listing))
+	const european = "Über Mädchen.txt\nPère Noël.txt"
+	assert.Equal(t, european, zipcontent.Repair(european))
+}
+
+func TestReencode(t *testing.T) {
+	t.Parallel()
+	err := zipcontent.Reencode(nil, nil, true)
+	assert.ErrorIs(t, err, database.ErrDB)
+}